@@ -23,6 +23,7 @@ import (
 	"github.com/cloud-platform/collaborative-dev/shared/database"
 	"github.com/cloud-platform/collaborative-dev/shared/logger"
 	"github.com/cloud-platform/collaborative-dev/shared/middleware"
+	"github.com/cloud-platform/collaborative-dev/shared/vault"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -137,6 +138,31 @@ func main() {
 		executionServiceConfig.ExecutorConfig.DefaultTimeout = cfg.CICD.Executor.DefaultTimeout
 	}
 	executionServiceConfig.ExecutorConfig.EnableAutoCleanup = cfg.CICD.Executor.EnableAutoCleanup
+	executionServiceConfig.ExecutorConfig.DefaultRegistry = cfg.CICD.Executor.DefaultRegistry
+
+	// checkout步骤从Git网关下载流水线提交的源码归档，服务令牌从 CICD_GIT_GATEWAY_TOKEN 读取
+	if cfg.CICD.Executor.GitGatewayURL != "" {
+		executionServiceConfig.ExecutorConfig.SourceFetcher = executor.NewGitArchiveFetcher(cfg.CICD.Executor.GitGatewayURL, os.Getenv("CICD_GIT_GATEWAY_TOKEN"))
+	} else {
+		zapLoggerInstance.Warn("cicd.executor.git_gateway_url not set, checkout steps will fail")
+	}
+
+	// 镜像仓库凭据等作业密钥从Vault读取，未配置Vault时读取 CICD_SECRET_* 环境变量
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		secrets, err := vault.NewVaultClient(&vault.Config{
+			Address:    addr,
+			Token:      os.Getenv("VAULT_TOKEN"),
+			Namespace:  os.Getenv("VAULT_NAMESPACE"),
+			Timeout:    10 * time.Second,
+			MaxRetries: 3,
+		}, zapLoggerInstance)
+		if err != nil {
+			zapLoggerInstance.Fatal("Failed to connect to Vault", zap.Error(err))
+		}
+		executionServiceConfig.ExecutorConfig.SecretResolver = executor.NewVaultSecretResolver(secrets, cfg.CICD.Executor.SecretPathPrefix)
+	} else {
+		zapLoggerInstance.Warn("VAULT_ADDR not set, job secrets are read from CICD_SECRET_* environment variables")
+	}
 
 	executionService, err := executor.NewExecutionService(
 		executionServiceConfig,
@@ -167,6 +193,9 @@ func main() {
 	// 这是为了避免循环依赖。作业调度器可以独立工作。
 	// 未来可以通过事件系统或消息队列来实现解耦
 
+	// 作业结果经调度器上报，以便按重试策略重新排队
+	runnerCommManager.SetJobResultReporter(jobScheduler)
+
 	pipelineService := service.NewPipelineService(pipelineRepo, storageManager, zapLoggerInstance)
 	pipelineHandler := handlers.NewPipelineHandler(pipelineService, zapLoggerInstance)
 
@@ -239,26 +268,30 @@ func main() {
 			pipelines.DELETE("/:id", pipelineHandler.DeletePipeline) // 删除流水线
 
 			// 流水线操作
-			pipelines.POST("/:id/trigger", pipelineHandler.TriggerPipeline) // 触发流水线
-			pipelines.GET("/:id/runs", pipelineHandler.GetPipelineRuns)     // 获取流水线运行列表
-			pipelines.GET("/:id/stats", pipelineHandler.GetPipelineStats)   // 获取流水线统计
+			pipelines.POST("/:id/trigger", pipelineHandler.TriggerPipeline)       // 触发流水线
+			pipelines.GET("/:id/runs", pipelineHandler.GetPipelineRuns)           // 获取流水线运行列表
+			pipelines.GET("/:id/stats", pipelineHandler.GetPipelineStats)         // 获取流水线统计
+			pipelines.GET("/:id/analytics", pipelineHandler.GetPipelineAnalytics) // 获取流水线分析数据
 		}
 
 		// 流水线运行管理路由
 		pipelineRuns := v1.Group("/pipeline-runs")
 		pipelineRuns.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
 		{
-			pipelineRuns.GET("/:id", pipelineHandler.GetPipelineRun)            // 获取运行详情
-			pipelineRuns.POST("/:id/cancel", pipelineHandler.CancelPipelineRun) // 取消运行
-			pipelineRuns.POST("/:id/retry", pipelineHandler.RetryPipelineRun)   // 重试运行
-			pipelineRuns.GET("/:run_id/jobs", pipelineHandler.GetJobs)          // 获取作业列表
+			pipelineRuns.GET("/:id", pipelineHandler.GetPipelineRun)                           // 获取运行详情
+			pipelineRuns.GET("/:id/chain", pipelineHandler.GetPipelineRunChain)                // 获取上下游触发链
+			pipelineRuns.GET("/:id/critical-path", pipelineHandler.GetPipelineRunCriticalPath) // 获取关键路径
+			pipelineRuns.POST("/:id/cancel", pipelineHandler.CancelPipelineRun)                // 取消运行
+			pipelineRuns.POST("/:id/retry", pipelineHandler.RetryPipelineRun)                  // 重试运行
+			pipelineRuns.GET("/:run_id/jobs", pipelineHandler.GetJobs)                         // 获取作业列表
 		}
 
 		// 作业管理路由
 		jobs := v1.Group("/jobs")
 		jobs.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
 		{
-			jobs.GET("/:id", pipelineHandler.GetJob) // 获取作业详情
+			jobs.GET("/:id", pipelineHandler.GetJob)                  // 获取作业详情
+			jobs.GET("/:id/attempts", pipelineHandler.GetJobAttempts) // 获取作业执行尝试
 		}
 
 		// 执行器管理路由
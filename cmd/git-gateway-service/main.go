@@ -6,16 +6,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/handlers"
 	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
 	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service/notification"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/storage"
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
 	"github.com/cloud-platform/collaborative-dev/shared/config"
 	"github.com/cloud-platform/collaborative-dev/shared/database"
 	"github.com/cloud-platform/collaborative-dev/shared/logger"
 	"github.com/cloud-platform/collaborative-dev/shared/middleware"
+	"github.com/cloud-platform/collaborative-dev/shared/vault"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
@@ -54,6 +59,42 @@ func main() {
 	gitRepo := repository.NewGitRepository(db.DB)
 	sqlxDB := sqlx.NewDb(db.SqlDB, "postgres")
 	webhookRepo := repository.NewWebhookRepository(sqlxDB, zapLoggerInstance)
+	codeSearchRepo := repository.NewCodeSearchRepository(db.DB)
+	mirrorRepo := repository.NewMirrorRepository(db.DB)
+	lfsRepo := repository.NewLFSRepository(db.DB)
+	pushPolicyRepo := repository.NewPushPolicyRepository(db.DB)
+	signingKeyRepo := repository.NewSigningKeyRepository(db.DB)
+	codeOwnerRepo := repository.NewCodeOwnerRepository(db.DB)
+	maintenanceRepo := repository.NewMaintenanceRepository(db.DB)
+	refAuditRepo := repository.NewRefAuditRepository(db.DB)
+
+	// 连接Vault，用于保存导入和镜像的远程凭据
+	var secrets vault.VaultClient
+	if addr := os.Getenv("VAULT_ADDR"); addr != "" {
+		secrets, err = vault.NewVaultClient(&vault.Config{
+			Address:    addr,
+			Token:      os.Getenv("VAULT_TOKEN"),
+			Namespace:  os.Getenv("VAULT_NAMESPACE"),
+			Timeout:    10 * time.Second,
+			MaxRetries: 3,
+		}, zapLoggerInstance)
+		if err != nil {
+			zapLoggerInstance.Fatal("Failed to connect to Vault", zap.Error(err))
+		}
+	} else {
+		zapLoggerInstance.Warn("VAULT_ADDR not set, mirror credentials are kept in memory only")
+		secrets = vault.NewMockVaultClient(zapLoggerInstance)
+	}
+
+	// LFS对象存储，未单独配置时放在平台存储目录下
+	lfsStoragePath := cfg.Git.LFSStoragePath
+	if lfsStoragePath == "" {
+		lfsStoragePath = filepath.Join(cfg.Storage.Local.BasePath, "lfs")
+	}
+	lfsStore, err := storage.NewLocalLFSStore(lfsStoragePath)
+	if err != nil {
+		zapLoggerInstance.Fatal("Failed to initialize LFS storage", zap.Error(err))
+	}
 
 	// 创建Webhook配置
 	webhookConfig := service.WebhookConfig{
@@ -64,20 +105,97 @@ func main() {
 		EnableBatching: false,
 		BatchSize:      10,
 		BatchTimeout:   5 * time.Second,
+
+		DisableAfterFailures: 10,
+	}
+
+	if cfg.Git.HookBaseURL != "" && cfg.Git.HookSecret == "" {
+		zapLoggerInstance.Warn("GIT_HOOK_SECRET not set, push policy and ref audit hooks are not installed")
 	}
 
-	gitService := service.NewGitService(gitRepo, zapLoggerInstance, "/var/git/repositories", cfg)
-	webhookService := service.NewWebhookService(gitRepo, webhookRepo, nil, webhookConfig, zapLoggerInstance)
+	signatureVerifier := service.NewSignatureVerifier(signingKeyRepo, zapLoggerInstance)
+	pushPolicyService := service.NewPushPolicyService(gitRepo, pushPolicyRepo, signatureVerifier, zapLoggerInstance, cfg.Git.HookBaseURL, cfg.Git.HookSecret)
+	codeOwnersService := service.NewCodeOwnersService(gitRepo, codeOwnerRepo, pushPolicyService, service.NewTeamDirectory(cfg.Git.TeamServiceURL), zapLoggerInstance)
+	refAuditService := service.NewRefAuditService(gitRepo, refAuditRepo, pushPolicyService, zapLoggerInstance, cfg.Git.RefRetention, cfg.Git.HookBaseURL, cfg.Git.HookSecret)
+	gitService := service.NewGitService(gitRepo, signatureVerifier, zapLoggerInstance, "/var/git/repositories", cfg, refAuditService, codeOwnersService)
+	codeSearchService := service.NewCodeSearchService(gitRepo, codeSearchRepo, zapLoggerInstance)
+
+	// 仓库维护失败告警，未配置Webhook时只记录日志
+	var maintenanceAlerts *notification.NotificationManager
+	if cfg.Git.MaintenanceAlertWebhook != "" {
+		maintenanceAlerts = notification.NewNotificationManager(zapLoggerInstance)
+		maintenanceAlerts.RegisterNotifier(notification.NewSlackNotifier(&notification.SlackConfig{
+			WebhookURL: cfg.Git.MaintenanceAlertWebhook,
+			Username:   "git-gateway",
+			MaxRetries: 3,
+			RetryDelay: 5 * time.Second,
+		}, zapLoggerInstance))
+	}
+	maintenanceService := service.NewMaintenanceService(gitRepo, maintenanceRepo, maintenanceAlerts, zapLoggerInstance,
+		cfg.Git.MaintenanceInterval, cfg.Git.MaintenancePushThreshold, cfg.Git.MaintenanceMaxAge)
+
+	mirrorService := service.NewMirrorService(gitService, gitRepo, mirrorRepo, secrets, refAuditService, zapLoggerInstance, codeSearchService, maintenanceService)
+	webhookService := service.NewWebhookService(gitRepo, webhookRepo, nil, webhookConfig, zapLoggerInstance, codeSearchService, mirrorService, maintenanceService)
+	jwtService := auth.NewJWTService(cfg.Auth.JWTSecret, cfg.Auth.JWTExpiration, cfg.Auth.RefreshTokenExpiry)
+	lfsService := service.NewLFSService(gitRepo, lfsRepo, lfsStore, jwtService, zapLoggerInstance, cfg.Git.BaseURL, cfg.Git.LFSActionExpiry, cfg.Git.LFSGCGracePeriod)
 
 	gitHandler := handlers.NewGitHandler(gitService, zapLoggerInstance)
 	webhookHandler := handlers.NewWebhookHandler(webhookService, zapLoggerInstance)
+	codeSearchHandler := handlers.NewCodeSearchHandler(codeSearchService, zapLoggerInstance)
+	mirrorHandler := handlers.NewMirrorHandler(mirrorService, zapLoggerInstance)
+	lfsHandler := handlers.NewLFSHandler(lfsService, jwtService, zapLoggerInstance)
+	gitHTTPHandler := handlers.NewGitHTTPHandler(gitService, jwtService, zapLoggerInstance)
+	pushPolicyHandler := handlers.NewPushPolicyHandler(pushPolicyService, zapLoggerInstance)
+	codeOwnersHandler := handlers.NewCodeOwnersHandler(codeOwnersService, zapLoggerInstance)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenanceService, zapLoggerInstance)
+	refAuditHandler := handlers.NewRefAuditHandler(refAuditService, zapLoggerInstance)
+
+	// 启动拉取镜像定时同步
+	if err := mirrorService.Start(context.Background()); err != nil {
+		zapLoggerInstance.Fatal("Failed to start mirror scheduler", zap.Error(err))
+	}
+
+	// 启动仓库定时维护
+	if err := maintenanceService.Start(context.Background()); err != nil {
+		zapLoggerInstance.Fatal("Failed to start repository maintenance", zap.Error(err))
+	}
+
+	// 启动引用审计保留提交清理
+	if err := refAuditService.Start(context.Background()); err != nil {
+		zapLoggerInstance.Fatal("Failed to start ref audit service", zap.Error(err))
+	}
+
+	// 启动Webhook投递队列
+	if err := webhookService.Start(context.Background()); err != nil {
+		zapLoggerInstance.Fatal("Failed to start webhook delivery queue", zap.Error(err))
+	}
+
+	// 启动LFS垃圾回收
+	gcCtx, stopGC := context.WithCancel(context.Background())
+	go service.StartLFSGarbageCollector(gcCtx, lfsService, cfg.Git.LFSGCInterval, zapLoggerInstance)
 
 	r := gin.New()
 
 	r.Use(middleware.CORS(cfg.Security.CorsAllowedOrigins))
 	r.Use(middleware.Logger(appLogger))
 	r.Use(middleware.SecurityHeaders())
-	r.Use(middleware.Timeout(30 * time.Second))
+	r.Use(middleware.TimeoutExcept(30*time.Second, longRunningRoute))
+
+	// Git智能HTTP传输：克隆和拉取，改名或迁移前的旧地址沿重定向解析
+	r.GET("/:project_id/:repo/info/refs", gitHTTPHandler.InfoRefs)
+	r.POST("/:project_id/:repo/git-upload-pack", gitHTTPHandler.UploadPack)
+
+	// Git LFS：批量接口位于仓库克隆地址下，对象传输使用批量接口签发的对象令牌
+	r.POST("/:project_id/:repo/info/lfs/objects/batch", lfsHandler.Batch)
+	lfsObjects := r.Group("/api/v1/lfs/objects")
+	{
+		lfsObjects.PUT("/:repository_id/:oid", lfsHandler.UploadObject)         // 上传对象
+		lfsObjects.GET("/:repository_id/:oid", lfsHandler.DownloadObject)       // 下载对象
+		lfsObjects.POST("/:repository_id/:oid/verify", lfsHandler.VerifyObject) // 确认上传
+	}
+
+	// pre-receive推送策略检查：由仓库钩子调用并校验钩子令牌
+	r.POST("/api/v1/git-hooks/:repository_id/pre-receive", handlers.GitHookAuth(cfg.Git.HookSecret), pushPolicyHandler.HandlePreReceive)
 
 	v1 := r.Group("/api/v1")
 	{
@@ -98,11 +216,49 @@ func main() {
 			repositories.POST("", gitHandler.CreateRepository)            // 创建仓库
 			repositories.GET("", gitHandler.ListRepositories)             // 获取仓库列表
 			repositories.GET("/search", gitHandler.SearchRepositories)    // 搜索仓库
+			repositories.GET("/resolve", gitHandler.ResolveRepository)    // 按项目和名称查找仓库，旧名称经重定向解析
+			repositories.POST("/import", mirrorHandler.ImportRepository)  // 从外部远程导入仓库
 			repositories.GET("/:id", gitHandler.GetRepository)            // 获取仓库详情
 			repositories.PUT("/:id", gitHandler.UpdateRepository)         // 更新仓库
 			repositories.DELETE("/:id", gitHandler.DeleteRepository)      // 删除仓库
 			repositories.GET("/:id/stats", gitHandler.GetRepositoryStats) // 获取仓库统计
 
+			// 仓库归档下载：从 git archive 流式输出
+			repositories.GET("/:id/archive/*ref", gitHandler.DownloadArchive)  // 下载归档
+			repositories.HEAD("/:id/archive/*ref", gitHandler.DownloadArchive) // 获取归档信息
+
+			// Fork管理
+			repositories.POST("/:id/forks", gitHandler.ForkRepository) // fork仓库
+			repositories.GET("/:id/forks", gitHandler.ListForks)       // 获取fork列表
+			repositories.POST("/:id/sync", gitHandler.SyncFork)        // 同步上游到fork
+
+			// 仓库模板
+			repositories.PUT("/:id/template", gitHandler.SetRepositoryTemplate)       // 设为模板或更新模板设置
+			repositories.GET("/:id/template", gitHandler.GetRepositoryTemplate)       // 获取模板设置
+			repositories.DELETE("/:id/template", gitHandler.DeleteRepositoryTemplate) // 取消模板标记
+
+			// 迁移与归档
+			repositories.POST("/:id/transfer", gitHandler.TransferRepository)   // 迁移到另一个项目
+			repositories.POST("/:id/archive", gitHandler.ArchiveRepository)     // 归档仓库（只读）
+			repositories.POST("/:id/unarchive", gitHandler.UnarchiveRepository) // 取消归档
+
+			// 导入与镜像
+			repositories.GET("/:id/import", mirrorHandler.GetImportStatus)              // 获取导入进度
+			repositories.POST("/:id/mirrors", mirrorHandler.CreateMirror)               // 创建镜像
+			repositories.GET("/:id/mirrors", mirrorHandler.ListMirrors)                 // 获取镜像列表
+			repositories.PUT("/:id/mirrors/:mirror_id", mirrorHandler.UpdateMirror)     // 更新镜像
+			repositories.DELETE("/:id/mirrors/:mirror_id", mirrorHandler.DeleteMirror)  // 删除镜像
+			repositories.POST("/:id/mirrors/:mirror_id/sync", mirrorHandler.SyncMirror) // 立即同步镜像
+
+			// Git LFS
+			repositories.GET("/:id/lfs/objects", lfsHandler.ListObjects) // 获取LFS对象列表
+
+			// 推送策略
+			repositories.GET("/:id/push-policy", pushPolicyHandler.GetPolicy)                    // 获取仓库推送策略
+			repositories.PUT("/:id/push-policy", pushPolicyHandler.SetPolicy)                    // 设置仓库推送策略
+			repositories.DELETE("/:id/push-policy", pushPolicyHandler.DeletePolicy)              // 删除仓库推送策略
+			repositories.GET("/:id/push-policy/effective", pushPolicyHandler.GetEffectivePolicy) // 获取合并后的生效策略
+
 			// 分支管理
 			repositories.POST("/:id/branches", gitHandler.CreateBranch)           // 创建分支
 			repositories.GET("/:id/branches", gitHandler.ListBranches)            // 获取分支列表
@@ -111,6 +267,10 @@ func main() {
 			repositories.PUT("/:id/default-branch", gitHandler.SetDefaultBranch)  // 设置默认分支
 			repositories.POST("/:id/merge", gitHandler.MergeBranch)               // 合并分支
 
+			// 引用审计
+			repositories.GET("/:id/ref-audit", refAuditHandler.ListRefAudit)        // 查询引用更新记录
+			repositories.POST("/:id/restore-branch", refAuditHandler.RestoreBranch) // 恢复已删除或被强制推送的分支
+
 			// 提交管理
 			repositories.POST("/:id/commits", gitHandler.CreateCommit)           // 创建提交
 			repositories.GET("/:id/commits", gitHandler.ListCommits)             // 获取提交列表
@@ -118,6 +278,10 @@ func main() {
 			repositories.GET("/:id/commits/:sha/diff", gitHandler.GetCommitDiff) // 获取提交差异
 			repositories.GET("/:id/compare", gitHandler.CompareBranches)         // 比较分支
 
+			// 代码所有者
+			repositories.GET("/:id/codeowners", codeOwnersHandler.GetCodeowners)  // 获取解析后的CODEOWNERS
+			repositories.GET("/:id/code-owners", codeOwnersHandler.GetCodeOwners) // 获取分支比较中变更文件的代码所有者
+
 			// 标签管理
 			repositories.POST("/:id/tags", gitHandler.CreateTag)        // 创建标签
 			repositories.GET("/:id/tags", gitHandler.ListTags)          // 获取标签列表
@@ -125,12 +289,22 @@ func main() {
 			repositories.DELETE("/:id/tags/:tag", gitHandler.DeleteTag) // 删除标签
 
 			// 文件操作
-			repositories.GET("/:id/files", gitHandler.GetFileContent)     // 获取文件内容
-			repositories.GET("/:id/tree", gitHandler.GetDirectoryContent) // 获取目录内容
+			repositories.GET("/:id/files", gitHandler.GetFileContent)         // 获取文件内容
+			repositories.GET("/:id/tree", gitHandler.GetDirectoryContent)     // 获取目录内容
+			repositories.GET("/:id/files/blame", gitHandler.BlameFile)        // 获取文件blame
+			repositories.GET("/:id/files/history", gitHandler.GetFileHistory) // 获取文件提交历史
+
+			// 代码搜索索引
+			repositories.GET("/:id/search-index", codeSearchHandler.GetIndexStatus)                 // 获取索引状态
+			repositories.PUT("/:id/search-index/branches", codeSearchHandler.UpdateIndexedBranches) // 设置索引分支
+			repositories.POST("/:id/search-index/rebuild", codeSearchHandler.RebuildIndex)          // 重建索引
+
+			// Pull Request管理
+			repositories.POST("/:id/pull-requests", gitHandler.CreatePullRequest)                                // 创建PR（支持从fork发起），自动请求代码所有者审查
+			repositories.GET("/:id/pull-requests", gitHandler.ListPullRequests)                                  // 获取PR列表，可按状态过滤
+			repositories.GET("/:id/pull-requests/:number/code-owners", codeOwnersHandler.GetPullRequestApproval) // 获取代码所有者批准状态
 
 			// TODO: Pull Request管理 - 待实现
-			// repositories.POST("/:id/pull-requests", gitHandler.CreatePullRequest)         // 创建PR
-			// repositories.GET("/:id/pull-requests", gitHandler.ListPullRequests)           // 获取PR列表
 			// repositories.GET("/:id/pull-requests/:number", gitHandler.GetPullRequest)     // 获取PR详情
 			// repositories.PUT("/:id/pull-requests/:number", gitHandler.UpdatePullRequest) // 更新PR
 			// repositories.POST("/:id/pull-requests/:number/merge", gitHandler.MergePullRequest) // 合并PR
@@ -147,6 +321,39 @@ func main() {
 			// repositories.GET("/:id/pull-requests/:number/reviews", gitHandler.GetPRReviews)   // 获取PR审查
 		}
 
+		// 代码搜索路由 - 需要JWT认证
+		search := v1.Group("/search")
+		search.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
+		{
+			search.GET("/code", codeSearchHandler.SearchCode) // 跨仓库搜索代码
+		}
+
+		// 项目级推送策略和合并请求 - 需要JWT认证
+		projects := v1.Group("/projects")
+		projects.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
+		{
+			projects.GET("/:project_id/push-policy", pushPolicyHandler.GetPolicy)          // 获取项目推送策略
+			projects.PUT("/:project_id/push-policy", pushPolicyHandler.SetPolicy)          // 设置项目推送策略
+			projects.DELETE("/:project_id/push-policy", pushPolicyHandler.DeletePolicy)    // 删除项目推送策略
+			projects.GET("/:project_id/pull-requests", gitHandler.ListProjectPullRequests) // 按任务编号查找项目下各仓库的PR
+		}
+
+		// LFS管理路由 - 仅管理员
+		lfsAdmin := v1.Group("/lfs")
+		lfsAdmin.Use(middleware.JWTAuth(cfg.Auth.JWTSecret), middleware.RequireRole("admin"))
+		{
+			lfsAdmin.POST("/gc", lfsHandler.CollectGarbage) // 立即执行LFS垃圾回收
+		}
+
+		// 仓库维护路由 - 仅管理员
+		maintenance := v1.Group("/repositories")
+		maintenance.Use(middleware.JWTAuth(cfg.Auth.JWTSecret), middleware.RequireRole("admin"))
+		{
+			maintenance.POST("/:id/maintenance", maintenanceHandler.TriggerMaintenance) // 立即维护仓库
+			maintenance.GET("/:id/maintenance", maintenanceHandler.GetStatus)           // 获取维护状态与健康状态
+			maintenance.GET("/:id/maintenance/runs", maintenanceHandler.ListRuns)       // 获取维护记录
+		}
+
 		// Webhook管理路由 - 需要JWT认证
 		webhooks := v1.Group("/webhooks")
 		webhooks.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
@@ -166,6 +373,13 @@ func main() {
 			webhooks.DELETE("/triggers/:trigger_id", webhookHandler.DeleteWebhookTrigger)      // 删除触发器
 			webhooks.POST("/triggers/:trigger_id/enable", webhookHandler.EnableWebhookTrigger) // 启用/禁用触发器
 
+			// 投递管理
+			webhooks.GET("/deliveries", webhookHandler.ListWebhookDeliveries)                            // 列出仓库投递记录（需repository_id）
+			webhooks.GET("/deliveries/:delivery_id", webhookHandler.GetWebhookDelivery)                  // 获取投递详情及尝试记录
+			webhooks.POST("/deliveries/:delivery_id/redeliver", webhookHandler.RedeliverWebhookDelivery) // 重新投递
+			webhooks.GET("/hooks/:webhook_id/statistics", webhookHandler.GetWebhookDeliveryStats)        // 仓库Webhook投递统计
+			webhooks.POST("/hooks/:webhook_id/enable", webhookHandler.EnableWebhook)                     // 启用/禁用仓库Webhook
+
 			// 统计信息
 			webhooks.GET("/statistics", webhookHandler.GetWebhookStatistics) // 获取统计信息
 		}
@@ -176,6 +390,9 @@ func main() {
 			// 通用Git钩子端点
 			gitHooks.POST("/:repository_id", webhookHandler.HandleGitWebhook) // 通用Git钩子
 
+			// post-receive引用审计，由仓库钩子调用，校验钩子令牌
+			gitHooks.POST("/:repository_id/post-receive", handlers.GitHookAuth(cfg.Git.HookSecret), refAuditHandler.HandlePostReceive)
+
 			// GitHub兼容端点
 			gitHooks.POST("/github/:repository_id", webhookHandler.HandleGitHubWebhook) // GitHub钩子
 
@@ -209,5 +426,27 @@ func main() {
 		appLogger.Fatal("Server forced to shutdown:", err)
 	}
 
+	mirrorService.Stop()
+	maintenanceService.Stop()
+	refAuditService.Stop()
+	webhookService.Stop()
+	stopGC()
+
 	appLogger.Info("Server exited")
 }
+
+// longRunningRoutes 不使用超时中间件的路由：归档下载、克隆拉取和LFS对象为流式传输，扫描大推送可能耗时较长
+var longRunningRoutes = map[string]bool{
+	"/:project_id/:repo/info/refs":                   true,
+	"/:project_id/:repo/git-upload-pack":             true,
+	"/api/v1/repositories/:id/archive/*ref":          true,
+	"/:project_id/:repo/info/lfs/objects/batch":      true,
+	"/api/v1/lfs/objects/:repository_id/:oid":        true,
+	"/api/v1/lfs/objects/:repository_id/:oid/verify": true,
+	"/api/v1/git-hooks/:repository_id/pre-receive":   true,
+}
+
+// longRunningRoute 判断请求是否匹配不使用超时中间件的路由
+func longRunningRoute(c *gin.Context) bool {
+	return longRunningRoutes[c.FullPath()]
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/cloud-platform/collaborative-dev/cmd/iam-service/services"
+	"github.com/cloud-platform/collaborative-dev/shared/api"
+	"github.com/cloud-platform/collaborative-dev/shared/logger"
+)
+
+type RegistryTokenHandler struct {
+	tokenService *services.RegistryTokenService
+	logger       logger.Logger
+	respHandler  *api.ResponseHandler
+}
+
+func NewRegistryTokenHandler(tokenService *services.RegistryTokenService, logger logger.Logger) *RegistryTokenHandler {
+	return &RegistryTokenHandler{
+		tokenService: tokenService,
+		logger:       logger,
+		respHandler:  api.NewResponseHandler(),
+	}
+}
+
+// GetRegistryToken issues a scoped token for the container registry
+// @Summary Get Registry Token
+// @Description Docker Token认证端点：使用Basic认证（账号密码或API令牌）或Bearer访问令牌换取镜像仓库令牌
+// @Tags Registry
+// @Produce json
+// @Param service query string false "镜像仓库服务名"
+// @Param scope query []string false "访问范围，如 repository:acme/web/api:pull,push" collectionFormat(multi)
+// @Success 200 {object} services.RegistryTokenResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/auth/registry/token [get]
+func (h *RegistryTokenHandler) GetRegistryToken(c *gin.Context) {
+	req := &services.RegistryTokenRequest{
+		Service: c.Query("service"),
+	}
+
+	// docker客户端可能在一个scope参数中用空格分隔多个范围
+	for _, scope := range c.QueryArray("scope") {
+		req.Scopes = append(req.Scopes, strings.Fields(scope)...)
+	}
+
+	if username, password, ok := c.Request.BasicAuth(); ok {
+		req.Username = username
+		req.Password = password
+	} else if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		req.BearerToken = strings.TrimPrefix(authHeader, "Bearer ")
+	}
+
+	resp, err := h.tokenService.IssueToken(c.Request.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRegistryAuthFailed):
+			h.logger.Warn("镜像仓库令牌认证失败", "username", req.Username, "client_ip", c.ClientIP(), "error", err)
+			c.Header("WWW-Authenticate", `Basic realm="registry"`)
+			h.respHandler.Unauthorized(c, "认证失败")
+		case errors.Is(err, services.ErrInvalidRegistryScope):
+			h.respHandler.BadRequest(c, err.Error(), nil)
+		default:
+			h.logger.Error("签发镜像仓库令牌失败", "error", err)
+			h.respHandler.InternalServerError(c, "签发镜像仓库令牌失败")
+		}
+		return
+	}
+
+	// Docker Token规范要求直接返回令牌对象，不使用统一响应包装
+	c.JSON(http.StatusOK, resp)
+}
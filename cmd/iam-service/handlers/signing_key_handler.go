@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/cloud-platform/collaborative-dev/cmd/iam-service/services"
+	"github.com/cloud-platform/collaborative-dev/shared/api"
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"github.com/cloud-platform/collaborative-dev/shared/logger"
+)
+
+type SigningKeyHandler struct {
+	signingKeyService *services.SigningKeyService
+	logger            logger.Logger
+	respHandler       *api.ResponseHandler
+}
+
+func NewSigningKeyHandler(signingKeyService *services.SigningKeyService, logger logger.Logger) *SigningKeyHandler {
+	return &SigningKeyHandler{
+		signingKeyService: signingKeyService,
+		logger:            logger,
+		respHandler:       api.NewResponseHandler(),
+	}
+}
+
+// AddSigningKey adds a GPG or SSH commit signing key for the current user
+// @Summary Add Signing Key
+// @Description 上传GPG（ASCII armor格式）或SSH（authorized_keys格式）签名公钥，用于校验提交和标签签名
+// @Tags Signing Keys
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body services.AddSigningKeyRequest true "签名公钥"
+// @Success 201 {object} services.SigningKeyResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/auth/signing-keys [post]
+func (h *SigningKeyHandler) AddSigningKey(c *gin.Context) {
+	var req services.AddSigningKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respHandler.BadRequest(c, "请求参数无效", nil)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.respHandler.Unauthorized(c, "用户未认证")
+		return
+	}
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		h.respHandler.BadRequest(c, "缺少租户信息", nil)
+		return
+	}
+
+	key, err := h.signingKeyService.AddSigningKey(c.Request.Context(), tenantID.(uuid.UUID), userID.(uuid.UUID), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrInvalidSigningKey):
+			h.respHandler.BadRequest(c, err.Error(), nil)
+		case errors.Is(err, services.ErrSigningKeyExists):
+			h.respHandler.Conflict(c, "该签名公钥已被添加")
+		default:
+			h.logger.Error("添加签名公钥失败", "error", err)
+			h.respHandler.InternalServerError(c, "添加签名公钥失败")
+		}
+		return
+	}
+
+	h.respHandler.Created(c, "签名公钥添加成功", key)
+}
+
+// GetSigningKeys lists the current user's signing keys
+// @Summary List Signing Keys
+// @Description 获取当前用户的签名公钥列表
+// @Tags Signing Keys
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {array} services.SigningKeyResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/auth/signing-keys [get]
+func (h *SigningKeyHandler) GetSigningKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.respHandler.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	keys, err := h.signingKeyService.ListSigningKeys(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		h.logger.Error("获取签名公钥失败", "error", err)
+		h.respHandler.InternalServerError(c, "获取签名公钥失败")
+		return
+	}
+
+	h.respHandler.OK(c, "获取签名公钥成功", keys)
+}
+
+// DeleteSigningKey deletes one of the current user's signing keys
+// @Summary Delete Signing Key
+// @Description 删除签名公钥，之后由该公钥签名的提交不再显示为已验证
+// @Tags Signing Keys
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param id path string true "签名公钥ID"
+// @Success 200 {object} StandardResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/v1/auth/signing-keys/{id} [delete]
+func (h *SigningKeyHandler) DeleteSigningKey(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		h.respHandler.BadRequest(c, "无效的签名公钥ID", nil)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		h.respHandler.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	if err := h.signingKeyService.DeleteSigningKey(c.Request.Context(), userID.(uuid.UUID), keyID); err != nil {
+		if errors.Is(err, services.ErrSigningKeyNotFound) {
+			h.respHandler.NotFound(c, "签名公钥不存在")
+			return
+		}
+		h.logger.Error("删除签名公钥失败", "error", err)
+		h.respHandler.InternalServerError(c, "删除签名公钥失败")
+		return
+	}
+
+	h.respHandler.OK(c, "签名公钥已删除", nil)
+}
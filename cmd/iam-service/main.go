@@ -88,6 +88,15 @@ func main() {
 	// 初始化API令牌服务
 	apiTokenService := services.NewAPITokenService(db.DB)
 
+	// 初始化镜像仓库令牌服务
+	registryTokenService := services.NewRegistryTokenService(db.DB, jwtService, apiTokenService, services.RegistryTokenConfig{
+		Service:  cfg.Registry.Service,
+		TokenTTL: cfg.Registry.TokenTTL,
+	})
+
+	// 初始化提交签名公钥服务
+	signingKeyService := services.NewSigningKeyService(db.DB)
+
 	// 初始化处理器
 	authHandler := handlers.NewAuthHandler(userService, appLogger)
 	userHandler := handlers.NewUserHandler(userService, userMgmtService, appLogger)
@@ -96,6 +105,8 @@ func main() {
 	sessionHandler := handlers.NewSessionHandler(sessionMgmtService, appLogger)
 	ssoHandler := handlers.NewSSOHandler(ssoService, jwtService, appLogger)
 	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenService, appLogger)
+	registryTokenHandler := handlers.NewRegistryTokenHandler(registryTokenService, appLogger)
+	signingKeyHandler := handlers.NewSigningKeyHandler(signingKeyService, appLogger)
 
 	// 设置Gin路由
 	r := gin.New()
@@ -130,6 +141,7 @@ func main() {
 			auth.POST("/logout", authHandler.Logout)
 			auth.GET("/validate", authHandler.ValidateToken)
 			auth.POST("/mfa/verify", mfaHandler.VerifyMFA)
+			auth.GET("/registry/token", registryTokenHandler.GetRegistryToken)
 		}
 
 		// SSO路由（部分需要认证）
@@ -167,6 +179,14 @@ func main() {
 				sessions.DELETE("", sessionHandler.RevokeAllSessions)
 			}
 
+			// 提交签名公钥（GPG/SSH）
+			signingKeys := protected.Group("/auth/signing-keys")
+			{
+				signingKeys.GET("", signingKeyHandler.GetSigningKeys)
+				signingKeys.POST("", signingKeyHandler.AddSigningKey)
+				signingKeys.DELETE("/:id", signingKeyHandler.DeleteSigningKey)
+			}
+
 			// 用户管理（需要管理员权限）
 			users := protected.Group("/users")
 			users.Use(middleware.RequireRole("admin", "manager"))
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"github.com/cloud-platform/collaborative-dev/shared/models"
+)
+
+// 镜像仓库令牌错误
+var (
+	ErrRegistryAuthFailed   = errors.New("镜像仓库认证失败")
+	ErrInvalidRegistryScope = errors.New("无效的镜像仓库令牌请求")
+)
+
+// RegistryTokenConfig 镜像仓库令牌配置
+type RegistryTokenConfig struct {
+	Service  string        // 令牌受众，必须与镜像仓库服务配置一致
+	TokenTTL time.Duration // 令牌有效期
+}
+
+// RegistryTokenRequest 镜像仓库令牌请求（Docker Token认证规范）
+type RegistryTokenRequest struct {
+	Service     string
+	Scopes      []string
+	Username    string // Basic认证用户名（邮箱或用户名）
+	Password    string // Basic认证密码或cdt_开头的API令牌
+	BearerToken string // 平台访问令牌
+}
+
+// RegistryTokenResponse 镜像仓库令牌响应
+type RegistryTokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+	IssuedAt    string `json:"issued_at"`
+}
+
+// RegistryTokenService 为镜像仓库签发带访问范围的令牌
+type RegistryTokenService struct {
+	db              *gorm.DB
+	jwtService      *auth.JWTService
+	apiTokenService *APITokenService
+	config          RegistryTokenConfig
+}
+
+// NewRegistryTokenService 创建镜像仓库令牌服务
+func NewRegistryTokenService(db *gorm.DB, jwtService *auth.JWTService, apiTokenService *APITokenService, config RegistryTokenConfig) *RegistryTokenService {
+	if config.TokenTTL <= 0 {
+		config.TokenTTL = 5 * time.Minute
+	}
+	return &RegistryTokenService{
+		db:              db,
+		jwtService:      jwtService,
+		apiTokenService: apiTokenService,
+		config:          config,
+	}
+}
+
+// registryPrincipal 令牌请求方
+type registryPrincipal struct {
+	user     *models.User
+	apiToken *models.APIToken // 使用API令牌认证时非空，操作还需受令牌scope限制
+}
+
+// IssueToken 认证请求方并按权限签发令牌
+//
+// 请求的scope中未被授权的操作会被剔除而不是报错，由镜像仓库在访问时拒绝。
+func (s *RegistryTokenService) IssueToken(ctx context.Context, req *RegistryTokenRequest) (*RegistryTokenResponse, error) {
+	if req.Service != "" && req.Service != s.config.Service {
+		return nil, fmt.Errorf("%w: 未知的service %s", ErrInvalidRegistryScope, req.Service)
+	}
+
+	principal, err := s.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var access []auth.RegistryAccess
+	for _, scope := range req.Scopes {
+		requested, err := auth.ParseRegistryScope(scope)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRegistryScope, err)
+		}
+		if requested.Type != "repository" {
+			continue
+		}
+
+		granted, err := s.authorize(ctx, principal, requested)
+		if err != nil {
+			return nil, err
+		}
+		access = append(access, *granted)
+	}
+
+	token, expiresAt, err := s.jwtService.GenerateRegistryToken(principal.user.ID, principal.user.TenantID, s.config.Service, access, s.config.TokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegistryTokenResponse{
+		Token:       token,
+		AccessToken: token,
+		ExpiresIn:   int(s.config.TokenTTL.Seconds()),
+		IssuedAt:    expiresAt.Add(-s.config.TokenTTL).UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// authenticate 认证请求方，支持平台访问令牌、API令牌和账号密码
+func (s *RegistryTokenService) authenticate(ctx context.Context, req *RegistryTokenRequest) (*registryPrincipal, error) {
+	switch {
+	case req.BearerToken != "":
+		claims, err := s.jwtService.ValidateToken(req.BearerToken)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRegistryAuthFailed, err)
+		}
+		user, err := s.loadUser(ctx, "id = ?", claims.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return &registryPrincipal{user: user}, nil
+
+	case strings.HasPrefix(req.Password, "cdt_"):
+		token, err := s.apiTokenService.ValidateAPIToken(ctx, req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRegistryAuthFailed, err)
+		}
+		user, err := s.loadUser(ctx, "id = ?", token.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return &registryPrincipal{user: user, apiToken: token}, nil
+
+	case req.Username != "" && req.Password != "":
+		user, err := s.loadUser(ctx, "email = ? OR username = ?", req.Username, req.Username)
+		if err != nil {
+			return nil, err
+		}
+		if !user.CheckPassword(req.Password) {
+			return nil, fmt.Errorf("%w: 用户名或密码错误", ErrRegistryAuthFailed)
+		}
+		return &registryPrincipal{user: user}, nil
+	}
+
+	return nil, fmt.Errorf("%w: 缺少认证信息", ErrRegistryAuthFailed)
+}
+
+// loadUser 加载可用的用户及其权限
+func (s *RegistryTokenService) loadUser(ctx context.Context, query string, args ...interface{}) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).
+		Preload("Roles").
+		Preload("Roles.Permissions").
+		Where("is_active = ? AND deleted_at IS NULL", true).
+		Where(query, args...).
+		First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: 用户不存在或未激活", ErrRegistryAuthFailed)
+		}
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if user.IsLocked() {
+		return nil, fmt.Errorf("%w: 账户已被锁定", ErrRegistryAuthFailed)
+	}
+	return &user, nil
+}
+
+// authorize 计算请求方对仓库实际可执行的操作
+//
+// 仓库名称格式为 <租户域名>/<项目Key>/<镜像>：
+// 拉取和推送要求是该租户下项目的成员；删除还要求具备 registry:delete 权限。
+func (s *RegistryTokenService) authorize(ctx context.Context, principal *registryPrincipal, requested *auth.RegistryAccess) (*auth.RegistryAccess, error) {
+	granted := &auth.RegistryAccess{Type: requested.Type, Name: requested.Name, Actions: []string{}}
+
+	parts := strings.SplitN(requested.Name, "/", 3)
+	if len(parts) < 3 {
+		return granted, nil
+	}
+
+	var tenantCount int64
+	err := s.db.WithContext(ctx).
+		Model(&models.Tenant{}).
+		Where("id = ? AND domain = ?", principal.user.TenantID, parts[0]).
+		Count(&tenantCount).Error
+	if err != nil {
+		return nil, fmt.Errorf("查询租户失败: %w", err)
+	}
+	if tenantCount == 0 {
+		return granted, nil
+	}
+
+	isMember, err := s.isProjectMember(ctx, principal.user, parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if !isMember {
+		return granted, nil
+	}
+
+	for _, action := range requested.Actions {
+		allowed := false
+		switch action {
+		case auth.RegistryActionPull, auth.RegistryActionPush:
+			allowed = true
+		case auth.RegistryActionDelete:
+			allowed = principal.user.HasPermission("registry", auth.RegistryActionDelete)
+		}
+
+		if allowed && principal.apiToken != nil {
+			if allowed, err = s.apiTokenService.CheckTokenPermission(ctx, principal.apiToken.ID, "registry", action); err != nil {
+				return nil, err
+			}
+		}
+
+		if allowed && !granted.Allows(action) {
+			granted.Actions = append(granted.Actions, action)
+		}
+	}
+
+	return granted, nil
+}
+
+// isProjectMember 判断用户是否为租户下指定项目的成员
+func (s *RegistryTokenService) isProjectMember(ctx context.Context, user *models.User, projectKey string) (bool, error) {
+	var count int64
+	err := s.db.WithContext(ctx).
+		Table("project_members pm").
+		Joins("JOIN projects p ON p.id = pm.project_id").
+		Where("pm.user_id = ? AND p.tenant_id = ? AND LOWER(p.key) = ? AND p.deleted_at IS NULL",
+			user.ID, user.TenantID, strings.ToLower(projectKey)).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("查询项目成员失败: %w", err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"github.com/cloud-platform/collaborative-dev/shared/models"
+)
+
+// 签名公钥错误
+var (
+	ErrSigningKeyExists   = errors.New("签名公钥已被添加")
+	ErrSigningKeyNotFound = errors.New("签名公钥不存在")
+)
+
+// AddSigningKeyRequest 添加签名公钥请求
+type AddSigningKeyRequest struct {
+	Name      string `json:"name" binding:"required,max=255"`
+	KeyType   string `json:"key_type" binding:"required,oneof=gpg ssh"`
+	PublicKey string `json:"public_key" binding:"required"`
+}
+
+// SigningKeyResponse 签名公钥响应，不返回公钥原文
+type SigningKeyResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	KeyType     string    `json:"key_type"`
+	Fingerprint string    `json:"fingerprint"`
+	KeyIDs      []string  `json:"key_ids"`
+	Emails      []string  `json:"emails"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// SigningKeyService 管理用户的提交签名公钥
+type SigningKeyService struct {
+	db *gorm.DB
+}
+
+// NewSigningKeyService 创建签名公钥服务
+func NewSigningKeyService(db *gorm.DB) *SigningKeyService {
+	return &SigningKeyService{db: db}
+}
+
+// AddSigningKey 解析并保存签名公钥，同一公钥只能属于一个用户
+func (s *SigningKeyService) AddSigningKey(ctx context.Context, tenantID, userID uuid.UUID, req *AddSigningKeyRequest) (*SigningKeyResponse, error) {
+	publicKey := strings.TrimSpace(req.PublicKey)
+	info, err := auth.ParseSigningKey(req.KeyType, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.SigningKey{}).
+		Where("key_type = ? AND fingerprint = ?", info.Type, info.Fingerprint).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("检查签名公钥失败: %w", err)
+	}
+	if count > 0 {
+		return nil, ErrSigningKeyExists
+	}
+
+	keyIDs, err := json.Marshal(info.KeyIDs)
+	if err != nil {
+		return nil, fmt.Errorf("序列化密钥ID失败: %w", err)
+	}
+	emails, err := json.Marshal(nonNilStrings(info.Emails))
+	if err != nil {
+		return nil, fmt.Errorf("序列化邮箱失败: %w", err)
+	}
+
+	key := &models.SigningKey{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		UserID:      userID,
+		Name:        req.Name,
+		KeyType:     info.Type,
+		Fingerprint: info.Fingerprint,
+		KeyIDs:      keyIDs,
+		Emails:      emails,
+		PublicKey:   publicKey,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(key).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) || strings.Contains(err.Error(), "duplicate key") {
+			return nil, ErrSigningKeyExists
+		}
+		return nil, fmt.Errorf("保存签名公钥失败: %w", err)
+	}
+
+	return toSigningKeyResponse(key), nil
+}
+
+// ListSigningKeys 获取用户的签名公钥
+func (s *SigningKeyService) ListSigningKeys(ctx context.Context, userID uuid.UUID) ([]SigningKeyResponse, error) {
+	var keys []models.SigningKey
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("获取签名公钥失败: %w", err)
+	}
+
+	responses := make([]SigningKeyResponse, 0, len(keys))
+	for i := range keys {
+		responses = append(responses, *toSigningKeyResponse(&keys[i]))
+	}
+	return responses, nil
+}
+
+// DeleteSigningKey 删除用户的签名公钥，此后由该公钥签名的提交不再显示为已验证
+func (s *SigningKeyService) DeleteSigningKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	result := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", keyID, userID).
+		Delete(&models.SigningKey{})
+	if result.Error != nil {
+		return fmt.Errorf("删除签名公钥失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrSigningKeyNotFound
+	}
+	return nil
+}
+
+// toSigningKeyResponse 转换为响应结构
+func toSigningKeyResponse(key *models.SigningKey) *SigningKeyResponse {
+	resp := &SigningKeyResponse{
+		ID:          key.ID,
+		Name:        key.Name,
+		KeyType:     key.KeyType,
+		Fingerprint: key.Fingerprint,
+		KeyIDs:      []string{},
+		Emails:      []string{},
+		CreatedAt:   key.CreatedAt,
+	}
+	json.Unmarshal(key.KeyIDs, &resp.KeyIDs)
+	json.Unmarshal(key.Emails, &resp.Emails)
+	return resp
+}
+
+// nonNilStrings 把nil切片转换为空切片，保证序列化为 []
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
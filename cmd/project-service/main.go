@@ -78,8 +78,12 @@ func main() {
 
 	projectService := service.NewProjectServiceWithTransaction(projectRepo, gitGatewayClient, transactionMgr, zapLoggerInstance)
 
+	// 初始化平台事件发布器，工作流通知经由通知服务发送
+	eventPublisher := client.NewKafkaEventPublisher(cfg.Kafka.Brokers, client.PlatformEventsTopic, zapLoggerInstance)
+	defer eventPublisher.Close()
+
 	// 初始化敏捷服务
-	agileService := service.NewAgileService(db.DB, zapLoggerInstance)
+	agileService := service.NewAgileService(db.DB, gitGatewayClient, eventPublisher, zapLoggerInstance)
 
 	// 初始化Dashboard服务 (暂时注释)
 	// dashboardService := service.NewDashboardService(db.DB, agileService, zapLoggerInstance)
@@ -95,6 +99,7 @@ func main() {
 	projectHandler := handlers.NewProjectHandler(projectService, webhookHandler, zapLoggerInstance)
 	gitHandler := handler.NewGitHandler(projectService, zapLoggerInstance)
 	agileHandler := handler.NewAgileHandler(agileService, zapLoggerInstance)
+	boardHandler := handler.NewBoardHandler(agileService, zapLoggerInstance)
 	// dashboardHandler := handler.NewDashboardHandler(dashboardService, zapLoggerInstance) // 暂时注释
 
 	// 创建通用logger用于中间件
@@ -152,6 +157,9 @@ func main() {
 			projects.POST("/:id/sprints/:sprintId/close", agileHandler.CloseSprint)             // 关闭Sprint
 			projects.GET("/:id/sprints/:sprintId/burndown", agileHandler.GetSprintBurndownData) // 燃尽图数据
 
+			// Sprint规划依赖
+			projects.GET("/:id/sprints/:sprintId/dependencies", agileHandler.GetSprintDependencies) // Sprint阻塞链
+
 			// 敏捷管理 - Epic
 			projects.POST("/:id/epics", agileHandler.CreateEpic)     // 创建Epic
 			projects.GET("/:id/epics", agileHandler.ListEpics)       // 获取Epic列表
@@ -165,17 +173,52 @@ func main() {
 			// 任务排序管理
 			projects.POST("/:id/tasks/rebalance", agileHandler.RebalanceTaskRanks)    // 重新平衡任务排名
 			projects.GET("/:id/tasks/validate-order", agileHandler.ValidateTaskOrder) // 验证任务排序
+
+			// 敏捷管理 - 工作流
+			projects.POST("/:id/workflows", agileHandler.CreateWorkflow)               // 创建工作流
+			projects.GET("/:id/workflows", agileHandler.ListWorkflows)                 // 获取工作流列表
+			projects.GET("/:id/workflows/:workflowId", agileHandler.GetWorkflow)       // 获取工作流详情
+			projects.PUT("/:id/workflows/:workflowId", agileHandler.UpdateWorkflow)    // 更新工作流
+			projects.DELETE("/:id/workflows/:workflowId", agileHandler.DeleteWorkflow) // 删除工作流
+
+			// 保存的过滤器
+			projects.POST("/:id/filters", agileHandler.CreateSavedFilter) // 创建过滤器
+			projects.GET("/:id/filters", agileHandler.ListSavedFilters)   // 获取可见的过滤器列表
+
+			// 任务自定义字段
+			projects.POST("/:id/custom-fields", agileHandler.CreateCustomField)            // 创建自定义字段
+			projects.GET("/:id/custom-fields", agileHandler.ListCustomFields)              // 获取自定义字段列表
+			projects.PUT("/:id/custom-fields/:fieldId", agileHandler.UpdateCustomField)    // 更新自定义字段
+			projects.DELETE("/:id/custom-fields/:fieldId", agileHandler.DeleteCustomField) // 删除自定义字段
+
+			// 任务导出
+			projects.GET("/:id/tasks/export", agileHandler.ExportTasks) // 按过滤条件导出CSV
+
+			// 任务变更历史
+			projects.GET("/:id/activity", agileHandler.ListProjectActivity)    // 获取项目活动流
+			projects.GET("/:id/flow-metrics", agileHandler.GetTaskFlowMetrics) // 获取累积流图和周期时间
 		}
 
 		// 任务管理路由
 		tasks := v1.Group("/tasks")
 		tasks.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
 		{
-			tasks.GET("/:taskId", agileHandler.GetTask)                  // 获取任务详情
-			tasks.PUT("/:taskId", agileHandler.UpdateTask)               // 更新任务
-			tasks.DELETE("/:taskId", agileHandler.DeleteTask)            // 删除任务
-			tasks.POST("/:taskId/status", agileHandler.UpdateTaskStatus) // 更新任务状态
-			tasks.POST("/:taskId/assign", agileHandler.AssignTask)       // 分配任务
+			tasks.GET("/:taskId", agileHandler.GetTask)                        // 获取任务详情
+			tasks.PUT("/:taskId", agileHandler.UpdateTask)                     // 更新任务
+			tasks.DELETE("/:taskId", agileHandler.DeleteTask)                  // 删除任务
+			tasks.POST("/:taskId/status", agileHandler.UpdateTaskStatus)       // 更新任务状态
+			tasks.POST("/:taskId/assign", agileHandler.AssignTask)             // 分配任务
+			tasks.GET("/:taskId/transitions", agileHandler.GetTaskTransitions) // 获取可用的状态转换
+			tasks.POST("/:taskId/transitions", agileHandler.TransitionTask)    // 执行状态转换
+
+			// 任务链接和依赖
+			tasks.POST("/:taskId/links", agileHandler.CreateTaskLink)               // 创建任务链接
+			tasks.GET("/:taskId/links", agileHandler.ListTaskLinks)                 // 获取任务链接
+			tasks.DELETE("/:taskId/links/:linkId", agileHandler.DeleteTaskLink)     // 删除任务链接
+			tasks.GET("/:taskId/dependencies", agileHandler.GetTaskDependencyGraph) // 获取依赖图
+
+			// 任务变更历史
+			tasks.GET("/:taskId/history", agileHandler.GetTaskHistory) // 获取任务变更历史
 
 			// 任务拖拽排序
 			tasks.POST("/reorder", agileHandler.ReorderTasks)            // 重新排序任务
@@ -191,6 +234,30 @@ func main() {
 			tasks.GET("/:taskId/worklogs", agileHandler.ListWorkLogs) // 获取工作日志
 		}
 
+		// 保存的过滤器路由
+		filters := v1.Group("/filters")
+		filters.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
+		{
+			filters.GET("/:filterId", agileHandler.GetSavedFilter)                      // 获取过滤器详情
+			filters.PUT("/:filterId", agileHandler.UpdateSavedFilter)                   // 更新过滤器
+			filters.DELETE("/:filterId", agileHandler.DeleteSavedFilter)                // 删除过滤器
+			filters.GET("/:filterId/tasks", agileHandler.RunSavedFilter)                // 执行过滤器
+			filters.GET("/:filterId/statistics", agileHandler.GetSavedFilterStatistics) // 过滤器分组统计（仪表板数据源）
+		}
+
+		// 看板路由
+		boards := v1.Group("/boards")
+		boards.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
+		{
+			boards.POST("", boardHandler.CreateBoard)                      // 创建看板
+			boards.GET("", boardHandler.ListBoards)                        // 获取看板列表
+			boards.GET("/:id", boardHandler.GetBoard)                      // 获取看板详情
+			boards.PUT("/:id", boardHandler.UpdateBoard)                   // 更新看板（含泳道配置）
+			boards.DELETE("/:id", boardHandler.DeleteBoard)                // 删除看板
+			boards.GET("/:id/statistics", boardHandler.GetBoardStatistics) // 看板统计
+			boards.GET("/:id/swimlanes", boardHandler.GetBoardSwimlanes)   // 按泳道分组的看板任务
+		}
+
 		// 用户工作负载路由
 		users := v1.Group("/users")
 		users.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/handlers"
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/repository"
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/service"
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/storage"
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"github.com/cloud-platform/collaborative-dev/shared/config"
+	"github.com/cloud-platform/collaborative-dev/shared/database"
+	"github.com/cloud-platform/collaborative-dev/shared/logger"
+	"github.com/cloud-platform/collaborative-dev/shared/middleware"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	appLogger, err := logger.NewZapLogger(cfg.Log.ToLoggerConfig())
+	if err != nil {
+		log.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	zapLoggerInstance, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("Failed to initialize zap logger: %v", err)
+	}
+	defer zapLoggerInstance.Sync()
+
+	// 连接数据库
+	db, err := database.NewPostgresDB(cfg.Database.ToDBConfig())
+	if err != nil {
+		zapLoggerInstance.Fatal("Failed to connect to database", zap.Error(err))
+	}
+	defer db.Close()
+
+	// 初始化数据块存储
+	storagePath := cfg.Registry.StoragePath
+	if storagePath == "" {
+		storagePath = filepath.Join(cfg.Storage.Local.BasePath, "registry")
+	}
+	blobStore, err := storage.NewLocalBlobStore(storagePath)
+	if err != nil {
+		zapLoggerInstance.Fatal("Failed to initialize blob store", zap.Error(err))
+	}
+
+	// 初始化依赖
+	jwtService := auth.NewJWTService(cfg.Auth.JWTSecret, cfg.Auth.JWTExpiration, cfg.Auth.RefreshTokenExpiry)
+	registryRepo := repository.NewRegistryRepository(db.DB)
+	registryService := service.NewRegistryService(registryRepo, blobStore, zapLoggerInstance,
+		cfg.Registry.GCGracePeriod, cfg.Registry.UploadTimeout)
+
+	distributionHandler := handlers.NewDistributionHandler(registryService, jwtService,
+		cfg.Registry.Service, cfg.Registry.Realm, zapLoggerInstance)
+	registryHandler := handlers.NewRegistryHandler(registryService, zapLoggerInstance)
+
+	// 启动垃圾回收
+	gcCtx, stopGC := context.WithCancel(context.Background())
+	defer stopGC()
+	go service.StartGarbageCollector(gcCtx, registryService, cfg.Registry.GCInterval, zapLoggerInstance)
+
+	r := gin.New()
+	r.Use(middleware.Logger(appLogger))
+	r.Use(middleware.Recovery(appLogger))
+
+	// OCI Distribution API：数据块上传可能耗时较长，不使用超时中间件
+	distributionHandler.RegisterRoutes(r)
+
+	v1 := r.Group("/api/v1")
+	v1.Use(middleware.CORS(cfg.Security.CorsAllowedOrigins))
+	v1.Use(middleware.SecurityHeaders())
+	v1.Use(middleware.Timeout(30 * time.Second))
+	{
+		// 健康检查
+		v1.GET("/health", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"service": "registry-service",
+				"status":  "healthy",
+				"version": "1.0.0",
+			})
+		})
+
+		// 镜像仓库管理路由 - 需要JWT认证
+		registry := v1.Group("/registry")
+		registry.Use(middleware.JWTAuth(cfg.Auth.JWTSecret))
+		{
+			registry.GET("/repositories", registryHandler.ListRepositories)                                           // 获取仓库列表
+			registry.DELETE("/repositories/*name", middleware.RequireRole("admin"), registryHandler.DeleteRepository) // 删除仓库（需要管理员权限）
+			registry.GET("/usage", registryHandler.GetUsage)                                                          // 获取存储用量
+
+			// 垃圾回收（需要管理员权限）
+			registry.POST("/gc", middleware.RequireRole("admin"), registryHandler.CollectGarbage)
+		}
+	}
+
+	srv := &http.Server{
+		Addr:    cfg.Server.Address(),
+		Handler: r,
+	}
+
+	go func() {
+		appLogger.Info("Starting Registry service on", cfg.Server.Address())
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			appLogger.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	appLogger.Info("Shutting down server...")
+	stopGC()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		appLogger.Fatal("Server forced to shutdown:", err)
+	}
+
+	appLogger.Info("Server exited")
+}
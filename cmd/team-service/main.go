@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -140,6 +141,7 @@ func main() {
 		teams := api.Group("/teams")
 		{
 			teams.POST("", createTeam)
+			teams.GET("", findTeamsByName)
 			teams.GET("/project/:projectId", getProjectTeams)
 			teams.GET("/:id", getTeam)
 			teams.PUT("/:id", updateTeam)
@@ -259,6 +261,28 @@ func getProjectTeams(c *gin.Context) {
 	})
 }
 
+// findTeamsByName 按名称查找团队（不区分大小写），供CODEOWNERS中的 @org/team 解析成员
+func findTeamsByName(c *gin.Context) {
+	name := c.Query("name")
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "团队名称不能为空"})
+		return
+	}
+
+	var matched []Team
+	for _, team := range teams {
+		if team.IsActive && strings.EqualFold(team.Name, name) {
+			matched = append(matched, team)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"teams":   matched,
+		"total":   len(matched),
+	})
+}
+
 func getTeam(c *gin.Context) {
 	teamID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -0,0 +1,43 @@
+-- CI/CD作业重试策略迁移
+-- 为作业增加重试策略与失败原因，并记录每次执行尝试
+
+-- 作业表新增重试相关字段
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS retry_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS max_retries INTEGER NOT NULL DEFAULT 3;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS retry_policy JSONB;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS failure_reason VARCHAR(50);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS next_retry_at TIMESTAMP WITH TIME ZONE;
+
+-- 创建作业执行记录表（每次尝试一条）
+CREATE TABLE IF NOT EXISTS job_executions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    runner_id UUID NOT NULL,
+    attempt INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL,
+    failure_reason VARCHAR(50),
+    exit_code INTEGER,
+    error_message TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    log_path VARCHAR(512),
+    artifact_paths JSONB DEFAULT '[]',
+    resource_usage JSONB DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- 创建索引
+CREATE INDEX IF NOT EXISTS idx_jobs_next_retry_at ON jobs(next_retry_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_job_executions_job_id ON job_executions(job_id, attempt);
+CREATE INDEX IF NOT EXISTS idx_job_executions_runner_id ON job_executions(runner_id);
+
+-- 失败原因约束
+ALTER TABLE jobs
+ADD CONSTRAINT IF NOT EXISTS chk_job_failure_reason
+CHECK (failure_reason IS NULL OR failure_reason IN ('script_failure', 'runner_lost', 'timeout', 'infrastructure_failure'));
+
+COMMENT ON TABLE job_executions IS '作业执行尝试记录表';
+COMMENT ON COLUMN jobs.retry_policy IS '作业重试策略，JSON格式';
+COMMENT ON COLUMN jobs.next_retry_at IS '下一次重试时间（退避中的作业在此之前不会被调度）';
+COMMENT ON COLUMN job_executions.attempt IS '第几次执行，从1开始';
@@ -0,0 +1,9 @@
+-- CI/CD镜像构建步骤迁移
+-- 记录流水线运行构建并推送的镜像摘要
+
+ALTER TABLE pipeline_runs ADD COLUMN IF NOT EXISTS built_images JSONB NOT NULL DEFAULT '[]';
+
+-- 按摘要反查构建来源
+CREATE INDEX IF NOT EXISTS idx_pipeline_runs_built_images ON pipeline_runs USING GIN (built_images jsonb_path_ops);
+
+COMMENT ON COLUMN pipeline_runs.built_images IS '本次运行构建并推送的镜像（名称、标签、摘要），JSON数组';
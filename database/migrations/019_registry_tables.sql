@@ -0,0 +1,93 @@
+-- 镜像仓库服务迁移
+-- OCI Distribution 镜像仓库：仓库、数据块、清单、标签与分块上传会话
+
+-- 镜像仓库（名称格式：<租户域名>/<项目Key>/<镜像>）
+CREATE TABLE IF NOT EXISTS registry_repositories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_pushed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_registry_repositories_tenant ON registry_repositories(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_registry_repositories_project ON registry_repositories(project_id);
+
+-- 内容寻址数据块，跨仓库共享
+CREATE TABLE IF NOT EXISTS registry_blobs (
+    digest VARCHAR(100) PRIMARY KEY,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 仓库与数据块关联
+CREATE TABLE IF NOT EXISTS registry_repository_blobs (
+    repository_id UUID NOT NULL REFERENCES registry_repositories(id) ON DELETE CASCADE,
+    digest VARCHAR(100) NOT NULL REFERENCES registry_blobs(digest),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (repository_id, digest)
+);
+
+CREATE INDEX IF NOT EXISTS idx_registry_repository_blobs_digest ON registry_repository_blobs(digest);
+
+-- 镜像清单
+CREATE TABLE IF NOT EXISTS registry_manifests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    repository_id UUID NOT NULL REFERENCES registry_repositories(id) ON DELETE CASCADE,
+    digest VARCHAR(100) NOT NULL,
+    media_type VARCHAR(255) NOT NULL,
+    artifact_type VARCHAR(255),
+    subject_digest VARCHAR(100),
+    size BIGINT NOT NULL,
+    payload BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_registry_manifest_digest UNIQUE (repository_id, digest)
+);
+
+CREATE INDEX IF NOT EXISTS idx_registry_manifests_subject ON registry_manifests(repository_id, subject_digest) WHERE subject_digest IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_registry_manifests_created_at ON registry_manifests(created_at);
+
+-- 清单引用（配置、镜像层或索引中的子清单）
+CREATE TABLE IF NOT EXISTS registry_manifest_references (
+    manifest_id UUID NOT NULL REFERENCES registry_manifests(id) ON DELETE CASCADE,
+    digest VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('blob', 'manifest')),
+    PRIMARY KEY (manifest_id, digest)
+);
+
+CREATE INDEX IF NOT EXISTS idx_registry_manifest_references_digest ON registry_manifest_references(digest, kind);
+
+-- 镜像标签
+CREATE TABLE IF NOT EXISTS registry_tags (
+    repository_id UUID NOT NULL REFERENCES registry_repositories(id) ON DELETE CASCADE,
+    name VARCHAR(128) NOT NULL,
+    manifest_id UUID NOT NULL REFERENCES registry_manifests(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (repository_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_registry_tags_manifest ON registry_tags(manifest_id);
+
+-- 分块上传会话
+CREATE TABLE IF NOT EXISTS registry_uploads (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    repository_id UUID NOT NULL REFERENCES registry_repositories(id) ON DELETE CASCADE,
+    tenant_id UUID NOT NULL,
+    "offset" BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_registry_uploads_tenant ON registry_uploads(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_registry_uploads_updated_at ON registry_uploads(updated_at);
+
+COMMENT ON TABLE registry_repositories IS '镜像仓库 - 按 租户/项目/镜像 命名';
+COMMENT ON TABLE registry_blobs IS '镜像数据块 - 内容寻址，跨仓库共享存储';
+COMMENT ON TABLE registry_repository_blobs IS '仓库与数据块关联 - 决定数据块在哪些仓库可见，用于配额统计';
+COMMENT ON TABLE registry_manifests IS '镜像清单 - 无标签且未被引用的清单由垃圾回收清理';
+COMMENT ON TABLE registry_manifest_references IS '清单引用的数据块与子清单';
+COMMENT ON TABLE registry_tags IS '镜像标签';
+COMMENT ON TABLE registry_uploads IS '分块上传会话 - 超时未完成的会话由垃圾回收清理';
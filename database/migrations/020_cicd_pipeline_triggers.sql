@@ -0,0 +1,26 @@
+-- CI/CD跨项目流水线触发迁移
+-- trigger作业可在其他仓库/项目中启动流水线，记录上下游运行关系
+
+ALTER TABLE pipeline_runs ADD COLUMN IF NOT EXISTS parent_run_id UUID REFERENCES pipeline_runs(id) ON DELETE SET NULL;
+ALTER TABLE pipeline_runs ADD COLUMN IF NOT EXISTS parent_job_id UUID REFERENCES jobs(id) ON DELETE SET NULL;
+ALTER TABLE pipeline_runs ADD COLUMN IF NOT EXISTS upstream_artifacts JSONB NOT NULL DEFAULT '[]';
+
+-- 按上游运行查找下游运行（递归查询触发链）
+CREATE INDEX IF NOT EXISTS idx_pipeline_runs_parent_run_id ON pipeline_runs(parent_run_id) WHERE parent_run_id IS NOT NULL;
+
+-- 新增触发类型：pipeline
+ALTER TABLE pipeline_runs DROP CONSTRAINT IF EXISTS chk_trigger_type;
+ALTER TABLE pipeline_runs
+ADD CONSTRAINT chk_trigger_type
+CHECK (trigger_type IN ('manual', 'push', 'pull_request', 'scheduled', 'webhook', 'pipeline'));
+
+-- 新增失败原因：downstream_failure
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS chk_job_failure_reason;
+ALTER TABLE jobs
+ADD CONSTRAINT chk_job_failure_reason
+CHECK (failure_reason IS NULL OR failure_reason IN ('script_failure', 'runner_lost', 'timeout', 'infrastructure_failure', 'downstream_failure'));
+
+COMMENT ON COLUMN pipeline_runs.trigger_type IS '触发类型：manual, push, pull_request, scheduled, webhook, pipeline';
+COMMENT ON COLUMN pipeline_runs.parent_run_id IS '触发本次运行的上游流水线运行ID';
+COMMENT ON COLUMN pipeline_runs.parent_job_id IS '触发本次运行的上游trigger作业ID';
+COMMENT ON COLUMN pipeline_runs.upstream_artifacts IS '上游作业传递的产物，JSON数组';
@@ -0,0 +1,17 @@
+-- CI/CD流水线分析迁移
+-- 记录作业依赖与失败步骤，支持关键路径与失败排行统计
+
+-- 作业依赖（依赖作业ID数组），用于计算关键路径
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS dependencies JSONB NOT NULL DEFAULT '[]';
+
+-- 导致作业失败的步骤名称（由Runner上报）
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS failed_step VARCHAR(255);
+ALTER TABLE job_executions ADD COLUMN IF NOT EXISTS failed_step VARCHAR(255);
+
+-- 分析查询索引
+CREATE INDEX IF NOT EXISTS idx_pipeline_runs_pipeline_created ON pipeline_runs(pipeline_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_jobs_runner_started ON jobs(runner_id, started_at) WHERE runner_id IS NOT NULL;
+
+COMMENT ON COLUMN jobs.dependencies IS '依赖的作业ID，JSON数组';
+COMMENT ON COLUMN jobs.failed_step IS '导致作业失败的步骤名称';
+COMMENT ON COLUMN job_executions.failed_step IS '本次尝试失败的步骤名称';
@@ -0,0 +1,42 @@
+-- Git网关代码搜索迁移
+-- 按分支保存文件内容，使用pg_trgm三元组索引支持子串与正则搜索
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 参与搜索的分支及索引进度（默认分支自动加入，其他分支需显式配置）
+CREATE TABLE IF NOT EXISTS code_search_branches (
+    repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    branch VARCHAR(255) NOT NULL,
+    commit_sha VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    file_count BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    indexed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (repository_id, branch),
+    CONSTRAINT chk_code_search_branch_status CHECK (status IN ('pending', 'indexing', 'ready', 'failed'))
+);
+
+-- 已索引的文件内容（不含二进制文件和超过1MB的文件）
+CREATE TABLE IF NOT EXISTS code_search_documents (
+    repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    branch VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    blob_sha VARCHAR(64) NOT NULL,
+    language VARCHAR(50) NOT NULL DEFAULT '',
+    size BIGINT NOT NULL,
+    content TEXT NOT NULL,
+    indexed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (repository_id, branch, path)
+);
+
+-- 三元组索引：LIKE/ILIKE 与正则（~、~*）均可使用
+CREATE INDEX IF NOT EXISTS idx_code_search_documents_content_trgm ON code_search_documents USING GIN (content gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_code_search_documents_path_trgm ON code_search_documents USING GIN (path gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_code_search_documents_language ON code_search_documents(language);
+
+COMMENT ON TABLE code_search_branches IS '代码搜索分支索引进度，commit_sha为增量更新起点';
+COMMENT ON TABLE code_search_documents IS '代码搜索文档，按仓库/分支/路径存储文件内容';
@@ -0,0 +1,19 @@
+-- Git网关仓库fork迁移
+-- 记录fork与上游的关系，支持从fork向上游发起合并请求
+
+ALTER TABLE repositories
+    ADD COLUMN IF NOT EXISTS forked_from_id UUID REFERENCES repositories(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS fork_count INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS objects_shared BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_repositories_forked_from_id ON repositories(forked_from_id) WHERE forked_from_id IS NOT NULL;
+
+-- 来自fork的合并请求记录源仓库，同仓库合并请求为空
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS source_repository_id UUID REFERENCES repositories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_pull_requests_source_repository_id ON pull_requests(source_repository_id) WHERE source_repository_id IS NOT NULL;
+
+COMMENT ON COLUMN repositories.forked_from_id IS '上游仓库ID，为空表示非fork仓库';
+COMMENT ON COLUMN repositories.objects_shared IS '是否通过alternates共享上游对象库';
+COMMENT ON COLUMN pull_requests.source_repository_id IS '来自fork时的源仓库ID';
@@ -0,0 +1,50 @@
+-- Git网关仓库导入与镜像迁移
+-- 从外部Git远程导入仓库，以及拉取/推送镜像；远程凭据保存在Vault中，不入库
+
+ALTER TABLE repositories
+    ADD COLUMN IF NOT EXISTS is_mirror BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS repository_imports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    source_url VARCHAR(1024) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    phase VARCHAR(100),
+    progress INTEGER NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    error_message TEXT,
+    created_by UUID NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_imports_repository_id ON repository_imports(repository_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_repository_imports_unfinished ON repository_imports(status) WHERE status IN ('pending', 'running');
+
+CREATE TABLE IF NOT EXISTS repository_mirrors (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    direction VARCHAR(10) NOT NULL CHECK (direction IN ('pull', 'push')),
+    remote_url VARCHAR(1024) NOT NULL,
+    has_credentials BOOLEAN NOT NULL DEFAULT FALSE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    interval_minutes INTEGER NOT NULL DEFAULT 60 CHECK (interval_minutes > 0),
+    last_sync_status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (last_sync_status IN ('pending', 'running', 'succeeded', 'failed')),
+    last_sync_at TIMESTAMP WITH TIME ZONE,
+    last_success_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    next_sync_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_mirrors_repository_id ON repository_mirrors(repository_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_repository_mirrors_single_pull ON repository_mirrors(repository_id) WHERE direction = 'pull';
+CREATE INDEX IF NOT EXISTS idx_repository_mirrors_due ON repository_mirrors(next_sync_at) WHERE direction = 'pull' AND enabled = TRUE;
+
+COMMENT ON COLUMN repositories.is_mirror IS '是否为拉取镜像，镜像仓库只读';
+COMMENT ON COLUMN repository_imports.progress IS '总体进度 0-100';
+COMMENT ON COLUMN repository_mirrors.has_credentials IS '凭据保存在Vault的git-gateway/mirrors/<id>路径';
+COMMENT ON COLUMN repository_mirrors.next_sync_at IS '拉取镜像下次同步时间';
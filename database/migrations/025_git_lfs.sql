@@ -0,0 +1,28 @@
+-- Git网关LFS迁移
+-- LFS对象按OID内容寻址全局存储一份，通过仓库关联计入仓库大小和租户存储配额
+
+ALTER TABLE repositories
+    ADD COLUMN IF NOT EXISTS lfs_size BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS lfs_objects (
+    oid VARCHAR(64) PRIMARY KEY,
+    size BIGINT NOT NULL CHECK (size >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lfs_objects_created_at ON lfs_objects(created_at);
+
+CREATE TABLE IF NOT EXISTS repository_lfs_objects (
+    repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    oid VARCHAR(64) NOT NULL REFERENCES lfs_objects(oid),
+    size BIGINT NOT NULL CHECK (size >= 0),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (repository_id, oid)
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_lfs_objects_oid ON repository_lfs_objects(oid);
+CREATE INDEX IF NOT EXISTS idx_repository_lfs_objects_created_at ON repository_lfs_objects(repository_id, created_at);
+
+COMMENT ON COLUMN repositories.lfs_size IS '仓库大小中LFS对象所占字节数';
+COMMENT ON TABLE lfs_objects IS 'Git LFS对象，按SHA-256内容寻址，多个仓库共享';
+COMMENT ON TABLE repository_lfs_objects IS '仓库与LFS对象的关联，不再被任何引用指向的关联由垃圾回收解除';
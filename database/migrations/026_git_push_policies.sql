@@ -0,0 +1,27 @@
+-- Git网关推送策略迁移
+-- 项目和仓库级推送策略，由仓库的pre-receive钩子回调网关检查
+
+CREATE TABLE IF NOT EXISTS push_policies (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    scope_type VARCHAR(20) NOT NULL CHECK (scope_type IN ('project', 'repository')),
+    scope_id UUID NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    max_file_size BIGINT NOT NULL DEFAULT 0 CHECK (max_file_size >= 0),
+    forbidden_paths JSONB NOT NULL DEFAULT '[]',
+    forbidden_extensions JSONB NOT NULL DEFAULT '[]',
+    commit_message_pattern VARCHAR(512),
+    allowed_email_domains JSONB NOT NULL DEFAULT '[]',
+    secret_scanning BOOLEAN NOT NULL DEFAULT FALSE,
+    secret_patterns JSONB NOT NULL DEFAULT '[]',
+    protected_refs JSONB NOT NULL DEFAULT '[]',
+    updated_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_push_policy_scope ON push_policies(scope_type, scope_id);
+
+COMMENT ON TABLE push_policies IS '推送策略，仓库策略在项目策略基础上收紧';
+COMMENT ON COLUMN push_policies.max_file_size IS '单个文件大小上限（字节），0表示不限制';
+COMMENT ON COLUMN push_policies.allowed_email_domains IS '允许的作者邮箱域名，含子域名；仓库策略覆盖项目策略';
+COMMENT ON COLUMN push_policies.protected_refs IS '禁止删除和强制推送的引用模式，不以refs/开头的视为分支名';
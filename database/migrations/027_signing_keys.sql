@@ -0,0 +1,46 @@
+-- 提交签名校验迁移
+-- 用户在IAM上传GPG/SSH签名公钥，git网关据此校验提交和标签签名；受保护分支可要求已验证签名
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+    id UUID DEFAULT uuid_generate_v4() PRIMARY KEY,
+    tenant_id UUID NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    key_type VARCHAR(10) NOT NULL CHECK (key_type IN ('gpg', 'ssh')),
+    fingerprint VARCHAR(100) NOT NULL,
+    key_ids JSONB NOT NULL DEFAULT '[]',
+    emails JSONB NOT NULL DEFAULT '[]',
+    public_key TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_fingerprint ON signing_keys(key_type, fingerprint);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_user_id ON signing_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_tenant_id ON signing_keys(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_key_ids ON signing_keys USING GIN (key_ids);
+
+COMMENT ON TABLE signing_keys IS '用户的提交签名公钥，同一公钥只能属于一个用户';
+COMMENT ON COLUMN signing_keys.fingerprint IS 'GPG为主密钥指纹，SSH为SHA256指纹';
+COMMENT ON COLUMN signing_keys.key_ids IS '签名中可能出现的密钥标识：GPG为主密钥和子密钥的长ID，SSH为指纹';
+COMMENT ON COLUMN signing_keys.emails IS 'GPG公钥用户ID中的邮箱';
+
+ALTER TABLE commits
+    ADD COLUMN IF NOT EXISTS signature_type VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS signature_status VARCHAR(20) NOT NULL DEFAULT 'unverified'
+        CHECK (signature_status IN ('verified', 'unverified', 'unknown_key', 'bad_signature')),
+    ADD COLUMN IF NOT EXISTS signature_key_id VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS signature_signer_user_id UUID,
+    ADD COLUMN IF NOT EXISTS signature_signer_email VARCHAR(255);
+
+ALTER TABLE tags
+    ADD COLUMN IF NOT EXISTS signature_type VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS signature_status VARCHAR(20) NOT NULL DEFAULT 'unverified'
+        CHECK (signature_status IN ('verified', 'unverified', 'unknown_key', 'bad_signature')),
+    ADD COLUMN IF NOT EXISTS signature_key_id VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS signature_signer_user_id UUID,
+    ADD COLUMN IF NOT EXISTS signature_signer_email VARCHAR(255);
+
+ALTER TABLE push_policies
+    ADD COLUMN IF NOT EXISTS require_signed_commits BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN push_policies.require_signed_commits IS '受保护分支只接受签名已验证的新提交';
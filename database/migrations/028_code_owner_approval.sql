@@ -0,0 +1,7 @@
+-- 代码所有者迁移
+-- CODEOWNERS从PR目标分支读取，新PR自动向变更文件的所有者请求审查；受保护分支可要求代码所有者批准
+
+ALTER TABLE push_policies
+    ADD COLUMN IF NOT EXISTS require_code_owner_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+COMMENT ON COLUMN push_policies.require_code_owner_approval IS '向受保护分支发起的PR须经变更文件的代码所有者批准';
@@ -0,0 +1,48 @@
+-- Git网关仓库维护迁移
+-- 按推送量定时执行repack/gc和fsck，并重新统计仓库大小和对象数
+
+CREATE TABLE IF NOT EXISTS repository_maintenance (
+    repository_id UUID PRIMARY KEY REFERENCES repositories(id) ON DELETE CASCADE,
+    pushes_since_run INTEGER NOT NULL DEFAULT 0,
+    health VARCHAR(20) NOT NULL DEFAULT 'unknown' CHECK (health IN ('unknown', 'healthy', 'corrupt')),
+    last_status VARCHAR(20) CHECK (last_status IN ('running', 'succeeded', 'failed')),
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_success_at TIMESTAMP WITH TIME ZONE,
+    last_full_gc_at TIMESTAMP WITH TIME ZONE,
+    disk_size BIGINT NOT NULL DEFAULT 0,
+    object_count BIGINT NOT NULL DEFAULT 0,
+    pack_count BIGINT NOT NULL DEFAULT 0,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    retry_after TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_maintenance_pending ON repository_maintenance(pushes_since_run DESC) WHERE pushes_since_run > 0;
+
+CREATE TABLE IF NOT EXISTS repository_maintenance_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    trigger VARCHAR(20) NOT NULL CHECK (trigger IN ('scheduled', 'manual')),
+    mode VARCHAR(20) NOT NULL CHECK (mode IN ('repack', 'gc')),
+    status VARCHAR(20) NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    triggered_by UUID,
+    prune_objects BOOLEAN NOT NULL DEFAULT FALSE,
+    health VARCHAR(20) CHECK (health IN ('unknown', 'healthy', 'corrupt')),
+    fsck_output TEXT,
+    size_before BIGINT NOT NULL DEFAULT 0,
+    size_after BIGINT NOT NULL DEFAULT 0,
+    object_count BIGINT NOT NULL DEFAULT 0,
+    pack_count BIGINT NOT NULL DEFAULT 0,
+    error_message TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_maintenance_runs_repository_id ON repository_maintenance_runs(repository_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_repository_maintenance_runs_running ON repository_maintenance_runs(status) WHERE status = 'running';
+
+COMMENT ON TABLE repository_maintenance IS '仓库维护状态，推送时累加计数，定时任务按计数领取仓库';
+COMMENT ON COLUMN repository_maintenance.disk_size IS 'Git对象库占用的磁盘大小（字节），不含LFS对象和fork借用的上游对象';
+COMMENT ON COLUMN repository_maintenance.retry_after IS '执行中的租约到期时间或失败后的重试时间，此前不自动维护';
+COMMENT ON TABLE repository_maintenance_runs IS '仓库维护记录';
+COMMENT ON COLUMN repository_maintenance_runs.prune_objects IS '是否清理不可达对象；被fork共享对象库的上游不清理';
@@ -0,0 +1,49 @@
+-- Webhook投递队列迁移
+-- 投递记录同时作为持久化队列，投递目标为仓库Webhook或触发器的call_webhook动作；
+-- 每次尝试单独记录，仓库Webhook连续失败过多时自动停用
+
+-- 投递原先只关联触发器，改为关联仓库Webhook或触发器之一
+ALTER TABLE webhook_deliveries DROP CONSTRAINT IF EXISTS webhook_deliveries_webhook_id_fkey;
+ALTER TABLE webhook_deliveries ALTER COLUMN webhook_id DROP NOT NULL;
+ALTER TABLE webhook_deliveries ALTER COLUMN attempts SET DEFAULT 0;
+
+ALTER TABLE webhook_deliveries
+    ADD CONSTRAINT webhook_deliveries_webhook_id_fkey FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS trigger_id UUID REFERENCES webhook_triggers(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS repository_id UUID REFERENCES repositories(id) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS event_type VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS redelivery_of UUID REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS request_headers JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS last_error TEXT;
+
+ALTER TABLE webhook_deliveries
+    ADD CONSTRAINT webhook_deliveries_target_check CHECK (webhook_id IS NOT NULL OR trigger_id IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_retry_at) WHERE status IN ('pending', 'retrying');
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_created ON webhook_deliveries(webhook_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_repository_id ON webhook_deliveries(repository_id);
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    response_body TEXT NOT NULL DEFAULT '',
+    duration BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, attempt);
+
+ALTER TABLE webhooks
+    ADD COLUMN IF NOT EXISTS consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS disabled_reason TEXT;
+
+COMMENT ON TABLE webhook_delivery_attempts IS 'Webhook投递尝试记录，每次HTTP请求一行';
+COMMENT ON COLUMN webhook_delivery_attempts.status_code IS '响应状态码，0表示请求未完成（连接失败、超时等）';
+COMMENT ON COLUMN webhook_delivery_attempts.response_body IS '响应体摘录';
+COMMENT ON COLUMN webhook_deliveries.request_headers IS '自定义请求头，不含签名；签名在每次发送时用最新密钥计算';
+COMMENT ON COLUMN webhook_deliveries.next_retry_at IS '下次投递时间；发送期间用作领取租约';
+COMMENT ON COLUMN webhooks.consecutive_failures IS '连续投递失败次数，成功后清零，达到阈值时自动停用';
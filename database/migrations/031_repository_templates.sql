@@ -0,0 +1,28 @@
+-- 仓库模板迁移
+-- 仓库可标记为模板，新仓库从模板的分支快照初始化，并替换文件路径和内容中的变量
+
+ALTER TABLE repositories
+    ADD COLUMN IF NOT EXISTS is_template BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS templated_from UUID REFERENCES repositories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_repositories_is_template ON repositories(project_id) WHERE is_template;
+
+CREATE TABLE IF NOT EXISTS repository_templates (
+    repository_id UUID PRIMARY KEY REFERENCES repositories(id) ON DELETE CASCADE,
+    variables JSONB NOT NULL DEFAULT '[]',
+    include_all_branches BOOLEAN NOT NULL DEFAULT FALSE,
+    protected_branches JSONB NOT NULL DEFAULT '[]',
+    webhooks JSONB NOT NULL DEFAULT '[]',
+    pipeline_path VARCHAR(512) NOT NULL DEFAULT '',
+    pipeline_content TEXT NOT NULL DEFAULT '',
+    updated_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE repository_templates IS '仓库模板设置';
+COMMENT ON COLUMN repository_templates.variables IS '模板变量声明，文件路径和内容中的 {{name}} 在创建仓库时替换';
+COMMENT ON COLUMN repository_templates.protected_branches IS '新仓库中标记为受保护的分支模式';
+COMMENT ON COLUMN repository_templates.webhooks IS '新仓库自动添加的Webhook，URL可使用模板变量';
+COMMENT ON COLUMN repository_templates.pipeline_path IS '写入新仓库的流水线文件路径，覆盖模板中的同名文件';
+COMMENT ON COLUMN repositories.templated_from IS '创建时使用的模板仓库';
@@ -0,0 +1,17 @@
+-- 仓库迁移与改名重定向
+-- 仓库改名或迁移到其他项目后，旧的项目和名称继续解析到该仓库；新仓库占用同一名称时重定向失效
+
+CREATE TABLE IF NOT EXISTS repository_redirects (
+    project_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (project_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_repository_redirects_repository ON repository_redirects(repository_id);
+
+COMMENT ON TABLE repository_redirects IS '仓库改名或迁移前的旧位置';
+COMMENT ON COLUMN repository_redirects.project_id IS '旧位置所在项目';
+COMMENT ON COLUMN repository_redirects.name IS '旧仓库名称';
+COMMENT ON COLUMN repository_redirects.repository_id IS '当前指向的仓库';
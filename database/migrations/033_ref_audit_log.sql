@@ -0,0 +1,35 @@
+-- 引用审计日志
+-- 记录推送、网关API、镜像同步和恢复操作引起的每次分支和标签更新；
+-- 删除和强制推送丢弃的旧提交在保留期内挂在 refs/keep-around/ 下，可用于恢复分支
+
+CREATE TABLE IF NOT EXISTS ref_audit_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    repository_id UUID NOT NULL REFERENCES repositories(id) ON DELETE CASCADE,
+    ref VARCHAR(512) NOT NULL,
+    ref_type VARCHAR(20) NOT NULL,
+    old_sha VARCHAR(64) NOT NULL,
+    new_sha VARCHAR(64) NOT NULL,
+    actor_id UUID,
+    actor_name VARCHAR(255) NOT NULL DEFAULT '',
+    transport VARCHAR(20) NOT NULL,
+    forced BOOLEAN NOT NULL DEFAULT FALSE,
+    kept_until TIMESTAMP WITH TIME ZONE,
+    kept BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT ref_audit_log_ref_type_check CHECK (ref_type IN ('branch', 'tag', 'other')),
+    CONSTRAINT ref_audit_log_transport_check CHECK (transport IN ('ssh', 'http', 'push', 'api', 'mirror', 'restore'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_ref_audit_log_repository_created ON ref_audit_log(repository_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ref_audit_log_repository_ref ON ref_audit_log(repository_id, ref, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ref_audit_log_kept ON ref_audit_log(kept_until) WHERE kept;
+CREATE INDEX IF NOT EXISTS idx_ref_audit_log_kept_sha ON ref_audit_log(repository_id, old_sha) WHERE kept;
+
+COMMENT ON TABLE ref_audit_log IS '引用更新审计记录';
+COMMENT ON COLUMN ref_audit_log.old_sha IS '更新前的对象ID，新建引用时为全零';
+COMMENT ON COLUMN ref_audit_log.new_sha IS '更新后的对象ID，删除引用时为全零';
+COMMENT ON COLUMN ref_audit_log.actor_name IS '推送前端上报的用户名';
+COMMENT ON COLUMN ref_audit_log.transport IS '更新来源：ssh、http、push、api、mirror、restore';
+COMMENT ON COLUMN ref_audit_log.forced IS '非快进更新，旧提交不再可达';
+COMMENT ON COLUMN ref_audit_log.kept_until IS '旧提交保留到该时间';
+COMMENT ON COLUMN ref_audit_log.kept IS '保留引用是否仍存在';
@@ -0,0 +1,31 @@
+-- 任务工作流
+-- 每个项目可以为不同任务类型配置状态、转换、转换条件和后置动作；
+-- task_types 为空的工作流作为项目默认工作流，没有任何配置时使用服务内置的工作流
+
+CREATE TABLE IF NOT EXISTS task_workflows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    task_types JSONB NOT NULL DEFAULT '[]',
+    initial_status VARCHAR(50) NOT NULL,
+    statuses JSONB NOT NULL DEFAULT '[]',
+    transitions JSONB NOT NULL DEFAULT '[]',
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_workflows_project ON task_workflows(project_id) WHERE deleted_at IS NULL;
+
+COMMENT ON TABLE task_workflows IS '项目任务工作流';
+COMMENT ON COLUMN task_workflows.task_types IS '适用的任务类型，为空时作为项目默认工作流';
+COMMENT ON COLUMN task_workflows.statuses IS '状态列表：key、name、category（todo、in_progress、done）';
+COMMENT ON COLUMN task_workflows.transitions IS '转换列表：name、from、to、conditions、post_functions';
+
+-- 工作流后置动作设置的解决结果
+ALTER TABLE agile_tasks ADD COLUMN IF NOT EXISTS resolution VARCHAR(50);
+ALTER TABLE agile_tasks ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN agile_tasks.resolution IS '解决结果，如 fixed、won''t fix、duplicate';
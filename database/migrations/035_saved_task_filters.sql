@@ -0,0 +1,34 @@
+-- 保存的任务过滤器
+-- 过滤器保存一条任务查询语句，可以仅自己可见、项目内共享或共享给指定的成员；
+-- 看板泳道和仪表板组件通过过滤器ID引用查询
+
+CREATE TABLE IF NOT EXISTS saved_task_filters (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    owner_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    query TEXT NOT NULL,
+    visibility VARCHAR(20) NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'project', 'users')),
+    shared_with JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_task_filters_project ON saved_task_filters(project_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_saved_task_filters_owner ON saved_task_filters(owner_id) WHERE deleted_at IS NULL;
+
+COMMENT ON TABLE saved_task_filters IS '保存的任务查询';
+COMMENT ON COLUMN saved_task_filters.query IS '任务查询语句，例如 assignee = currentUser() AND status != done ORDER BY priority DESC';
+COMMENT ON COLUMN saved_task_filters.visibility IS '可见性：private 仅创建者，project 项目成员，team 创建者和 shared_with 中的成员';
+COMMENT ON COLUMN saved_task_filters.shared_with IS 'team 可见性下共享的用户ID列表';
+
+-- 看板泳道
+ALTER TABLE boards ADD COLUMN IF NOT EXISTS swimlane_filter_ids JSONB NOT NULL DEFAULT '[]';
+
+COMMENT ON COLUMN boards.swimlane_filter_ids IS '按顺序作为泳道的过滤器ID，任务归入第一个匹配的泳道';
+
+-- 查询语言的标签和组件匹配使用 jsonb 包含运算
+CREATE INDEX IF NOT EXISTS idx_agile_tasks_labels ON agile_tasks USING GIN (labels);
+CREATE INDEX IF NOT EXISTS idx_agile_tasks_components ON agile_tasks USING GIN (components);
@@ -0,0 +1,19 @@
+-- 任务链接
+-- 任务之间的类型化链接，可以跨项目。链接按正向存储（源任务 blocks/duplicates/clones 目标任务），
+-- relates_to 没有方向，按ID顺序只存一条。blocks 链接构成依赖图，创建时拒绝形成循环
+
+CREATE TABLE IF NOT EXISTS task_links (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    source_task_id UUID NOT NULL REFERENCES agile_tasks(id) ON DELETE CASCADE,
+    target_task_id UUID NOT NULL REFERENCES agile_tasks(id) ON DELETE CASCADE,
+    link_type VARCHAR(30) NOT NULL CHECK (link_type IN ('blocks', 'relates_to', 'duplicates', 'clones')),
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (source_task_id <> target_task_id),
+    UNIQUE (source_task_id, target_task_id, link_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_links_target ON task_links(target_task_id, link_type);
+
+COMMENT ON TABLE task_links IS '任务之间的类型化链接';
+COMMENT ON COLUMN task_links.link_type IS '链接类型：blocks、relates_to、duplicates、clones，反向名称为 is blocked by、relates to、is duplicated by、is cloned by';
@@ -0,0 +1,34 @@
+-- 任务自定义字段
+-- 项目级的字段定义，取值以字段键保存在 agile_tasks.custom_fields 中。
+-- 键只允许小写字母、数字和下划线，查询语言以 cf.<key> 引用并内联到SQL表达式中
+
+CREATE TABLE IF NOT EXISTS task_custom_fields (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    key VARCHAR(50) NOT NULL CHECK (key ~ '^[a-z][a-z0-9_]{0,49}$'),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('text', 'number', 'date', 'select', 'multi_select', 'user', 'url')),
+    options JSONB,
+    task_types JSONB,
+    required_types JSONB,
+    default_value JSONB,
+    show_on_card BOOLEAN NOT NULL DEFAULT FALSE,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_custom_fields_project_key
+    ON task_custom_fields(project_id, key) WHERE deleted_at IS NULL;
+
+ALTER TABLE agile_tasks ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_agile_tasks_custom_fields ON agile_tasks USING GIN (custom_fields);
+
+COMMENT ON TABLE task_custom_fields IS '项目级任务自定义字段定义';
+COMMENT ON COLUMN task_custom_fields.key IS '字段键，创建后不可修改，删除字段时同时清除任务上的取值';
+COMMENT ON COLUMN task_custom_fields.required_types IS '必填的任务类型，只在创建任务和修改该字段时检查';
+COMMENT ON COLUMN agile_tasks.custom_fields IS '自定义字段取值：文本、链接、日期(YYYY-MM-DD)、单选、用户ID为字符串，数字为数值，多选为字符串数组';
@@ -0,0 +1,25 @@
+-- 任务变更历史
+-- 每次修改任务时逐字段记录旧值和新值，同一次操作的记录共享 change_set_id。
+-- status 字段的历史用于还原状态时间线，计算周期时间和累积流图
+
+CREATE TABLE IF NOT EXISTS task_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v7(),
+    task_id UUID NOT NULL REFERENCES agile_tasks(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL,
+    change_set_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+    field VARCHAR(100),
+    old_value TEXT,
+    new_value TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_changes_task ON task_changes(task_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_task_changes_project ON task_changes(project_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_task_changes_project_field ON task_changes(project_id, field, created_at);
+
+COMMENT ON TABLE task_changes IS '任务字段级变更历史，仅调整排序不记录';
+COMMENT ON COLUMN task_changes.field IS '字段名，自定义字段为 cf.<key>；删除任务的记录为空';
+COMMENT ON COLUMN task_changes.old_value IS '旧值文本：字符串和ID原样保存，数值和列表保存为JSON，空值为NULL';
+COMMENT ON COLUMN task_changes.new_value IS '新值文本，格式同 old_value';
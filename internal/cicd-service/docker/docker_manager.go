@@ -28,7 +28,9 @@ type DockerManager interface {
 
 	// 镜像管理
 	PullImage(ctx context.Context, image string) error
+	PullImageWithAuth(ctx context.Context, image string, auth *RegistryAuth) error
 	BuildImage(ctx context.Context, buildContext io.Reader, options *BuildOptions) (string, error)
+	PushImage(ctx context.Context, image string, auth *RegistryAuth) (string, error)
 	RemoveImage(ctx context.Context, imageID string, force bool) error
 	ListImages(ctx context.Context) ([]*Image, error)
 
@@ -164,6 +166,17 @@ type BuildOptions struct {
 	Target     string            `json:"target"`
 	NoCache    bool              `json:"no_cache"`
 	PullParent bool              `json:"pull_parent"`
+	CacheFrom  []string          `json:"cache_from"` // 用于匹配层缓存的镜像
+
+	// 拉取私有基础镜像/缓存镜像所需的仓库凭据
+	RegistryAuths []*RegistryAuth `json:"-"`
+}
+
+// RegistryAuth 镜像仓库认证信息
+type RegistryAuth struct {
+	ServerAddress string `json:"server_address"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
 }
 
 // Image 镜像信息
@@ -2,6 +2,7 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
@@ -12,6 +13,8 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/go-connections/nat"
 	"go.uber.org/zap"
 )
@@ -373,6 +376,27 @@ func (dm *dockerManager) PullImage(ctx context.Context, imageName string) error
 	return nil
 }
 
+// PullImageWithAuth 使用仓库凭据拉取镜像
+func (dm *dockerManager) PullImageWithAuth(ctx context.Context, imageName string, auth *RegistryAuth) error {
+	encodedAuth, err := encodeRegistryAuth(auth)
+	if err != nil {
+		return err
+	}
+
+	reader, err := dm.client.ImagePull(ctx, imageName, image.PullOptions{RegistryAuth: encodedAuth})
+	if err != nil {
+		return fmt.Errorf("拉取镜像失败: %v", err)
+	}
+	defer reader.Close()
+
+	if err := readJSONMessages(reader, nil); err != nil {
+		return fmt.Errorf("拉取镜像失败: %w", err)
+	}
+
+	dm.logger.Info("镜像拉取成功", zap.String("image", imageName))
+	return nil
+}
+
 // BuildImage 构建镜像
 func (dm *dockerManager) BuildImage(ctx context.Context, buildContext io.Reader, options *BuildOptions) (string, error) {
 	// 转换BuildArgs格式
@@ -390,32 +414,123 @@ func (dm *dockerManager) BuildImage(ctx context.Context, buildContext io.Reader,
 		BuildArgs:  buildArgs,
 		Labels:     options.Labels,
 		Target:     options.Target,
+		CacheFrom:  options.CacheFrom,
 	}
 
 	if options.Dockerfile != "" {
 		buildOpts.Dockerfile = options.Dockerfile
 	}
 
+	if len(options.RegistryAuths) > 0 {
+		buildOpts.AuthConfigs = make(map[string]registry.AuthConfig, len(options.RegistryAuths))
+		for _, auth := range options.RegistryAuths {
+			buildOpts.AuthConfigs[auth.ServerAddress] = registry.AuthConfig{
+				Username:      auth.Username,
+				Password:      auth.Password,
+				ServerAddress: auth.ServerAddress,
+			}
+		}
+	}
+
 	response, err := dm.client.ImageBuild(ctx, buildContext, buildOpts)
 	if err != nil {
 		return "", fmt.Errorf("构建镜像失败: %v", err)
 	}
 	defer response.Body.Close()
 
-	// 读取构建输出
-	buildOutput, err := io.ReadAll(response.Body)
+	// 读取构建输出，构建失败时错误信息在输出流中返回
+	var buildOutput strings.Builder
+	err = readJSONMessages(response.Body, func(msg *jsonmessage.JSONMessage) {
+		buildOutput.WriteString(msg.Stream)
+	})
 	if err != nil {
-		return "", fmt.Errorf("读取构建输出失败: %v", err)
+		return buildOutput.String(), fmt.Errorf("构建镜像失败: %w", err)
 	}
 
 	dm.logger.Info("镜像构建成功", zap.Strings("tags", options.Tags))
 
-	// 返回构建输出（实际应该解析并返回镜像ID）
-	return string(buildOutput), nil
+	return buildOutput.String(), nil
+}
+
+// PushImage 推送镜像，返回镜像仓库中的内容摘要
+func (dm *dockerManager) PushImage(ctx context.Context, imageRef string, auth *RegistryAuth) (string, error) {
+	encodedAuth, err := encodeRegistryAuth(auth)
+	if err != nil {
+		return "", err
+	}
+
+	reader, err := dm.client.ImagePush(ctx, imageRef, image.PushOptions{RegistryAuth: encodedAuth})
+	if err != nil {
+		return "", fmt.Errorf("推送镜像失败: %v", err)
+	}
+	defer reader.Close()
+
+	// 推送完成后守护进程在aux字段中返回摘要
+	var digest string
+	err = readJSONMessages(reader, func(msg *jsonmessage.JSONMessage) {
+		if msg.Aux == nil {
+			return
+		}
+		var result struct {
+			Tag    string `json:"Tag"`
+			Digest string `json:"Digest"`
+		}
+		if json.Unmarshal(*msg.Aux, &result) == nil && result.Digest != "" {
+			digest = result.Digest
+		}
+	})
+	if err != nil {
+		return "", fmt.Errorf("推送镜像失败: %w", err)
+	}
+
+	dm.logger.Info("镜像推送成功", zap.String("image", imageRef), zap.String("digest", digest))
+	return digest, nil
 }
 
 // 辅助方法
 
+// encodeRegistryAuth 编码仓库凭据，未提供凭据时返回空字符串
+func encodeRegistryAuth(auth *RegistryAuth) (string, error) {
+	if auth == nil || auth.Username == "" {
+		return "", nil
+	}
+
+	encoded, err := registry.EncodeAuthConfig(registry.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		ServerAddress: auth.ServerAddress,
+	})
+	if err != nil {
+		return "", fmt.Errorf("编码仓库凭据失败: %w", err)
+	}
+	return encoded, nil
+}
+
+// readJSONMessages 逐条读取Docker守护进程返回的JSON消息流，遇到错误消息时返回错误
+func readJSONMessages(reader io.Reader, handle func(msg *jsonmessage.JSONMessage)) error {
+	decoder := json.NewDecoder(reader)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("解析输出失败: %w", err)
+		}
+
+		if msg.Error != nil {
+			return fmt.Errorf("%s", msg.Error.Message)
+		}
+		if msg.ErrorMessage != "" {
+			return fmt.Errorf("%s", msg.ErrorMessage)
+		}
+
+		if handle != nil {
+			handle(&msg)
+		}
+	}
+}
+
 // inspectToContainer 将Docker检查结果转换为Container对象
 func (dm *dockerManager) inspectToContainer(inspect *types.ContainerJSON) *Container {
 	// 解析创建时间
@@ -26,6 +26,9 @@ type PipelineEngine interface {
 
 	// 处理作业结果
 	HandleJobResult(ctx context.Context, jobID uuid.UUID, result *JobResult) error
+
+	// 记录单次执行尝试（独立日志与耗时）
+	RecordJobAttempt(ctx context.Context, result *JobResult) (*models.JobExecution, error)
 }
 
 // ExecutionStatus 执行状态
@@ -54,14 +57,18 @@ type JobExecutionStatus struct {
 
 // JobResult 作业执行结果
 type JobResult struct {
-	JobID      uuid.UUID        `json:"job_id"`
-	RunnerID   uuid.UUID        `json:"runner_id"`
-	Status     models.JobStatus `json:"status"`
-	ExitCode   *int             `json:"exit_code"`
-	Output     string           `json:"output"`
-	StartedAt  time.Time        `json:"started_at"`
-	FinishedAt time.Time        `json:"finished_at"`
-	Artifacts  []string         `json:"artifacts"`
+	JobID         uuid.UUID            `json:"job_id"`
+	RunnerID      uuid.UUID            `json:"runner_id"`
+	Attempt       int                  `json:"attempt"`
+	Status        models.JobStatus     `json:"status"`
+	FailureReason models.FailureReason `json:"failure_reason,omitempty"`
+	FailedStep    string               `json:"failed_step,omitempty"`
+	ExitCode      *int                 `json:"exit_code"`
+	Output        string               `json:"output"`
+	ErrorMessage  string               `json:"error_message,omitempty"`
+	StartedAt     time.Time            `json:"started_at"`
+	FinishedAt    time.Time            `json:"finished_at"`
+	Artifacts     []string             `json:"artifacts"`
 }
 
 // PipelineDefinition 流水线定义
@@ -106,7 +113,14 @@ type JobConfig struct {
 	If         string            `yaml:"if"`
 	TimeoutMin int               `yaml:"timeout-minutes"`
 	Variables  map[string]string `yaml:"variables"`
+	Secrets    []string          `yaml:"secrets"`
 	Steps      []StepConfig      `yaml:"steps"`
+
+	// 重试策略，未配置时使用作业默认的MaxRetries
+	Retry *models.RetryPolicy `yaml:"retry"`
+
+	// trigger作业：在其他仓库/项目中启动下游流水线，与steps互斥
+	Trigger *models.TriggerJobConfig `yaml:"trigger"`
 }
 
 // StepConfig 步骤配置
@@ -118,6 +132,9 @@ type StepConfig struct {
 	Env             map[string]string `yaml:"env"`
 	If              string            `yaml:"if"`
 	ContinueOnError bool              `yaml:"continue-on-error"`
+
+	// uses: docker-build 时的镜像构建配置
+	Build *models.DockerBuildStep `yaml:"build"`
 }
 
 // pipelineEngine 流水线执行引擎实现
@@ -331,6 +348,13 @@ func (e *pipelineEngine) HandleJobResult(ctx context.Context, jobID uuid.UUID, r
 		"finished_at": result.FinishedAt,
 	}
 
+	if result.FailureReason != "" {
+		updates["failure_reason"] = result.FailureReason
+	}
+	if result.FailedStep != "" {
+		updates["failed_step"] = result.FailedStep
+	}
+
 	duration := result.FinishedAt.Sub(result.StartedAt)
 	durationSeconds := int64(duration.Seconds())
 	updates["duration"] = durationSeconds
@@ -363,6 +387,49 @@ func (e *pipelineEngine) HandleJobResult(ctx context.Context, jobID uuid.UUID, r
 	return nil
 }
 
+// RecordJobAttempt 记录单次执行尝试
+// 每次尝试的日志单独存储，便于重试后回看每一次的输出
+func (e *pipelineEngine) RecordJobAttempt(ctx context.Context, result *JobResult) (*models.JobExecution, error) {
+	attempt := result.Attempt
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	logName := fmt.Sprintf("attempt-%d", attempt)
+	finishedAt := result.FinishedAt
+	execution := &models.JobExecution{
+		JobID:         result.JobID,
+		RunnerID:      result.RunnerID,
+		Attempt:       attempt,
+		Status:        result.Status,
+		FailureReason: result.FailureReason,
+		FailedStep:    result.FailedStep,
+		ExitCode:      result.ExitCode,
+		ErrorMessage:  result.ErrorMessage,
+		StartedAt:     result.StartedAt,
+		FinishedAt:    &finishedAt,
+		Duration:      result.FinishedAt.Sub(result.StartedAt),
+		ArtifactPaths: result.Artifacts,
+	}
+
+	if result.Output != "" {
+		if err := e.storage.WriteLog(ctx, result.JobID, logName, []byte(result.Output)); err != nil {
+			e.logger.Warn("存储作业尝试日志失败",
+				zap.String("job_id", result.JobID.String()),
+				zap.Int("attempt", attempt),
+				zap.Error(err))
+		} else {
+			execution.LogPath = fmt.Sprintf("%s/%s", result.JobID, logName)
+		}
+	}
+
+	if err := e.repo.CreateJobExecution(ctx, execution); err != nil {
+		return nil, fmt.Errorf("保存作业执行记录失败: %w", err)
+	}
+
+	return execution, nil
+}
+
 // 私有方法
 
 // parsePipelineDefinition 解析流水线定义
@@ -427,6 +494,9 @@ func (e *pipelineEngine) buildJobDependencyGraph(jobs map[string]JobConfig) (map
 	graph := make(map[string][]string)
 
 	for jobName, job := range jobs {
+		if err := validateTriggerJob(jobName, &job); err != nil {
+			return nil, err
+		}
 		graph[jobName] = job.DependsOn
 	}
 
@@ -569,12 +639,37 @@ func (e *pipelineEngine) executeJob(execution *pipelineExecution, run *models.Pi
 
 	jobConfig := execution.Definition.Jobs[jobName]
 
+	jobType := models.JobTypeBuild // 使用正确的类型
+	if jobConfig.Trigger != nil {
+		jobType = models.JobTypeTrigger
+	}
+
 	// 创建作业记录
 	job := &models.Job{
 		PipelineRunID: run.ID,
 		Name:          jobConfig.Name,
-		Type:          models.JobTypeBuild, // 使用正确的类型
+		Type:          jobType,
 		Status:        models.JobStatusPending,
+		RetryPolicy:   jobConfig.Retry,
+		Secrets:       jobConfig.Secrets,
+	}
+	steps, err := buildJobSteps(jobConfig.Steps)
+	if err != nil {
+		return fmt.Errorf("解析作业步骤失败: %w", err)
+	}
+	job.Steps = steps
+
+	// 记录依赖作业ID，用于关键路径分析（依赖作业此时均已创建）
+	for _, dep := range jobConfig.DependsOn {
+		if depExec, ok := execution.Jobs[dep]; ok {
+			job.Dependencies = append(job.Dependencies, depExec.JobID)
+		}
+	}
+	if jobConfig.Retry != nil {
+		job.MaxRetries = jobConfig.Retry.Max
+	}
+	if jobConfig.TimeoutMin > 0 {
+		job.Config = map[string]interface{}{"timeout": float64(jobConfig.TimeoutMin * 60)}
 	}
 
 	if err := e.repo.CreateJob(execution.Context, job); err != nil {
@@ -589,6 +684,11 @@ func (e *pipelineEngine) executeJob(execution *pipelineExecution, run *models.Pi
 	}
 	execution.Jobs[jobName] = jobExec
 
+	// trigger作业由引擎直接处理，不分配执行器
+	if jobConfig.Trigger != nil {
+		return e.executeTriggerJob(execution, run, job, jobExec, jobConfig.Trigger)
+	}
+
 	// 查找可用的执行器
 	runners, err := e.repo.GetAvailableRunners(execution.Context, nil)
 	if err != nil {
@@ -659,6 +759,37 @@ func (e *pipelineEngine) executeJob(execution *pipelineExecution, run *models.Pi
 	return nil
 }
 
+// buildJobSteps 将步骤配置转换为作业步骤
+func buildJobSteps(configs []StepConfig) ([]models.JobStep, error) {
+	steps := make([]models.JobStep, 0, len(configs))
+	for i, config := range configs {
+		step := models.JobStep{
+			Name:         config.Name,
+			Commands:     config.Run,
+			Environment:  config.Env,
+			AllowFailure: config.ContinueOnError,
+		}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step-%d", i+1)
+		}
+
+		if config.Uses == models.StepUsesDockerBuild {
+			if config.Build == nil || config.Build.Repository == "" {
+				return nil, fmt.Errorf("步骤 %s 缺少镜像名称(build.repository)", step.Name)
+			}
+			step.DockerBuild = config.Build
+			step.Commands = ""
+		}
+		if models.IsCheckoutAction(config.Uses) {
+			step.Checkout = &models.CheckoutStep{Ref: config.With["ref"], Path: config.With["path"]}
+			step.Commands = ""
+		}
+
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
 // updateRunStatus 更新流水线运行状态
 func (e *pipelineEngine) updateRunStatus(ctx context.Context, runID uuid.UUID, status models.PipelineStatus) error {
 	updates := map[string]interface{}{
@@ -0,0 +1,311 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/cicd-service/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// triggerPollInterval 等待下游流水线完成时的轮询间隔
+const triggerPollInterval = 5 * time.Second
+
+// validateTriggerJob 校验trigger作业配置
+func validateTriggerJob(jobName string, job *JobConfig) error {
+	if job.Trigger == nil {
+		return nil
+	}
+	if len(job.Steps) > 0 {
+		return fmt.Errorf("作业 %s 不能同时配置trigger和steps", jobName)
+	}
+	if err := job.Trigger.Validate(); err != nil {
+		return fmt.Errorf("作业 %s: %w", jobName, err)
+	}
+
+	// 传递的产物必须来自已声明依赖的作业，否则触发时产物可能尚未生成
+	for _, upstream := range job.Trigger.Artifacts {
+		dependent := false
+		for _, dep := range job.DependsOn {
+			if dep == upstream {
+				dependent = true
+				break
+			}
+		}
+		if !dependent {
+			return fmt.Errorf("作业 %s 传递的产物来自 %s，但未在depends-on中声明", jobName, upstream)
+		}
+	}
+	return nil
+}
+
+// executeTriggerJob 执行trigger作业：启动下游流水线，按策略等待并同步其结果
+func (e *pipelineEngine) executeTriggerJob(execution *pipelineExecution, run *models.PipelineRun, job *models.Job, jobExec *jobExecution, config *models.TriggerJobConfig) error {
+	ctx := execution.Context
+	logger := execution.Logger.With(zap.String("job_id", job.ID.String()))
+
+	startedAt := time.Now().UTC()
+	jobExec.Status = models.JobStatusRunning
+	jobExec.StartedAt = &startedAt
+
+	updates := map[string]interface{}{
+		"status":     models.JobStatusRunning,
+		"started_at": startedAt,
+	}
+	if err := e.repo.UpdateJob(ctx, job.ID, updates); err != nil {
+		return fmt.Errorf("更新作业状态失败: %w", err)
+	}
+
+	child, err := e.startDownstreamPipeline(execution, run, job, config)
+	if err != nil {
+		logger.Error("触发下游流水线失败", zap.Error(err))
+		return e.finishTriggerJob(jobExec, job.ID, models.JobStatusFailed, models.FailureReasonInfrastructure, err.Error())
+	}
+
+	logger.Info("已触发下游流水线",
+		zap.String("downstream_run_id", child.ID.String()),
+		zap.String("downstream_pipeline_id", child.PipelineID.String()))
+
+	if !config.WaitForResult() {
+		return e.finishTriggerJob(jobExec, job.ID, models.JobStatusSuccess, "",
+			fmt.Sprintf("已触发下游流水线运行 %s", child.ID))
+	}
+
+	// depend策略：等待下游完成并同步其结果
+	result, err := e.waitForDownstream(ctx, child.ID, time.Duration(config.TimeoutMin)*time.Minute)
+	if err != nil {
+		reason := models.FailureReasonInfrastructure
+		if errors.Is(err, context.DeadlineExceeded) {
+			reason = models.FailureReasonTimeout
+		}
+		return e.finishTriggerJob(jobExec, job.ID, models.JobStatusFailed, reason,
+			fmt.Sprintf("等待下游流水线运行 %s 失败: %v", child.ID, err))
+	}
+
+	switch result.Status {
+	case models.PipelineStatusSuccess:
+		return e.finishTriggerJob(jobExec, job.ID, models.JobStatusSuccess, "",
+			fmt.Sprintf("下游流水线运行 %s 执行成功", child.ID))
+	case models.PipelineStatusCancelled:
+		return e.finishTriggerJob(jobExec, job.ID, models.JobStatusCancelled, models.FailureReasonDownstream,
+			fmt.Sprintf("下游流水线运行 %s 已取消", child.ID))
+	default:
+		return e.finishTriggerJob(jobExec, job.ID, models.JobStatusFailed, models.FailureReasonDownstream,
+			fmt.Sprintf("下游流水线运行 %s 执行失败", child.ID))
+	}
+}
+
+// startDownstreamPipeline 解析目标流水线并创建、启动下游运行
+func (e *pipelineEngine) startDownstreamPipeline(execution *pipelineExecution, run *models.PipelineRun, job *models.Job, config *models.TriggerJobConfig) (*models.PipelineRun, error) {
+	ctx := execution.Context
+
+	upstream, err := e.repo.GetPipelineByID(ctx, run.PipelineID)
+	if err != nil {
+		return nil, fmt.Errorf("获取上游流水线失败: %w", err)
+	}
+	var upstreamProjectID uuid.UUID
+	if upstream.Repository != nil {
+		upstreamProjectID = upstream.Repository.ProjectID
+	}
+
+	target, targetRepo, err := e.resolveTriggerTarget(ctx, config, upstreamProjectID, run.TriggerBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// 沿触发链向上检查，防止跨项目的循环触发
+	ancestors, err := e.repo.GetPipelineRunAncestors(ctx, run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询上游触发链失败: %w", err)
+	}
+	ancestry := make([]uuid.UUID, 0, len(ancestors))
+	for _, ancestor := range ancestors {
+		ancestry = append(ancestry, ancestor.PipelineID)
+	}
+	if err := models.DetectTriggerCycle(ancestry, target.ID); err != nil {
+		return nil, err
+	}
+
+	artifacts, err := e.collectUpstreamArtifacts(execution, run, config.Artifacts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 上游上下文变量优先，避免被配置覆盖
+	variables := make(map[string]string, len(config.Variables)+5)
+	for key, value := range config.Variables {
+		variables[key] = value
+	}
+	for key, value := range models.UpstreamVariables(run, upstreamProjectID) {
+		variables[key] = value
+	}
+
+	branch := config.Branch
+	if branch == "" {
+		branch = targetRepo.DefaultBranch
+	}
+
+	child := &models.PipelineRun{
+		PipelineID:        target.ID,
+		TriggerType:       models.TriggerTypePipeline,
+		TriggerBy:         run.TriggerBy,
+		Branch:            &branch,
+		Status:            models.PipelineStatusPending,
+		Variables:         variables,
+		ParentRunID:       &run.ID,
+		ParentJobID:       &job.ID,
+		UpstreamArtifacts: artifacts,
+		CreatedAt:         time.Now().UTC(),
+	}
+	if err := e.repo.CreatePipelineRun(ctx, child); err != nil {
+		return nil, fmt.Errorf("创建下游流水线运行失败: %w", err)
+	}
+
+	// depend策略下上游取消时下游随之取消；否则下游独立运行，不受上游结束影响
+	childCtx := context.Background()
+	if config.WaitForResult() {
+		childCtx = ctx
+	}
+	if err := e.ExecutePipeline(childCtx, child); err != nil {
+		e.updateRunStatus(context.Background(), child.ID, models.PipelineStatusFailed)
+		return nil, fmt.Errorf("启动下游流水线失败: %w", err)
+	}
+
+	return child, nil
+}
+
+// resolveTriggerTarget 解析trigger作业的目标流水线，并校验上游有权触发：
+// 目标必须与上游属于同一租户，跨项目时触发用户需是目标项目成员
+func (e *pipelineEngine) resolveTriggerTarget(ctx context.Context, config *models.TriggerJobConfig, upstreamProjectID uuid.UUID, triggerBy *uuid.UUID) (*models.Pipeline, *models.Repository, error) {
+	if upstreamProjectID == uuid.Nil {
+		return nil, nil, fmt.Errorf("%w: 无法确定上游流水线所属项目", models.ErrTriggerForbidden)
+	}
+
+	projectID := upstreamProjectID
+	if config.Project != "" {
+		projectID = uuid.MustParse(config.Project) // Validate已校验格式
+	}
+
+	var targetRepo *models.Repository
+	var err error
+	if repoID, parseErr := uuid.Parse(config.Repository); parseErr == nil {
+		targetRepo, err = e.repo.GetRepositoryByID(ctx, repoID)
+	} else {
+		targetRepo, err = e.repo.GetRepositoryByName(ctx, projectID, config.Repository)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("目标仓库 %s 不存在: %w", config.Repository, err)
+	}
+
+	allowed, err := e.repo.CanTriggerInProject(ctx, upstreamProjectID, targetRepo.ProjectID, triggerBy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("校验触发权限失败: %w", err)
+	}
+	if !allowed {
+		// 不区分不存在和无权限，避免泄露其他租户的仓库
+		return nil, nil, fmt.Errorf("%w: 仓库 %s", models.ErrTriggerForbidden, config.Repository)
+	}
+
+	target, err := e.repo.GetActivePipelineByName(ctx, targetRepo.ID, config.Pipeline)
+	if err != nil {
+		if config.Pipeline == "" {
+			return nil, nil, fmt.Errorf("目标仓库 %s 没有启用的流水线: %w", targetRepo.Name, err)
+		}
+		return nil, nil, fmt.Errorf("目标流水线 %s 不存在或已禁用: %w", config.Pipeline, err)
+	}
+
+	return target, targetRepo, nil
+}
+
+// collectUpstreamArtifacts 收集指定上游作业的构建产物
+func (e *pipelineEngine) collectUpstreamArtifacts(execution *pipelineExecution, run *models.PipelineRun, jobNames []string) ([]models.UpstreamArtifact, error) {
+	artifacts := make([]models.UpstreamArtifact, 0)
+	for _, name := range jobNames {
+		upstreamExec, exists := execution.Jobs[name]
+		if !exists {
+			return nil, fmt.Errorf("上游作业 %s 尚未执行，无法传递产物", name)
+		}
+
+		upstreamJob, err := e.repo.GetJobByID(execution.Context, upstreamExec.JobID)
+		if err != nil {
+			return nil, fmt.Errorf("获取上游作业 %s 失败: %w", name, err)
+		}
+
+		for _, path := range upstreamJob.ArtifactPaths {
+			artifacts = append(artifacts, models.UpstreamArtifact{
+				RunID:   run.ID.String(),
+				JobID:   upstreamJob.ID.String(),
+				JobName: name,
+				Path:    path,
+			})
+		}
+	}
+	return artifacts, nil
+}
+
+// waitForDownstream 轮询等待下游流水线运行结束
+// timeout为0时一直等待到上游执行上下文结束
+func (e *pipelineEngine) waitForDownstream(ctx context.Context, runID uuid.UUID, timeout time.Duration) (*models.PipelineRun, error) {
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(triggerPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-waitCtx.Done():
+			// 上游取消或等待超时，同步取消下游运行
+			if err := e.CancelPipeline(context.Background(), runID); err != nil {
+				e.logger.Warn("取消下游流水线失败", zap.String("run_id", runID.String()), zap.Error(err))
+			}
+			return nil, waitCtx.Err()
+		case <-ticker.C:
+			child, err := e.repo.GetPipelineRunByID(waitCtx, runID)
+			if err != nil {
+				return nil, fmt.Errorf("查询下游流水线运行失败: %w", err)
+			}
+			if child.IsFinished() {
+				return child, nil
+			}
+		}
+	}
+}
+
+// finishTriggerJob 记录trigger作业结果，失败时返回错误以阻止后续依赖作业
+func (e *pipelineEngine) finishTriggerJob(jobExec *jobExecution, jobID uuid.UUID, status models.JobStatus, reason models.FailureReason, message string) error {
+	finishedAt := time.Now().UTC()
+	jobExec.Status = status
+	jobExec.FinishedAt = &finishedAt
+	jobExec.Output.WriteString(message)
+
+	updates := map[string]interface{}{
+		"status":      status,
+		"finished_at": finishedAt,
+		"log_output":  message,
+	}
+	if jobExec.StartedAt != nil {
+		updates["duration"] = int64(finishedAt.Sub(*jobExec.StartedAt).Seconds())
+	}
+	if status != models.JobStatusSuccess {
+		updates["failure_reason"] = reason
+		updates["error_message"] = message
+	}
+
+	// 上游上下文可能已取消，使用独立上下文保证结果落库
+	if err := e.repo.UpdateJob(context.Background(), jobID, updates); err != nil {
+		return fmt.Errorf("更新作业完成状态失败: %w", err)
+	}
+
+	if status != models.JobStatusSuccess {
+		return errors.New(message)
+	}
+	return nil
+}
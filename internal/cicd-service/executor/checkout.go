@@ -0,0 +1,208 @@
+package executor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/cicd-service/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SourceFetcher 获取仓库源码归档（tar.gz）
+type SourceFetcher interface {
+	FetchArchive(ctx context.Context, repositoryID uuid.UUID, ref string) (io.ReadCloser, error)
+}
+
+// gitArchiveFetcher 通过Git网关的归档下载接口获取源码，按提交SHA下载的归档可被网关和代理缓存
+type gitArchiveFetcher struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewGitArchiveFetcher 创建Git网关归档下载器，token为访问Git网关的服务令牌
+func NewGitArchiveFetcher(baseURL, token string) SourceFetcher {
+	return &gitArchiveFetcher{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		// 归档为流式下载，只限制建立连接和等待响应头的时间，整体时长由作业超时控制
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: 60 * time.Second,
+			},
+		},
+	}
+}
+
+// FetchArchive 下载ref对应的tar.gz归档，调用方负责关闭返回的流
+func (f *gitArchiveFetcher) FetchArchive(ctx context.Context, repositoryID uuid.UUID, ref string) (io.ReadCloser, error) {
+	archiveURL := fmt.Sprintf("%s/api/v1/repositories/%s/archive/%s.tar.gz",
+		f.baseURL, repositoryID.String(), (&url.URL{Path: ref}).EscapedPath())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, archiveURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建归档下载请求失败: %w", err)
+	}
+	if f.token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.token)
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载源码归档失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("下载源码归档失败: Git网关返回 %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// executeCheckoutStep 执行代码检出步骤：下载流水线提交的源码归档并解压到工作空间
+func (je *jobExecutor) executeCheckoutStep(ctx context.Context, job *models.Job, step *models.JobStep, status *JobExecutionStatus) error {
+	err := je.checkoutSource(ctx, job, step)
+	if err == nil {
+		return nil
+	}
+	if step.AllowFailure {
+		je.logger.Warn("代码检出步骤失败（允许失败）",
+			zap.String("job_id", job.ID.String()),
+			zap.String("step", step.Name),
+			zap.Error(err))
+		return nil
+	}
+	return je.handleExecutionError(status, fmt.Errorf("步骤 %s 代码检出失败: %w", step.Name, err))
+}
+
+// checkoutSource 下载并解压源码归档
+func (je *jobExecutor) checkoutSource(ctx context.Context, job *models.Job, step *models.JobStep) error {
+	if je.config.SourceFetcher == nil {
+		return fmt.Errorf("执行器未配置源码下载")
+	}
+	if job.PipelineRun == nil || job.PipelineRun.Pipeline == nil {
+		return fmt.Errorf("作业缺少流水线仓库信息")
+	}
+
+	ref := step.Checkout.CheckoutRef(job.PipelineRun)
+	if ref == "" {
+		return fmt.Errorf("未指定检出的提交")
+	}
+
+	workspaceDir := fmt.Sprintf("/tmp/cicd-workspaces/job-%s", job.ID.String())
+	targetDir, err := resolveWorkspacePath(workspaceDir, step.Checkout.Path)
+	if err != nil {
+		return err
+	}
+
+	archive, err := je.config.SourceFetcher.FetchArchive(ctx, job.PipelineRun.Pipeline.RepositoryID, ref)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	if err := extractTarGz(archive, targetDir); err != nil {
+		return fmt.Errorf("解压源码归档失败: %w", err)
+	}
+
+	je.logger.Info("代码检出完成",
+		zap.String("job_id", job.ID.String()),
+		zap.String("repository_id", job.PipelineRun.Pipeline.RepositoryID.String()),
+		zap.String("ref", ref),
+		zap.String("path", targetDir))
+	return nil
+}
+
+// resolveWorkspacePath 解析工作空间下的目录，禁止越出工作空间
+func resolveWorkspacePath(workspaceDir, path string) (string, error) {
+	dir := filepath.Join(workspaceDir, filepath.Clean("/"+path))
+	rel, err := filepath.Rel(workspaceDir, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("检出目录 %s 超出工作空间", path)
+	}
+	return dir, nil
+}
+
+// extractTarGz 将tar.gz流解压到目录并去掉归档的根目录（网关归档总带有 <仓库名>-<ref>/ 前缀），
+// 跳过越出目标目录或位于符号链接之下的条目
+func extractTarGz(r io.Reader, dest string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+
+	symlinks := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		_, name, found := strings.Cut(strings.TrimPrefix(header.Name, "./"), "/")
+		if !found {
+			continue
+		}
+		target := filepath.Join(dest, filepath.Clean("/"+name))
+		if target == dest || symlinks[target] || underSymlink(dest, target, symlinks) {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tr)
+			file.Close()
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// 链接目标保持仓库中的相对路径，不跟随链接写入文件
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil && !os.IsExist(err) {
+				return err
+			}
+			symlinks[target] = true
+		}
+	}
+}
+
+// underSymlink 判断路径的上级目录是否为解压出的符号链接，避免经由链接写到目标目录之外
+func underSymlink(dest, target string, symlinks map[string]bool) bool {
+	for dir := filepath.Dir(target); dir != dest && len(dir) > len(dest); dir = filepath.Dir(dir) {
+		if symlinks[dir] {
+			return true
+		}
+	}
+	return false
+}
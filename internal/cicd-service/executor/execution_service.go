@@ -116,7 +116,13 @@ func NewExecutionService(
 		config = DefaultExecutionServiceConfig()
 	}
 
-	// 创建作业执行器
+	// 创建作业执行器，镜像构建步骤推送后即记录到流水线运行
+	if config.ExecutorConfig == nil {
+		config.ExecutorConfig = DefaultExecutorConfig()
+	}
+	if config.ExecutorConfig.ImageRecorder == nil && pipelineRepo != nil {
+		config.ExecutorConfig.ImageRecorder = pipelineRepo
+	}
 	executor := NewJobExecutor(
 		config.ExecutorConfig,
 		dockerManager,
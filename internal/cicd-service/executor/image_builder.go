@@ -0,0 +1,332 @@
+package executor
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/cicd-service/docker"
+	"github.com/cloud-platform/collaborative-dev/internal/cicd-service/models"
+	"github.com/cloud-platform/collaborative-dev/shared/vault"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SecretResolver 作业密钥解析接口
+type SecretResolver interface {
+	ResolveSecret(ctx context.Context, job *models.Job, name string) (string, error)
+}
+
+// ImageRecorder 记录流水线运行推送的镜像，部署据此引用不可变的镜像摘要
+type ImageRecorder interface {
+	AppendPipelineRunImage(ctx context.Context, runID uuid.UUID, image *models.BuiltImage) error
+}
+
+// vaultSecretResolver 基于Vault的密钥解析器
+type vaultSecretResolver struct {
+	client     vault.VaultClient
+	pathPrefix string
+}
+
+// NewVaultSecretResolver 创建基于Vault的密钥解析器
+// 密钥存储于 {pathPrefix}/{name}，取value字段
+func NewVaultSecretResolver(client vault.VaultClient, pathPrefix string) SecretResolver {
+	return &vaultSecretResolver{
+		client:     client,
+		pathPrefix: strings.TrimSuffix(pathPrefix, "/"),
+	}
+}
+
+// ResolveSecret 从Vault读取密钥
+func (r *vaultSecretResolver) ResolveSecret(ctx context.Context, job *models.Job, name string) (string, error) {
+	data, err := r.client.GetSecret(ctx, fmt.Sprintf("%s/%s", r.pathPrefix, name))
+	if err != nil {
+		return "", fmt.Errorf("读取密钥 %s 失败: %w", name, err)
+	}
+
+	value, ok := data["value"].(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("密钥 %s 内容为空", name)
+	}
+	return value, nil
+}
+
+// envSecretResolver 基于环境变量的密钥解析器，读取 CICD_SECRET_{NAME}
+type envSecretResolver struct{}
+
+// NewEnvSecretResolver 创建基于环境变量的密钥解析器
+func NewEnvSecretResolver() SecretResolver {
+	return &envSecretResolver{}
+}
+
+// ResolveSecret 从环境变量读取密钥
+func (r *envSecretResolver) ResolveSecret(ctx context.Context, job *models.Job, name string) (string, error) {
+	key := "CICD_SECRET_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+	value := os.Getenv(key)
+	if value == "" {
+		return "", fmt.Errorf("密钥 %s 未配置", name)
+	}
+	return value, nil
+}
+
+// imageBuilder 镜像构建步骤执行器
+type imageBuilder struct {
+	dockerManager   docker.DockerManager
+	secretResolver  SecretResolver
+	defaultRegistry string
+	logger          *zap.Logger
+}
+
+// newImageBuilder 创建镜像构建步骤执行器
+func newImageBuilder(dockerManager docker.DockerManager, config *ExecutorConfig, logger *zap.Logger) *imageBuilder {
+	resolver := config.SecretResolver
+	if resolver == nil {
+		resolver = NewEnvSecretResolver()
+	}
+
+	return &imageBuilder{
+		dockerManager:   dockerManager,
+		secretResolver:  resolver,
+		defaultRegistry: config.DefaultRegistry,
+		logger:          logger.With(zap.String("component", "image_builder")),
+	}
+}
+
+// Build 执行镜像构建步骤，推送后返回镜像摘要信息
+func (b *imageBuilder) Build(ctx context.Context, job *models.Job, step *models.JobStep, workspaceDir string) (*models.BuiltImage, error) {
+	spec := step.DockerBuild
+	if spec.Repository == "" {
+		return nil, fmt.Errorf("未指定镜像名称")
+	}
+
+	contextDir, err := resolveBuildContext(workspaceDir, spec.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	imageName := spec.ImageName(b.defaultRegistry)
+	tags := models.ResolveImageTags(spec.Tags, models.NewImageTagContext(job.PipelineRun))
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("无法根据标签模板生成镜像标签: %v", spec.Tags)
+	}
+
+	refs := make([]string, len(tags))
+	for i, tag := range tags {
+		refs[i] = imageName + ":" + tag
+	}
+
+	auth, err := b.resolveAuth(ctx, job, spec, imageName)
+	if err != nil {
+		return nil, err
+	}
+
+	buildOptions := &docker.BuildOptions{
+		Dockerfile: spec.Dockerfile,
+		Tags:       refs,
+		BuildArgs:  spec.BuildArgs,
+		Labels:     spec.Labels,
+		Target:     spec.Target,
+		PullParent: true,
+	}
+	if auth != nil {
+		buildOptions.RegistryAuths = []*docker.RegistryAuth{auth}
+	}
+
+	if spec.UseCache() {
+		buildOptions.CacheFrom = b.prepareCache(ctx, spec, refs, auth)
+
+		// 内联缓存元数据随镜像推送，供下次运行复用
+		buildArgs := make(map[string]string, len(spec.BuildArgs)+1)
+		for k, v := range spec.BuildArgs {
+			buildArgs[k] = v
+		}
+		buildArgs["BUILDKIT_INLINE_CACHE"] = "1"
+		buildOptions.BuildArgs = buildArgs
+	} else {
+		buildOptions.NoCache = true
+	}
+
+	b.logger.Info("开始构建镜像",
+		zap.String("job_id", job.ID.String()),
+		zap.String("step", step.Name),
+		zap.Strings("tags", refs))
+
+	buildContext := tarDirectory(contextDir)
+	defer buildContext.Close()
+
+	if _, err := b.dockerManager.BuildImage(ctx, buildContext, buildOptions); err != nil {
+		return nil, err
+	}
+
+	image := &models.BuiltImage{
+		JobID:    job.ID.String(),
+		StepName: step.Name,
+		Image:    imageName,
+		Tags:     tags,
+	}
+
+	if !spec.ShouldPush() {
+		return image, nil
+	}
+
+	for _, ref := range refs {
+		digest, err := b.dockerManager.PushImage(ctx, ref, auth)
+		if err != nil {
+			return nil, err
+		}
+		if image.Digest == "" {
+			image.Digest = digest
+		}
+	}
+	image.PushedAt = time.Now().UTC()
+
+	b.logger.Info("镜像推送完成",
+		zap.String("job_id", job.ID.String()),
+		zap.String("image", imageName),
+		zap.String("digest", image.Digest))
+
+	return image, nil
+}
+
+// resolveAuth 解析仓库凭据，凭据必须在作业secrets中声明
+func (b *imageBuilder) resolveAuth(ctx context.Context, job *models.Job, spec *models.DockerBuildStep, imageName string) (*docker.RegistryAuth, error) {
+	if spec.UsernameSecret == "" && spec.PasswordSecret == "" {
+		return nil, nil
+	}
+	if spec.UsernameSecret == "" || spec.PasswordSecret == "" {
+		return nil, fmt.Errorf("仓库凭据需同时指定username-secret和password-secret")
+	}
+
+	values := make([]string, 2)
+	for i, name := range []string{spec.UsernameSecret, spec.PasswordSecret} {
+		if !containsString(job.Secrets, name) {
+			return nil, fmt.Errorf("密钥 %s 未在作业secrets中声明", name)
+		}
+		value, err := b.secretResolver.ResolveSecret(ctx, job, name)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	return &docker.RegistryAuth{
+		ServerAddress: registryHost(imageName),
+		Username:      values[0],
+		Password:      values[1],
+	}, nil
+}
+
+// prepareCache 拉取可复用的缓存镜像，首次构建时镜像不存在属正常情况
+func (b *imageBuilder) prepareCache(ctx context.Context, spec *models.DockerBuildStep, refs []string, auth *docker.RegistryAuth) []string {
+	candidates := append(append([]string{}, refs...), spec.CacheFrom...)
+
+	var cacheFrom []string
+	for _, ref := range candidates {
+		if err := b.dockerManager.PullImageWithAuth(ctx, ref, auth); err != nil {
+			b.logger.Debug("缓存镜像不可用", zap.String("image", ref), zap.Error(err))
+			continue
+		}
+		cacheFrom = append(cacheFrom, ref)
+	}
+	return cacheFrom
+}
+
+// resolveBuildContext 解析构建上下文目录，禁止越出工作空间
+func resolveBuildContext(workspaceDir, contextPath string) (string, error) {
+	if contextPath == "" {
+		contextPath = "."
+	}
+
+	dir := filepath.Join(workspaceDir, filepath.Clean("/"+contextPath))
+	rel, err := filepath.Rel(workspaceDir, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("构建上下文 %s 超出工作空间", contextPath)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("构建上下文不存在: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("构建上下文 %s 不是目录", contextPath)
+	}
+	return dir, nil
+}
+
+// tarDirectory 将目录打包为tar流
+func tarDirectory(dir string) io.ReadCloser {
+	reader, writer := io.Pipe()
+
+	go func() {
+		tw := tar.NewWriter(writer)
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(dir, path)
+			if err != nil || rel == "." {
+				return err
+			}
+
+			link := ""
+			if info.Mode()&os.ModeSymlink != 0 {
+				if link, err = os.Readlink(path); err != nil {
+					return err
+				}
+			}
+
+			header, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			header.Name = filepath.ToSlash(rel)
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			_, err = io.Copy(tw, file)
+			return err
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		writer.CloseWithError(err)
+	}()
+
+	return reader
+}
+
+// registryHost 从镜像名中提取仓库地址
+func registryHost(imageName string) string {
+	parts := strings.SplitN(imageName, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		return parts[0]
+	}
+	return "docker.io"
+}
+
+// containsString 判断切片是否包含指定字符串
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
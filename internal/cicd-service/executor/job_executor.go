@@ -46,6 +46,12 @@ type JobExecutionStatus struct {
 	ExitCode     *int             `json:"exit_code,omitempty"`
 	ErrorMessage string           `json:"error_message,omitempty"`
 	Resources    *ResourceUsage   `json:"resources,omitempty"`
+
+	// 镜像构建步骤产出的镜像
+	Images []models.BuiltImage `json:"images,omitempty"`
+
+	// 镜像构建步骤把脚本步骤分成多段，每段一个容器；ContainerID为最近一段的容器
+	StepContainers []string `json:"step_containers,omitempty"`
 }
 
 // ResourceUsage 资源使用情况
@@ -80,6 +86,14 @@ type ExecutorConfig struct {
 	// 日志配置
 	LogRetentionDays int  `json:"log_retention_days"`
 	StreamLogs       bool `json:"stream_logs"`
+
+	// 镜像构建配置
+	DefaultRegistry string         `json:"default_registry"` // 步骤未指定registry时推送的仓库
+	SecretResolver  SecretResolver `json:"-"`                // 仓库凭据解析，默认读取环境变量
+	ImageRecorder   ImageRecorder  `json:"-"`                // 记录推送的镜像摘要，为空时只保留在执行状态中
+
+	// 代码检出配置
+	SourceFetcher SourceFetcher `json:"-"` // 下载源码归档，为空时checkout步骤失败
 }
 
 // jobExecutor 作业执行器实现
@@ -87,6 +101,7 @@ type jobExecutor struct {
 	config         *ExecutorConfig
 	dockerManager  docker.DockerManager
 	storageManager storage.StorageManager
+	imageBuilder   *imageBuilder
 	logger         *zap.Logger
 
 	// 执行状态管理
@@ -116,6 +131,7 @@ func NewJobExecutor(
 		config:         config,
 		dockerManager:  dockerManager,
 		storageManager: storageManager,
+		imageBuilder:   newImageBuilder(dockerManager, config, logger),
 		logger:         logger.With(zap.String("component", "job_executor")),
 		executions:     make(map[uuid.UUID]*JobExecutionStatus),
 		semaphore:      make(chan struct{}, config.MaxConcurrentJobs),
@@ -169,7 +185,7 @@ func (je *jobExecutor) ExecuteJob(ctx context.Context, job *models.Job) error {
 	return je.executeJobInternal(execCtx, job, status)
 }
 
-// executeJobInternal 内部作业执行逻辑
+// executeJobInternal 内部作业执行逻辑，步骤按声明顺序执行
 func (je *jobExecutor) executeJobInternal(ctx context.Context, job *models.Job, status *JobExecutionStatus) error {
 	startTime := time.Now()
 	status.StartTime = &startTime
@@ -179,8 +195,78 @@ func (je *jobExecutor) executeJobInternal(ctx context.Context, job *models.Job,
 		status.EndTime = &endTime
 	}()
 
+	segments := splitJobSegments(job.Steps)
+	for i, segment := range segments {
+		if segment.checkout != nil {
+			if err := je.executeCheckoutStep(ctx, job, segment.checkout, status); err != nil {
+				return err
+			}
+			continue
+		}
+		if segment.build != nil {
+			if err := je.executeImageBuildStep(ctx, job, segment.build, status); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := je.executeScriptSegment(ctx, job, segment, i, status); err != nil {
+			return err
+		}
+		if status.Status != models.JobStatusSuccess {
+			return nil
+		}
+		// 后面还有步骤时作业仍在执行
+		if i < len(segments)-1 {
+			status.Status = models.JobStatusRunning
+		}
+	}
+
+	status.Status = models.JobStatusSuccess
+	return nil
+}
+
+// jobSegment 按声明顺序划分的执行段：连续的脚本步骤在同一个容器中执行，代码检出和镜像构建步骤单独执行
+type jobSegment struct {
+	steps    []models.JobStep
+	offset   int // 段内第一个步骤在作业中的序号，用于日志中的步骤编号
+	build    *models.JobStep
+	checkout *models.JobStep
+}
+
+// native 是否为不在作业容器中执行的原生步骤
+func (s jobSegment) native() bool {
+	return s.build != nil || s.checkout != nil
+}
+
+// splitJobSegments 按步骤声明顺序划分执行段，没有步骤时返回一个执行默认脚本的段
+func splitJobSegments(steps []models.JobStep) []jobSegment {
+	var segments []jobSegment
+	for i := range steps {
+		if steps[i].Checkout != nil {
+			segments = append(segments, jobSegment{checkout: &steps[i]})
+			continue
+		}
+		if steps[i].DockerBuild != nil {
+			segments = append(segments, jobSegment{build: &steps[i]})
+			continue
+		}
+		if n := len(segments); n > 0 && !segments[n-1].native() {
+			segments[n-1].steps = append(segments[n-1].steps, steps[i])
+			continue
+		}
+		segments = append(segments, jobSegment{steps: []models.JobStep{steps[i]}, offset: i})
+	}
+	if len(segments) == 0 {
+		segments = append(segments, jobSegment{})
+	}
+	return segments
+}
+
+// executeScriptSegment 在容器中执行一段脚本步骤，各段容器共享作业工作空间
+func (je *jobExecutor) executeScriptSegment(ctx context.Context, job *models.Job, segment jobSegment, index int, status *JobExecutionStatus) error {
 	// 1. 准备执行环境
-	containerConfig, err := je.prepareExecutionEnvironment(job)
+	containerConfig, err := je.prepareExecutionEnvironment(job, segment, index)
 	if err != nil {
 		return je.handleExecutionError(status, fmt.Errorf("准备执行环境失败: %v", err))
 	}
@@ -192,6 +278,7 @@ func (je *jobExecutor) executeJobInternal(ctx context.Context, job *models.Job,
 	}
 
 	status.ContainerID = container.ID
+	status.StepContainers = append(status.StepContainers, container.ID)
 	je.logger.Info("容器创建成功",
 		zap.String("job_id", job.ID.String()),
 		zap.String("container_id", container.ID))
@@ -207,8 +294,36 @@ func (je *jobExecutor) executeJobInternal(ctx context.Context, job *models.Job,
 	return je.monitorContainerExecution(ctx, job, status, container.ID)
 }
 
-// prepareExecutionEnvironment 准备执行环境
-func (je *jobExecutor) prepareExecutionEnvironment(job *models.Job) (*docker.ContainerConfig, error) {
+// executeImageBuildStep 执行镜像构建步骤，推送成功后立即记录镜像摘要
+func (je *jobExecutor) executeImageBuildStep(ctx context.Context, job *models.Job, step *models.JobStep, status *JobExecutionStatus) error {
+	workspaceDir := fmt.Sprintf("/tmp/cicd-workspaces/job-%s", job.ID.String())
+
+	image, err := je.imageBuilder.Build(ctx, job, step, workspaceDir)
+	if err != nil {
+		if step.AllowFailure {
+			je.logger.Warn("镜像构建步骤失败（允许失败）",
+				zap.String("job_id", job.ID.String()),
+				zap.String("step", step.Name),
+				zap.Error(err))
+			return nil
+		}
+		return je.handleExecutionError(status, fmt.Errorf("步骤 %s 镜像构建失败: %w", step.Name, err))
+	}
+
+	status.Images = append(status.Images, *image)
+	if je.config.ImageRecorder != nil {
+		if err := je.config.ImageRecorder.AppendPipelineRunImage(ctx, job.PipelineRunID, image); err != nil {
+			je.logger.Error("记录构建镜像失败",
+				zap.String("job_id", job.ID.String()),
+				zap.String("image", image.Image),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// prepareExecutionEnvironment 准备一段脚本步骤的执行环境
+func (je *jobExecutor) prepareExecutionEnvironment(job *models.Job, segment jobSegment, index int) (*docker.ContainerConfig, error) {
 	// 解析作业配置
 	image := "ubuntu:20.04" // 默认镜像
 	if job.Config != nil {
@@ -218,7 +333,7 @@ func (je *jobExecutor) prepareExecutionEnvironment(job *models.Job) (*docker.Con
 	}
 
 	// 构建执行命令
-	commands := je.buildExecutionCommands(job)
+	commands := je.buildExecutionCommands(job, segment)
 
 	// 设置环境变量
 	env := je.buildEnvironmentVariables(job)
@@ -239,9 +354,15 @@ func (je *jobExecutor) prepareExecutionEnvironment(job *models.Job) (*docker.Con
 		}
 	}
 
+	// 第一段之后的容器名称带段序号
+	name := fmt.Sprintf("job-%s", job.ID.String())
+	if index > 0 {
+		name = fmt.Sprintf("%s-%d", name, index+1)
+	}
+
 	// 构建容器配置
 	config := &docker.ContainerConfig{
-		Name:       fmt.Sprintf("job-%s", job.ID.String()),
+		Name:       name,
 		Image:      strings.Split(image, ":")[0],
 		Tag:        je.extractImageTag(image),
 		Cmd:        commands,
@@ -283,8 +404,8 @@ func (je *jobExecutor) prepareExecutionEnvironment(job *models.Job) (*docker.Con
 	return config, nil
 }
 
-// buildExecutionCommands 构建执行命令
-func (je *jobExecutor) buildExecutionCommands(job *models.Job) []string {
+// buildExecutionCommands 构建一段脚本步骤的执行命令
+func (je *jobExecutor) buildExecutionCommands(job *models.Job, segment jobSegment) []string {
 	var commands []string
 
 	// 基础设置命令
@@ -301,12 +422,13 @@ func (je *jobExecutor) buildExecutionCommands(job *models.Job) []string {
 	}
 
 	// 添加作业步骤
-	if job.Steps != nil && len(job.Steps) > 0 {
-		for i, step := range job.Steps {
+	if len(segment.steps) > 0 {
+		for i, step := range segment.steps {
+			number := segment.offset + i + 1
 			scriptParts = append(scriptParts,
-				fmt.Sprintf("echo '--- 步骤 %d: %s ---'", i+1, step.Name),
+				fmt.Sprintf("echo '--- 步骤 %d: %s ---'", number, step.Name),
 				step.Commands,
-				fmt.Sprintf("echo '步骤 %d 完成'", i+1),
+				fmt.Sprintf("echo '步骤 %d 完成'", number),
 			)
 		}
 	} else {
@@ -526,6 +648,8 @@ func (je *jobExecutor) GetJobStatus(jobID uuid.UUID) (*JobExecutionStatus, error
 		resourcesCopy := *status.Resources
 		statusCopy.Resources = &resourcesCopy
 	}
+	statusCopy.Images = append([]models.BuiltImage(nil), status.Images...)
+	statusCopy.StepContainers = append([]string(nil), status.StepContainers...)
 
 	return &statusCopy, nil
 }
@@ -569,10 +693,10 @@ func (je *jobExecutor) CleanupJob(ctx context.Context, jobID uuid.UUID) error {
 
 	je.logger.Info("清理作业资源", zap.String("job_id", jobID.String()))
 
-	// 清理容器
-	if status.ContainerID != "" {
-		if err := je.dockerManager.RemoveContainer(ctx, status.ContainerID, true); err != nil {
-			je.logger.Error("清理容器失败", zap.Error(err))
+	// 清理各段的容器
+	for _, containerID := range status.StepContainers {
+		if err := je.dockerManager.RemoveContainer(ctx, containerID, true); err != nil {
+			je.logger.Error("清理容器失败", zap.String("container_id", containerID), zap.Error(err))
 		}
 	}
 
@@ -617,15 +741,7 @@ func (je *jobExecutor) HealthCheck(ctx context.Context) error {
 
 // createExecutionContext 创建执行上下文
 func (je *jobExecutor) createExecutionContext(parentCtx context.Context, job *models.Job) (context.Context, context.CancelFunc) {
-	timeout := je.config.DefaultTimeout
-
-	if job.Config != nil {
-		if t, ok := job.Config["timeout"].(float64); ok && t > 0 {
-			timeout = time.Duration(t) * time.Second
-		}
-	}
-
-	return context.WithTimeout(parentCtx, timeout)
+	return context.WithTimeout(parentCtx, job.GetTimeout(je.config.DefaultTimeout))
 }
 
 // extractImageTag 提取镜像标签
@@ -334,6 +334,39 @@ func (h *PipelineHandler) GetPipelineRun(c *gin.Context) {
 	response.Success(c, http.StatusOK, "获取成功", run)
 }
 
+// GetPipelineRunChain 获取流水线运行的上下游触发链
+// @Summary 获取流水线触发链
+// @Description 获取运行所在的完整触发链，包括根运行及其全部下游运行，通过parent_run_id组成树
+// @Tags pipeline-runs
+// @Produce json
+// @Param id path string true "运行ID"
+// @Success 200 {object} response.Response{data=models.PipelineRunChain}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/pipeline-runs/{id}/chain [get]
+func (h *PipelineHandler) GetPipelineRunChain(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的运行ID", err)
+		return
+	}
+
+	chain, err := h.service.GetPipelineRunChain(c.Request.Context(), id)
+	if err != nil {
+		if err == service.ErrPipelineRunNotFound {
+			response.Error(c, http.StatusNotFound, "流水线运行不存在", err)
+			return
+		}
+		h.logger.Error("获取流水线触发链失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "获取流水线触发链失败", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "获取成功", chain)
+}
+
 // GetPipelineRuns 获取流水线运行列表
 // @Summary 获取流水线运行列表
 // @Description 根据流水线ID分页获取运行列表
@@ -521,6 +554,34 @@ func (h *PipelineHandler) GetJobs(c *gin.Context) {
 	response.Success(c, http.StatusOK, "获取成功", jobs)
 }
 
+// GetJobAttempts 获取作业执行尝试
+// @Summary 获取作业执行尝试
+// @Description 获取作业每次执行（含重试）的状态、失败原因、耗时与日志路径
+// @Tags jobs
+// @Produce json
+// @Param id path string true "作业ID"
+// @Success 200 {object} response.Response{data=[]models.JobExecution}
+// @Failure 400 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/jobs/{id}/attempts [get]
+func (h *PipelineHandler) GetJobAttempts(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的作业ID", err)
+		return
+	}
+
+	attempts, err := h.service.GetJobAttempts(c.Request.Context(), id)
+	if err != nil {
+		h.logger.Error("获取作业执行记录失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "获取作业执行记录失败", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "获取成功", attempts)
+}
+
 // 执行器管理接口
 
 // RegisterRunner 注册执行器
@@ -790,6 +851,75 @@ func (h *PipelineHandler) GetPipelineStats(c *gin.Context) {
 	response.Success(c, http.StatusOK, "获取成功", stats)
 }
 
+// GetPipelineAnalytics 获取流水线分析数据
+// @Summary 获取流水线分析数据
+// @Description 获取流水线及各作业的排队/执行耗时P50/P95、按天成功率、失败最多的作业和步骤以及执行器利用率
+// @Tags statistics
+// @Produce json
+// @Param id path string true "流水线ID"
+// @Param days query int false "统计天数" default(30)
+// @Success 200 {object} response.Response{data=models.PipelineAnalytics}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/pipelines/{id}/analytics [get]
+func (h *PipelineHandler) GetPipelineAnalytics(c *gin.Context) {
+	idStr := c.Param("id")
+	pipelineID, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的流水线ID", err)
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	analytics, err := h.service.GetPipelineAnalytics(c.Request.Context(), pipelineID, days)
+	if err != nil {
+		if err == service.ErrPipelineNotFound {
+			response.Error(c, http.StatusNotFound, "流水线不存在", err)
+			return
+		}
+		h.logger.Error("获取流水线分析数据失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "获取流水线分析数据失败", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "获取成功", analytics)
+}
+
+// GetPipelineRunCriticalPath 获取流水线运行的关键路径
+// @Summary 获取流水线运行关键路径
+// @Description 根据作业依赖图计算决定总耗时的作业链，并给出每个作业的松弛时间
+// @Tags statistics
+// @Produce json
+// @Param id path string true "运行ID"
+// @Success 200 {object} response.Response{data=models.CriticalPath}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/pipeline-runs/{id}/critical-path [get]
+func (h *PipelineHandler) GetPipelineRunCriticalPath(c *gin.Context) {
+	idStr := c.Param("id")
+	runID, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "无效的运行ID", err)
+		return
+	}
+
+	path, err := h.service.GetPipelineRunCriticalPath(c.Request.Context(), runID)
+	if err != nil {
+		if err == service.ErrPipelineRunNotFound {
+			response.Error(c, http.StatusNotFound, "流水线运行不存在", err)
+			return
+		}
+		h.logger.Error("计算关键路径失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "计算关键路径失败", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "获取成功", path)
+}
+
 // GetRunnerStats 获取执行器统计信息
 // @Summary 获取执行器统计信息
 // @Description 获取执行器的运行统计信息
@@ -0,0 +1,28 @@
+package models
+
+import "strings"
+
+// StepUsesCheckout 原生代码检出步骤标识（steps[].uses），同时兼容 actions/checkout@<版本>
+const StepUsesCheckout = "checkout"
+
+// CheckoutStep 代码检出步骤配置：从Git网关下载仓库归档并解压到工作空间，执行器无需git客户端和仓库凭据
+type CheckoutStep struct {
+	Ref  string `json:"ref,omitempty" yaml:"ref"`   // 检出的分支、标签或提交，默认为流水线运行的提交
+	Path string `json:"path,omitempty" yaml:"path"` // 解压到工作空间下的目录，默认为工作空间根目录
+}
+
+// IsCheckoutAction 判断steps[].uses是否为代码检出步骤
+func IsCheckoutAction(uses string) bool {
+	return uses == StepUsesCheckout || uses == "actions/checkout" || strings.HasPrefix(uses, "actions/checkout@")
+}
+
+// CheckoutRef 获取检出的引用，未指定时使用流水线运行的提交
+func (s *CheckoutStep) CheckoutRef(run *PipelineRun) string {
+	if s.Ref != "" {
+		return s.Ref
+	}
+	if run == nil {
+		return ""
+	}
+	return run.CommitSHA
+}
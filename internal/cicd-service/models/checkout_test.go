@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsCheckoutAction(t *testing.T) {
+	assert.True(t, IsCheckoutAction("checkout"))
+	assert.True(t, IsCheckoutAction("actions/checkout"))
+	assert.True(t, IsCheckoutAction("actions/checkout@v4"))
+	assert.False(t, IsCheckoutAction("actions/setup-node@v4"))
+	assert.False(t, IsCheckoutAction(StepUsesDockerBuild))
+}
+
+func TestCheckoutRef(t *testing.T) {
+	run := &PipelineRun{CommitSHA: "0123456789abcdef0123456789abcdef01234567"}
+
+	assert.Equal(t, run.CommitSHA, (&CheckoutStep{}).CheckoutRef(run))
+	assert.Equal(t, "v1.2.0", (&CheckoutStep{Ref: "v1.2.0"}).CheckoutRef(run))
+	assert.Empty(t, (&CheckoutStep{}).CheckoutRef(nil))
+}
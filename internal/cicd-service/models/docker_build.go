@@ -0,0 +1,191 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// StepUsesDockerBuild 原生镜像构建步骤标识（steps[].uses）
+const StepUsesDockerBuild = "docker-build"
+
+// 默认镜像标签模板
+var DefaultImageTagTemplates = []string{"{{short_sha}}", "{{branch}}"}
+
+// DockerBuildStep 镜像构建并推送步骤配置
+type DockerBuildStep struct {
+	Context    string            `json:"context,omitempty" yaml:"context"`       // 构建上下文目录（相对工作空间），默认"."
+	Dockerfile string            `json:"dockerfile,omitempty" yaml:"dockerfile"` // Dockerfile路径（相对构建上下文），默认"Dockerfile"
+	Target     string            `json:"target,omitempty" yaml:"target"`         // 多阶段构建目标
+	BuildArgs  map[string]string `json:"build_args,omitempty" yaml:"build-args"` // 构建参数
+	Labels     map[string]string `json:"labels,omitempty" yaml:"labels"`         // 镜像标签（label）
+
+	// 镜像命名
+	Registry   string   `json:"registry,omitempty" yaml:"registry"`     // 镜像仓库地址，为空时使用执行器默认仓库
+	Repository string   `json:"repository" yaml:"repository"`           // 镜像名称，如 team/service
+	Tags       []string `json:"tags,omitempty" yaml:"tags"`             // 标签模板，支持{{branch}}、{{sha}}、{{short_sha}}、{{tag}}、{{semver}}、{{semver.minor}}、{{semver.major}}
+	Push       *bool    `json:"push,omitempty" yaml:"push"`             // 是否推送，默认推送
+	Cache      *bool    `json:"cache,omitempty" yaml:"cache"`           // 是否复用上次构建的镜像层，默认开启
+	CacheFrom  []string `json:"cache_from,omitempty" yaml:"cache-from"` // 额外的缓存镜像
+
+	// 仓库凭据（引用作业声明的密钥名称，不直接写明文）
+	UsernameSecret string `json:"username_secret,omitempty" yaml:"username-secret"`
+	PasswordSecret string `json:"password_secret,omitempty" yaml:"password-secret"`
+}
+
+// ShouldPush 是否推送镜像
+func (s *DockerBuildStep) ShouldPush() bool {
+	return s.Push == nil || *s.Push
+}
+
+// UseCache 是否启用层缓存
+func (s *DockerBuildStep) UseCache() bool {
+	return s.Cache == nil || *s.Cache
+}
+
+// ImageName 获取不含标签的完整镜像名
+func (s *DockerBuildStep) ImageName(defaultRegistry string) string {
+	registry := s.Registry
+	if registry == "" {
+		registry = defaultRegistry
+	}
+	registry = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://"), "/")
+
+	if registry == "" {
+		return s.Repository
+	}
+	return registry + "/" + s.Repository
+}
+
+// BuiltImage 流水线构建并推送的镜像
+type BuiltImage struct {
+	JobID    string    `json:"job_id"`
+	StepName string    `json:"step_name"`
+	Image    string    `json:"image"`  // 不含标签的镜像名
+	Tags     []string  `json:"tags"`   // 推送的标签
+	Digest   string    `json:"digest"` // 内容摘要，如 sha256:...
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// Reference 获取不可变的摘要引用（image@sha256:...）
+func (b *BuiltImage) Reference() string {
+	if b.Digest == "" {
+		return ""
+	}
+	return b.Image + "@" + b.Digest
+}
+
+// ImageTagContext 标签模板渲染上下文
+type ImageTagContext struct {
+	Branch    string
+	CommitSHA string
+	GitTag    string
+	RunID     string
+}
+
+var (
+	invalidTagChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+	semverPattern   = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+)
+
+// NewImageTagContext 从流水线运行构建标签上下文
+func NewImageTagContext(run *PipelineRun) ImageTagContext {
+	tagCtx := ImageTagContext{}
+	if run == nil {
+		return tagCtx
+	}
+
+	tagCtx.CommitSHA = run.CommitSHA
+	tagCtx.RunID = run.ID.String()
+	if run.Branch != nil {
+		tagCtx.Branch = *run.Branch
+	}
+	if tag, ok := run.Variables["TAG_NAME"]; ok {
+		tagCtx.GitTag = tag
+	}
+	if strings.HasPrefix(tagCtx.Branch, "refs/tags/") {
+		tagCtx.GitTag = strings.TrimPrefix(tagCtx.Branch, "refs/tags/")
+		tagCtx.Branch = ""
+	}
+	tagCtx.Branch = strings.TrimPrefix(tagCtx.Branch, "refs/heads/")
+
+	return tagCtx
+}
+
+// ResolveImageTags 渲染标签模板
+// 引用了当前上下文中不存在的值（如非标签构建中的{{semver}}）的模板会被忽略，结果去重
+func ResolveImageTags(templates []string, tagCtx ImageTagContext) []string {
+	if len(templates) == 0 {
+		templates = DefaultImageTagTemplates
+	}
+
+	values := map[string]string{
+		"branch": tagCtx.Branch,
+		"sha":    tagCtx.CommitSHA,
+		"tag":    tagCtx.GitTag,
+		"run_id": tagCtx.RunID,
+	}
+	if len(tagCtx.CommitSHA) >= 8 {
+		values["short_sha"] = tagCtx.CommitSHA[:8]
+	} else {
+		values["short_sha"] = tagCtx.CommitSHA
+	}
+	if m := semverPattern.FindStringSubmatch(tagCtx.GitTag); m != nil {
+		values["semver"] = strings.TrimPrefix(tagCtx.GitTag, "v")
+		values["semver.major"] = m[1]
+		values["semver.minor"] = m[1] + "." + m[2]
+		if m[4] != "" {
+			// 预发布版本不覆盖主/次版本标签
+			values["semver.major"] = ""
+			values["semver.minor"] = ""
+		}
+	}
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, template := range templates {
+		tag, ok := renderTagTemplate(template, values)
+		if !ok || tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// renderTagTemplate 渲染单个标签模板，任一占位符为空时返回false
+func renderTagTemplate(template string, values map[string]string) (string, bool) {
+	result := template
+	for {
+		start := strings.Index(result, "{{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(result[start:], "}}")
+		if end < 0 {
+			return "", false
+		}
+		end += start
+
+		key := strings.TrimSpace(result[start+2 : end])
+		value := values[key]
+		if value == "" {
+			return "", false
+		}
+		result = result[:start] + value + result[end+2:]
+	}
+
+	return SanitizeImageTag(result), true
+}
+
+// SanitizeImageTag 将任意字符串转换为合法的镜像标签
+func SanitizeImageTag(tag string) string {
+	tag = invalidTagChars.ReplaceAllString(tag, "-")
+	tag = strings.TrimLeft(tag, ".-")
+	if len(tag) > 128 {
+		tag = tag[:128]
+	}
+	return tag
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveImageTags(t *testing.T) {
+	branchCtx := ImageTagContext{Branch: "feature/login-page", CommitSHA: "0123456789abcdef0123456789abcdef01234567"}
+	releaseCtx := ImageTagContext{GitTag: "v1.4.2", CommitSHA: "0123456789abcdef0123456789abcdef01234567"}
+
+	tests := []struct {
+		name      string
+		templates []string
+		ctx       ImageTagContext
+		expected  []string
+	}{
+		{"默认模板", nil, branchCtx, []string{"01234567", "feature-login-page"}},
+		{"完整SHA", []string{"{{sha}}"}, branchCtx, []string{"0123456789abcdef0123456789abcdef01234567"}},
+		{"组合模板", []string{"{{branch}}-{{short_sha}}"}, branchCtx, []string{"feature-login-page-01234567"}},
+		{"分支构建忽略semver", []string{"{{semver}}", "{{branch}}"}, branchCtx, []string{"feature-login-page"}},
+		{"语义化版本标签", []string{"{{semver}}", "{{semver.minor}}", "{{semver.major}}", "latest"}, releaseCtx, []string{"1.4.2", "1.4", "1", "latest"}},
+		{"预发布版本不生成主版本标签", []string{"{{semver}}", "{{semver.major}}"}, ImageTagContext{GitTag: "v2.0.0-rc.1"}, []string{"2.0.0-rc.1"}},
+		{"非语义化标签", []string{"{{tag}}", "{{semver}}"}, ImageTagContext{GitTag: "nightly"}, []string{"nightly"}},
+		{"结果去重", []string{"{{branch}}", "main"}, ImageTagContext{Branch: "main"}, []string{"main"}},
+		{"未闭合占位符被忽略", []string{"{{branch", "{{branch}}"}, ImageTagContext{Branch: "main"}, []string{"main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ResolveImageTags(tt.templates, tt.ctx))
+		})
+	}
+}
+
+func TestNewImageTagContext(t *testing.T) {
+	branch := "refs/heads/main"
+	run := &PipelineRun{ID: uuid.New(), CommitSHA: "abc123", Branch: &branch}
+	tagCtx := NewImageTagContext(run)
+	assert.Equal(t, "main", tagCtx.Branch)
+	assert.Equal(t, "", tagCtx.GitTag)
+
+	tagRef := "refs/tags/v1.0.0"
+	run.Branch = &tagRef
+	tagCtx = NewImageTagContext(run)
+	assert.Equal(t, "", tagCtx.Branch)
+	assert.Equal(t, "v1.0.0", tagCtx.GitTag)
+
+	run.Branch = nil
+	run.Variables = map[string]string{"TAG_NAME": "v2.1.0"}
+	assert.Equal(t, "v2.1.0", NewImageTagContext(run).GitTag)
+}
+
+func TestDockerBuildStep_ImageName(t *testing.T) {
+	step := &DockerBuildStep{Repository: "team/api"}
+	assert.Equal(t, "team/api", step.ImageName(""))
+	assert.Equal(t, "registry.example.com/team/api", step.ImageName("https://registry.example.com/"))
+
+	step.Registry = "ghcr.io"
+	assert.Equal(t, "ghcr.io/team/api", step.ImageName("registry.example.com"))
+}
+
+func TestSanitizeImageTag(t *testing.T) {
+	assert.Equal(t, "feature-a-b", SanitizeImageTag("feature/a b"))
+	assert.Equal(t, "release", SanitizeImageTag("--release"))
+	assert.Len(t, SanitizeImageTag(strings.Repeat("a", 200)), 128)
+}
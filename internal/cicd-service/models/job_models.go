@@ -28,6 +28,7 @@ const (
 	JobTypeDeploy  JobType = "deploy"
 	JobTypeScript  JobType = "script"
 	JobTypeCleanup JobType = "cleanup"
+	JobTypeTrigger JobType = "trigger" // 触发下游流水线，不占用执行器
 )
 
 // JobPriority 作业优先级枚举
@@ -81,13 +82,20 @@ type Job struct {
 	ErrorMessage string         `json:"error_message"`
 
 	// 重试信息
-	RetryCount int `json:"retry_count" gorm:"default:0"`
-	MaxRetries int `json:"max_retries" gorm:"default:3"`
+	RetryCount    int           `json:"retry_count" gorm:"default:0"`
+	MaxRetries    int           `json:"max_retries" gorm:"default:3"`
+	RetryPolicy   *RetryPolicy  `json:"retry_policy,omitempty" gorm:"type:jsonb"`
+	FailureReason FailureReason `json:"failure_reason,omitempty" gorm:"size:50"`
+	FailedStep    string        `json:"failed_step,omitempty" gorm:"size:255"` // 导致失败的步骤名称
+	NextRetryAt   *time.Time    `json:"next_retry_at"`
 
 	// 日志和输出
 	LogPath       string   `json:"log_path"`
 	ArtifactPaths []string `json:"artifact_paths" gorm:"type:jsonb"`
 
+	// 每次执行尝试的记录（含独立日志与耗时）
+	Attempts []JobExecution `json:"attempts,omitempty" gorm:"foreignKey:JobID"`
+
 	// 审计字段
 	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
@@ -104,6 +112,12 @@ type JobStep struct {
 	Timeout      *time.Duration    `json:"timeout,omitempty"`
 	AllowFailure bool              `json:"allow_failure,omitempty"`
 	When         string            `json:"when,omitempty"` // always, on_success, on_failure, manual
+
+	// 原生镜像构建步骤（设置后忽略Commands）
+	DockerBuild *DockerBuildStep `json:"docker_build,omitempty"`
+
+	// 原生代码检出步骤（设置后忽略Commands）
+	Checkout *CheckoutStep `json:"checkout,omitempty"`
 }
 
 // JobRequirements 作业资源要求
@@ -160,6 +174,29 @@ func (j *Job) IsRetryable() bool {
 	return j.Status == JobStatusFailed && j.RetryCount < j.MaxRetries
 }
 
+// GetRetryPolicy 获取作业的重试策略，未配置时按MaxRetries生成默认策略
+func (j *Job) GetRetryPolicy() *RetryPolicy {
+	if j.RetryPolicy != nil {
+		return j.RetryPolicy
+	}
+	return DefaultRetryPolicy(j.MaxRetries)
+}
+
+// GetTimeout 获取作业的超时时间（Config中的timeout，单位秒），未配置时返回defaultTimeout
+func (j *Job) GetTimeout(defaultTimeout time.Duration) time.Duration {
+	switch t := j.Config["timeout"].(type) {
+	case float64:
+		if t > 0 {
+			return time.Duration(t * float64(time.Second))
+		}
+	case int:
+		if t > 0 {
+			return time.Duration(t) * time.Second
+		}
+	}
+	return defaultTimeout
+}
+
 // CanStart 判断作业是否可以开始执行
 func (j *Job) CanStart() bool {
 	return j.Status == JobStatusPending
@@ -211,9 +248,14 @@ type JobExecution struct {
 	RunnerID uuid.UUID `json:"runner_id" gorm:"type:uuid;not null;index"`
 	Runner   *Runner   `json:"runner,omitempty" gorm:"foreignKey:RunnerID"`
 
-	Status       JobStatus `json:"status" gorm:"not null"`
-	ExitCode     *int      `json:"exit_code"`
-	ErrorMessage string    `json:"error_message"`
+	// 第几次执行（从1开始，重试时递增）
+	Attempt int `json:"attempt" gorm:"not null;default:1"`
+
+	Status        JobStatus     `json:"status" gorm:"not null"`
+	FailureReason FailureReason `json:"failure_reason,omitempty" gorm:"size:50"`
+	FailedStep    string        `json:"failed_step,omitempty" gorm:"size:255"`
+	ExitCode      *int          `json:"exit_code"`
+	ErrorMessage  string        `json:"error_message"`
 
 	StartedAt  time.Time     `json:"started_at" gorm:"not null"`
 	FinishedAt *time.Time    `json:"finished_at"`
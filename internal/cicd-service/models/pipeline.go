@@ -59,12 +59,19 @@ type PipelineRun struct {
 	FinishedAt  *time.Time        `json:"finished_at"`
 	Duration    *int64            `json:"duration"` // 持续时间（秒）
 	Variables   map[string]string `json:"variables" gorm:"type:jsonb"`
+	BuiltImages []BuiltImage      `json:"built_images,omitempty" gorm:"type:jsonb"`
 	CreatedAt   time.Time         `json:"created_at" gorm:"not null;default:now()"`
 
+	// 上下游关系（由trigger作业触发时设置）
+	ParentRunID       *uuid.UUID         `json:"parent_run_id,omitempty" gorm:"type:uuid;index"`
+	ParentJobID       *uuid.UUID         `json:"parent_job_id,omitempty" gorm:"type:uuid"`
+	UpstreamArtifacts []UpstreamArtifact `json:"upstream_artifacts,omitempty" gorm:"type:jsonb"`
+
 	// 关联关系
-	Pipeline    *Pipeline `json:"pipeline,omitempty" gorm:"foreignKey:PipelineID"`
-	TriggerUser *User     `json:"trigger_user,omitempty" gorm:"foreignKey:TriggerBy"`
-	Jobs        []Job     `json:"jobs,omitempty" gorm:"foreignKey:PipelineRunID"`
+	Pipeline    *Pipeline     `json:"pipeline,omitempty" gorm:"foreignKey:PipelineID"`
+	TriggerUser *User         `json:"trigger_user,omitempty" gorm:"foreignKey:TriggerBy"`
+	Jobs        []Job         `json:"jobs,omitempty" gorm:"foreignKey:PipelineRunID"`
+	ChildRuns   []PipelineRun `json:"child_runs,omitempty" gorm:"foreignKey:ParentRunID"`
 }
 
 // Runner 执行器模型
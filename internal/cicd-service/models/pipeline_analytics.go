@@ -0,0 +1,233 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobGraphCycle 作业依赖存在循环，无法计算关键路径
+var ErrJobGraphCycle = errors.New("作业依赖存在循环")
+
+// DurationPercentiles 耗时分位数统计（秒）
+type DurationPercentiles struct {
+	Samples int64   `json:"samples"`
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	Avg     float64 `json:"avg"`
+}
+
+// SuccessRatePoint 按天统计的成功率
+// 成功率只统计已结束且未取消的运行（成功+失败）
+type SuccessRatePoint struct {
+	Date           time.Time `json:"date"`
+	TotalRuns      int64     `json:"total_runs"`
+	SuccessfulRuns int64     `json:"successful_runs"`
+	FailedRuns     int64     `json:"failed_runs"`
+	SuccessRate    float64   `json:"success_rate"`
+}
+
+// JobAnalytics 按作业名称聚合的统计
+type JobAnalytics struct {
+	JobName     string              `json:"job_name"`
+	Runs        int64               `json:"runs"`
+	Failures    int64               `json:"failures"`
+	FailureRate float64             `json:"failure_rate"`
+	QueueTime   DurationPercentiles `json:"queue_time"`
+	Duration    DurationPercentiles `json:"duration"`
+}
+
+// FailingStep 失败次数较多的步骤
+type FailingStep struct {
+	JobName  string `json:"job_name"`
+	StepName string `json:"step_name"`
+	Failures int64  `json:"failures"`
+}
+
+// RunnerUtilization 执行器在统计窗口内的利用率
+type RunnerUtilization struct {
+	RunnerID            uuid.UUID `json:"runner_id"`
+	RunnerName          string    `json:"runner_name"`
+	Jobs                int64     `json:"jobs"`
+	BusySeconds         float64   `json:"busy_seconds"`
+	PipelineBusySeconds float64   `json:"pipeline_busy_seconds"` // 其中用于本流水线的时间
+	Utilization         float64   `json:"utilization"`           // 忙碌时间占窗口的百分比
+}
+
+// PipelineAnalytics 流水线分析数据
+type PipelineAnalytics struct {
+	PipelineID   uuid.UUID           `json:"pipeline_id"`
+	From         time.Time           `json:"from"`
+	To           time.Time           `json:"to"`
+	QueueTime    DurationPercentiles `json:"queue_time"`
+	Duration     DurationPercentiles `json:"duration"`
+	SuccessTrend []SuccessRatePoint  `json:"success_trend"`
+	Jobs         []JobAnalytics      `json:"jobs"`
+	FailingJobs  []JobAnalytics      `json:"failing_jobs"`
+	FailingSteps []FailingStep       `json:"failing_steps"`
+	Runners      []RunnerUtilization `json:"runners"`
+}
+
+// CriticalPathJob 关键路径分析中的作业（时间均为相对运行开始的秒数）
+type CriticalPathJob struct {
+	JobID          uuid.UUID   `json:"job_id"`
+	Name           string      `json:"name"`
+	Status         JobStatus   `json:"status"`
+	DependsOn      []uuid.UUID `json:"depends_on"`
+	QueueSeconds   float64     `json:"queue_seconds"`
+	RunSeconds     float64     `json:"run_seconds"`
+	EarliestStart  float64     `json:"earliest_start"`
+	EarliestFinish float64     `json:"earliest_finish"`
+	Slack          float64     `json:"slack"` // 不影响总耗时的可延长时间，关键路径上为0
+	Critical       bool        `json:"critical"`
+}
+
+// CriticalPath 流水线运行的关键路径
+type CriticalPath struct {
+	RunID        uuid.UUID         `json:"run_id"`
+	TotalSeconds float64           `json:"total_seconds"`
+	Path         []CriticalPathJob `json:"path"` // 按执行顺序排列
+	Jobs         []CriticalPathJob `json:"jobs"` // 全部作业（按拓扑序）
+}
+
+// ComputeCriticalPath 计算作业依赖图的关键路径
+//
+// 每个作业的权重为排队时间加执行时间；运行中的作业按当前时间计算，未开始的作业权重为0。
+// 关键路径从最晚完成的作业开始，沿最晚完成的依赖回溯，优化其中任一作业都能缩短总耗时。
+func ComputeCriticalPath(runID uuid.UUID, jobs []Job, now time.Time) (*CriticalPath, error) {
+	nodes := make(map[uuid.UUID]*CriticalPathJob, len(jobs))
+	successors := make(map[uuid.UUID][]uuid.UUID, len(jobs))
+	inDegree := make(map[uuid.UUID]int, len(jobs))
+
+	ordered := make([]Job, len(jobs))
+	copy(ordered, jobs)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if !ordered[i].CreatedAt.Equal(ordered[j].CreatedAt) {
+			return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	for _, job := range ordered {
+		node := &CriticalPathJob{
+			JobID:     job.ID,
+			Name:      job.Name,
+			Status:    job.Status,
+			DependsOn: []uuid.UUID{},
+		}
+		if job.StartedAt != nil {
+			node.QueueSeconds = positiveSeconds(job.StartedAt.Sub(job.CreatedAt))
+			finishedAt := now
+			if job.FinishedAt != nil {
+				finishedAt = *job.FinishedAt
+			}
+			node.RunSeconds = positiveSeconds(finishedAt.Sub(*job.StartedAt))
+		}
+		nodes[job.ID] = node
+	}
+
+	// 忽略不属于本次运行的依赖
+	for _, job := range ordered {
+		for _, dep := range job.Dependencies {
+			if _, ok := nodes[dep]; !ok || dep == job.ID {
+				continue
+			}
+			nodes[job.ID].DependsOn = append(nodes[job.ID].DependsOn, dep)
+			successors[dep] = append(successors[dep], job.ID)
+			inDegree[job.ID]++
+		}
+	}
+
+	// Kahn拓扑排序并计算最早开始/完成时间
+	queue := make([]uuid.UUID, 0, len(ordered))
+	for _, job := range ordered {
+		if inDegree[job.ID] == 0 {
+			queue = append(queue, job.ID)
+		}
+	}
+
+	topo := make([]uuid.UUID, 0, len(ordered))
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		topo = append(topo, id)
+
+		node := nodes[id]
+		for _, dep := range node.DependsOn {
+			if nodes[dep].EarliestFinish > node.EarliestStart {
+				node.EarliestStart = nodes[dep].EarliestFinish
+			}
+		}
+		node.EarliestFinish = node.EarliestStart + node.QueueSeconds + node.RunSeconds
+
+		for _, succ := range successors[id] {
+			inDegree[succ]--
+			if inDegree[succ] == 0 {
+				queue = append(queue, succ)
+			}
+		}
+	}
+	if len(topo) != len(ordered) {
+		return nil, ErrJobGraphCycle
+	}
+
+	result := &CriticalPath{RunID: runID, Path: []CriticalPathJob{}, Jobs: make([]CriticalPathJob, 0, len(topo))}
+	var last *CriticalPathJob
+	for _, id := range topo {
+		if node := nodes[id]; last == nil || node.EarliestFinish >= last.EarliestFinish {
+			last = node
+		}
+	}
+	if last == nil {
+		return result, nil
+	}
+	result.TotalSeconds = last.EarliestFinish
+
+	// 反向计算最晚完成时间得到松弛时间
+	latestFinish := make(map[uuid.UUID]float64, len(topo))
+	for i := len(topo) - 1; i >= 0; i-- {
+		id := topo[i]
+		lf := result.TotalSeconds
+		for _, succ := range successors[id] {
+			node := nodes[succ]
+			if start := latestFinish[succ] - node.QueueSeconds - node.RunSeconds; start < lf {
+				lf = start
+			}
+		}
+		latestFinish[id] = lf
+		nodes[id].Slack = lf - nodes[id].EarliestFinish
+	}
+
+	// 从最晚完成的作业（并列时取拓扑序靠后者）沿最晚完成的依赖回溯
+	var path []CriticalPathJob
+	for node := last; node != nil; {
+		node.Critical = true
+		path = append(path, *node)
+
+		var gate *CriticalPathJob
+		for _, dep := range node.DependsOn {
+			if gate == nil || nodes[dep].EarliestFinish > gate.EarliestFinish {
+				gate = nodes[dep]
+			}
+		}
+		node = gate
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		result.Path = append(result.Path, path[i])
+	}
+
+	for _, id := range topo {
+		result.Jobs = append(result.Jobs, *nodes[id])
+	}
+	return result, nil
+}
+
+// positiveSeconds 转换为秒，负数（时钟偏差）按0处理
+func positiveSeconds(d time.Duration) float64 {
+	if d < 0 {
+		return 0
+	}
+	return d.Seconds()
+}
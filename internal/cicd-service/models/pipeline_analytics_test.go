@@ -0,0 +1,82 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// analyticsJob 构造已完成的作业：created为相对基准的秒数，queue/run为排队与执行秒数
+func analyticsJob(name string, created, queue, run int, deps ...uuid.UUID) Job {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := base.Add(time.Duration(created) * time.Second)
+	startedAt := createdAt.Add(time.Duration(queue) * time.Second)
+	finishedAt := startedAt.Add(time.Duration(run) * time.Second)
+	return Job{
+		ID:           uuid.New(),
+		Name:         name,
+		Status:       JobStatusSuccess,
+		Dependencies: deps,
+		CreatedAt:    createdAt,
+		StartedAt:    &startedAt,
+		FinishedAt:   &finishedAt,
+	}
+}
+
+func TestComputeCriticalPath(t *testing.T) {
+	// build -> (unit, lint) -> deploy，unit耗时最长
+	build := analyticsJob("build", 0, 5, 60)
+	unit := analyticsJob("unit", 65, 10, 120, build.ID)
+	lint := analyticsJob("lint", 65, 2, 20, build.ID)
+	deploy := analyticsJob("deploy", 195, 3, 30, unit.ID, lint.ID)
+
+	path, err := ComputeCriticalPath(uuid.New(), []Job{deploy, lint, build, unit}, time.Now())
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(path.Path))
+	for _, job := range path.Path {
+		names = append(names, job.Name)
+		assert.InDelta(t, 0, job.Slack, 0.001)
+	}
+	assert.Equal(t, []string{"build", "unit", "deploy"}, names)
+	assert.InDelta(t, 65+130+33, path.TotalSeconds, 0.001)
+
+	require.Len(t, path.Jobs, 4)
+	for _, job := range path.Jobs {
+		if job.Name == "lint" {
+			assert.False(t, job.Critical)
+			assert.InDelta(t, 130-22, job.Slack, 0.001)
+		}
+	}
+}
+
+func TestComputeCriticalPathRunningAndPending(t *testing.T) {
+	now := time.Now().UTC()
+	startedAt := now.Add(-90 * time.Second)
+	running := Job{ID: uuid.New(), Name: "test", Status: JobStatusRunning, CreatedAt: startedAt, StartedAt: &startedAt}
+	pending := Job{ID: uuid.New(), Name: "deploy", Status: JobStatusPending, CreatedAt: now, Dependencies: []uuid.UUID{running.ID, uuid.New()}}
+
+	path, err := ComputeCriticalPath(uuid.New(), []Job{running, pending}, now)
+	require.NoError(t, err)
+	assert.InDelta(t, 90, path.TotalSeconds, 0.001)
+	require.Len(t, path.Path, 2)
+	assert.Equal(t, "test", path.Path[0].Name)
+	// 不属于本次运行的依赖被忽略
+	assert.Equal(t, []uuid.UUID{running.ID}, path.Path[1].DependsOn)
+}
+
+func TestComputeCriticalPathCycle(t *testing.T) {
+	a := analyticsJob("a", 0, 0, 10)
+	b := analyticsJob("b", 10, 0, 10, a.ID)
+	a.Dependencies = []uuid.UUID{b.ID}
+
+	_, err := ComputeCriticalPath(uuid.New(), []Job{a, b}, time.Now())
+	assert.ErrorIs(t, err, ErrJobGraphCycle)
+
+	empty, err := ComputeCriticalPath(uuid.New(), nil, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, empty.Path)
+}
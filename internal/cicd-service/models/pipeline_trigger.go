@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// TriggerTypePipeline 由上游流水线的trigger作业触发
+const TriggerTypePipeline TriggerType = "pipeline"
+
+// 下游触发策略
+const (
+	TriggerStrategyAsync  = ""       // 触发后立即返回，不关心下游结果
+	TriggerStrategyDepend = "depend" // 等待下游完成并同步其结果
+)
+
+// MaxTriggerDepth 流水线触发链的最大深度（根流水线深度为1）
+const MaxTriggerDepth = 10
+
+// 下游触发错误
+var (
+	ErrTriggerCycle         = errors.New("流水线触发链存在循环")
+	ErrTriggerDepthExceeded = errors.New("流水线触发链超过最大深度")
+	ErrTriggerForbidden     = errors.New("无权触发目标流水线")
+)
+
+// TriggerJobConfig trigger作业配置，在其他仓库/项目中启动流水线
+type TriggerJobConfig struct {
+	Project    string            `json:"project,omitempty" yaml:"project"`             // 目标项目ID，为空时使用当前项目
+	Repository string            `json:"repository" yaml:"repository"`                 // 目标仓库ID或项目内的仓库名称
+	Pipeline   string            `json:"pipeline,omitempty" yaml:"pipeline"`           // 目标流水线名称，为空时使用仓库中第一条启用的流水线
+	Branch     string            `json:"branch,omitempty" yaml:"branch"`               // 目标分支，为空时使用仓库默认分支
+	Variables  map[string]string `json:"variables,omitempty" yaml:"variables"`         // 传递给下游的变量
+	Artifacts  []string          `json:"artifacts,omitempty" yaml:"artifacts"`         // 传递产物的上游作业名称
+	Strategy   string            `json:"strategy,omitempty" yaml:"strategy"`           // 为depend时等待并同步下游结果
+	TimeoutMin int               `json:"timeout_min,omitempty" yaml:"timeout-minutes"` // depend模式下等待下游的超时时间
+}
+
+// Validate 校验trigger作业配置
+func (c *TriggerJobConfig) Validate() error {
+	if strings.TrimSpace(c.Repository) == "" {
+		return errors.New("trigger作业缺少目标仓库(trigger.repository)")
+	}
+	if c.Project != "" {
+		if _, err := uuid.Parse(c.Project); err != nil {
+			return fmt.Errorf("无效的目标项目ID: %s", c.Project)
+		}
+	}
+	if c.Strategy != TriggerStrategyAsync && c.Strategy != TriggerStrategyDepend {
+		return fmt.Errorf("不支持的触发策略: %s", c.Strategy)
+	}
+	if c.TimeoutMin < 0 {
+		return errors.New("trigger.timeout-minutes 不能为负数")
+	}
+	return nil
+}
+
+// WaitForResult 是否等待下游流水线完成
+func (c *TriggerJobConfig) WaitForResult() bool {
+	return c.Strategy == TriggerStrategyDepend
+}
+
+// UpstreamArtifact 上游作业传递给下游流水线的产物
+type UpstreamArtifact struct {
+	RunID   string `json:"run_id"`
+	JobID   string `json:"job_id"`
+	JobName string `json:"job_name"`
+	Path    string `json:"path"`
+}
+
+// PipelineRunChain 流水线触发链，包含根运行及其全部下游运行
+type PipelineRunChain struct {
+	RootRunID uuid.UUID     `json:"root_run_id"`
+	Runs      []PipelineRun `json:"runs"` // 按触发顺序排列，通过parent_run_id组成树
+}
+
+// DetectTriggerCycle 检测触发目标是否会形成循环
+//
+// ancestry 为当前运行及其所有上游运行所属的流水线ID（顺序不限），
+// 目标流水线已出现在链上即视为循环，跨项目同样适用。
+func DetectTriggerCycle(ancestry []uuid.UUID, target uuid.UUID) error {
+	for _, pipelineID := range ancestry {
+		if pipelineID == target {
+			return fmt.Errorf("%w: 流水线 %s 已在上游触发链中", ErrTriggerCycle, target)
+		}
+	}
+	if len(ancestry)+1 > MaxTriggerDepth {
+		return fmt.Errorf("%w: 最大深度为 %d", ErrTriggerDepthExceeded, MaxTriggerDepth)
+	}
+	return nil
+}
+
+// UpstreamVariables 生成传递给下游流水线的上游上下文变量
+func UpstreamVariables(run *PipelineRun, projectID uuid.UUID) map[string]string {
+	vars := map[string]string{
+		"CI_UPSTREAM_RUN_ID":      run.ID.String(),
+		"CI_UPSTREAM_PIPELINE_ID": run.PipelineID.String(),
+		"CI_UPSTREAM_COMMIT_SHA":  run.CommitSHA,
+	}
+	if projectID != uuid.Nil {
+		vars["CI_UPSTREAM_PROJECT_ID"] = projectID.String()
+	}
+	if run.Branch != nil {
+		vars["CI_UPSTREAM_BRANCH"] = *run.Branch
+	}
+	return vars
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerJobConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TriggerJobConfig
+		wantErr bool
+	}{
+		{"仓库名称", TriggerJobConfig{Repository: "integration-tests"}, false},
+		{"跨项目且等待结果", TriggerJobConfig{Project: uuid.New().String(), Repository: "e2e", Strategy: TriggerStrategyDepend}, false},
+		{"缺少仓库", TriggerJobConfig{Pipeline: "ci"}, true},
+		{"无效项目ID", TriggerJobConfig{Project: "not-a-uuid", Repository: "e2e"}, true},
+		{"未知策略", TriggerJobConfig{Repository: "e2e", Strategy: "mirror"}, true},
+		{"负数超时", TriggerJobConfig{Repository: "e2e", TimeoutMin: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDetectTriggerCycle(t *testing.T) {
+	service, integration, e2e := uuid.New(), uuid.New(), uuid.New()
+
+	// service -> integration -> e2e
+	assert.NoError(t, DetectTriggerCycle([]uuid.UUID{integration, service}, e2e))
+
+	// e2e -> service 形成跨项目循环
+	assert.ErrorIs(t, DetectTriggerCycle([]uuid.UUID{e2e, integration, service}, service), ErrTriggerCycle)
+
+	// 触发自身
+	assert.ErrorIs(t, DetectTriggerCycle([]uuid.UUID{service}, service), ErrTriggerCycle)
+
+	// 超过最大深度
+	ancestry := make([]uuid.UUID, MaxTriggerDepth)
+	for i := range ancestry {
+		ancestry[i] = uuid.New()
+	}
+	assert.ErrorIs(t, DetectTriggerCycle(ancestry, uuid.New()), ErrTriggerDepthExceeded)
+	assert.NoError(t, DetectTriggerCycle(ancestry[1:], uuid.New()))
+}
+
+func TestUpstreamVariables(t *testing.T) {
+	branch := "main"
+	projectID := uuid.New()
+	run := &PipelineRun{ID: uuid.New(), PipelineID: uuid.New(), CommitSHA: "0123456789abcdef0123456789abcdef01234567", Branch: &branch}
+
+	vars := UpstreamVariables(run, projectID)
+	assert.Equal(t, run.ID.String(), vars["CI_UPSTREAM_RUN_ID"])
+	assert.Equal(t, run.PipelineID.String(), vars["CI_UPSTREAM_PIPELINE_ID"])
+	assert.Equal(t, projectID.String(), vars["CI_UPSTREAM_PROJECT_ID"])
+	assert.Equal(t, "main", vars["CI_UPSTREAM_BRANCH"])
+
+	vars = UpstreamVariables(&PipelineRun{ID: uuid.New()}, uuid.Nil)
+	assert.NotContains(t, vars, "CI_UPSTREAM_PROJECT_ID")
+	assert.NotContains(t, vars, "CI_UPSTREAM_BRANCH")
+}
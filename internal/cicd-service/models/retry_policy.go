@@ -0,0 +1,149 @@
+package models
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// FailureReason 作业失败原因枚举
+type FailureReason string
+
+const (
+	FailureReasonScriptFailure  FailureReason = "script_failure"         // 脚本执行失败（非零退出码）
+	FailureReasonRunnerLost     FailureReason = "runner_lost"            // Runner失联
+	FailureReasonTimeout        FailureReason = "timeout"                // 执行超时
+	FailureReasonInfrastructure FailureReason = "infrastructure_failure" // 基础设施故障（派发失败、容器异常等）
+	FailureReasonDownstream     FailureReason = "downstream_failure"     // trigger作业触发的下游流水线失败
+)
+
+// RetryWhenAlways 任意失败均重试
+const RetryWhenAlways = "always"
+
+// 默认退避参数
+const (
+	DefaultRetryInitialSeconds = 10
+	DefaultRetryMaxSeconds     = 300
+	DefaultRetryMultiplier     = 2.0
+	DefaultRetryJitter         = 0.2
+)
+
+// RetryPolicy 作业重试策略
+type RetryPolicy struct {
+	Max       int           `json:"max" yaml:"max"`                         // 最大重试次数
+	When      []string      `json:"when,omitempty" yaml:"when"`             // 触发重试的失败原因，为空时配合ExitCodes判断
+	ExitCodes []int         `json:"exit_codes,omitempty" yaml:"exit-codes"` // 触发重试的退出码（仅脚本失败）
+	Backoff   *RetryBackoff `json:"backoff,omitempty" yaml:"backoff"`       // 退避配置
+}
+
+// RetryBackoff 指数退避配置
+type RetryBackoff struct {
+	InitialSeconds int     `json:"initial_seconds" yaml:"initial-seconds"` // 首次重试等待秒数
+	MaxSeconds     int     `json:"max_seconds" yaml:"max-seconds"`         // 最大等待秒数
+	Multiplier     float64 `json:"multiplier" yaml:"multiplier"`           // 增长倍数
+	Jitter         float64 `json:"jitter" yaml:"jitter"`                   // 抖动比例（0-1）
+}
+
+// DefaultRetryPolicy 创建默认重试策略（任意失败均重试）
+func DefaultRetryPolicy(maxRetries int) *RetryPolicy {
+	return &RetryPolicy{
+		Max:  maxRetries,
+		When: []string{RetryWhenAlways},
+	}
+}
+
+// ShouldRetry 判断第attempt次重试前的失败是否需要重试
+// attempt为已经重试过的次数
+func (p *RetryPolicy) ShouldRetry(reason FailureReason, exitCode *int, attempt int) bool {
+	if p == nil || attempt >= p.Max {
+		return false
+	}
+
+	// 未声明任何条件时，任意失败均重试
+	if len(p.When) == 0 && len(p.ExitCodes) == 0 {
+		return true
+	}
+
+	for _, when := range p.When {
+		if when == RetryWhenAlways || FailureReason(when) == reason {
+			return true
+		}
+	}
+
+	if reason == FailureReasonScriptFailure && exitCode != nil {
+		for _, code := range p.ExitCodes {
+			if code == *exitCode {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NextDelay 计算第attempt次重试（从0开始）前的等待时间
+func (p *RetryPolicy) NextDelay(attempt int) time.Duration {
+	backoff := p.backoff()
+
+	delay := float64(backoff.InitialSeconds) * math.Pow(backoff.Multiplier, float64(attempt))
+	if max := float64(backoff.MaxSeconds); delay > max {
+		delay = max
+	}
+
+	// 在[1-jitter, 1+jitter]范围内随机抖动，避免重试风暴
+	if backoff.Jitter > 0 {
+		delay *= 1 + backoff.Jitter*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(delay * float64(time.Second))
+}
+
+// backoff 获取补全默认值后的退避配置
+func (p *RetryPolicy) backoff() RetryBackoff {
+	backoff := RetryBackoff{
+		InitialSeconds: DefaultRetryInitialSeconds,
+		MaxSeconds:     DefaultRetryMaxSeconds,
+		Multiplier:     DefaultRetryMultiplier,
+		Jitter:         DefaultRetryJitter,
+	}
+
+	if p == nil || p.Backoff == nil {
+		return backoff
+	}
+
+	if p.Backoff.InitialSeconds > 0 {
+		backoff.InitialSeconds = p.Backoff.InitialSeconds
+	}
+	if p.Backoff.MaxSeconds > 0 {
+		backoff.MaxSeconds = p.Backoff.MaxSeconds
+	}
+	if p.Backoff.Multiplier >= 1 {
+		backoff.Multiplier = p.Backoff.Multiplier
+	}
+	if p.Backoff.Jitter >= 0 && p.Backoff.Jitter <= 1 {
+		backoff.Jitter = p.Backoff.Jitter
+	}
+
+	return backoff
+}
+
+// RunnerReportedFailureReason 过滤Runner上报的失败原因，仅接受Runner能够自行判断的基础设施故障；
+// 超时、Runner失联等原因由服务端推断，其余取值一律视为未声明
+func RunnerReportedFailureReason(reason string) FailureReason {
+	if FailureReason(reason) == FailureReasonInfrastructure {
+		return FailureReasonInfrastructure
+	}
+	return ""
+}
+
+// ClassifyFailure 推断Runner未声明原因的失败：执行时长达到作业超时视为超时，否则为脚本失败。
+// elapsed应由服务端按作业开始时间计算，不依赖Runner上报的时间
+func ClassifyFailure(status JobStatus, elapsed, timeout time.Duration) FailureReason {
+	if status != JobStatusFailed {
+		return ""
+	}
+	if timeout > 0 && elapsed >= timeout {
+		return FailureReasonTimeout
+	}
+	return FailureReasonScriptFailure
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_ShouldRetry(t *testing.T) {
+	exitCode := func(code int) *int { return &code }
+
+	tests := []struct {
+		name     string
+		policy   *RetryPolicy
+		reason   FailureReason
+		exitCode *int
+		attempt  int
+		expected bool
+	}{
+		{"空策略不重试", nil, FailureReasonScriptFailure, exitCode(1), 0, false},
+		{"无条件时任意失败重试", &RetryPolicy{Max: 2}, FailureReasonTimeout, nil, 0, true},
+		{"超过最大次数不重试", &RetryPolicy{Max: 2}, FailureReasonTimeout, nil, 2, false},
+		{"always匹配任意原因", &RetryPolicy{Max: 1, When: []string{RetryWhenAlways}}, FailureReasonRunnerLost, nil, 0, true},
+		{"匹配失败原因", &RetryPolicy{Max: 3, When: []string{"runner_lost", "infrastructure_failure"}}, FailureReasonRunnerLost, nil, 1, true},
+		{"脚本失败不在条件中", &RetryPolicy{Max: 3, When: []string{"runner_lost"}}, FailureReasonScriptFailure, exitCode(1), 0, false},
+		{"匹配退出码", &RetryPolicy{Max: 3, ExitCodes: []int{137, 143}}, FailureReasonScriptFailure, exitCode(137), 0, true},
+		{"退出码不匹配", &RetryPolicy{Max: 3, ExitCodes: []int{137}}, FailureReasonScriptFailure, exitCode(1), 0, false},
+		{"退出码只适用于脚本失败", &RetryPolicy{Max: 3, ExitCodes: []int{137}}, FailureReasonTimeout, exitCode(137), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.ShouldRetry(tt.reason, tt.exitCode, tt.attempt))
+		})
+	}
+}
+
+func TestRetryPolicy_NextDelay(t *testing.T) {
+	policy := &RetryPolicy{
+		Max: 5,
+		Backoff: &RetryBackoff{
+			InitialSeconds: 5,
+			MaxSeconds:     30,
+			Multiplier:     2,
+		},
+	}
+
+	assert.Equal(t, 5*time.Second, policy.NextDelay(0))
+	assert.Equal(t, 10*time.Second, policy.NextDelay(1))
+	assert.Equal(t, 20*time.Second, policy.NextDelay(2))
+	assert.Equal(t, 30*time.Second, policy.NextDelay(3), "应受最大等待时间限制")
+
+	jittered := &RetryPolicy{Max: 1}
+	for i := 0; i < 20; i++ {
+		delay := jittered.NextDelay(0)
+		assert.GreaterOrEqual(t, delay, 8*time.Second)
+		assert.LessOrEqual(t, delay, 12*time.Second)
+	}
+}
+
+func TestClassifyFailure(t *testing.T) {
+	timeout := 30 * time.Minute
+	assert.Equal(t, FailureReason(""), ClassifyFailure(JobStatusSuccess, time.Hour, timeout))
+	assert.Equal(t, FailureReasonScriptFailure, ClassifyFailure(JobStatusFailed, time.Minute, timeout))
+	assert.Equal(t, FailureReasonTimeout, ClassifyFailure(JobStatusFailed, timeout, timeout))
+	assert.Equal(t, FailureReasonScriptFailure, ClassifyFailure(JobStatusFailed, time.Hour, 0), "未设置超时")
+}
+
+func TestJobGetTimeout(t *testing.T) {
+	fallback := 30 * time.Minute
+	assert.Equal(t, fallback, (&Job{}).GetTimeout(fallback), "未配置timeout-minutes")
+	assert.Equal(t, 5*time.Minute, (&Job{Config: map[string]interface{}{"timeout": float64(300)}}).GetTimeout(fallback))
+	assert.Equal(t, fallback, (&Job{Config: map[string]interface{}{"timeout": float64(0)}}).GetTimeout(fallback))
+
+	// 作业自身的超时短于全局超时时，达到作业超时即视为超时
+	job := &Job{Config: map[string]interface{}{"timeout": float64(300)}}
+	assert.Equal(t, FailureReasonTimeout, ClassifyFailure(JobStatusFailed, 6*time.Minute, job.GetTimeout(fallback)))
+}
+
+func TestRunnerReportedFailureReason(t *testing.T) {
+	assert.Equal(t, FailureReasonInfrastructure, RunnerReportedFailureReason("infrastructure_failure"))
+	assert.Equal(t, FailureReason(""), RunnerReportedFailureReason("timeout"), "超时由服务端推断")
+	assert.Equal(t, FailureReason(""), RunnerReportedFailureReason("runner_lost"), "失联由服务端推断")
+	assert.Equal(t, FailureReason(""), RunnerReportedFailureReason("downstream_failure"))
+	assert.Equal(t, FailureReason(""), RunnerReportedFailureReason("anything"))
+	assert.Equal(t, FailureReason(""), RunnerReportedFailureReason(""))
+}
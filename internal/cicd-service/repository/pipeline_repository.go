@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/cloud-platform/collaborative-dev/internal/cicd-service/models"
@@ -19,6 +22,12 @@ type PipelineRepository interface {
 	UpdatePipeline(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
 	DeletePipeline(ctx context.Context, id uuid.UUID) error
 	ListPipelines(ctx context.Context, page, pageSize int) ([]models.Pipeline, int64, error)
+	GetActivePipelineByName(ctx context.Context, repositoryID uuid.UUID, name string) (*models.Pipeline, error)
+
+	// 代码仓库查询（trigger作业解析目标仓库）
+	GetRepositoryByID(ctx context.Context, id uuid.UUID) (*models.Repository, error)
+	GetRepositoryByName(ctx context.Context, projectID uuid.UUID, name string) (*models.Repository, error)
+	CanTriggerInProject(ctx context.Context, upstreamProjectID, targetProjectID uuid.UUID, userID *uuid.UUID) (bool, error)
 
 	// 流水线运行管理
 	CreatePipelineRun(ctx context.Context, run *models.PipelineRun) error
@@ -26,15 +35,23 @@ type PipelineRepository interface {
 	GetPipelineRunsByPipeline(ctx context.Context, pipelineID uuid.UUID, page, pageSize int) ([]models.PipelineRun, int64, error)
 	UpdatePipelineRun(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
 	CancelPipelineRun(ctx context.Context, id uuid.UUID) error
+	AppendPipelineRunImage(ctx context.Context, id uuid.UUID, image *models.BuiltImage) error
 	GetRunningPipelineRuns(ctx context.Context) ([]models.PipelineRun, error)
+	GetPipelineRunAncestors(ctx context.Context, id uuid.UUID) ([]models.PipelineRun, error)
+	GetPipelineRunDescendants(ctx context.Context, id uuid.UUID) ([]models.PipelineRun, error)
 
 	// 作业管理
 	CreateJob(ctx context.Context, job *models.Job) error
 	GetJobByID(ctx context.Context, id uuid.UUID) (*models.Job, error)
 	GetJobsByPipelineRun(ctx context.Context, pipelineRunID uuid.UUID) ([]models.Job, error)
 	UpdateJob(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
+	UpdateJobIfStatus(ctx context.Context, id uuid.UUID, status models.JobStatus, updates map[string]interface{}) (bool, error)
 	GetPendingJobs(ctx context.Context, runnerTags []string) ([]models.Job, error)
 
+	// 作业执行记录（每次尝试一条）
+	CreateJobExecution(ctx context.Context, execution *models.JobExecution) error
+	GetJobExecutions(ctx context.Context, jobID uuid.UUID) ([]models.JobExecution, error)
+
 	// 执行器管理
 	RegisterRunner(ctx context.Context, runner *models.Runner) error
 	GetRunnerByID(ctx context.Context, id uuid.UUID) (*models.Runner, error)
@@ -47,6 +64,7 @@ type PipelineRepository interface {
 	// 统计查询
 	GetPipelineStats(ctx context.Context, pipelineID uuid.UUID, days int) (*models.PipelineStats, error)
 	GetRunnerStats(ctx context.Context, runnerID uuid.UUID) (*models.RunnerStats, error)
+	GetPipelineAnalytics(ctx context.Context, pipelineID uuid.UUID, from, to time.Time, limit int) (*models.PipelineAnalytics, error)
 }
 
 // pipelineRepository 流水线仓库实现
@@ -145,6 +163,62 @@ func (r *pipelineRepository) ListPipelines(ctx context.Context, page, pageSize i
 	return pipelines, total, err
 }
 
+// GetActivePipelineByName 获取仓库中指定名称的启用流水线，名称为空时返回最早创建的启用流水线
+func (r *pipelineRepository) GetActivePipelineByName(ctx context.Context, repositoryID uuid.UUID, name string) (*models.Pipeline, error) {
+	var pipeline models.Pipeline
+	db := r.db.WithContext(ctx).
+		Preload("Repository").
+		Where("repository_id = ? AND is_active = ? AND deleted_at IS NULL", repositoryID, true)
+	if name != "" {
+		db = db.Where("name = ?", name)
+	}
+
+	if err := db.Order("created_at ASC").First(&pipeline).Error; err != nil {
+		return nil, err
+	}
+	return &pipeline, nil
+}
+
+// 代码仓库查询实现
+
+// GetRepositoryByID 根据ID获取代码仓库
+func (r *pipelineRepository) GetRepositoryByID(ctx context.Context, id uuid.UUID) (*models.Repository, error) {
+	var repo models.Repository
+	if err := r.db.WithContext(ctx).First(&repo, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// GetRepositoryByName 根据项目ID和名称获取代码仓库
+func (r *pipelineRepository) GetRepositoryByName(ctx context.Context, projectID uuid.UUID, name string) (*models.Repository, error) {
+	var repo models.Repository
+	if err := r.db.WithContext(ctx).First(&repo, "project_id = ? AND name = ?", projectID, name).Error; err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// CanTriggerInProject 检查上游项目的流水线能否触发目标项目的流水线：
+// 两个项目必须属于同一租户；跨项目触发时触发用户必须是目标项目的负责人或成员
+func (r *pipelineRepository) CanTriggerInProject(ctx context.Context, upstreamProjectID, targetProjectID uuid.UUID, userID *uuid.UUID) (bool, error) {
+	triggerUser := uuid.Nil
+	if userID != nil {
+		triggerUser = *userID
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM projects up
+		JOIN projects tp ON tp.tenant_id = up.tenant_id
+		WHERE up.id = ? AND tp.id = ? AND tp.deleted_at IS NULL AND (
+			tp.id = up.id
+			OR tp.manager_id = ?
+			OR EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = tp.id AND pm.user_id = ?)
+		)`, upstreamProjectID, targetProjectID, triggerUser, triggerUser).
+		Scan(&count).Error
+	return count > 0, err
+}
+
 // 流水线运行管理实现
 
 // CreatePipelineRun 创建流水线运行
@@ -209,6 +283,20 @@ func (r *pipelineRepository) CancelPipelineRun(ctx context.Context, id uuid.UUID
 	return r.UpdatePipelineRun(ctx, id, updates)
 }
 
+// AppendPipelineRunImage 追加流水线运行构建的镜像
+func (r *pipelineRepository) AppendPipelineRunImage(ctx context.Context, id uuid.UUID, image *models.BuiltImage) error {
+	data, err := json.Marshal([]*models.BuiltImage{image})
+	if err != nil {
+		return fmt.Errorf("序列化镜像信息失败: %w", err)
+	}
+
+	// 使用jsonb追加，避免并发作业互相覆盖
+	return r.db.WithContext(ctx).
+		Model(&models.PipelineRun{}).
+		Where("id = ?", id).
+		Update("built_images", gorm.Expr("COALESCE(built_images, '[]'::jsonb) || ?::jsonb", string(data))).Error
+}
+
 // GetRunningPipelineRuns 获取正在运行的流水线
 func (r *pipelineRepository) GetRunningPipelineRuns(ctx context.Context) ([]models.PipelineRun, error) {
 	var runs []models.PipelineRun
@@ -219,6 +307,53 @@ func (r *pipelineRepository) GetRunningPipelineRuns(ctx context.Context) ([]mode
 	return runs, err
 }
 
+// GetPipelineRunAncestors 获取运行自身及其全部上游运行，从自身到根运行排列
+// 仅返回触发链相关字段（id、pipeline_id、parent_run_id、parent_job_id、status）
+func (r *pipelineRepository) GetPipelineRunAncestors(ctx context.Context, id uuid.UUID) ([]models.PipelineRun, error) {
+	var runs []models.PipelineRun
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT pr.id, pr.pipeline_id, pr.parent_run_id, pr.parent_job_id, pr.status, 0 AS depth
+			FROM pipeline_runs pr WHERE pr.id = ?
+			UNION ALL
+			SELECT parent.id, parent.pipeline_id, parent.parent_run_id, parent.parent_job_id, parent.status, a.depth + 1
+			FROM pipeline_runs parent
+			JOIN ancestors a ON parent.id = a.parent_run_id
+			WHERE a.depth < ?
+		)
+		SELECT id, pipeline_id, parent_run_id, parent_job_id, status FROM ancestors ORDER BY depth ASC`, id, models.MaxTriggerDepth).
+		Scan(&runs).Error
+	return runs, err
+}
+
+// GetPipelineRunDescendants 获取运行自身及其全部下游运行，按创建时间排列
+func (r *pipelineRepository) GetPipelineRunDescendants(ctx context.Context, id uuid.UUID) ([]models.PipelineRun, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE descendants AS (
+			SELECT pr.id, 0 AS depth FROM pipeline_runs pr WHERE pr.id = ?
+			UNION ALL
+			SELECT child.id, d.depth + 1 FROM pipeline_runs child
+			JOIN descendants d ON child.parent_run_id = d.id
+			WHERE d.depth < ?
+		)
+		SELECT id FROM descendants`, id, models.MaxTriggerDepth).
+		Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []models.PipelineRun
+	err = r.db.WithContext(ctx).
+		Preload("Pipeline").
+		Preload("Pipeline.Repository").
+		Preload("Jobs").
+		Where("id IN ?", ids).
+		Order("created_at ASC").
+		Find(&runs).Error
+	return runs, err
+}
+
 // 作业管理实现
 
 // CreateJob 创建作业
@@ -230,8 +365,11 @@ func (r *pipelineRepository) CreateJob(ctx context.Context, job *models.Job) err
 func (r *pipelineRepository) GetJobByID(ctx context.Context, id uuid.UUID) (*models.Job, error) {
 	var job models.Job
 	err := r.db.WithContext(ctx).
-		Preload("PipelineRun").
+		Preload("PipelineRun.Pipeline").
 		Preload("Runner").
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempt ASC")
+		}).
 		First(&job, "id = ?", id).Error
 	if err != nil {
 		return nil, err
@@ -258,12 +396,25 @@ func (r *pipelineRepository) UpdateJob(ctx context.Context, id uuid.UUID, update
 		Updates(updates).Error
 }
 
+// UpdateJobIfStatus 仅当作业仍处于指定状态时更新，返回是否更新成功
+func (r *pipelineRepository) UpdateJobIfStatus(ctx context.Context, id uuid.UUID, status models.JobStatus, updates map[string]interface{}) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.Job{}).
+		Where("id = ? AND status = ?", id, status).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 // GetPendingJobs 获取待执行的作业
 func (r *pipelineRepository) GetPendingJobs(ctx context.Context, runnerTags []string) ([]models.Job, error) {
 	var jobs []models.Job
 	query := r.db.WithContext(ctx).
 		Where("status = ?", models.JobStatusPending).
-		Preload("PipelineRun").
+		Where("next_retry_at IS NULL OR next_retry_at <= ?", time.Now().UTC()). // 跳过仍在退避中的重试作业
+		Preload("PipelineRun.Pipeline").
 		Order("created_at ASC")
 
 	// 如果指定了标签，则进行标签过滤
@@ -276,6 +427,21 @@ func (r *pipelineRepository) GetPendingJobs(ctx context.Context, runnerTags []st
 	return jobs, err
 }
 
+// CreateJobExecution 创建作业执行记录
+func (r *pipelineRepository) CreateJobExecution(ctx context.Context, execution *models.JobExecution) error {
+	return r.db.WithContext(ctx).Create(execution).Error
+}
+
+// GetJobExecutions 获取作业的全部执行记录（按尝试次数升序）
+func (r *pipelineRepository) GetJobExecutions(ctx context.Context, jobID uuid.UUID) ([]models.JobExecution, error) {
+	var executions []models.JobExecution
+	err := r.db.WithContext(ctx).
+		Where("job_id = ?", jobID).
+		Order("attempt ASC").
+		Find(&executions).Error
+	return executions, err
+}
+
 // 执行器管理实现
 
 // RegisterRunner 注册执行器
@@ -463,3 +629,183 @@ func (r *pipelineRepository) GetRunnerStats(ctx context.Context, runnerID uuid.U
 
 	return &stats, nil
 }
+
+// percentileColumns 生成耗时分位数统计列，expr为以秒计的耗时表达式
+func percentileColumns(expr, filter, prefix string) string {
+	return fmt.Sprintf(`COUNT(*) FILTER (WHERE %[2]s) AS %[3]s_samples,
+		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY %[1]s) FILTER (WHERE %[2]s), 0) AS %[3]s_p50,
+		COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY %[1]s) FILTER (WHERE %[2]s), 0) AS %[3]s_p95,
+		COALESCE(AVG(%[1]s) FILTER (WHERE %[2]s), 0) AS %[3]s_avg`, expr, filter, prefix)
+}
+
+// durationPercentileRow 分位数查询结果
+type durationPercentileRow struct {
+	QueueSamples    int64
+	QueueP50        float64
+	QueueP95        float64
+	QueueAvg        float64
+	DurationSamples int64
+	DurationP50     float64
+	DurationP95     float64
+	DurationAvg     float64
+}
+
+func (row *durationPercentileRow) queueTime() models.DurationPercentiles {
+	return models.DurationPercentiles{Samples: row.QueueSamples, P50: row.QueueP50, P95: row.QueueP95, Avg: row.QueueAvg}
+}
+
+func (row *durationPercentileRow) duration() models.DurationPercentiles {
+	return models.DurationPercentiles{Samples: row.DurationSamples, P50: row.DurationP50, P95: row.DurationP95, Avg: row.DurationAvg}
+}
+
+// 排队时间为创建到开始执行，执行时间为开始到结束
+const (
+	queueSecondsExpr    = "EXTRACT(EPOCH FROM (%[1]s.started_at - %[1]s.created_at))"
+	durationSecondsExpr = "EXTRACT(EPOCH FROM (%[1]s.finished_at - %[1]s.started_at))"
+	startedFilter       = "%[1]s.started_at IS NOT NULL"
+	finishedFilter      = "%[1]s.started_at IS NOT NULL AND %[1]s.finished_at IS NOT NULL"
+)
+
+// timingColumns 生成指定表别名的排队与执行耗时分位数列
+func timingColumns(alias string) string {
+	return percentileColumns(fmt.Sprintf(queueSecondsExpr, alias), fmt.Sprintf(startedFilter, alias), "queue") + ",\n\t\t" +
+		percentileColumns(fmt.Sprintf(durationSecondsExpr, alias), fmt.Sprintf(finishedFilter, alias), "duration")
+}
+
+// GetPipelineAnalytics 获取流水线在时间窗口内的分析数据
+// limit 限制失败作业与失败步骤排行的条数
+func (r *pipelineRepository) GetPipelineAnalytics(ctx context.Context, pipelineID uuid.UUID, from, to time.Time, limit int) (*models.PipelineAnalytics, error) {
+	analytics := &models.PipelineAnalytics{
+		PipelineID:   pipelineID,
+		From:         from,
+		To:           to,
+		SuccessTrend: []models.SuccessRatePoint{},
+		Jobs:         []models.JobAnalytics{},
+		FailingJobs:  []models.JobAnalytics{},
+		FailingSteps: []models.FailingStep{},
+		Runners:      []models.RunnerUtilization{},
+	}
+	db := r.db.WithContext(ctx)
+
+	// 流水线级排队与执行耗时
+	var runTiming durationPercentileRow
+	err := db.Raw(`SELECT `+timingColumns("pr")+`
+		FROM pipeline_runs pr
+		WHERE pr.pipeline_id = ? AND pr.created_at >= ? AND pr.created_at < ?`,
+		pipelineID, from, to).Scan(&runTiming).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计流水线耗时失败: %w", err)
+	}
+	analytics.QueueTime = runTiming.queueTime()
+	analytics.Duration = runTiming.duration()
+
+	// 按天统计成功率
+	err = db.Raw(`SELECT date_trunc('day', created_at) AS date,
+			COUNT(*) FILTER (WHERE status IN (?, ?)) AS total_runs,
+			COUNT(*) FILTER (WHERE status = ?) AS successful_runs,
+			COUNT(*) FILTER (WHERE status = ?) AS failed_runs
+		FROM pipeline_runs
+		WHERE pipeline_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY 1 ORDER BY 1`,
+		models.PipelineStatusSuccess, models.PipelineStatusFailed,
+		models.PipelineStatusSuccess, models.PipelineStatusFailed,
+		pipelineID, from, to).Scan(&analytics.SuccessTrend).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计成功率趋势失败: %w", err)
+	}
+	for i := range analytics.SuccessTrend {
+		point := &analytics.SuccessTrend[i]
+		if point.TotalRuns > 0 {
+			point.SuccessRate = float64(point.SuccessfulRuns) / float64(point.TotalRuns) * 100
+		}
+	}
+
+	// 按作业名称统计耗时与失败率
+	var jobRows []struct {
+		JobName  string
+		Runs     int64
+		Failures int64
+		durationPercentileRow
+	}
+	err = db.Raw(`SELECT j.name AS job_name,
+			COUNT(*) AS runs,
+			COUNT(*) FILTER (WHERE j.status = ?) AS failures,
+			`+timingColumns("j")+`
+		FROM jobs j
+		JOIN pipeline_runs pr ON pr.id = j.pipeline_run_id
+		WHERE pr.pipeline_id = ? AND pr.created_at >= ? AND pr.created_at < ?
+		GROUP BY j.name
+		ORDER BY j.name`,
+		models.JobStatusFailed, pipelineID, from, to).Scan(&jobRows).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计作业耗时失败: %w", err)
+	}
+	for _, row := range jobRows {
+		job := models.JobAnalytics{
+			JobName:   row.JobName,
+			Runs:      row.Runs,
+			Failures:  row.Failures,
+			QueueTime: row.queueTime(),
+			Duration:  row.duration(),
+		}
+		if row.Runs > 0 {
+			job.FailureRate = float64(row.Failures) / float64(row.Runs) * 100
+		}
+		analytics.Jobs = append(analytics.Jobs, job)
+		if job.Failures > 0 {
+			analytics.FailingJobs = append(analytics.FailingJobs, job)
+		}
+	}
+	sort.SliceStable(analytics.FailingJobs, func(i, j int) bool {
+		return analytics.FailingJobs[i].Failures > analytics.FailingJobs[j].Failures
+	})
+	if len(analytics.FailingJobs) > limit {
+		analytics.FailingJobs = analytics.FailingJobs[:limit]
+	}
+
+	// 失败次数最多的步骤
+	err = db.Raw(`SELECT j.name AS job_name, j.failed_step AS step_name, COUNT(*) AS failures
+		FROM jobs j
+		JOIN pipeline_runs pr ON pr.id = j.pipeline_run_id
+		WHERE pr.pipeline_id = ? AND pr.created_at >= ? AND pr.created_at < ?
+			AND j.status = ? AND j.failed_step IS NOT NULL AND j.failed_step <> ''
+		GROUP BY j.name, j.failed_step
+		ORDER BY failures DESC, j.name
+		LIMIT ?`,
+		pipelineID, from, to, models.JobStatusFailed, limit).Scan(&analytics.FailingSteps).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计失败步骤失败: %w", err)
+	}
+
+	// 执行过本流水线作业的执行器在窗口内的忙碌时间（含其他流水线的作业）
+	err = db.Raw(`SELECT r.id AS runner_id, r.name AS runner_name,
+			COUNT(j.id) AS jobs,
+			COALESCE(SUM(busy.seconds), 0) AS busy_seconds,
+			COALESCE(SUM(busy.seconds) FILTER (WHERE pr.pipeline_id = ?), 0) AS pipeline_busy_seconds
+		FROM runners r
+		JOIN jobs j ON j.runner_id = r.id
+		JOIN pipeline_runs pr ON pr.id = j.pipeline_run_id
+		CROSS JOIN LATERAL (
+			SELECT EXTRACT(EPOCH FROM (LEAST(COALESCE(j.finished_at, NOW()), ?) - GREATEST(j.started_at, ?))) AS seconds
+		) busy
+		WHERE j.started_at IS NOT NULL AND j.started_at < ? AND COALESCE(j.finished_at, NOW()) > ?
+			AND r.id IN (
+				SELECT DISTINCT j2.runner_id FROM jobs j2
+				JOIN pipeline_runs pr2 ON pr2.id = j2.pipeline_run_id
+				WHERE pr2.pipeline_id = ? AND pr2.created_at >= ? AND pr2.created_at < ? AND j2.runner_id IS NOT NULL
+			)
+		GROUP BY r.id, r.name
+		ORDER BY busy_seconds DESC`,
+		pipelineID, to, from, to, from, pipelineID, from, to).Scan(&analytics.Runners).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计执行器利用率失败: %w", err)
+	}
+	window := to.Sub(from).Seconds()
+	for i := range analytics.Runners {
+		if window > 0 {
+			analytics.Runners[i].Utilization = analytics.Runners[i].BusySeconds / window * 100
+		}
+	}
+
+	return analytics, nil
+}
@@ -38,6 +38,14 @@ type RunnerCommunicationManager interface {
 
 	// 获取Runner状态
 	GetRunnerStatus(runnerID uuid.UUID) (*RunnerConnectionStatus, error)
+
+	// 设置作业结果上报器（如作业调度器，用于执行重试策略）
+	SetJobResultReporter(reporter JobResultReporter)
+}
+
+// JobResultReporter 作业结果上报接口
+type JobResultReporter interface {
+	ReportJobResult(ctx context.Context, result *engine.JobResult) error
 }
 
 // JobMessage 作业消息
@@ -54,14 +62,16 @@ type JobMessage struct {
 
 // JobResult 作业结果
 type JobResult struct {
-	JobID      uuid.UUID `json:"job_id"`
-	Status     string    `json:"status"`
-	ExitCode   int       `json:"exit_code"`
-	Output     string    `json:"output"`
-	Error      string    `json:"error"`
-	StartedAt  time.Time `json:"started_at"`
-	FinishedAt time.Time `json:"finished_at"`
-	Artifacts  []string  `json:"artifacts"`
+	JobID         uuid.UUID `json:"job_id"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason"` // 可选：仅接受infrastructure_failure，其余原因由服务端推断
+	FailedStep    string    `json:"failed_step"`    // 可选：导致失败的步骤名称
+	ExitCode      int       `json:"exit_code"`
+	Output        string    `json:"output"`
+	Error         string    `json:"error"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	Artifacts     []string  `json:"artifacts"`
 }
 
 // RunnerMessage Runner消息
@@ -97,9 +107,10 @@ type runnerConnection struct {
 
 // runnerCommunicationManager Runner通信管理器实现
 type runnerCommunicationManager struct {
-	repo   repository.PipelineRepository
-	engine engine.PipelineEngine
-	logger *zap.Logger
+	repo     repository.PipelineRepository
+	engine   engine.PipelineEngine
+	reporter JobResultReporter
+	logger   *zap.Logger
 
 	// WebSocket升级器
 	upgrader websocket.Upgrader
@@ -345,8 +356,64 @@ func (m *runnerCommunicationManager) GetRunnerStatus(runnerID uuid.UUID) (*Runne
 	return status, nil
 }
 
+// SetJobResultReporter 设置作业结果上报器
+func (m *runnerCommunicationManager) SetJobResultReporter(reporter JobResultReporter) {
+	m.reporter = reporter
+}
+
 // 私有方法
 
+// reportJobResult 上报作业结果，未设置上报器时直接交给执行引擎
+func (m *runnerCommunicationManager) reportJobResult(ctx context.Context, result *engine.JobResult) error {
+	if m.reporter != nil {
+		return m.reporter.ReportJobResult(ctx, result)
+	}
+	return m.engine.HandleJobResult(ctx, result.JobID, result)
+}
+
+// reportRunnerLost Runner断开时将其正在执行的作业上报为Runner失联
+func (m *runnerCommunicationManager) reportRunnerLost(conn *runnerConnection) {
+	conn.mu.Lock()
+	jobID := conn.currentJob
+	conn.currentJob = nil
+	conn.mu.Unlock()
+
+	if jobID == nil {
+		return
+	}
+
+	// 在独立协程中上报，避免阻塞消息循环
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		now := time.Now().UTC()
+		result := &engine.JobResult{
+			JobID:         *jobID,
+			RunnerID:      conn.runnerID,
+			Status:        models.JobStatusFailed,
+			FailureReason: models.FailureReasonRunnerLost,
+			ErrorMessage:  "Runner连接中断",
+			StartedAt:     now,
+			FinishedAt:    now,
+		}
+
+		// 执行尝试的耗时从作业实际开始时间计算，而不是Runner建立连接的时间
+		job, err := m.repo.GetJobByID(ctx, *jobID)
+		if err != nil {
+			conn.logger.Warn("获取失联作业失败", zap.String("job_id", jobID.String()), zap.Error(err))
+		} else if job.StartedAt != nil {
+			result.StartedAt = *job.StartedAt
+		}
+
+		if err := m.reportJobResult(ctx, result); err != nil {
+			conn.logger.Error("上报Runner失联作业失败",
+				zap.String("job_id", jobID.String()),
+				zap.Error(err))
+		}
+	}()
+}
+
 // messageLoop 主消息处理循环
 func (m *runnerCommunicationManager) messageLoop() {
 	defer close(m.doneCh)
@@ -370,6 +437,8 @@ func (m *runnerCommunicationManager) messageLoop() {
 			}
 			m.connMu.Unlock()
 
+			m.reportRunnerLost(conn)
+
 			// 更新Runner状态为离线
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			m.repo.UpdateRunnerStatus(ctx, conn.runnerID, models.RunnerStatusOffline)
@@ -427,6 +496,7 @@ func (m *runnerCommunicationManager) checkHeartbeats() {
 			conn.conn.Close()
 			delete(m.connections, runnerID)
 			close(conn.send)
+			m.reportRunnerLost(conn)
 		}
 	}
 }
@@ -539,18 +609,21 @@ func (c *runnerConnection) handleJobResult(data interface{}) {
 
 	// 转换为引擎的JobResult格式
 	engineResult := &engine.JobResult{
-		JobID:      result.JobID,
-		RunnerID:   c.runnerID,
-		Status:     models.JobStatus(result.Status),
-		ExitCode:   &result.ExitCode,
-		Output:     result.Output,
-		StartedAt:  result.StartedAt,
-		FinishedAt: result.FinishedAt,
-		Artifacts:  result.Artifacts,
-	}
-
-	// 通知执行引擎
-	if err := c.manager.engine.HandleJobResult(context.Background(), result.JobID, engineResult); err != nil {
+		JobID:         result.JobID,
+		RunnerID:      c.runnerID,
+		Status:        models.JobStatus(result.Status),
+		FailureReason: models.RunnerReportedFailureReason(result.FailureReason),
+		FailedStep:    result.FailedStep,
+		ExitCode:      &result.ExitCode,
+		Output:        result.Output,
+		ErrorMessage:  result.Error,
+		StartedAt:     result.StartedAt,
+		FinishedAt:    result.FinishedAt,
+		Artifacts:     result.Artifacts,
+	}
+
+	// 上报作业结果（由调度器决定是否重试）
+	if err := c.manager.reportJobResult(context.Background(), engineResult); err != nil {
 		c.logger.Error("处理作业结果失败", zap.Error(err))
 	}
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloud-platform/collaborative-dev/internal/cicd-service/engine"
@@ -49,8 +50,11 @@ type JobScheduler interface {
 	// 设置调度策略
 	SetSchedulingStrategy(strategy SchedulingStrategy) error
 
-	// 获取作业历史
+	// 获取作业历史（每次执行尝试一条记录）
 	GetJobHistory(limit int) ([]*JobExecution, error)
+
+	// 上报作业结果，按重试策略重新排队或结束作业
+	ReportJobResult(ctx context.Context, result *engine.JobResult) error
 }
 
 // ScheduleJob 调度作业
@@ -68,6 +72,7 @@ type ScheduleJob struct {
 	RetryCount        int                    `json:"retry_count"`        // 当前重试次数
 	EstimatedDuration time.Duration          `json:"estimated_duration"` // 预估执行时间
 	ResourceRequests  *ResourceRequests      `json:"resource_requests"`  // 资源需求
+	RetryPolicy       *models.RetryPolicy    `json:"retry_policy"`       // 重试策略
 }
 
 // ResourceRequests 资源需求
@@ -135,15 +140,20 @@ type QueueDepthPoint struct {
 
 // JobExecution 作业执行历史
 type JobExecution struct {
-	JobID      uuid.UUID     `json:"job_id"`
-	Name       string        `json:"name"`
-	Status     string        `json:"status"`
-	StartedAt  *time.Time    `json:"started_at"`
-	FinishedAt *time.Time    `json:"finished_at"`
-	Duration   time.Duration `json:"duration"`
-	RunnerID   uuid.UUID     `json:"runner_id"`
-	ExitCode   *int          `json:"exit_code"`
-	RetryCount int           `json:"retry_count"`
+	JobID         uuid.UUID            `json:"job_id"`
+	Name          string               `json:"name"`
+	Attempt       int                  `json:"attempt"`
+	Status        string               `json:"status"`
+	FailureReason models.FailureReason `json:"failure_reason,omitempty"`
+	StartedAt     *time.Time           `json:"started_at"`
+	FinishedAt    *time.Time           `json:"finished_at"`
+	Duration      time.Duration        `json:"duration"`
+	RunnerID      uuid.UUID            `json:"runner_id"`
+	ExitCode      *int                 `json:"exit_code"`
+	RetryCount    int                  `json:"retry_count"`
+	WillRetry     bool                 `json:"will_retry"`
+	NextRetryAt   *time.Time           `json:"next_retry_at,omitempty"`
+	LogPath       string               `json:"log_path,omitempty"`
 }
 
 // JobAssignment 作业分配结果
@@ -189,16 +199,17 @@ type jobScheduler struct {
 	startedAt time.Time
 
 	// 多级队列和优先级处理
-	priorityQueues  map[int]chan *ScheduleJob // 优先级队列
-	dependencyGraph map[uuid.UUID][]uuid.UUID // 依赖关系图
-	readyJobs       chan *ScheduleJob         // 准备就绪的作业
+	priorityQueues  map[int]chan *ScheduleJob  // 优先级队列
+	dependencyGraph map[uuid.UUID][]uuid.UUID  // 依赖关系图
+	readyJobs       chan *ScheduleJob          // 准备就绪的作业
+	dispatchedJobs  map[uuid.UUID]*ScheduleJob // 已派发到Runner的作业，重试时沿用原配置和标签
 	workers         []*worker
 	assignments     chan *JobAssignment
 
-	// 统计和指标数据
-	processedJobs     int64
-	failedJobs        int64
-	cancelledJobs     int64
+	// 统计和指标数据，计数器由调度循环、工作器和结果上报并发更新
+	processedJobs     atomic.Int64
+	failedJobs        atomic.Int64
+	cancelledJobs     atomic.Int64
 	lastProcessedAt   time.Time
 	jobExecutions     []*JobExecution                 // 作业执行历史
 	queueDepthHistory []QueueDepthPoint               // 队列深度历史
@@ -275,6 +286,7 @@ func NewJobScheduler(repo repository.PipelineRepository, engine engine.PipelineE
 		priorityQueues:    make(map[int]chan *ScheduleJob),
 		dependencyGraph:   make(map[uuid.UUID][]uuid.UUID),
 		readyJobs:         make(chan *ScheduleJob, config.QueueSize),
+		dispatchedJobs:    make(map[uuid.UUID]*ScheduleJob),
 		assignments:       make(chan *JobAssignment, config.QueueSize),
 		jobExecutions:     make([]*JobExecution, 0, config.MaxHistorySize),
 		queueDepthHistory: make([]QueueDepthPoint, 0, config.MaxHistorySize),
@@ -437,7 +449,7 @@ func (s *jobScheduler) CancelJob(jobID uuid.UUID) error {
 		return fmt.Errorf("取消作业失败: %v", err)
 	}
 
-	s.cancelledJobs++
+	s.cancelledJobs.Add(1)
 	s.logger.Info("作业已取消", zap.String("job_id", jobID.String()))
 
 	return nil
@@ -488,11 +500,11 @@ func (s *jobScheduler) GetMetrics() *SchedulerMetrics {
 
 	// 计算作业处理率
 	uptime := time.Since(s.startedAt)
-	jobsPerSecond := float64(s.processedJobs) / uptime.Seconds()
+	jobsPerSecond := float64(s.processedJobs.Load()) / uptime.Seconds()
 
 	// 计算成功率
-	totalJobs := s.processedJobs + s.failedJobs + s.cancelledJobs
-	successRate := float64(s.processedJobs) / float64(totalJobs)
+	totalJobs := s.processedJobs.Load() + s.failedJobs.Load() + s.cancelledJobs.Load()
+	successRate := float64(s.processedJobs.Load()) / float64(totalJobs)
 	if totalJobs == 0 {
 		successRate = 0
 	}
@@ -576,6 +588,145 @@ func (s *jobScheduler) GetJobHistory(limit int) ([]*JobExecution, error) {
 	return result, nil
 }
 
+// ReportJobResult 上报作业结果
+// 每次结果都会记录为一次独立的执行尝试；失败时根据作业的重试策略决定是否重新排队
+func (s *jobScheduler) ReportJobResult(ctx context.Context, result *engine.JobResult) error {
+	job, err := s.repo.GetJobByID(ctx, result.JobID)
+	if err != nil {
+		return fmt.Errorf("获取作业失败: %w", err)
+	}
+
+	logger := s.logger.With(
+		zap.String("job_id", job.ID.String()),
+		zap.Int("attempt", job.RetryCount+1))
+
+	result.Attempt = job.RetryCount + 1
+	if result.Status == models.JobStatusFailed && result.FailureReason == "" {
+		// Runner上报的时间不可信，按服务端记录的开始时间判断是否超时
+		elapsed := result.FinishedAt.Sub(result.StartedAt)
+		if job.StartedAt != nil {
+			elapsed = time.Since(*job.StartedAt)
+		}
+		result.FailureReason = models.ClassifyFailure(result.Status, elapsed, job.GetTimeout(s.config.JobTimeout))
+	}
+
+	history := &JobExecution{
+		JobID:         job.ID,
+		Name:          job.Name,
+		Attempt:       result.Attempt,
+		Status:        string(result.Status),
+		FailureReason: result.FailureReason,
+		StartedAt:     &result.StartedAt,
+		FinishedAt:    &result.FinishedAt,
+		Duration:      result.FinishedAt.Sub(result.StartedAt),
+		RunnerID:      result.RunnerID,
+		ExitCode:      result.ExitCode,
+		RetryCount:    job.RetryCount,
+	}
+
+	execution, err := s.engine.RecordJobAttempt(ctx, result)
+	if err != nil {
+		logger.Warn("记录作业执行尝试失败", zap.Error(err))
+	} else {
+		history.LogPath = execution.LogPath
+	}
+
+	policy := job.GetRetryPolicy()
+	if result.Status == models.JobStatusFailed && policy.ShouldRetry(result.FailureReason, result.ExitCode, job.RetryCount) {
+		nextRetryAt, requeued, err := s.requeueJob(ctx, job, result, policy)
+		if err != nil {
+			return err
+		}
+		if !requeued {
+			// 同一次执行的结果已被处理（如Runner失联后又收到迟到的结果），或作业已被取消
+			logger.Info("作业状态已变化，忽略重复的执行结果", zap.String("status", string(job.Status)))
+			return nil
+		}
+
+		history.WillRetry = true
+		history.NextRetryAt = &nextRetryAt
+		s.addJobExecution(history)
+
+		logger.Info("作业失败，已按重试策略重新排队",
+			zap.String("failure_reason", string(result.FailureReason)),
+			zap.Time("next_retry_at", nextRetryAt))
+		return nil
+	}
+
+	s.addJobExecution(history)
+	s.forgetDispatchedJob(job.ID)
+
+	if result.Status == models.JobStatusFailed {
+		s.failedJobs.Add(1)
+	}
+
+	return s.engine.HandleJobResult(ctx, job.ID, result)
+}
+
+// requeueJob 将失败作业重置为待执行状态，并在退避时间后重新参与调度。
+// 仅重新排队仍在运行中的作业，避免已取消的作业被重新执行、同一次失败被重复上报时多次排队；返回是否已重新排队
+func (s *jobScheduler) requeueJob(ctx context.Context, job *models.Job, result *engine.JobResult, policy *models.RetryPolicy) (time.Time, bool, error) {
+	delay := policy.NextDelay(job.RetryCount)
+	nextRetryAt := time.Now().UTC().Add(delay)
+
+	updates := map[string]interface{}{
+		"status":         models.JobStatusPending,
+		"retry_count":    job.RetryCount + 1,
+		"failure_reason": result.FailureReason,
+		"exit_code":      result.ExitCode,
+		"next_retry_at":  nextRetryAt,
+		"runner_id":      nil,
+		"started_at":     nil,
+		"finished_at":    nil,
+	}
+
+	requeued, err := s.repo.UpdateJobIfStatus(ctx, job.ID, models.JobStatusRunning, updates)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("重新排队作业失败: %w", err)
+	}
+	if !requeued {
+		return time.Time{}, false, nil
+	}
+
+	// 作业发现循环只会拾取已到重试时间的作业（见GetPendingJobs），
+	// 这里按退避时间直接提交，避免等待下一个轮询周期
+	scheduleJob := &ScheduleJob{
+		JobID:         job.ID,
+		PipelineRunID: job.PipelineRunID,
+		Name:          job.Name,
+		Stage:         string(job.Type),
+		Priority:      int(job.Priority),
+		CreatedAt:     job.CreatedAt,
+		Config:        job.Config,
+		MaxRetries:    policy.Max,
+		RetryCount:    job.RetryCount + 1,
+		RetryPolicy:   policy,
+	}
+	// 沿用首次派发时的配置和标签，避免重试作业被分配到不匹配的Runner
+	if original := s.dispatchedJob(job.ID); original != nil {
+		scheduleJob.Stage = original.Stage
+		scheduleJob.Priority = original.Priority
+		scheduleJob.RequiredTags = original.RequiredTags
+		scheduleJob.Config = original.Config
+		scheduleJob.Dependencies = original.Dependencies
+		scheduleJob.EstimatedDuration = original.EstimatedDuration
+		scheduleJob.ResourceRequests = original.ResourceRequests
+	}
+	if scheduleJob.Config == nil {
+		scheduleJob.Config = make(map[string]interface{})
+	}
+
+	time.AfterFunc(delay, func() {
+		if err := s.SubmitJob(scheduleJob); err != nil {
+			s.logger.Debug("重试作业提交失败，等待作业发现循环处理",
+				zap.String("job_id", job.ID.String()),
+				zap.Error(err))
+		}
+	})
+
+	return nextRetryAt, true, nil
+}
+
 // SubmitJob 提交作业到队列
 func (s *jobScheduler) SubmitJob(job *ScheduleJob) error {
 	s.mu.RLock()
@@ -612,8 +763,8 @@ func (s *jobScheduler) GetStatus() *SchedulerStatus {
 		IsRunning:       s.isRunning,
 		IsPaused:        s.isPaused,
 		StartedAt:       s.startedAt,
-		ProcessedJobs:   s.processedJobs,
-		FailedJobs:      s.failedJobs,
+		ProcessedJobs:   s.processedJobs.Load(),
+		FailedJobs:      s.failedJobs.Load(),
 		ActiveWorkers:   len(s.workers),
 		QueuedJobs:      totalQueuedJobs,
 		LastProcessedAt: s.lastProcessedAt,
@@ -756,7 +907,7 @@ func (s *jobScheduler) scheduleJob(ctx context.Context, job *ScheduleJob) {
 	runners, err := s.repo.GetAvailableRunners(ctx, job.RequiredTags)
 	if err != nil {
 		logger.Error("获取可用执行器失败", zap.Error(err))
-		s.failedJobs++
+		s.failedJobs.Add(1)
 		return
 	}
 
@@ -776,8 +927,10 @@ func (s *jobScheduler) scheduleJob(ctx context.Context, job *ScheduleJob) {
 	s.assignJobToWorker(job, &runner)
 
 	logger.Info("作业已分配", zap.String("runner_id", runner.ID.String()))
-	s.processedJobs++
+	s.processedJobs.Add(1)
+	s.mu.Lock()
 	s.lastProcessedAt = time.Now()
+	s.mu.Unlock()
 }
 
 // selectBestRunner 选择最佳执行器
@@ -898,8 +1051,8 @@ func (s *jobScheduler) discoverPendingJobs(ctx context.Context) {
 func (s *jobScheduler) updateStatistics() {
 	// 这里可以添加更复杂的统计逻辑
 	s.logger.Debug("更新调度器统计信息",
-		zap.Int64("processed_jobs", s.processedJobs),
-		zap.Int64("failed_jobs", s.failedJobs),
+		zap.Int64("processed_jobs", s.processedJobs.Load()),
+		zap.Int64("failed_jobs", s.failedJobs.Load()),
 		zap.Int("queued_jobs", len(s.readyJobs)))
 }
 
@@ -939,7 +1092,7 @@ func (w *worker) processJob(job *ScheduleJob) {
 
 	if err := w.scheduler.repo.UpdateJob(ctx, job.JobID, updates); err != nil {
 		logger.Error("更新作业状态失败", zap.Error(err))
-		w.scheduler.failedJobs++
+		w.scheduler.failedJobs.Add(1)
 		return
 	}
 
@@ -947,7 +1100,7 @@ func (w *worker) processJob(job *ScheduleJob) {
 	_, err := w.scheduler.repo.GetJobByID(ctx, job.JobID)
 	if err != nil {
 		logger.Error("获取作业详情失败", zap.Error(err))
-		w.scheduler.failedJobs++
+		w.scheduler.failedJobs.Add(1)
 		return
 	}
 
@@ -957,7 +1110,7 @@ func (w *worker) processJob(job *ScheduleJob) {
 		runners, err := w.scheduler.repo.GetAvailableRunners(ctx, job.RequiredTags)
 		if err != nil || len(runners) == 0 {
 			logger.Error("无可用Runner", zap.Error(err))
-			w.scheduler.failedJobs++
+			w.scheduler.failedJobs.Add(1)
 			return
 		}
 
@@ -975,18 +1128,27 @@ func (w *worker) processJob(job *ScheduleJob) {
 			Config:    job.Config,
 		}
 
+		w.scheduler.recordDispatchedJob(job)
+
 		// 发送作业到Runner
+		startedAt := time.Now().UTC()
 		if err := w.scheduler.runnerComm.SendJobToRunner(runner.ID, jobMessage); err != nil {
 			logger.Error("发送作业到Runner失败", zap.Error(err))
-			w.scheduler.failedJobs++
 
-			// 更新作业状态为失败
-			failUpdates := map[string]interface{}{
-				"status":      models.JobStatusFailed,
-				"finished_at": time.Now().UTC(),
-				"log_output":  fmt.Sprintf("发送作业到Runner失败: %v", err),
+			// 派发失败属于基础设施故障，交由重试策略处理
+			result := &engine.JobResult{
+				JobID:         job.JobID,
+				RunnerID:      runner.ID,
+				Status:        models.JobStatusFailed,
+				FailureReason: models.FailureReasonInfrastructure,
+				Output:        fmt.Sprintf("发送作业到Runner失败: %v", err),
+				ErrorMessage:  err.Error(),
+				StartedAt:     startedAt,
+				FinishedAt:    time.Now().UTC(),
+			}
+			if reportErr := w.scheduler.ReportJobResult(ctx, result); reportErr != nil {
+				logger.Error("上报作业失败结果失败", zap.Error(reportErr))
 			}
-			w.scheduler.repo.UpdateJob(ctx, job.JobID, failUpdates)
 			return
 		}
 
@@ -1009,7 +1171,7 @@ func (w *worker) processJob(job *ScheduleJob) {
 
 	if err := w.scheduler.repo.UpdateJob(ctx, job.JobID, finalUpdates); err != nil {
 		logger.Error("更新作业完成状态失败", zap.Error(err))
-		w.scheduler.failedJobs++
+		w.scheduler.failedJobs.Add(1)
 		return
 	}
 
@@ -1211,6 +1373,30 @@ func (s *jobScheduler) addJobExecution(execution *JobExecution) {
 	}
 }
 
+// recordDispatchedJob 记录已派发的作业，供重试时沿用
+func (s *jobScheduler) recordDispatchedJob(job *ScheduleJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dispatchedJobs[job.JobID] = job
+}
+
+// dispatchedJob 获取作业最近一次派发时的调度信息
+func (s *jobScheduler) dispatchedJob(jobID uuid.UUID) *ScheduleJob {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.dispatchedJobs[jobID]
+}
+
+// forgetDispatchedJob 作业结束后移除派发记录
+func (s *jobScheduler) forgetDispatchedJob(jobID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.dispatchedJobs, jobID)
+}
+
 // processPriorityQueues 处理优先级队列
 func (s *jobScheduler) processPriorityQueues(ctx context.Context) {
 	// 按优先级从高到低处理队列（10到1）
@@ -31,10 +31,12 @@ type PipelineService interface {
 	GetPipelineRunsByPipeline(ctx context.Context, pipelineID uuid.UUID, page, pageSize int) (*models.PipelineRunListResponse, error)
 	CancelPipelineRun(ctx context.Context, id uuid.UUID, userID uuid.UUID) error
 	RetryPipelineRun(ctx context.Context, id uuid.UUID, userID uuid.UUID) (*models.PipelineRun, error)
+	GetPipelineRunChain(ctx context.Context, id uuid.UUID) (*models.PipelineRunChain, error)
 
 	// 作业管理
 	GetJob(ctx context.Context, id uuid.UUID) (*models.Job, error)
 	GetJobsByPipelineRun(ctx context.Context, pipelineRunID uuid.UUID) ([]models.Job, error)
+	GetJobAttempts(ctx context.Context, jobID uuid.UUID) ([]models.JobExecution, error)
 	UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status models.JobStatus, runnerID uuid.UUID) error
 	UpdateJobOutput(ctx context.Context, jobID uuid.UUID, output string, exitCode *int) error
 
@@ -50,6 +52,8 @@ type PipelineService interface {
 	// 统计查询
 	GetPipelineStats(ctx context.Context, pipelineID uuid.UUID, days int) (*models.PipelineStats, error)
 	GetRunnerStats(ctx context.Context, runnerID uuid.UUID) (*models.RunnerStats, error)
+	GetPipelineAnalytics(ctx context.Context, pipelineID uuid.UUID, days int) (*models.PipelineAnalytics, error)
+	GetPipelineRunCriticalPath(ctx context.Context, runID uuid.UUID) (*models.CriticalPath, error)
 
 	// 后台任务
 	ProcessPendingJobs(ctx context.Context) error
@@ -398,6 +402,31 @@ func (s *pipelineService) RetryPipelineRun(ctx context.Context, id uuid.UUID, us
 	return newRun, nil
 }
 
+// GetPipelineRunChain 获取运行所在的完整触发链（从根运行开始的全部上下游运行）
+func (s *pipelineService) GetPipelineRunChain(ctx context.Context, id uuid.UUID) (*models.PipelineRunChain, error) {
+	ancestors, err := s.repo.GetPipelineRunAncestors(ctx, id)
+	if err != nil {
+		s.logger.Error("查询上游触发链失败", zap.Error(err), zap.String("run_id", id.String()))
+		return nil, fmt.Errorf("查询上游触发链失败: %w", err)
+	}
+	if len(ancestors) == 0 {
+		return nil, ErrPipelineRunNotFound
+	}
+
+	// 祖先按从自身到根排列，最后一个即根运行
+	rootID := ancestors[len(ancestors)-1].ID
+	runs, err := s.repo.GetPipelineRunDescendants(ctx, rootID)
+	if err != nil {
+		s.logger.Error("查询下游触发链失败", zap.Error(err), zap.String("root_run_id", rootID.String()))
+		return nil, fmt.Errorf("查询下游触发链失败: %w", err)
+	}
+
+	return &models.PipelineRunChain{
+		RootRunID: rootID,
+		Runs:      runs,
+	}, nil
+}
+
 // 验证函数
 
 // validateCreatePipelineRequest 验证创建流水线请求
@@ -494,6 +523,16 @@ func (s *pipelineService) GetJobsByPipelineRun(ctx context.Context, pipelineRunI
 	return jobs, nil
 }
 
+// GetJobAttempts 获取作业的全部执行尝试
+func (s *pipelineService) GetJobAttempts(ctx context.Context, jobID uuid.UUID) ([]models.JobExecution, error) {
+	attempts, err := s.repo.GetJobExecutions(ctx, jobID)
+	if err != nil {
+		s.logger.Error("获取作业执行记录失败", zap.Error(err), zap.String("job_id", jobID.String()))
+		return nil, fmt.Errorf("获取作业执行记录失败: %w", err)
+	}
+	return attempts, nil
+}
+
 // UpdateJobStatus 更新作业状态
 func (s *pipelineService) UpdateJobStatus(ctx context.Context, jobID uuid.UUID, status models.JobStatus, runnerID uuid.UUID) error {
 	updates := map[string]interface{}{
@@ -740,6 +779,46 @@ func (s *pipelineService) GetRunnerStats(ctx context.Context, runnerID uuid.UUID
 	return stats, nil
 }
 
+// analyticsTopN 失败作业与失败步骤排行的条数
+const analyticsTopN = 10
+
+// GetPipelineAnalytics 获取流水线分析数据（排队/执行耗时分位数、成功率趋势、失败排行、执行器利用率）
+func (s *pipelineService) GetPipelineAnalytics(ctx context.Context, pipelineID uuid.UUID, days int) (*models.PipelineAnalytics, error) {
+	if days <= 0 || days > 365 {
+		days = 30 // 默认30天
+	}
+
+	if _, err := s.repo.GetPipelineByID(ctx, pipelineID); err != nil {
+		return nil, ErrPipelineNotFound
+	}
+
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -days)
+	analytics, err := s.repo.GetPipelineAnalytics(ctx, pipelineID, from, to, analyticsTopN)
+	if err != nil {
+		s.logger.Error("获取流水线分析数据失败", zap.Error(err), zap.String("pipeline_id", pipelineID.String()))
+		return nil, fmt.Errorf("获取流水线分析数据失败: %w", err)
+	}
+
+	return analytics, nil
+}
+
+// GetPipelineRunCriticalPath 计算流水线运行的关键路径
+func (s *pipelineService) GetPipelineRunCriticalPath(ctx context.Context, runID uuid.UUID) (*models.CriticalPath, error) {
+	run, err := s.repo.GetPipelineRunByID(ctx, runID)
+	if err != nil {
+		return nil, ErrPipelineRunNotFound
+	}
+
+	path, err := models.ComputeCriticalPath(run.ID, run.Jobs, time.Now().UTC())
+	if err != nil {
+		s.logger.Error("计算关键路径失败", zap.Error(err), zap.String("run_id", runID.String()))
+		return nil, fmt.Errorf("计算关键路径失败: %w", err)
+	}
+
+	return path, nil
+}
+
 // 后台任务实现
 
 // ProcessPendingJobs 处理待执行作业
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CodeOwnersHandler 代码所有者处理器
+type CodeOwnersHandler struct {
+	codeOwnersService service.CodeOwnersService
+	logger            *zap.Logger
+}
+
+// NewCodeOwnersHandler 创建代码所有者处理器
+func NewCodeOwnersHandler(codeOwnersService service.CodeOwnersService, logger *zap.Logger) *CodeOwnersHandler {
+	return &CodeOwnersHandler{
+		codeOwnersService: codeOwnersService,
+		logger:            logger,
+	}
+}
+
+// GetCodeowners 获取分支中解析后的CODEOWNERS，包括无法解析的行
+func (h *CodeOwnersHandler) GetCodeowners(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	codeowners, err := h.codeOwnersService.GetCodeowners(c.Request.Context(), repositoryID, c.Query("ref"))
+	if err != nil {
+		h.respondCodeOwnersError(c, "Failed to get CODEOWNERS", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "CODEOWNERS retrieved successfully", codeowners)
+}
+
+// GetCodeOwners 获取分支比较中变更文件的代码所有者
+func (h *CodeOwnersHandler) GetCodeOwners(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	base := c.Query("base")
+	head := c.Query("head")
+	if base == "" || head == "" {
+		response.Error(c, http.StatusBadRequest, "Both base and head parameters are required", nil)
+		return
+	}
+
+	report, err := h.codeOwnersService.GetCodeOwners(c.Request.Context(), repositoryID, base, head)
+	if err != nil {
+		h.respondCodeOwnersError(c, "Failed to get code owners", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Code owners retrieved successfully", report)
+}
+
+// GetPullRequestApproval 获取PR的代码所有者批准状态
+func (h *CodeOwnersHandler) GetPullRequestApproval(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number <= 0 {
+		response.Error(c, http.StatusBadRequest, "Invalid pull request number", err)
+		return
+	}
+
+	approval, err := h.codeOwnersService.GetPullRequestApproval(c.Request.Context(), repositoryID, number)
+	if err != nil {
+		h.respondCodeOwnersError(c, "Failed to get code owner approval", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Code owner approval retrieved successfully", approval)
+}
+
+// respondCodeOwnersError 将代码所有者错误映射为HTTP状态码
+func (h *CodeOwnersHandler) respondCodeOwnersError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidRef):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRepositoryNotFound), errors.Is(err, models.ErrRefNotFound),
+		errors.Is(err, models.ErrCodeownersNotFound), errors.Is(err, models.ErrPullRequestNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CodeSearchHandler 代码搜索HTTP处理器
+type CodeSearchHandler struct {
+	codeSearchService service.CodeSearchService
+	logger            *zap.Logger
+}
+
+// NewCodeSearchHandler 创建代码搜索处理器
+func NewCodeSearchHandler(codeSearchService service.CodeSearchService, logger *zap.Logger) *CodeSearchHandler {
+	return &CodeSearchHandler{
+		codeSearchService: codeSearchService,
+		logger:            logger,
+	}
+}
+
+// SearchCode 搜索代码
+func (h *CodeSearchHandler) SearchCode(c *gin.Context) {
+	access, ok := searchAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	var query models.CodeSearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	for _, idStr := range c.QueryArray("repository_id") {
+		id, err := uuid.Parse(idStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+			return
+		}
+		query.RepositoryIDs = append(query.RepositoryIDs, id)
+	}
+
+	resp, err := h.codeSearchService.Search(c.Request.Context(), &query, access)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidSearchQuery) {
+			response.Error(c, http.StatusBadRequest, "Invalid search query", err)
+			return
+		}
+		h.logger.Error("搜索代码失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to search code", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Code search completed successfully", resp)
+}
+
+// GetIndexStatus 获取仓库代码索引状态
+func (h *CodeSearchHandler) GetIndexStatus(c *gin.Context) {
+	repositoryID, access, ok := h.parseRepositoryRequest(c)
+	if !ok {
+		return
+	}
+
+	branches, err := h.codeSearchService.GetIndexStatus(c.Request.Context(), repositoryID, access)
+	if err != nil {
+		h.respondError(c, "Failed to get index status", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Index status retrieved successfully", gin.H{"branches": branches})
+}
+
+// UpdateIndexedBranches 设置参与搜索的分支
+func (h *CodeSearchHandler) UpdateIndexedBranches(c *gin.Context) {
+	repositoryID, access, ok := h.parseRepositoryRequest(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSearchBranchesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	branches, err := h.codeSearchService.UpdateIndexedBranches(c.Request.Context(), repositoryID, req.Branches, access)
+	if err != nil {
+		h.respondError(c, "Failed to update indexed branches", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Indexed branches updated successfully", gin.H{"branches": branches})
+}
+
+// RebuildIndex 重建仓库代码索引
+func (h *CodeSearchHandler) RebuildIndex(c *gin.Context) {
+	repositoryID, access, ok := h.parseRepositoryRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.codeSearchService.RebuildIndex(c.Request.Context(), repositoryID, access); err != nil {
+		h.respondError(c, "Failed to rebuild index", err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Index rebuild started", nil)
+}
+
+// parseRepositoryRequest 解析仓库ID和当前用户
+func (h *CodeSearchHandler) parseRepositoryRequest(c *gin.Context) (uuid.UUID, models.CodeSearchAccess, bool) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return uuid.Nil, models.CodeSearchAccess{}, false
+	}
+
+	access, ok := searchAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return uuid.Nil, models.CodeSearchAccess{}, false
+	}
+
+	return repositoryID, access, true
+}
+
+// respondError 将索引管理错误映射为HTTP状态码
+func (h *CodeSearchHandler) respondError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrRepositoryNotReadable):
+		response.Error(c, http.StatusNotFound, "Repository not found", err)
+	case errors.Is(err, models.ErrRepositoryAccessDenied):
+		response.Error(c, http.StatusForbidden, message, err)
+	case errors.Is(err, models.ErrInvalidRef):
+		response.Error(c, http.StatusBadRequest, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// searchAccess 从认证信息中获取用户和租户
+func searchAccess(c *gin.Context) (models.CodeSearchAccess, bool) {
+	userID, ok := contextUUID(c, "user_id")
+	if !ok {
+		return models.CodeSearchAccess{}, false
+	}
+	tenantID, ok := contextUUID(c, "tenant_id")
+	if !ok {
+		return models.CodeSearchAccess{}, false
+	}
+	return models.CodeSearchAccess{UserID: userID, TenantID: tenantID}, true
+}
+
+// contextUUID 读取上下文中的UUID，兼容JWT（uuid.UUID）和字符串两种写入方式
+func contextUUID(c *gin.Context, key string) (uuid.UUID, bool) {
+	value, exists := c.Get(key)
+	if !exists {
+		return uuid.Nil, false
+	}
+	switch v := value.(type) {
+	case uuid.UUID:
+		return v, v != uuid.Nil
+	case string:
+		id, err := uuid.Parse(v)
+		return id, err == nil
+	default:
+		return uuid.Nil, false
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -37,23 +39,15 @@ func (h *GitHandler) CreateRepository(c *gin.Context) {
 		return
 	}
 
-	// 从JWT中获取用户ID（这里简化处理）
-	userIDStr, exists := c.Get("user_id")
-	if !exists {
+	access, ok := repositoryAccess(c)
+	if !ok {
 		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
 		return
 	}
 
-	userID, err := uuid.Parse(userIDStr.(string))
+	repository, err := h.gitService.CreateRepository(c.Request.Context(), &req, access)
 	if err != nil {
-		response.Error(c, http.StatusBadRequest, "Invalid user ID", err)
-		return
-	}
-
-	repository, err := h.gitService.CreateRepository(c.Request.Context(), &req, userID)
-	if err != nil {
-		h.logger.Error("Failed to create repository", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to create repository", err)
+		h.respondTemplateError(c, "Failed to create repository", err)
 		return
 	}
 
@@ -116,10 +110,15 @@ func (h *GitHandler) UpdateRepository(c *gin.Context) {
 		return
 	}
 
-	repository, err := h.gitService.UpdateRepository(c.Request.Context(), id, &req)
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	repository, err := h.gitService.UpdateRepository(c.Request.Context(), id, &req, access)
 	if err != nil {
-		h.logger.Error("Failed to update repository", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to update repository", err)
+		h.respondTransferError(c, "Failed to update repository", err)
 		return
 	}
 
@@ -203,10 +202,9 @@ func (h *GitHandler) CreateBranch(c *gin.Context) {
 		return
 	}
 
-	branch, err := h.gitService.CreateBranch(c.Request.Context(), repositoryID, &req)
+	branch, err := h.gitService.CreateBranch(refAuditContext(c), repositoryID, &req)
 	if err != nil {
-		h.logger.Error("Failed to create branch", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to create branch", err)
+		h.respondWriteError(c, "Failed to create branch", err)
 		return
 	}
 
@@ -272,9 +270,8 @@ func (h *GitHandler) DeleteBranch(c *gin.Context) {
 		return
 	}
 
-	if err := h.gitService.DeleteBranch(c.Request.Context(), repositoryID, branchName); err != nil {
-		h.logger.Error("Failed to delete branch", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to delete branch", err)
+	if err := h.gitService.DeleteBranch(refAuditContext(c), repositoryID, branchName); err != nil {
+		h.respondWriteError(c, "Failed to delete branch", err)
 		return
 	}
 
@@ -325,9 +322,8 @@ func (h *GitHandler) MergeBranch(c *gin.Context) {
 		return
 	}
 
-	if err := h.gitService.MergeBranch(c.Request.Context(), repositoryID, req.TargetBranch, req.SourceBranch); err != nil {
-		h.logger.Error("Failed to merge branch", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to merge branch", err)
+	if err := h.gitService.MergeBranch(refAuditContext(c), repositoryID, req.TargetBranch, req.SourceBranch); err != nil {
+		h.respondWriteError(c, "Failed to merge branch", err)
 		return
 	}
 
@@ -351,10 +347,9 @@ func (h *GitHandler) CreateCommit(c *gin.Context) {
 		return
 	}
 
-	commit, err := h.gitService.CreateCommit(c.Request.Context(), repositoryID, &req)
+	commit, err := h.gitService.CreateCommit(refAuditContext(c), repositoryID, &req)
 	if err != nil {
-		h.logger.Error("Failed to create commit", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to create commit", err)
+		h.respondWriteError(c, "Failed to create commit", err)
 		return
 	}
 
@@ -424,10 +419,15 @@ func (h *GitHandler) GetCommitDiff(c *gin.Context) {
 		return
 	}
 
-	diff, err := h.gitService.GetCommitDiff(c.Request.Context(), repositoryID, sha)
+	var opts models.DiffOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	diff, err := h.gitService.GetCommitDiff(c.Request.Context(), repositoryID, sha, &opts)
 	if err != nil {
-		h.logger.Error("Failed to get commit diff", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to get commit diff", err)
+		h.respondFileError(c, "Failed to get commit diff", err)
 		return
 	}
 
@@ -451,10 +451,15 @@ func (h *GitHandler) CompareBranches(c *gin.Context) {
 		return
 	}
 
-	diff, err := h.gitService.CompareBranches(c.Request.Context(), repositoryID, base, head)
+	var opts models.DiffOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+
+	diff, err := h.gitService.CompareBranches(c.Request.Context(), repositoryID, base, head, &opts)
 	if err != nil {
-		h.logger.Error("Failed to compare branches", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to compare branches", err)
+		h.respondFileError(c, "Failed to compare branches", err)
 		return
 	}
 
@@ -478,10 +483,9 @@ func (h *GitHandler) CreateTag(c *gin.Context) {
 		return
 	}
 
-	tag, err := h.gitService.CreateTag(c.Request.Context(), repositoryID, &req)
+	tag, err := h.gitService.CreateTag(refAuditContext(c), repositoryID, &req)
 	if err != nil {
-		h.logger.Error("Failed to create tag", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to create tag", err)
+		h.respondWriteError(c, "Failed to create tag", err)
 		return
 	}
 
@@ -547,9 +551,8 @@ func (h *GitHandler) DeleteTag(c *gin.Context) {
 		return
 	}
 
-	if err := h.gitService.DeleteTag(c.Request.Context(), repositoryID, tagName); err != nil {
-		h.logger.Error("Failed to delete tag", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to delete tag", err)
+	if err := h.gitService.DeleteTag(refAuditContext(c), repositoryID, tagName); err != nil {
+		h.respondWriteError(c, "Failed to delete tag", err)
 		return
 	}
 
@@ -626,3 +629,628 @@ func (h *GitHandler) GetDirectoryContent(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, "Directory content retrieved successfully", gin.H{"files": files})
 }
+
+// BlameFile 获取文件blame信息
+func (h *GitHandler) BlameFile(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	req := &models.BlameRequest{
+		Ref:  c.Query("branch"),
+		Path: c.Query("path"),
+	}
+	if req.Path == "" {
+		response.Error(c, http.StatusBadRequest, "Path parameter is required", nil)
+		return
+	}
+	if v := c.Query("start_line"); v != "" {
+		if req.StartLine, err = strconv.Atoi(v); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid start_line", err)
+			return
+		}
+	}
+	if v := c.Query("end_line"); v != "" {
+		if req.EndLine, err = strconv.Atoi(v); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid end_line", err)
+			return
+		}
+	}
+
+	blame, err := h.gitService.BlameFile(c.Request.Context(), repositoryID, req)
+	if err != nil {
+		h.respondFileError(c, "Failed to get blame", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Blame retrieved successfully", blame)
+}
+
+// GetFileHistory 获取文件提交历史
+func (h *GitHandler) GetFileHistory(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	filePath := c.Query("path")
+	if filePath == "" {
+		response.Error(c, http.StatusBadRequest, "Path parameter is required", nil)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	resp, err := h.gitService.GetFileHistory(c.Request.Context(), repositoryID, c.Query("branch"), filePath, page, pageSize)
+	if err != nil {
+		h.respondFileError(c, "Failed to get file history", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "File history retrieved successfully", resp)
+}
+
+// respondFileError 将文件和差异查询错误映射为HTTP状态码
+func (h *GitHandler) respondFileError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidRef), errors.Is(err, models.ErrInvalidPath), errors.Is(err, models.ErrInvalidLineRange),
+		errors.Is(err, models.ErrInvalidDiffOptions):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRefNotFound), errors.Is(err, models.ErrFileNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// DownloadArchive 下载仓库归档
+// 路径形如 /repositories/:id/archive/<ref>.tar.gz，ref可包含斜杠
+func (h *GitHandler) DownloadArchive(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	ref, format, err := models.ParseArchiveName(c.Param("ref"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid archive name", err)
+		return
+	}
+
+	req := &models.ArchiveRequest{
+		Ref:    ref,
+		Format: format,
+		Path:   c.Query("path"),
+		Prefix: c.Query("prefix"),
+	}
+
+	archive, err := h.gitService.ResolveArchive(c.Request.Context(), repositoryID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrArchiveFormat), errors.Is(err, models.ErrInvalidRef), errors.Is(err, models.ErrInvalidPath):
+			response.Error(c, http.StatusBadRequest, "Invalid archive request", err)
+		case errors.Is(err, models.ErrArchiveNotFound), errors.Is(err, models.ErrRefNotFound):
+			response.Error(c, http.StatusNotFound, "Ref or path not found", err)
+		default:
+			h.logger.Error("Failed to resolve archive", zap.Error(err))
+			response.Error(c, http.StatusNotFound, "Repository not found", err)
+		}
+		return
+	}
+
+	etag := archive.ETag()
+	c.Header("ETag", etag)
+	c.Header("X-Commit-SHA", archive.CommitSHA)
+	if archive.Immutable() {
+		c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	} else {
+		// 分支和标签可能移动，每次都需按提交SHA重新验证
+		c.Header("Cache-Control", "private, no-cache")
+	}
+
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Content-Type", archive.Format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, archive.Filename))
+
+	if c.Request.Method == http.MethodHead {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Status(http.StatusOK)
+	if err := h.gitService.StreamArchive(c.Request.Context(), archive, c.Writer); err != nil {
+		h.logger.Error("Failed to stream archive",
+			zap.String("repository_id", repositoryID.String()),
+			zap.String("ref", archive.Ref),
+			zap.Error(err))
+		// 尚未输出内容时仍可返回错误响应，否则只能中断连接
+		if !c.Writer.Written() {
+			c.Header("Content-Disposition", "")
+			response.Error(c, http.StatusInternalServerError, "Failed to create archive", err)
+		}
+	}
+}
+
+// etagMatches 判断If-None-Match是否包含指定ETag
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Fork管理处理器
+
+// ForkRepository fork仓库到指定项目
+func (h *GitHandler) ForkRepository(c *gin.Context) {
+	sourceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var req models.ForkRepositoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	fork, err := h.gitService.ForkRepository(c.Request.Context(), sourceID, &req, access)
+	if err != nil {
+		h.respondForkError(c, "Failed to fork repository", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Repository forked successfully", fork)
+}
+
+// ListForks 获取仓库的fork列表
+func (h *GitHandler) ListForks(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	resp, err := h.gitService.ListForks(c.Request.Context(), repositoryID, page, pageSize)
+	if err != nil {
+		h.respondForkError(c, "Failed to list forks", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Forks retrieved successfully", resp)
+}
+
+// SyncFork 将上游默认分支同步到fork
+func (h *GitHandler) SyncFork(c *gin.Context) {
+	forkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var req models.SyncForkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	result, err := h.gitService.SyncFork(refAuditContext(c), forkID, &req, access)
+	if err != nil {
+		// 分叉或冲突时同时返回比较结果，便于调用方提示用户
+		if errors.Is(err, models.ErrForkDiverged) || errors.Is(err, models.ErrForkSyncConflict) {
+			c.JSON(http.StatusConflict, response.Response{
+				Code:    http.StatusConflict,
+				Message: "Fork cannot be synced automatically",
+				Data:    result,
+				Error: &response.ErrorInfo{
+					Type:    http.StatusText(http.StatusConflict),
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		h.respondForkError(c, "Failed to sync fork", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Fork synced successfully", result)
+}
+
+// CreatePullRequest 创建PR，源分支可来自fork
+func (h *GitHandler) CreatePullRequest(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var req models.CreatePullRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	pr, err := h.gitService.CreatePullRequest(c.Request.Context(), repositoryID, &req, access)
+	if err != nil {
+		h.respondForkError(c, "Failed to create pull request", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Pull request created successfully", pr)
+}
+
+// ListPullRequests 获取仓库的PR列表，status 可选 open、closed、merged、draft
+func (h *GitHandler) ListPullRequests(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	status, page, pageSize, ok := pullRequestListQuery(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.gitService.ListPullRequests(c.Request.Context(), repositoryID, status, page, pageSize)
+	if err != nil {
+		h.respondForkError(c, "Failed to list pull requests", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Pull requests retrieved successfully", resp)
+}
+
+// ListProjectPullRequests 获取项目下所有仓库中标题或源分支引用了任务编号（reference，如 PROJ-123）的PR
+func (h *GitHandler) ListProjectPullRequests(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+	reference := c.Query("reference")
+	if reference == "" {
+		response.Error(c, http.StatusBadRequest, "Reference is required", nil)
+		return
+	}
+
+	status, page, pageSize, ok := pullRequestListQuery(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.gitService.ListProjectPullRequests(c.Request.Context(), projectID, status, reference, page, pageSize)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPullRequestReference) {
+			response.Error(c, http.StatusBadRequest, "Invalid reference", err)
+			return
+		}
+		h.logger.Error("Failed to list project pull requests", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to list pull requests", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Pull requests retrieved successfully", resp)
+}
+
+// pullRequestListQuery 解析PR列表的状态过滤和分页参数，status 可选 open、closed、merged、draft
+func pullRequestListQuery(c *gin.Context) (*models.PullRequestStatus, int, int, bool) {
+	var status *models.PullRequestStatus
+	if value := c.Query("status"); value != "" {
+		s := models.PullRequestStatus(value)
+		switch s {
+		case models.PullRequestStatusOpen, models.PullRequestStatusClosed, models.PullRequestStatusMerged, models.PullRequestStatusDraft:
+			status = &s
+		default:
+			response.Error(c, http.StatusBadRequest, "Invalid pull request status", nil)
+			return nil, 0, 0, false
+		}
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return status, page, pageSize, true
+}
+
+// 模板管理处理器
+
+// SetRepositoryTemplate 将仓库设为模板或更新模板设置
+func (h *GitHandler) SetRepositoryTemplate(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var req models.SetRepositoryTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	template, err := h.gitService.SetRepositoryTemplate(c.Request.Context(), repositoryID, &req, access)
+	if err != nil {
+		h.respondTemplateError(c, "Failed to set repository template", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Repository template saved successfully", template)
+}
+
+// GetRepositoryTemplate 获取仓库模板设置
+func (h *GitHandler) GetRepositoryTemplate(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	template, err := h.gitService.GetRepositoryTemplate(c.Request.Context(), repositoryID)
+	if err != nil {
+		if errors.Is(err, models.ErrNotATemplate) {
+			response.Error(c, http.StatusNotFound, "Repository is not a template", err)
+			return
+		}
+		h.respondTemplateError(c, "Failed to get repository template", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Repository template retrieved successfully", template)
+}
+
+// DeleteRepositoryTemplate 取消仓库的模板标记
+func (h *GitHandler) DeleteRepositoryTemplate(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.gitService.DeleteRepositoryTemplate(c.Request.Context(), repositoryID, access); err != nil {
+		if errors.Is(err, models.ErrNotATemplate) {
+			response.Error(c, http.StatusNotFound, "Repository is not a template", err)
+			return
+		}
+		h.respondTemplateError(c, "Failed to delete repository template", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Repository template removed successfully", nil)
+}
+
+// 迁移与归档处理器
+
+// TransferRepository 迁移仓库到另一个项目
+func (h *GitHandler) TransferRepository(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var req models.TransferRepositoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	repo, err := h.gitService.TransferRepository(c.Request.Context(), repositoryID, &req, access)
+	if err != nil {
+		h.respondTransferError(c, "Failed to transfer repository", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Repository transferred successfully", repo)
+}
+
+// ArchiveRepository 归档仓库，归档后只读
+func (h *GitHandler) ArchiveRepository(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// UnarchiveRepository 取消归档
+func (h *GitHandler) UnarchiveRepository(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+// setArchived 切换仓库归档状态
+func (h *GitHandler) setArchived(c *gin.Context, archived bool) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if archived {
+		repo, err := h.gitService.ArchiveRepository(c.Request.Context(), repositoryID, access)
+		if err != nil {
+			h.respondTransferError(c, "Failed to archive repository", err)
+			return
+		}
+		response.Success(c, http.StatusOK, "Repository archived successfully", repo)
+		return
+	}
+
+	repo, err := h.gitService.UnarchiveRepository(c.Request.Context(), repositoryID, access)
+	if err != nil {
+		h.respondTransferError(c, "Failed to unarchive repository", err)
+		return
+	}
+	response.Success(c, http.StatusOK, "Repository unarchived successfully", repo)
+}
+
+// ResolveRepository 按项目和名称查找仓库，改名或迁移前的旧名称同样可以解析
+func (h *GitHandler) ResolveRepository(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Query("project_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+	name := c.Query("name")
+	if name == "" {
+		response.Error(c, http.StatusBadRequest, "Repository name is required", nil)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	resolution, err := h.gitService.ResolveRepository(c.Request.Context(), projectID, name, access)
+	if err != nil {
+		h.respondTransferError(c, "Failed to resolve repository", err)
+		return
+	}
+	if resolution.Redirected {
+		c.Header("Location", "/api/v1/repositories/"+resolution.Repository.ID.String())
+	}
+
+	response.Success(c, http.StatusOK, "Repository resolved successfully", resolution)
+}
+
+// respondTransferError 将迁移、改名和归档错误映射为HTTP状态码
+func (h *GitHandler) respondTransferError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidRepositoryName):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRepositoryArchived), errors.Is(err, models.ErrRepositoryAccessDenied),
+		errors.Is(err, models.ErrProjectAccessDenied):
+		response.Error(c, http.StatusForbidden, message, err)
+	case errors.Is(err, models.ErrRepositoryNameTaken):
+		response.Error(c, http.StatusConflict, message, err)
+	case errors.Is(err, models.ErrRepositoryNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// respondTemplateError 将模板和从模板创建仓库的错误映射为HTTP状态码
+func (h *GitHandler) respondTemplateError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidTemplate), errors.Is(err, models.ErrTemplateVariable),
+		errors.Is(err, models.ErrInvalidTemplatePath), errors.Is(err, models.ErrNotATemplate),
+		errors.Is(err, models.ErrInvalidRef):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRepositoryAccessDenied), errors.Is(err, models.ErrProjectAccessDenied):
+		response.Error(c, http.StatusForbidden, message, err)
+	case errors.Is(err, models.ErrRepositoryNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// respondForkError 将fork和PR错误映射为HTTP状态码
+func (h *GitHandler) respondForkError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidRef), errors.Is(err, models.ErrNotAFork),
+		errors.Is(err, models.ErrInvalidSyncStrategy), errors.Is(err, models.ErrInvalidPullRequestSource):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrForkVisibility), errors.Is(err, models.ErrRepositoryReadOnly),
+		errors.Is(err, models.ErrRepositoryArchived), errors.Is(err, models.ErrRepositoryAccessDenied),
+		errors.Is(err, models.ErrProjectAccessDenied):
+		response.Error(c, http.StatusForbidden, message, err)
+	case errors.Is(err, models.ErrRepositoryNameTaken):
+		response.Error(c, http.StatusConflict, message, err)
+	case errors.Is(err, models.ErrRefNotFound), errors.Is(err, models.ErrRepositoryNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// repositoryAccess 从认证信息中获取用户和租户
+func repositoryAccess(c *gin.Context) (models.RepositoryAccess, bool) {
+	userID, ok := contextUUID(c, "user_id")
+	if !ok {
+		return models.RepositoryAccess{}, false
+	}
+	tenantID, ok := contextUUID(c, "tenant_id")
+	if !ok {
+		return models.RepositoryAccess{}, false
+	}
+	return models.RepositoryAccess{UserID: userID, TenantID: tenantID}, true
+}
+
+// respondWriteError 响应写操作错误，只读镜像和已归档仓库返回403
+func (h *GitHandler) respondWriteError(c *gin.Context, message string, err error) {
+	if errors.Is(err, models.ErrRepositoryReadOnly) || errors.Is(err, models.ErrRepositoryArchived) {
+		response.Error(c, http.StatusForbidden, message, err)
+		return
+	}
+	h.logger.Error(message, zap.Error(err))
+	response.Error(c, http.StatusInternalServerError, message, err)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// uploadPackService 智能HTTP协议中克隆和拉取使用的服务名
+const uploadPackService = "git-upload-pack"
+
+// GitHTTPHandler Git智能HTTP传输处理器
+// 路径与仓库克隆地址一致（/<project_id>/<name>.git），改名或迁移前的旧地址沿重定向解析
+type GitHTTPHandler struct {
+	gitService service.GitService
+	jwtService *auth.JWTService
+	logger     *zap.Logger
+}
+
+// NewGitHTTPHandler 创建Git智能HTTP传输处理器
+func NewGitHTTPHandler(gitService service.GitService, jwtService *auth.JWTService, logger *zap.Logger) *GitHTTPHandler {
+	return &GitHTTPHandler{
+		gitService: gitService,
+		jwtService: jwtService,
+		logger:     logger,
+	}
+}
+
+// InfoRefs 引用通告，克隆和拉取的第一步
+func (h *GitHTTPHandler) InfoRefs(c *gin.Context) {
+	if c.Query("service") != uploadPackService {
+		c.String(http.StatusForbidden, "Unsupported service\n")
+		return
+	}
+
+	repo, ok := h.resolve(c)
+	if !ok {
+		return
+	}
+
+	protocol := c.GetHeader("Git-Protocol")
+	c.Header("Content-Type", "application/x-"+uploadPackService+"-advertisement")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	// 协议v2的能力通告由 upload-pack 自行输出，不带服务头
+	if !strings.Contains(protocol, "version=2") {
+		if _, err := io.WriteString(c.Writer, pktLine("# service="+uploadPackService+"\n")+"0000"); err != nil {
+			return
+		}
+	}
+	if err := h.gitService.UploadPack(c.Request.Context(), repo, true, protocol, http.NoBody, c.Writer); err != nil {
+		h.logger.Error("Failed to advertise refs",
+			zap.String("repository_id", repo.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// UploadPack 处理克隆和拉取的打包请求
+func (h *GitHTTPHandler) UploadPack(c *gin.Context) {
+	repo, ok := h.resolve(c)
+	if !ok {
+		return
+	}
+
+	body := io.Reader(c.Request.Body)
+	if c.GetHeader("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Invalid gzip request body\n")
+			return
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	c.Header("Content-Type", "application/x-"+uploadPackService+"-result")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	if err := h.gitService.UploadPack(c.Request.Context(), repo, false, c.GetHeader("Git-Protocol"), body, c.Writer); err != nil {
+		h.logger.Error("Failed to serve upload-pack",
+			zap.String("repository_id", repo.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// resolve 认证Git客户端并按克隆地址解析仓库，不可读的仓库按不存在处理
+func (h *GitHTTPHandler) resolve(c *gin.Context) (*models.Repository, bool) {
+	claims, ok := authenticateGitClient(c, h.jwtService)
+	if !ok {
+		c.Header("WWW-Authenticate", `Basic realm="Git"`)
+		c.String(http.StatusUnauthorized, "Authentication required\n")
+		return nil, false
+	}
+
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		c.String(http.StatusNotFound, "Repository not found\n")
+		return nil, false
+	}
+
+	access := models.RepositoryAccess{UserID: claims.UserID, TenantID: claims.TenantID}
+	resolution, err := h.gitService.ResolveRepository(c.Request.Context(), projectID, c.Param("repo"), access)
+	if err != nil {
+		if errors.Is(err, models.ErrRepositoryNotFound) {
+			c.String(http.StatusNotFound, "Repository not found\n")
+			return nil, false
+		}
+		h.logger.Error("Failed to resolve repository", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to resolve repository\n")
+		return nil, false
+	}
+	return resolution.Repository, true
+}
+
+// pktLine 按Git pkt-line格式编码一行：4位十六进制长度（含自身）加内容
+func pktLine(line string) string {
+	return fmt.Sprintf("%04x%s", len(line)+4, line)
+}
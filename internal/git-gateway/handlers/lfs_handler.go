@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// LFSHandler Git LFS处理器
+// 批量接口和对象传输接口遵循Git LFS协议，错误以LFS格式返回；管理接口使用平台统一响应
+type LFSHandler struct {
+	lfsService service.LFSService
+	jwtService *auth.JWTService
+	logger     *zap.Logger
+}
+
+// NewLFSHandler 创建Git LFS处理器
+func NewLFSHandler(lfsService service.LFSService, jwtService *auth.JWTService, logger *zap.Logger) *LFSHandler {
+	return &LFSHandler{
+		lfsService: lfsService,
+		jwtService: jwtService,
+		logger:     logger,
+	}
+}
+
+// Batch Git LFS批量接口
+// git-lfs客户端以 Basic（密码为访问令牌）或 Bearer 方式携带平台访问令牌
+func (h *LFSHandler) Batch(c *gin.Context) {
+	claims, ok := h.authenticate(c)
+	if !ok {
+		c.Header("LFS-Authenticate", `Basic realm="Git LFS"`)
+		h.respondLFS(c, http.StatusUnauthorized, models.LFSErrorResponse{Message: "Credentials needed"})
+		return
+	}
+
+	projectID, err := uuid.Parse(c.Param("project_id"))
+	if err != nil {
+		h.respondLFS(c, http.StatusNotFound, models.LFSErrorResponse{Message: "Repository not found"})
+		return
+	}
+
+	var req models.LFSBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondLFS(c, http.StatusUnprocessableEntity, models.LFSErrorResponse{Message: "Invalid request body: " + err.Error()})
+		return
+	}
+
+	access := models.LFSAccess{UserID: claims.UserID, TenantID: claims.TenantID}
+	resp, err := h.lfsService.Batch(c.Request.Context(), projectID, c.Param("repo"), &req, access)
+	if err != nil {
+		h.respondLFSError(c, "Failed to process batch request", err)
+		return
+	}
+
+	h.respondLFS(c, http.StatusOK, resp)
+}
+
+// UploadObject 上传对象内容
+func (h *LFSHandler) UploadObject(c *gin.Context) {
+	repositoryID, oid, ok := h.authorizeObject(c, models.LFSOperationUpload)
+	if !ok {
+		return
+	}
+
+	size := c.Request.ContentLength
+	if size < 0 {
+		h.respondLFS(c, http.StatusLengthRequired, models.LFSErrorResponse{Message: "Content-Length is required"})
+		return
+	}
+
+	if err := h.lfsService.UploadObject(c.Request.Context(), repositoryID, oid, size, c.Request.Body); err != nil {
+		h.respondLFSError(c, "Failed to upload object", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DownloadObject 下载对象内容，支持Range请求
+func (h *LFSHandler) DownloadObject(c *gin.Context) {
+	repositoryID, oid, ok := h.authorizeObject(c, models.LFSOperationDownload)
+	if !ok {
+		return
+	}
+
+	content, err := h.lfsService.DownloadObject(c.Request.Context(), repositoryID, oid)
+	if err != nil {
+		h.respondLFSError(c, "Failed to download object", err)
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Type", "application/octet-stream")
+	http.ServeContent(c.Writer, c.Request, oid, time.Time{}, content)
+}
+
+// VerifyObject 上传完成后确认对象，使用上传令牌
+func (h *LFSHandler) VerifyObject(c *gin.Context) {
+	repositoryID, oid, ok := h.authorizeObject(c, models.LFSOperationUpload)
+	if !ok {
+		return
+	}
+
+	var object models.LFSObjectSpec
+	if err := c.ShouldBindJSON(&object); err != nil || object.OID != oid {
+		h.respondLFS(c, http.StatusUnprocessableEntity, models.LFSErrorResponse{Message: "Invalid verify request"})
+		return
+	}
+
+	if err := h.lfsService.VerifyObject(c.Request.Context(), repositoryID, &object); err != nil {
+		h.respondLFSError(c, "Failed to verify object", err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListObjects 分页获取仓库的LFS对象
+func (h *LFSHandler) ListObjects(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	resp, err := h.lfsService.ListObjects(c.Request.Context(), repositoryID, page, pageSize)
+	if err != nil {
+		if errors.Is(err, models.ErrRepositoryNotFound) {
+			response.Error(c, http.StatusNotFound, "Repository not found", err)
+			return
+		}
+		h.logger.Error("Failed to list LFS objects", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to list LFS objects", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "LFS objects retrieved successfully", resp)
+}
+
+// CollectGarbage 立即执行LFS垃圾回收
+func (h *LFSHandler) CollectGarbage(c *gin.Context) {
+	result, err := h.lfsService.CollectGarbage(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to collect LFS garbage", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to collect LFS garbage", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "LFS garbage collection completed", result)
+}
+
+// authenticate 从 Authorization 头解析平台访问令牌
+func (h *LFSHandler) authenticate(c *gin.Context) (*auth.Claims, bool) {
+	return authenticateGitClient(c, h.jwtService)
+}
+
+// authenticateGitClient 解析Git客户端携带的平台访问令牌，支持 Bearer 和 Basic（密码为访问令牌）
+func authenticateGitClient(c *gin.Context, jwtService *auth.JWTService) (*auth.Claims, bool) {
+	header := c.GetHeader("Authorization")
+
+	var token string
+	switch {
+	case strings.HasPrefix(header, "Bearer "):
+		token = strings.TrimPrefix(header, "Bearer ")
+	case strings.HasPrefix(header, "Basic "):
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, "Basic "))
+		if err != nil {
+			return nil, false
+		}
+		_, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, false
+		}
+		token = password
+	default:
+		return nil, false
+	}
+
+	claims, err := jwtService.ValidateToken(token)
+	if err != nil {
+		return nil, false
+	}
+	return claims, true
+}
+
+// authorizeObject 校验对象传输令牌与请求的仓库、对象和操作一致
+func (h *LFSHandler) authorizeObject(c *gin.Context, operation string) (uuid.UUID, string, bool) {
+	repositoryID, err := uuid.Parse(c.Param("repository_id"))
+	if err != nil {
+		h.respondLFS(c, http.StatusNotFound, models.LFSErrorResponse{Message: "Repository not found"})
+		return uuid.Nil, "", false
+	}
+	oid := c.Param("oid")
+	if err := models.ValidateLFSOID(oid); err != nil {
+		h.respondLFS(c, http.StatusUnprocessableEntity, models.LFSErrorResponse{Message: err.Error()})
+		return uuid.Nil, "", false
+	}
+
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	claims, err := h.jwtService.ValidateLFSToken(token)
+	if err != nil {
+		h.respondLFS(c, http.StatusUnauthorized, models.LFSErrorResponse{Message: "Invalid or expired object token"})
+		return uuid.Nil, "", false
+	}
+	if !claims.Allows(repositoryID, oid, operation) {
+		h.respondLFS(c, http.StatusForbidden, models.LFSErrorResponse{Message: "Token does not grant access to this object"})
+		return uuid.Nil, "", false
+	}
+
+	return repositoryID, oid, true
+}
+
+// respondLFSError 将LFS错误映射为HTTP状态码
+func (h *LFSHandler) respondLFSError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrLFSInvalidOperation), errors.Is(err, models.ErrLFSInvalidObject),
+		errors.Is(err, models.ErrLFSSizeMismatch):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrRepositoryNotFound), errors.Is(err, models.ErrLFSObjectNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrLFSAccessDenied), errors.Is(err, models.ErrRepositoryReadOnly),
+		errors.Is(err, models.ErrRepositoryArchived):
+		status = http.StatusForbidden
+	case errors.Is(err, models.ErrLFSQuotaExceeded):
+		status = http.StatusInsufficientStorage
+	default:
+		h.logger.Error(message, zap.Error(err))
+		h.respondLFS(c, status, models.LFSErrorResponse{Message: message})
+		return
+	}
+
+	h.respondLFS(c, status, models.LFSErrorResponse{Message: message + ": " + err.Error()})
+}
+
+// respondLFS 以LFS媒体类型输出JSON
+func (h *LFSHandler) respondLFS(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", models.LFSMediaType)
+	c.JSON(status, body)
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MaintenanceHandler 仓库维护处理器
+type MaintenanceHandler struct {
+	maintenanceService service.MaintenanceService
+	logger             *zap.Logger
+}
+
+// NewMaintenanceHandler 创建仓库维护处理器
+func NewMaintenanceHandler(maintenanceService service.MaintenanceService, logger *zap.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+		logger:             logger,
+	}
+}
+
+// TriggerMaintenance 立即维护仓库，维护在后台进行
+func (h *MaintenanceHandler) TriggerMaintenance(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var req models.TriggerMaintenanceRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+	}
+
+	userID, ok := contextUUID(c, "user_id")
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	run, err := h.maintenanceService.TriggerMaintenance(c.Request.Context(), repositoryID, &req, userID)
+	if err != nil {
+		h.respondMaintenanceError(c, "Failed to start maintenance", err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Repository maintenance started", run)
+}
+
+// GetStatus 获取仓库维护状态、健康状态和最近的维护记录
+func (h *MaintenanceHandler) GetStatus(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	status, err := h.maintenanceService.GetStatus(c.Request.Context(), repositoryID)
+	if err != nil {
+		h.respondMaintenanceError(c, "Failed to get maintenance status", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Maintenance status retrieved successfully", status)
+}
+
+// ListRuns 分页获取仓库的维护记录
+func (h *MaintenanceHandler) ListRuns(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	resp, err := h.maintenanceService.ListRuns(c.Request.Context(), repositoryID, page, pageSize)
+	if err != nil {
+		h.respondMaintenanceError(c, "Failed to list maintenance runs", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Maintenance runs retrieved successfully", resp)
+}
+
+// respondMaintenanceError 将仓库维护错误映射为HTTP状态码
+func (h *MaintenanceHandler) respondMaintenanceError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidMaintenanceMode):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRepositoryNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	case errors.Is(err, models.ErrMaintenanceRunning):
+		response.Error(c, http.StatusConflict, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MirrorHandler 仓库导入与镜像处理器
+type MirrorHandler struct {
+	mirrorService service.MirrorService
+	logger        *zap.Logger
+}
+
+// NewMirrorHandler 创建仓库导入与镜像处理器
+func NewMirrorHandler(mirrorService service.MirrorService, logger *zap.Logger) *MirrorHandler {
+	return &MirrorHandler{
+		mirrorService: mirrorService,
+		logger:        logger,
+	}
+}
+
+// ImportRepository 从外部Git远程导入仓库，导入在后台进行
+func (h *MirrorHandler) ImportRepository(c *gin.Context) {
+	var req models.ImportRepositoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	resp, err := h.mirrorService.ImportRepository(c.Request.Context(), &req, access)
+	if err != nil {
+		h.respondMirrorError(c, "Failed to import repository", err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Repository import started", resp)
+}
+
+// GetImportStatus 获取仓库导入状态和进度
+func (h *MirrorHandler) GetImportStatus(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	imp, err := h.mirrorService.GetImportStatus(c.Request.Context(), repositoryID, access)
+	if err != nil {
+		h.respondMirrorError(c, "Failed to get import status", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Import status retrieved successfully", imp)
+}
+
+// CreateMirror 创建拉取或推送镜像
+func (h *MirrorHandler) CreateMirror(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var req models.CreateMirrorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	mirror, err := h.mirrorService.CreateMirror(c.Request.Context(), repositoryID, &req, access)
+	if err != nil {
+		h.respondMirrorError(c, "Failed to create mirror", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Mirror created successfully", mirror)
+}
+
+// ListMirrors 获取仓库镜像及同步状态
+func (h *MirrorHandler) ListMirrors(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	mirrors, err := h.mirrorService.ListMirrors(c.Request.Context(), repositoryID, access)
+	if err != nil {
+		h.respondMirrorError(c, "Failed to list mirrors", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Mirrors retrieved successfully", mirrors)
+}
+
+// UpdateMirror 更新镜像配置
+func (h *MirrorHandler) UpdateMirror(c *gin.Context) {
+	repositoryID, mirrorID, ok := parseMirrorParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateMirrorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	mirror, err := h.mirrorService.UpdateMirror(c.Request.Context(), repositoryID, mirrorID, &req, access)
+	if err != nil {
+		h.respondMirrorError(c, "Failed to update mirror", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Mirror updated successfully", mirror)
+}
+
+// DeleteMirror 删除镜像
+func (h *MirrorHandler) DeleteMirror(c *gin.Context) {
+	repositoryID, mirrorID, ok := parseMirrorParams(c)
+	if !ok {
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.mirrorService.DeleteMirror(c.Request.Context(), repositoryID, mirrorID, access); err != nil {
+		h.respondMirrorError(c, "Failed to delete mirror", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Mirror deleted successfully", nil)
+}
+
+// SyncMirror 立即触发镜像同步
+func (h *MirrorHandler) SyncMirror(c *gin.Context) {
+	repositoryID, mirrorID, ok := parseMirrorParams(c)
+	if !ok {
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.mirrorService.SyncMirror(c.Request.Context(), repositoryID, mirrorID, access); err != nil {
+		h.respondMirrorError(c, "Failed to sync mirror", err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Mirror sync started", nil)
+}
+
+// parseMirrorParams 解析路径中的仓库ID和镜像ID
+func parseMirrorParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	mirrorID, err := uuid.Parse(c.Param("mirror_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid mirror ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return repositoryID, mirrorID, true
+}
+
+// respondMirrorError 将导入和镜像错误映射为HTTP状态码
+func (h *MirrorHandler) respondMirrorError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidRemoteURL), errors.Is(err, models.ErrInvalidMirrorInterval):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRepositoryNameTaken), errors.Is(err, models.ErrPullMirrorExists),
+		errors.Is(err, models.ErrMirrorDisabled):
+		response.Error(c, http.StatusConflict, message, err)
+	case errors.Is(err, models.ErrRepositoryNotFound), errors.Is(err, models.ErrMirrorNotFound),
+		errors.Is(err, models.ErrImportNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	case errors.Is(err, models.ErrRepositoryAccessDenied), errors.Is(err, models.ErrProjectAccessDenied):
+		response.Error(c, http.StatusForbidden, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
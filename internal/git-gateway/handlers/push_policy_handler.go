@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxPreReceiveInput pre-receive输入大小上限
+const maxPreReceiveInput = 1 << 20
+
+// PushPolicyHandler 推送策略处理器
+type PushPolicyHandler struct {
+	pushPolicyService service.PushPolicyService
+	logger            *zap.Logger
+}
+
+// NewPushPolicyHandler 创建推送策略处理器
+func NewPushPolicyHandler(pushPolicyService service.PushPolicyService, logger *zap.Logger) *PushPolicyHandler {
+	return &PushPolicyHandler{
+		pushPolicyService: pushPolicyService,
+		logger:            logger,
+	}
+}
+
+// GetPolicy 获取项目或仓库的推送策略
+func (h *PushPolicyHandler) GetPolicy(c *gin.Context) {
+	scope, scopeID, ok := parsePolicyScope(c)
+	if !ok {
+		return
+	}
+
+	policy, err := h.pushPolicyService.GetPolicy(c.Request.Context(), scope, scopeID)
+	if err != nil {
+		h.respondPolicyError(c, "Failed to get push policy", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Push policy retrieved successfully", policy)
+}
+
+// SetPolicy 设置项目或仓库的推送策略
+func (h *PushPolicyHandler) SetPolicy(c *gin.Context) {
+	scope, scopeID, ok := parsePolicyScope(c)
+	if !ok {
+		return
+	}
+
+	var req models.PushPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	policy, err := h.pushPolicyService.SetPolicy(c.Request.Context(), scope, scopeID, &req, access)
+	if err != nil {
+		h.respondPolicyError(c, "Failed to set push policy", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Push policy updated successfully", policy)
+}
+
+// DeletePolicy 删除项目或仓库的推送策略
+func (h *PushPolicyHandler) DeletePolicy(c *gin.Context) {
+	scope, scopeID, ok := parsePolicyScope(c)
+	if !ok {
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.pushPolicyService.DeletePolicy(c.Request.Context(), scope, scopeID, access); err != nil {
+		h.respondPolicyError(c, "Failed to delete push policy", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Push policy deleted successfully", nil)
+}
+
+// GetEffectivePolicy 获取仓库合并项目策略后的生效策略
+func (h *PushPolicyHandler) GetEffectivePolicy(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	policy, err := h.pushPolicyService.GetEffectivePolicy(c.Request.Context(), repositoryID)
+	if err != nil {
+		h.respondPolicyError(c, "Failed to get effective push policy", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Effective push policy retrieved successfully", policy)
+}
+
+// HandlePreReceive 接收仓库pre-receive钩子的检查请求
+// 请求体为钩子标准输入原文；响应为纯文本，非2xx状态时钩子把响应内容输出到 git push 并拒绝推送
+func (h *PushPolicyHandler) HandlePreReceive(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("repository_id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "invalid repository ID\n")
+		return
+	}
+
+	updates, err := models.ParsePreReceiveInput(io.LimitReader(c.Request.Body, maxPreReceiveInput))
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s\n", err.Error())
+		return
+	}
+
+	result, err := h.pushPolicyService.CheckPreReceive(c.Request.Context(), repositoryID, &models.PreReceiveRequest{
+		Updates:        updates,
+		QuarantinePath: c.GetHeader("X-Git-Quarantine-Path"),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrRepositoryNotFound):
+			c.String(http.StatusNotFound, "repository not found\n")
+		case errors.Is(err, models.ErrInvalidPreReceive):
+			c.String(http.StatusBadRequest, "%s\n", err.Error())
+		default:
+			h.logger.Error("Failed to check push policy", zap.String("repository_id", repositoryID.String()), zap.Error(err))
+			c.String(http.StatusInternalServerError, "push policy check failed, please retry later\n")
+		}
+		return
+	}
+
+	if !result.Allowed {
+		c.String(http.StatusForbidden, "%s", result.Message())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GitHookAuth 校验仓库钩子回调携带的令牌，钩子路由不经过用户认证，只接受本仓库钩子的调用
+func GitHookAuth(hookSecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repositoryID, err := uuid.Parse(c.Param("repository_id"))
+		if err != nil {
+			c.String(http.StatusBadRequest, "invalid repository ID\n")
+			c.Abort()
+			return
+		}
+		if !models.VerifyGitHookToken(hookSecret, repositoryID, c.GetHeader("X-Git-Hook-Token")) {
+			c.String(http.StatusUnauthorized, "invalid hook token\n")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// parsePolicyScope 根据路由参数确定策略作用范围
+func parsePolicyScope(c *gin.Context) (models.PushPolicyScope, uuid.UUID, bool) {
+	if projectID := c.Param("project_id"); projectID != "" {
+		id, err := uuid.Parse(projectID)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+			return "", uuid.Nil, false
+		}
+		return models.PushPolicyScopeProject, id, true
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return "", uuid.Nil, false
+	}
+	return models.PushPolicyScopeRepository, id, true
+}
+
+// respondPolicyError 将推送策略错误映射为HTTP状态码
+func (h *PushPolicyHandler) respondPolicyError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidPushPolicy), errors.Is(err, models.ErrInvalidPushPolicyScope):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRepositoryNotFound), errors.Is(err, models.ErrPushPolicyNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	case errors.Is(err, models.ErrRepositoryAccessDenied), errors.Is(err, models.ErrProjectAccessDenied):
+		response.Error(c, http.StatusForbidden, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
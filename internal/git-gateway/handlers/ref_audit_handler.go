@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RefAuditHandler 引用审计处理器
+type RefAuditHandler struct {
+	refAuditService service.RefAuditService
+	logger          *zap.Logger
+}
+
+// NewRefAuditHandler 创建引用审计处理器
+func NewRefAuditHandler(refAuditService service.RefAuditService, logger *zap.Logger) *RefAuditHandler {
+	return &RefAuditHandler{
+		refAuditService: refAuditService,
+		logger:          logger,
+	}
+}
+
+// ListRefAudit 查询仓库的引用更新记录
+func (h *RefAuditHandler) ListRefAudit(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var filter models.RefAuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid query parameters", err)
+		return
+	}
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid actor ID", err)
+			return
+		}
+		filter.ActorID = &id
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	resp, err := h.refAuditService.ListRefAudit(c.Request.Context(), repositoryID, &filter, access)
+	if err != nil {
+		h.respondRefAuditError(c, "Failed to list ref audit log", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Ref audit log retrieved successfully", resp)
+}
+
+// RestoreBranch 恢复已删除或被强制推送覆盖的分支
+func (h *RefAuditHandler) RestoreBranch(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid repository ID", err)
+		return
+	}
+
+	var req models.RestoreBranchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	result, err := h.refAuditService.RestoreBranch(c.Request.Context(), repositoryID, &req, access)
+	if err != nil {
+		h.respondRefAuditError(c, "Failed to restore branch", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Branch restored successfully", result)
+}
+
+// HandlePostReceive post-receive钩子回调，记录推送引起的引用更新
+// 路由经 GitHookAuth 校验钩子令牌，推送者信息来自推送前端设置的钩子环境变量；推送已经完成，出错时钩子只输出警告
+func (h *RefAuditHandler) HandlePostReceive(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Param("repository_id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "invalid repository ID\n")
+		return
+	}
+
+	updates, err := models.ParsePreReceiveInput(io.LimitReader(c.Request.Body, maxPreReceiveInput))
+	if err != nil {
+		c.String(http.StatusBadRequest, "%s\n", err.Error())
+		return
+	}
+
+	actor := models.RefActor{Name: c.GetHeader("X-Git-User-Name")}
+	if userID, err := uuid.Parse(c.GetHeader("X-Git-User-ID")); err == nil {
+		actor.UserID = &userID
+	}
+	transport := models.ParsePushTransport(c.GetHeader("X-Git-Transport"))
+
+	if err := h.refAuditService.HandlePostReceive(c.Request.Context(), repositoryID, updates, actor, transport); err != nil {
+		if errors.Is(err, models.ErrRepositoryNotFound) {
+			c.String(http.StatusNotFound, "repository not found\n")
+			return
+		}
+		h.logger.Error("Failed to record ref updates", zap.String("repository_id", repositoryID.String()), zap.Error(err))
+		c.String(http.StatusInternalServerError, "failed to record ref updates\n")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// respondRefAuditError 将引用审计错误映射为HTTP状态码
+func (h *RefAuditHandler) respondRefAuditError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidRefAuditFilter), errors.Is(err, models.ErrInvalidRef):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRepositoryNotFound), errors.Is(err, models.ErrNothingToRestore):
+		response.Error(c, http.StatusNotFound, message, err)
+	case errors.Is(err, models.ErrRestoreTargetUnknown):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrRepositoryReadOnly), errors.Is(err, models.ErrRepositoryArchived),
+		errors.Is(err, models.ErrProtectedRefUpdate), errors.Is(err, models.ErrRepositoryAccessDenied):
+		response.Error(c, http.StatusForbidden, message, err)
+	case errors.Is(err, models.ErrRestoreTargetMissing):
+		response.Error(c, http.StatusGone, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
+
+// refAuditContext 在请求上下文中携带当前用户，供引用审计记录API操作的发起者
+func refAuditContext(c *gin.Context) context.Context {
+	if userID, ok := contextUUID(c, "user_id"); ok {
+		return service.WithRefActor(c.Request.Context(), userID)
+	}
+	return c.Request.Context()
+}
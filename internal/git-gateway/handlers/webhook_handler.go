@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -457,6 +458,165 @@ func (h *WebhookHandler) GetWebhookStatistics(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Webhook statistics retrieved successfully", stats)
 }
 
+// 投递管理端点
+
+// ListWebhookDeliveries 列出仓库的投递记录，需指定仓库且为仓库管理员
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	repositoryID, err := uuid.Parse(c.Query("repository_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid or missing repository ID", err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	var webhookID *uuid.UUID
+	if webhookIDStr := c.Query("webhook_id"); webhookIDStr != "" {
+		id, err := uuid.Parse(webhookIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err)
+			return
+		}
+		webhookID = &id
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	resp, err := h.webhookService.ListWebhookDeliveries(c.Request.Context(), repositoryID, webhookID, page, pageSize, access)
+	if err != nil {
+		h.respondDeliveryError(c, "Failed to list webhook deliveries", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook deliveries retrieved successfully", resp)
+}
+
+// GetWebhookDelivery 获取投递记录及每次尝试
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid delivery ID", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	delivery, err := h.webhookService.GetWebhookDelivery(c.Request.Context(), deliveryID, access)
+	if err != nil {
+		h.respondDeliveryError(c, "Failed to get webhook delivery", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook delivery retrieved successfully", delivery)
+}
+
+// RedeliverWebhookDelivery 重新投递
+func (h *WebhookHandler) RedeliverWebhookDelivery(c *gin.Context) {
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid delivery ID", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	delivery, err := h.webhookService.RetryWebhookDelivery(c.Request.Context(), deliveryID, access)
+	if err != nil {
+		h.respondDeliveryError(c, "Failed to redeliver webhook", err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Webhook redelivery queued", delivery)
+}
+
+// GetWebhookDeliveryStats 获取仓库Webhook的投递统计
+func (h *WebhookHandler) GetWebhookDeliveryStats(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	stats, err := h.webhookService.GetWebhookDeliveryStats(c.Request.Context(), webhookID, access)
+	if err != nil {
+		h.respondDeliveryError(c, "Failed to get webhook delivery statistics", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook delivery statistics retrieved successfully", stats)
+}
+
+// EnableWebhook 启用/禁用仓库Webhook，重新启用会清除自动停用状态
+func (h *WebhookHandler) EnableWebhook(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("webhook_id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid webhook ID", err)
+		return
+	}
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	access, ok := repositoryAccess(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return
+	}
+
+	if err := h.webhookService.EnableWebhook(c.Request.Context(), webhookID, req.Enabled, access); err != nil {
+		h.respondDeliveryError(c, "Failed to enable/disable webhook", err)
+		return
+	}
+
+	action := "disabled"
+	if req.Enabled {
+		action = "enabled"
+	}
+
+	response.Success(c, http.StatusOK, "Webhook "+action+" successfully", gin.H{
+		"webhook_id": webhookID,
+		"enabled":    req.Enabled,
+	})
+}
+
+// respondDeliveryError 将投递管理错误映射为HTTP状态码，非仓库管理员返回403
+func (h *WebhookHandler) respondDeliveryError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrWebhookDeliveryNotFound), errors.Is(err, models.ErrWebhookNotFound),
+		errors.Is(err, models.ErrRepositoryNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	case errors.Is(err, models.ErrRepositoryAccessDenied):
+		response.Error(c, http.StatusForbidden, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
+
 // 辅助方法
 
 // mapGitHubEventType 映射GitHub事件类型到内部事件类型
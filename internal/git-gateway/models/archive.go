@@ -0,0 +1,152 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ArchiveFormat 仓库归档格式
+type ArchiveFormat string
+
+const (
+	ArchiveFormatTarGz ArchiveFormat = "tar.gz"
+	ArchiveFormatZip   ArchiveFormat = "zip"
+)
+
+// 归档错误
+var (
+	ErrArchiveFormat   = errors.New("unsupported archive format")
+	ErrArchiveNotFound = errors.New("ref or path not found")
+)
+
+// ContentType 归档的MIME类型
+func (f ArchiveFormat) ContentType() string {
+	if f == ArchiveFormatZip {
+		return "application/zip"
+	}
+	return "application/gzip"
+}
+
+// GitFormat git archive --format 参数
+func (f ArchiveFormat) GitFormat() string {
+	if f == ArchiveFormatZip {
+		return "zip"
+	}
+	return "tar.gz"
+}
+
+// ArchiveLink 归档下载地址
+type ArchiveLink struct {
+	Format ArchiveFormat `json:"format"`
+	URL    string        `json:"url"`
+}
+
+// ArchiveLinks 生成ref各格式的归档下载地址，供发布页面提供源码下载
+func ArchiveLinks(repositoryID uuid.UUID, ref string) []ArchiveLink {
+	escaped := (&url.URL{Path: ref}).EscapedPath()
+	formats := []ArchiveFormat{ArchiveFormatTarGz, ArchiveFormatZip}
+	links := make([]ArchiveLink, 0, len(formats))
+	for _, format := range formats {
+		links = append(links, ArchiveLink{
+			Format: format,
+			URL:    fmt.Sprintf("/api/v1/repositories/%s/archive/%s.%s", repositoryID, escaped, format),
+		})
+	}
+	return links
+}
+
+// ArchiveRequest 仓库归档下载请求
+type ArchiveRequest struct {
+	Ref    string        // 分支、标签或提交SHA
+	Format ArchiveFormat // 归档格式
+	Path   string        // 仅归档该子目录，为空时归档整个仓库
+	Prefix string        // 归档内的根目录名，为空时使用 <仓库名>-<ref>
+}
+
+// RepositoryArchive 解析后的归档信息
+type RepositoryArchive struct {
+	GitPath   string
+	Ref       string
+	CommitSHA string
+	Format    ArchiveFormat
+	Path      string
+	Prefix    string // 以/结尾
+	Filename  string
+}
+
+// ETag 归档内容仅由提交、子目录、根目录名和格式决定
+func (a *RepositoryArchive) ETag() string {
+	return fmt.Sprintf(`"%s-%s-%s"`, a.CommitSHA, a.Format, shortHash(a.Path+"\x00"+a.Prefix))
+}
+
+// Immutable 按完整提交SHA请求的归档内容永远不变，可长期缓存
+func (a *RepositoryArchive) Immutable() bool {
+	return strings.EqualFold(a.Ref, a.CommitSHA)
+}
+
+// fullSHAPattern 完整的提交SHA（SHA-1或SHA-256）
+var fullSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{40}([0-9a-fA-F]{24})?$`)
+
+// IsFullCommitSHA 判断是否为完整提交SHA
+func IsFullCommitSHA(ref string) bool {
+	return fullSHAPattern.MatchString(ref)
+}
+
+// ParseArchiveName 解析 <ref>.tar.gz / <ref>.zip 形式的归档名称
+// ref 可以包含斜杠（如 feature/login.zip）
+func ParseArchiveName(name string) (string, ArchiveFormat, error) {
+	name = strings.TrimPrefix(name, "/")
+
+	var ref string
+	var format ArchiveFormat
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"):
+		ref, format = strings.TrimSuffix(name, ".tar.gz"), ArchiveFormatTarGz
+	case strings.HasSuffix(name, ".tgz"):
+		ref, format = strings.TrimSuffix(name, ".tgz"), ArchiveFormatTarGz
+	case strings.HasSuffix(name, ".zip"):
+		ref, format = strings.TrimSuffix(name, ".zip"), ArchiveFormatZip
+	default:
+		return "", "", fmt.Errorf("%w: %s", ErrArchiveFormat, name)
+	}
+
+	if err := ValidateRefName(ref); err != nil {
+		return "", "", err
+	}
+	return ref, format, nil
+}
+
+// archiveNameUnsafe 文件名和根目录名中需要替换的字符
+var archiveNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ArchiveBaseName 生成 <仓库名>-<ref> 形式的文件名（不含扩展名）
+func ArchiveBaseName(repoName, ref string) string {
+	name := archiveNameUnsafe.ReplaceAllString(repoName+"-"+ref, "-")
+	return strings.Trim(name, "-.")
+}
+
+// CleanArchivePrefix 规范化归档根目录名，结果以/结尾
+func CleanArchivePrefix(prefix, fallback string) (string, error) {
+	prefix = strings.Trim(strings.TrimSpace(prefix), "/")
+	if prefix == "" {
+		prefix = fallback
+	}
+	cleaned, err := CleanRepoPath(prefix)
+	if err != nil || cleaned == "" {
+		return "", fmt.Errorf("%w: prefix %q", ErrInvalidPath, prefix)
+	}
+	return cleaned + "/", nil
+}
+
+// shortHash 生成短哈希，用于ETag区分子目录与根目录名
+func shortHash(s string) string {
+	h := fnv.New32a()
+	h.Write([]byte(s))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
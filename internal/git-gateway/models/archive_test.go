@@ -0,0 +1,108 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArchiveName(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantRef    string
+		wantFormat ArchiveFormat
+		wantErr    error
+	}{
+		{"分支tar.gz", "/main.tar.gz", "main", ArchiveFormatTarGz, nil},
+		{"tgz别名", "v1.2.0.tgz", "v1.2.0", ArchiveFormatTarGz, nil},
+		{"带斜杠的分支", "/feature/login.zip", "feature/login", ArchiveFormatZip, nil},
+		{"提交SHA", "0123456789abcdef0123456789abcdef01234567.zip", "0123456789abcdef0123456789abcdef01234567", ArchiveFormatZip, nil},
+		{"不支持的格式", "main.tar.bz2", "", "", ErrArchiveFormat},
+		{"缺少ref", ".zip", "", "", ErrInvalidRef},
+		{"选项注入", "--output=x.zip", "", "", ErrInvalidRef},
+		{"修订表达式", "main~1.zip", "", "", ErrInvalidRef},
+		{"reflog表达式", "main@{1}.zip", "", "", ErrInvalidRef},
+		{"路径跳转", "a..b.tar.gz", "", "", ErrInvalidRef},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, format, err := ParseArchiveName(tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRef, ref)
+			assert.Equal(t, tt.wantFormat, format)
+		})
+	}
+}
+
+func TestCleanRepoPath(t *testing.T) {
+	cleaned, err := CleanRepoPath("/docs/api/")
+	require.NoError(t, err)
+	assert.Equal(t, "docs/api", cleaned)
+
+	cleaned, err = CleanRepoPath("./")
+	require.NoError(t, err)
+	assert.Empty(t, cleaned)
+
+	_, err = CleanRepoPath("docs/../../etc")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+
+	_, err = CleanRepoPath("-docs")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+func TestCleanArchivePrefix(t *testing.T) {
+	prefix, err := CleanArchivePrefix("", ArchiveBaseName("web-app", "feature/login"))
+	require.NoError(t, err)
+	assert.Equal(t, "web-app-feature-login/", prefix)
+
+	prefix, err = CleanArchivePrefix("/src/", "fallback")
+	require.NoError(t, err)
+	assert.Equal(t, "src/", prefix)
+
+	_, err = CleanArchivePrefix("../outside", "fallback")
+	assert.ErrorIs(t, err, ErrInvalidPath)
+}
+
+func TestRepositoryArchiveCaching(t *testing.T) {
+	sha := "0123456789abcdef0123456789abcdef01234567"
+	branch := &RepositoryArchive{Ref: "main", CommitSHA: sha, Format: ArchiveFormatTarGz, Prefix: "app-main/"}
+	pinned := &RepositoryArchive{Ref: sha, CommitSHA: sha, Format: ArchiveFormatTarGz, Prefix: "app-main/"}
+
+	// 同一提交、同一内容的归档ETag相同，分支移动后ETag随之变化
+	assert.Equal(t, branch.ETag(), pinned.ETag())
+	assert.False(t, branch.Immutable())
+	assert.True(t, pinned.Immutable())
+
+	zip := *branch
+	zip.Format = ArchiveFormatZip
+	assert.NotEqual(t, branch.ETag(), zip.ETag())
+
+	subdir := *branch
+	subdir.Path = "docs"
+	assert.NotEqual(t, branch.ETag(), subdir.ETag())
+}
+
+func TestArchiveLinks(t *testing.T) {
+	repoID := uuid.MustParse("0190f1a2-3b4c-7d5e-8f60-718293a4b5c6")
+	links := ArchiveLinks(repoID, "release/v1.2.0")
+
+	require.Len(t, links, 2)
+	assert.Equal(t, ArchiveFormatTarGz, links[0].Format)
+	assert.Equal(t, "/api/v1/repositories/"+repoID.String()+"/archive/release/v1.2.0.tar.gz", links[0].URL)
+	assert.Equal(t, ArchiveFormatZip, links[1].Format)
+
+	// 下载地址可被归档接口解析回原标签
+	ref, format, err := ParseArchiveName(strings.TrimPrefix(links[1].URL, "/api/v1/repositories/"+repoID.String()+"/archive"))
+	require.NoError(t, err)
+	assert.Equal(t, "release/v1.2.0", ref)
+	assert.Equal(t, ArchiveFormatZip, format)
+}
@@ -0,0 +1,296 @@
+package models
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// blame和文件历史错误
+var (
+	ErrFileNotFound     = errors.New("file not found at ref")
+	ErrInvalidLineRange = errors.New("invalid line range")
+)
+
+// BlameCommit blame结果中引用的提交信息
+type BlameCommit struct {
+	SHA          string    `json:"sha"`
+	Author       string    `json:"author"`
+	AuthorEmail  string    `json:"author_email"`
+	AuthoredAt   time.Time `json:"authored_at"`
+	Committer    string    `json:"committer"`
+	CommittedAt  time.Time `json:"committed_at"`
+	Summary      string    `json:"summary"`
+	Boundary     bool      `json:"boundary"`                // 是否为历史边界提交（文件的最早版本）
+	PreviousSHA  string    `json:"previous_sha,omitempty"`  // 该提交的父提交，用于继续向前追溯
+	PreviousPath string    `json:"previous_path,omitempty"` // 父提交中的文件路径
+}
+
+// BlameRange 连续且来自同一提交的行范围（行号从1开始）
+type BlameRange struct {
+	CommitSHA         string   `json:"commit_sha"`
+	StartLine         int      `json:"start_line"`
+	EndLine           int      `json:"end_line"`
+	OriginalStartLine int      `json:"original_start_line"` // 在该提交中的起始行号
+	OriginalPath      string   `json:"original_path"`       // 在该提交中的文件路径，文件重命名后与当前路径不同
+	Lines             []string `json:"lines"`
+}
+
+// FileBlame 文件blame结果
+type FileBlame struct {
+	Path      string                 `json:"path"`
+	Ref       string                 `json:"ref"`
+	CommitSHA string                 `json:"commit_sha"`
+	Ranges    []BlameRange           `json:"ranges"`
+	Commits   map[string]BlameCommit `json:"commits"` // 按SHA索引，避免在每个范围中重复
+}
+
+// BlameRequest 文件blame请求
+type BlameRequest struct {
+	Ref       string // 为空时使用默认分支
+	Path      string
+	StartLine int // 可选，与EndLine一起限定行范围
+	EndLine   int
+}
+
+// Validate 校验blame请求
+func (r *BlameRequest) Validate() error {
+	if r.StartLine < 0 || r.EndLine < 0 {
+		return fmt.Errorf("%w: negative line number", ErrInvalidLineRange)
+	}
+	if r.EndLine > 0 && r.StartLine > r.EndLine {
+		return fmt.Errorf("%w: start_line %d is after end_line %d", ErrInvalidLineRange, r.StartLine, r.EndLine)
+	}
+	return nil
+}
+
+// FileCommit 文件历史中的提交
+type FileCommit struct {
+	SHA            string    `json:"sha"`
+	Message        string    `json:"message"`
+	Author         string    `json:"author"`
+	AuthorEmail    string    `json:"author_email"`
+	Committer      string    `json:"committer"`
+	CommitterEmail string    `json:"committer_email"`
+	CommittedAt    time.Time `json:"committed_at"`
+	ParentSHAs     []string  `json:"parent_shas"`
+	ChangeType     string    `json:"change_type"`        // added, modified, deleted, renamed, copied
+	Path           string    `json:"path"`               // 该提交中的文件路径
+	OldPath        string    `json:"old_path,omitempty"` // 重命名或复制前的路径
+}
+
+// FileHistoryResponse 文件历史响应
+type FileHistoryResponse struct {
+	Path     string       `json:"path"`
+	Ref      string       `json:"ref"`
+	Commits  []FileCommit `json:"commits"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+// ParseBlamePorcelain 解析 git blame --porcelain 的输出
+//
+// 每组以"<sha> <原行号> <当前行号> <行数>"开头；提交信息只在该提交首次出现时输出，
+// filename 仅在首次出现或同一提交涉及多个路径时输出，因此需要按提交记住最近的文件名。
+func ParseBlamePorcelain(output string) ([]BlameRange, map[string]BlameCommit, error) {
+	ranges := make([]BlameRange, 0)
+	commits := make(map[string]BlameCommit)
+	filenames := make(map[string]string)
+
+	var current *BlameRange
+	var commit *BlameCommit
+	var authorTime, committerTime int64
+
+	flushCommit := func() {
+		if commit == nil {
+			return
+		}
+		if authorTime > 0 {
+			commit.AuthoredAt = time.Unix(authorTime, 0).UTC()
+		}
+		if committerTime > 0 {
+			commit.CommittedAt = time.Unix(committerTime, 0).UTC()
+		}
+		commits[commit.SHA] = *commit
+		commit = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// 内容行
+		if strings.HasPrefix(line, "\t") {
+			if current == nil {
+				return nil, nil, fmt.Errorf("unexpected blame content line")
+			}
+			flushCommit()
+			if current.OriginalPath == "" {
+				current.OriginalPath = filenames[current.CommitSHA]
+			}
+			current.Lines = append(current.Lines, line[1:])
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+
+		// 分组头
+		if IsFullCommitSHA(key) {
+			fields := strings.Fields(value)
+			if len(fields) < 2 {
+				return nil, nil, fmt.Errorf("invalid blame header: %s", line)
+			}
+			if len(fields) < 3 {
+				continue // 组内后续行，内容追加到当前范围
+			}
+			origLine, err1 := strconv.Atoi(fields[0])
+			finalLine, err2 := strconv.Atoi(fields[1])
+			count, err3 := strconv.Atoi(fields[2])
+			if err1 != nil || err2 != nil || err3 != nil {
+				return nil, nil, fmt.Errorf("invalid blame header: %s", line)
+			}
+
+			ranges = append(ranges, BlameRange{
+				CommitSHA:         key,
+				StartLine:         finalLine,
+				EndLine:           finalLine + count - 1,
+				OriginalStartLine: origLine,
+				Lines:             make([]string, 0, count),
+			})
+			current = &ranges[len(ranges)-1]
+
+			if _, seen := commits[key]; !seen {
+				commit = &BlameCommit{SHA: key}
+				authorTime, committerTime = 0, 0
+			}
+			continue
+		}
+
+		if current == nil {
+			return nil, nil, fmt.Errorf("unexpected blame line: %s", line)
+		}
+
+		switch key {
+		case "filename":
+			filenames[current.CommitSHA] = value
+			current.OriginalPath = value
+		case "author":
+			if commit != nil {
+				commit.Author = value
+			}
+		case "author-mail":
+			if commit != nil {
+				commit.AuthorEmail = strings.Trim(value, "<>")
+			}
+		case "author-time":
+			authorTime, _ = strconv.ParseInt(value, 10, 64)
+		case "committer":
+			if commit != nil {
+				commit.Committer = value
+			}
+		case "committer-time":
+			committerTime, _ = strconv.ParseInt(value, 10, 64)
+		case "summary":
+			if commit != nil {
+				commit.Summary = value
+			}
+		case "boundary":
+			if commit != nil {
+				commit.Boundary = true
+			}
+		case "previous":
+			if commit != nil {
+				commit.PreviousSHA, commit.PreviousPath, _ = strings.Cut(value, " ")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read blame output: %w", err)
+	}
+	flushCommit()
+	return ranges, commits, nil
+}
+
+// FileHistoryFormat 解析文件历史使用的 git log --format
+// 每条记录以\x1e开头，字段以\x1f分隔，随后是 --name-status 输出
+const FileHistoryFormat = "%x1e%H%x1f%s%x1f%an%x1f%ae%x1f%cn%x1f%ce%x1f%ct%x1f%P"
+
+// ParseFileHistory 解析 git log --follow --name-status --format=FileHistoryFormat 的输出
+func ParseFileHistory(output, path string) ([]FileCommit, error) {
+	commits := make([]FileCommit, 0)
+	for _, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		header, changes, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, "\x1f")
+		if len(fields) != 8 {
+			return nil, fmt.Errorf("invalid log record: %q", header)
+		}
+
+		timestamp, err := strconv.ParseInt(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid commit time %q: %w", fields[6], err)
+		}
+
+		commit := FileCommit{
+			SHA:            fields[0],
+			Message:        fields[1],
+			Author:         fields[2],
+			AuthorEmail:    fields[3],
+			Committer:      fields[4],
+			CommitterEmail: fields[5],
+			CommittedAt:    time.Unix(timestamp, 0).UTC(),
+			ParentSHAs:     strings.Fields(fields[7]),
+			ChangeType:     "modified",
+			Path:           path,
+		}
+
+		// --follow 时只输出被跟踪文件的变更
+		for _, change := range strings.Split(changes, "\n") {
+			parts := strings.Split(strings.TrimSpace(change), "\t")
+			if len(parts) < 2 || parts[0] == "" {
+				continue
+			}
+			commit.ChangeType = fileChangeType(parts[0])
+			commit.Path = parts[len(parts)-1]
+			if len(parts) == 3 {
+				commit.OldPath = parts[1]
+			}
+			break
+		}
+
+		// 较早的提交使用重命名前的路径
+		if commit.OldPath != "" {
+			path = commit.OldPath
+		} else {
+			path = commit.Path
+		}
+
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// fileChangeType 将 --name-status 状态字母转换为变更类型
+func fileChangeType(status string) string {
+	switch status[0] {
+	case 'A':
+		return "added"
+	case 'D':
+		return "deleted"
+	case 'R':
+		return "renamed"
+	case 'C':
+		return "copied"
+	default:
+		return "modified"
+	}
+}
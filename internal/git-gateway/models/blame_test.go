@@ -0,0 +1,120 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	blameInitialSHA = "0443b5d4a255a067e22c179db9bdc4d93c6b496f"
+	blameRenameSHA  = "cb2855987980984aa043d08d4277903b2d60ffc1"
+)
+
+// old.txt 在第二个提交中重命名为 new.txt 并修改第2行、追加第4行
+const samplePorcelain = blameInitialSHA + ` 1 1 1
+author Alice
+author-mail <alice@example.com>
+author-time 1700000000
+author-tz +0000
+committer Alice
+committer-mail <alice@example.com>
+committer-time 1700000000
+committer-tz +0000
+summary initial import
+boundary
+filename old.txt
+	a
+` + blameRenameSHA + ` 2 2 1
+author Bob
+author-mail <bob@example.com>
+author-time 1700003600
+author-tz +0000
+committer Bob
+committer-mail <bob@example.com>
+committer-time 1700003600
+committer-tz +0000
+summary rename and update
+previous ` + blameInitialSHA + ` old.txt
+filename new.txt
+	B
+` + blameInitialSHA + ` 3 3 2
+	c
+` + blameInitialSHA + ` 4 4
+	d
+` + blameRenameSHA + ` 4 5 1
+	e
+`
+
+func TestParseBlamePorcelain(t *testing.T) {
+	ranges, commits, err := ParseBlamePorcelain(samplePorcelain)
+	require.NoError(t, err)
+	require.Len(t, ranges, 4)
+
+	// 重命名前的行保留原始路径
+	assert.Equal(t, BlameRange{CommitSHA: blameInitialSHA, StartLine: 1, EndLine: 1, OriginalStartLine: 1, OriginalPath: "old.txt", Lines: []string{"a"}}, ranges[0])
+	assert.Equal(t, "new.txt", ranges[1].OriginalPath)
+
+	// 重复出现的提交不再输出filename，沿用该提交最近的文件名
+	assert.Equal(t, 3, ranges[2].StartLine)
+	assert.Equal(t, 4, ranges[2].EndLine)
+	assert.Equal(t, "old.txt", ranges[2].OriginalPath)
+	assert.Equal(t, []string{"c", "d"}, ranges[2].Lines)
+	assert.Equal(t, "new.txt", ranges[3].OriginalPath)
+
+	require.Len(t, commits, 2)
+	initial := commits[blameInitialSHA]
+	assert.Equal(t, "Alice", initial.Author)
+	assert.Equal(t, "alice@example.com", initial.AuthorEmail)
+	assert.Equal(t, int64(1700000000), initial.AuthoredAt.Unix())
+	assert.True(t, initial.Boundary)
+
+	rename := commits[blameRenameSHA]
+	assert.Equal(t, "rename and update", rename.Summary)
+	assert.Equal(t, blameInitialSHA, rename.PreviousSHA)
+	assert.Equal(t, "old.txt", rename.PreviousPath)
+	assert.False(t, rename.Boundary)
+}
+
+func TestParseBlamePorcelainInvalid(t *testing.T) {
+	_, _, err := ParseBlamePorcelain("\tdangling line\n")
+	assert.Error(t, err)
+
+	_, _, err = ParseBlamePorcelain(blameInitialSHA + " x 1 1\n")
+	assert.Error(t, err)
+}
+
+func TestBlameRequestValidate(t *testing.T) {
+	assert.NoError(t, (&BlameRequest{Path: "main.go"}).Validate())
+	assert.NoError(t, (&BlameRequest{Path: "main.go", StartLine: 10}).Validate())
+	assert.NoError(t, (&BlameRequest{Path: "main.go", StartLine: 10, EndLine: 20}).Validate())
+	assert.ErrorIs(t, (&BlameRequest{Path: "main.go", StartLine: 20, EndLine: 10}).Validate(), ErrInvalidLineRange)
+	assert.ErrorIs(t, (&BlameRequest{Path: "main.go", StartLine: -1}).Validate(), ErrInvalidLineRange)
+}
+
+func TestParseFileHistory(t *testing.T) {
+	output := "\x1e" + blameRenameSHA + "\x1fupdate\x1fBob\x1fbob@example.com\x1fBob\x1fbob@example.com\x1f1700007200\x1f" + blameInitialSHA + "\n\nM\tnew.txt\n" +
+		"\x1e" + blameRenameSHA + "\x1frename\x1fBob\x1fbob@example.com\x1fBob\x1fbob@example.com\x1f1700003600\x1f" + blameInitialSHA + "\n\nR100\told.txt\tnew.txt\n" +
+		"\x1e" + blameInitialSHA + "\x1fmerge\x1fAlice\x1falice@example.com\x1fAlice\x1falice@example.com\x1f1700001800\x1fa b\n" +
+		"\x1e" + blameInitialSHA + "\x1finitial import\x1fAlice\x1falice@example.com\x1fAlice\x1falice@example.com\x1f1700000000\x1f\n\nA\told.txt\n"
+
+	commits, err := ParseFileHistory(output, "new.txt")
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+
+	assert.Equal(t, "modified", commits[0].ChangeType)
+	assert.Equal(t, "new.txt", commits[0].Path)
+
+	assert.Equal(t, "renamed", commits[1].ChangeType)
+	assert.Equal(t, "new.txt", commits[1].Path)
+	assert.Equal(t, "old.txt", commits[1].OldPath)
+
+	// 没有变更列表的提交（如合并提交）使用重命名前的路径
+	assert.Equal(t, "old.txt", commits[2].Path)
+	assert.Equal(t, []string{"a", "b"}, commits[2].ParentSHAs)
+
+	assert.Equal(t, "added", commits[3].ChangeType)
+	assert.Empty(t, commits[3].ParentSHAs)
+	assert.Equal(t, int64(1700000000), commits[3].CommittedAt.Unix())
+}
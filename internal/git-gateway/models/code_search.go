@@ -0,0 +1,405 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// 代码搜索限制
+const (
+	CodeSearchMaxFileSize     = 1 << 20 // 超过1MB的文件不建立索引
+	CodeSearchMinQueryLength  = 3       // 三元组索引至少需要3个字符
+	CodeSearchMaxPageSize     = 50
+	CodeSearchMaxLineMatches  = 20  // 每个文件最多返回的匹配行数
+	CodeSearchMaxSnippetRunes = 500 // 超长行截断长度
+)
+
+// 代码搜索错误
+var (
+	ErrInvalidSearchQuery    = errors.New("invalid search query")
+	ErrRepositoryNotReadable = errors.New("repository not found or access denied")
+)
+
+// CodeSearchIndexStatus 分支索引状态
+type CodeSearchIndexStatus string
+
+const (
+	CodeSearchIndexPending  CodeSearchIndexStatus = "pending"
+	CodeSearchIndexIndexing CodeSearchIndexStatus = "indexing"
+	CodeSearchIndexReady    CodeSearchIndexStatus = "ready"
+	CodeSearchIndexFailed   CodeSearchIndexStatus = "failed"
+)
+
+// CodeSearchDocument 已索引的文件内容
+type CodeSearchDocument struct {
+	RepositoryID uuid.UUID `json:"repository_id" gorm:"type:uuid;primaryKey"`
+	Branch       string    `json:"branch" gorm:"size:255;primaryKey"`
+	Path         string    `json:"path" gorm:"type:text;primaryKey"`
+	BlobSHA      string    `json:"blob_sha" gorm:"size:64;not null"`
+	Language     string    `json:"language" gorm:"size:50;index"`
+	Size         int64     `json:"size" gorm:"not null"`
+	Content      string    `json:"-" gorm:"type:text;not null"`
+	IndexedAt    time.Time `json:"indexed_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (CodeSearchDocument) TableName() string {
+	return "code_search_documents"
+}
+
+// CodeSearchBranch 参与代码搜索的分支及其索引进度
+// 默认分支始终被索引，其他分支需要显式配置
+type CodeSearchBranch struct {
+	RepositoryID uuid.UUID             `json:"repository_id" gorm:"type:uuid;primaryKey"`
+	Branch       string                `json:"branch" gorm:"size:255;primaryKey"`
+	CommitSHA    string                `json:"commit_sha" gorm:"size:64"` // 已索引到的提交，增量更新的起点
+	Status       CodeSearchIndexStatus `json:"status" gorm:"size:20;not null;default:'pending'"`
+	FileCount    int64                 `json:"file_count" gorm:"not null;default:0"`
+	ErrorMessage *string               `json:"error_message,omitempty" gorm:"type:text"`
+	IndexedAt    *time.Time            `json:"indexed_at"`
+	CreatedAt    time.Time             `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt    time.Time             `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (CodeSearchBranch) TableName() string {
+	return "code_search_branches"
+}
+
+// UpdateSearchBranchesRequest 设置额外索引的分支
+type UpdateSearchBranchesRequest struct {
+	Branches []string `json:"branches" binding:"max=20"`
+}
+
+// CodeSearchAccess 搜索者的身份，用于过滤可读仓库
+type CodeSearchAccess struct {
+	UserID   uuid.UUID
+	TenantID uuid.UUID
+}
+
+// CodeSearchQuery 代码搜索条件
+type CodeSearchQuery struct {
+	Query         string      `form:"q"`
+	Regex         bool        `form:"regex"`
+	CaseSensitive bool        `form:"case_sensitive"`
+	RepositoryIDs []uuid.UUID `form:"-"`
+	Branch        string      `form:"branch"`   // 为空时只搜索默认分支
+	Path          string      `form:"path"`     // 文件名或路径的glob，如 *.go、cmd/**/main.go
+	Language      string      `form:"language"` // 语言过滤，如 go、typescript
+	Page          int         `form:"page"`
+	PageSize      int         `form:"page_size"`
+}
+
+// Normalize 校验并规范化搜索条件
+func (q *CodeSearchQuery) Normalize() error {
+	q.Language = strings.ToLower(strings.TrimSpace(q.Language))
+	q.Path = strings.TrimSpace(q.Path)
+	if utf8.RuneCountInString(q.Query) < CodeSearchMinQueryLength {
+		return fmt.Errorf("%w: query must be at least %d characters", ErrInvalidSearchQuery, CodeSearchMinQueryLength)
+	}
+	if _, err := q.Matcher(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSearchQuery, err)
+	}
+	if q.Branch != "" {
+		if err := ValidateRefName(q.Branch); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSearchQuery, err)
+		}
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 || q.PageSize > CodeSearchMaxPageSize {
+		q.PageSize = 20
+	}
+	return nil
+}
+
+// Matcher 生成用于提取匹配行的正则表达式
+func (q *CodeSearchQuery) Matcher() (*regexp.Regexp, error) {
+	pattern := q.Query
+	if !q.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !q.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// PathPattern 将路径glob转换为数据库正则
+// 不含/的模式只匹配文件名，含/的模式匹配完整路径
+func (q *CodeSearchQuery) PathPattern() string {
+	if q.Path == "" {
+		return ""
+	}
+	return GlobToPathRegex(q.Path)
+}
+
+// GlobToPathRegex 将glob转换为正则：** 匹配任意层级，* 和 ? 不跨越目录
+func GlobToPathRegex(glob string) string {
+	glob = strings.TrimPrefix(glob, "/")
+
+	var b strings.Builder
+	if strings.Contains(glob, "/") {
+		b.WriteString("^")
+	} else {
+		b.WriteString("(^|/)")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				// **/ 也匹配零层目录
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// CodeSearchMatchRange 行内匹配位置（按字符计，左闭右开）
+type CodeSearchMatchRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// CodeSearchLineMatch 匹配行
+type CodeSearchLineMatch struct {
+	LineNumber int                    `json:"line_number"`
+	Line       string                 `json:"line"`
+	Ranges     []CodeSearchMatchRange `json:"ranges"`
+}
+
+// CodeSearchResult 单个文件的搜索结果
+type CodeSearchResult struct {
+	RepositoryID   uuid.UUID             `json:"repository_id"`
+	RepositoryName string                `json:"repository_name"`
+	ProjectID      uuid.UUID             `json:"project_id"`
+	Branch         string                `json:"branch"`
+	Path           string                `json:"path"`
+	Language       string                `json:"language"`
+	BlobSHA        string                `json:"blob_sha"`
+	Matches        []CodeSearchLineMatch `json:"matches"`
+	MoreMatches    bool                  `json:"more_matches"` // 匹配行超过返回上限
+}
+
+// CodeSearchHit 数据库返回的匹配文件
+type CodeSearchHit struct {
+	CodeSearchDocument
+	RepositoryName string
+	ProjectID      uuid.UUID
+}
+
+// CodeSearchResponse 代码搜索响应
+type CodeSearchResponse struct {
+	Results  []CodeSearchResult `json:"results"`
+	Total    int64              `json:"total"` // 匹配的文件数
+	Page     int                `json:"page"`
+	PageSize int                `json:"page_size"`
+}
+
+// FindLineMatches 提取匹配行及行内高亮位置
+func FindLineMatches(content string, matcher *regexp.Regexp, limit int) ([]CodeSearchLineMatch, bool) {
+	matches := make([]CodeSearchLineMatch, 0)
+	lineNumber := 0
+	for len(content) > 0 {
+		lineNumber++
+		line := content
+		if idx := strings.IndexByte(content, '\n'); idx >= 0 {
+			line, content = content[:idx], content[idx+1:]
+		} else {
+			content = ""
+		}
+		line = strings.TrimSuffix(line, "\r")
+
+		locs := matcher.FindAllStringIndex(line, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		if len(matches) == limit {
+			return matches, true
+		}
+
+		match := CodeSearchLineMatch{LineNumber: lineNumber, Ranges: make([]CodeSearchMatchRange, 0, len(locs))}
+		truncated := line
+		if utf8.RuneCountInString(line) > CodeSearchMaxSnippetRunes {
+			truncated = string([]rune(line)[:CodeSearchMaxSnippetRunes])
+		}
+		match.Line = truncated
+
+		for _, loc := range locs {
+			if loc[0] == loc[1] || loc[0] >= len(truncated) {
+				continue
+			}
+			end := loc[1]
+			if end > len(truncated) {
+				end = len(truncated)
+			}
+			match.Ranges = append(match.Ranges, CodeSearchMatchRange{
+				Start: utf8.RuneCountInString(line[:loc[0]]),
+				End:   utf8.RuneCountInString(line[:end]),
+			})
+		}
+		matches = append(matches, match)
+	}
+	return matches, false
+}
+
+// IsBinaryContent 判断内容是否为二进制（前8000字节中包含NUL）
+func IsBinaryContent(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// languageByExtension 文件扩展名到语言的映射
+var languageByExtension = map[string]string{
+	".go":    "go",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".py":    "python",
+	".java":  "java",
+	".kt":    "kotlin",
+	".rb":    "ruby",
+	".rs":    "rust",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".php":   "php",
+	".swift": "swift",
+	".scala": "scala",
+	".sh":    "shell",
+	".bash":  "shell",
+	".sql":   "sql",
+	".proto": "protobuf",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".json":  "json",
+	".toml":  "toml",
+	".xml":   "xml",
+	".html":  "html",
+	".css":   "css",
+	".scss":  "scss",
+	".vue":   "vue",
+	".md":    "markdown",
+	".tf":    "terraform",
+}
+
+// languageByFilename 无扩展名的特殊文件
+var languageByFilename = map[string]string{
+	"dockerfile":  "dockerfile",
+	"makefile":    "makefile",
+	"jenkinsfile": "groovy",
+	"go.mod":      "go-module",
+}
+
+// DetectLanguage 根据文件名识别语言，无法识别时返回空字符串
+func DetectLanguage(filePath string) string {
+	base := strings.ToLower(path.Base(filePath))
+	if lang, ok := languageByFilename[base]; ok {
+		return lang
+	}
+	if strings.HasPrefix(base, "dockerfile.") {
+		return "dockerfile"
+	}
+	return languageByExtension[path.Ext(base)]
+}
+
+// GitTreeEntry git ls-tree 中的文件条目
+type GitTreeEntry struct {
+	Mode string
+	SHA  string
+	Size int64
+	Path string
+}
+
+// IsRegularFile 是否为普通文件（排除符号链接和子模块）
+func (e GitTreeEntry) IsRegularFile() bool {
+	return e.Mode == "100644" || e.Mode == "100755"
+}
+
+// ParseLsTree 解析 git ls-tree -r -z -l 的输出
+func ParseLsTree(output string) ([]GitTreeEntry, error) {
+	entries := make([]GitTreeEntry, 0)
+	for _, record := range strings.Split(output, "\x00") {
+		if record == "" {
+			continue
+		}
+		meta, filePath, ok := strings.Cut(record, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("invalid ls-tree entry: %q", record)
+		}
+		if fields[1] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ls-tree size %q: %w", fields[3], err)
+		}
+		entries = append(entries, GitTreeEntry{Mode: fields[0], SHA: fields[2], Size: size, Path: filePath})
+	}
+	return entries, nil
+}
+
+// GitTreeChange git diff-tree 中的文件变更
+type GitTreeChange struct {
+	Status  byte // A、M、D、T
+	NewMode string
+	NewSHA  string
+	Path    string
+}
+
+// ParseDiffTreeRaw 解析 git diff-tree -r -z --no-renames 的原始输出
+// 每个变更为 ":<旧模式> <新模式> <旧SHA> <新SHA> <状态>\0<路径>\0"
+func ParseDiffTreeRaw(output string) ([]GitTreeChange, error) {
+	changes := make([]GitTreeChange, 0)
+	records := strings.Split(output, "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(record, ":"))
+		if !strings.HasPrefix(record, ":") || len(fields) != 5 || i+1 >= len(records) || records[i+1] == "" {
+			return nil, fmt.Errorf("invalid diff-tree entry: %q", record)
+		}
+		i++
+		changes = append(changes, GitTreeChange{
+			Status:  fields[4][0],
+			NewMode: fields[1],
+			NewSHA:  fields[3],
+			Path:    records[i],
+		})
+	}
+	return changes, nil
+}
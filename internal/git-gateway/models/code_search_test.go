@@ -0,0 +1,125 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeSearchQueryNormalize(t *testing.T) {
+	q := &CodeSearchQuery{Query: "NewGitService", Language: " Go ", PageSize: 500}
+	require.NoError(t, q.Normalize())
+	assert.Equal(t, "go", q.Language)
+	assert.Equal(t, 1, q.Page)
+	assert.Equal(t, 20, q.PageSize)
+
+	assert.ErrorIs(t, (&CodeSearchQuery{Query: "ab"}).Normalize(), ErrInvalidSearchQuery)
+	assert.ErrorIs(t, (&CodeSearchQuery{Query: "func (", Regex: true}).Normalize(), ErrInvalidSearchQuery)
+	assert.ErrorIs(t, (&CodeSearchQuery{Query: "main", Branch: "--all"}).Normalize(), ErrInvalidSearchQuery)
+
+	// 字面量模式下正则元字符按原样匹配
+	assert.NoError(t, (&CodeSearchQuery{Query: "func ("}).Normalize())
+}
+
+func TestGlobToPathRegex(t *testing.T) {
+	tests := []struct {
+		glob    string
+		path    string
+		matched bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/server/main.go", true},
+		{"*.go", "main.go.bak", false},
+		{"main.go", "cmd/api/main.go", true},
+		{"main.go", "cmd/api/domain.go", false},
+		{"cmd/*/main.go", "cmd/api/main.go", true},
+		{"cmd/*/main.go", "cmd/api/v1/main.go", false},
+		{"cmd/**/main.go", "cmd/api/v1/main.go", true},
+		{"cmd/**/main.go", "cmd/main.go", true},
+		{"internal/**", "internal/a/b.go", true},
+		{"internal/**", "pkg/internal/b.go", false},
+		{"?.md", "a.md", true},
+		{"?.md", "ab.md", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.path, func(t *testing.T) {
+			re := regexp.MustCompile("(?i)" + GlobToPathRegex(tt.glob))
+			assert.Equal(t, tt.matched, re.MatchString(tt.path))
+		})
+	}
+}
+
+func TestFindLineMatches(t *testing.T) {
+	q := &CodeSearchQuery{Query: "gitservice"}
+	matcher, err := q.Matcher()
+	require.NoError(t, err)
+
+	content := "package main\r\n\n// 创建GitService\nsvc := NewGitService(repo)\nvar _ GitService = svc // gitService\n"
+	matches, more := FindLineMatches(content, matcher, 10)
+	assert.False(t, more)
+	require.Len(t, matches, 3)
+
+	// 位置按字符计算，多字节字符不影响高亮
+	assert.Equal(t, 3, matches[0].LineNumber)
+	assert.Equal(t, []CodeSearchMatchRange{{Start: 5, End: 15}}, matches[0].Ranges)
+
+	assert.Equal(t, 4, matches[1].LineNumber)
+	assert.Equal(t, "svc := NewGitService(repo)", matches[1].Line)
+	assert.Equal(t, []CodeSearchMatchRange{{Start: 10, End: 20}}, matches[1].Ranges)
+
+	assert.Len(t, matches[2].Ranges, 2)
+
+	limited, more := FindLineMatches(content, matcher, 2)
+	assert.True(t, more)
+	assert.Len(t, limited, 2)
+}
+
+func TestDetectLanguage(t *testing.T) {
+	assert.Equal(t, "go", DetectLanguage("cmd/main.go"))
+	assert.Equal(t, "typescript", DetectLanguage("web/App.TSX"))
+	assert.Equal(t, "dockerfile", DetectLanguage("deploy/Dockerfile"))
+	assert.Equal(t, "dockerfile", DetectLanguage("Dockerfile.dev"))
+	assert.Equal(t, "go-module", DetectLanguage("go.mod"))
+	assert.Empty(t, DetectLanguage("LICENSE"))
+}
+
+func TestIsBinaryContent(t *testing.T) {
+	assert.False(t, IsBinaryContent([]byte("plain text\n")))
+	assert.True(t, IsBinaryContent([]byte{0x89, 'P', 'N', 'G', 0x00, 0x01}))
+}
+
+func TestParseLsTree(t *testing.T) {
+	output := "100644 blob aaaa 12\tREADME.md\x00" +
+		"100755 blob bbbb 40\tscripts/build file.sh\x00" +
+		"120000 blob cccc 9\tlink\x00" +
+		"160000 commit dddd       -\tvendor/lib\x00"
+
+	entries, err := ParseLsTree(output)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, GitTreeEntry{Mode: "100755", SHA: "bbbb", Size: 40, Path: "scripts/build file.sh"}, entries[1])
+	assert.True(t, entries[0].IsRegularFile())
+	assert.False(t, entries[2].IsRegularFile())
+
+	_, err = ParseLsTree("garbage\x00")
+	assert.Error(t, err)
+}
+
+func TestParseDiffTreeRaw(t *testing.T) {
+	output := ":100644 100644 aaaa bbbb M\x00main.go\x00" +
+		":000000 100644 0000 cccc A\x00docs/new file.md\x00" +
+		":100644 000000 dddd 0000 D\x00old.go\x00"
+
+	changes, err := ParseDiffTreeRaw(output)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	assert.Equal(t, GitTreeChange{Status: 'M', NewMode: "100644", NewSHA: "bbbb", Path: "main.go"}, changes[0])
+	assert.Equal(t, "docs/new file.md", changes[1].Path)
+	assert.Equal(t, byte('D'), changes[2].Status)
+
+	_, err = ParseDiffTreeRaw(":100644 100644 aaaa bbbb M\x00")
+	assert.Error(t, err)
+}
@@ -0,0 +1,273 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CodeownersPaths CODEOWNERS文件的查找顺序，使用第一个存在的文件
+var CodeownersPaths = []string{"CODEOWNERS", ".github/CODEOWNERS", "docs/CODEOWNERS"}
+
+// ErrCodeownersNotFound 目标分支中没有CODEOWNERS文件
+var ErrCodeownersNotFound = errors.New("CODEOWNERS file not found")
+
+// CodeOwnerType 代码所有者类型
+type CodeOwnerType string
+
+const (
+	CodeOwnerTypeUser  CodeOwnerType = "user"  // @username
+	CodeOwnerTypeEmail CodeOwnerType = "email" // user@example.com
+	CodeOwnerTypeTeam  CodeOwnerType = "team"  // @org/team，按团队服务中的团队名解析
+)
+
+// CodeOwner CODEOWNERS中声明的一个所有者
+type CodeOwner struct {
+	Type CodeOwnerType `json:"type"`
+	Name string        `json:"name"` // 用户名、邮箱或团队名（不含组织前缀），已转为小写
+	Raw  string        `json:"raw"`  // 文件中的原始写法
+}
+
+// CodeownersRule CODEOWNERS中的一条规则
+type CodeownersRule struct {
+	Line    int         `json:"line"`
+	Pattern string      `json:"pattern"`
+	Owners  []CodeOwner `json:"owners"` // 为空表示匹配的文件没有所有者
+
+	re *regexp.Regexp
+}
+
+// CodeownersError CODEOWNERS中无法解析的行，该行被忽略
+type CodeownersError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// Codeowners 解析后的CODEOWNERS文件
+type Codeowners struct {
+	Path   string            `json:"path"` // 文件在仓库中的路径
+	Ref    string            `json:"ref"`
+	Rules  []CodeownersRule  `json:"rules"`
+	Errors []CodeownersError `json:"errors,omitempty"`
+}
+
+// ParseCodeowners 解析CODEOWNERS内容
+// 每行为一个gitignore风格的路径模式和若干所有者，#开头的行和行尾的#之后为注释
+func ParseCodeowners(content string) *Codeowners {
+	codeowners := &Codeowners{Rules: []CodeownersRule{}}
+	for i, line := range strings.Split(content, "\n") {
+		lineNo := i + 1
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		pattern := fields[0]
+		re, err := compileCodeownersPattern(pattern)
+		if err != nil {
+			codeowners.Errors = append(codeowners.Errors, CodeownersError{Line: lineNo, Message: err.Error()})
+			continue
+		}
+
+		rule := CodeownersRule{Line: lineNo, Pattern: pattern, Owners: []CodeOwner{}, re: re}
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			owner, err := parseCodeOwner(field)
+			if err != nil {
+				codeowners.Errors = append(codeowners.Errors, CodeownersError{Line: lineNo, Message: err.Error()})
+				continue
+			}
+			rule.Owners = append(rule.Owners, owner)
+		}
+		codeowners.Rules = append(codeowners.Rules, rule)
+	}
+	return codeowners
+}
+
+// Match 返回匹配文件路径的规则，多条规则匹配时以最后一条为准，无匹配时返回 nil
+func (c *Codeowners) Match(filePath string) *CodeownersRule {
+	filePath = strings.TrimPrefix(filePath, "/")
+	for i := len(c.Rules) - 1; i >= 0; i-- {
+		if c.Rules[i].re.MatchString(filePath) {
+			return &c.Rules[i]
+		}
+	}
+	return nil
+}
+
+// parseCodeOwner 解析所有者：@user、@org/team 或邮箱
+func parseCodeOwner(value string) (CodeOwner, error) {
+	owner := CodeOwner{Raw: value}
+	if name, ok := strings.CutPrefix(value, "@"); ok {
+		if org, team, isTeam := strings.Cut(name, "/"); isTeam {
+			if org == "" || team == "" || strings.Contains(team, "/") {
+				return owner, fmt.Errorf("invalid team owner %q", value)
+			}
+			owner.Type, owner.Name = CodeOwnerTypeTeam, strings.ToLower(team)
+			return owner, nil
+		}
+		if name == "" || strings.Contains(name, "@") {
+			return owner, fmt.Errorf("invalid user owner %q", value)
+		}
+		owner.Type, owner.Name = CodeOwnerTypeUser, strings.ToLower(name)
+		return owner, nil
+	}
+
+	local, domain, ok := strings.Cut(value, "@")
+	if !ok || local == "" || domain == "" || strings.Contains(domain, "@") {
+		return owner, fmt.Errorf("invalid owner %q, expected @user, @org/team or an email address", value)
+	}
+	owner.Type, owner.Name = CodeOwnerTypeEmail, strings.ToLower(value)
+	return owner, nil
+}
+
+// compileCodeownersPattern 将gitignore风格的模式编译为正则
+// 以"/"开头或中间含"/"的模式从仓库根目录匹配，否则匹配任一层级；
+// 匹配目录的模式同时匹配目录下全部文件，但以"/*"结尾的模式只匹配直接子文件；
+// "**"匹配任意层级目录。不支持"!"取反和"[]"字符集
+func compileCodeownersPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") {
+		return nil, fmt.Errorf("negated pattern %q is not supported", pattern)
+	}
+	if strings.ContainsAny(pattern, "[]") {
+		return nil, fmt.Errorf("character range in pattern %q is not supported", pattern)
+	}
+	pattern = strings.TrimPrefix(pattern, `\`) // \# 表示以#开头的文件名
+
+	dirOnly := strings.HasSuffix(pattern, "/")
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+
+	segments := strings.Split(trimmed, "/")
+	last := segments[len(segments)-1]
+	for i, segment := range segments {
+		isLast := i == len(segments)-1
+		if segment == "**" {
+			if isLast {
+				expr.WriteString(".*")
+			} else {
+				expr.WriteString("(?:.*/)?")
+			}
+			continue
+		}
+		expr.WriteString(globSegmentExpr(segment))
+		if !isLast {
+			expr.WriteString("/")
+		}
+	}
+
+	switch {
+	case last == "**":
+	case dirOnly:
+		expr.WriteString("/.*")
+	case last == "*" && len(segments) > 1:
+	default:
+		expr.WriteString("(?:/.*)?")
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return re, nil
+}
+
+// globSegmentExpr 将单个路径段中的 * 和 ? 转为正则，\ 转义下一个字符
+func globSegmentExpr(segment string) string {
+	var expr strings.Builder
+	escaped := false
+	for _, r := range segment {
+		switch {
+		case escaped:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			expr.WriteString("[^/]*")
+		case r == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return expr.String()
+}
+
+// CodeOwnerUser 代码所有者对应的平台用户
+type CodeOwnerUser struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+}
+
+// FileCodeOwners 一个变更文件的代码所有者
+type FileCodeOwners struct {
+	Path    string      `json:"path"`
+	Pattern string      `json:"pattern"` // 生效的规则模式
+	Line    int         `json:"line"`    // 生效的规则所在行
+	Owners  []CodeOwner `json:"owners"`
+	UserIDs []uuid.UUID `json:"user_ids"` // 所有者解析出的平台用户，团队展开为成员
+}
+
+// CodeOwnersReport 一次分支比较中变更文件的代码所有者
+type CodeOwnersReport struct {
+	Base           string            `json:"base"`
+	Head           string            `json:"head"`
+	CodeownersPath string            `json:"codeowners_path"` // 为空表示目标分支没有CODEOWNERS
+	Files          []FileCodeOwners  `json:"files"`           // 只包含有所有者的文件
+	Users          []CodeOwnerUser   `json:"users"`           // 全部变更文件涉及的所有者用户
+	Unresolved     []CodeOwner       `json:"unresolved,omitempty"`
+	Errors         []CodeownersError `json:"errors,omitempty"`
+}
+
+// CodeOwnerApproval PR的代码所有者批准状态
+type CodeOwnerApproval struct {
+	Required     bool              `json:"required"`      // 目标分支是否要求代码所有者批准
+	Approved     bool              `json:"approved"`      // 每个有所有者的变更文件都已获得其中一位所有者批准
+	PendingFiles []FileCodeOwners  `json:"pending_files"` // 尚未获得所有者批准的文件
+	Report       *CodeOwnersReport `json:"report"`
+}
+
+// PendingCodeOwnerFiles 返回尚未获得所有者批准的文件
+// 每位审查者以最新一次审查为准；PR作者本人的审查不计入；
+// 所有者都无法解析为平台用户的文件始终待批准，须修正CODEOWNERS，不会因无人可批准而放行
+func PendingCodeOwnerFiles(files []FileCodeOwners, reviews []PRReview, authorID uuid.UUID) []FileCodeOwners {
+	latest := make(map[uuid.UUID]PRReview)
+	for _, review := range reviews {
+		if current, ok := latest[review.ReviewerID]; !ok || review.CreatedAt.After(current.CreatedAt) {
+			latest[review.ReviewerID] = review
+		}
+	}
+
+	pending := []FileCodeOwners{}
+	for _, file := range files {
+		approved := false
+		for _, userID := range file.UserIDs {
+			review, ok := latest[userID]
+			if userID != authorID && ok && review.IsApproved() {
+				approved = true
+				break
+			}
+		}
+		if !approved {
+			pending = append(pending, file)
+		}
+	}
+	return pending
+}
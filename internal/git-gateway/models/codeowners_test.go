@@ -0,0 +1,135 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCodeowners(t *testing.T) {
+	content := "# 默认所有者\n" +
+		"*       @Alice\n" +
+		"\n" +
+		"*.go    @bob dev@example.com # 行尾注释\n" +
+		"/docs/  @acme/Docs-Team\n" +
+		"!*.md   @alice\n" +
+		"/vendor/\n" +
+		"*.pem   @ @acme/\n"
+
+	codeowners := ParseCodeowners(content)
+	require.Len(t, codeowners.Rules, 5)
+
+	assert.Equal(t, []CodeOwner{{Type: CodeOwnerTypeUser, Name: "alice", Raw: "@Alice"}}, codeowners.Rules[0].Owners)
+	assert.Equal(t, []CodeOwner{
+		{Type: CodeOwnerTypeUser, Name: "bob", Raw: "@bob"},
+		{Type: CodeOwnerTypeEmail, Name: "dev@example.com", Raw: "dev@example.com"},
+	}, codeowners.Rules[1].Owners)
+	assert.Equal(t, []CodeOwner{{Type: CodeOwnerTypeTeam, Name: "docs-team", Raw: "@acme/Docs-Team"}}, codeowners.Rules[2].Owners)
+	assert.Equal(t, 7, codeowners.Rules[3].Line)
+	assert.Empty(t, codeowners.Rules[3].Owners)
+	assert.Empty(t, codeowners.Rules[4].Owners)
+
+	// 取反模式整行忽略，无效所有者单独忽略
+	require.Len(t, codeowners.Errors, 3)
+	assert.Equal(t, 6, codeowners.Errors[0].Line)
+	assert.Equal(t, 8, codeowners.Errors[1].Line)
+	assert.Equal(t, 8, codeowners.Errors[2].Line)
+}
+
+func TestCodeownersMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"通配符匹配全部文件", "*", "src/main.go", true},
+		{"扩展名匹配任一层级", "*.js", "web/app/index.js", true},
+		{"扩展名不匹配", "*.js", "web/app/index.ts", false},
+		{"目录模式匹配任一层级目录", "apps/", "services/apps/api/main.go", true},
+		{"目录模式不匹配同名文件", "apps/", "apps", false},
+		{"根目录锚定", "/build/logs/", "build/logs/today/out.log", true},
+		{"根目录锚定不匹配子目录", "/build/logs/", "src/build/logs/out.log", false},
+		{"单层通配只匹配直接子文件", "docs/*", "docs/getting-started.md", true},
+		{"单层通配不匹配嵌套文件", "docs/*", "docs/build-app/troubleshooting.md", false},
+		{"双星号匹配任意层级目录", "**/logs", "deeply/nested/logs/out.log", true},
+		{"双星号匹配根目录", "**/logs", "logs/out.log", true},
+		{"中间双星号", "src/**/test.go", "src/a/b/test.go", true},
+		{"中间双星号匹配零层", "src/**/test.go", "src/test.go", true},
+		{"结尾双星号", "/src/**", "src/a/b.go", true},
+		{"不带斜杠的路径匹配目录内容", "/apps/github", "apps/github/main.go", true},
+		{"问号匹配单个字符", "file?.txt", "dir/file1.txt", true},
+		{"问号不匹配斜杠", "a?b", "a/b", false},
+		{"大小写敏感", "*.GO", "main.go", false},
+		{"转义井号", `\#notes`, "#notes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codeowners := ParseCodeowners(tt.pattern + " @owner")
+			require.Empty(t, codeowners.Errors)
+			assert.Equal(t, tt.want, codeowners.Match(tt.path) != nil)
+		})
+	}
+}
+
+func TestCodeownersLastMatchWins(t *testing.T) {
+	codeowners := ParseCodeowners("* @default\n/apps/ @apps\n/apps/github\n")
+
+	rule := codeowners.Match("README.md")
+	require.NotNil(t, rule)
+	assert.Equal(t, "default", rule.Owners[0].Name)
+
+	rule = codeowners.Match("apps/api/main.go")
+	require.NotNil(t, rule)
+	assert.Equal(t, "apps", rule.Owners[0].Name)
+
+	// 没有所有者的规则覆盖之前的规则
+	rule = codeowners.Match("apps/github/main.go")
+	require.NotNil(t, rule)
+	assert.Empty(t, rule.Owners)
+}
+
+func TestPendingCodeOwnerFiles(t *testing.T) {
+	author, alice, bob := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	files := []FileCodeOwners{
+		{Path: "api/main.go", UserIDs: []uuid.UUID{alice}},
+		{Path: "web/index.js", UserIDs: []uuid.UUID{bob, author}},
+		{Path: "docs/README.md", UserIDs: []uuid.UUID{}},
+	}
+
+	tests := []struct {
+		name    string
+		reviews []PRReview
+		want    []string
+	}{
+		{"无审查", nil, []string{"api/main.go", "web/index.js", "docs/README.md"}},
+		{"部分批准", []PRReview{
+			{ReviewerID: alice, Status: ReviewStatusApproved, CreatedAt: now},
+		}, []string{"web/index.js", "docs/README.md"}},
+		{"作者批准不计入", []PRReview{
+			{ReviewerID: alice, Status: ReviewStatusApproved, CreatedAt: now},
+			{ReviewerID: author, Status: ReviewStatusApproved, CreatedAt: now},
+		}, []string{"web/index.js", "docs/README.md"}},
+		{"以最新审查为准", []PRReview{
+			{ReviewerID: alice, Status: ReviewStatusApproved, CreatedAt: now},
+			{ReviewerID: alice, Status: ReviewStatusRejected, CreatedAt: now.Add(time.Minute)},
+			{ReviewerID: bob, Status: ReviewStatusPending, CreatedAt: now},
+			{ReviewerID: bob, Status: ReviewStatusApproved, CreatedAt: now.Add(time.Minute)},
+		}, []string{"api/main.go", "docs/README.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			for _, file := range PendingCodeOwnerFiles(files, tt.reviews, author) {
+				paths = append(paths, file.Path)
+			}
+			assert.Equal(t, tt.want, paths)
+		})
+	}
+}
@@ -0,0 +1,578 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidDiffOptions 差异查询选项无效
+var ErrInvalidDiffOptions = errors.New("invalid diff options")
+
+// 差异分页和大小限制。文件列表和增删行数总是完整返回，行级内容按页生成，
+// 超过行数限制的文件折叠，需要时通过 path 单独查询
+const (
+	DiffDefaultPageSize        = 50
+	DiffMaxPageSize            = 300
+	DiffDefaultMaxLines        = 2000  // 单个文件默认最多返回的差异行数，超过时折叠
+	DiffMaxLinesLimit          = 50000 // 单独查询文件时最多返回的差异行数
+	DiffPageLineBudget         = 20000 // 一页最多返回的差异行数，超出后的文件折叠
+	DiffDefaultContext         = 3
+	DiffMaxContext             = 100
+	DiffDefaultRenameThreshold = 50
+	diffMaxWordTokens          = 400 // 超过该词数的行不计算行内差异
+)
+
+// DiffWhitespace 空白字符变化的处理方式
+type DiffWhitespace string
+
+const (
+	DiffWhitespaceShow         DiffWhitespace = ""              // 显示全部空白变化
+	DiffWhitespaceIgnoreAll    DiffWhitespace = "ignore-all"    // 忽略所有空白（-w）
+	DiffWhitespaceIgnoreChange DiffWhitespace = "ignore-change" // 忽略空白数量的变化（-b）
+	DiffWhitespaceIgnoreEOL    DiffWhitespace = "ignore-eol"    // 忽略行尾空白（--ignore-space-at-eol）
+)
+
+// DiffOptions 差异查询选项
+type DiffOptions struct {
+	Whitespace      DiffWhitespace `form:"whitespace"`
+	WordDiff        bool           `form:"word_diff"`        // 为成对的删除行和新增行计算行内差异
+	Renames         *bool          `form:"renames"`          // 是否检测重命名，默认检测
+	RenameThreshold int            `form:"rename_threshold"` // 重命名相似度阈值（百分比），默认50
+	Copies          bool           `form:"copies"`           // 检测复制，来源限于本次修改的文件
+	Context         *int           `form:"context"`          // 上下文行数，默认3
+	Path            string         `form:"path"`             // 只返回该文件，匹配新路径或旧路径
+	MaxLines        int            `form:"max_lines"`        // 单个文件最多返回的差异行数
+	Page            int            `form:"page"`
+	PageSize        int            `form:"page_size"`
+}
+
+// Normalize 校验并填充默认值
+func (o *DiffOptions) Normalize() error {
+	switch o.Whitespace {
+	case DiffWhitespaceShow, DiffWhitespaceIgnoreAll, DiffWhitespaceIgnoreChange, DiffWhitespaceIgnoreEOL:
+	default:
+		return fmt.Errorf("%w: whitespace %q", ErrInvalidDiffOptions, o.Whitespace)
+	}
+	if o.RenameThreshold == 0 {
+		o.RenameThreshold = DiffDefaultRenameThreshold
+	}
+	if o.RenameThreshold < 1 || o.RenameThreshold > 100 {
+		return fmt.Errorf("%w: rename_threshold must be between 1 and 100", ErrInvalidDiffOptions)
+	}
+	if o.Context == nil {
+		context := DiffDefaultContext
+		o.Context = &context
+	}
+	if *o.Context < 0 || *o.Context > DiffMaxContext {
+		return fmt.Errorf("%w: context must be between 0 and %d", ErrInvalidDiffOptions, DiffMaxContext)
+	}
+
+	if o.Path != "" {
+		cleaned, err := CleanRepoPath(o.Path)
+		if err != nil {
+			return err
+		}
+		o.Path = cleaned
+	}
+	if o.MaxLines <= 0 {
+		o.MaxLines = DiffDefaultMaxLines
+	}
+	if o.MaxLines > DiffMaxLinesLimit {
+		o.MaxLines = DiffMaxLinesLimit
+	}
+
+	if o.Page < 1 {
+		o.Page = 1
+	}
+	if o.PageSize < 1 || o.PageSize > DiffMaxPageSize {
+		o.PageSize = DiffDefaultPageSize
+	}
+	return nil
+}
+
+// DetectRenames 是否检测重命名
+func (o *DiffOptions) DetectRenames() bool {
+	return o.Renames == nil || *o.Renames
+}
+
+// GitArgs 选项对应的 git diff 参数，需先调用 Normalize。上下文行数（-U）会开启补丁输出，由调用方单独添加
+func (o *DiffOptions) GitArgs() []string {
+	var args []string
+	switch o.Whitespace {
+	case DiffWhitespaceIgnoreAll:
+		args = append(args, "-w")
+	case DiffWhitespaceIgnoreChange:
+		args = append(args, "-b")
+	case DiffWhitespaceIgnoreEOL:
+		args = append(args, "--ignore-space-at-eol")
+	}
+
+	if o.DetectRenames() {
+		args = append(args, fmt.Sprintf("-M%d%%", o.RenameThreshold))
+		if o.Copies {
+			args = append(args, fmt.Sprintf("-C%d%%", o.RenameThreshold))
+		}
+	} else {
+		args = append(args, "--no-renames")
+	}
+	return args
+}
+
+// DiffLineType 差异行类型
+type DiffLineType string
+
+const (
+	DiffLineContext DiffLineType = "context"
+	DiffLineAdded   DiffLineType = "added"
+	DiffLineDeleted DiffLineType = "deleted"
+)
+
+// DiffSegment 行内差异片段
+type DiffSegment struct {
+	Text    string `json:"text"`
+	Changed bool   `json:"changed"`
+}
+
+// DiffLine 差异中的一行，行号从1开始；新增行没有旧行号，删除行没有新行号
+type DiffLine struct {
+	Type      DiffLineType  `json:"type"`
+	OldLine   int           `json:"old_line,omitempty"`
+	NewLine   int           `json:"new_line,omitempty"`
+	Content   string        `json:"content"`
+	NoNewline bool          `json:"no_newline,omitempty"` // 文件末尾没有换行符
+	Segments  []DiffSegment `json:"segments,omitempty"`   // 行内差异，仅在 word_diff 时返回
+}
+
+// DiffHunk 差异块
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Section  string     `json:"section,omitempty"` // @@ 之后的函数或段落上下文
+	Lines    []DiffLine `json:"lines"`
+}
+
+// ParseDiffStat 解析 git diff --raw --numstat -z --no-abbrev 的输出。
+// 忽略空白时只有空白变化的文件不出现在numstat中，以numstat为准
+func ParseDiffStat(output []byte) ([]DiffFile, error) {
+	tokens := strings.Split(string(output), "\x00")
+	raw := make(map[string]DiffFile)
+
+	var files []DiffFile
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "" {
+			continue
+		}
+
+		if strings.HasPrefix(token, ":") {
+			// :<旧模式> <新模式> <旧对象> <新对象> <状态>，之后是一个或两个路径
+			fields := strings.Fields(token[1:])
+			if len(fields) != 5 || fields[4] == "" {
+				return nil, fmt.Errorf("unexpected diff raw line: %q", token)
+			}
+			file := DiffFile{
+				Status:     fields[4][:1],
+				OldMode:    diffMode(fields[0]),
+				NewMode:    diffMode(fields[1]),
+				OldBlobSHA: diffObject(fields[2]),
+				NewBlobSHA: diffObject(fields[3]),
+			}
+			if score := fields[4][1:]; score != "" {
+				file.Similarity, _ = strconv.Atoi(score)
+			}
+
+			paths := 1
+			if file.Status == "R" || file.Status == "C" {
+				paths = 2
+			}
+			if i+paths >= len(tokens) {
+				return nil, fmt.Errorf("truncated diff raw output")
+			}
+			file.Path = tokens[i+paths]
+			if paths == 2 {
+				oldPath := tokens[i+1]
+				file.OldPath = &oldPath
+			}
+			i += paths
+			raw[diffFileKey(file.OldPath, file.Path)] = file
+			continue
+		}
+
+		// <新增>\t<删除>\t<路径>，重命名时路径为空，随后是旧路径和新路径
+		parts := strings.SplitN(token, "\t", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("unexpected diff numstat line: %q", token)
+		}
+		var oldPath *string
+		path := parts[2]
+		if path == "" {
+			if i+2 >= len(tokens) {
+				return nil, fmt.Errorf("truncated diff numstat output")
+			}
+			old := tokens[i+1]
+			oldPath, path = &old, tokens[i+2]
+			i += 2
+		}
+
+		file, ok := raw[diffFileKey(oldPath, path)]
+		if !ok {
+			return nil, fmt.Errorf("diff numstat entry without raw entry: %q", path)
+		}
+		if parts[0] == "-" && parts[1] == "-" {
+			file.Binary = true
+		} else {
+			added, _ := strconv.Atoi(parts[0])
+			deleted, _ := strconv.Atoi(parts[1])
+			file.AddedLines, file.DeletedLines = int32(added), int32(deleted)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// diffFileKey raw和numstat记录的匹配键
+func diffFileKey(oldPath *string, path string) string {
+	if oldPath == nil {
+		return path
+	}
+	return *oldPath + "\x00" + path
+}
+
+// diffMode 文件模式，不存在的一侧为空
+func diffMode(mode string) string {
+	if strings.Trim(mode, "0") == "" {
+		return ""
+	}
+	return mode
+}
+
+// diffObject 对象ID，不存在的一侧为空
+func diffObject(sha string) string {
+	if isZeroSHA(sha) {
+		return ""
+	}
+	return sha
+}
+
+// DiffPatch 一个文件的行级差异
+type DiffPatch struct {
+	Path      string // 新路径，删除的文件为旧路径
+	Binary    bool
+	Truncated bool // 差异行超过上限，之后的内容被丢弃
+	Hunks     []DiffHunk
+}
+
+// ParseDiffPatch 逐行解析 git diff -p 的输出（需使用 a/ b/ 前缀）。
+// 每个文件最多保留 maxLines 行差异，超出部分只读取不保存
+func ParseDiffPatch(r io.Reader, maxLines int) ([]DiffPatch, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	var (
+		patches   []DiffPatch
+		current   *DiffPatch
+		hunk      *DiffHunk
+		lines     int // 当前文件已保存的行数
+		oldLeft   int // 当前差异块剩余的旧行数
+		newLeft   int
+		oldLine   int
+		newLine   int
+		inHunk    bool
+		lastSaved bool // 上一行是否被保存，用于标记末尾无换行
+	)
+
+	for {
+		text, err := reader.ReadString('\n')
+		if text == "" && err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		line := strings.TrimSuffix(text, "\n")
+
+		if inHunk && (oldLeft > 0 || newLeft > 0) && line != "" && line[0] != '\\' {
+			var entry DiffLine
+			switch line[0] {
+			case ' ':
+				entry = DiffLine{Type: DiffLineContext, OldLine: oldLine, NewLine: newLine}
+				oldLine, newLine = oldLine+1, newLine+1
+				oldLeft, newLeft = oldLeft-1, newLeft-1
+			case '-':
+				entry = DiffLine{Type: DiffLineDeleted, OldLine: oldLine}
+				oldLine, oldLeft = oldLine+1, oldLeft-1
+			case '+':
+				entry = DiffLine{Type: DiffLineAdded, NewLine: newLine}
+				newLine, newLeft = newLine+1, newLeft-1
+			default:
+				return nil, fmt.Errorf("unexpected diff line: %q", line)
+			}
+
+			lastSaved = false
+			if lines < maxLines {
+				entry.Content = line[1:]
+				hunk.Lines = append(hunk.Lines, entry)
+				lines++
+				lastSaved = true
+			} else {
+				current.Truncated = true
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			patches = append(patches, DiffPatch{Path: diffHeaderPath(line)})
+			current, hunk, lines, inHunk = &patches[len(patches)-1], nil, 0, false
+
+		case current == nil:
+			// 文件头之前的内容（不应出现）
+
+		case strings.HasPrefix(line, "@@ "):
+			if lines >= maxLines {
+				current.Truncated = true
+				hunk = &DiffHunk{}
+			} else {
+				current.Hunks = append(current.Hunks, DiffHunk{})
+				hunk = &current.Hunks[len(current.Hunks)-1]
+			}
+			if err := parseHunkHeader(line, hunk); err != nil {
+				return nil, err
+			}
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+			inHunk, lastSaved = true, false
+
+		case strings.HasPrefix(line, `\ `):
+			// "\ No newline at end of file" 作用于上一行
+			if lastSaved && hunk != nil && len(hunk.Lines) > 0 {
+				hunk.Lines[len(hunk.Lines)-1].NoNewline = true
+			}
+
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			if p, ok := diffPatchPath(line[4:], "b/"); ok {
+				current.Path = p
+			}
+
+		case !inHunk && strings.HasPrefix(line, "--- "):
+			if p, ok := diffPatchPath(line[4:], "a/"); ok && current.Path == "" {
+				current.Path = p
+			}
+
+		case !inHunk && (strings.HasPrefix(line, "rename to ") || strings.HasPrefix(line, "copy to ")):
+			_, name, _ := strings.Cut(line, " to ")
+			current.Path = unquoteDiffPath(name)
+
+		case !inHunk && strings.HasPrefix(line, "Binary files "):
+			current.Binary = true
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+	return patches, nil
+}
+
+// parseHunkHeader 解析 "@@ -l,s +l,s @@ section"
+func parseHunkHeader(line string, hunk *DiffHunk) error {
+	rest := strings.TrimPrefix(line, "@@ ")
+	ranges, section, ok := strings.Cut(rest, " @@")
+	if !ok {
+		return fmt.Errorf("unexpected hunk header: %q", line)
+	}
+	oldRange, newRange, ok := strings.Cut(ranges, " ")
+	if !ok || !strings.HasPrefix(oldRange, "-") || !strings.HasPrefix(newRange, "+") {
+		return fmt.Errorf("unexpected hunk header: %q", line)
+	}
+
+	var err error
+	if hunk.OldStart, hunk.OldLines, err = parseHunkRange(oldRange[1:]); err != nil {
+		return fmt.Errorf("unexpected hunk header: %q", line)
+	}
+	if hunk.NewStart, hunk.NewLines, err = parseHunkRange(newRange[1:]); err != nil {
+		return fmt.Errorf("unexpected hunk header: %q", line)
+	}
+	hunk.Section = strings.TrimPrefix(section, " ")
+	return nil
+}
+
+// parseHunkRange 解析 "l,s" 或 "l"（行数为1）
+func parseHunkRange(r string) (int, int, error) {
+	startText, countText, hasCount := strings.Cut(r, ",")
+	start, err := strconv.Atoi(startText)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !hasCount {
+		return start, 1, nil
+	}
+	count, err := strconv.Atoi(countText)
+	return start, count, err
+}
+
+// diffHeaderPath 从 "diff --git a/X b/X" 中取出路径，仅适用于新旧路径相同的情况；
+// 重命名和带引号的路径由之后的 rename to 或 +++ 行确定
+func diffHeaderPath(line string) string {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if len(rest) < 5 || (len(rest)-5)%2 != 0 || !strings.HasPrefix(rest, "a/") {
+		return ""
+	}
+	n := (len(rest) - 5) / 2
+	name := rest[2 : 2+n]
+	if rest[2+n:] != " b/"+name {
+		return ""
+	}
+	return name
+}
+
+// diffPatchPath 解析 ---/+++ 行中的路径，/dev/null 返回 false
+func diffPatchPath(name, prefix string) (string, bool) {
+	// 路径含空格时git在行尾追加制表符
+	name = unquoteDiffPath(strings.TrimSuffix(name, "\t"))
+	if !strings.HasPrefix(name, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(name, prefix), true
+}
+
+// unquoteDiffPath 还原git按C语言风格加引号的路径
+func unquoteDiffPath(name string) string {
+	if len(name) < 2 || name[0] != '"' {
+		return name
+	}
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		return unquoted
+	}
+	return name
+}
+
+// ApplyWordDiff 为差异块中相邻的删除行和新增行逐对计算行内差异
+func ApplyWordDiff(hunks []DiffHunk) {
+	for h := range hunks {
+		lines := hunks[h].Lines
+		for i := 0; i < len(lines); {
+			if lines[i].Type != DiffLineDeleted {
+				i++
+				continue
+			}
+			delStart := i
+			for i < len(lines) && lines[i].Type == DiffLineDeleted {
+				i++
+			}
+			addStart := i
+			for i < len(lines) && lines[i].Type == DiffLineAdded {
+				i++
+			}
+
+			pairs := min(addStart-delStart, i-addStart)
+			for p := 0; p < pairs; p++ {
+				oldLine, newLine := &lines[delStart+p], &lines[addStart+p]
+				oldLine.Segments, newLine.Segments = wordDiff(oldLine.Content, newLine.Content)
+			}
+		}
+	}
+}
+
+// wordDiff 按词比较两行，没有共同内容或行过长时不返回片段
+func wordDiff(oldText, newText string) ([]DiffSegment, []DiffSegment) {
+	oldTokens, newTokens := diffTokens(oldText), diffTokens(newText)
+	if len(oldTokens) > diffMaxWordTokens || len(newTokens) > diffMaxWordTokens {
+		return nil, nil
+	}
+
+	// 最长公共子序列
+	n, m := len(oldTokens), len(newTokens)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldTokens[i] == newTokens[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	common := false
+	var oldSegments, newSegments []DiffSegment
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldTokens[i] == newTokens[j]:
+			if strings.TrimSpace(oldTokens[i]) != "" {
+				common = true
+			}
+			oldSegments = appendSegment(oldSegments, oldTokens[i], false)
+			newSegments = appendSegment(newSegments, newTokens[j], false)
+			i, j = i+1, j+1
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			newSegments = appendSegment(newSegments, newTokens[j], true)
+			j++
+		default:
+			oldSegments = appendSegment(oldSegments, oldTokens[i], true)
+			i++
+		}
+	}
+
+	if !common {
+		return nil, nil
+	}
+	return oldSegments, newSegments
+}
+
+// appendSegment 追加片段，与前一个片段状态相同时合并
+func appendSegment(segments []DiffSegment, text string, changed bool) []DiffSegment {
+	if last := len(segments) - 1; last >= 0 && segments[last].Changed == changed {
+		segments[last].Text += text
+		return segments
+	}
+	return append(segments, DiffSegment{Text: text, Changed: changed})
+}
+
+// diffTokens 把一行拆分为单词、连续空白和单个标点
+func diffTokens(s string) []string {
+	var tokens []string
+	var current bytes.Buffer
+	kind := 0 // 0: 无，1: 单词，2: 空白
+
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+		kind = 0
+	}
+
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			if kind != 1 {
+				flush()
+				kind = 1
+			}
+			current.WriteRune(r)
+		case unicode.IsSpace(r):
+			if kind != 2 {
+				flush()
+				kind = 2
+			}
+			current.WriteRune(r)
+		default:
+			flush()
+			tokens = append(tokens, string(r))
+		}
+	}
+	flush()
+	return tokens
+}
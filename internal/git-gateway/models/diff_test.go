@@ -0,0 +1,187 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffOptionsNormalize(t *testing.T) {
+	context := 200
+	tests := []struct {
+		name    string
+		opts    DiffOptions
+		wantErr error
+	}{
+		{"默认值", DiffOptions{}, nil},
+		{"未知空白模式", DiffOptions{Whitespace: "ignore"}, ErrInvalidDiffOptions},
+		{"相似度超出范围", DiffOptions{RenameThreshold: 101}, ErrInvalidDiffOptions},
+		{"上下文过多", DiffOptions{Context: &context}, ErrInvalidDiffOptions},
+		{"路径跳出仓库", DiffOptions{Path: "../etc/passwd"}, ErrInvalidPath},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Normalize()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, DiffDefaultContext, *tt.opts.Context)
+			assert.Equal(t, DiffDefaultMaxLines, tt.opts.MaxLines)
+			assert.Equal(t, DiffDefaultPageSize, tt.opts.PageSize)
+			assert.Equal(t, []string{"-M50%"}, tt.opts.GitArgs())
+		})
+	}
+}
+
+func TestDiffOptionsGitArgs(t *testing.T) {
+	renames := false
+	opts := DiffOptions{Whitespace: DiffWhitespaceIgnoreChange, Renames: &renames, Copies: true}
+	require.NoError(t, opts.Normalize())
+	assert.Equal(t, []string{"-b", "--no-renames"}, opts.GitArgs())
+
+	opts = DiffOptions{Copies: true, RenameThreshold: 80}
+	require.NoError(t, opts.Normalize())
+	assert.Equal(t, []string{"-M80%", "-C80%"}, opts.GitArgs())
+}
+
+func TestParseDiffStat(t *testing.T) {
+	blobA, blobB := strings.Repeat("a", 40), strings.Repeat("b", 40)
+	zero := strings.Repeat("0", 40)
+	output := ":100644 100644 " + blobA + " " + blobB + " M\x00main.go\x00" +
+		":000000 100644 " + zero + " " + blobB + " A\x00logo.png\x00" +
+		":100644 100644 " + blobA + " " + blobB + " R087\x00old name.txt\x00new name.txt\x00" +
+		":100644 100644 " + blobA + " " + blobB + " M\x00ws.txt\x00" +
+		"3\t1\tmain.go\x00" +
+		"-\t-\tlogo.png\x00" +
+		"2\t0\t\x00old name.txt\x00new name.txt\x00"
+
+	files, err := ParseDiffStat([]byte(output))
+	require.NoError(t, err)
+	// 只有空白变化的文件不在numstat中
+	require.Len(t, files, 3)
+
+	assert.Equal(t, "main.go", files[0].Path)
+	assert.Equal(t, "M", files[0].Status)
+	assert.Equal(t, int32(3), files[0].AddedLines)
+	assert.Equal(t, int32(1), files[0].DeletedLines)
+
+	assert.True(t, files[1].Binary)
+	assert.Equal(t, "", files[1].OldMode)
+	assert.Equal(t, "", files[1].OldBlobSHA)
+	assert.Equal(t, "100644", files[1].NewMode)
+
+	assert.Equal(t, "R", files[2].Status)
+	assert.Equal(t, 87, files[2].Similarity)
+	assert.Equal(t, "new name.txt", files[2].Path)
+	require.NotNil(t, files[2].OldPath)
+	assert.Equal(t, "old name.txt", *files[2].OldPath)
+	assert.Equal(t, int32(2), files[2].AddedLines)
+}
+
+func TestParseDiffPatch(t *testing.T) {
+	patch := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@ package main
+ package main
+-var x = 1
++var x = 2
+ // end
+\ No newline at end of file
+diff --git a/old name.txt b/new name.txt
+similarity index 90%
+rename from old name.txt
+rename to new name.txt
+diff --git "a/tab\there" "b/tab\there"
+new file mode 100644
+--- /dev/null
++++ "b/tab\there"
+@@ -0,0 +1 @@
++--- not a header
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-a
+-b
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+	patches, err := ParseDiffPatch(strings.NewReader(patch), 100)
+	require.NoError(t, err)
+	require.Len(t, patches, 5)
+
+	main := patches[0]
+	assert.Equal(t, "main.go", main.Path)
+	require.Len(t, main.Hunks, 1)
+	hunk := main.Hunks[0]
+	assert.Equal(t, "package main", hunk.Section)
+	require.Len(t, hunk.Lines, 4)
+	assert.Equal(t, DiffLine{Type: DiffLineDeleted, OldLine: 2, Content: "var x = 1"}, hunk.Lines[1])
+	assert.Equal(t, DiffLine{Type: DiffLineAdded, NewLine: 2, Content: "var x = 2"}, hunk.Lines[2])
+	assert.True(t, hunk.Lines[3].NoNewline)
+
+	assert.Equal(t, "new name.txt", patches[1].Path)
+	assert.Empty(t, patches[1].Hunks)
+
+	assert.Equal(t, "tab\there", patches[2].Path)
+	assert.Equal(t, "--- not a header", patches[2].Hunks[0].Lines[0].Content)
+
+	assert.Equal(t, "gone.txt", patches[3].Path)
+	assert.Len(t, patches[3].Hunks[0].Lines, 2)
+
+	assert.True(t, patches[4].Binary)
+	assert.Equal(t, "logo.png", patches[4].Path)
+}
+
+func TestParseDiffPatchTruncated(t *testing.T) {
+	patch := "diff --git a/big b/big\n--- a/big\n+++ b/big\n@@ -0,0 +1,3 @@\n+1\n+2\n+3\n@@ -10,0 +11 @@\n+4\n" +
+		"diff --git a/small b/small\n--- a/small\n+++ b/small\n@@ -1 +1 @@\n-a\n+b\n"
+
+	patches, err := ParseDiffPatch(strings.NewReader(patch), 2)
+	require.NoError(t, err)
+	require.Len(t, patches, 2)
+
+	assert.True(t, patches[0].Truncated)
+	require.Len(t, patches[0].Hunks, 1)
+	assert.Len(t, patches[0].Hunks[0].Lines, 2)
+
+	// 上限按文件计算
+	assert.False(t, patches[1].Truncated)
+	assert.Len(t, patches[1].Hunks[0].Lines, 2)
+}
+
+func TestApplyWordDiff(t *testing.T) {
+	hunks := []DiffHunk{{Lines: []DiffLine{
+		{Type: DiffLineDeleted, Content: "return x + 1"},
+		{Type: DiffLineDeleted, Content: "unmatched"},
+		{Type: DiffLineAdded, Content: "return y + 1"},
+		{Type: DiffLineContext, Content: "}"},
+		{Type: DiffLineDeleted, Content: "foo"},
+		{Type: DiffLineAdded, Content: "bar"},
+	}}}
+	ApplyWordDiff(hunks)
+	lines := hunks[0].Lines
+
+	assert.Equal(t, []DiffSegment{
+		{Text: "return ", Changed: false},
+		{Text: "x", Changed: true},
+		{Text: " + 1", Changed: false},
+	}, lines[0].Segments)
+	assert.Equal(t, []DiffSegment{
+		{Text: "return ", Changed: false},
+		{Text: "y", Changed: true},
+		{Text: " + 1", Changed: false},
+	}, lines[2].Segments)
+	// 没有配对的行和完全不同的行不返回片段
+	assert.Nil(t, lines[1].Segments)
+	assert.Nil(t, lines[4].Segments)
+	assert.Nil(t, lines[5].Segments)
+}
@@ -0,0 +1,137 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// fork相关错误
+var (
+	ErrRepositoryNotFound       = errors.New("repository not found")
+	ErrRepositoryNameTaken      = errors.New("repository name already exists in project")
+	ErrForkVisibility           = errors.New("fork cannot be more visible than its upstream")
+	ErrNotAFork                 = errors.New("repository is not a fork")
+	ErrForkDiverged             = errors.New("fork branch has diverged from upstream")
+	ErrForkSyncConflict         = errors.New("merging upstream into fork has conflicts")
+	ErrInvalidSyncStrategy      = errors.New("invalid fork sync strategy")
+	ErrInvalidPullRequestSource = errors.New("invalid pull request source")
+	ErrPullRequestNotFound      = errors.New("pull request not found")
+	ErrRepositoryAccessDenied   = errors.New("repository access denied")
+	ErrProjectAccessDenied      = errors.New("project access denied")
+
+	ErrInvalidPullRequestReference = errors.New("invalid pull request reference")
+)
+
+// pullRequestReferenceFormat 任务编号格式，如 PROJ-123
+var pullRequestReferenceFormat = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*-[0-9]+$`)
+
+// PullRequestReferencePattern 生成匹配PR标题或源分支中任务编号的正则（同时适用于Go和PostgreSQL），
+// 前后不能紧邻字母数字，避免 PROJ-12 匹配到 PROJ-123
+func PullRequestReferencePattern(reference string) (string, error) {
+	if !pullRequestReferenceFormat.MatchString(reference) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPullRequestReference, reference)
+	}
+	return `(^|[^[:alnum:]])` + reference + `($|[^[:alnum:]])`, nil
+}
+
+// RepositoryAccess 发起仓库操作的用户和租户，用于跨仓库、跨项目操作的权限校验
+type RepositoryAccess struct {
+	UserID   uuid.UUID
+	TenantID uuid.UUID
+}
+
+// UpstreamRefPrefix 同步fork时上游分支在fork仓库中的暂存引用前缀
+const UpstreamRefPrefix = "refs/upstream/"
+
+// PullRequestHeadRef 来自fork的PR在上游仓库中的源分支引用
+func PullRequestHeadRef(number int) string {
+	return fmt.Sprintf("refs/pull/%d/head", number)
+}
+
+// ForkRepositoryRequest 创建fork请求
+type ForkRepositoryRequest struct {
+	ProjectID   string                `json:"project_id" binding:"required,uuid"`
+	Name        *string               `json:"name" validate:"omitempty,min=1,max=255"` // 为空时沿用上游仓库名称
+	Description *string               `json:"description" validate:"omitempty,max=2000"`
+	Visibility  *RepositoryVisibility `json:"visibility" validate:"omitempty,oneof=public private internal"` // 为空时沿用上游可见性
+}
+
+// ForkSyncStrategy fork同步策略
+type ForkSyncStrategy string
+
+const (
+	ForkSyncStrategyFastForward ForkSyncStrategy = "fast_forward" // 只允许快进，分叉时返回错误
+	ForkSyncStrategyMerge       ForkSyncStrategy = "merge"        // 能快进则快进，否则创建合并提交
+)
+
+// SyncForkRequest 同步fork请求
+type SyncForkRequest struct {
+	Branch   string           `json:"branch"`   // fork中要同步的分支，为空时使用fork的默认分支
+	Strategy ForkSyncStrategy `json:"strategy"` // 为空时使用merge
+}
+
+// Normalize 填充默认值并校验
+func (r *SyncForkRequest) Normalize(defaultBranch string) error {
+	if r.Branch == "" {
+		r.Branch = defaultBranch
+	}
+	if err := ValidateRefName(r.Branch); err != nil {
+		return err
+	}
+	switch r.Strategy {
+	case "":
+		r.Strategy = ForkSyncStrategyMerge
+	case ForkSyncStrategyFastForward, ForkSyncStrategyMerge:
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidSyncStrategy, r.Strategy)
+	}
+	return nil
+}
+
+// ForkSyncStatus fork同步结果状态
+type ForkSyncStatus string
+
+const (
+	ForkSyncStatusUpToDate      ForkSyncStatus = "up_to_date"
+	ForkSyncStatusFastForwarded ForkSyncStatus = "fast_forwarded"
+	ForkSyncStatusMerged        ForkSyncStatus = "merged"
+)
+
+// ForkSyncResult fork同步结果
+type ForkSyncResult struct {
+	Branch         string         `json:"branch"`
+	UpstreamBranch string         `json:"upstream_branch"`
+	Status         ForkSyncStatus `json:"status"`
+	BeforeSHA      string         `json:"before_sha"`
+	AfterSHA       string         `json:"after_sha"`
+	UpstreamSHA    string         `json:"upstream_sha"`
+	AheadBy        int            `json:"ahead_by"`            // 同步前fork领先上游的提交数
+	BehindBy       int            `json:"behind_by"`           // 同步前fork落后上游的提交数
+	Conflicts      []string       `json:"conflicts,omitempty"` // 合并冲突的文件
+}
+
+// ResolveForkVisibility 计算fork的可见性：默认沿用上游，且不得比上游更公开，避免私有代码经fork泄露
+func ResolveForkVisibility(upstream RepositoryVisibility, requested *RepositoryVisibility) (RepositoryVisibility, error) {
+	if requested == nil || *requested == "" {
+		return upstream, nil
+	}
+	if visibilityRank(*requested) > visibilityRank(upstream) {
+		return "", fmt.Errorf("%w: %s upstream cannot be forked as %s", ErrForkVisibility, upstream, *requested)
+	}
+	return *requested, nil
+}
+
+// visibilityRank 可见性由低到高排序
+func visibilityRank(v RepositoryVisibility) int {
+	switch v {
+	case RepositoryVisibilityPublic:
+		return 2
+	case RepositoryVisibilityInternal:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveForkVisibility(t *testing.T) {
+	visibility := func(v RepositoryVisibility) *RepositoryVisibility { return &v }
+
+	tests := []struct {
+		name      string
+		upstream  RepositoryVisibility
+		requested *RepositoryVisibility
+		want      RepositoryVisibility
+		wantErr   error
+	}{
+		{"默认沿用上游", RepositoryVisibilityInternal, nil, RepositoryVisibilityInternal, nil},
+		{"空值沿用上游", RepositoryVisibilityPrivate, visibility(""), RepositoryVisibilityPrivate, nil},
+		{"降低可见性", RepositoryVisibilityPublic, visibility(RepositoryVisibilityPrivate), RepositoryVisibilityPrivate, nil},
+		{"相同可见性", RepositoryVisibilityInternal, visibility(RepositoryVisibilityInternal), RepositoryVisibilityInternal, nil},
+		{"私有仓库fork为公开", RepositoryVisibilityPrivate, visibility(RepositoryVisibilityPublic), "", ErrForkVisibility},
+		{"私有仓库fork为内部", RepositoryVisibilityPrivate, visibility(RepositoryVisibilityInternal), "", ErrForkVisibility},
+		{"内部仓库fork为公开", RepositoryVisibilityInternal, visibility(RepositoryVisibilityPublic), "", ErrForkVisibility},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveForkVisibility(tt.upstream, tt.requested)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSyncForkRequestNormalize(t *testing.T) {
+	req := &SyncForkRequest{}
+	require.NoError(t, req.Normalize("main"))
+	assert.Equal(t, "main", req.Branch)
+	assert.Equal(t, ForkSyncStrategyMerge, req.Strategy)
+
+	req = &SyncForkRequest{Branch: "release/1.0", Strategy: ForkSyncStrategyFastForward}
+	require.NoError(t, req.Normalize("main"))
+	assert.Equal(t, "release/1.0", req.Branch)
+	assert.Equal(t, ForkSyncStrategyFastForward, req.Strategy)
+
+	req = &SyncForkRequest{Strategy: "rebase"}
+	assert.ErrorIs(t, req.Normalize("main"), ErrInvalidSyncStrategy)
+
+	req = &SyncForkRequest{Branch: "--force"}
+	assert.ErrorIs(t, req.Normalize("main"), ErrInvalidRef)
+}
+
+func TestPullRequestHeadRef(t *testing.T) {
+	assert.Equal(t, "refs/pull/42/head", PullRequestHeadRef(42))
+}
+
+func TestPullRequestReferencePattern(t *testing.T) {
+	pattern, err := PullRequestReferencePattern("PROJ-12")
+	require.NoError(t, err)
+
+	re := regexp.MustCompile("(?i)" + pattern)
+	assert.True(t, re.MatchString("PROJ-12 fix login"))
+	assert.True(t, re.MatchString("feature/proj-12-login"))
+	assert.False(t, re.MatchString("PROJ-123 other task"))
+	assert.False(t, re.MatchString("XPROJ-12"))
+
+	for _, reference := range []string{"", "PROJ", "PROJ-", "PROJ-1'; --", "PROJ-1|.*"} {
+		_, err := PullRequestReferencePattern(reference)
+		assert.ErrorIs(t, err, ErrInvalidPullRequestReference, reference)
+	}
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// 引用和路径校验错误
+var (
+	ErrInvalidRef  = errors.New("invalid ref")
+	ErrInvalidPath = errors.New("invalid path")
+	ErrRefNotFound = errors.New("ref not found")
+)
+
+// ValidateRefName 校验引用名称，拒绝可能被git解析为选项或表达式的输入
+func ValidateRefName(ref string) error {
+	if ref == "" || strings.HasPrefix(ref, "-") || strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/") {
+		return fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+	if strings.Contains(ref, "..") || strings.Contains(ref, "//") || strings.Contains(ref, "@{") {
+		return fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+	}
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return fmt.Errorf("%w: %q", ErrInvalidRef, ref)
+		}
+	}
+	return nil
+}
+
+// CleanRepoPath 规范化仓库内的相对路径，拒绝跳出仓库根目录的路径
+func CleanRepoPath(p string) (string, error) {
+	p = strings.Trim(strings.TrimSpace(p), "/")
+	if p == "" {
+		return "", nil
+	}
+	for _, segment := range strings.Split(p, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: %s", ErrInvalidPath, p)
+		}
+	}
+	cleaned := path.Clean(p)
+	if cleaned == "." {
+		return "", nil
+	}
+	if strings.HasPrefix(cleaned, "-") {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPath, p)
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,214 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Git LFS错误
+var (
+	ErrLFSObjectNotFound   = errors.New("LFS object not found")
+	ErrLFSInvalidObject    = errors.New("invalid LFS object")
+	ErrLFSSizeMismatch     = errors.New("LFS object size mismatch")
+	ErrLFSQuotaExceeded    = errors.New("tenant storage quota exceeded")
+	ErrLFSAccessDenied     = errors.New("LFS access denied")
+	ErrLFSInvalidOperation = errors.New("invalid LFS operation")
+)
+
+// Git LFS协议常量
+const (
+	LFSMediaType         = "application/vnd.git-lfs+json"
+	LFSTransferBasic     = "basic"
+	LFSHashAlgoSHA256    = "sha256"
+	LFSPointerVersion    = "https://git-lfs.github.com/spec/v1"
+	LFSPointerMaxSize    = 1024 // 指针文件大小上限，超过的blob不可能是指针
+	LFSMaxBatchObjects   = 1000 // 单次批量请求的对象数上限
+	LFSOperationUpload   = "upload"
+	LFSOperationDownload = "download"
+)
+
+// lfsOIDPattern LFS对象ID为小写十六进制SHA-256
+var lfsOIDPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// LFSObject LFS对象，按OID内容寻址，多个仓库可共享
+type LFSObject struct {
+	OID       string    `json:"oid" gorm:"column:oid;size:64;primary_key"`
+	Size      int64     `json:"size" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (LFSObject) TableName() string {
+	return "lfs_objects"
+}
+
+// RepositoryLFSObject 仓库与LFS对象的关联，对象大小计入仓库大小
+type RepositoryLFSObject struct {
+	RepositoryID uuid.UUID `json:"repository_id" gorm:"type:uuid;primary_key"`
+	OID          string    `json:"oid" gorm:"column:oid;size:64;primary_key"`
+	Size         int64     `json:"size" gorm:"not null"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (RepositoryLFSObject) TableName() string {
+	return "repository_lfs_objects"
+}
+
+// LFSAccess 访问LFS的用户身份
+type LFSAccess struct {
+	UserID   uuid.UUID
+	TenantID uuid.UUID
+}
+
+// LFSBatchRequest 批量接口请求
+type LFSBatchRequest struct {
+	Operation string          `json:"operation" binding:"required"`
+	Transfers []string        `json:"transfers"`
+	Ref       *LFSRef         `json:"ref"`
+	Objects   []LFSObjectSpec `json:"objects" binding:"required"`
+	HashAlgo  string          `json:"hash_algo"`
+}
+
+// LFSRef 批量请求关联的引用
+type LFSRef struct {
+	Name string `json:"name"`
+}
+
+// LFSObjectSpec 批量请求中的对象
+type LFSObjectSpec struct {
+	OID  string `json:"oid"`
+	Size int64  `json:"size"`
+}
+
+// LFSBatchResponse 批量接口响应
+type LFSBatchResponse struct {
+	Transfer string            `json:"transfer"`
+	Objects  []LFSObjectResult `json:"objects"`
+	HashAlgo string            `json:"hash_algo"`
+}
+
+// LFSObjectResult 批量响应中的对象，Actions为空表示无需传输
+type LFSObjectResult struct {
+	OID           string                `json:"oid"`
+	Size          int64                 `json:"size"`
+	Authenticated bool                  `json:"authenticated,omitempty"`
+	Actions       map[string]*LFSAction `json:"actions,omitempty"`
+	Error         *LFSObjectError       `json:"error,omitempty"`
+}
+
+// LFSAction 对象的上传/下载/校验操作
+type LFSAction struct {
+	Href      string            `json:"href"`
+	Header    map[string]string `json:"header,omitempty"`
+	ExpiresIn int               `json:"expires_in,omitempty"` // 秒
+}
+
+// LFSObjectError 批量响应中单个对象的错误
+type LFSObjectError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// LFSErrorResponse LFS接口错误响应
+type LFSErrorResponse struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url,omitempty"`
+	RequestID        string `json:"request_id,omitempty"`
+}
+
+// LFSObjectListResponse 仓库LFS对象列表响应
+type LFSObjectListResponse struct {
+	Objects   []RepositoryLFSObject `json:"objects"`
+	Total     int64                 `json:"total"`
+	TotalSize int64                 `json:"total_size"`
+	Page      int                   `json:"page"`
+	PageSize  int                   `json:"page_size"`
+}
+
+// LFSGCResult LFS垃圾回收结果
+type LFSGCResult struct {
+	RepositoriesScanned int           `json:"repositories_scanned"`
+	LinksDeleted        int           `json:"links_deleted"`
+	ObjectsDeleted      int           `json:"objects_deleted"`
+	BytesFreed          int64         `json:"bytes_freed"`
+	Duration            time.Duration `json:"duration"`
+}
+
+// ValidateLFSOID 校验LFS对象ID
+func ValidateLFSOID(oid string) error {
+	if !lfsOIDPattern.MatchString(oid) {
+		return fmt.Errorf("%w: oid must be a lowercase hex SHA-256", ErrLFSInvalidObject)
+	}
+	return nil
+}
+
+// Validate 校验批量请求的操作、哈希算法和传输方式
+func (r *LFSBatchRequest) Validate() error {
+	if r.Operation != LFSOperationUpload && r.Operation != LFSOperationDownload {
+		return fmt.Errorf("%w: %s", ErrLFSInvalidOperation, r.Operation)
+	}
+	if r.HashAlgo != "" && r.HashAlgo != LFSHashAlgoSHA256 {
+		return fmt.Errorf("%w: unsupported hash algorithm %s", ErrLFSInvalidOperation, r.HashAlgo)
+	}
+	if len(r.Transfers) > 0 && !containsString(r.Transfers, LFSTransferBasic) {
+		return fmt.Errorf("%w: only the basic transfer adapter is supported", ErrLFSInvalidOperation)
+	}
+	if len(r.Objects) > LFSMaxBatchObjects {
+		return fmt.Errorf("%w: at most %d objects per batch", ErrLFSInvalidOperation, LFSMaxBatchObjects)
+	}
+	return nil
+}
+
+// ParseLFSPointer 解析LFS指针文件内容，返回对象ID和大小
+func ParseLFSPointer(data []byte) (string, int64, bool) {
+	if len(data) > LFSPointerMaxSize || !bytes.HasPrefix(data, []byte("version ")) {
+		return "", 0, false
+	}
+
+	var version, oid string
+	size := int64(-1)
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			return "", 0, false
+		}
+		switch key {
+		case "version":
+			version = value
+		case "oid":
+			oid, ok = strings.CutPrefix(value, LFSHashAlgoSHA256+":")
+			if !ok {
+				return "", 0, false
+			}
+		case "size":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				return "", 0, false
+			}
+			size = n
+		}
+	}
+
+	if version != LFSPointerVersion || ValidateLFSOID(oid) != nil || size < 0 {
+		return "", 0, false
+	}
+	return oid, size, true
+}
+
+// containsString 判断切片中是否包含字符串
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
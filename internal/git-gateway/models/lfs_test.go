@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testLFSOID = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+func TestParseLFSPointer(t *testing.T) {
+	valid := "version https://git-lfs.github.com/spec/v1\noid sha256:" + testLFSOID + "\nsize 12345\n"
+
+	tests := []struct {
+		name     string
+		data     string
+		wantOID  string
+		wantSize int64
+		wantOK   bool
+	}{
+		{"标准指针", valid, testLFSOID, 12345, true},
+		{"缺少结尾换行", strings.TrimSuffix(valid, "\n"), testLFSOID, 12345, true},
+		{"包含扩展字段", valid + "ext-0-foo sha256:" + testLFSOID + "\n", testLFSOID, 12345, true},
+		{"普通文件", "hello world\n", "", 0, false},
+		{"版本不符", strings.Replace(valid, "spec/v1", "spec/v2", 1), "", 0, false},
+		{"不支持的哈希", strings.Replace(valid, "sha256:", "sha1:", 1), "", 0, false},
+		{"OID非法", strings.Replace(valid, testLFSOID, "abc", 1), "", 0, false},
+		{"缺少大小", "version https://git-lfs.github.com/spec/v1\noid sha256:" + testLFSOID + "\n", "", 0, false},
+		{"负数大小", strings.Replace(valid, "size 12345", "size -1", 1), "", 0, false},
+		{"超过指针大小上限", valid + strings.Repeat("x", LFSPointerMaxSize), "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oid, size, ok := ParseLFSPointer([]byte(tt.data))
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantOID, oid)
+			assert.Equal(t, tt.wantSize, size)
+		})
+	}
+}
+
+func TestLFSBatchRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     LFSBatchRequest
+		wantErr bool
+	}{
+		{"下载", LFSBatchRequest{Operation: LFSOperationDownload}, false},
+		{"上传并声明basic传输", LFSBatchRequest{Operation: LFSOperationUpload, Transfers: []string{"lfs-standalone-file", LFSTransferBasic}, HashAlgo: LFSHashAlgoSHA256}, false},
+		{"未知操作", LFSBatchRequest{Operation: "delete"}, true},
+		{"不支持的哈希算法", LFSBatchRequest{Operation: LFSOperationDownload, HashAlgo: "sha512"}, true},
+		{"不支持的传输方式", LFSBatchRequest{Operation: LFSOperationDownload, Transfers: []string{"ssh"}}, true},
+		{"对象过多", LFSBatchRequest{Operation: LFSOperationDownload, Objects: make([]LFSObjectSpec, LFSMaxBatchObjects+1)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrLFSInvalidOperation)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 仓库维护错误
+var (
+	ErrInvalidMaintenanceMode = errors.New("invalid maintenance mode")
+	ErrMaintenanceRunning     = errors.New("maintenance is already running for this repository")
+)
+
+// MaintenanceMode 维护方式
+type MaintenanceMode string
+
+const (
+	MaintenanceModeRepack MaintenanceMode = "repack" // 增量打包松散对象，不删除任何对象
+	MaintenanceModeGC     MaintenanceMode = "gc"     // 完整gc：合并全部pack并清理不可达对象
+)
+
+// MaintenanceTrigger 维护触发方式
+type MaintenanceTrigger string
+
+const (
+	MaintenanceTriggerScheduled MaintenanceTrigger = "scheduled"
+	MaintenanceTriggerManual    MaintenanceTrigger = "manual"
+)
+
+// MaintenanceStatus 维护执行状态
+type MaintenanceStatus string
+
+const (
+	MaintenanceStatusRunning   MaintenanceStatus = "running"
+	MaintenanceStatusSucceeded MaintenanceStatus = "succeeded"
+	MaintenanceStatusFailed    MaintenanceStatus = "failed"
+)
+
+// RepositoryHealth 仓库对象库健康状态
+type RepositoryHealth string
+
+const (
+	RepositoryHealthUnknown RepositoryHealth = "unknown" // 尚未执行过fsck
+	RepositoryHealthHealthy RepositoryHealth = "healthy"
+	RepositoryHealthCorrupt RepositoryHealth = "corrupt" // fsck发现损坏或缺失的对象
+)
+
+// RepositoryMaintenance 仓库维护状态，每个仓库一行
+type RepositoryMaintenance struct {
+	RepositoryID        uuid.UUID         `json:"repository_id" gorm:"type:uuid;primary_key"`
+	PushesSinceRun      int               `json:"pushes_since_run" gorm:"not null;default:0"` // 上次维护以来的推送次数
+	Health              RepositoryHealth  `json:"health" gorm:"size:20;not null;default:unknown"`
+	LastStatus          MaintenanceStatus `json:"last_status" gorm:"size:20"`
+	LastRunAt           *time.Time        `json:"last_run_at"`
+	LastSuccessAt       *time.Time        `json:"last_success_at"`
+	LastFullGCAt        *time.Time        `json:"last_full_gc_at"`
+	DiskSize            int64             `json:"disk_size" gorm:"not null;default:0"` // Git对象库占用的磁盘大小（字节），不含LFS
+	ObjectCount         int64             `json:"object_count" gorm:"not null;default:0"`
+	PackCount           int64             `json:"pack_count" gorm:"not null;default:0"`
+	ConsecutiveFailures int               `json:"consecutive_failures" gorm:"not null;default:0"`
+	RetryAfter          *time.Time        `json:"retry_after"` // 失败后在此之前不再自动执行
+	UpdatedAt           time.Time         `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (RepositoryMaintenance) TableName() string {
+	return "repository_maintenance"
+}
+
+// MaintenanceRun 一次仓库维护记录
+type MaintenanceRun struct {
+	ID           uuid.UUID          `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v7()"`
+	RepositoryID uuid.UUID          `json:"repository_id" gorm:"type:uuid;not null;index"`
+	Trigger      MaintenanceTrigger `json:"trigger" gorm:"size:20;not null"`
+	Mode         MaintenanceMode    `json:"mode" gorm:"size:20;not null"`
+	Status       MaintenanceStatus  `json:"status" gorm:"size:20;not null;default:running"`
+	TriggeredBy  *uuid.UUID         `json:"triggered_by" gorm:"type:uuid"`
+	PruneObjects bool               `json:"prune_objects" gorm:"not null;default:false"` // 是否清理不可达对象，有fork借用对象时为false
+	Health       RepositoryHealth   `json:"health" gorm:"size:20"`
+	FsckOutput   *string            `json:"fsck_output" gorm:"type:text"` // fsck发现的问题，已截断
+	SizeBefore   int64              `json:"size_before" gorm:"not null;default:0"`
+	SizeAfter    int64              `json:"size_after" gorm:"not null;default:0"`
+	ObjectCount  int64              `json:"object_count" gorm:"not null;default:0"`
+	PackCount    int64              `json:"pack_count" gorm:"not null;default:0"`
+	ErrorMessage *string            `json:"error_message" gorm:"type:text"`
+	StartedAt    time.Time          `json:"started_at" gorm:"not null;default:now()"`
+	FinishedAt   *time.Time         `json:"finished_at"`
+}
+
+// TableName 指定表名
+func (MaintenanceRun) TableName() string {
+	return "repository_maintenance_runs"
+}
+
+// TriggerMaintenanceRequest 手动触发维护请求
+type TriggerMaintenanceRequest struct {
+	Mode MaintenanceMode `json:"mode"` // 为空时执行完整gc
+}
+
+// Normalize 校验维护方式
+func (r *TriggerMaintenanceRequest) Normalize() error {
+	switch r.Mode {
+	case "":
+		r.Mode = MaintenanceModeGC
+	case MaintenanceModeRepack, MaintenanceModeGC:
+	default:
+		return fmt.Errorf("%w: %q", ErrInvalidMaintenanceMode, r.Mode)
+	}
+	return nil
+}
+
+// MaintenanceStatusResponse 仓库维护状态及最近的维护记录
+type MaintenanceStatusResponse struct {
+	State *RepositoryMaintenance `json:"state"`
+	Runs  []MaintenanceRun       `json:"runs"`
+}
+
+// MaintenanceRunListResponse 维护记录列表响应
+type MaintenanceRunListResponse struct {
+	Runs     []MaintenanceRun `json:"runs"`
+	Total    int64            `json:"total"`
+	Page     int              `json:"page"`
+	PageSize int              `json:"page_size"`
+}
+
+// GitObjectStats git count-objects -v 的统计结果，大小单位为字节
+type GitObjectStats struct {
+	LooseCount  int64
+	LooseSize   int64
+	InPack      int64
+	PackCount   int64
+	PackSize    int64
+	GarbageSize int64
+}
+
+// ObjectCount 对象总数
+func (s GitObjectStats) ObjectCount() int64 {
+	return s.LooseCount + s.InPack
+}
+
+// DiskSize 对象库占用的磁盘大小，不含alternates中借用的对象
+func (s GitObjectStats) DiskSize() int64 {
+	return s.LooseSize + s.PackSize + s.GarbageSize
+}
+
+// ParseCountObjects 解析 git count-objects -v 的输出，大小以KiB为单位
+func ParseCountObjects(output string) (GitObjectStats, error) {
+	var stats GitObjectStats
+	fields := map[string]*int64{
+		"count":        &stats.LooseCount,
+		"size":         &stats.LooseSize,
+		"in-pack":      &stats.InPack,
+		"packs":        &stats.PackCount,
+		"size-pack":    &stats.PackSize,
+		"size-garbage": &stats.GarbageSize,
+	}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		target, known := fields[strings.TrimSpace(key)]
+		if !known {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return stats, fmt.Errorf("invalid count-objects value %q: %w", line, err)
+		}
+		*target = n
+	}
+	stats.LooseSize *= 1024
+	stats.PackSize *= 1024
+	stats.GarbageSize *= 1024
+	return stats, nil
+}
+
+// MaintenanceRetryDelay 连续失败后的重试间隔，从1小时起倍增，最长1天
+func MaintenanceRetryDelay(consecutiveFailures int) time.Duration {
+	delay := time.Hour
+	for i := 1; i < consecutiveFailures && delay < 24*time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > 24*time.Hour {
+		delay = 24 * time.Hour
+	}
+	return delay
+}
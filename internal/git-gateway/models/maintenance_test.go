@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCountObjects(t *testing.T) {
+	output := "count: 12\n" +
+		"size: 48\n" +
+		"in-pack: 3400\n" +
+		"packs: 2\n" +
+		"size-pack: 1024\n" +
+		"prune-packable: 0\n" +
+		"garbage: 1\n" +
+		"size-garbage: 4\n" +
+		"alternate: /var/git/repositories/upstream.git/objects\n"
+
+	stats, err := ParseCountObjects(output)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3412), stats.ObjectCount())
+	assert.Equal(t, int64(2), stats.PackCount)
+	assert.Equal(t, int64((48+1024+4)*1024), stats.DiskSize())
+
+	_, err = ParseCountObjects("count: many\n")
+	assert.Error(t, err)
+}
+
+func TestMaintenanceRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{"首次失败", 1, time.Hour},
+		{"第二次失败", 2, 2 * time.Hour},
+		{"第四次失败", 4, 8 * time.Hour},
+		{"最长一天", 10, 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MaintenanceRetryDelay(tt.failures))
+		})
+	}
+}
+
+func TestTriggerMaintenanceRequestNormalize(t *testing.T) {
+	req := &TriggerMaintenanceRequest{}
+	require.NoError(t, req.Normalize())
+	assert.Equal(t, MaintenanceModeGC, req.Mode)
+
+	req = &TriggerMaintenanceRequest{Mode: MaintenanceModeRepack}
+	require.NoError(t, req.Normalize())
+	assert.Equal(t, MaintenanceModeRepack, req.Mode)
+
+	req = &TriggerMaintenanceRequest{Mode: "aggressive"}
+	assert.ErrorIs(t, req.Normalize(), ErrInvalidMaintenanceMode)
+}
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 导入与镜像错误
+var (
+	ErrInvalidRemoteURL      = errors.New("invalid remote URL")
+	ErrRemoteAddressDenied   = errors.New("remote address is not allowed")
+	ErrInvalidMirrorInterval = errors.New("invalid mirror interval")
+	ErrPullMirrorExists      = errors.New("repository already has a pull mirror")
+	ErrMirrorNotFound        = errors.New("mirror not found")
+	ErrMirrorDisabled        = errors.New("mirror is disabled")
+	ErrImportNotFound        = errors.New("import not found")
+	ErrRepositoryReadOnly    = errors.New("repository is a read-only mirror")
+)
+
+// 镜像同步间隔（分钟）
+const (
+	DefaultMirrorIntervalMinutes = 60
+	MinMirrorIntervalMinutes     = 5
+	MaxMirrorIntervalMinutes     = 7 * 24 * 60
+)
+
+// MirrorRefspecs 导入和镜像同步的引用范围
+// 只同步分支和标签：外部托管平台的 refs/pull/* 等引用会与本平台PR引用冲突
+var MirrorRefspecs = []string{
+	"+refs/heads/*:refs/heads/*",
+	"+refs/tags/*:refs/tags/*",
+}
+
+// ImportStatus 导入任务状态
+type ImportStatus string
+
+const (
+	ImportStatusPending   ImportStatus = "pending"
+	ImportStatusRunning   ImportStatus = "running"
+	ImportStatusSucceeded ImportStatus = "succeeded"
+	ImportStatusFailed    ImportStatus = "failed"
+)
+
+// RepositoryImport 仓库导入任务
+type RepositoryImport struct {
+	ID           uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v7()"`
+	RepositoryID uuid.UUID    `json:"repository_id" gorm:"type:uuid;not null;index"`
+	SourceURL    string       `json:"source_url" gorm:"size:1024;not null"` // 不含凭据
+	Status       ImportStatus `json:"status" gorm:"size:20;not null;default:'pending'"`
+	Phase        string       `json:"phase" gorm:"size:100"`              // 当前阶段，如 Receiving objects
+	Progress     int          `json:"progress" gorm:"not null;default:0"` // 总体进度 0-100
+	ErrorMessage *string      `json:"error_message" gorm:"type:text"`
+	CreatedBy    uuid.UUID    `json:"created_by" gorm:"type:uuid;not null"`
+	StartedAt    *time.Time   `json:"started_at"`
+	FinishedAt   *time.Time   `json:"finished_at"`
+	CreatedAt    time.Time    `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt    time.Time    `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (RepositoryImport) TableName() string {
+	return "repository_imports"
+}
+
+// MirrorDirection 镜像方向
+type MirrorDirection string
+
+const (
+	MirrorDirectionPull MirrorDirection = "pull" // 定期从外部远程拉取，本地仓库只读
+	MirrorDirectionPush MirrorDirection = "push" // 每次推送后复制到外部远程
+)
+
+// MirrorSyncStatus 镜像同步状态
+type MirrorSyncStatus string
+
+const (
+	MirrorSyncStatusPending   MirrorSyncStatus = "pending"
+	MirrorSyncStatusRunning   MirrorSyncStatus = "running"
+	MirrorSyncStatusSucceeded MirrorSyncStatus = "succeeded"
+	MirrorSyncStatusFailed    MirrorSyncStatus = "failed"
+)
+
+// RepositoryMirror 仓库镜像配置，凭据保存在Vault中
+type RepositoryMirror struct {
+	ID              uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v7()"`
+	RepositoryID    uuid.UUID        `json:"repository_id" gorm:"type:uuid;not null;index"`
+	Direction       MirrorDirection  `json:"direction" gorm:"size:10;not null"`
+	RemoteURL       string           `json:"remote_url" gorm:"size:1024;not null"` // 不含凭据
+	HasCredentials  bool             `json:"has_credentials" gorm:"not null;default:false"`
+	Enabled         bool             `json:"enabled" gorm:"not null;default:true"`
+	IntervalMinutes int              `json:"interval_minutes" gorm:"not null;default:60"` // 仅拉取镜像使用
+	LastSyncStatus  MirrorSyncStatus `json:"last_sync_status" gorm:"size:20;not null;default:'pending'"`
+	LastSyncAt      *time.Time       `json:"last_sync_at"`
+	LastSuccessAt   *time.Time       `json:"last_success_at"`
+	LastError       *string          `json:"last_error" gorm:"type:text"`
+	NextSyncAt      *time.Time       `json:"next_sync_at" gorm:"index"`
+	CreatedBy       uuid.UUID        `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt       time.Time        `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (RepositoryMirror) TableName() string {
+	return "repository_mirrors"
+}
+
+// MirrorCredentials 远程仓库HTTP凭据，密码可为访问令牌
+type MirrorCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password" binding:"required"`
+}
+
+// AuthorizationHeader 生成HTTP Basic认证头
+func (c *MirrorCredentials) AuthorizationHeader() string {
+	username := c.Username
+	if username == "" {
+		username = "git"
+	}
+	token := base64.StdEncoding.EncodeToString([]byte(username + ":" + c.Password))
+	return "Authorization: Basic " + token
+}
+
+// ToSecret 转换为Vault秘钥数据
+func (c *MirrorCredentials) ToSecret() map[string]interface{} {
+	return map[string]interface{}{
+		"username": c.Username,
+		"password": c.Password,
+	}
+}
+
+// MirrorCredentialsFromSecret 从Vault秘钥数据读取凭据
+func MirrorCredentialsFromSecret(data map[string]interface{}) (*MirrorCredentials, error) {
+	password, _ := data["password"].(string)
+	if password == "" {
+		return nil, fmt.Errorf("credential secret has no password")
+	}
+	username, _ := data["username"].(string)
+	return &MirrorCredentials{Username: username, Password: password}, nil
+}
+
+// ImportRepositoryRequest 从外部Git远程导入仓库请求
+type ImportRepositoryRequest struct {
+	ProjectID             string               `json:"project_id" binding:"required,uuid"`
+	Name                  string               `json:"name" binding:"required,min=1,max=255"`
+	Description           *string              `json:"description" validate:"omitempty,max=2000"`
+	Visibility            RepositoryVisibility `json:"visibility" binding:"required,oneof=public private internal"`
+	SourceURL             string               `json:"source_url" binding:"required"`
+	Credentials           *MirrorCredentials   `json:"credentials"`
+	Mirror                bool                 `json:"mirror"`                  // 导入后保留为拉取镜像
+	MirrorIntervalMinutes int                  `json:"mirror_interval_minutes"` // 为0时使用默认间隔
+}
+
+// ImportRepositoryResponse 导入仓库响应
+type ImportRepositoryResponse struct {
+	Repository *Repository       `json:"repository"`
+	Import     *RepositoryImport `json:"import"`
+}
+
+// CreateMirrorRequest 创建镜像请求
+type CreateMirrorRequest struct {
+	Direction       MirrorDirection    `json:"direction" binding:"required,oneof=pull push"`
+	RemoteURL       string             `json:"remote_url" binding:"required"`
+	Credentials     *MirrorCredentials `json:"credentials"`
+	IntervalMinutes int                `json:"interval_minutes"` // 仅拉取镜像使用，为0时使用默认间隔
+}
+
+// UpdateMirrorRequest 更新镜像请求
+type UpdateMirrorRequest struct {
+	Enabled         *bool              `json:"enabled"`
+	IntervalMinutes *int               `json:"interval_minutes"`
+	Credentials     *MirrorCredentials `json:"credentials"` // 替换已保存的凭据
+}
+
+// NormalizeMirrorInterval 校验同步间隔，0表示默认值
+func NormalizeMirrorInterval(minutes int) (int, error) {
+	if minutes == 0 {
+		return DefaultMirrorIntervalMinutes, nil
+	}
+	if minutes < MinMirrorIntervalMinutes || minutes > MaxMirrorIntervalMinutes {
+		return 0, fmt.Errorf("%w: must be between %d and %d minutes", ErrInvalidMirrorInterval, MinMirrorIntervalMinutes, MaxMirrorIntervalMinutes)
+	}
+	return minutes, nil
+}
+
+// IsDeniedRemoteIP 判断远程地址是否指向内部网络：私有网段（10/8、172.16/12、192.168/16、fc00::/7）、
+// 回环、链路本地、未指定和组播地址均不允许作为导入和镜像的远程
+func IsDeniedRemoteIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast()
+}
+
+// ParseRemoteURL 校验外部远程地址，返回去除凭据后的地址及地址中携带的凭据
+//
+// 只允许http(s)：file、ssh、ext等传输方式可读取服务器本地文件或执行命令；
+// 同时拒绝内部网络地址，避免通过导入访问本机、内网服务或云元数据服务。
+// 域名解析后的地址在连接时另行校验（见service.runRemoteGit）。
+func ParseRemoteURL(raw string) (string, *MirrorCredentials, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrInvalidRemoteURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", nil, fmt.Errorf("%w: only http and https are supported", ErrInvalidRemoteURL)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return "", nil, fmt.Errorf("%w: missing host", ErrInvalidRemoteURL)
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return "", nil, fmt.Errorf("%w: local addresses are not allowed", ErrInvalidRemoteURL)
+	}
+	if ip := net.ParseIP(host); ip != nil && IsDeniedRemoteIP(ip) {
+		return "", nil, fmt.Errorf("%w: local addresses are not allowed", ErrInvalidRemoteURL)
+	}
+
+	var creds *MirrorCredentials
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok && password != "" {
+			creds = &MirrorCredentials{Username: u.User.Username(), Password: password}
+		} else if u.User.Username() != "" {
+			// 只有用户名时视为访问令牌
+			creds = &MirrorCredentials{Password: u.User.Username()}
+		}
+		u.User = nil
+	}
+	u.Fragment = ""
+	u.RawQuery = ""
+
+	return u.String(), creds, nil
+}
+
+// gitProgressPattern 匹配 "Receiving objects:  45% (450/1000)" 形式的进度行
+var gitProgressPattern = regexp.MustCompile(`^(?:remote: )?([A-Za-z ]+):\s+(\d{1,3})%`)
+
+// ParseGitProgress 解析git --progress输出的一行，返回阶段与百分比
+func ParseGitProgress(line string) (string, int, bool) {
+	m := gitProgressPattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", 0, false
+	}
+	percent, err := strconv.Atoi(m[2])
+	if err != nil || percent > 100 {
+		return "", 0, false
+	}
+	return strings.TrimSpace(m[1]), percent, true
+}
+
+// ImportProgress 将阶段进度折算为总体进度
+// 远程打包占0-10%，接收对象占10-80%，解析差异占80-95%，更新引用和分支记录占最后5%
+func ImportProgress(phase string, percent int) int {
+	switch phase {
+	case "Counting objects", "Compressing objects", "Enumerating objects":
+		return percent / 10
+	case "Receiving objects":
+		return 10 + percent*70/100
+	case "Resolving deltas":
+		return 80 + percent*15/100
+	default:
+		return -1
+	}
+}
+
+// ParseSymrefHead 解析 git ls-remote --symref <url> HEAD 输出中的默认分支
+func ParseSymrefHead(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		ref, target, ok := strings.Cut(strings.TrimSpace(line), "\t")
+		if !ok || target != "HEAD" {
+			continue
+		}
+		if branch, ok := strings.CutPrefix(ref, "ref: refs/heads/"); ok {
+			return branch
+		}
+	}
+	return ""
+}
@@ -0,0 +1,428 @@
+package models
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 推送策略错误
+var (
+	ErrInvalidPushPolicy      = errors.New("invalid push policy")
+	ErrPushPolicyNotFound     = errors.New("push policy not found")
+	ErrInvalidPushPolicyScope = errors.New("invalid push policy scope")
+	ErrInvalidPreReceive      = errors.New("invalid pre-receive input")
+	ErrProtectedRefUpdate     = errors.New("protected ref cannot be deleted or force-updated")
+)
+
+// PushPolicyScope 推送策略作用范围
+type PushPolicyScope string
+
+const (
+	PushPolicyScopeProject    PushPolicyScope = "project"
+	PushPolicyScopeRepository PushPolicyScope = "repository"
+)
+
+// 推送策略规则名称，用于拒绝信息
+const (
+	PushRuleReadOnly      = "read-only"
+	PushRuleMaxFileSize   = "max-file-size"
+	PushRuleForbiddenPath = "forbidden-path"
+	PushRuleForbiddenExt  = "forbidden-extension"
+	PushRuleCommitMessage = "commit-message"
+	PushRuleAuthorEmail   = "author-email"
+	PushRuleSecret        = "secret"
+	PushRuleProtectedRef  = "protected-ref"
+	PushRuleSignedCommit  = "signed-commit"
+)
+
+// ZeroSHA 引用创建/删除时pre-receive输入中的空对象ID（SHA-1仓库）
+const ZeroSHA = "0000000000000000000000000000000000000000"
+
+// PushPolicy 推送策略，项目策略作用于项目下全部仓库，仓库策略在其基础上收紧
+type PushPolicy struct {
+	ID                       uuid.UUID       `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v7()"`
+	ScopeType                PushPolicyScope `json:"scope_type" gorm:"size:20;not null;uniqueIndex:idx_push_policy_scope"`
+	ScopeID                  uuid.UUID       `json:"scope_id" gorm:"type:uuid;not null;uniqueIndex:idx_push_policy_scope"`
+	Enabled                  bool            `json:"enabled" gorm:"not null;default:true"`
+	MaxFileSize              int64           `json:"max_file_size" gorm:"not null;default:0"`                   // 单个文件大小上限（字节），0表示不限制
+	ForbiddenPaths           []string        `json:"forbidden_paths" gorm:"type:jsonb;serializer:json"`         // 禁止的路径模式
+	ForbiddenExtensions      []string        `json:"forbidden_extensions" gorm:"type:jsonb;serializer:json"`    // 禁止的扩展名，如 .exe
+	CommitMessagePattern     string          `json:"commit_message_pattern" gorm:"size:512"`                    // 提交信息必须匹配的正则
+	AllowedEmailDomains      []string        `json:"allowed_email_domains" gorm:"type:jsonb;serializer:json"`   // 允许的作者邮箱域名，含子域名
+	SecretScanning           bool            `json:"secret_scanning" gorm:"not null;default:false"`             // 扫描新增行中的密钥
+	SecretPatterns           []string        `json:"secret_patterns" gorm:"type:jsonb;serializer:json"`         // 内置规则之外的密钥正则
+	ProtectedRefs            []string        `json:"protected_refs" gorm:"type:jsonb;serializer:json"`          // 禁止删除和强制推送的引用模式
+	RequireSignedCommits     bool            `json:"require_signed_commits" gorm:"not null;default:false"`      // 受保护分支只接受已验证签名的提交
+	RequireCodeOwnerApproval bool            `json:"require_code_owner_approval" gorm:"not null;default:false"` // 向受保护分支发起的PR须经代码所有者批准
+	UpdatedBy                uuid.UUID       `json:"updated_by" gorm:"type:uuid;not null"`
+	CreatedAt                time.Time       `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt                time.Time       `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (PushPolicy) TableName() string {
+	return "push_policies"
+}
+
+// PushPolicyRequest 设置推送策略请求，整体替换已有策略
+type PushPolicyRequest struct {
+	Enabled                  *bool    `json:"enabled"`
+	MaxFileSize              int64    `json:"max_file_size" binding:"min=0"`
+	ForbiddenPaths           []string `json:"forbidden_paths"`
+	ForbiddenExtensions      []string `json:"forbidden_extensions"`
+	CommitMessagePattern     string   `json:"commit_message_pattern"`
+	AllowedEmailDomains      []string `json:"allowed_email_domains"`
+	SecretScanning           bool     `json:"secret_scanning"`
+	SecretPatterns           []string `json:"secret_patterns"`
+	ProtectedRefs            []string `json:"protected_refs"`
+	RequireSignedCommits     bool     `json:"require_signed_commits"`
+	RequireCodeOwnerApproval bool     `json:"require_code_owner_approval"`
+}
+
+// Normalize 校验并规范化策略请求
+func (r *PushPolicyRequest) Normalize() error {
+	if r.MaxFileSize < 0 {
+		return fmt.Errorf("%w: max_file_size must not be negative", ErrInvalidPushPolicy)
+	}
+
+	var err error
+	if r.ForbiddenPaths, err = normalizePatterns(r.ForbiddenPaths, "forbidden_paths", nil); err != nil {
+		return err
+	}
+	if r.ProtectedRefs, err = normalizePatterns(r.ProtectedRefs, "protected_refs", nil); err != nil {
+		return err
+	}
+	if r.ForbiddenExtensions, err = normalizePatterns(r.ForbiddenExtensions, "forbidden_extensions", normalizeExtension); err != nil {
+		return err
+	}
+	if r.AllowedEmailDomains, err = normalizePatterns(r.AllowedEmailDomains, "allowed_email_domains", normalizeDomain); err != nil {
+		return err
+	}
+
+	r.CommitMessagePattern = strings.TrimSpace(r.CommitMessagePattern)
+	if r.CommitMessagePattern != "" {
+		if _, err := regexp.Compile(r.CommitMessagePattern); err != nil {
+			return fmt.Errorf("%w: commit_message_pattern: %v", ErrInvalidPushPolicy, err)
+		}
+	}
+	for _, pattern := range r.SecretPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%w: secret_patterns: %v", ErrInvalidPushPolicy, err)
+		}
+	}
+	return nil
+}
+
+// normalizePatterns 去除空白和空项，校验glob语法
+func normalizePatterns(values []string, field string, normalize func(string) string) ([]string, error) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if normalize != nil {
+			value = normalize(value)
+		}
+		if value == "" {
+			continue
+		}
+		if _, err := path.Match(value, ""); err != nil {
+			return nil, fmt.Errorf("%w: %s: invalid pattern %q", ErrInvalidPushPolicy, field, value)
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// normalizeExtension 扩展名统一为小写并带前导点
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimPrefix(ext, "*"))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// normalizeDomain 域名统一为小写并去掉前导@
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimPrefix(domain, "@"))
+}
+
+// EffectivePushPolicy 仓库生效的推送策略，由项目策略和仓库策略合并而成
+type EffectivePushPolicy struct {
+	MaxFileSize              int64    `json:"max_file_size"`
+	ForbiddenPaths           []string `json:"forbidden_paths"`
+	ForbiddenExtensions      []string `json:"forbidden_extensions"`
+	CommitMessagePatterns    []string `json:"commit_message_patterns"` // 提交信息须全部匹配
+	AllowedEmailDomains      []string `json:"allowed_email_domains"`
+	SecretScanning           bool     `json:"secret_scanning"`
+	SecretPatterns           []string `json:"secret_patterns"`
+	ProtectedRefs            []string `json:"protected_refs"`
+	RequireSignedCommits     bool     `json:"require_signed_commits"`      // 作用于受保护引用和标记为受保护的分支
+	RequireCodeOwnerApproval bool     `json:"require_code_owner_approval"` // 作用于目标分支受保护的PR
+}
+
+// MergePushPolicies 合并项目和仓库策略，取二者中更严格的规则
+// 文件大小取较小的上限，列表取并集，提交信息须同时匹配两个正则；
+// 允许的邮箱域名由仓库策略覆盖项目策略。未启用的策略忽略
+func MergePushPolicies(policies ...*PushPolicy) *EffectivePushPolicy {
+	effective := &EffectivePushPolicy{}
+	for _, policy := range policies {
+		if policy == nil || !policy.Enabled {
+			continue
+		}
+		if policy.MaxFileSize > 0 && (effective.MaxFileSize == 0 || policy.MaxFileSize < effective.MaxFileSize) {
+			effective.MaxFileSize = policy.MaxFileSize
+		}
+		effective.ForbiddenPaths = appendUnique(effective.ForbiddenPaths, policy.ForbiddenPaths...)
+		effective.ForbiddenExtensions = appendUnique(effective.ForbiddenExtensions, policy.ForbiddenExtensions...)
+		effective.ProtectedRefs = appendUnique(effective.ProtectedRefs, policy.ProtectedRefs...)
+		effective.SecretPatterns = appendUnique(effective.SecretPatterns, policy.SecretPatterns...)
+		if policy.CommitMessagePattern != "" {
+			effective.CommitMessagePatterns = appendUnique(effective.CommitMessagePatterns, policy.CommitMessagePattern)
+		}
+		if len(policy.AllowedEmailDomains) > 0 {
+			effective.AllowedEmailDomains = policy.AllowedEmailDomains
+		}
+		effective.SecretScanning = effective.SecretScanning || policy.SecretScanning
+		effective.RequireSignedCommits = effective.RequireSignedCommits || policy.RequireSignedCommits
+		effective.RequireCodeOwnerApproval = effective.RequireCodeOwnerApproval || policy.RequireCodeOwnerApproval
+	}
+	return effective
+}
+
+// NeedsContentScan 判断是否需要读取提交内容
+func (p *EffectivePushPolicy) NeedsContentScan() bool {
+	return p.MaxFileSize > 0 || len(p.ForbiddenPaths) > 0 || len(p.ForbiddenExtensions) > 0 ||
+		len(p.CommitMessagePatterns) > 0 || len(p.AllowedEmailDomains) > 0 || p.SecretScanning
+}
+
+// appendUnique 追加切片中尚不存在的值
+func appendUnique(values []string, items ...string) []string {
+	for _, item := range items {
+		if !containsString(values, item) {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// MatchPathPattern 判断文件路径是否匹配禁止路径模式
+// 不含"/"的模式匹配任一层级的文件或目录名（如 .env、*.pem）；
+// 以"/"结尾的模式匹配目录及其下全部文件；其他模式匹配完整路径
+func MatchPathPattern(pattern, filePath string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+		segments := strings.Split(filePath, "/")
+		for i := 1; i < len(segments); i++ {
+			if matchGlob(dir, strings.Join(segments[:i], "/")) {
+				return true
+			}
+			if !strings.Contains(dir, "/") && matchGlob(dir, segments[i-1]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !strings.Contains(pattern, "/") {
+		for _, segment := range strings.Split(filePath, "/") {
+			if matchGlob(pattern, segment) {
+				return true
+			}
+		}
+		return false
+	}
+	return matchGlob(strings.TrimPrefix(pattern, "/"), filePath)
+}
+
+// HasForbiddenExtension 判断文件是否使用禁止的扩展名，返回匹配的扩展名
+func HasForbiddenExtension(extensions []string, filePath string) (string, bool) {
+	name := strings.ToLower(path.Base(filePath))
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) && len(name) > len(ext) {
+			return ext, true
+		}
+	}
+	return "", false
+}
+
+// MatchRefPattern 判断引用是否匹配模式，不以 refs/ 开头的模式视为分支名
+func MatchRefPattern(pattern, ref string) bool {
+	if !strings.HasPrefix(pattern, "refs/") {
+		pattern = "refs/heads/" + pattern
+	}
+	return matchGlob(pattern, ref)
+}
+
+// EmailDomainAllowed 判断邮箱域名是否在允许列表中，允许子域名
+func EmailDomainAllowed(domains []string, email string) bool {
+	_, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !ok || domain == "" {
+		return false
+	}
+	for _, allowed := range domains {
+		if domain == allowed || strings.HasSuffix(domain, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob path.Match的包装，非法模式视为不匹配
+func matchGlob(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
+
+// SecretRule 密钥扫描规则
+type SecretRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// BuiltinSecretRules 内置密钥扫描规则
+var BuiltinSecretRules = []SecretRule{
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"private key", regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"GitLab token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"Stripe live key", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{24,}\b`)},
+	{"hard-coded credential", regexp.MustCompile(`(?i)\b(password|passwd|secret|api[_-]?key|access[_-]?token)\b\s*[:=]\s*["'][^"'\s]{8,}["']`)},
+}
+
+// RefUpdate pre-receive输入中的一条引用更新
+type RefUpdate struct {
+	OldSHA string `json:"old_sha"`
+	NewSHA string `json:"new_sha"`
+	Ref    string `json:"ref"`
+}
+
+// IsCreate 是否为新建引用
+func (u RefUpdate) IsCreate() bool {
+	return isZeroSHA(u.OldSHA)
+}
+
+// IsDelete 是否为删除引用
+func (u RefUpdate) IsDelete() bool {
+	return isZeroSHA(u.NewSHA)
+}
+
+// isZeroSHA 判断是否为全零对象ID，兼容SHA-256仓库
+func isZeroSHA(sha string) bool {
+	return sha != "" && strings.Trim(sha, "0") == ""
+}
+
+// GitHookToken 仓库钩子回调网关时携带的令牌，由钩子密钥和仓库ID计算，
+// 令牌写在仓库的钩子脚本中，泄露只影响该仓库的回调
+func GitHookToken(secret string, repositoryID uuid.UUID) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(repositoryID.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyGitHookToken 校验钩子回调令牌，未配置钩子密钥时一律拒绝
+func VerifyGitHookToken(secret string, repositoryID uuid.UUID, token string) bool {
+	if secret == "" || token == "" {
+		return false
+	}
+	return hmac.Equal([]byte(GitHookToken(secret, repositoryID)), []byte(token))
+}
+
+// ParsePreReceiveInput 解析pre-receive钩子的标准输入，每行为 "<old> <new> <ref>"
+func ParsePreReceiveInput(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !shaPattern.MatchString(fields[0]) || !shaPattern.MatchString(fields[1]) ||
+			!strings.HasPrefix(fields[2], "refs/") {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPreReceive, line)
+		}
+		updates = append(updates, RefUpdate{OldSHA: fields[0], NewSHA: fields[1], Ref: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPreReceive, err)
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("%w: no ref updates", ErrInvalidPreReceive)
+	}
+	return updates, nil
+}
+
+// shaPattern 完整的十六进制对象ID（SHA-1或SHA-256）
+var shaPattern = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// PreReceiveRequest 推送策略检查请求
+type PreReceiveRequest struct {
+	Updates        []RefUpdate
+	QuarantinePath string // 推送对象的隔离目录，检查期间对象尚未进入仓库
+}
+
+// PushPolicyViolation 违反推送策略的一项
+type PushPolicyViolation struct {
+	Rule    string `json:"rule"`
+	Ref     string `json:"ref,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// String 格式化为推送输出中的一行
+func (v PushPolicyViolation) String() string {
+	var location []string
+	if v.Ref != "" {
+		location = append(location, v.Ref)
+	}
+	if v.Commit != "" {
+		location = append(location, "commit "+shortSHA(v.Commit))
+	}
+	if v.Path != "" {
+		location = append(location, v.Path)
+	}
+	if len(location) == 0 {
+		return fmt.Sprintf("[%s] %s", v.Rule, v.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s", v.Rule, strings.Join(location, ", "), v.Message)
+}
+
+// PreReceiveResult 推送策略检查结果
+type PreReceiveResult struct {
+	Allowed    bool                  `json:"allowed"`
+	Violations []PushPolicyViolation `json:"violations"`
+}
+
+// Message 生成显示在 git push 输出中的拒绝信息
+func (r *PreReceiveResult) Message() string {
+	if r.Allowed {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Push rejected by repository push policy:\n")
+	for _, v := range r.Violations {
+		b.WriteString("  " + v.String() + "\n")
+	}
+	return b.String()
+}
+
+// shortSHA 截取7位短SHA
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 引用审计与恢复错误
+var (
+	ErrInvalidRefAuditFilter = errors.New("invalid ref audit filter")
+	ErrNothingToRestore      = errors.New("no deleted or force-pushed revision to restore")
+	ErrRestoreTargetMissing  = errors.New("restore target commit no longer exists")
+	ErrRestoreTargetUnknown  = errors.New("restore target is not a recorded revision of the branch")
+)
+
+// KeepAroundRefPrefix 被删除或强制推送覆盖的提交在保留期内挂在该前缀下，避免被gc清理。
+// 该前缀对克隆和推送隐藏（transfer.hideRefs）
+const KeepAroundRefPrefix = "refs/keep-around/"
+
+// KeepAroundRef 保留提交的引用名称
+func KeepAroundRef(sha string) string {
+	return KeepAroundRefPrefix + sha
+}
+
+// RefType 引用类型
+type RefType string
+
+const (
+	RefTypeBranch RefType = "branch"
+	RefTypeTag    RefType = "tag"
+	RefTypeOther  RefType = "other"
+)
+
+// RefTypeOf 根据完整引用名判断类型
+func RefTypeOf(ref string) RefType {
+	switch {
+	case strings.HasPrefix(ref, "refs/heads/"):
+		return RefTypeBranch
+	case strings.HasPrefix(ref, "refs/tags/"):
+		return RefTypeTag
+	}
+	return RefTypeOther
+}
+
+// RefTransport 引用更新的来源
+type RefTransport string
+
+const (
+	RefTransportSSH     RefTransport = "ssh"     // git push over SSH
+	RefTransportHTTP    RefTransport = "http"    // git push over HTTP(S)
+	RefTransportPush    RefTransport = "push"    // 推送前端未标明协议
+	RefTransportAPI     RefTransport = "api"     // 网关API操作：创建分支、提交、合并、标签等
+	RefTransportMirror  RefTransport = "mirror"  // 拉取镜像同步
+	RefTransportRestore RefTransport = "restore" // 恢复分支操作
+)
+
+// ParsePushTransport 解析推送钩子上报的协议，未知值按 push 记录
+func ParsePushTransport(value string) RefTransport {
+	switch t := RefTransport(strings.ToLower(strings.TrimSpace(value))); t {
+	case RefTransportSSH, RefTransportHTTP:
+		return t
+	case "https":
+		return RefTransportHTTP
+	}
+	return RefTransportPush
+}
+
+// RefActor 引用更新的发起者
+type RefActor struct {
+	UserID *uuid.UUID
+	Name   string // 推送前端上报的用户名，API操作为空
+}
+
+// RefAuditEntry 一次引用更新的审计记录
+type RefAuditEntry struct {
+	ID           uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v7()"`
+	RepositoryID uuid.UUID    `json:"repository_id" gorm:"type:uuid;not null;index"`
+	Ref          string       `json:"ref" gorm:"size:512;not null"`
+	RefType      RefType      `json:"ref_type" gorm:"size:20;not null"`
+	OldSHA       string       `json:"old_sha" gorm:"size:64;not null"` // 新建引用时为全零
+	NewSHA       string       `json:"new_sha" gorm:"size:64;not null"` // 删除引用时为全零
+	ActorID      *uuid.UUID   `json:"actor_id" gorm:"type:uuid"`
+	ActorName    string       `json:"actor_name,omitempty" gorm:"size:255;not null;default:''"`
+	Transport    RefTransport `json:"transport" gorm:"size:20;not null"`
+	Forced       bool         `json:"forced" gorm:"not null;default:false"` // 非快进更新，旧提交不再可达
+	KeptUntil    *time.Time   `json:"kept_until"`                           // 旧提交保留到该时间，之后可被gc清理
+	Kept         bool         `json:"kept" gorm:"not null;default:false"`   // 保留引用是否仍存在
+	CreatedAt    time.Time    `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (RefAuditEntry) TableName() string {
+	return "ref_audit_log"
+}
+
+// IsCreate 是否为新建引用
+func (e *RefAuditEntry) IsCreate() bool {
+	return isZeroSHA(e.OldSHA)
+}
+
+// IsDelete 是否为删除引用
+func (e *RefAuditEntry) IsDelete() bool {
+	return isZeroSHA(e.NewSHA)
+}
+
+// Rewritten 是否丢弃了旧提交（删除或强制推送），可以从旧提交恢复
+func (e *RefAuditEntry) Rewritten() bool {
+	return !e.IsCreate() && (e.IsDelete() || e.Forced)
+}
+
+// RefAuditFilter 引用审计查询条件
+type RefAuditFilter struct {
+	Ref       string       `form:"ref"` // 分支或标签名称，也可使用完整引用名
+	RefType   RefType      `form:"ref_type"`
+	ActorID   *uuid.UUID   `form:"-"`
+	Transport RefTransport `form:"transport"`
+	Forced    *bool        `form:"forced"`
+	Since     *time.Time   `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     *time.Time   `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page      int          `form:"page"`
+	PageSize  int          `form:"page_size"`
+}
+
+// RefAuditMaxPageSize 审计记录每页最大数量
+const RefAuditMaxPageSize = 100
+
+// Normalize 校验并规范化查询条件
+func (f *RefAuditFilter) Normalize() error {
+	f.Ref = strings.TrimSpace(f.Ref)
+	if f.Ref != "" {
+		if err := ValidateRefName(f.Ref); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRefAuditFilter, err)
+		}
+	}
+	switch f.RefType {
+	case "", RefTypeBranch, RefTypeTag, RefTypeOther:
+	default:
+		return fmt.Errorf("%w: ref_type %q", ErrInvalidRefAuditFilter, f.RefType)
+	}
+	switch f.Transport {
+	case "", RefTransportSSH, RefTransportHTTP, RefTransportPush, RefTransportAPI, RefTransportMirror, RefTransportRestore:
+	default:
+		return fmt.Errorf("%w: transport %q", ErrInvalidRefAuditFilter, f.Transport)
+	}
+	if f.Since != nil && f.Until != nil && f.Until.Before(*f.Since) {
+		return fmt.Errorf("%w: until is before since", ErrInvalidRefAuditFilter)
+	}
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	if f.PageSize < 1 || f.PageSize > RefAuditMaxPageSize {
+		f.PageSize = 20
+	}
+	return nil
+}
+
+// RefCandidates 过滤条件中的名称可能对应的完整引用名
+func (f *RefAuditFilter) RefCandidates() []string {
+	if f.Ref == "" {
+		return nil
+	}
+	if strings.HasPrefix(f.Ref, "refs/") {
+		return []string{f.Ref}
+	}
+	switch f.RefType {
+	case RefTypeBranch:
+		return []string{"refs/heads/" + f.Ref}
+	case RefTypeTag:
+		return []string{"refs/tags/" + f.Ref}
+	}
+	return []string{"refs/heads/" + f.Ref, "refs/tags/" + f.Ref}
+}
+
+// RefAuditListResponse 引用审计列表响应
+type RefAuditListResponse struct {
+	Entries  []RefAuditEntry `json:"entries"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+// RestoreBranchRequest 恢复分支请求
+type RestoreBranchRequest struct {
+	Branch string `json:"branch" binding:"required"`
+	SHA    string `json:"sha"` // 为空时恢复到该分支最近一次删除或强制推送之前的提交
+}
+
+// Normalize 校验恢复请求
+func (r *RestoreBranchRequest) Normalize() error {
+	r.Branch = strings.TrimPrefix(strings.TrimSpace(r.Branch), "refs/heads/")
+	if err := ValidateRefName(r.Branch); err != nil {
+		return err
+	}
+	r.SHA = strings.ToLower(strings.TrimSpace(r.SHA))
+	if r.SHA != "" && !shaPattern.MatchString(r.SHA) {
+		return fmt.Errorf("%w: sha must be a full commit id", ErrInvalidRef)
+	}
+	return nil
+}
+
+// RestoreBranchResult 恢复分支结果
+type RestoreBranchResult struct {
+	Branch   string         `json:"branch"`
+	SHA      string         `json:"sha"`
+	Previous string         `json:"previous,omitempty"` // 恢复前分支指向的提交，分支已删除时为空
+	Created  bool           `json:"created"`            // 分支原本不存在，重新创建
+	Entry    *RefAuditEntry `json:"entry,omitempty"`    // 恢复操作本身的审计记录，分支未变化时为空
+}
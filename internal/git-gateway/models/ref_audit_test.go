@@ -0,0 +1,91 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePushTransport(t *testing.T) {
+	assert.Equal(t, RefTransportSSH, ParsePushTransport("ssh"))
+	assert.Equal(t, RefTransportHTTP, ParsePushTransport(" HTTP "))
+	assert.Equal(t, RefTransportHTTP, ParsePushTransport("https"))
+	assert.Equal(t, RefTransportPush, ParsePushTransport(""))
+	// 钩子不能伪造网关内部来源
+	assert.Equal(t, RefTransportPush, ParsePushTransport("restore"))
+}
+
+func TestRefAuditEntryRewritten(t *testing.T) {
+	zero := strings.Repeat("0", 40)
+	a, b := strings.Repeat("a", 40), strings.Repeat("b", 40)
+
+	tests := []struct {
+		name  string
+		entry RefAuditEntry
+		want  bool
+	}{
+		{"新建分支", RefAuditEntry{OldSHA: zero, NewSHA: a}, false},
+		{"快进更新", RefAuditEntry{OldSHA: a, NewSHA: b}, false},
+		{"强制推送", RefAuditEntry{OldSHA: a, NewSHA: b, Forced: true}, true},
+		{"删除分支", RefAuditEntry{OldSHA: a, NewSHA: zero}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.entry.Rewritten())
+		})
+	}
+}
+
+func TestRefAuditFilterNormalize(t *testing.T) {
+	since := time.Now()
+	until := since.Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		filter  RefAuditFilter
+		wantErr bool
+	}{
+		{"空条件", RefAuditFilter{}, false},
+		{"分支名", RefAuditFilter{Ref: "feature/login", RefType: RefTypeBranch}, false},
+		{"非法引用名", RefAuditFilter{Ref: "main..dev"}, true},
+		{"未知类型", RefAuditFilter{RefType: "note"}, true},
+		{"未知来源", RefAuditFilter{Transport: "ftp"}, true},
+		{"时间范围颠倒", RefAuditFilter{Since: &since, Until: &until}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Normalize()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRefAuditFilter)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, tt.filter.Page)
+			assert.Equal(t, 20, tt.filter.PageSize)
+		})
+	}
+}
+
+func TestRefAuditFilterRefCandidates(t *testing.T) {
+	assert.Nil(t, (&RefAuditFilter{}).RefCandidates())
+	assert.Equal(t, []string{"refs/heads/main", "refs/tags/main"}, (&RefAuditFilter{Ref: "main"}).RefCandidates())
+	assert.Equal(t, []string{"refs/tags/v1.0"}, (&RefAuditFilter{Ref: "v1.0", RefType: RefTypeTag}).RefCandidates())
+	assert.Equal(t, []string{"refs/heads/main"}, (&RefAuditFilter{Ref: "refs/heads/main", RefType: RefTypeTag}).RefCandidates())
+}
+
+func TestRestoreBranchRequestNormalize(t *testing.T) {
+	req := RestoreBranchRequest{Branch: "refs/heads/main", SHA: strings.Repeat("AB", 20)}
+	assert.NoError(t, req.Normalize())
+	assert.Equal(t, "main", req.Branch)
+	assert.Equal(t, strings.Repeat("ab", 20), req.SHA)
+
+	req = RestoreBranchRequest{Branch: "main", SHA: "abc123"}
+	assert.ErrorIs(t, req.Normalize(), ErrInvalidRef)
+
+	req = RestoreBranchRequest{Branch: "-main"}
+	assert.ErrorIs(t, req.Normalize(), ErrInvalidRef)
+}
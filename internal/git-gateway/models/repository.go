@@ -42,11 +42,24 @@ type Repository struct {
 	SSHURL   string `json:"ssh_url" gorm:"size:512"`           // SSH URL
 
 	// 统计信息
-	Size        int64 `json:"size" gorm:"default:0"`         // 仓库大小（字节）
+	Size        int64 `json:"size" gorm:"default:0"`         // 仓库大小（字节），含LFS对象
+	LFSSize     int64 `json:"lfs_size" gorm:"default:0"`     // 其中LFS对象大小（字节）
 	CommitCount int64 `json:"commit_count" gorm:"default:0"` // 提交数量
 	BranchCount int32 `json:"branch_count" gorm:"default:0"` // 分支数量
 	TagCount    int32 `json:"tag_count" gorm:"default:0"`    // 标签数量
 
+	// Fork信息
+	ForkedFromID  *uuid.UUID `json:"forked_from_id" gorm:"type:uuid;index"`        // 上游仓库，为空表示非fork
+	ForkCount     int32      `json:"fork_count" gorm:"default:0"`                  // 直接fork数量
+	ObjectsShared bool       `json:"objects_shared" gorm:"not null;default:false"` // 是否通过alternates共享上游对象
+
+	// 镜像信息
+	IsMirror bool `json:"is_mirror" gorm:"not null;default:false"` // 拉取镜像仓库，只读
+
+	// 模板信息
+	IsTemplate    bool       `json:"is_template" gorm:"not null;default:false"` // 可作为新仓库的模板
+	TemplatedFrom *uuid.UUID `json:"templated_from" gorm:"type:uuid"`           // 创建时使用的模板仓库
+
 	// 时间戳
 	CreatedAt    time.Time  `json:"created_at" gorm:"not null;default:now()"`
 	UpdatedAt    time.Time  `json:"updated_at" gorm:"not null;default:now()"`
@@ -54,11 +67,12 @@ type Repository struct {
 	LastPushedAt *time.Time `json:"last_pushed_at"`
 
 	// 关联关系
-	Project  *Project  `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
-	Branches []Branch  `json:"branches,omitempty" gorm:"foreignKey:RepositoryID"`
-	Commits  []Commit  `json:"commits,omitempty" gorm:"foreignKey:RepositoryID"`
-	Tags     []Tag     `json:"tags,omitempty" gorm:"foreignKey:RepositoryID"`
-	Webhooks []Webhook `json:"webhooks,omitempty" gorm:"foreignKey:RepositoryID"`
+	Project    *Project    `json:"project,omitempty" gorm:"foreignKey:ProjectID"`
+	ForkedFrom *Repository `json:"forked_from,omitempty" gorm:"foreignKey:ForkedFromID"`
+	Branches   []Branch    `json:"branches,omitempty" gorm:"foreignKey:RepositoryID"`
+	Commits    []Commit    `json:"commits,omitempty" gorm:"foreignKey:RepositoryID"`
+	Tags       []Tag       `json:"tags,omitempty" gorm:"foreignKey:RepositoryID"`
+	Webhooks   []Webhook   `json:"webhooks,omitempty" gorm:"foreignKey:RepositoryID"`
 }
 
 // Branch 分支模型
@@ -98,6 +112,9 @@ type Commit struct {
 	DeletedLines int32 `json:"deleted_lines" gorm:"default:0"`
 	ChangedFiles int32 `json:"changed_files" gorm:"default:0"`
 
+	// 签名校验结果
+	Signature SignatureInfo `json:"signature" gorm:"embedded;embeddedPrefix:signature_"`
+
 	// 时间戳
 	CommittedAt time.Time `json:"committed_at" gorm:"not null"`
 	CreatedAt   time.Time `json:"created_at" gorm:"not null;default:now()"`
@@ -135,6 +152,12 @@ type Tag struct {
 	TaggedAt     time.Time `json:"tagged_at" gorm:"not null"`
 	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
 
+	// 签名校验结果，轻量标签没有签名
+	Signature SignatureInfo `json:"signature" gorm:"embedded;embeddedPrefix:signature_"`
+
+	// 源码归档下载地址，不存储
+	Archives []ArchiveLink `json:"archives,omitempty" gorm:"-"`
+
 	// 关联关系
 	Repository *Repository `json:"repository,omitempty" gorm:"foreignKey:RepositoryID"`
 	Commit     *Commit     `json:"commit,omitempty" gorm:"foreignKey:CommitSHA;references:SHA"`
@@ -151,6 +174,11 @@ type Webhook struct {
 	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
 	UpdatedAt    time.Time `json:"updated_at" gorm:"not null;default:now()"`
 
+	// 投递健康状态，连续失败过多时自动停用
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	DisabledReason      *string    `json:"disabled_reason" gorm:"type:text"`
+
 	// 关联关系
 	Repository *Repository `json:"repository,omitempty" gorm:"foreignKey:RepositoryID"`
 }
@@ -172,6 +200,11 @@ type CreateRepositoryRequest struct {
 	Visibility    RepositoryVisibility `json:"visibility" binding:"required,oneof=public private internal"`
 	DefaultBranch *string              `json:"default_branch" validate:"omitempty,min=1,max=255"`
 	InitReadme    bool                 `json:"init_readme"`
+
+	// 从模板创建，设置后忽略InitReadme
+	TemplateID         *string           `json:"template_id" binding:"omitempty,uuid"`
+	TemplateVariables  map[string]string `json:"template_variables"`
+	IncludeAllBranches *bool             `json:"include_all_branches"` // 为空时使用模板的设置
 }
 
 // UpdateRepositoryRequest 更新仓库请求
@@ -216,22 +249,35 @@ type CreateTagRequest struct {
 	Tagger    CommitAuthor `json:"tagger" binding:"required"`
 }
 
-// GitDiff Git差异信息
+// GitDiff Git差异信息，文件按页返回，统计覆盖全部文件
 type GitDiff struct {
 	FromSHA      string     `json:"from_sha"`
 	ToSHA        string     `json:"to_sha"`
 	Files        []DiffFile `json:"files"`
 	TotalAdded   int32      `json:"total_added"`
 	TotalDeleted int32      `json:"total_deleted"`
+	TotalFiles   int        `json:"total_files"`
+	Page         int        `json:"page"`
+	PageSize     int        `json:"page_size"`
+	HasMore      bool       `json:"has_more"`
 }
 
+// DiffFile 差异中的文件
 type DiffFile struct {
-	Path         string  `json:"path"`
-	OldPath      *string `json:"old_path"`
-	Status       string  `json:"status"`
-	AddedLines   int32   `json:"added_lines"`
-	DeletedLines int32   `json:"deleted_lines"`
-	Patch        string  `json:"patch"`
+	Path         string     `json:"path"`
+	OldPath      *string    `json:"old_path"`
+	Status       string     `json:"status"`               // A、M、D、R、C、T，与 git diff --name-status 一致
+	Similarity   int        `json:"similarity,omitempty"` // 重命名或复制的相似度（百分比）
+	OldMode      string     `json:"old_mode,omitempty"`
+	NewMode      string     `json:"new_mode,omitempty"`
+	OldBlobSHA   string     `json:"old_blob_sha,omitempty"`
+	NewBlobSHA   string     `json:"new_blob_sha,omitempty"`
+	AddedLines   int32      `json:"added_lines"`
+	DeletedLines int32      `json:"deleted_lines"`
+	Binary       bool       `json:"binary"`
+	Collapsed    bool       `json:"collapsed"` // 差异过大未返回内容，可通过 path 单独查询
+	Truncated    bool       `json:"truncated"` // 差异行超过 max_lines，只返回了前面部分
+	Hunks        []DiffHunk `json:"hunks,omitempty"`
 }
 
 // 设置表名
@@ -426,6 +472,22 @@ func (r *Repository) IsActive() bool {
 	return r.Status == RepositoryStatusActive
 }
 
+// IsArchived 检查仓库是否已归档
+func (r *Repository) IsArchived() bool {
+	return r.Status == RepositoryStatusArchived
+}
+
+// CheckWritable 检查仓库是否允许写入：镜像和已归档的仓库只读
+func (r *Repository) CheckWritable() error {
+	if r.IsMirror {
+		return ErrRepositoryReadOnly
+	}
+	if r.IsArchived() {
+		return ErrRepositoryArchived
+	}
+	return nil
+}
+
 // IsPublic 检查仓库是否公开
 func (r *Repository) IsPublic() bool {
 	return r.Visibility == RepositoryVisibilityPublic
@@ -526,6 +588,14 @@ type RepositoryListResponse struct {
 	PageSize     int          `json:"page_size"`
 }
 
+// PullRequestListResponse PR列表响应
+type PullRequestListResponse struct {
+	PullRequests []PullRequest `json:"pull_requests"`
+	Total        int64         `json:"total"`
+	Page         int           `json:"page"`
+	PageSize     int           `json:"page_size"`
+}
+
 // CommitListResponse 提交列表响应
 type CommitListResponse struct {
 	Commits  []Commit `json:"commits"`
@@ -554,8 +624,9 @@ type PullRequest struct {
 	Status       PullRequestStatus `json:"status" gorm:"size:20;not null;default:'open'"`
 
 	// 分支信息
-	SourceBranch string `json:"source_branch" gorm:"size:255;not null"`
-	TargetBranch string `json:"target_branch" gorm:"size:255;not null"`
+	SourceRepositoryID *uuid.UUID `json:"source_repository_id" gorm:"type:uuid;index"` // 来自fork时为fork仓库，同仓库PR为空
+	SourceBranch       string     `json:"source_branch" gorm:"size:255;not null"`
+	TargetBranch       string     `json:"target_branch" gorm:"size:255;not null"`
 
 	// 作者信息
 	AuthorID    uuid.UUID `json:"author_id" gorm:"type:uuid;not null"`
@@ -577,9 +648,10 @@ type PullRequest struct {
 	ClosedAt  *time.Time `json:"closed_at"`
 
 	// 关联关系
-	Repository *Repository `json:"repository,omitempty" gorm:"foreignKey:RepositoryID"`
-	Comments   []PRComment `json:"comments,omitempty" gorm:"foreignKey:PullRequestID"`
-	Reviews    []PRReview  `json:"reviews,omitempty" gorm:"foreignKey:PullRequestID"`
+	Repository       *Repository `json:"repository,omitempty" gorm:"foreignKey:RepositoryID"`
+	SourceRepository *Repository `json:"source_repository,omitempty" gorm:"foreignKey:SourceRepositoryID"`
+	Comments         []PRComment `json:"comments,omitempty" gorm:"foreignKey:PullRequestID"`
+	Reviews          []PRReview  `json:"reviews,omitempty" gorm:"foreignKey:PullRequestID"`
 }
 
 // PullRequestStatus 合并请求状态枚举
@@ -634,12 +706,13 @@ type PRReview struct {
 
 // CreatePullRequestRequest 创建PR请求
 type CreatePullRequestRequest struct {
-	Title        string  `json:"title" binding:"required,min=1,max=255"`
-	Description  *string `json:"description" validate:"omitempty,max=5000"`
-	SourceBranch string  `json:"source_branch" binding:"required,min=1,max=255"`
-	TargetBranch string  `json:"target_branch" binding:"required,min=1,max=255"`
-	AuthorName   string  `json:"author_name" binding:"required"`
-	AuthorEmail  string  `json:"author_email" binding:"required,email"`
+	Title              string  `json:"title" binding:"required,min=1,max=255"`
+	Description        *string `json:"description" validate:"omitempty,max=5000"`
+	SourceRepositoryID *string `json:"source_repository_id" binding:"omitempty,uuid"` // 从fork发起时为fork仓库ID
+	SourceBranch       string  `json:"source_branch" binding:"required,min=1,max=255"`
+	TargetBranch       string  `json:"target_branch" binding:"required,min=1,max=255"`
+	AuthorName         string  `json:"author_name" binding:"required"`
+	AuthorEmail        string  `json:"author_email" binding:"required,email"`
 }
 
 // UpdatePullRequestRequest 更新PR请求
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// SignatureStatus 提交或标签的签名校验状态
+type SignatureStatus string
+
+const (
+	SignatureStatusVerified     SignatureStatus = "verified"      // 签名有效，且签名公钥属于提交者邮箱对应的平台用户
+	SignatureStatusUnverified   SignatureStatus = "unverified"    // 未签名，或签名有效但签名者与提交者邮箱不符
+	SignatureStatusUnknownKey   SignatureStatus = "unknown_key"   // 签名公钥未上传到平台
+	SignatureStatusBadSignature SignatureStatus = "bad_signature" // 签名格式错误或与内容不匹配
+)
+
+// 签名类型，与IAM服务中签名公钥的类型一致
+const (
+	SignatureTypeGPG = "gpg"
+	SignatureTypeSSH = "ssh"
+)
+
+var (
+	gpgSignatureBegin = []byte("-----BEGIN PGP SIGNATURE-----")
+	sshSignatureBegin = []byte("-----BEGIN SSH SIGNATURE-----")
+)
+
+// SignatureInfo 提交或标签的签名校验结果
+type SignatureInfo struct {
+	Type         string          `json:"type,omitempty" gorm:"size:10"` // gpg, ssh；未签名时为空
+	Status       SignatureStatus `json:"status" gorm:"size:20;not null;default:unverified"`
+	KeyID        string          `json:"key_id,omitempty" gorm:"size:100"` // GPG签发者密钥ID或SSH公钥指纹
+	SignerUserID *uuid.UUID      `json:"signer_user_id,omitempty" gorm:"type:uuid"`
+	SignerEmail  string          `json:"signer_email,omitempty" gorm:"size:255"` // 提交者或标签创建者邮箱
+}
+
+// UnsignedSignature 未签名对象的校验结果
+func UnsignedSignature() SignatureInfo {
+	return SignatureInfo{Status: SignatureStatusUnverified}
+}
+
+// IsVerified 签名是否已验证
+func (s SignatureInfo) IsVerified() bool {
+	return s.Status == SignatureStatusVerified
+}
+
+// SigningKey 用户上传的签名公钥，由IAM服务维护，网关只读
+type SigningKey struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	KeyType     string
+	Fingerprint string
+	PublicKey   string
+	Emails      []string // GPG用户ID中的邮箱
+	OwnerEmail  string   // 公钥所属用户的账号邮箱
+}
+
+// AllowsEmail 判断签名公钥能否为该邮箱的提交或标签背书
+// 邮箱须为公钥所属用户的账号邮箱；GPG公钥还须在用户ID中声明该邮箱
+func (k *SigningKey) AllowsEmail(email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" || email != strings.ToLower(k.OwnerEmail) {
+		return false
+	}
+	if k.KeyType == SignatureTypeGPG {
+		return containsString(k.Emails, email)
+	}
+	return true
+}
+
+// SignedObject 从原始提交或标签对象中拆出的签名
+type SignedObject struct {
+	Payload   []byte // 被签名的内容
+	Signature []byte // armor格式签名，未签名时为空
+	Email     string // 提交者或标签创建者邮箱
+}
+
+// ParseSignedCommit 解析 git cat-file commit 的输出
+// 签名位于gpgsig头中，续行以空格开头；被签名内容为去掉该头后的对象
+func ParseSignedCommit(raw []byte) *SignedObject {
+	obj := &SignedObject{}
+	header, body := raw, []byte(nil)
+	if i := bytes.Index(raw, []byte("\n\n")); i >= 0 {
+		header, body = raw[:i+1], raw[i+1:]
+	}
+
+	var payload, signature bytes.Buffer
+	inSignature := false
+	for _, line := range bytes.SplitAfter(header, []byte("\n")) {
+		if inSignature && bytes.HasPrefix(line, []byte(" ")) {
+			signature.Write(line[1:])
+			continue
+		}
+		if value, ok := bytes.CutPrefix(line, []byte("gpgsig ")); ok {
+			inSignature = true
+			signature.Write(value)
+			continue
+		}
+		inSignature = false
+		if value, ok := bytes.CutPrefix(line, []byte("committer ")); ok {
+			obj.Email = identityEmail(string(value))
+		}
+		payload.Write(line)
+	}
+	payload.Write(body)
+
+	obj.Payload = payload.Bytes()
+	if signature.Len() > 0 {
+		obj.Signature = signature.Bytes()
+	}
+	return obj
+}
+
+// ParseSignedTag 解析 git cat-file tag 的输出
+// 签名追加在标签信息末尾，被签名内容为签名之前的部分
+func ParseSignedTag(raw []byte) *SignedObject {
+	obj := &SignedObject{Payload: raw}
+	header, _, _ := bytes.Cut(raw, []byte("\n\n"))
+	for _, line := range bytes.Split(header, []byte("\n")) {
+		if value, ok := bytes.CutPrefix(line, []byte("tagger ")); ok {
+			obj.Email = identityEmail(string(value))
+		}
+	}
+
+	start := -1
+	for _, begin := range [][]byte{gpgSignatureBegin, sshSignatureBegin} {
+		if i := bytes.LastIndex(raw, begin); i > start && (i == 0 || raw[i-1] == '\n') {
+			start = i
+		}
+	}
+	if start > len(header) {
+		obj.Payload = raw[:start]
+		obj.Signature = raw[start:]
+	}
+	return obj
+}
+
+// identityEmail 从 "Name <email> 时间戳 时区" 中取出邮箱
+func identityEmail(identity string) string {
+	start := strings.LastIndex(identity, "<")
+	end := strings.LastIndex(identity, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return identity[start+1 : end]
+}
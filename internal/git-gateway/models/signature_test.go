@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSignedCommit(t *testing.T) {
+	raw := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n" +
+		"parent 1111111111111111111111111111111111111111\n" +
+		"author Alice <alice@example.com> 1700000000 +0800\n" +
+		"committer Bob <Bob@Example.com> 1700000000 +0800\n" +
+		"gpgsig -----BEGIN PGP SIGNATURE-----\n" +
+		" \n" +
+		" iQEzBAABCAAdFiEE\n" +
+		" -----END PGP SIGNATURE-----\n" +
+		"\n" +
+		"feat: signed\n\nbody\n"
+
+	obj := ParseSignedCommit([]byte(raw))
+	assert.Equal(t, "Bob@Example.com", obj.Email)
+	assert.Equal(t, "-----BEGIN PGP SIGNATURE-----\n\niQEzBAABCAAdFiEE\n-----END PGP SIGNATURE-----\n", string(obj.Signature))
+	assert.Equal(t, "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n"+
+		"parent 1111111111111111111111111111111111111111\n"+
+		"author Alice <alice@example.com> 1700000000 +0800\n"+
+		"committer Bob <Bob@Example.com> 1700000000 +0800\n"+
+		"\n"+
+		"feat: signed\n\nbody\n", string(obj.Payload))
+
+	// 未签名提交原样作为被签名内容
+	unsigned := "tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\ncommitter Bob <bob@example.com> 1700000000 +0800\n\nmsg\n"
+	obj = ParseSignedCommit([]byte(unsigned))
+	assert.Nil(t, obj.Signature)
+	assert.Equal(t, unsigned, string(obj.Payload))
+}
+
+func TestParseSignedTag(t *testing.T) {
+	payload := "object 1111111111111111111111111111111111111111\n" +
+		"type commit\n" +
+		"tag v1.0.0\n" +
+		"tagger Alice <alice@example.com> 1700000000 +0800\n" +
+		"\n" +
+		"release v1.0.0\n"
+	signature := "-----BEGIN SSH SIGNATURE-----\nU1NIU0lH\n-----END SSH SIGNATURE-----\n"
+
+	obj := ParseSignedTag([]byte(payload + signature))
+	assert.Equal(t, "alice@example.com", obj.Email)
+	assert.Equal(t, payload, string(obj.Payload))
+	assert.Equal(t, signature, string(obj.Signature))
+
+	obj = ParseSignedTag([]byte(payload))
+	assert.Nil(t, obj.Signature)
+	assert.Equal(t, payload, string(obj.Payload))
+}
+
+func TestSigningKeyAllowsEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   SigningKey
+		email string
+		want  bool
+	}{
+		{"SSH公钥匹配账号邮箱", SigningKey{KeyType: SignatureTypeSSH, OwnerEmail: "alice@example.com"}, "Alice@Example.com", true},
+		{"SSH公钥邮箱不符", SigningKey{KeyType: SignatureTypeSSH, OwnerEmail: "alice@example.com"}, "bob@example.com", false},
+		{"GPG公钥声明了账号邮箱", SigningKey{KeyType: SignatureTypeGPG, OwnerEmail: "alice@example.com", Emails: []string{"alice@example.com"}}, "alice@example.com", true},
+		{"GPG公钥未声明账号邮箱", SigningKey{KeyType: SignatureTypeGPG, OwnerEmail: "alice@example.com", Emails: []string{"alice@personal.dev"}}, "alice@example.com", false},
+		{"空邮箱", SigningKey{KeyType: SignatureTypeSSH}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.key.AllowsEmail(tt.email))
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 模板相关错误
+var (
+	ErrNotATemplate        = errors.New("repository is not a template")
+	ErrInvalidTemplate     = errors.New("invalid repository template")
+	ErrTemplateVariable    = errors.New("invalid template variables")
+	ErrInvalidTemplatePath = errors.New("template path is invalid after substitution")
+)
+
+// 内置模板变量，创建仓库时自动填充
+const (
+	TemplateVarRepositoryName = "repository_name"
+	TemplateVarProjectID      = "project_id"
+	TemplateVarDefaultBranch  = "default_branch"
+)
+
+// templateVariablePattern 模板中的变量占位符 {{name}}，名称前后允许空白；
+// 带点号的Go模板写法如 {{ .Values.x }} 不会匹配，未声明的变量保持原样
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+var templateVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TemplateSubstitutionMaxSize 超过该大小的文件不做内容替换（字节）
+const TemplateSubstitutionMaxSize = 1 << 20
+
+// TemplateVariable 模板声明的变量
+type TemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"` // 可引用内置变量，如 git.example.com/services/{{repository_name}}
+	Required    bool   `json:"required,omitempty"`
+}
+
+// TemplateWebhook 从模板创建仓库时添加的Webhook
+type TemplateWebhook struct {
+	URL    string   `json:"url"` // 可使用模板变量
+	Events []string `json:"events"`
+	Secret *string  `json:"secret,omitempty"`
+}
+
+// RepositoryTemplate 仓库模板设置，仓库标记为模板后可作为新仓库的初始内容
+type RepositoryTemplate struct {
+	RepositoryID       uuid.UUID          `json:"repository_id" gorm:"type:uuid;primary_key"`
+	Variables          []TemplateVariable `json:"variables" gorm:"type:jsonb;serializer:json"`
+	IncludeAllBranches bool               `json:"include_all_branches" gorm:"not null;default:false"`   // 默认是否复制全部分支，创建时可覆盖
+	ProtectedBranches  []string           `json:"protected_branches" gorm:"type:jsonb;serializer:json"` // 新仓库中标记为受保护的分支模式
+	Webhooks           []TemplateWebhook  `json:"webhooks" gorm:"type:jsonb;serializer:json"`
+	PipelinePath       string             `json:"pipeline_path" gorm:"size:512"` // 写入新仓库的流水线文件路径，为空表示不写入
+	PipelineContent    string             `json:"pipeline_content" gorm:"type:text"`
+	UpdatedBy          uuid.UUID          `json:"updated_by" gorm:"type:uuid;not null"`
+	CreatedAt          time.Time          `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt          time.Time          `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (RepositoryTemplate) TableName() string {
+	return "repository_templates"
+}
+
+// SetRepositoryTemplateRequest 将仓库设为模板的请求，整体替换已有设置
+type SetRepositoryTemplateRequest struct {
+	Variables          []TemplateVariable `json:"variables"`
+	IncludeAllBranches bool               `json:"include_all_branches"`
+	ProtectedBranches  []string           `json:"protected_branches"`
+	Webhooks           []TemplateWebhook  `json:"webhooks"`
+	PipelinePath       string             `json:"pipeline_path"`
+	PipelineContent    string             `json:"pipeline_content"`
+}
+
+// Normalize 校验并规范化模板设置
+func (r *SetRepositoryTemplateRequest) Normalize() error {
+	seen := make(map[string]bool)
+	for i := range r.Variables {
+		v := &r.Variables[i]
+		v.Name = strings.TrimSpace(v.Name)
+		if !templateVariableName.MatchString(v.Name) {
+			return fmt.Errorf("%w: invalid variable name %q", ErrInvalidTemplate, v.Name)
+		}
+		if isBuiltinTemplateVariable(v.Name) {
+			return fmt.Errorf("%w: variable %q is built in", ErrInvalidTemplate, v.Name)
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("%w: duplicate variable %q", ErrInvalidTemplate, v.Name)
+		}
+		seen[v.Name] = true
+	}
+
+	var err error
+	if r.ProtectedBranches, err = normalizePatterns(r.ProtectedBranches, "protected_branches", nil); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+
+	for i := range r.Webhooks {
+		hook := &r.Webhooks[i]
+		hook.URL = strings.TrimSpace(hook.URL)
+		if hook.URL == "" {
+			return fmt.Errorf("%w: webhook url is required", ErrInvalidTemplate)
+		}
+		if len(hook.Events) == 0 {
+			hook.Events = []string{string(EventTypePush)}
+		}
+	}
+
+	r.PipelinePath = strings.Trim(strings.TrimSpace(r.PipelinePath), "/")
+	if r.PipelinePath != "" {
+		if _, err := CleanTemplatePath(r.PipelinePath); err != nil {
+			return fmt.Errorf("%w: pipeline_path: %v", ErrInvalidTemplate, err)
+		}
+	} else if r.PipelineContent != "" {
+		return fmt.Errorf("%w: pipeline_path is required with pipeline_content", ErrInvalidTemplate)
+	}
+
+	return nil
+}
+
+// isBuiltinTemplateVariable 是否为内置变量
+func isBuiltinTemplateVariable(name string) bool {
+	switch name {
+	case TemplateVarRepositoryName, TemplateVarProjectID, TemplateVarDefaultBranch:
+		return true
+	}
+	return false
+}
+
+// ResolveTemplateVariables 合并内置变量、请求提供的值和声明的默认值。
+// 提供未声明的变量或缺少必填变量时返回错误
+func ResolveTemplateVariables(declared []TemplateVariable, builtins, provided map[string]string) (map[string]string, error) {
+	known := make(map[string]bool, len(declared))
+	for _, v := range declared {
+		known[v.Name] = true
+	}
+
+	var unknown []string
+	for name := range provided {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: unknown variables %s", ErrTemplateVariable, strings.Join(unknown, ", "))
+	}
+
+	values := make(map[string]string, len(builtins)+len(declared))
+	for name, value := range builtins {
+		values[name] = value
+	}
+
+	var missing []string
+	for _, v := range declared {
+		value, ok := provided[v.Name]
+		switch {
+		case ok && value != "":
+			values[v.Name] = value
+		case v.Default != "":
+			// 默认值只能引用内置变量
+			values[v.Name] = ExpandTemplateVariables(v.Default, builtins)
+		case v.Required:
+			missing = append(missing, v.Name)
+		default:
+			values[v.Name] = ""
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing required variables %s", ErrTemplateVariable, strings.Join(missing, ", "))
+	}
+
+	return values, nil
+}
+
+// ExpandTemplateVariables 替换文本中已知变量的占位符
+func ExpandTemplateVariables(text string, values map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	return templateVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := templateVariablePattern.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// ExpandTemplateContent 替换文件内容中的变量，二进制文件和超大文件保持不变
+func ExpandTemplateContent(content []byte, values map[string]string) []byte {
+	if len(content) > TemplateSubstitutionMaxSize || IsBinaryContent(content) || !bytes.Contains(content, []byte("{{")) {
+		return content
+	}
+	return []byte(ExpandTemplateVariables(string(content), values))
+}
+
+// ExpandTemplatePath 替换路径中的变量并校验结果仍是仓库内的相对路径
+func ExpandTemplatePath(p string, values map[string]string) (string, error) {
+	expanded, err := expandPathVariables(p, values)
+	if err != nil {
+		return "", err
+	}
+	return CleanTemplatePath(expanded)
+}
+
+// ExpandTemplateLink 替换符号链接目标中的变量，目标必须是解析后仍在仓库内的相对路径。
+// name 为链接自身在仓库中的路径（已替换变量）
+func ExpandTemplateLink(name, link string, values map[string]string) (string, error) {
+	expanded, err := expandPathVariables(link, values)
+	if err != nil {
+		return "", err
+	}
+	if expanded == "" || strings.HasPrefix(expanded, "/") || strings.Contains(expanded, "\\") || strings.ContainsRune(expanded, 0) {
+		return "", fmt.Errorf("%w: link %s -> %q", ErrInvalidTemplatePath, name, expanded)
+	}
+	resolved := path.Join(path.Dir(name), expanded)
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return "", fmt.Errorf("%w: link %s -> %q points outside the repository", ErrInvalidTemplatePath, name, expanded)
+	}
+	for _, part := range strings.Split(resolved, "/") {
+		if strings.EqualFold(part, ".git") {
+			return "", fmt.Errorf("%w: link %s -> %q", ErrInvalidTemplatePath, name, expanded)
+		}
+	}
+	return expanded, nil
+}
+
+// expandPathVariables 替换路径中的变量。变量值不能包含路径分隔符，
+// 否则一个路径段会被展开成多层目录，绕过按段进行的校验
+func expandPathVariables(p string, values map[string]string) (string, error) {
+	var invalid string
+	expanded := templateVariablePattern.ReplaceAllStringFunc(p, func(match string) string {
+		name := templateVariablePattern.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok {
+			return match
+		}
+		if invalid == "" && strings.ContainsAny(value, "/\\") {
+			invalid = name
+		}
+		return value
+	})
+	if invalid != "" {
+		return "", fmt.Errorf("%w: variable %q used in %q contains a path separator", ErrInvalidTemplatePath, invalid, p)
+	}
+	return expanded, nil
+}
+
+// CleanTemplatePath 校验仓库内相对路径：不能为空、绝对路径、包含..或.git目录
+func CleanTemplatePath(p string) (string, error) {
+	if p == "" || strings.HasPrefix(p, "/") || strings.Contains(p, "\\") || strings.ContainsRune(p, 0) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidTemplatePath, p)
+	}
+	cleaned := path.Clean(p)
+	for _, part := range strings.Split(cleaned, "/") {
+		if part == "" || part == "." || part == ".." || strings.EqualFold(part, ".git") {
+			return "", fmt.Errorf("%w: %q", ErrInvalidTemplatePath, p)
+		}
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveTemplateVariables(t *testing.T) {
+	declared := []TemplateVariable{
+		{Name: "module_path", Default: "git.example.com/services/{{repository_name}}"},
+		{Name: "owner", Required: true},
+		{Name: "description"},
+	}
+	builtins := map[string]string{TemplateVarRepositoryName: "billing"}
+
+	values, err := ResolveTemplateVariables(declared, builtins, map[string]string{"owner": "payments"})
+	require.NoError(t, err)
+	assert.Equal(t, "git.example.com/services/billing", values["module_path"])
+	assert.Equal(t, "payments", values["owner"])
+	assert.Equal(t, "billing", values[TemplateVarRepositoryName])
+	assert.Contains(t, values, "description")
+
+	_, err = ResolveTemplateVariables(declared, builtins, nil)
+	assert.ErrorIs(t, err, ErrTemplateVariable)
+
+	_, err = ResolveTemplateVariables(declared, builtins, map[string]string{"owner": "x", "typo": "y"})
+	assert.ErrorIs(t, err, ErrTemplateVariable)
+}
+
+func TestExpandTemplateVariables(t *testing.T) {
+	values := map[string]string{"name": "billing", "module_path": "git.example.com/billing"}
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"替换变量", "module {{module_path}}", "module git.example.com/billing"},
+		{"允许空白", "{{ name }}-service", "billing-service"},
+		{"未声明的变量保持原样", "{{unknown}}", "{{unknown}}"},
+		{"Go模板写法不替换", "{{ .Values.name }}", "{{ .Values.name }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ExpandTemplateVariables(tt.in, values))
+		})
+	}
+
+	binary := []byte("{{name}}\x00")
+	assert.Equal(t, binary, ExpandTemplateContent(binary, values))
+}
+
+func TestExpandTemplatePath(t *testing.T) {
+	values := map[string]string{"name": "billing", "escape": "../.."}
+
+	p, err := ExpandTemplatePath("cmd/{{name}}/main.go", values)
+	require.NoError(t, err)
+	assert.Equal(t, "cmd/billing/main.go", p)
+
+	_, err = ExpandTemplatePath("{{escape}}/etc/passwd", values)
+	assert.ErrorIs(t, err, ErrInvalidTemplatePath)
+
+	_, err = ExpandTemplatePath(".git/config", values)
+	assert.ErrorIs(t, err, ErrInvalidTemplatePath)
+
+	// 变量值不能把一个路径段展开成多层目录
+	_, err = ExpandTemplatePath("{{nested}}/main.go", map[string]string{"nested": "lnk/x"})
+	assert.ErrorIs(t, err, ErrInvalidTemplatePath)
+}
+
+func TestExpandTemplateLink(t *testing.T) {
+	values := map[string]string{"name": "billing", "nested": "a/b"}
+
+	tests := []struct {
+		name    string
+		path    string
+		link    string
+		want    string
+		wantErr bool
+	}{
+		{"同目录", "docs/latest", "v1", "v1", false},
+		{"指向上级目录", "docs/current/README.md", "../../README.md", "../../README.md", false},
+		{"替换变量", "bin/run", "{{name}}.sh", "billing.sh", false},
+		{"绝对路径", "lnk", "/etc", "", true},
+		{"逃出仓库", "docs/lnk", "../../etc", "", true},
+		{"指向.git", "lnk", ".git/config", "", true},
+		{"变量包含路径分隔符", "lnk", "{{nested}}", "", true},
+		{"空目标", "lnk", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandTemplateLink(tt.path, tt.link, values)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTemplatePath)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSetRepositoryTemplateRequestNormalize(t *testing.T) {
+	req := &SetRepositoryTemplateRequest{
+		Variables:       []TemplateVariable{{Name: " module_path "}},
+		Webhooks:        []TemplateWebhook{{URL: " https://ci.example.com/hook "}},
+		PipelinePath:    "/.ci/pipeline.yml",
+		PipelineContent: "stages: []",
+	}
+	require.NoError(t, req.Normalize())
+	assert.Equal(t, "module_path", req.Variables[0].Name)
+	assert.Equal(t, []string{"push"}, req.Webhooks[0].Events)
+	assert.Equal(t, ".ci/pipeline.yml", req.PipelinePath)
+
+	req = &SetRepositoryTemplateRequest{Variables: []TemplateVariable{{Name: TemplateVarRepositoryName}}}
+	assert.ErrorIs(t, req.Normalize(), ErrInvalidTemplate)
+
+	req = &SetRepositoryTemplateRequest{PipelineContent: "stages: []"}
+	assert.ErrorIs(t, req.Normalize(), ErrInvalidTemplate)
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 迁移、改名与归档相关错误
+var (
+	ErrRepositoryArchived    = errors.New("repository is archived")
+	ErrInvalidRepositoryName = errors.New("invalid repository name")
+)
+
+// repositoryNamePattern 仓库名称只允许字母、数字、点、下划线和连字符，名称会作为磁盘目录和克隆地址的一部分
+var repositoryNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// ValidateRepositoryName 校验改名和迁移的目标名称
+func ValidateRepositoryName(name string) error {
+	if name == "" || len(name) > 255 {
+		return fmt.Errorf("%w: name must be 1-255 characters", ErrInvalidRepositoryName)
+	}
+	if !repositoryNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidRepositoryName, name)
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".git") {
+		return fmt.Errorf("%w: name must not end with .git", ErrInvalidRepositoryName)
+	}
+	return nil
+}
+
+// TrimRepositoryName 去掉克隆地址中的 .git 后缀
+func TrimRepositoryName(name string) string {
+	return strings.TrimSuffix(name, ".git")
+}
+
+// RepositoryRedirect 仓库改名或迁移后，旧的项目和名称指向仓库当前位置。
+// 新仓库占用同一位置时重定向失效
+type RepositoryRedirect struct {
+	ProjectID    uuid.UUID `json:"project_id" gorm:"type:uuid;primaryKey"`
+	Name         string    `json:"name" gorm:"size:255;primaryKey"`
+	RepositoryID uuid.UUID `json:"repository_id" gorm:"type:uuid;not null;index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// TableName 指定表名
+func (RepositoryRedirect) TableName() string {
+	return "repository_redirects"
+}
+
+// TransferRepositoryRequest 将仓库迁移到另一个项目
+type TransferRepositoryRequest struct {
+	ProjectID string  `json:"project_id" binding:"required,uuid"`
+	Name      *string `json:"name" validate:"omitempty,min=1,max=255"` // 为空时沿用当前名称
+}
+
+// RepositoryResolution 按项目和名称解析仓库的结果
+type RepositoryResolution struct {
+	Repository *Repository `json:"repository"`
+	Redirected bool        `json:"redirected"` // 请求的是旧地址，客户端应改用仓库当前地址
+}
+
+// RepositoryLifecycleAction 通知项目服务的仓库生命周期事件
+type RepositoryLifecycleAction string
+
+const (
+	RepositoryActionArchived    RepositoryLifecycleAction = "archived"
+	RepositoryActionUnarchived  RepositoryLifecycleAction = "unarchived"
+	RepositoryActionRenamed     RepositoryLifecycleAction = "renamed"
+	RepositoryActionTransferred RepositoryLifecycleAction = "transferred"
+)
+
+// RepositoryLocation 仓库所在的项目和名称
+type RepositoryLocation struct {
+	ProjectID uuid.UUID `json:"project_id"`
+	Name      string    `json:"name"`
+}
+
+// RelocationAction 从旧位置移动到新位置对应的事件，位置未变时返回空
+func RelocationAction(from, to RepositoryLocation) RepositoryLifecycleAction {
+	switch {
+	case from.ProjectID != to.ProjectID:
+		return RepositoryActionTransferred
+	case from.Name != to.Name:
+		return RepositoryActionRenamed
+	}
+	return ""
+}
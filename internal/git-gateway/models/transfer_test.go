@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRepositoryName(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantErr bool
+	}{
+		{"普通名称", "billing-service", false},
+		{"包含点和下划线", "my_repo.v2", false},
+		{"空名称", "", true},
+		{"包含斜杠", "team/repo", true},
+		{"上级目录", "..", true},
+		{"以点开头", ".hidden", true},
+		{".git后缀", "repo.git", true},
+		{"包含空格", "my repo", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRepositoryName(tt.in)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidRepositoryName)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRelocationAction(t *testing.T) {
+	projectA, projectB := uuid.New(), uuid.New()
+
+	assert.Equal(t, RepositoryLifecycleAction(""), RelocationAction(
+		RepositoryLocation{ProjectID: projectA, Name: "app"}, RepositoryLocation{ProjectID: projectA, Name: "app"}))
+	assert.Equal(t, RepositoryActionRenamed, RelocationAction(
+		RepositoryLocation{ProjectID: projectA, Name: "app"}, RepositoryLocation{ProjectID: projectA, Name: "web"}))
+	assert.Equal(t, RepositoryActionTransferred, RelocationAction(
+		RepositoryLocation{ProjectID: projectA, Name: "app"}, RepositoryLocation{ProjectID: projectB, Name: "app"}))
+	assert.Equal(t, RepositoryActionTransferred, RelocationAction(
+		RepositoryLocation{ProjectID: projectA, Name: "app"}, RepositoryLocation{ProjectID: projectB, Name: "web"}))
+}
+
+func TestRepositoryCheckWritable(t *testing.T) {
+	assert.NoError(t, (&Repository{Status: RepositoryStatusActive}).CheckWritable())
+	assert.ErrorIs(t, (&Repository{Status: RepositoryStatusActive, IsMirror: true}).CheckWritable(), ErrRepositoryReadOnly)
+	assert.ErrorIs(t, (&Repository{Status: RepositoryStatusArchived}).CheckWritable(), ErrRepositoryArchived)
+}
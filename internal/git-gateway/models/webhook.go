@@ -1,11 +1,19 @@
 package models
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Webhook投递错误
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
 // User 用户模型（简化版，用于Webhook事件）
 type User struct {
 	ID       uuid.UUID `json:"id"`
@@ -162,23 +170,91 @@ type WebhookAction struct {
 	Secret  string                 `json:"secret"`
 }
 
-// WebhookDelivery 钩子投递记录
+// WebhookDelivery 钩子投递记录，同时作为持久化的投递队列
+// 目标为仓库Webhook（WebhookID）或触发器的call_webhook动作（TriggerID），两者必有其一；
+// 签名密钥不入库，每次发送时从Webhook或触发器读取
 type WebhookDelivery struct {
-	ID           uuid.UUID      `json:"id" db:"id"`
-	WebhookID    uuid.UUID      `json:"webhook_id" db:"webhook_id"`
-	EventID      uuid.UUID      `json:"event_id" db:"event_id"`
-	URL          string         `json:"url" db:"url"`
-	Method       string         `json:"method" db:"method"`
-	Status       DeliveryStatus `json:"status" db:"status"`
-	StatusCode   int            `json:"status_code" db:"status_code"`
-	RequestBody  string         `json:"request_body" db:"request_body"`
-	ResponseBody string         `json:"response_body" db:"response_body"`
-	Duration     int64          `json:"duration" db:"duration"` // 毫秒
-	Attempts     int            `json:"attempts" db:"attempts"`
-	MaxAttempts  int            `json:"max_attempts" db:"max_attempts"`
-	NextRetryAt  *time.Time     `json:"next_retry_at" db:"next_retry_at"`
-	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
+	ID             uuid.UUID         `json:"id" db:"id"`
+	WebhookID      *uuid.UUID        `json:"webhook_id" db:"webhook_id"`
+	TriggerID      *uuid.UUID        `json:"trigger_id" db:"trigger_id"`
+	RepositoryID   uuid.UUID         `json:"repository_id" db:"repository_id"`
+	EventID        uuid.UUID         `json:"event_id" db:"event_id"`
+	EventType      WebhookEventType  `json:"event_type" db:"event_type"`
+	RedeliveryOf   *uuid.UUID        `json:"redelivery_of" db:"redelivery_of"` // 手动重新投递时指向原投递
+	URL            string            `json:"url" db:"url"`
+	Method         string            `json:"method" db:"method"`
+	Status         DeliveryStatus    `json:"status" db:"status"`
+	StatusCode     int               `json:"status_code" db:"status_code"` // 最近一次尝试的响应状态码，0表示未收到响应
+	RequestHeaders map[string]string `json:"request_headers" db:"request_headers"`
+	RequestBody    string            `json:"request_body" db:"request_body"`
+	ResponseBody   string            `json:"response_body" db:"response_body"` // 最近一次尝试的响应摘录
+	Duration       int64             `json:"duration" db:"duration"`           // 毫秒
+	Attempts       int               `json:"attempts" db:"attempts"`
+	MaxAttempts    int               `json:"max_attempts" db:"max_attempts"`
+	NextRetryAt    *time.Time        `json:"next_retry_at" db:"next_retry_at"`
+	LastError      string            `json:"last_error" db:"last_error"`
+	CreatedAt      time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at" db:"updated_at"`
+
+	AttemptHistory []WebhookDeliveryAttempt `json:"attempt_history,omitempty" db:"-"`
+}
+
+// WebhookDeliveryAttempt 一次投递尝试
+type WebhookDeliveryAttempt struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	DeliveryID   uuid.UUID `json:"delivery_id" db:"delivery_id"`
+	Attempt      int       `json:"attempt" db:"attempt"`
+	StatusCode   int       `json:"status_code" db:"status_code"` // 0表示请求未完成（连接失败、超时等）
+	ResponseBody string    `json:"response_body" db:"response_body"`
+	Duration     int64     `json:"duration" db:"duration"` // 毫秒
+	Error        string    `json:"error" db:"error"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// Succeeded 是否投递成功
+func (a *WebhookDeliveryAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// Retryable 失败的尝试是否值得重试：网络错误、超时、限流和服务端错误会重试，其余4xx视为配置错误
+func (a *WebhookDeliveryAttempt) Retryable() bool {
+	switch {
+	case a.StatusCode == 0:
+		return true
+	case a.StatusCode == 408, a.StatusCode == 429:
+		return true
+	default:
+		return a.StatusCode >= 500
+	}
+}
+
+// maxWebhookRetryDelay 投递重试的最长间隔
+const maxWebhookRetryDelay = time.Hour
+
+// WebhookRetryDelay 第attempts次尝试失败后的重试间隔，从base起倍增
+func WebhookRetryDelay(base time.Duration, attempts int) time.Duration {
+	if base <= 0 {
+		base = 30 * time.Second
+	}
+	delay := base
+	for i := 1; i < attempts && delay < maxWebhookRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxWebhookRetryDelay {
+		delay = maxWebhookRetryDelay
+	}
+	return delay
+}
+
+// SubscribesTo Webhook是否订阅了事件类型，"*" 表示全部事件
+func (w *Webhook) SubscribesTo(eventType WebhookEventType) bool {
+	for _, event := range w.Events {
+		event = strings.TrimSpace(event)
+		if event == "*" || WebhookEventType(event) == eventType {
+			return true
+		}
+	}
+	return false
 }
 
 // DeliveryStatus 投递状态
@@ -268,4 +344,29 @@ type WebhookStatistics struct {
 	RecentEvents       []WebhookEvent             `json:"recent_events"`
 	AverageProcessTime float64                    `json:"average_process_time"`
 	SuccessRate        float64                    `json:"success_rate"`
+	Webhooks           []WebhookDeliveryStats     `json:"webhooks"` // 各仓库Webhook的投递统计
+}
+
+// WebhookDeliveryStats 单个仓库Webhook的投递统计
+type WebhookDeliveryStats struct {
+	WebhookID           uuid.UUID  `json:"webhook_id" db:"webhook_id"`
+	RepositoryID        uuid.UUID  `json:"repository_id" db:"repository_id"`
+	URL                 string     `json:"url" db:"url"`
+	IsActive            bool       `json:"is_active" db:"is_active"`
+	ConsecutiveFailures int        `json:"consecutive_failures" db:"consecutive_failures"`
+	DisabledReason      *string    `json:"disabled_reason" db:"disabled_reason"`
+	TotalDeliveries     int64      `json:"total_deliveries" db:"total_deliveries"`
+	Succeeded           int64      `json:"succeeded" db:"succeeded"`
+	Failed              int64      `json:"failed" db:"failed"`
+	Pending             int64      `json:"pending" db:"pending"`                   // 等待投递或重试中
+	SuccessRate         float64    `json:"success_rate" db:"-"`                    // 已完成投递中成功的比例
+	AverageDuration     float64    `json:"average_duration" db:"average_duration"` // 毫秒
+	LastDeliveryAt      *time.Time `json:"last_delivery_at" db:"last_delivery_at"`
+}
+
+// ComputeSuccessRate 根据已完成的投递计算成功率
+func (s *WebhookDeliveryStats) ComputeSuccessRate() {
+	if finished := s.Succeeded + s.Failed; finished > 0 {
+		s.SuccessRate = float64(s.Succeeded) / float64(finished)
+	}
 }
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		attempts int
+		want     time.Duration
+	}{
+		{"首次失败", 30 * time.Second, 1, 30 * time.Second},
+		{"第三次失败", 30 * time.Second, 3, 2 * time.Minute},
+		{"最长一小时", 30 * time.Second, 20, time.Hour},
+		{"未配置间隔", 0, 2, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, WebhookRetryDelay(tt.base, tt.attempts))
+		})
+	}
+}
+
+func TestWebhookDeliveryAttemptOutcome(t *testing.T) {
+	tests := []struct {
+		name      string
+		attempt   WebhookDeliveryAttempt
+		succeeded bool
+		retryable bool
+	}{
+		{"成功", WebhookDeliveryAttempt{StatusCode: 204}, true, false},
+		{"连接失败", WebhookDeliveryAttempt{Error: "connection refused"}, false, true},
+		{"服务端错误", WebhookDeliveryAttempt{StatusCode: 502, Error: "响应状态码 502"}, false, true},
+		{"限流", WebhookDeliveryAttempt{StatusCode: 429, Error: "响应状态码 429"}, false, true},
+		{"地址不存在", WebhookDeliveryAttempt{StatusCode: 404, Error: "响应状态码 404"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.succeeded, tt.attempt.Succeeded())
+			assert.Equal(t, tt.retryable, tt.attempt.Retryable())
+		})
+	}
+}
+
+func TestWebhookSubscribesTo(t *testing.T) {
+	webhook := &Webhook{Events: []string{"push", " pull_request "}}
+	assert.True(t, webhook.SubscribesTo(EventTypePush))
+	assert.True(t, webhook.SubscribesTo(EventTypePullRequest))
+	assert.False(t, webhook.SubscribesTo(EventTypeTagCreate))
+
+	webhook = &Webhook{Events: []string{"*"}}
+	assert.True(t, webhook.SubscribesTo(EventTypeTagCreate))
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CodeOwnerRepository 代码所有者数据访问接口，用户和项目成员由IAM服务维护
+type CodeOwnerRepository interface {
+	// FindProjectMembers 按用户名或邮箱（小写）查找项目成员，非项目成员不能成为代码所有者
+	FindProjectMembers(ctx context.Context, projectID uuid.UUID, usernames, emails []string) ([]models.CodeOwnerUser, error)
+}
+
+// codeOwnerRepository 代码所有者数据访问实现
+type codeOwnerRepository struct {
+	db *gorm.DB
+}
+
+// NewCodeOwnerRepository 创建代码所有者数据访问实例
+func NewCodeOwnerRepository(db *gorm.DB) CodeOwnerRepository {
+	return &codeOwnerRepository{
+		db: db,
+	}
+}
+
+// FindProjectMembers 按用户名或邮箱查找项目中的有效用户
+func (r *codeOwnerRepository) FindProjectMembers(ctx context.Context, projectID uuid.UUID, usernames, emails []string) ([]models.CodeOwnerUser, error) {
+	users := []models.CodeOwnerUser{}
+	if len(usernames) == 0 && len(emails) == 0 {
+		return users, nil
+	}
+	// IN () 对空列表无效，用不可能出现的值占位
+	if len(usernames) == 0 {
+		usernames = []string{""}
+	}
+	if len(emails) == 0 {
+		emails = []string{""}
+	}
+
+	err := r.db.WithContext(ctx).Raw(`
+	SELECT u.id, u.username, u.email
+	FROM users u
+	WHERE u.deleted_at IS NULL AND u.is_active
+	AND (LOWER(u.username) IN ? OR LOWER(u.email) IN ?)
+	AND EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = ? AND pm.user_id = u.id)`,
+		usernames, emails, projectID).
+		Scan(&users).Error
+	return users, err
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CodeSearchRepository 代码搜索索引数据访问接口
+type CodeSearchRepository interface {
+	// 索引文档
+	UpsertDocuments(ctx context.Context, docs []models.CodeSearchDocument) error
+	DeleteDocuments(ctx context.Context, repositoryID uuid.UUID, branch string, paths []string) error
+	DeleteBranchDocuments(ctx context.Context, repositoryID uuid.UUID, branch string) error
+	CountDocuments(ctx context.Context, repositoryID uuid.UUID, branch string) (int64, error)
+
+	// 索引分支
+	GetSearchBranch(ctx context.Context, repositoryID uuid.UUID, branch string) (*models.CodeSearchBranch, error)
+	ListSearchBranches(ctx context.Context, repositoryID uuid.UUID) ([]models.CodeSearchBranch, error)
+	SaveSearchBranch(ctx context.Context, branch *models.CodeSearchBranch) error
+	UpdateSearchBranch(ctx context.Context, repositoryID uuid.UUID, branch string, updates map[string]interface{}) error
+	DeleteSearchBranch(ctx context.Context, repositoryID uuid.UUID, branch string) error
+
+	// 搜索与权限
+	SearchDocuments(ctx context.Context, query *models.CodeSearchQuery, access models.CodeSearchAccess) ([]models.CodeSearchHit, int64, error)
+	CanReadRepository(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) (bool, error)
+	CanWriteRepository(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) (bool, error)
+}
+
+// codeSearchRepository 代码搜索索引数据访问实现
+type codeSearchRepository struct {
+	db *gorm.DB
+}
+
+// NewCodeSearchRepository 创建代码搜索索引数据访问实例
+func NewCodeSearchRepository(db *gorm.DB) CodeSearchRepository {
+	return &codeSearchRepository{
+		db: db,
+	}
+}
+
+// readableRepositoryCondition 用户可读仓库条件：同租户下公开/内部仓库，或用户所在项目的私有仓库
+const readableRepositoryCondition = `r.deleted_at IS NULL AND p.tenant_id = ? AND (
+	r.visibility IN ('public', 'internal')
+	OR EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = r.project_id AND pm.user_id = ?)
+)`
+
+// UpsertDocuments 批量写入或更新索引文档
+func (r *codeSearchRepository) UpsertDocuments(ctx context.Context, docs []models.CodeSearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "repository_id"}, {Name: "branch"}, {Name: "path"}},
+			DoUpdates: clause.AssignmentColumns([]string{"blob_sha", "language", "size", "content", "indexed_at"}),
+		}).
+		CreateInBatches(docs, 100).Error
+}
+
+// DeleteDocuments 删除指定路径的索引文档
+func (r *codeSearchRepository) DeleteDocuments(ctx context.Context, repositoryID uuid.UUID, branch string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Where("repository_id = ? AND branch = ? AND path IN ?", repositoryID, branch, paths).
+		Delete(&models.CodeSearchDocument{}).Error
+}
+
+// DeleteBranchDocuments 删除分支的全部索引文档
+func (r *codeSearchRepository) DeleteBranchDocuments(ctx context.Context, repositoryID uuid.UUID, branch string) error {
+	return r.db.WithContext(ctx).
+		Where("repository_id = ? AND branch = ?", repositoryID, branch).
+		Delete(&models.CodeSearchDocument{}).Error
+}
+
+// CountDocuments 统计分支已索引的文件数
+func (r *codeSearchRepository) CountDocuments(ctx context.Context, repositoryID uuid.UUID, branch string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.CodeSearchDocument{}).
+		Where("repository_id = ? AND branch = ?", repositoryID, branch).
+		Count(&count).Error
+	return count, err
+}
+
+// GetSearchBranch 获取分支索引状态
+func (r *codeSearchRepository) GetSearchBranch(ctx context.Context, repositoryID uuid.UUID, branch string) (*models.CodeSearchBranch, error) {
+	var searchBranch models.CodeSearchBranch
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND branch = ?", repositoryID, branch).
+		First(&searchBranch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &searchBranch, nil
+}
+
+// ListSearchBranches 获取仓库参与搜索的分支
+func (r *codeSearchRepository) ListSearchBranches(ctx context.Context, repositoryID uuid.UUID) ([]models.CodeSearchBranch, error) {
+	var branches []models.CodeSearchBranch
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ?", repositoryID).
+		Order("branch ASC").
+		Find(&branches).Error
+	return branches, err
+}
+
+// SaveSearchBranch 登记参与搜索的分支，已存在时保持原有索引进度
+func (r *codeSearchRepository) SaveSearchBranch(ctx context.Context, branch *models.CodeSearchBranch) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(branch).Error
+}
+
+// UpdateSearchBranch 更新分支索引状态
+func (r *codeSearchRepository) UpdateSearchBranch(ctx context.Context, repositoryID uuid.UUID, branch string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&models.CodeSearchBranch{}).
+		Where("repository_id = ? AND branch = ?", repositoryID, branch).
+		Updates(updates).Error
+}
+
+// DeleteSearchBranch 移除参与搜索的分支及其索引文档
+func (r *codeSearchRepository) DeleteSearchBranch(ctx context.Context, repositoryID uuid.UUID, branch string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ? AND branch = ?", repositoryID, branch).
+			Delete(&models.CodeSearchDocument{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("repository_id = ? AND branch = ?", repositoryID, branch).
+			Delete(&models.CodeSearchBranch{}).Error
+	})
+}
+
+// SearchDocuments 在用户可读的仓库中搜索代码
+// 内容条件使用三元组索引（pg_trgm）筛选候选文件，匹配行由调用方提取
+func (r *codeSearchRepository) SearchDocuments(ctx context.Context, query *models.CodeSearchQuery, access models.CodeSearchAccess) ([]models.CodeSearchHit, int64, error) {
+	conditions := []string{readableRepositoryCondition}
+	args := []interface{}{access.TenantID, access.UserID}
+
+	switch {
+	case query.Regex && query.CaseSensitive:
+		conditions = append(conditions, "d.content ~ ?")
+		args = append(args, query.Query)
+	case query.Regex:
+		conditions = append(conditions, "d.content ~* ?")
+		args = append(args, query.Query)
+	case query.CaseSensitive:
+		conditions = append(conditions, `d.content LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(query.Query)+"%")
+	default:
+		conditions = append(conditions, `d.content ILIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(query.Query)+"%")
+	}
+
+	if query.Branch != "" {
+		conditions = append(conditions, "d.branch = ?")
+		args = append(args, query.Branch)
+	} else {
+		conditions = append(conditions, "d.branch = r.default_branch")
+	}
+	if len(query.RepositoryIDs) > 0 {
+		conditions = append(conditions, "d.repository_id IN ?")
+		args = append(args, query.RepositoryIDs)
+	}
+	if pattern := query.PathPattern(); pattern != "" {
+		conditions = append(conditions, "d.path ~* ?")
+		args = append(args, pattern)
+	}
+	if query.Language != "" {
+		conditions = append(conditions, "d.language = ?")
+		args = append(args, query.Language)
+	}
+
+	from := `FROM code_search_documents d
+		JOIN repositories r ON r.id = d.repository_id
+		JOIN projects p ON p.id = r.project_id
+		WHERE ` + strings.Join(conditions, " AND ")
+
+	db := r.db.WithContext(ctx)
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) "+from, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var hits []models.CodeSearchHit
+	offset := (query.Page - 1) * query.PageSize
+	err := db.Raw(`SELECT d.repository_id, d.branch, d.path, d.blob_sha, d.language, d.size, d.content, d.indexed_at,
+			r.name AS repository_name, r.project_id `+from+`
+		ORDER BY r.name ASC, d.path ASC
+		LIMIT ? OFFSET ?`, append(args, query.PageSize, offset)...).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return hits, total, nil
+}
+
+// CanReadRepository 判断用户是否可读仓库
+func (r *codeSearchRepository) CanReadRepository(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM repositories r
+		JOIN projects p ON p.id = r.project_id
+		WHERE r.id = ? AND `+readableRepositoryCondition, repositoryID, access.TenantID, access.UserID).
+		Scan(&count).Error
+	return count > 0, err
+}
+
+// CanWriteRepository 检查用户能否管理仓库的搜索索引：同租户的项目成员
+func (r *codeSearchRepository) CanWriteRepository(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM repositories r
+		JOIN projects p ON p.id = r.project_id
+		WHERE r.id = ? AND `+writableRepositoryCondition, repositoryID, access.TenantID, access.UserID).
+		Scan(&count).Error
+	return count > 0, err
+}
+
+// escapeLike 转义LIKE模式中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
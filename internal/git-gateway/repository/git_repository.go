@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GitRepository Git仓库数据访问接口
@@ -19,6 +21,19 @@ type GitRepository interface {
 	UpdateRepository(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
 	DeleteRepository(ctx context.Context, id uuid.UUID) error
 
+	// Fork管理
+	ListForks(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) ([]models.Repository, int64, error)
+	UpdateForkCount(ctx context.Context, repositoryID uuid.UUID, delta int) error
+
+	// 模板管理
+	GetRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryTemplate, error)
+	SaveRepositoryTemplate(ctx context.Context, template *models.RepositoryTemplate) error
+	DeleteRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID) error
+
+	// 迁移与重定向
+	ResolveRepository(ctx context.Context, projectID uuid.UUID, name string) (*models.Repository, bool, error)
+	RelocateRepository(ctx context.Context, id uuid.UUID, to models.RepositoryLocation, updates map[string]interface{}) error
+
 	// 分支管理
 	CreateBranch(ctx context.Context, branch *models.Branch) error
 	GetBranchByName(ctx context.Context, repositoryID uuid.UUID, name string) (*models.Branch, error)
@@ -49,9 +64,11 @@ type GitRepository interface {
 
 	// Pull Request管理
 	CreatePullRequest(ctx context.Context, pr *models.PullRequest) error
+	GetNextPullRequestNumber(ctx context.Context, repositoryID uuid.UUID) (int, error)
 	GetPullRequestByID(ctx context.Context, id uuid.UUID) (*models.PullRequest, error)
 	GetPullRequestByNumber(ctx context.Context, repositoryID uuid.UUID, number int) (*models.PullRequest, error)
 	ListPullRequests(ctx context.Context, repositoryID uuid.UUID, status *models.PullRequestStatus, page, pageSize int) ([]models.PullRequest, int64, error)
+	ListProjectPullRequestsByReference(ctx context.Context, projectID uuid.UUID, status *models.PullRequestStatus, pattern string, page, pageSize int) ([]models.PullRequest, int64, error)
 	UpdatePullRequest(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
 	MergePullRequest(ctx context.Context, id uuid.UUID, mergeCommitSHA string, mergedBy uuid.UUID) error
 	ClosePullRequest(ctx context.Context, id uuid.UUID) error
@@ -70,6 +87,13 @@ type GitRepository interface {
 	// 统计和查询
 	GetRepositoryStats(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryStats, error)
 	SearchRepositories(ctx context.Context, query string, projectID *uuid.UUID, page, pageSize int) ([]models.Repository, int64, error)
+
+	// 权限校验
+	CanReadRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error)
+	CanWriteRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error)
+	CanAdminRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error)
+	CanWriteProject(ctx context.Context, projectID uuid.UUID, access models.RepositoryAccess) (bool, error)
+	CanAdminProject(ctx context.Context, projectID uuid.UUID, access models.RepositoryAccess) (bool, error)
 }
 
 // gitRepository Git仓库数据访问实现
@@ -165,6 +189,149 @@ func (r *gitRepository) DeleteRepository(ctx context.Context, id uuid.UUID) erro
 		Update("deleted_at", gorm.Expr("NOW()")).Error
 }
 
+// Fork管理实现
+
+// ListForks 获取仓库的直接fork列表
+func (r *gitRepository) ListForks(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) ([]models.Repository, int64, error) {
+	var repos []models.Repository
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Repository{}).
+		Where("forked_from_id = ? AND deleted_at IS NULL", repositoryID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.
+		Preload("Project").
+		Order("created_at ASC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&repos).Error
+
+	return repos, total, err
+}
+
+// UpdateForkCount 增减仓库的fork数量
+func (r *gitRepository) UpdateForkCount(ctx context.Context, repositoryID uuid.UUID, delta int) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", repositoryID).
+		Update("fork_count", gorm.Expr("GREATEST(fork_count + ?, 0)", delta)).Error
+}
+
+// 模板管理实现
+
+// GetRepositoryTemplate 获取仓库模板设置
+func (r *gitRepository) GetRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryTemplate, error) {
+	var template models.RepositoryTemplate
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ?", repositoryID).
+		First(&template).Error
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// SaveRepositoryTemplate 保存模板设置并将仓库标记为模板
+func (r *gitRepository) SaveRepositoryTemplate(ctx context.Context, template *models.RepositoryTemplate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "repository_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"variables", "include_all_branches", "protected_branches", "webhooks",
+				"pipeline_path", "pipeline_content", "updated_by", "updated_at",
+			}),
+		}, clause.Returning{}).Create(template).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Repository{}).
+			Where("id = ?", template.RepositoryID).
+			Update("is_template", true).Error
+	})
+}
+
+// DeleteRepositoryTemplate 删除模板设置并取消模板标记
+func (r *gitRepository) DeleteRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", repositoryID).
+			Delete(&models.RepositoryTemplate{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Repository{}).
+			Where("id = ?", repositoryID).
+			Update("is_template", false).Error
+	})
+}
+
+// 迁移与重定向实现
+
+// ResolveRepository 按项目和名称查找仓库，找不到时沿改名或迁移留下的重定向查找。
+// 第二个返回值表示是否经过了重定向
+func (r *gitRepository) ResolveRepository(ctx context.Context, projectID uuid.UUID, name string) (*models.Repository, bool, error) {
+	repo, err := r.GetRepositoryByProjectAndName(ctx, projectID, name)
+	if err == nil {
+		return repo, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	var redirect models.RepositoryRedirect
+	if err := r.db.WithContext(ctx).
+		Where("project_id = ? AND name = ?", projectID, name).
+		First(&redirect).Error; err != nil {
+		return nil, false, err
+	}
+
+	repo, err = r.GetRepositoryByID(ctx, redirect.RepositoryID)
+	if err != nil {
+		return nil, false, err
+	}
+	return repo, true, nil
+}
+
+// RelocateRepository 修改仓库的项目和名称，并为旧位置记录重定向。
+// 新位置上已有的重定向（包括本仓库之前留下的）随之失效
+func (r *gitRepository) RelocateRepository(ctx context.Context, id uuid.UUID, to models.RepositoryLocation, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.Repository
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "project_id", "name").
+			Where("id = ? AND deleted_at IS NULL", id).
+			First(&current).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("project_id = ? AND name = ?", to.ProjectID, to.Name).
+			Delete(&models.RepositoryRedirect{}).Error; err != nil {
+			return err
+		}
+
+		updates["project_id"] = to.ProjectID
+		updates["name"] = to.Name
+		if err := tx.Model(&models.Repository{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		redirect := &models.RepositoryRedirect{
+			ProjectID:    current.ProjectID,
+			Name:         current.Name,
+			RepositoryID: id,
+			CreatedAt:    time.Now(),
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "project_id"}, {Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"repository_id", "created_at"}),
+		}).Create(redirect).Error
+	})
+}
+
 // 分支管理实现
 
 // CreateBranch 创建分支
@@ -475,6 +642,17 @@ func (r *gitRepository) CreatePullRequest(ctx context.Context, pr *models.PullRe
 	return r.db.WithContext(ctx).Create(pr).Error
 }
 
+// GetNextPullRequestNumber 获取仓库下一个PR编号
+func (r *gitRepository) GetNextPullRequestNumber(ctx context.Context, repositoryID uuid.UUID) (int, error) {
+	var number int
+	err := r.db.WithContext(ctx).
+		Model(&models.PullRequest{}).
+		Where("repository_id = ?", repositoryID).
+		Select("COALESCE(MAX(number), 0) + 1").
+		Scan(&number).Error
+	return number, err
+}
+
 // GetPullRequestByID 通过ID获取PR
 func (r *gitRepository) GetPullRequestByID(ctx context.Context, id uuid.UUID) (*models.PullRequest, error) {
 	var pr models.PullRequest
@@ -537,6 +715,34 @@ func (r *gitRepository) ListPullRequests(ctx context.Context, repositoryID uuid.
 	return prs, total, err
 }
 
+// ListProjectPullRequestsByReference 获取项目下所有仓库中标题或源分支匹配正则的PR，不区分大小写
+func (r *gitRepository) ListProjectPullRequestsByReference(ctx context.Context, projectID uuid.UUID, status *models.PullRequestStatus, pattern string, page, pageSize int) ([]models.PullRequest, int64, error) {
+	var prs []models.PullRequest
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.PullRequest{}).
+		Joins("JOIN repositories ON repositories.id = pull_requests.repository_id").
+		Where("repositories.project_id = ? AND repositories.deleted_at IS NULL", projectID).
+		Where("(pull_requests.title ~* ? OR pull_requests.source_branch ~* ?)", pattern, pattern)
+
+	if status != nil {
+		query = query.Where("pull_requests.status = ?", *status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.
+		Order("pull_requests.created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&prs).Error
+
+	return prs, total, err
+}
+
 // UpdatePullRequest 更新PR
 func (r *gitRepository) UpdatePullRequest(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
 	return r.db.WithContext(ctx).
@@ -634,3 +840,63 @@ func (r *gitRepository) UpdatePRReviewStatus(ctx context.Context, pullRequestID
 		Where("pull_request_id = ? AND reviewer_id = ?", pullRequestID, reviewerID).
 		Update("status", status).Error
 }
+
+// 权限校验实现
+
+// writableProjectCondition 用户可在项目下创建和写入仓库：同租户的项目负责人或成员
+const writableProjectCondition = `p.deleted_at IS NULL AND p.tenant_id = ? AND (
+	p.manager_id = ?
+	OR EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = p.id AND pm.user_id = ?)
+)`
+
+// adminProjectCondition 用户可管理项目：同租户的项目负责人，或角色带 project.admin 权限的成员
+const adminProjectCondition = `p.deleted_at IS NULL AND p.tenant_id = ? AND (
+	p.manager_id = ?
+	OR EXISTS (SELECT 1 FROM project_members pm JOIN roles ro ON ro.id = pm.role_id
+		WHERE pm.project_id = p.id AND pm.user_id = ? AND ro.permissions @> '["project.admin"]')
+)`
+
+// adminRepositoryCondition 用户可管理仓库（迁移等）：即可管理仓库所在项目
+const adminRepositoryCondition = `r.deleted_at IS NULL AND ` + adminProjectCondition
+
+// CanReadRepository 检查用户能否读取仓库
+func (r *gitRepository) CanReadRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	return r.canAccessRepository(ctx, repositoryID, readableRepositoryCondition, access.TenantID, access.UserID)
+}
+
+// CanWriteRepository 检查用户能否写入仓库
+func (r *gitRepository) CanWriteRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	return r.canAccessRepository(ctx, repositoryID, writableRepositoryCondition, access.TenantID, access.UserID)
+}
+
+// CanAdminRepository 检查用户能否管理仓库
+func (r *gitRepository) CanAdminRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	return r.canAccessRepository(ctx, repositoryID, adminRepositoryCondition, access.TenantID, access.UserID, access.UserID)
+}
+
+// CanWriteProject 检查用户能否在项目下创建和写入仓库
+func (r *gitRepository) CanWriteProject(ctx context.Context, projectID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM projects p
+		WHERE p.id = ? AND `+writableProjectCondition, projectID, access.TenantID, access.UserID, access.UserID).
+		Scan(&count).Error
+	return count > 0, err
+}
+
+// CanAdminProject 检查用户能否管理项目
+func (r *gitRepository) CanAdminProject(ctx context.Context, projectID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM projects p
+		WHERE p.id = ? AND `+adminProjectCondition, projectID, access.TenantID, access.UserID, access.UserID).
+		Scan(&count).Error
+	return count > 0, err
+}
+
+func (r *gitRepository) canAccessRepository(ctx context.Context, repositoryID uuid.UUID, condition string, args ...interface{}) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM repositories r
+		JOIN projects p ON p.id = r.project_id
+		WHERE r.id = ? AND `+condition, append([]interface{}{repositoryID}, args...)...).
+		Scan(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/shared/quota"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LFSRepository Git LFS数据访问接口
+type LFSRepository interface {
+	// 对象
+	GetObject(ctx context.Context, oid string) (*models.LFSObject, error)
+	CreateObject(ctx context.Context, object *models.LFSObject) error
+
+	// 仓库关联
+	ListLinkedObjects(ctx context.Context, repositoryID uuid.UUID, oids []string) ([]models.RepositoryLFSObject, error)
+	LinkObject(ctx context.Context, repositoryID uuid.UUID, oid string, size int64) (bool, error)
+	UnlinkObjects(ctx context.Context, repositoryID uuid.UUID, oids []string) (int64, error)
+	ListRepositoryObjects(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) ([]models.RepositoryLFSObject, int64, error)
+
+	// 权限与配额
+	CanAccessRepository(ctx context.Context, repositoryID uuid.UUID, access models.LFSAccess, write bool) (bool, error)
+	GetTenantStorageUsage(ctx context.Context, repositoryID uuid.UUID) (*quota.TenantStorage, error)
+
+	// 垃圾回收
+	ListRepositoriesWithObjects(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error)
+	ListLinksBefore(ctx context.Context, repositoryID uuid.UUID, before time.Time) ([]models.RepositoryLFSObject, error)
+	ListOrphanObjects(ctx context.Context, before time.Time, limit int) ([]models.LFSObject, error)
+	DeleteOrphanObject(ctx context.Context, oid string) (bool, error)
+}
+
+// lfsRepository Git LFS数据访问实现
+type lfsRepository struct {
+	db *gorm.DB
+}
+
+// NewLFSRepository 创建Git LFS数据访问实例
+func NewLFSRepository(db *gorm.DB) LFSRepository {
+	return &lfsRepository{
+		db: db,
+	}
+}
+
+// writableRepositoryCondition 用户可写仓库条件：同租户下用户所在项目的仓库
+const writableRepositoryCondition = `r.deleted_at IS NULL AND p.tenant_id = ?
+	AND EXISTS (SELECT 1 FROM project_members pm WHERE pm.project_id = r.project_id AND pm.user_id = ?)`
+
+// GetObject 获取LFS对象
+func (r *lfsRepository) GetObject(ctx context.Context, oid string) (*models.LFSObject, error) {
+	var object models.LFSObject
+	err := r.db.WithContext(ctx).Where("oid = ?", oid).First(&object).Error
+	if err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+// CreateObject 创建LFS对象记录，已存在时忽略
+func (r *lfsRepository) CreateObject(ctx context.Context, object *models.LFSObject) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(object).Error
+}
+
+// ListLinkedObjects 获取已关联到仓库的对象
+func (r *lfsRepository) ListLinkedObjects(ctx context.Context, repositoryID uuid.UUID, oids []string) ([]models.RepositoryLFSObject, error) {
+	var links []models.RepositoryLFSObject
+	if len(oids) == 0 {
+		return links, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND oid IN ?", repositoryID, oids).
+		Find(&links).Error
+	return links, err
+}
+
+// LinkObject 关联对象到仓库并计入仓库大小，返回是否为新关联
+func (r *lfsRepository) LinkObject(ctx context.Context, repositoryID uuid.UUID, oid string, size int64) (bool, error) {
+	linked := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RepositoryLFSObject{RepositoryID: repositoryID, OID: oid, Size: size})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		linked = true
+		return tx.Model(&models.Repository{}).
+			Where("id = ?", repositoryID).
+			Updates(map[string]interface{}{
+				"size":       gorm.Expr("size + ?", size),
+				"lfs_size":   gorm.Expr("lfs_size + ?", size),
+				"updated_at": time.Now(),
+			}).Error
+	})
+	return linked, err
+}
+
+// UnlinkObjects 解除对象与仓库的关联并从仓库大小中扣除，返回释放的字节数
+func (r *lfsRepository) UnlinkObjects(ctx context.Context, repositoryID uuid.UUID, oids []string) (int64, error) {
+	if len(oids) == 0 {
+		return 0, nil
+	}
+
+	var freed int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var removed []models.RepositoryLFSObject
+		err := tx.Clauses(clause.Returning{}).
+			Where("repository_id = ? AND oid IN ?", repositoryID, oids).
+			Delete(&removed).Error
+		if err != nil {
+			return err
+		}
+		for _, link := range removed {
+			freed += link.Size
+		}
+		if freed == 0 {
+			return nil
+		}
+		return tx.Model(&models.Repository{}).
+			Where("id = ?", repositoryID).
+			Updates(map[string]interface{}{
+				"size":       gorm.Expr("GREATEST(size - ?, 0)", freed),
+				"lfs_size":   gorm.Expr("GREATEST(lfs_size - ?, 0)", freed),
+				"updated_at": time.Now(),
+			}).Error
+	})
+	return freed, err
+}
+
+// ListRepositoryObjects 分页获取仓库的LFS对象
+func (r *lfsRepository) ListRepositoryObjects(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) ([]models.RepositoryLFSObject, int64, error) {
+	var links []models.RepositoryLFSObject
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.RepositoryLFSObject{}).Where("repository_id = ?", repositoryID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&links).Error
+	return links, total, err
+}
+
+// CanAccessRepository 判断用户是否可读（write为true时可写）仓库
+func (r *lfsRepository) CanAccessRepository(ctx context.Context, repositoryID uuid.UUID, access models.LFSAccess, write bool) (bool, error) {
+	condition := readableRepositoryCondition
+	if write {
+		condition = writableRepositoryCondition
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`SELECT COUNT(*) FROM repositories r
+		JOIN projects p ON p.id = r.project_id
+		WHERE r.id = ? AND `+condition, repositoryID, access.TenantID, access.UserID).
+		Scan(&count).Error
+	return count > 0, err
+}
+
+// GetTenantStorageUsage 获取仓库所属租户的存储用量和配额，用量包含租户的全部Git仓库和镜像仓库
+func (r *lfsRepository) GetTenantStorageUsage(ctx context.Context, repositoryID uuid.UUID) (*quota.TenantStorage, error) {
+	var tenantID uuid.UUID
+	err := r.db.WithContext(ctx).Raw(`SELECT p.tenant_id FROM repositories r
+		JOIN projects p ON p.id = r.project_id
+		WHERE r.id = ?`, repositoryID).
+		Scan(&tenantID).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库租户失败: %w", err)
+	}
+
+	return quota.GetTenantStorage(ctx, r.db, tenantID)
+}
+
+// ListRepositoriesWithObjects 按ID顺序获取关联了LFS对象的仓库
+func (r *lfsRepository) ListRepositoriesWithObjects(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).
+		Model(&models.RepositoryLFSObject{}).
+		Distinct("repository_id").
+		Where("repository_id > ?", after).
+		Order("repository_id ASC").
+		Limit(limit).
+		Pluck("repository_id", &ids).Error
+	return ids, err
+}
+
+// ListLinksBefore 获取仓库中早于指定时间关联的对象
+func (r *lfsRepository) ListLinksBefore(ctx context.Context, repositoryID uuid.UUID, before time.Time) ([]models.RepositoryLFSObject, error) {
+	var links []models.RepositoryLFSObject
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND created_at < ?", repositoryID, before).
+		Find(&links).Error
+	return links, err
+}
+
+// ListOrphanObjects 获取早于指定时间创建且未关联任何仓库的对象
+func (r *lfsRepository) ListOrphanObjects(ctx context.Context, before time.Time, limit int) ([]models.LFSObject, error) {
+	var objects []models.LFSObject
+	err := r.db.WithContext(ctx).
+		Where("created_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM repository_lfs_objects l WHERE l.oid = lfs_objects.oid)").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&objects).Error
+	return objects, err
+}
+
+// DeleteOrphanObject 删除对象记录，期间被重新关联时不删除
+func (r *lfsRepository) DeleteOrphanObject(ctx context.Context, oid string) (bool, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		DELETE FROM lfs_objects o
+		WHERE o.oid = ?
+		  AND NOT EXISTS (SELECT 1 FROM repository_lfs_objects l WHERE l.oid = o.oid)`, oid)
+	if result.Error != nil {
+		return false, fmt.Errorf("删除LFS对象记录失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
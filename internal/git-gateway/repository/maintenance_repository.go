@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaintenanceRepository 仓库维护数据访问接口
+type MaintenanceRepository interface {
+	// 维护状态
+	RecordPush(ctx context.Context, repositoryID uuid.UUID) error
+	GetState(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryMaintenance, error)
+	UpdateState(ctx context.Context, repositoryID uuid.UUID, updates map[string]interface{}) error
+	ClaimDueRepositories(ctx context.Context, now time.Time, pushThreshold int, maxAge, lease time.Duration, limit int) ([]models.RepositoryMaintenance, error)
+
+	// 维护记录
+	CreateRun(ctx context.Context, run *models.MaintenanceRun) error
+	UpdateRun(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
+	ListRuns(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) ([]models.MaintenanceRun, int64, error)
+	FailInterruptedRuns(ctx context.Context, message string) (int64, error)
+}
+
+// maintenanceRepository 仓库维护数据访问实现
+type maintenanceRepository struct {
+	db *gorm.DB
+}
+
+// NewMaintenanceRepository 创建仓库维护数据访问实例
+func NewMaintenanceRepository(db *gorm.DB) MaintenanceRepository {
+	return &maintenanceRepository{
+		db: db,
+	}
+}
+
+// RecordPush 累加仓库自上次维护以来的推送次数
+func (r *maintenanceRepository) RecordPush(ctx context.Context, repositoryID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "repository_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"pushes_since_run": gorm.Expr("repository_maintenance.pushes_since_run + 1"),
+				"updated_at":       time.Now(),
+			}),
+		}).
+		Create(&models.RepositoryMaintenance{
+			RepositoryID:   repositoryID,
+			PushesSinceRun: 1,
+			Health:         models.RepositoryHealthUnknown,
+			UpdatedAt:      time.Now(),
+		}).Error
+}
+
+// GetState 获取仓库维护状态
+func (r *maintenanceRepository) GetState(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryMaintenance, error) {
+	var state models.RepositoryMaintenance
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ?", repositoryID).
+		First(&state).Error
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// UpdateState 更新仓库维护状态，记录不存在时先创建
+func (r *maintenanceRepository) UpdateState(ctx context.Context, repositoryID uuid.UUID, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RepositoryMaintenance{
+				RepositoryID: repositoryID,
+				Health:       models.RepositoryHealthUnknown,
+				UpdatedAt:    time.Now(),
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.RepositoryMaintenance{}).
+			Where("repository_id = ?", repositoryID).
+			Updates(updates).Error
+	})
+}
+
+// ClaimDueRepositories 领取需要维护的仓库
+// 推送次数达到阈值，或有推送且距上次维护超过maxAge的仓库到期；领取时清零推送计数，
+// 并将retry_after推迟lease，执行中的仓库不会被重复领取。多实例部署时使用 FOR UPDATE SKIP LOCKED
+func (r *maintenanceRepository) ClaimDueRepositories(ctx context.Context, now time.Time, pushThreshold int, maxAge, lease time.Duration, limit int) ([]models.RepositoryMaintenance, error) {
+	var states []models.RepositoryMaintenance
+	err := r.db.WithContext(ctx).Raw(`UPDATE repository_maintenance m
+		SET pushes_since_run = 0, retry_after = ?, updated_at = ?
+		WHERE m.repository_id IN (
+			SELECT repository_id FROM repository_maintenance
+			WHERE (retry_after IS NULL OR retry_after <= ?)
+			AND (pushes_since_run >= ?
+				OR (pushes_since_run > 0 AND (last_run_at IS NULL OR last_run_at <= ?)))
+			ORDER BY pushes_since_run DESC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING m.*`, now.Add(lease), now, now, pushThreshold, now.Add(-maxAge), limit).
+		Scan(&states).Error
+	return states, err
+}
+
+// CreateRun 创建维护记录
+func (r *maintenanceRepository) CreateRun(ctx context.Context, run *models.MaintenanceRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// UpdateRun 更新维护记录
+func (r *maintenanceRepository) UpdateRun(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	return r.db.WithContext(ctx).
+		Model(&models.MaintenanceRun{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// ListRuns 获取仓库的维护记录，按开始时间倒序
+func (r *maintenanceRepository) ListRuns(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) ([]models.MaintenanceRun, int64, error) {
+	var runs []models.MaintenanceRun
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.MaintenanceRun{}).Where("repository_id = ?", repositoryID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("started_at DESC").Offset(offset).Limit(pageSize).Find(&runs).Error
+	return runs, total, err
+}
+
+// FailInterruptedRuns 将服务重启前未完成的维护标记为失败
+func (r *maintenanceRepository) FailInterruptedRuns(ctx context.Context, message string) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&models.MaintenanceRun{}).
+		Where("status = ?", models.MaintenanceStatusRunning).
+		Updates(map[string]interface{}{
+			"status":        models.MaintenanceStatusFailed,
+			"error_message": message,
+			"finished_at":   time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
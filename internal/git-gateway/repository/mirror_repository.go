@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MirrorRepository 仓库导入与镜像数据访问接口
+type MirrorRepository interface {
+	// 导入任务
+	CreateImport(ctx context.Context, imp *models.RepositoryImport) error
+	GetLatestImport(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryImport, error)
+	UpdateImport(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
+	FailInterruptedImports(ctx context.Context, message string) (int64, error)
+
+	// 镜像
+	CreateMirror(ctx context.Context, mirror *models.RepositoryMirror) error
+	GetMirror(ctx context.Context, repositoryID, id uuid.UUID) (*models.RepositoryMirror, error)
+	ListMirrors(ctx context.Context, repositoryID uuid.UUID) ([]models.RepositoryMirror, error)
+	ListEnabledMirrors(ctx context.Context, repositoryID uuid.UUID, direction models.MirrorDirection) ([]models.RepositoryMirror, error)
+	UpdateMirror(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error
+	DeleteMirror(ctx context.Context, id uuid.UUID) error
+	ClaimDuePullMirrors(ctx context.Context, now time.Time, limit int) ([]models.RepositoryMirror, error)
+}
+
+// mirrorRepository 仓库导入与镜像数据访问实现
+type mirrorRepository struct {
+	db *gorm.DB
+}
+
+// NewMirrorRepository 创建仓库导入与镜像数据访问实例
+func NewMirrorRepository(db *gorm.DB) MirrorRepository {
+	return &mirrorRepository{
+		db: db,
+	}
+}
+
+// CreateImport 创建导入任务
+func (r *mirrorRepository) CreateImport(ctx context.Context, imp *models.RepositoryImport) error {
+	return r.db.WithContext(ctx).Create(imp).Error
+}
+
+// GetLatestImport 获取仓库最近一次导入任务
+func (r *mirrorRepository) GetLatestImport(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryImport, error) {
+	var imp models.RepositoryImport
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ?", repositoryID).
+		Order("created_at DESC").
+		First(&imp).Error
+	if err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+// UpdateImport 更新导入任务
+func (r *mirrorRepository) UpdateImport(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&models.RepositoryImport{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// FailInterruptedImports 将服务重启前未完成的导入任务标记为失败
+func (r *mirrorRepository) FailInterruptedImports(ctx context.Context, message string) (int64, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&models.RepositoryImport{}).
+		Where("status IN ?", []models.ImportStatus{models.ImportStatusPending, models.ImportStatusRunning}).
+		Updates(map[string]interface{}{
+			"status":        models.ImportStatusFailed,
+			"error_message": message,
+			"finished_at":   now,
+			"updated_at":    now,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// CreateMirror 创建镜像
+func (r *mirrorRepository) CreateMirror(ctx context.Context, mirror *models.RepositoryMirror) error {
+	return r.db.WithContext(ctx).Create(mirror).Error
+}
+
+// GetMirror 获取仓库下的镜像
+func (r *mirrorRepository) GetMirror(ctx context.Context, repositoryID, id uuid.UUID) (*models.RepositoryMirror, error) {
+	var mirror models.RepositoryMirror
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND repository_id = ?", id, repositoryID).
+		First(&mirror).Error
+	if err != nil {
+		return nil, err
+	}
+	return &mirror, nil
+}
+
+// ListMirrors 获取仓库的全部镜像
+func (r *mirrorRepository) ListMirrors(ctx context.Context, repositoryID uuid.UUID) ([]models.RepositoryMirror, error) {
+	var mirrors []models.RepositoryMirror
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ?", repositoryID).
+		Order("direction ASC, created_at ASC").
+		Find(&mirrors).Error
+	return mirrors, err
+}
+
+// ListEnabledMirrors 获取仓库指定方向的已启用镜像
+func (r *mirrorRepository) ListEnabledMirrors(ctx context.Context, repositoryID uuid.UUID, direction models.MirrorDirection) ([]models.RepositoryMirror, error) {
+	var mirrors []models.RepositoryMirror
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND direction = ? AND enabled = ?", repositoryID, direction, true).
+		Order("created_at ASC").
+		Find(&mirrors).Error
+	return mirrors, err
+}
+
+// UpdateMirror 更新镜像
+func (r *mirrorRepository) UpdateMirror(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return r.db.WithContext(ctx).
+		Model(&models.RepositoryMirror{}).
+		Where("id = ?", id).
+		Updates(updates).Error
+}
+
+// DeleteMirror 删除镜像
+func (r *mirrorRepository) DeleteMirror(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&models.RepositoryMirror{}).Error
+}
+
+// ClaimDuePullMirrors 领取到期的拉取镜像并推迟其下次同步时间
+// 使用 FOR UPDATE SKIP LOCKED，多实例部署时同一镜像只会被一个实例领取
+func (r *mirrorRepository) ClaimDuePullMirrors(ctx context.Context, now time.Time, limit int) ([]models.RepositoryMirror, error) {
+	var mirrors []models.RepositoryMirror
+	err := r.db.WithContext(ctx).Raw(`UPDATE repository_mirrors m
+		SET next_sync_at = ? + make_interval(mins => m.interval_minutes), updated_at = ?
+		WHERE m.id IN (
+			SELECT id FROM repository_mirrors
+			WHERE direction = ? AND enabled = TRUE AND next_sync_at <= ?
+			ORDER BY next_sync_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING m.*`, now, now, models.MirrorDirectionPull, now, limit).
+		Scan(&mirrors).Error
+	return mirrors, err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PushPolicyRepository 推送策略数据访问接口
+type PushPolicyRepository interface {
+	GetPolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID) (*models.PushPolicy, error)
+	UpsertPolicy(ctx context.Context, policy *models.PushPolicy) error
+	DeletePolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID) (bool, error)
+}
+
+// pushPolicyRepository 推送策略数据访问实现
+type pushPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewPushPolicyRepository 创建推送策略数据访问实例
+func NewPushPolicyRepository(db *gorm.DB) PushPolicyRepository {
+	return &pushPolicyRepository{
+		db: db,
+	}
+}
+
+// GetPolicy 获取项目或仓库的推送策略
+func (r *pushPolicyRepository) GetPolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID) (*models.PushPolicy, error) {
+	var policy models.PushPolicy
+	err := r.db.WithContext(ctx).
+		Where("scope_type = ? AND scope_id = ?", scope, scopeID).
+		First(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// UpsertPolicy 创建或整体替换推送策略
+func (r *pushPolicyRepository) UpsertPolicy(ctx context.Context, policy *models.PushPolicy) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "scope_type"}, {Name: "scope_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"enabled", "max_file_size", "forbidden_paths", "forbidden_extensions",
+				"commit_message_pattern", "allowed_email_domains", "secret_scanning",
+				"secret_patterns", "protected_refs", "require_signed_commits", "require_code_owner_approval",
+				"updated_by", "updated_at",
+			}),
+		}, clause.Returning{}).
+		Create(policy).Error
+}
+
+// DeletePolicy 删除推送策略，返回是否存在
+func (r *pushPolicyRepository) DeletePolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("scope_type = ? AND scope_id = ?", scope, scopeID).
+		Delete(&models.PushPolicy{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefAuditRepository 引用审计数据访问接口
+type RefAuditRepository interface {
+	CreateEntries(ctx context.Context, entries []models.RefAuditEntry) error
+	ListEntries(ctx context.Context, repositoryID uuid.UUID, filter *models.RefAuditFilter) ([]models.RefAuditEntry, int64, error)
+	GetLatestRewrite(ctx context.Context, repositoryID uuid.UUID, ref string) (*models.RefAuditEntry, error)
+	ListRefRevisions(ctx context.Context, repositoryID uuid.UUID, ref string, limit int) ([]string, error)
+
+	// 保留引用
+	ListExpiredKeeps(ctx context.Context, now time.Time, limit int) ([]models.RefAuditEntry, error)
+	ReleaseKeeps(ctx context.Context, repositoryID uuid.UUID, sha string, now time.Time) error
+}
+
+// refAuditRepository 引用审计数据访问实现
+type refAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewRefAuditRepository 创建引用审计数据访问实例
+func NewRefAuditRepository(db *gorm.DB) RefAuditRepository {
+	return &refAuditRepository{
+		db: db,
+	}
+}
+
+// CreateEntries 批量写入审计记录
+func (r *refAuditRepository) CreateEntries(ctx context.Context, entries []models.RefAuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&entries).Error
+}
+
+// ListEntries 按条件分页查询审计记录，最新的在前
+func (r *refAuditRepository) ListEntries(ctx context.Context, repositoryID uuid.UUID, filter *models.RefAuditFilter) ([]models.RefAuditEntry, int64, error) {
+	var entries []models.RefAuditEntry
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.RefAuditEntry{}).Where("repository_id = ?", repositoryID)
+	if refs := filter.RefCandidates(); len(refs) > 0 {
+		query = query.Where("ref IN ?", refs)
+	}
+	if filter.RefType != "" {
+		query = query.Where("ref_type = ?", filter.RefType)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Transport != "" {
+		query = query.Where("transport = ?", filter.Transport)
+	}
+	if filter.Forced != nil {
+		query = query.Where("forced = ?", *filter.Forced)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(filter.PageSize).Find(&entries).Error
+	return entries, total, err
+}
+
+// GetLatestRewrite 获取引用最近一次删除或强制推送的记录
+func (r *refAuditRepository) GetLatestRewrite(ctx context.Context, repositoryID uuid.UUID, ref string) (*models.RefAuditEntry, error) {
+	var entry models.RefAuditEntry
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND ref = ?", repositoryID, ref).
+		Where("(forced OR new_sha ~ '^0+$') AND old_sha !~ '^0+$'").
+		Order("created_at DESC, id DESC").
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListRefRevisions 列出审计记录中引用曾经指向的提交，最近的在前，不含全零ID
+func (r *refAuditRepository) ListRefRevisions(ctx context.Context, repositoryID uuid.UUID, ref string, limit int) ([]string, error) {
+	var entries []models.RefAuditEntry
+	err := r.db.WithContext(ctx).
+		Select("old_sha", "new_sha").
+		Where("repository_id = ? AND ref = ?", repositoryID, ref).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var revisions []string
+	for _, entry := range entries {
+		for _, sha := range []string{entry.NewSHA, entry.OldSHA} {
+			if sha != "" && strings.Trim(sha, "0") != "" && !seen[sha] {
+				seen[sha] = true
+				revisions = append(revisions, sha)
+			}
+		}
+	}
+	return revisions, nil
+}
+
+// ListExpiredKeeps 列出保留期已过且没有其他记录仍需保留的提交，每个仓库和提交返回一条
+func (r *refAuditRepository) ListExpiredKeeps(ctx context.Context, now time.Time, limit int) ([]models.RefAuditEntry, error) {
+	var entries []models.RefAuditEntry
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT ON (repository_id, old_sha) *
+		FROM ref_audit_log e
+		WHERE kept AND kept_until <= ?
+			AND NOT EXISTS (
+				SELECT 1 FROM ref_audit_log o
+				WHERE o.repository_id = e.repository_id AND o.old_sha = e.old_sha
+					AND o.kept AND o.kept_until > ?
+			)
+		ORDER BY repository_id, old_sha
+		LIMIT ?`, now, now, limit).Scan(&entries).Error
+	return entries, err
+}
+
+// ReleaseKeeps 保留引用删除后更新对应记录
+func (r *refAuditRepository) ReleaseKeeps(ctx context.Context, repositoryID uuid.UUID, sha string, now time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&models.RefAuditEntry{}).
+		Where("repository_id = ? AND old_sha = ? AND kept AND kept_until <= ?", repositoryID, sha, now).
+		Update("kept", false).Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SigningKeyRepository 签名公钥数据访问接口，签名公钥由IAM服务维护
+type SigningKeyRepository interface {
+	// FindByKeyID 按签名中的密钥标识查找签名公钥，不存在时返回 nil
+	FindByKeyID(ctx context.Context, keyType, keyID string) (*models.SigningKey, error)
+}
+
+// signingKeyRepository 签名公钥数据访问实现
+type signingKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewSigningKeyRepository 创建签名公钥数据访问实例
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	return &signingKeyRepository{
+		db: db,
+	}
+}
+
+// signingKeyRow 签名公钥及所属用户邮箱的查询结果
+type signingKeyRow struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	KeyType     string
+	Fingerprint string
+	PublicKey   string
+	Emails      []byte
+	OwnerEmail  string
+}
+
+// FindByKeyID 按签名中的密钥标识查找签名公钥
+// GPG签名可能由子密钥签发，因此按公钥的全部密钥ID匹配；已删除用户的公钥不参与校验
+func (r *signingKeyRepository) FindByKeyID(ctx context.Context, keyType, keyID string) (*models.SigningKey, error) {
+	keyIDs, err := json.Marshal([]string{keyID})
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []signingKeyRow
+	err = r.db.WithContext(ctx).Raw(`
+	SELECT k.id, k.user_id, k.key_type, k.fingerprint, k.public_key, k.emails, u.email AS owner_email
+	FROM signing_keys k
+	JOIN users u ON u.id = k.user_id AND u.deleted_at IS NULL AND u.is_active
+	WHERE k.key_type = ? AND k.key_ids @> ?::jsonb
+	LIMIT 1`, keyType, string(keyIDs)).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	row := rows[0]
+	key := &models.SigningKey{
+		ID:          row.ID,
+		UserID:      row.UserID,
+		KeyType:     row.KeyType,
+		Fingerprint: row.Fingerprint,
+		PublicKey:   row.PublicKey,
+		OwnerEmail:  row.OwnerEmail,
+	}
+	if len(row.Emails) > 0 {
+		if err := json.Unmarshal(row.Emails, &key.Emails); err != nil {
+			return nil, fmt.Errorf("解析签名公钥邮箱失败: %w", err)
+		}
+	}
+	return key, nil
+}
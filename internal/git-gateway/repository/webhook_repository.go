@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
 	"github.com/google/uuid"
@@ -32,11 +34,15 @@ type WebhookRepository interface {
 	// 投递管理
 	CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
 	GetWebhookDelivery(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error)
-	ListWebhookDeliveries(ctx context.Context, webhookID *uuid.UUID, page, pageSize int) (*models.WebhookDeliveryListResponse, error)
+	ListWebhookDeliveries(ctx context.Context, repositoryID uuid.UUID, webhookID *uuid.UUID, page, pageSize int) (*models.WebhookDeliveryListResponse, error)
 	UpdateWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, updates map[string]interface{}) error
+	ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	CreateWebhookDeliveryAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookDeliveryAttempt, error)
 
 	// 统计信息
 	GetWebhookStatistics(ctx context.Context, repositoryID *uuid.UUID) (*models.WebhookStatistics, error)
+	ListWebhookDeliveryStats(ctx context.Context, repositoryID, webhookID *uuid.UUID) ([]models.WebhookDeliveryStats, error)
 }
 
 // webhookRepository Webhook数据访问实现
@@ -557,28 +563,366 @@ func (r *webhookRepository) DeleteWebhookTrigger(ctx context.Context, triggerID
 	return nil
 }
 
-// 其他方法的实现占位符
+// 投递记录管理实现
+
+// webhookDeliveryColumns 投递记录查询列，可为空的列转为零值
+const webhookDeliveryColumns = `id, webhook_id, trigger_id, repository_id, event_id, event_type,
+	redelivery_of, url, method, status, COALESCE(status_code, 0), COALESCE(request_headers, '{}'),
+	COALESCE(request_body, ''), COALESCE(response_body, ''), COALESCE(duration, 0), attempts,
+	max_attempts, next_retry_at, COALESCE(last_error, ''), created_at, updated_at`
+
+// rowScanner sql.Row 和 sql.Rows 的公共扫描接口
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhookDelivery 扫描一行投递记录
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var headersJSON []byte
+	err := row.Scan(
+		&delivery.ID, &delivery.WebhookID, &delivery.TriggerID, &delivery.RepositoryID,
+		&delivery.EventID, &delivery.EventType, &delivery.RedeliveryOf, &delivery.URL,
+		&delivery.Method, &delivery.Status, &delivery.StatusCode, &headersJSON,
+		&delivery.RequestBody, &delivery.ResponseBody, &delivery.Duration, &delivery.Attempts,
+		&delivery.MaxAttempts, &delivery.NextRetryAt, &delivery.LastError,
+		&delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(headersJSON, &delivery.RequestHeaders); err != nil {
+		return nil, fmt.Errorf("解析请求头失败: %w", err)
+	}
+	return &delivery, nil
+}
+
+// scanWebhookDeliveries 扫描多行投递记录
+func scanWebhookDeliveries(rows *sql.Rows) ([]models.WebhookDelivery, error) {
+	defer rows.Close()
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("扫描投递记录失败: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+// CreateWebhookDelivery 创建投递记录
 func (r *webhookRepository) CreateWebhookDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
-	// TODO: 实现投递记录创建
-	return fmt.Errorf("未实现")
+	query := `
+		INSERT INTO webhook_deliveries (
+			id, webhook_id, trigger_id, repository_id, event_id, event_type,
+			redelivery_of, url, method, status, request_headers, request_body,
+			attempts, max_attempts, next_retry_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+		)`
+
+	headersJSON, err := json.Marshal(delivery.RequestHeaders)
+	if err != nil {
+		return fmt.Errorf("序列化请求头失败: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		delivery.ID, delivery.WebhookID, delivery.TriggerID, delivery.RepositoryID,
+		delivery.EventID, delivery.EventType, delivery.RedeliveryOf, delivery.URL,
+		delivery.Method, delivery.Status, headersJSON, delivery.RequestBody,
+		delivery.Attempts, delivery.MaxAttempts, delivery.NextRetryAt,
+		delivery.CreatedAt, delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("插入投递记录失败: %w", err)
+	}
+
+	return nil
 }
 
+// GetWebhookDelivery 获取投递记录
 func (r *webhookRepository) GetWebhookDelivery(ctx context.Context, deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
-	// TODO: 实现投递记录查询
-	return nil, fmt.Errorf("未实现")
+	query := fmt.Sprintf("SELECT %s FROM webhook_deliveries WHERE id = $1", webhookDeliveryColumns)
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, deliveryID))
+	if err != nil {
+		return nil, fmt.Errorf("查询投递记录失败: %w", err)
+	}
+
+	return delivery, nil
 }
 
-func (r *webhookRepository) ListWebhookDeliveries(ctx context.Context, webhookID *uuid.UUID, page, pageSize int) (*models.WebhookDeliveryListResponse, error) {
-	// TODO: 实现投递记录列表
-	return &models.WebhookDeliveryListResponse{}, nil
+// ListWebhookDeliveries 列出仓库的投递记录，webhookID为空时列出仓库全部投递
+func (r *webhookRepository) ListWebhookDeliveries(ctx context.Context, repositoryID uuid.UUID, webhookID *uuid.UUID, page, pageSize int) (*models.WebhookDeliveryListResponse, error) {
+	whereClause := "WHERE repository_id = $1"
+	args := []interface{}{repositoryID}
+	argIndex := 2
+
+	if webhookID != nil {
+		whereClause += fmt.Sprintf(" AND webhook_id = $%d", argIndex)
+		args = append(args, *webhookID)
+		argIndex++
+	}
+
+	var total int64
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM webhook_deliveries %s", whereClause)
+	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+		return nil, fmt.Errorf("计算投递记录总数失败: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+	listQuery := fmt.Sprintf(`
+		SELECT %s
+		FROM webhook_deliveries
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d`, webhookDeliveryColumns, whereClause, argIndex, argIndex+1)
+	args = append(args, pageSize, offset)
+
+	rows, err := r.db.QueryContext(ctx, listQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查询投递记录列表失败: %w", err)
+	}
+	deliveries, err := scanWebhookDeliveries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	return &models.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
 }
 
+// UpdateWebhookDelivery 更新投递记录
 func (r *webhookRepository) UpdateWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, updates map[string]interface{}) error {
-	// TODO: 实现投递记录更新
-	return fmt.Errorf("未实现")
+	// 定义允许更新的列白名单
+	allowedColumns := map[string]bool{
+		"status":        true,
+		"status_code":   true,
+		"response_body": true,
+		"duration":      true,
+		"attempts":      true,
+		"next_retry_at": true,
+		"last_error":    true,
+		"updated_at":    true,
+	}
+
+	setClause := ""
+	args := []interface{}{}
+	argIndex := 1
+
+	for column, value := range updates {
+		if !allowedColumns[column] {
+			return fmt.Errorf("不允许更新的列: %s", column)
+		}
+
+		if argIndex > 1 {
+			setClause += ", "
+		}
+		setClause += fmt.Sprintf("%s = $%d", column, argIndex)
+		args = append(args, value)
+		argIndex++
+	}
+
+	if setClause == "" {
+		return fmt.Errorf("没有要更新的字段")
+	}
+
+	query := fmt.Sprintf("UPDATE webhook_deliveries SET %s WHERE id = $%d", setClause, argIndex)
+	args = append(args, deliveryID)
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("更新投递记录失败: %w", err)
+	}
+
+	return nil
 }
 
+// ClaimDueWebhookDeliveries 领取到期的投递并将下次重试时间推迟lease，避免发送期间被重复领取
+// 使用 FOR UPDATE SKIP LOCKED，多实例部署时同一投递只会被一个实例领取
+func (r *webhookRepository) ClaimDueWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	query := fmt.Sprintf(`
+		UPDATE webhook_deliveries d
+		SET next_retry_at = $1, updated_at = $2
+		WHERE d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status IN ($3, $4) AND next_retry_at <= $2
+			ORDER BY next_retry_at ASC
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %s`, webhookDeliveryColumns)
+
+	rows, err := r.db.QueryContext(ctx, query,
+		now.Add(lease), now, models.DeliveryStatusPending, models.DeliveryStatusRetrying, limit)
+	if err != nil {
+		return nil, fmt.Errorf("领取到期投递失败: %w", err)
+	}
+	return scanWebhookDeliveries(rows)
+}
+
+// CreateWebhookDeliveryAttempt 记录一次投递尝试
+func (r *webhookRepository) CreateWebhookDeliveryAttempt(ctx context.Context, attempt *models.WebhookDeliveryAttempt) error {
+	query := `
+		INSERT INTO webhook_delivery_attempts (
+			id, delivery_id, attempt, status_code, response_body, duration, error, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		attempt.ID, attempt.DeliveryID, attempt.Attempt, attempt.StatusCode,
+		attempt.ResponseBody, attempt.Duration, attempt.Error, attempt.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("插入投递尝试失败: %w", err)
+	}
+
+	return nil
+}
+
+// ListWebhookDeliveryAttempts 获取投递的全部尝试，按尝试顺序排列
+func (r *webhookRepository) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID uuid.UUID) ([]models.WebhookDeliveryAttempt, error) {
+	query := `
+		SELECT id, delivery_id, attempt, status_code, response_body, duration, error, created_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt ASC`
+
+	attempts := []models.WebhookDeliveryAttempt{}
+	if err := r.db.SelectContext(ctx, &attempts, query, deliveryID); err != nil {
+		return nil, fmt.Errorf("查询投递尝试失败: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// GetWebhookStatistics 获取事件和投递统计信息
 func (r *webhookRepository) GetWebhookStatistics(ctx context.Context, repositoryID *uuid.UUID) (*models.WebhookStatistics, error) {
-	// TODO: 实现统计信息查询
-	return &models.WebhookStatistics{}, nil
+	stats := &models.WebhookStatistics{
+		EventsByType:     make(map[models.WebhookEventType]int64),
+		EventsBySource:   make(map[string]int64),
+		DeliveryByStatus: make(map[models.DeliveryStatus]int64),
+		TriggersByRepo:   make(map[uuid.UUID]int64),
+	}
+
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	if repositoryID != nil {
+		whereClause = "WHERE repository_id = $1"
+		args = append(args, *repositoryID)
+	}
+
+	// 事件总数与处理情况
+	eventQuery := fmt.Sprintf(`
+		SELECT COUNT(*),
+			   COUNT(*) FILTER (WHERE processed),
+			   COUNT(*) FILTER (WHERE error_message IS NOT NULL AND error_message <> ''),
+			   COALESCE(AVG(EXTRACT(EPOCH FROM (processed_at - created_at))) FILTER (WHERE processed_at IS NOT NULL), 0)
+		FROM webhook_events %s`, whereClause)
+	err := r.db.QueryRowContext(ctx, eventQuery, args...).Scan(
+		&stats.TotalEvents, &stats.ProcessedEvents, &stats.FailedEvents, &stats.AverageProcessTime)
+	if err != nil {
+		return nil, fmt.Errorf("统计事件失败: %w", err)
+	}
+
+	groupCounts := []struct {
+		query string
+		apply func(key string, count int64)
+	}{
+		{
+			query: fmt.Sprintf("SELECT event_type, COUNT(*) FROM webhook_events %s GROUP BY event_type", whereClause),
+			apply: func(key string, count int64) { stats.EventsByType[models.WebhookEventType(key)] = count },
+		},
+		{
+			query: fmt.Sprintf("SELECT source, COUNT(*) FROM webhook_events %s GROUP BY source", whereClause),
+			apply: func(key string, count int64) { stats.EventsBySource[key] = count },
+		},
+		{
+			query: fmt.Sprintf("SELECT status, COUNT(*) FROM webhook_deliveries %s GROUP BY status", whereClause),
+			apply: func(key string, count int64) { stats.DeliveryByStatus[models.DeliveryStatus(key)] = count },
+		},
+		{
+			query: fmt.Sprintf("SELECT repository_id::text, COUNT(*) FROM webhook_triggers %s GROUP BY repository_id", whereClause),
+			apply: func(key string, count int64) {
+				if id, err := uuid.Parse(key); err == nil {
+					stats.TriggersByRepo[id] = count
+				}
+			},
+		},
+	}
+	for _, group := range groupCounts {
+		rows, err := r.db.QueryContext(ctx, group.query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("分组统计失败: %w", err)
+		}
+		for rows.Next() {
+			var key string
+			var count int64
+			if err := rows.Scan(&key, &count); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("扫描分组统计失败: %w", err)
+			}
+			group.apply(key, count)
+		}
+		rows.Close()
+	}
+
+	// 成功率只计算已完成的投递
+	succeeded := stats.DeliveryByStatus[models.DeliveryStatusSuccess]
+	if finished := succeeded + stats.DeliveryByStatus[models.DeliveryStatusFailed]; finished > 0 {
+		stats.SuccessRate = float64(succeeded) / float64(finished)
+	}
+
+	recent, err := r.ListWebhookEvents(ctx, &models.WebhookEventFilter{RepositoryID: repositoryID}, 1, 10)
+	if err != nil {
+		return nil, err
+	}
+	stats.RecentEvents = recent.Events
+
+	return stats, nil
+}
+
+// ListWebhookDeliveryStats 按仓库Webhook统计投递情况，可按仓库或Webhook过滤
+func (r *webhookRepository) ListWebhookDeliveryStats(ctx context.Context, repositoryID, webhookID *uuid.UUID) ([]models.WebhookDeliveryStats, error) {
+	whereClause := "WHERE 1=1"
+	args := []interface{}{}
+	argIndex := 1
+
+	if repositoryID != nil {
+		whereClause += fmt.Sprintf(" AND w.repository_id = $%d", argIndex)
+		args = append(args, *repositoryID)
+		argIndex++
+	}
+	if webhookID != nil {
+		whereClause += fmt.Sprintf(" AND w.id = $%d", argIndex)
+		args = append(args, *webhookID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT w.id AS webhook_id, w.repository_id, w.url, w.is_active,
+			   w.consecutive_failures, w.disabled_reason,
+			   COUNT(d.id) AS total_deliveries,
+			   COUNT(d.id) FILTER (WHERE d.status = 'success') AS succeeded,
+			   COUNT(d.id) FILTER (WHERE d.status = 'failed') AS failed,
+			   COUNT(d.id) FILTER (WHERE d.status IN ('pending', 'retrying')) AS pending,
+			   COALESCE(AVG(d.duration) FILTER (WHERE d.attempts > 0), 0) AS average_duration,
+			   MAX(d.created_at) AS last_delivery_at
+		FROM webhooks w
+		LEFT JOIN webhook_deliveries d ON d.webhook_id = w.id
+		%s
+		GROUP BY w.id
+		ORDER BY w.created_at DESC`, whereClause)
+
+	stats := []models.WebhookDeliveryStats{}
+	if err := r.db.SelectContext(ctx, &stats, query, args...); err != nil {
+		return nil, fmt.Errorf("统计Webhook投递失败: %w", err)
+	}
+	for i := range stats {
+		stats[i].ComputeSuccessRate()
+	}
+
+	return stats, nil
 }
@@ -0,0 +1,473 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxCodeownersSize  = 3 << 20 // 超过该大小的CODEOWNERS被忽略
+	teamServiceTimeout = 5 * time.Second
+)
+
+// CodeOwnersService 代码所有者服务接口
+type CodeOwnersService interface {
+	PullRequestListener
+
+	// GetCodeowners 解析分支中的CODEOWNERS文件，ref为空时使用默认分支
+	GetCodeowners(ctx context.Context, repositoryID uuid.UUID, ref string) (*models.Codeowners, error)
+	// GetCodeOwners 计算从base到head变更的文件的代码所有者，CODEOWNERS取自base
+	GetCodeOwners(ctx context.Context, repositoryID uuid.UUID, base, head string) (*models.CodeOwnersReport, error)
+	// GetPullRequestApproval 获取PR的代码所有者批准状态
+	GetPullRequestApproval(ctx context.Context, repositoryID uuid.UUID, number int) (*models.CodeOwnerApproval, error)
+}
+
+// PullRequestListener PR事件监听器，在PR创建后调用
+type PullRequestListener interface {
+	OnPullRequestCreated(ctx context.Context, pr *models.PullRequest)
+}
+
+// TeamDirectory 团队成员查询接口，团队由团队服务维护
+type TeamDirectory interface {
+	// TeamMemberEmails 返回同名团队中有效成员的邮箱
+	TeamMemberEmails(ctx context.Context, name string) ([]string, error)
+}
+
+// codeOwnersService 代码所有者服务实现
+type codeOwnersService struct {
+	repo       repository.GitRepository
+	ownerRepo  repository.CodeOwnerRepository
+	pushPolicy PushPolicyService
+	teams      TeamDirectory // 为空时团队所有者无法解析
+	logger     *zap.Logger
+}
+
+// NewCodeOwnersService 创建代码所有者服务
+func NewCodeOwnersService(repo repository.GitRepository, ownerRepo repository.CodeOwnerRepository, pushPolicy PushPolicyService, teams TeamDirectory, logger *zap.Logger) CodeOwnersService {
+	return &codeOwnersService{
+		repo:       repo,
+		ownerRepo:  ownerRepo,
+		pushPolicy: pushPolicy,
+		teams:      teams,
+		logger:     logger,
+	}
+}
+
+// GetCodeowners 解析分支中的CODEOWNERS文件
+func (s *codeOwnersService) GetCodeowners(ctx context.Context, repositoryID uuid.UUID, ref string) (*models.Codeowners, error) {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = repo.DefaultBranch
+	}
+	if err := models.ValidateRefName(ref); err != nil {
+		return nil, err
+	}
+
+	codeowners, err := s.loadCodeowners(ctx, repo.GitPath, ref)
+	if err != nil {
+		return nil, err
+	}
+	if codeowners == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrCodeownersNotFound, ref)
+	}
+	return codeowners, nil
+}
+
+// GetCodeOwners 计算分支比较中变更文件的代码所有者
+func (s *codeOwnersService) GetCodeOwners(ctx context.Context, repositoryID uuid.UUID, base, head string) (*models.CodeOwnersReport, error) {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := models.ValidateRefName(base); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateRefName(head); err != nil {
+		return nil, err
+	}
+	return s.buildReport(ctx, repo, base, head)
+}
+
+// GetPullRequestApproval 获取PR的代码所有者批准状态
+func (s *codeOwnersService) GetPullRequestApproval(ctx context.Context, repositoryID uuid.UUID, number int) (*models.CodeOwnerApproval, error) {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := s.repo.GetPullRequestByNumber(ctx, repositoryID, number)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: #%d", models.ErrPullRequestNotFound, number)
+		}
+		return nil, fmt.Errorf("获取PR失败: %w", err)
+	}
+
+	required, err := s.pushPolicy.RequiresCodeOwnerApproval(ctx, repositoryID, pr.TargetBranch)
+	if err != nil {
+		return nil, fmt.Errorf("获取代码所有者批准规则失败: %w", err)
+	}
+	report, err := s.buildReport(ctx, repo, pr.TargetBranch, pullRequestHead(pr))
+	if err != nil {
+		return nil, err
+	}
+
+	pending := models.PendingCodeOwnerFiles(report.Files, pr.Reviews, pr.AuthorID)
+	return &models.CodeOwnerApproval{
+		Required:     required,
+		Approved:     len(pending) == 0,
+		PendingFiles: pending,
+		Report:       report,
+	}, nil
+}
+
+// OnPullRequestCreated 为新PR向变更文件的代码所有者请求审查，PR作者除外
+// 请求失败不影响PR创建，只记录日志
+func (s *codeOwnersService) OnPullRequestCreated(ctx context.Context, pr *models.PullRequest) {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, pr.RepositoryID)
+	if err != nil {
+		s.logger.Warn("请求代码所有者审查失败", zap.String("pull_request_id", pr.ID.String()), zap.Error(err))
+		return
+	}
+	report, err := s.buildReport(ctx, repo, pr.TargetBranch, pullRequestHead(pr))
+	if err != nil {
+		s.logger.Warn("请求代码所有者审查失败", zap.String("pull_request_id", pr.ID.String()), zap.Error(err))
+		return
+	}
+
+	requested := 0
+	for _, user := range report.Users {
+		if user.ID == pr.AuthorID {
+			continue
+		}
+		review := &models.PRReview{
+			PullRequestID: pr.ID,
+			ReviewerID:    user.ID,
+			ReviewerName:  user.Username,
+			Status:        models.ReviewStatusPending,
+		}
+		if err := s.repo.CreatePRReview(ctx, review); err != nil {
+			s.logger.Warn("创建代码所有者审查请求失败",
+				zap.String("pull_request_id", pr.ID.String()),
+				zap.String("reviewer_id", user.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		pr.Reviews = append(pr.Reviews, *review)
+		requested++
+	}
+
+	if requested > 0 {
+		s.logger.Info("已请求代码所有者审查",
+			zap.String("pull_request_id", pr.ID.String()),
+			zap.Int("reviewers", requested))
+	}
+}
+
+// buildReport 读取base中的CODEOWNERS，计算base与head合并基点以来变更文件的所有者
+func (s *codeOwnersService) buildReport(ctx context.Context, repo *models.Repository, base, head string) (*models.CodeOwnersReport, error) {
+	report := &models.CodeOwnersReport{
+		Base:  base,
+		Head:  head,
+		Files: []models.FileCodeOwners{},
+		Users: []models.CodeOwnerUser{},
+	}
+
+	codeowners, err := s.loadCodeowners(ctx, repo.GitPath, base)
+	if err != nil {
+		return nil, err
+	}
+	if codeowners == nil {
+		return report, nil
+	}
+	report.CodeownersPath = codeowners.Path
+	report.Errors = codeowners.Errors
+
+	paths, err := s.changedFiles(ctx, repo.GitPath, base, head)
+	if err != nil {
+		return nil, err
+	}
+	for _, filePath := range paths {
+		rule := codeowners.Match(filePath)
+		if rule == nil || len(rule.Owners) == 0 {
+			continue
+		}
+		report.Files = append(report.Files, models.FileCodeOwners{
+			Path:    filePath,
+			Pattern: rule.Pattern,
+			Line:    rule.Line,
+			Owners:  rule.Owners,
+		})
+	}
+
+	if err := s.resolveOwners(ctx, repo.ProjectID, report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// resolveOwners 将所有者解析为项目成员，填充各文件的用户和无法解析的所有者
+func (s *codeOwnersService) resolveOwners(ctx context.Context, projectID uuid.UUID, report *models.CodeOwnersReport) error {
+	owners := make(map[string]models.CodeOwner)
+	var usernames, emails []string
+	teamEmails := make(map[string][]string)
+	for _, file := range report.Files {
+		for _, owner := range file.Owners {
+			if _, seen := owners[owner.Raw]; seen {
+				continue
+			}
+			owners[owner.Raw] = owner
+			switch owner.Type {
+			case models.CodeOwnerTypeUser:
+				usernames = append(usernames, owner.Name)
+			case models.CodeOwnerTypeEmail:
+				emails = append(emails, owner.Name)
+			case models.CodeOwnerTypeTeam:
+				members := s.teamMemberEmails(ctx, owner.Name)
+				teamEmails[owner.Name] = members
+				emails = append(emails, members...)
+			}
+		}
+	}
+	if len(owners) == 0 {
+		return nil
+	}
+
+	users, err := s.ownerRepo.FindProjectMembers(ctx, projectID, usernames, emails)
+	if err != nil {
+		return fmt.Errorf("查找代码所有者失败: %w", err)
+	}
+	byUsername := make(map[string]models.CodeOwnerUser, len(users))
+	byEmail := make(map[string]models.CodeOwnerUser, len(users))
+	for _, user := range users {
+		byUsername[strings.ToLower(user.Username)] = user
+		byEmail[strings.ToLower(user.Email)] = user
+	}
+
+	// ownerUsers 解析一个所有者对应的用户
+	ownerUsers := func(owner models.CodeOwner) []models.CodeOwnerUser {
+		var result []models.CodeOwnerUser
+		switch owner.Type {
+		case models.CodeOwnerTypeUser:
+			if user, ok := byUsername[owner.Name]; ok {
+				result = append(result, user)
+			}
+		case models.CodeOwnerTypeEmail:
+			if user, ok := byEmail[owner.Name]; ok {
+				result = append(result, user)
+			}
+		case models.CodeOwnerTypeTeam:
+			for _, email := range teamEmails[owner.Name] {
+				if user, ok := byEmail[email]; ok {
+					result = append(result, user)
+				}
+			}
+		}
+		return result
+	}
+
+	seenUsers := make(map[uuid.UUID]bool)
+	unresolved := make(map[string]bool)
+	for i := range report.Files {
+		file := &report.Files[i]
+		fileUsers := make(map[uuid.UUID]bool)
+		file.UserIDs = []uuid.UUID{}
+		for _, owner := range file.Owners {
+			resolved := ownerUsers(owner)
+			if len(resolved) == 0 && !unresolved[owner.Raw] {
+				unresolved[owner.Raw] = true
+				report.Unresolved = append(report.Unresolved, owner)
+			}
+			for _, user := range resolved {
+				if !fileUsers[user.ID] {
+					fileUsers[user.ID] = true
+					file.UserIDs = append(file.UserIDs, user.ID)
+				}
+				if !seenUsers[user.ID] {
+					seenUsers[user.ID] = true
+					report.Users = append(report.Users, user)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// teamMemberEmails 查询团队成员邮箱，团队服务不可用时团队视为无法解析
+func (s *codeOwnersService) teamMemberEmails(ctx context.Context, name string) []string {
+	if s.teams == nil {
+		return nil
+	}
+	emails, err := s.teams.TeamMemberEmails(ctx, name)
+	if err != nil {
+		s.logger.Warn("查询团队成员失败", zap.String("team", name), zap.Error(err))
+		return nil
+	}
+	for i, email := range emails {
+		emails[i] = strings.ToLower(email)
+	}
+	return emails
+}
+
+// loadCodeowners 按查找顺序读取ref中的CODEOWNERS，不存在时返回 nil
+func (s *codeOwnersService) loadCodeowners(ctx context.Context, repoPath, ref string) (*models.Codeowners, error) {
+	commitSHA, err := resolveCommit(ctx, repoPath, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filePath := range models.CodeownersPaths {
+		cmd := exec.CommandContext(ctx, "git", "cat-file", "-s", commitSHA+":"+filePath)
+		cmd.Dir = repoPath
+		output, err := cmd.Output()
+		if err != nil {
+			continue // 文件不存在
+		}
+
+		size, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的文件大小: %w", err)
+		}
+
+		codeowners := &models.Codeowners{Rules: []models.CodeownersRule{}}
+		if size > maxCodeownersSize {
+			codeowners.Errors = []models.CodeownersError{{Message: "file exceeds the 3 MB size limit and is ignored"}}
+		} else {
+			cmd = exec.CommandContext(ctx, "git", "cat-file", "blob", commitSHA+":"+filePath)
+			cmd.Dir = repoPath
+			content, err := cmd.Output()
+			if err != nil {
+				return nil, fmt.Errorf("读取%s失败: %w", filePath, err)
+			}
+			codeowners = models.ParseCodeowners(string(content))
+		}
+		codeowners.Path = filePath
+		codeowners.Ref = ref
+		return codeowners, nil
+	}
+	return nil, nil
+}
+
+// changedFiles 列出从base与head的合并基点到head变更的文件，重命名按删除和新增计入
+func (s *codeOwnersService) changedFiles(ctx context.Context, repoPath, base, head string) ([]string, error) {
+	baseSHA, err := resolveCommit(ctx, repoPath, base)
+	if err != nil {
+		return nil, err
+	}
+	headSHA, err := resolveCommit(ctx, repoPath, head)
+	if err != nil {
+		return nil, err
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "diff", "--name-only", "--no-renames", "-z", baseSHA+"..."+headSHA)
+	cmd.Dir = repoPath
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取变更文件失败: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var paths []string
+	for _, filePath := range strings.Split(string(output), "\x00") {
+		if filePath != "" {
+			paths = append(paths, filePath)
+		}
+	}
+	return paths, nil
+}
+
+// pullRequestHead PR源分支在目标仓库中的引用，来自fork的PR使用 refs/pull/<number>/head
+func pullRequestHead(pr *models.PullRequest) string {
+	if pr.SourceRepositoryID != nil && *pr.SourceRepositoryID != pr.RepositoryID {
+		return models.PullRequestHeadRef(pr.Number)
+	}
+	return "refs/heads/" + pr.SourceBranch
+}
+
+// resolveCommit 将引用解析为提交SHA
+func resolveCommit(ctx context.Context, repoPath, ref string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", models.ErrRefNotFound, ref)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// teamServiceDirectory 通过团队服务HTTP接口查询团队成员
+type teamServiceDirectory struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewTeamDirectory 创建团队服务客户端，baseURL为空时返回 nil
+func NewTeamDirectory(baseURL string) TeamDirectory {
+	if baseURL == "" {
+		return nil
+	}
+	return &teamServiceDirectory{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: teamServiceTimeout},
+	}
+}
+
+// teamServiceResponse 团队服务按名称查找团队的响应
+type teamServiceResponse struct {
+	Teams []struct {
+		Members []struct {
+			Status string `json:"status"`
+			User   struct {
+				Email string `json:"email"`
+			} `json:"user"`
+		} `json:"members"`
+	} `json:"teams"`
+}
+
+// TeamMemberEmails 查询同名团队中有效成员的邮箱
+func (d *teamServiceDirectory) TeamMemberEmails(ctx context.Context, name string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL+"/api/v1/teams?name="+url.QueryEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("团队服务返回状态码 %d", resp.StatusCode)
+	}
+
+	var body teamServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("解析团队服务响应失败: %w", err)
+	}
+	var emails []string
+	for _, team := range body.Teams {
+		for _, member := range team.Members {
+			if member.Status == "active" && member.User.Email != "" {
+				emails = append(emails, member.User.Email)
+			}
+		}
+	}
+	return emails, nil
+}
@@ -0,0 +1,567 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 代码索引参数
+const (
+	codeIndexTimeout     = 30 * time.Minute // 单个分支索引的超时时间
+	codeIndexConcurrency = 2                // 同时进行索引的分支数
+	codeIndexBatchSize   = 100              // 每批写入的文档数
+)
+
+// CodeSearchService 代码搜索服务接口
+type CodeSearchService interface {
+	PushListener
+
+	// 搜索
+	Search(ctx context.Context, query *models.CodeSearchQuery, access models.CodeSearchAccess) (*models.CodeSearchResponse, error)
+
+	// 索引管理
+	GetIndexStatus(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) ([]models.CodeSearchBranch, error)
+	UpdateIndexedBranches(ctx context.Context, repositoryID uuid.UUID, branches []string, access models.CodeSearchAccess) ([]models.CodeSearchBranch, error)
+	RebuildIndex(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) error
+	IndexBranch(ctx context.Context, repositoryID uuid.UUID, branch string) error
+}
+
+// PushListener 推送事件监听器，在Webhook事件处理时调用
+type PushListener interface {
+	OnPush(repositoryID uuid.UUID, ref string)
+}
+
+// codeSearchService 代码搜索服务实现
+type codeSearchService struct {
+	repo       repository.GitRepository
+	searchRepo repository.CodeSearchRepository
+	logger     *zap.Logger
+
+	locks     sync.Map      // 分支索引锁，key为 repositoryID/branch
+	semaphore chan struct{} // 限制并发索引数
+}
+
+// NewCodeSearchService 创建代码搜索服务
+func NewCodeSearchService(repo repository.GitRepository, searchRepo repository.CodeSearchRepository, logger *zap.Logger) CodeSearchService {
+	return &codeSearchService{
+		repo:       repo,
+		searchRepo: searchRepo,
+		logger:     logger,
+		semaphore:  make(chan struct{}, codeIndexConcurrency),
+	}
+}
+
+// Search 在用户可读的仓库中搜索代码，返回带高亮位置的匹配行
+func (s *codeSearchService) Search(ctx context.Context, query *models.CodeSearchQuery, access models.CodeSearchAccess) (*models.CodeSearchResponse, error) {
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	matcher, err := query.Matcher()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidSearchQuery, err)
+	}
+
+	hits, total, err := s.searchRepo.SearchDocuments(ctx, query, access)
+	if err != nil {
+		return nil, fmt.Errorf("搜索代码失败: %w", err)
+	}
+
+	results := make([]models.CodeSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		matches, more := models.FindLineMatches(hit.Content, matcher, models.CodeSearchMaxLineMatches)
+		results = append(results, models.CodeSearchResult{
+			RepositoryID:   hit.RepositoryID,
+			RepositoryName: hit.RepositoryName,
+			ProjectID:      hit.ProjectID,
+			Branch:         hit.Branch,
+			Path:           hit.Path,
+			Language:       hit.Language,
+			BlobSHA:        hit.BlobSHA,
+			Matches:        matches,
+			MoreMatches:    more,
+		})
+	}
+
+	return &models.CodeSearchResponse{
+		Results:  results,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+// GetIndexStatus 获取仓库各分支的索引状态
+func (s *codeSearchService) GetIndexStatus(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) ([]models.CodeSearchBranch, error) {
+	if err := s.checkReadable(ctx, repositoryID, access); err != nil {
+		return nil, err
+	}
+	return s.searchRepo.ListSearchBranches(ctx, repositoryID)
+}
+
+// UpdateIndexedBranches 设置默认分支之外参与搜索的分支，新增分支立即开始索引，需要仓库写权限
+func (s *codeSearchService) UpdateIndexedBranches(ctx context.Context, repositoryID uuid.UUID, branches []string, access models.CodeSearchAccess) ([]models.CodeSearchBranch, error) {
+	if err := s.checkWritable(ctx, repositoryID, access); err != nil {
+		return nil, err
+	}
+
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库失败: %w", err)
+	}
+
+	wanted := map[string]bool{repo.DefaultBranch: true}
+	for _, branch := range branches {
+		if err := models.ValidateRefName(branch); err != nil {
+			return nil, err
+		}
+		wanted[branch] = true
+	}
+
+	existing, err := s.searchRepo.ListSearchBranches(ctx, repositoryID)
+	if err != nil {
+		return nil, fmt.Errorf("获取索引分支失败: %w", err)
+	}
+	for _, current := range existing {
+		if wanted[current.Branch] {
+			delete(wanted, current.Branch)
+			continue
+		}
+		if err := s.searchRepo.DeleteSearchBranch(ctx, repositoryID, current.Branch); err != nil {
+			return nil, fmt.Errorf("移除索引分支失败: %w", err)
+		}
+	}
+
+	for branch := range wanted {
+		if err := s.searchRepo.SaveSearchBranch(ctx, newSearchBranch(repositoryID, branch)); err != nil {
+			return nil, fmt.Errorf("添加索引分支失败: %w", err)
+		}
+		s.indexAsync(repositoryID, branch)
+	}
+
+	return s.searchRepo.ListSearchBranches(ctx, repositoryID)
+}
+
+// RebuildIndex 丢弃增量进度，重新索引仓库的全部分支，需要仓库写权限
+func (s *codeSearchService) RebuildIndex(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) error {
+	if err := s.checkWritable(ctx, repositoryID, access); err != nil {
+		return err
+	}
+
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return fmt.Errorf("获取仓库失败: %w", err)
+	}
+	if err := s.searchRepo.SaveSearchBranch(ctx, newSearchBranch(repositoryID, repo.DefaultBranch)); err != nil {
+		return fmt.Errorf("添加索引分支失败: %w", err)
+	}
+
+	branches, err := s.searchRepo.ListSearchBranches(ctx, repositoryID)
+	if err != nil {
+		return fmt.Errorf("获取索引分支失败: %w", err)
+	}
+	for _, branch := range branches {
+		updates := map[string]interface{}{
+			"commit_sha": "",
+			"status":     models.CodeSearchIndexPending,
+		}
+		if err := s.searchRepo.UpdateSearchBranch(ctx, repositoryID, branch.Branch, updates); err != nil {
+			return fmt.Errorf("重置索引状态失败: %w", err)
+		}
+		s.indexAsync(repositoryID, branch.Branch)
+	}
+	return nil
+}
+
+// OnPush 推送后增量更新对应分支的索引
+func (s *codeSearchService) OnPush(repositoryID uuid.UUID, ref string) {
+	branch, ok := strings.CutPrefix(ref, "refs/heads/")
+	if !ok || branch == "" {
+		return
+	}
+	s.indexAsync(repositoryID, branch)
+}
+
+// indexAsync 在后台索引分支
+func (s *codeSearchService) indexAsync(repositoryID uuid.UUID, branch string) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Error("代码索引时发生panic",
+					zap.String("repository_id", repositoryID.String()),
+					zap.String("branch", branch),
+					zap.Any("panic", r))
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), codeIndexTimeout)
+		defer cancel()
+
+		if err := s.IndexBranch(ctx, repositoryID, branch); err != nil {
+			s.logger.Error("代码索引失败",
+				zap.String("repository_id", repositoryID.String()),
+				zap.String("branch", branch),
+				zap.Error(err))
+		}
+	}()
+}
+
+// IndexBranch 将分支索引更新到最新提交
+// 已有索引进度时只处理两次提交之间变更的文件，否则全量索引
+func (s *codeSearchService) IndexBranch(ctx context.Context, repositoryID uuid.UUID, branch string) error {
+	// 同一分支串行索引，连续推送时后一次从前一次的结果继续
+	lock, _ := s.locks.LoadOrStore(repositoryID.String()+"/"+branch, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	select {
+	case s.semaphore <- struct{}{}:
+		defer func() { <-s.semaphore }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return fmt.Errorf("获取仓库失败: %w", err)
+	}
+
+	state, err := s.searchRepo.GetSearchBranch(ctx, repositoryID, branch)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// 只有默认分支会自动加入索引
+		if branch != repo.DefaultBranch {
+			return nil
+		}
+		state = newSearchBranch(repositoryID, branch)
+		if err := s.searchRepo.SaveSearchBranch(ctx, state); err != nil {
+			return fmt.Errorf("添加索引分支失败: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("获取索引状态失败: %w", err)
+	}
+
+	commitSHA, err := s.resolveBranch(ctx, repo.GitPath, branch)
+	if err != nil {
+		// 分支已删除，清空索引但保留配置，重新创建后继续索引
+		s.logger.Info("分支不存在，清空代码索引",
+			zap.String("repository_id", repositoryID.String()),
+			zap.String("branch", branch))
+		if err := s.searchRepo.DeleteBranchDocuments(ctx, repositoryID, branch); err != nil {
+			return fmt.Errorf("清空分支索引失败: %w", err)
+		}
+		return s.searchRepo.UpdateSearchBranch(ctx, repositoryID, branch, map[string]interface{}{
+			"commit_sha":    "",
+			"status":        models.CodeSearchIndexReady,
+			"file_count":    0,
+			"error_message": nil,
+		})
+	}
+
+	if state.CommitSHA == commitSHA && state.Status == models.CodeSearchIndexReady {
+		return nil
+	}
+
+	if err := s.searchRepo.UpdateSearchBranch(ctx, repositoryID, branch, map[string]interface{}{
+		"status": models.CodeSearchIndexIndexing,
+	}); err != nil {
+		return fmt.Errorf("更新索引状态失败: %w", err)
+	}
+
+	start := time.Now()
+	indexed, deleted, err := s.indexCommit(ctx, repo.GitPath, repositoryID, branch, state.CommitSHA, commitSHA)
+	if err != nil {
+		message := err.Error()
+		if updateErr := s.searchRepo.UpdateSearchBranch(context.Background(), repositoryID, branch, map[string]interface{}{
+			"status":        models.CodeSearchIndexFailed,
+			"error_message": message,
+		}); updateErr != nil {
+			s.logger.Error("更新索引状态失败", zap.Error(updateErr))
+		}
+		return err
+	}
+
+	fileCount, err := s.searchRepo.CountDocuments(ctx, repositoryID, branch)
+	if err != nil {
+		return fmt.Errorf("统计索引文件失败: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if err := s.searchRepo.UpdateSearchBranch(ctx, repositoryID, branch, map[string]interface{}{
+		"commit_sha":    commitSHA,
+		"status":        models.CodeSearchIndexReady,
+		"file_count":    fileCount,
+		"error_message": nil,
+		"indexed_at":    now,
+	}); err != nil {
+		return fmt.Errorf("更新索引状态失败: %w", err)
+	}
+
+	s.logger.Info("代码索引完成",
+		zap.String("repository_id", repositoryID.String()),
+		zap.String("branch", branch),
+		zap.String("commit_sha", commitSHA),
+		zap.Int("indexed", indexed),
+		zap.Int("deleted", deleted),
+		zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// indexCommit 将索引从 fromSHA 更新到 toSHA，返回写入和删除的文件数
+func (s *codeSearchService) indexCommit(ctx context.Context, repoPath string, repositoryID uuid.UUID, branch, fromSHA, toSHA string) (int, int, error) {
+	var entries []models.GitTreeEntry
+	var removed []string
+
+	if fromSHA != "" && s.commitExists(ctx, repoPath, fromSHA) {
+		// 增量：强制推送后旧提交不是祖先也可以直接比较两棵树
+		output, err := s.runGit(ctx, repoPath, "diff-tree", "-r", "-z", "--no-renames", fromSHA, toSHA)
+		if err != nil {
+			return 0, 0, fmt.Errorf("比较提交失败: %w", err)
+		}
+		changes, err := models.ParseDiffTreeRaw(output)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, change := range changes {
+			entry := models.GitTreeEntry{Mode: change.NewMode, SHA: change.NewSHA, Size: -1, Path: change.Path}
+			if change.Status == 'D' || !entry.IsRegularFile() {
+				removed = append(removed, change.Path)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	} else {
+		// 全量
+		if err := s.searchRepo.DeleteBranchDocuments(ctx, repositoryID, branch); err != nil {
+			return 0, 0, fmt.Errorf("清空分支索引失败: %w", err)
+		}
+		output, err := s.runGit(ctx, repoPath, "ls-tree", "-r", "-z", "-l", toSHA)
+		if err != nil {
+			return 0, 0, fmt.Errorf("读取文件列表失败: %w", err)
+		}
+		tree, err := models.ParseLsTree(output)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, entry := range tree {
+			if entry.IsRegularFile() && entry.Size <= models.CodeSearchMaxFileSize {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	indexed := 0
+	batch := make([]models.CodeSearchDocument, 0, codeIndexBatchSize)
+	flush := func() error {
+		if err := s.searchRepo.UpsertDocuments(ctx, batch); err != nil {
+			return fmt.Errorf("写入索引失败: %w", err)
+		}
+		indexed += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err := s.readBlobs(ctx, repoPath, entries, func(entry models.GitTreeEntry, content []byte) error {
+		// 二进制文件和超大文件不参与搜索，变为此类文件时移除旧索引
+		if content == nil || models.IsBinaryContent(content) {
+			removed = append(removed, entry.Path)
+			return nil
+		}
+		batch = append(batch, models.CodeSearchDocument{
+			RepositoryID: repositoryID,
+			Branch:       branch,
+			Path:         entry.Path,
+			BlobSHA:      entry.SHA,
+			Language:     models.DetectLanguage(entry.Path),
+			Size:         int64(len(content)),
+			Content:      strings.ToValidUTF8(string(content), "�"),
+			IndexedAt:    time.Now().UTC(),
+		})
+		if len(batch) == codeIndexBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := flush(); err != nil {
+		return 0, 0, err
+	}
+
+	for start := 0; start < len(removed); start += codeIndexBatchSize {
+		end := start + codeIndexBatchSize
+		if end > len(removed) {
+			end = len(removed)
+		}
+		if err := s.searchRepo.DeleteDocuments(ctx, repositoryID, branch, removed[start:end]); err != nil {
+			return 0, 0, fmt.Errorf("删除索引失败: %w", err)
+		}
+	}
+
+	return indexed, len(removed), nil
+}
+
+// readBlobs 通过 git cat-file --batch 批量读取文件内容
+// 超过大小上限的文件以nil内容回调
+func (s *codeSearchService) readBlobs(ctx context.Context, repoPath string, entries []models.GitTreeEntry, fn func(models.GitTreeEntry, []byte) error) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = repoPath
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("创建git输入管道失败: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("创建git输出管道失败: %w", err)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动git cat-file失败: %w", err)
+	}
+
+	// 写入和读取并行，避免管道缓冲区写满后互相等待
+	go func() {
+		defer stdin.Close()
+		w := bufio.NewWriter(stdin)
+		for _, entry := range entries {
+			if _, err := w.WriteString(entry.SHA + "\n"); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}()
+
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	readErr := func() error {
+		for _, entry := range entries {
+			header, err := reader.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("读取对象头失败: %w", err)
+			}
+			fields := strings.Fields(header)
+			if len(fields) != 3 {
+				return fmt.Errorf("对象 %s 读取失败: %s", entry.SHA, strings.TrimSpace(header))
+			}
+			size, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				return fmt.Errorf("无效的对象大小: %w", err)
+			}
+
+			var content []byte
+			if size <= models.CodeSearchMaxFileSize {
+				content = make([]byte, size)
+				if _, err := io.ReadFull(reader, content); err != nil {
+					return fmt.Errorf("读取对象内容失败: %w", err)
+				}
+			} else if _, err := io.CopyN(io.Discard, reader, size); err != nil {
+				return fmt.Errorf("读取对象内容失败: %w", err)
+			}
+			if _, err := reader.Discard(1); err != nil { // 内容后的换行符
+				return fmt.Errorf("读取对象内容失败: %w", err)
+			}
+
+			if err := fn(entry, content); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+
+	if readErr != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return readErr
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git cat-file执行失败: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// resolveBranch 获取分支最新提交
+func (s *codeSearchService) resolveBranch(ctx context.Context, repoPath, branch string) (string, error) {
+	output, err := s.runGit(ctx, repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// commitExists 判断提交是否仍存在（强制推送并gc后可能被清理）
+func (s *codeSearchService) commitExists(ctx context.Context, repoPath, sha string) bool {
+	_, err := s.runGit(ctx, repoPath, "cat-file", "-e", sha+"^{commit}")
+	return err == nil
+}
+
+// runGit 执行git命令并返回标准输出
+func (s *codeSearchService) runGit(ctx context.Context, repoPath string, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
+
+// checkReadable 校验用户可读仓库，不可读时与不存在返回相同错误
+func (s *codeSearchService) checkReadable(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) error {
+	readable, err := s.searchRepo.CanReadRepository(ctx, repositoryID, access)
+	if err != nil {
+		return fmt.Errorf("检查仓库权限失败: %w", err)
+	}
+	if !readable {
+		return models.ErrRepositoryNotReadable
+	}
+	return nil
+}
+
+// checkWritable 校验用户能管理仓库索引，不可读的仓库按不存在处理
+func (s *codeSearchService) checkWritable(ctx context.Context, repositoryID uuid.UUID, access models.CodeSearchAccess) error {
+	if err := s.checkReadable(ctx, repositoryID, access); err != nil {
+		return err
+	}
+	writable, err := s.searchRepo.CanWriteRepository(ctx, repositoryID, access)
+	if err != nil {
+		return fmt.Errorf("检查仓库权限失败: %w", err)
+	}
+	if !writable {
+		return models.ErrRepositoryAccessDenied
+	}
+	return nil
+}
+
+// newSearchBranch 创建待索引的分支记录
+func newSearchBranch(repositoryID uuid.UUID, branch string) *models.CodeSearchBranch {
+	now := time.Now().UTC()
+	return &models.CodeSearchBranch{
+		RepositoryID: repositoryID,
+		Branch:       branch,
+		Status:       models.CodeSearchIndexPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 结构化差异。
+// 先用 --raw --numstat 列出全部文件及增删行数。numstat 仍要逐个比较文件内容，
+// 但不生成和传输补丁文本；之后只为当前页、未超过行数限制的文本文件生成 -p 差异并解析为差异块。
+// 超大的生成文件因此不会被解析和返回，只出现在列表中；需要时按 path 单独查询。
+
+// GetCommitDiff 获取提交相对第一个父提交的差异，根提交与空树比较
+func (s *gitService) GetCommitDiff(ctx context.Context, repositoryID uuid.UUID, sha string, opts *models.DiffOptions) (*models.GitDiff, error) {
+	if err := models.ValidateRefName(sha); err != nil {
+		return nil, err
+	}
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := s.resolveGitCommit(ctx, repo.GitPath, sha)
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.resolveDiffParent(ctx, repo.GitPath, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getGitDiff(ctx, repo.GitPath, from, to, opts)
+}
+
+// CompareBranches 比较两个分支、标签或提交
+func (s *gitService) CompareBranches(ctx context.Context, repositoryID uuid.UUID, base, head string, opts *models.DiffOptions) (*models.GitDiff, error) {
+	if err := models.ValidateRefName(base); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateRefName(head); err != nil {
+		return nil, err
+	}
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析为SHA，翻页期间分支移动不会导致各页内容不一致
+	from, err := s.resolveGitCommit(ctx, repo.GitPath, base)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.resolveGitCommit(ctx, repo.GitPath, head)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getGitDiff(ctx, repo.GitPath, from, to, opts)
+}
+
+// resolveDiffParent 获取提交的第一个父提交，根提交返回空树
+func (s *gitService) resolveDiffParent(ctx context.Context, repoPath, sha string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", sha+"^")
+	cmd.Dir = repoPath
+	if output, err := cmd.Output(); err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	// 空树的ID取决于仓库的哈希算法
+	cmd = exec.CommandContext(ctx, "git", "hash-object", "-t", "tree", "--stdin")
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader("")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get empty tree: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// getGitDiff 获取两个提交之间的结构化差异
+func (s *gitService) getGitDiff(ctx context.Context, repoPath, from, to string, opts *models.DiffOptions) (*models.GitDiff, error) {
+	if opts == nil {
+		opts = &models.DiffOptions{}
+	}
+	if err := opts.Normalize(); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("Getting git diff",
+		zap.String("repo_path", repoPath),
+		zap.String("from", from),
+		zap.String("to", to))
+
+	args := append([]string{"-c", "core.quotePath=false", "diff", "--raw", "--numstat", "-z", "--no-abbrev", "--no-ext-diff"}, opts.GitArgs()...)
+	args = append(args, from, to)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diff: %w", err)
+	}
+
+	files, err := models.ParseDiffStat(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse diff: %w", err)
+	}
+
+	diff := &models.GitDiff{
+		FromSHA:  from,
+		ToSHA:    to,
+		Page:     opts.Page,
+		PageSize: opts.PageSize,
+	}
+	for _, file := range files {
+		diff.TotalAdded += file.AddedLines
+		diff.TotalDeleted += file.DeletedLines
+	}
+
+	if opts.Path != "" {
+		files = filterDiffFiles(files, opts.Path)
+	}
+	diff.TotalFiles = len(files)
+
+	start := (opts.Page - 1) * opts.PageSize
+	if start > len(files) {
+		start = len(files)
+	}
+	end := min(start+opts.PageSize, len(files))
+	diff.Files = files[start:end]
+	diff.HasMore = end < len(files)
+
+	if err := s.loadDiffHunks(ctx, repoPath, from, to, opts, diff.Files); err != nil {
+		return nil, err
+	}
+	return diff, nil
+}
+
+// filterDiffFiles 只保留新路径或旧路径与 path 相同的文件
+func filterDiffFiles(files []models.DiffFile, path string) []models.DiffFile {
+	var matched []models.DiffFile
+	for _, file := range files {
+		if file.Path == path || (file.OldPath != nil && *file.OldPath == path) {
+			matched = append(matched, file)
+		}
+	}
+	return matched
+}
+
+// loadDiffHunks 为未折叠的文本文件生成差异块。
+// 单独查询文件时只受 max_lines 限制，否则超过单文件上限或整页行数预算的文件折叠
+func (s *gitService) loadDiffHunks(ctx context.Context, repoPath, from, to string, opts *models.DiffOptions, files []models.DiffFile) error {
+	var pathspecs []string
+	budget := models.DiffPageLineBudget
+	for i := range files {
+		file := &files[i]
+		if file.Binary {
+			continue
+		}
+
+		changed := int(file.AddedLines + file.DeletedLines)
+		if opts.Path == "" && (changed > opts.MaxLines || changed > budget) {
+			file.Collapsed = true
+			continue
+		}
+		budget -= changed
+
+		pathspecs = append(pathspecs, file.Path)
+		if file.OldPath != nil {
+			pathspecs = append(pathspecs, *file.OldPath)
+		}
+	}
+	if len(pathspecs) == 0 {
+		return nil
+	}
+
+	// 路径按字面匹配，重命名和复制需要同时包含新旧路径才能被识别
+	args := []string{"--literal-pathspecs", "-c", "core.quotePath=false", "diff", "-p", "--no-color", "--no-ext-diff", "--src-prefix=a/", "--dst-prefix=b/"}
+	args = append(args, opts.GitArgs()...)
+	args = append(args, fmt.Sprintf("-U%d", *opts.Context), from, to, "--")
+	args = append(args, pathspecs...)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get diff: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to get diff: %w", err)
+	}
+
+	patches, parseErr := models.ParseDiffPatch(stdout, opts.MaxLines)
+	if parseErr != nil {
+		// 解析提前失败时git可能仍在写输出，结束进程以免Wait一直阻塞
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("failed to parse diff: %w", parseErr)
+	}
+	// 读完剩余输出，Wait 需要git写完后才能返回
+	io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to get diff: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	byPath := make(map[string]*models.DiffPatch, len(patches))
+	for i := range patches {
+		byPath[patches[i].Path] = &patches[i]
+	}
+	for i := range files {
+		file := &files[i]
+		patch, ok := byPath[file.Path]
+		if !ok || file.Binary || file.Collapsed {
+			continue
+		}
+		file.Binary = patch.Binary
+		file.Truncated = patch.Truncated
+		file.Hunks = patch.Hunks
+		if opts.WordDiff {
+			models.ApplyWordDiff(file.Hunks)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,663 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Fork管理实现
+//
+// fork仓库通过 objects/info/alternates 借用上游的对象库，只保存自身新增的对象。
+// 上游删除前会先将其fork解除共享（见 dissociateForks），避免fork引用的对象随上游一起消失。
+
+// ForkRepository 将仓库fork到指定项目
+func (s *gitService) ForkRepository(ctx context.Context, sourceID uuid.UUID, req *models.ForkRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error) {
+	source, err := getRepositoryOrNotFound(ctx, s.repo, sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	projectID, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID: %w", err)
+	}
+
+	// 需要能读取上游，并能在同租户的目标项目下创建仓库
+	if err := s.checkRepositoryAccess(ctx, source.ID, access, false); err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectWritable(ctx, projectID, access); err != nil {
+		return nil, err
+	}
+
+	name := source.Name
+	if req.Name != nil && *req.Name != "" {
+		name = *req.Name
+	}
+
+	visibility, err := models.ResolveForkVisibility(source.Visibility, req.Visibility)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.repo.GetRepositoryByProjectAndName(ctx, projectID, name); err == nil && existing != nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrRepositoryNameTaken, name)
+	}
+
+	description := source.Description
+	if req.Description != nil {
+		description = req.Description
+	}
+
+	repoPath := filepath.Join(s.gitRoot, projectID.String(), name+".git")
+	fork := &models.Repository{
+		ProjectID:     projectID,
+		Name:          name,
+		Description:   description,
+		Visibility:    visibility,
+		Status:        models.RepositoryStatusActive,
+		DefaultBranch: source.DefaultBranch,
+		GitPath:       repoPath,
+		CloneURL:      s.generateCloneURL(projectID, name),
+		SSHURL:        s.generateSSHURL(projectID, name),
+		ForkedFromID:  &source.ID,
+	}
+
+	if err := s.repo.CreateRepository(ctx, fork); err != nil {
+		return nil, fmt.Errorf("failed to create repository record: %w", err)
+	}
+
+	shared, err := s.cloneForkRepository(ctx, source.GitPath, repoPath)
+	if err != nil {
+		// 回滚数据库记录
+		s.repo.DeleteRepository(ctx, fork.ID)
+		return nil, fmt.Errorf("failed to clone repository: %w", err)
+	}
+	fork.ObjectsShared = shared
+
+	if err := installGitHooks(repoPath, s.config.Git.HookBaseURL, s.config.Git.HookSecret, fork.ID); err != nil {
+		s.logger.Warn("Failed to install git hooks",
+			zap.String("repository_id", fork.ID.String()),
+			zap.Error(err))
+	}
+
+	if err := s.repo.UpdateRepository(ctx, fork.ID, map[string]interface{}{
+		"objects_shared": shared,
+		"size":           source.Size,
+	}); err != nil {
+		s.logger.Error("Failed to update fork repository", zap.Error(err))
+	}
+
+	if err := s.createForkBranches(ctx, fork); err != nil {
+		s.logger.Error("Failed to create fork branch records", zap.Error(err))
+	}
+	s.updateRepositoryBranchCount(ctx, fork.ID)
+	s.updateRepositoryCommitCount(ctx, fork.ID)
+
+	if err := s.repo.UpdateForkCount(ctx, source.ID, 1); err != nil {
+		s.logger.Error("Failed to update fork count", zap.Error(err))
+	}
+
+	s.logger.Info("Repository forked successfully",
+		zap.String("source_repository_id", source.ID.String()),
+		zap.String("repository_id", fork.ID.String()),
+		zap.String("user_id", access.UserID.String()),
+		zap.Bool("objects_shared", shared))
+
+	return s.repo.GetRepositoryByID(ctx, fork.ID)
+}
+
+// ListForks 获取仓库的直接fork列表
+func (s *gitService) ListForks(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) (*models.RepositoryListResponse, error) {
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+
+	repos, total, err := s.repo.ListForks(ctx, repositoryID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RepositoryListResponse{
+		Repositories: repos,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+	}, nil
+}
+
+// SyncFork 将上游默认分支同步到fork的分支：能快进则快进，否则按策略创建合并提交
+func (s *gitService) SyncFork(ctx context.Context, forkID uuid.UUID, req *models.SyncForkRequest, access models.RepositoryAccess) (*models.ForkSyncResult, error) {
+	fork, err := getRepositoryOrNotFound(ctx, s.repo, forkID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRepositoryAccess(ctx, fork.ID, access, true); err != nil {
+		return nil, err
+	}
+	if fork.ForkedFromID == nil {
+		return nil, models.ErrNotAFork
+	}
+	if err := fork.CheckWritable(); err != nil {
+		return nil, err
+	}
+	if err := req.Normalize(fork.DefaultBranch); err != nil {
+		return nil, err
+	}
+
+	upstream, err := getRepositoryOrNotFound(ctx, s.repo, *fork.ForkedFromID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upstream repository: %w", err)
+	}
+	// 上游可能在fork之后改为私有或迁移到其他项目，同步前重新校验读权限
+	if err := s.checkRepositoryAccess(ctx, upstream.ID, access, false); err != nil {
+		return nil, fmt.Errorf("upstream repository: %w", err)
+	}
+
+	result := &models.ForkSyncResult{
+		Branch:         req.Branch,
+		UpstreamBranch: upstream.DefaultBranch,
+	}
+
+	// 将上游分支取到fork的暂存引用，共享对象时只需传输引用
+	upstreamRef := models.UpstreamRefPrefix + upstream.DefaultBranch
+	if err := s.fetchGitRef(ctx, fork.GitPath, upstream.GitPath, "refs/heads/"+upstream.DefaultBranch, upstreamRef); err != nil {
+		return nil, err
+	}
+
+	if result.UpstreamSHA, err = s.resolveGitCommit(ctx, fork.GitPath, upstreamRef); err != nil {
+		return nil, err
+	}
+	if result.BeforeSHA, err = s.resolveGitCommit(ctx, fork.GitPath, "refs/heads/"+req.Branch); err != nil {
+		return nil, err
+	}
+	result.AfterSHA = result.BeforeSHA
+
+	if result.AheadBy, result.BehindBy, err = s.countAheadBehind(ctx, fork.GitPath, result.BeforeSHA, result.UpstreamSHA); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case result.BehindBy == 0:
+		result.Status = models.ForkSyncStatusUpToDate
+		return result, nil
+
+	case result.AheadBy == 0:
+		// 使用旧值校验，防止与并发推送互相覆盖
+		cmd := exec.CommandContext(ctx, "git", "update-ref", "refs/heads/"+req.Branch, result.UpstreamSHA, result.BeforeSHA)
+		cmd.Dir = fork.GitPath
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to fast-forward branch: %s", string(output))
+		}
+		result.Status = models.ForkSyncStatusFastForwarded
+		result.AfterSHA = result.UpstreamSHA
+
+	case req.Strategy == models.ForkSyncStrategyFastForward:
+		return result, fmt.Errorf("%w: %d commits ahead, %d commits behind", models.ErrForkDiverged, result.AheadBy, result.BehindBy)
+
+	default:
+		message := fmt.Sprintf("Merge branch '%s' of upstream into '%s'", upstream.DefaultBranch, req.Branch)
+		mergeSHA, conflicts, err := s.mergeIntoBareBranch(ctx, fork.GitPath, req.Branch, result.UpstreamSHA, message)
+		if err != nil {
+			result.Conflicts = conflicts
+			return result, err
+		}
+		result.Status = models.ForkSyncStatusMerged
+		result.AfterSHA = mergeSHA
+	}
+
+	s.recordRefUpdates(ctx, fork, []models.RefUpdate{{
+		OldSHA: result.BeforeSHA,
+		NewSHA: result.AfterSHA,
+		Ref:    "refs/heads/" + req.Branch,
+	}})
+	s.repo.UpdateBranch(ctx, fork.ID, req.Branch, map[string]interface{}{
+		"commit_sha": result.AfterSHA,
+	})
+	s.updateRepositoryCommitCount(ctx, fork.ID)
+
+	s.logger.Info("Fork synced with upstream",
+		zap.String("repository_id", fork.ID.String()),
+		zap.String("upstream_repository_id", upstream.ID.String()),
+		zap.String("branch", req.Branch),
+		zap.String("status", string(result.Status)))
+
+	return result, nil
+}
+
+// CreatePullRequest 创建PR，源分支可以来自本仓库或本仓库的直接fork
+func (s *gitService) CreatePullRequest(ctx context.Context, repositoryID uuid.UUID, req *models.CreatePullRequestRequest, access models.RepositoryAccess) (*models.PullRequest, error) {
+	target, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRepositoryAccess(ctx, target.ID, access, false); err != nil {
+		return nil, err
+	}
+	if err := target.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	if err := models.ValidateRefName(req.SourceBranch); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateRefName(req.TargetBranch); err != nil {
+		return nil, err
+	}
+	if _, err := s.resolveGitCommit(ctx, target.GitPath, "refs/heads/"+req.TargetBranch); err != nil {
+		return nil, err
+	}
+
+	source := target
+	if req.SourceRepositoryID != nil {
+		sourceID, err := uuid.Parse(*req.SourceRepositoryID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid source repository ID", models.ErrInvalidPullRequestSource)
+		}
+		if sourceID != target.ID {
+			if source, err = getRepositoryOrNotFound(ctx, s.repo, sourceID); err != nil {
+				return nil, fmt.Errorf("failed to get source repository: %w", err)
+			}
+			if err := s.checkRepositoryAccess(ctx, source.ID, access, false); err != nil {
+				return nil, fmt.Errorf("source repository: %w", err)
+			}
+			// 只允许从直接fork向其上游发起PR
+			if source.ForkedFromID == nil || *source.ForkedFromID != target.ID {
+				return nil, fmt.Errorf("%w: source repository is not a fork of the target", models.ErrInvalidPullRequestSource)
+			}
+		}
+	}
+
+	if source.ID == target.ID && req.SourceBranch == req.TargetBranch {
+		return nil, fmt.Errorf("%w: source and target branch are the same", models.ErrInvalidPullRequestSource)
+	}
+	if _, err := s.resolveGitCommit(ctx, source.GitPath, "refs/heads/"+req.SourceBranch); err != nil {
+		return nil, err
+	}
+
+	number, err := s.repo.GetNextPullRequestNumber(ctx, target.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate pull request number: %w", err)
+	}
+
+	pr := &models.PullRequest{
+		RepositoryID: target.ID,
+		Number:       number,
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       models.PullRequestStatusOpen,
+		SourceBranch: req.SourceBranch,
+		TargetBranch: req.TargetBranch,
+		AuthorID:     access.UserID,
+		AuthorName:   req.AuthorName,
+		AuthorEmail:  req.AuthorEmail,
+	}
+
+	// fork的提交取到上游的 refs/pull/<number>/head，审查和合并无需访问fork仓库
+	// 上游不借用fork的对象库，fork被删除后PR仍然完整
+	if source.ID != target.ID {
+		pr.SourceRepositoryID = &source.ID
+		if err := s.fetchGitRef(ctx, target.GitPath, source.GitPath, "refs/heads/"+req.SourceBranch, models.PullRequestHeadRef(number)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.CreatePullRequest(ctx, pr); err != nil {
+		if pr.SourceRepositoryID != nil {
+			s.deleteGitRef(ctx, target.GitPath, models.PullRequestHeadRef(number))
+		}
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+
+	s.logger.Info("Pull request created",
+		zap.String("repository_id", target.ID.String()),
+		zap.String("source_repository_id", source.ID.String()),
+		zap.Int("number", number))
+
+	for _, listener := range s.prListeners {
+		listener.OnPullRequestCreated(ctx, pr)
+	}
+
+	return pr, nil
+}
+
+// ListPullRequests 获取仓库的PR列表，可按状态过滤
+func (s *gitService) ListPullRequests(ctx context.Context, repositoryID uuid.UUID, status *models.PullRequestStatus, page, pageSize int) (*models.PullRequestListResponse, error) {
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+
+	prs, total, err := s.repo.ListPullRequests(ctx, repositoryID, status, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	return &models.PullRequestListResponse{
+		PullRequests: prs,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+	}, nil
+}
+
+// ListProjectPullRequests 获取项目下所有仓库中标题或源分支引用了任务编号的PR，可按状态过滤
+func (s *gitService) ListProjectPullRequests(ctx context.Context, projectID uuid.UUID, status *models.PullRequestStatus, reference string, page, pageSize int) (*models.PullRequestListResponse, error) {
+	pattern, err := models.PullRequestReferencePattern(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	prs, total, err := s.repo.ListProjectPullRequestsByReference(ctx, projectID, status, pattern, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	return &models.PullRequestListResponse{
+		PullRequests: prs,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+	}, nil
+}
+
+// checkRepositoryAccess 校验用户对仓库的读写权限，不可读时与不存在返回相同错误
+func (s *gitService) checkRepositoryAccess(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess, write bool) error {
+	if err := requireRepositoryReadable(ctx, s.repo, repositoryID, access); err != nil {
+		return err
+	}
+	if !write {
+		return nil
+	}
+	return requireRepositoryWritable(ctx, s.repo, repositoryID, access)
+}
+
+// checkProjectWritable 校验用户能在同租户的项目下创建和写入仓库
+func (s *gitService) checkProjectWritable(ctx context.Context, projectID uuid.UUID, access models.RepositoryAccess) error {
+	return requireProjectWritable(ctx, s.repo, projectID, access)
+}
+
+// getRepositoryOrNotFound 获取仓库，记录不存在时返回 ErrRepositoryNotFound
+func getRepositoryOrNotFound(ctx context.Context, repo repository.GitRepository, id uuid.UUID) (*models.Repository, error) {
+	found, err := repo.GetRepositoryByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", models.ErrRepositoryNotFound, id)
+		}
+		return nil, fmt.Errorf("获取仓库失败: %w", err)
+	}
+	return found, nil
+}
+
+// requireRepositoryReadable 校验用户能读取仓库，不可读时与不存在返回相同错误
+func requireRepositoryReadable(ctx context.Context, repo repository.GitRepository, repositoryID uuid.UUID, access models.RepositoryAccess) error {
+	readable, err := repo.CanReadRepository(ctx, repositoryID, access)
+	if err != nil {
+		return fmt.Errorf("failed to check repository access: %w", err)
+	}
+	if !readable {
+		return fmt.Errorf("%w: %s", models.ErrRepositoryNotFound, repositoryID)
+	}
+	return nil
+}
+
+// requireRepositoryWritable 校验用户能写入可读的仓库，调用方需先确认可读
+func requireRepositoryWritable(ctx context.Context, repo repository.GitRepository, repositoryID uuid.UUID, access models.RepositoryAccess) error {
+	writable, err := repo.CanWriteRepository(ctx, repositoryID, access)
+	if err != nil {
+		return fmt.Errorf("failed to check repository access: %w", err)
+	}
+	if !writable {
+		return models.ErrRepositoryAccessDenied
+	}
+	return nil
+}
+
+// requireProjectWritable 校验用户能在同租户的项目下创建仓库，供不持有gitService的服务共用
+func requireProjectWritable(ctx context.Context, repo repository.GitRepository, projectID uuid.UUID, access models.RepositoryAccess) error {
+	writable, err := repo.CanWriteProject(ctx, projectID, access)
+	if err != nil {
+		return fmt.Errorf("failed to check project access: %w", err)
+	}
+	if !writable {
+		return fmt.Errorf("%w: %s", models.ErrProjectAccessDenied, projectID)
+	}
+	return nil
+}
+
+// requireProjectAdmin 校验用户是项目负责人或项目管理员
+func requireProjectAdmin(ctx context.Context, repo repository.GitRepository, projectID uuid.UUID, access models.RepositoryAccess) error {
+	admin, err := repo.CanAdminProject(ctx, projectID, access)
+	if err != nil {
+		return fmt.Errorf("failed to check project access: %w", err)
+	}
+	if !admin {
+		return fmt.Errorf("%w: %s", models.ErrProjectAccessDenied, projectID)
+	}
+	return nil
+}
+
+// cloneForkRepository 克隆fork仓库，优先通过alternates共享上游对象，失败时退回完整复制
+func (s *gitService) cloneForkRepository(ctx context.Context, sourcePath, forkPath string) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(forkPath), 0755); err != nil {
+		return false, err
+	}
+
+	shared := true
+	cmd := exec.CommandContext(ctx, "git", "clone", "--bare", "--shared", sourcePath, forkPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		s.logger.Warn("Shared clone failed, falling back to full copy",
+			zap.String("source_path", sourcePath),
+			zap.String("output", string(output)))
+		os.RemoveAll(forkPath)
+
+		shared = false
+		cmd = exec.CommandContext(ctx, "git", "clone", "--bare", "--no-local", sourcePath, forkPath)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			os.RemoveAll(forkPath)
+			return false, fmt.Errorf("%s", string(output))
+		}
+	}
+
+	// fork不保留指向上游路径的远程配置，同步时显式指定上游
+	cmd = exec.CommandContext(ctx, "git", "remote", "remove", "origin")
+	cmd.Dir = forkPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		s.logger.Warn("Failed to remove origin remote from fork",
+			zap.String("fork_path", forkPath),
+			zap.String("output", string(output)))
+	}
+
+	return shared, nil
+}
+
+// createForkBranches 根据fork中的实际分支创建分支记录
+func (s *gitService) createForkBranches(ctx context.Context, fork *models.Repository) error {
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(refname:lstrip=2) %(objectname)", "refs/heads")
+	cmd.Dir = fork.GitPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		name, sha, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		branch := &models.Branch{
+			RepositoryID: fork.ID,
+			Name:         name,
+			CommitSHA:    sha,
+			IsDefault:    name == fork.DefaultBranch,
+		}
+		if err := s.repo.CreateBranch(ctx, branch); err != nil {
+			return fmt.Errorf("failed to create branch %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// dissociateForks 上游删除前让共享对象的fork复制所需对象并移除alternates
+func (s *gitService) dissociateForks(ctx context.Context, upstream *models.Repository) error {
+	const pageSize = 100
+	for page := 1; ; page++ {
+		forks, total, err := s.repo.ListForks(ctx, upstream.ID, page, pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list forks: %w", err)
+		}
+
+		for _, fork := range forks {
+			if !fork.ObjectsShared {
+				continue
+			}
+			if err := s.dissociateGitObjects(ctx, fork.GitPath); err != nil {
+				return fmt.Errorf("failed to dissociate fork %s: %w", fork.ID, err)
+			}
+			s.repo.UpdateRepository(ctx, fork.ID, map[string]interface{}{
+				"objects_shared": false,
+			})
+		}
+
+		if int64(page*pageSize) >= total {
+			return nil
+		}
+	}
+}
+
+// dissociateGitObjects 将alternates中的对象打包进仓库自身后移除alternates
+func (s *gitService) dissociateGitObjects(ctx context.Context, repoPath string) error {
+	cmd := exec.CommandContext(ctx, "git", "repack", "-a", "-d")
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to repack: %s", string(output))
+	}
+
+	alternates := filepath.Join(repoPath, "objects", "info", "alternates")
+	if err := os.Remove(alternates); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// fetchGitRef 从本地另一仓库取单个分支到指定引用
+func (s *gitService) fetchGitRef(ctx context.Context, repoPath, fromPath, fromRef, toRef string) error {
+	cmd := exec.CommandContext(ctx, "git", "fetch", "--no-tags", "--quiet", fromPath, "+"+fromRef+":"+toRef)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if strings.Contains(string(output), "couldn't find remote ref") {
+			return fmt.Errorf("%w: %s", models.ErrRefNotFound, fromRef)
+		}
+		return fmt.Errorf("failed to fetch %s: %s", fromRef, string(output))
+	}
+	return nil
+}
+
+// deleteGitRef 删除引用，失败只记录日志
+func (s *gitService) deleteGitRef(ctx context.Context, repoPath, ref string) {
+	cmd := exec.CommandContext(ctx, "git", "update-ref", "-d", ref)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		s.logger.Warn("Failed to delete git ref",
+			zap.String("repo_path", repoPath),
+			zap.String("ref", ref),
+			zap.String("output", string(output)))
+	}
+}
+
+// countAheadBehind 计算base相对upstream领先和落后的提交数
+func (s *gitService) countAheadBehind(ctx context.Context, repoPath, base, upstream string) (int, int, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-list", "--left-right", "--count", base+"..."+upstream)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to compare commits: %w", err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", string(output))
+	}
+	ahead, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	behind, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+// mergeIntoBareBranch 在临时工作目录中将提交合并到裸仓库的分支并推回
+// 冲突时返回冲突文件列表和 ErrForkSyncConflict
+func (s *gitService) mergeIntoBareBranch(ctx context.Context, bareRepoPath, branch, commitSHA, message string) (string, []string, error) {
+	tempDir, err := os.MkdirTemp("", "git-merge-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// --shared 通过alternates读取裸仓库对象，无需复制
+	cmd := exec.CommandContext(ctx, "git", "clone", "--quiet", "--shared", "--single-branch", "--branch", branch, bareRepoPath, tempDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", nil, fmt.Errorf("failed to clone repository: %s", string(output))
+	}
+
+	gitConfig := [][]string{
+		{"config", "user.name", "System"},
+		{"config", "user.email", "system@cloudplatform.local"},
+	}
+	for _, args := range gitConfig {
+		cmd = exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = tempDir
+		if err := cmd.Run(); err != nil {
+			return "", nil, fmt.Errorf("failed to configure git: %w", err)
+		}
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "merge", "--no-ff", "--no-edit", "-m", message, commitSHA)
+	cmd.Dir = tempDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		conflictCmd := exec.CommandContext(ctx, "git", "diff", "--name-only", "--diff-filter=U")
+		conflictCmd.Dir = tempDir
+		conflictOutput, diffErr := conflictCmd.Output()
+		if conflicts := strings.TrimSpace(string(conflictOutput)); diffErr == nil && conflicts != "" {
+			return "", strings.Split(conflicts, "\n"), models.ErrForkSyncConflict
+		}
+		return "", nil, fmt.Errorf("failed to merge: %s", string(output))
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	cmd.Dir = tempDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get merge commit: %w", err)
+	}
+	mergeSHA := strings.TrimSpace(string(output))
+
+	// 普通推送只接受快进，分支在合并期间被更新时推送失败而不会覆盖
+	// 合并已通过网关自身的检查，推送时跳过pre-receive策略钩子
+	cmd = exec.CommandContext(ctx, "git", "push", "--quiet", "origin", "HEAD:refs/heads/"+branch)
+	cmd.Dir = tempDir
+	cmd.Env = append(os.Environ(), gitGatewayInternalEnv+"=1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", nil, fmt.Errorf("failed to push merge commit: %s", string(output))
+	}
+
+	return mergeSHA, nil, nil
+}
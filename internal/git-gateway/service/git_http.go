@@ -0,0 +1,48 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"go.uber.org/zap"
+)
+
+// 智能HTTP传输：克隆和拉取由 git upload-pack 以无状态RPC方式处理。
+// 克隆地址的项目和仓库名经 ResolveRepository 解析，改名或迁移前的旧地址仍可克隆和拉取。
+
+// UploadPack 执行 git upload-pack，advertise 为 true 时只输出引用通告。
+// protocol 为客户端 Git-Protocol 请求头，用于协商协议版本
+func (s *gitService) UploadPack(ctx context.Context, repo *models.Repository, advertise bool, protocol string, input io.Reader, output io.Writer) error {
+	args := []string{"upload-pack", "--stateless-rpc"}
+	if advertise {
+		args = append(args, "--advertise-refs")
+	}
+	args = append(args, repo.GitPath)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin = input
+	cmd.Stdout = output
+	cmd.Stderr = &stderr
+	if protocol != "" {
+		cmd.Env = append(os.Environ(), "GIT_PROTOCOL="+protocol)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("git upload-pack failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	s.logger.Debug("Served git upload-pack",
+		zap.String("repository_id", repo.ID.String()),
+		zap.Bool("advertise", advertise))
+	return nil
+}
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -30,6 +32,8 @@ type GitCommitInfo struct {
 	DeletedLines   int       `json:"deleted_lines"`
 	ParentSHAs     []string  `json:"parent_shas"`
 	TreeSHA        string    `json:"tree_sha"`
+
+	Signature models.SignatureInfo `json:"signature"` // 签名校验结果
 }
 
 // FileChange 文件变更信息
@@ -44,12 +48,37 @@ type FileChange struct {
 // GitService Git服务接口
 type GitService interface {
 	// 仓库管理
-	CreateRepository(ctx context.Context, req *models.CreateRepositoryRequest, userID uuid.UUID) (*models.Repository, error)
+	CreateRepository(ctx context.Context, req *models.CreateRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error)
 	GetRepository(ctx context.Context, id uuid.UUID) (*models.Repository, error)
 	ListRepositories(ctx context.Context, projectID *uuid.UUID, page, pageSize int) (*models.RepositoryListResponse, error)
-	UpdateRepository(ctx context.Context, id uuid.UUID, req *models.UpdateRepositoryRequest) (*models.Repository, error)
+	UpdateRepository(ctx context.Context, id uuid.UUID, req *models.UpdateRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error)
 	DeleteRepository(ctx context.Context, id uuid.UUID) error
 
+	// Fork管理
+	ForkRepository(ctx context.Context, sourceID uuid.UUID, req *models.ForkRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error)
+	ListForks(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) (*models.RepositoryListResponse, error)
+	SyncFork(ctx context.Context, forkID uuid.UUID, req *models.SyncForkRequest, access models.RepositoryAccess) (*models.ForkSyncResult, error)
+
+	// 模板管理
+	SetRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID, req *models.SetRepositoryTemplateRequest, access models.RepositoryAccess) (*models.RepositoryTemplate, error)
+	GetRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryTemplate, error)
+	DeleteRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) error
+
+	// 迁移、改名与归档
+	TransferRepository(ctx context.Context, id uuid.UUID, req *models.TransferRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error)
+	RenameRepository(ctx context.Context, id uuid.UUID, name string, access models.RepositoryAccess) (*models.Repository, error)
+	ArchiveRepository(ctx context.Context, id uuid.UUID, access models.RepositoryAccess) (*models.Repository, error)
+	UnarchiveRepository(ctx context.Context, id uuid.UUID, access models.RepositoryAccess) (*models.Repository, error)
+	ResolveRepository(ctx context.Context, projectID uuid.UUID, name string, access models.RepositoryAccess) (*models.RepositoryResolution, error)
+
+	// 智能HTTP传输
+	UploadPack(ctx context.Context, repo *models.Repository, advertise bool, protocol string, input io.Reader, output io.Writer) error
+
+	// Pull Request管理
+	CreatePullRequest(ctx context.Context, repositoryID uuid.UUID, req *models.CreatePullRequestRequest, access models.RepositoryAccess) (*models.PullRequest, error)
+	ListPullRequests(ctx context.Context, repositoryID uuid.UUID, status *models.PullRequestStatus, page, pageSize int) (*models.PullRequestListResponse, error)
+	ListProjectPullRequests(ctx context.Context, projectID uuid.UUID, status *models.PullRequestStatus, reference string, page, pageSize int) (*models.PullRequestListResponse, error)
+
 	// 分支管理
 	CreateBranch(ctx context.Context, repositoryID uuid.UUID, req *models.CreateBranchRequest) (*models.Branch, error)
 	GetBranch(ctx context.Context, repositoryID uuid.UUID, name string) (*models.Branch, error)
@@ -62,8 +91,8 @@ type GitService interface {
 	CreateCommit(ctx context.Context, repositoryID uuid.UUID, req *models.CreateCommitRequest) (*models.Commit, error)
 	GetCommit(ctx context.Context, repositoryID uuid.UUID, sha string) (*models.Commit, error)
 	ListCommits(ctx context.Context, repositoryID uuid.UUID, branch string, page, pageSize int) (*models.CommitListResponse, error)
-	GetCommitDiff(ctx context.Context, repositoryID uuid.UUID, sha string) (*models.GitDiff, error)
-	CompareBranches(ctx context.Context, repositoryID uuid.UUID, base, head string) (*models.GitDiff, error)
+	GetCommitDiff(ctx context.Context, repositoryID uuid.UUID, sha string, opts *models.DiffOptions) (*models.GitDiff, error)
+	CompareBranches(ctx context.Context, repositoryID uuid.UUID, base, head string, opts *models.DiffOptions) (*models.GitDiff, error)
 
 	// 标签管理
 	CreateTag(ctx context.Context, repositoryID uuid.UUID, req *models.CreateTagRequest) (*models.Tag, error)
@@ -74,6 +103,12 @@ type GitService interface {
 	// 文件操作
 	GetFileContent(ctx context.Context, repositoryID uuid.UUID, branch, filePath string) ([]byte, error)
 	GetDirectoryContent(ctx context.Context, repositoryID uuid.UUID, branch, dirPath string) ([]models.FileInfo, error)
+	BlameFile(ctx context.Context, repositoryID uuid.UUID, req *models.BlameRequest) (*models.FileBlame, error)
+	GetFileHistory(ctx context.Context, repositoryID uuid.UUID, ref, filePath string, page, pageSize int) (*models.FileHistoryResponse, error)
+
+	// 归档下载
+	ResolveArchive(ctx context.Context, repositoryID uuid.UUID, req *models.ArchiveRequest) (*models.RepositoryArchive, error)
+	StreamArchive(ctx context.Context, archive *models.RepositoryArchive, w io.Writer) error
 
 	// 统计和搜索
 	GetRepositoryStats(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryStats, error)
@@ -82,26 +117,38 @@ type GitService interface {
 
 // gitService Git服务实现
 type gitService struct {
-	repo    repository.GitRepository
-	logger  *zap.Logger
-	gitRoot string // Git仓库根目录
-	config  *config.Config
+	repo        repository.GitRepository
+	verifier    SignatureVerifier
+	logger      *zap.Logger
+	gitRoot     string // Git仓库根目录
+	config      *config.Config
+	prListeners []PullRequestListener // PR事件监听器（如代码所有者审查请求）
+	projects    ProjectNotifier       // 为空时不通知项目服务
+	refAudit    RefUpdateRecorder     // 为空时不记录API引起的引用更新
 }
 
 // NewGitService 创建Git服务实例
-func NewGitService(repo repository.GitRepository, logger *zap.Logger, gitRoot string, cfg *config.Config) GitService {
-	return &gitService{
-		repo:    repo,
-		logger:  logger,
-		gitRoot: gitRoot,
-		config:  cfg,
-	}
+// prListeners 在PR创建后收到通知
+func NewGitService(repo repository.GitRepository, verifier SignatureVerifier, logger *zap.Logger, gitRoot string, cfg *config.Config, refAudit RefUpdateRecorder, prListeners ...PullRequestListener) GitService {
+	s := &gitService{
+		repo:        repo,
+		verifier:    verifier,
+		logger:      logger,
+		gitRoot:     gitRoot,
+		config:      cfg,
+		prListeners: prListeners,
+		refAudit:    refAudit,
+	}
+	if cfg != nil {
+		s.projects = NewProjectNotifier(cfg.Git.ProjectServiceURL, cfg.Git.ProjectWebhookSecret)
+	}
+	return s
 }
 
 // 仓库管理实现
 
 // CreateRepository 创建仓库
-func (s *gitService) CreateRepository(ctx context.Context, req *models.CreateRepositoryRequest, userID uuid.UUID) (*models.Repository, error) {
+func (s *gitService) CreateRepository(ctx context.Context, req *models.CreateRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error) {
 	// 检查仓库名称是否已存在
 	projectID, err := uuid.Parse(req.ProjectID)
 	if err != nil {
@@ -122,6 +169,14 @@ func (s *gitService) CreateRepository(ctx context.Context, req *models.CreateRep
 		return nil, fmt.Errorf("repository name '%s' already exists with different configuration", req.Name)
 	}
 
+	// 从模板创建时先校验模板和变量，避免留下创建了一半的仓库
+	var tmpl *templateSource
+	if req.TemplateID != nil {
+		if tmpl, err = s.resolveTemplateSource(ctx, projectID, req, access); err != nil {
+			return nil, err
+		}
+	}
+
 	// 创建Git仓库路径
 	repoPath := filepath.Join(s.gitRoot, projectID.String(), req.Name+".git")
 
@@ -129,6 +184,8 @@ func (s *gitService) CreateRepository(ctx context.Context, req *models.CreateRep
 	defaultBranch := "main"
 	if req.DefaultBranch != nil {
 		defaultBranch = *req.DefaultBranch
+	} else if tmpl != nil {
+		defaultBranch = tmpl.defaultBranch
 	}
 
 	repository := &models.Repository{
@@ -146,20 +203,41 @@ func (s *gitService) CreateRepository(ctx context.Context, req *models.CreateRep
 		BranchCount:   1, // 默认分支
 		TagCount:      0,
 	}
+	if tmpl != nil {
+		repository.TemplatedFrom = &tmpl.repo.ID
+	}
 
 	if err := s.repo.CreateRepository(ctx, repository); err != nil {
 		return nil, fmt.Errorf("failed to create repository record: %w", err)
 	}
 
 	// 创建物理Git仓库
-	if err := s.initGitRepository(repoPath, defaultBranch, req.InitReadme); err != nil {
+	if err := s.initGitRepository(repoPath, defaultBranch, req.InitReadme && tmpl == nil); err != nil {
 		// 回滚数据库记录
 		s.repo.DeleteRepository(ctx, repository.ID)
 		return nil, fmt.Errorf("failed to initialize git repository: %w", err)
 	}
 
-	// 创建默认分支记录
-	if err := s.createDefaultBranch(ctx, repository.ID, defaultBranch); err != nil {
+	if tmpl != nil {
+		if err := s.populateFromTemplate(ctx, tmpl, repoPath); err != nil {
+			s.repo.DeleteRepository(ctx, repository.ID)
+			os.RemoveAll(repoPath)
+			return nil, fmt.Errorf("failed to initialize repository from template: %w", err)
+		}
+	}
+
+	// 安装推送策略检查和引用审计钩子
+	if err := installGitHooks(repoPath, s.config.Git.HookBaseURL, s.config.Git.HookSecret, repository.ID); err != nil {
+		s.logger.Warn("Failed to install git hooks",
+			zap.String("repository_id", repository.ID.String()),
+			zap.Error(err))
+	}
+
+	if tmpl != nil {
+		// 分支记录、受保护分支和Webhook按模板设置
+		s.applyTemplateSettings(ctx, repository, tmpl)
+	} else if err := s.createDefaultBranch(ctx, repository.ID, defaultBranch); err != nil {
+		// 创建默认分支记录
 		s.logger.Error("Failed to create default branch record", zap.Error(err))
 	}
 
@@ -191,18 +269,25 @@ func (s *gitService) ListRepositories(ctx context.Context, projectID *uuid.UUID,
 }
 
 // UpdateRepository 更新仓库
-func (s *gitService) UpdateRepository(ctx context.Context, id uuid.UUID, req *models.UpdateRepositoryRequest) (*models.Repository, error) {
+func (s *gitService) UpdateRepository(ctx context.Context, id uuid.UUID, req *models.UpdateRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error) {
 	// 检查仓库是否存在
-	_, err := s.repo.GetRepositoryByID(ctx, id)
+	current, err := getRepositoryOrNotFound(ctx, s.repo, id)
 	if err != nil {
 		return nil, err
 	}
+	if current.IsArchived() {
+		return nil, models.ErrRepositoryArchived
+	}
+
+	// 改名需要移动仓库目录并保留旧地址的重定向
+	if req.Name != nil && *req.Name != current.Name {
+		if _, err := s.RenameRepository(ctx, id, *req.Name, access); err != nil {
+			return nil, err
+		}
+	}
 
 	updates := make(map[string]interface{})
 
-	if req.Name != nil {
-		updates["name"] = *req.Name
-	}
 	if req.Description != nil {
 		updates["description"] = *req.Description
 	}
@@ -246,11 +331,24 @@ func (s *gitService) DeleteRepository(ctx context.Context, id uuid.UUID) error {
 		return err
 	}
 
+	// 共享对象的fork先复制所需对象，再删除上游
+	if repo.ForkCount > 0 {
+		if err := s.dissociateForks(ctx, repo); err != nil {
+			return err
+		}
+	}
+
 	// 软删除数据库记录
 	if err := s.repo.DeleteRepository(ctx, id); err != nil {
 		return err
 	}
 
+	if repo.ForkedFromID != nil {
+		if err := s.repo.UpdateForkCount(ctx, *repo.ForkedFromID, -1); err != nil {
+			s.logger.Error("Failed to update fork count", zap.Error(err))
+		}
+	}
+
 	// 异步删除物理Git仓库文件（带重试机制）
 	if s.config.Git.AsyncDeleteEnabled {
 		go s.asyncDeleteRepository(repo)
@@ -337,11 +435,16 @@ func (s *gitService) CreateBranch(ctx context.Context, repositoryID uuid.UUID, r
 	if err != nil {
 		return nil, err
 	}
+	if err := repo.CheckWritable(); err != nil {
+		return nil, err
+	}
 
 	// 在Git仓库中创建分支
+	refs := s.snapshotRefs(ctx, repo.GitPath, "refs/heads/"+req.Name)
 	if err := s.createGitBranch(repo.GitPath, req.Name, req.FromSHA); err != nil {
 		return nil, fmt.Errorf("failed to create git branch: %w", err)
 	}
+	s.recordRefChanges(ctx, repo, refs, "refs/heads/"+req.Name)
 
 	// 创建分支记录
 	branch := &models.Branch{
@@ -389,10 +492,15 @@ func (s *gitService) DeleteBranch(ctx context.Context, repositoryID uuid.UUID, n
 	if err != nil {
 		return err
 	}
+	if err := repo.CheckWritable(); err != nil {
+		return err
+	}
 
+	refs := s.snapshotRefs(ctx, repo.GitPath, "refs/heads/"+name)
 	if err := s.deleteGitBranch(repo.GitPath, name); err != nil {
 		return fmt.Errorf("failed to delete git branch: %w", err)
 	}
+	s.recordRefChanges(ctx, repo, refs, "refs/heads/"+name)
 
 	// 删除分支记录
 	if err := s.repo.DeleteBranch(ctx, repositoryID, name); err != nil {
@@ -416,6 +524,9 @@ func (s *gitService) MergeBranch(ctx context.Context, repositoryID uuid.UUID, ta
 	if err != nil {
 		return err
 	}
+	if err := repo.CheckWritable(); err != nil {
+		return err
+	}
 
 	// 检查源分支和目标分支是否存在
 	if _, err := s.repo.GetBranchByName(ctx, repositoryID, sourceBranch); err != nil {
@@ -427,9 +538,11 @@ func (s *gitService) MergeBranch(ctx context.Context, repositoryID uuid.UUID, ta
 	}
 
 	// 执行Git合并
+	refs := s.snapshotRefs(ctx, repo.GitPath, "refs/heads/"+targetBranch)
 	if err := s.mergeBranch(repo.GitPath, targetBranch, sourceBranch); err != nil {
 		return fmt.Errorf("failed to merge branches: %w", err)
 	}
+	s.recordRefChanges(ctx, repo, refs, "refs/heads/"+targetBranch)
 
 	// 获取合并后的提交SHA
 	cmd := exec.Command("git", "rev-parse", "HEAD")
@@ -462,15 +575,24 @@ func (s *gitService) CreateCommit(ctx context.Context, repositoryID uuid.UUID, r
 	if err != nil {
 		return nil, err
 	}
+	if err := repo.CheckWritable(); err != nil {
+		return nil, err
+	}
 
-	// 在Git仓库中创建提交
+	// 在Git仓库中创建提交，未指定分支时提交到当前检出的分支
+	branchRef := "refs/heads"
+	if req.Branch != "" {
+		branchRef += "/" + req.Branch
+	}
+	refs := s.snapshotRefs(ctx, repo.GitPath, branchRef)
 	commitSHA, err := s.createGitCommit(repo.GitPath, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create git commit: %w", err)
 	}
+	s.recordRefChanges(ctx, repo, refs, branchRef)
 
 	// 从Git获取提交详细信息
-	gitCommit, err := s.getGitCommitInfo(repo.GitPath, commitSHA)
+	gitCommit, err := s.getGitCommitInfo(ctx, repo.GitPath, commitSHA)
 	if err != nil {
 		s.logger.Error("Failed to get git commit info", zap.Error(err))
 		gitCommit = &GitCommitInfo{
@@ -484,6 +606,7 @@ func (s *gitService) CreateCommit(ctx context.Context, repositoryID uuid.UUID, r
 			AddedLines:     0,
 			DeletedLines:   0,
 			ParentSHAs:     []string{},
+			Signature:      models.UnsignedSignature(),
 		}
 	}
 
@@ -500,6 +623,7 @@ func (s *gitService) CreateCommit(ctx context.Context, repositoryID uuid.UUID, r
 		AddedLines:     int32(gitCommit.AddedLines),
 		DeletedLines:   int32(gitCommit.DeletedLines),
 		ChangedFiles:   int32(len(req.Files)),
+		Signature:      gitCommit.Signature,
 	}
 
 	if err := s.repo.CreateCommit(ctx, commit); err != nil {
@@ -561,7 +685,7 @@ func (s *gitService) GetCommit(ctx context.Context, repositoryID uuid.UUID, sha
 	}
 
 	// 优先从Git获取最新的提交信息
-	gitCommit, err := s.getGitCommitInfo(repo.GitPath, sha)
+	gitCommit, err := s.getGitCommitInfo(ctx, repo.GitPath, sha)
 	if err != nil {
 		s.logger.Error("Failed to get git commit info, falling back to database", zap.Error(err))
 		// 回退到数据库查询
@@ -581,6 +705,7 @@ func (s *gitService) GetCommit(ctx context.Context, repositoryID uuid.UUID, sha
 		AddedLines:     int32(gitCommit.AddedLines),
 		DeletedLines:   int32(gitCommit.DeletedLines),
 		ChangedFiles:   0, // 在需要时计算
+		Signature:      gitCommit.Signature,
 	}
 
 	// 获取文件变更数量
@@ -619,9 +744,23 @@ func (s *gitService) ListCommits(ctx context.Context, repositoryID uuid.UUID, br
 		}, nil
 	}
 
+	// 批量校验本页提交的签名
+	shas := make([]string, 0, len(gitCommits))
+	for _, gitCommit := range gitCommits {
+		shas = append(shas, gitCommit.SHA)
+	}
+	signatures, err := s.verifier.VerifyObjects(ctx, repo.GitPath, nil, "commit", shas)
+	if err != nil {
+		s.logger.Warn("Failed to verify commit signatures", zap.Error(err))
+	}
+
 	// 转换Git提交为模型格式
 	var commits []models.Commit
 	for _, gitCommit := range gitCommits {
+		signature, ok := signatures[gitCommit.SHA]
+		if !ok {
+			signature = models.UnsignedSignature()
+		}
 		commit := models.Commit{
 			RepositoryID:   repositoryID,
 			SHA:            gitCommit.SHA,
@@ -634,6 +773,7 @@ func (s *gitService) ListCommits(ctx context.Context, repositoryID uuid.UUID, br
 			AddedLines:     int32(gitCommit.AddedLines),
 			DeletedLines:   int32(gitCommit.DeletedLines),
 			ChangedFiles:   0, // 在需要时计算
+			Signature:      signature,
 		}
 		commits = append(commits, commit)
 	}
@@ -652,26 +792,6 @@ func (s *gitService) ListCommits(ctx context.Context, repositoryID uuid.UUID, br
 	}, nil
 }
 
-// GetCommitDiff 获取提交差异
-func (s *gitService) GetCommitDiff(ctx context.Context, repositoryID uuid.UUID, sha string) (*models.GitDiff, error) {
-	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
-	if err != nil {
-		return nil, err
-	}
-
-	return s.getGitDiff(repo.GitPath, sha+"^", sha)
-}
-
-// CompareBranches 比较分支
-func (s *gitService) CompareBranches(ctx context.Context, repositoryID uuid.UUID, base, head string) (*models.GitDiff, error) {
-	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
-	if err != nil {
-		return nil, err
-	}
-
-	return s.getGitDiff(repo.GitPath, base, head)
-}
-
 // 标签管理实现
 
 // CreateTag 创建标签
@@ -680,11 +800,16 @@ func (s *gitService) CreateTag(ctx context.Context, repositoryID uuid.UUID, req
 	if err != nil {
 		return nil, err
 	}
+	if err := repo.CheckWritable(); err != nil {
+		return nil, err
+	}
 
 	// 在Git仓库中创建标签
+	refs := s.snapshotRefs(ctx, repo.GitPath, "refs/tags/"+req.Name)
 	if err := s.createGitTag(repo.GitPath, req); err != nil {
 		return nil, fmt.Errorf("failed to create git tag: %w", err)
 	}
+	s.recordRefChanges(ctx, repo, refs, "refs/tags/"+req.Name)
 
 	// 创建标签记录
 	tag := &models.Tag{
@@ -695,6 +820,7 @@ func (s *gitService) CreateTag(ctx context.Context, repositoryID uuid.UUID, req
 		Tagger:       req.Tagger.Name,
 		TaggerEmail:  req.Tagger.Email,
 		TaggedAt:     time.Now(),
+		Signature:    models.UnsignedSignature(), // 网关创建的标签不签名
 	}
 
 	if err := s.repo.CreateTag(ctx, tag); err != nil {
@@ -704,17 +830,64 @@ func (s *gitService) CreateTag(ctx context.Context, repositoryID uuid.UUID, req
 	// 更新仓库标签计数
 	s.updateRepositoryTagCount(ctx, repositoryID)
 
+	tag.Archives = models.ArchiveLinks(repositoryID, tag.Name)
 	return tag, nil
 }
 
 // GetTag 获取标签信息
 func (s *gitService) GetTag(ctx context.Context, repositoryID uuid.UUID, name string) (*models.Tag, error) {
-	return s.repo.GetTagByName(ctx, repositoryID, name)
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := s.repo.GetTagByName(ctx, repositoryID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []models.Tag{*tag}
+	s.applyTagSignatures(ctx, repo.GitPath, tags)
+	tags[0].Archives = models.ArchiveLinks(repositoryID, tags[0].Name)
+	return &tags[0], nil
 }
 
 // ListTags 获取标签列表
 func (s *gitService) ListTags(ctx context.Context, repositoryID uuid.UUID) ([]models.Tag, error) {
-	return s.repo.ListTags(ctx, repositoryID)
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.repo.ListTags(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.applyTagSignatures(ctx, repo.GitPath, tags)
+	for i := range tags {
+		tags[i].Archives = models.ArchiveLinks(repositoryID, tags[i].Name)
+	}
+	return tags, nil
+}
+
+// applyTagSignatures 按仓库中的标签对象校验签名，签名公钥可能在标签创建后上传或删除，因此每次实时校验
+func (s *gitService) applyTagSignatures(ctx context.Context, repoPath string, tags []models.Tag) {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, "refs/tags/"+tag.Name)
+	}
+
+	signatures, err := s.verifier.VerifyObjects(ctx, repoPath, nil, "tag", names)
+	if err != nil {
+		s.logger.Warn("Failed to verify tag signatures", zap.Error(err))
+		return
+	}
+	for i := range tags {
+		if signature, ok := signatures["refs/tags/"+tags[i].Name]; ok {
+			tags[i].Signature = signature
+		}
+	}
 }
 
 // DeleteTag 删除标签
@@ -723,11 +896,16 @@ func (s *gitService) DeleteTag(ctx context.Context, repositoryID uuid.UUID, name
 	if err != nil {
 		return err
 	}
+	if err := repo.CheckWritable(); err != nil {
+		return err
+	}
 
 	// 从Git仓库删除标签
+	refs := s.snapshotRefs(ctx, repo.GitPath, "refs/tags/"+name)
 	if err := s.deleteGitTag(repo.GitPath, name); err != nil {
 		return fmt.Errorf("failed to delete git tag: %w", err)
 	}
+	s.recordRefChanges(ctx, repo, refs, "refs/tags/"+name)
 
 	// 删除标签记录
 	if err := s.repo.DeleteTag(ctx, repositoryID, name); err != nil {
@@ -762,6 +940,178 @@ func (s *gitService) GetDirectoryContent(ctx context.Context, repositoryID uuid.
 	return s.getGitDirectoryContent(repo.GitPath, branch, dirPath)
 }
 
+// BlameFile 获取文件每一行最后修改的提交，跨文件重命名继续追溯
+func (s *gitService) BlameFile(ctx context.Context, repositoryID uuid.UUID, req *models.BlameRequest) (*models.FileBlame, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	repo, filePath, commitSHA, ref, err := s.resolveFileAtRef(ctx, repositoryID, req.Ref, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges, commits, err := s.getGitBlame(ctx, repo.GitPath, commitSHA, filePath, req.StartLine, req.EndLine)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FileBlame{
+		Path:      filePath,
+		Ref:       ref,
+		CommitSHA: commitSHA,
+		Ranges:    ranges,
+		Commits:   commits,
+	}, nil
+}
+
+// GetFileHistory 获取单个文件的提交历史（--follow语义，跨重命名）
+func (s *gitService) GetFileHistory(ctx context.Context, repositoryID uuid.UUID, ref, filePath string, page, pageSize int) (*models.FileHistoryResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	repo, cleanPath, commitSHA, ref, err := s.resolveFileAtRef(ctx, repositoryID, ref, filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, total, err := s.getGitFileHistory(ctx, repo.GitPath, commitSHA, cleanPath, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FileHistoryResponse{
+		Path:     cleanPath,
+		Ref:      ref,
+		Commits:  commits,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// resolveFileAtRef 校验文件路径并将引用解析为提交SHA，ref为空时使用默认分支
+func (s *gitService) resolveFileAtRef(ctx context.Context, repositoryID uuid.UUID, ref, filePath string) (*models.Repository, string, string, string, error) {
+	cleanPath, err := models.CleanRepoPath(filePath)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	if cleanPath == "" {
+		return nil, "", "", "", fmt.Errorf("%w: path is required", models.ErrInvalidPath)
+	}
+
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	if ref == "" {
+		ref = repo.DefaultBranch
+	}
+	if err := models.ValidateRefName(ref); err != nil {
+		return nil, "", "", "", err
+	}
+
+	commitSHA, err := s.resolveGitCommit(ctx, repo.GitPath, ref)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+
+	objectType, err := s.getGitObjectType(ctx, repo.GitPath, commitSHA+":"+cleanPath)
+	if err != nil || objectType != "blob" {
+		return nil, "", "", "", fmt.Errorf("%w: %s at %s", models.ErrFileNotFound, cleanPath, ref)
+	}
+
+	return repo, cleanPath, commitSHA, ref, nil
+}
+
+// 归档下载实现
+
+// ResolveArchive 将引用解析为提交SHA并校验子目录，确定归档的文件名和根目录名
+func (s *gitService) ResolveArchive(ctx context.Context, repositoryID uuid.UUID, req *models.ArchiveRequest) (*models.RepositoryArchive, error) {
+	if err := models.ValidateRefName(req.Ref); err != nil {
+		return nil, err
+	}
+	if req.Format != models.ArchiveFormatTarGz && req.Format != models.ArchiveFormatZip {
+		return nil, fmt.Errorf("%w: %s", models.ErrArchiveFormat, req.Format)
+	}
+
+	subPath, err := models.CleanRepoPath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	commitSHA, err := s.resolveGitCommit(ctx, repo.GitPath, req.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	baseName := models.ArchiveBaseName(repo.Name, req.Ref)
+	if subPath != "" {
+		objectType, err := s.getGitObjectType(ctx, repo.GitPath, commitSHA+":"+subPath)
+		if err != nil || objectType != "tree" {
+			return nil, fmt.Errorf("%w: directory %s at %s", models.ErrArchiveNotFound, subPath, req.Ref)
+		}
+		baseName = models.ArchiveBaseName(baseName, subPath)
+	}
+
+	prefix, err := models.CleanArchivePrefix(req.Prefix, baseName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RepositoryArchive{
+		GitPath:   repo.GitPath,
+		Ref:       req.Ref,
+		CommitSHA: commitSHA,
+		Format:    req.Format,
+		Path:      subPath,
+		Prefix:    prefix,
+		Filename:  baseName + "." + string(req.Format),
+	}, nil
+}
+
+// StreamArchive 将git archive的输出直接写入w，不在内存中缓存归档内容
+func (s *gitService) StreamArchive(ctx context.Context, archive *models.RepositoryArchive, w io.Writer) error {
+	treeish := archive.CommitSHA
+	if archive.Path != "" {
+		treeish += ":" + archive.Path
+	}
+
+	s.logger.Info("Streaming git archive",
+		zap.String("repo_path", archive.GitPath),
+		zap.String("ref", archive.Ref),
+		zap.String("commit_sha", archive.CommitSHA),
+		zap.String("path", archive.Path),
+		zap.String("format", string(archive.Format)))
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "archive",
+		"--format="+archive.Format.GitFormat(),
+		"--prefix="+archive.Prefix,
+		treeish)
+	cmd.Dir = archive.GitPath
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("failed to create archive: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
 // 统计和搜索实现
 
 // GetRepositoryStats 获取仓库统计信息
@@ -1199,62 +1549,129 @@ func (s *gitService) deleteGitTag(repoPath, tagName string) error {
 	return nil
 }
 
-// getGitDiff 获取Git差异
-func (s *gitService) getGitDiff(repoPath, from, to string) (*models.GitDiff, error) {
-	s.logger.Info("Getting git diff",
+// getGitFileContent 获取Git文件内容
+func (s *gitService) getGitFileContent(repoPath, branch, filePath string) ([]byte, error) {
+	s.logger.Info("Getting git file content",
 		zap.String("repo_path", repoPath),
-		zap.String("from", from),
-		zap.String("to", to))
+		zap.String("branch", branch),
+		zap.String("file_path", filePath))
 
-	cmd := exec.Command("git", "diff", "--name-status", from+".."+to)
+	cmd := exec.Command("git", "show", branch+":"+filePath)
 	cmd.Dir = repoPath
 
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get diff: %w", err)
+		return nil, fmt.Errorf("failed to get file content: %w", err)
 	}
 
-	// 解析差异输出
-	var files []models.DiffFile
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		if len(line) < 2 {
-			continue
+	return output, nil
+}
+
+// resolveGitCommit 将分支、标签或SHA解析为完整的提交SHA
+func (s *gitService) resolveGitCommit(ctx context.Context, repoPath, ref string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", models.ErrRefNotFound, ref)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// getGitBlame 执行git blame，-M 检测文件内移动的行，文件重命名由git自动跟踪
+func (s *gitService) getGitBlame(ctx context.Context, repoPath, commitSHA, filePath string, startLine, endLine int) ([]models.BlameRange, map[string]models.BlameCommit, error) {
+	s.logger.Debug("Getting git blame",
+		zap.String("repo_path", repoPath),
+		zap.String("commit_sha", commitSHA),
+		zap.String("file_path", filePath))
+
+	args := []string{"blame", "--porcelain", "-M"}
+	if startLine > 0 || endLine > 0 {
+		if startLine == 0 {
+			startLine = 1
+		}
+		lineRange := strconv.Itoa(startLine) + ","
+		if endLine > 0 {
+			lineRange += strconv.Itoa(endLine)
 		}
+		args = append(args, "-L", lineRange)
+	}
+	args = append(args, commitSHA, "--", filePath)
 
-		parts := line[2:] // 跳过状态字符和制表符
-		status := string(line[0])
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	cmd.Stderr = &stderr
 
-		diffFile := models.DiffFile{
-			Path:   parts,
-			Status: status,
+	output, err := cmd.Output()
+	if err != nil {
+		if strings.Contains(stderr.String(), "has only") {
+			return nil, nil, fmt.Errorf("%w: %s", models.ErrInvalidLineRange, strings.TrimSpace(stderr.String()))
 		}
-		files = append(files, diffFile)
+		return nil, nil, fmt.Errorf("failed to get blame: %w: %s", err, strings.TrimSpace(stderr.String()))
 	}
 
-	return &models.GitDiff{
-		FromSHA: from,
-		ToSHA:   to,
-		Files:   files,
-	}, nil
+	return models.ParseBlamePorcelain(string(output))
 }
 
-// getGitFileContent 获取Git文件内容
-func (s *gitService) getGitFileContent(repoPath, branch, filePath string) ([]byte, error) {
-	s.logger.Info("Getting git file content",
+// getGitFileHistory 获取文件提交历史
+// --follow 时 --skip 在路径过滤之前生效，因此取前 page*pageSize 条后在内存中截取当前页
+func (s *gitService) getGitFileHistory(ctx context.Context, repoPath, commitSHA, filePath string, page, pageSize int) ([]models.FileCommit, int64, error) {
+	s.logger.Debug("Getting git file history",
 		zap.String("repo_path", repoPath),
-		zap.String("branch", branch),
-		zap.String("file_path", filePath))
+		zap.String("commit_sha", commitSHA),
+		zap.String("file_path", filePath),
+		zap.Int("page", page),
+		zap.Int("page_size", pageSize))
 
-	cmd := exec.Command("git", "show", branch+":"+filePath)
+	skip := (page - 1) * pageSize
+	cmd := exec.CommandContext(ctx, "git", "log", "--follow", "-M", "--name-status",
+		"--format="+models.FileHistoryFormat, "--max-count="+strconv.Itoa(skip+pageSize),
+		commitSHA, "--", filePath)
 	cmd.Dir = repoPath
 
 	output, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file content: %w", err)
+		return nil, 0, fmt.Errorf("failed to get file history: %w", err)
 	}
 
-	return output, nil
+	commits, err := models.ParseFileHistory(string(output), filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if skip >= len(commits) {
+		commits = []models.FileCommit{}
+	} else {
+		commits = commits[skip:]
+	}
+
+	// 获取总提交数
+	countCmd := exec.CommandContext(ctx, "git", "log", "--follow", "--format=%H", commitSHA, "--", filePath)
+	countCmd.Dir = repoPath
+
+	total := int64(skip + len(commits))
+	if countOutput, err := countCmd.Output(); err == nil {
+		total = int64(len(strings.Fields(string(countOutput))))
+	} else {
+		s.logger.Warn("Failed to get file commit count", zap.Error(err))
+	}
+
+	return commits, total, nil
+}
+
+// getGitObjectType 获取Git对象类型（blob、tree等）
+func (s *gitService) getGitObjectType(ctx context.Context, repoPath, object string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "-t", object)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get object type: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
 }
 
 // getGitDirectoryContent 获取Git目录内容
@@ -1359,6 +1776,42 @@ func (s *gitService) updateRepositoryTagCount(ctx context.Context, repositoryID
 	})
 }
 
+// 引用审计方法
+
+// snapshotRefs 在修改引用前读取其当前值，未启用引用审计时返回 nil
+func (s *gitService) snapshotRefs(ctx context.Context, repoPath string, patterns ...string) map[string]string {
+	if s.refAudit == nil {
+		return nil
+	}
+	refs, err := listGitRefs(ctx, repoPath, patterns...)
+	if err != nil {
+		s.logger.Warn("Failed to snapshot refs for audit", zap.String("path", repoPath), zap.Error(err))
+		return nil
+	}
+	return refs
+}
+
+// recordRefChanges 对比修改前的快照，记录API操作引起的引用更新
+func (s *gitService) recordRefChanges(ctx context.Context, repo *models.Repository, before map[string]string, patterns ...string) {
+	if before == nil {
+		return
+	}
+	after, err := listGitRefs(ctx, repo.GitPath, patterns...)
+	if err != nil {
+		s.logger.Warn("Failed to read refs for audit", zap.String("repository_id", repo.ID.String()), zap.Error(err))
+		return
+	}
+	s.recordRefUpdates(ctx, repo, refUpdatesBetween(before, after))
+}
+
+// recordRefUpdates 记录API操作引起的引用更新，发起者取自上下文
+func (s *gitService) recordRefUpdates(ctx context.Context, repo *models.Repository, updates []models.RefUpdate) {
+	if s.refAudit == nil || len(updates) == 0 {
+		return
+	}
+	s.refAudit.RecordRefUpdates(ctx, repo, updates, refActorFromContext(ctx), models.RefTransportAPI)
+}
+
 // calculateLineDiff 计算行变更差异
 func (s *gitService) calculateLineDiff(oldContent, newContent []byte) (added int, deleted int) {
 	oldLines := strings.Split(string(oldContent), "\n")
@@ -1424,8 +1877,8 @@ func (s *gitService) calculatePreciseLineDiff(oldLines, newLines []string) (adde
 	return added, deleted
 }
 
-// getGitCommitInfo 从Git获取提交详细信息
-func (s *gitService) getGitCommitInfo(repoPath, commitSHA string) (*GitCommitInfo, error) {
+// getGitCommitInfo 从Git获取提交详细信息并校验提交签名
+func (s *gitService) getGitCommitInfo(ctx context.Context, repoPath, commitSHA string) (*GitCommitInfo, error) {
 	s.logger.Debug("Getting git commit info",
 		zap.String("repo_path", repoPath),
 		zap.String("commit_sha", commitSHA))
@@ -1467,6 +1920,17 @@ func (s *gitService) getGitCommitInfo(repoPath, commitSHA string) (*GitCommitInf
 		CommittedAt:    time.Unix(timestamp, 0),
 		ParentSHAs:     parentSHAs,
 		TreeSHA:        lines[9],
+		Signature:      models.UnsignedSignature(),
+	}
+
+	// 校验提交签名，签名校验失败不影响提交信息的获取
+	signatures, err := s.verifier.VerifyObjects(ctx, repoPath, nil, "commit", []string{commitInfo.SHA})
+	if err != nil {
+		s.logger.Warn("Failed to verify commit signature",
+			zap.String("commit_sha", commitInfo.SHA),
+			zap.Error(err))
+	} else if signature, ok := signatures[commitInfo.SHA]; ok {
+		commitInfo.Signature = signature
 	}
 
 	// 获取统计信息
@@ -0,0 +1,505 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 模板管理实现
+//
+// 从模板创建的仓库不继承模板历史：每个复制的分支生成一个新提交，内容为模板分支的快照，
+// 文件路径和文本内容中的 {{变量}} 被替换。非默认分支的提交以默认分支的初始提交为父提交，
+// 便于之后比较和合并。模板中 .gitattributes 标记为 export-ignore 的文件不会复制。
+
+// templateSource 创建仓库时解析好的模板
+type templateSource struct {
+	repo          *models.Repository
+	settings      *models.RepositoryTemplate
+	values        map[string]string // 已合并内置变量和默认值
+	allBranches   bool
+	defaultBranch string // 新仓库的默认分支
+}
+
+// SetRepositoryTemplate 将仓库设为模板或更新模板设置，需要仓库管理员权限
+func (s *gitService) SetRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID, req *models.SetRepositoryTemplateRequest, access models.RepositoryAccess) (*models.RepositoryTemplate, error) {
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+	if err := s.checkRepositoryAdmin(ctx, repositoryID, access); err != nil {
+		return nil, err
+	}
+
+	template := &models.RepositoryTemplate{
+		RepositoryID:       repositoryID,
+		Variables:          req.Variables,
+		IncludeAllBranches: req.IncludeAllBranches,
+		ProtectedBranches:  req.ProtectedBranches,
+		Webhooks:           req.Webhooks,
+		PipelinePath:       req.PipelinePath,
+		PipelineContent:    req.PipelineContent,
+		UpdatedBy:          access.UserID,
+		UpdatedAt:          time.Now(),
+	}
+	if err := s.repo.SaveRepositoryTemplate(ctx, template); err != nil {
+		return nil, fmt.Errorf("failed to save repository template: %w", err)
+	}
+
+	s.logger.Info("Repository template saved",
+		zap.String("repository_id", repositoryID.String()),
+		zap.String("user_id", access.UserID.String()),
+		zap.Int("variables", len(req.Variables)))
+
+	return template, nil
+}
+
+// GetRepositoryTemplate 获取仓库的模板设置
+func (s *gitService) GetRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryTemplate, error) {
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+
+	template, err := s.repo.GetRepositoryTemplate(ctx, repositoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrNotATemplate
+		}
+		return nil, fmt.Errorf("failed to get repository template: %w", err)
+	}
+	return template, nil
+}
+
+// DeleteRepositoryTemplate 取消仓库的模板标记，已创建的仓库不受影响；需要仓库管理员权限
+func (s *gitService) DeleteRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) error {
+	if _, err := s.GetRepositoryTemplate(ctx, repositoryID); err != nil {
+		return err
+	}
+	if err := s.checkRepositoryAdmin(ctx, repositoryID, access); err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteRepositoryTemplate(ctx, repositoryID); err != nil {
+		return fmt.Errorf("failed to delete repository template: %w", err)
+	}
+
+	s.logger.Info("Repository template removed", zap.String("repository_id", repositoryID.String()))
+	return nil
+}
+
+// resolveTemplateSource 校验模板并解析变量，在创建仓库记录之前调用，参数错误时不留下半成品。
+// 用户需要能读取模板仓库，并能在目标项目下创建仓库
+func (s *gitService) resolveTemplateSource(ctx context.Context, projectID uuid.UUID, req *models.CreateRepositoryRequest, access models.RepositoryAccess) (*templateSource, error) {
+	templateID, err := uuid.Parse(*req.TemplateID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template ID: %w", err)
+	}
+
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRepositoryAccess(ctx, templateID, access, false); err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectWritable(ctx, projectID, access); err != nil {
+		return nil, err
+	}
+	if !repo.IsTemplate {
+		return nil, models.ErrNotATemplate
+	}
+	settings, err := s.GetRepositoryTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyTemplateBranch(ctx, repo.GitPath, repo.DefaultBranch); err != nil {
+		return nil, err
+	}
+
+	defaultBranch := repo.DefaultBranch
+	if req.DefaultBranch != nil {
+		defaultBranch = *req.DefaultBranch
+	}
+
+	values, err := models.ResolveTemplateVariables(settings.Variables, map[string]string{
+		models.TemplateVarRepositoryName: req.Name,
+		models.TemplateVarProjectID:      projectID.String(),
+		models.TemplateVarDefaultBranch:  defaultBranch,
+	}, req.TemplateVariables)
+	if err != nil {
+		return nil, err
+	}
+
+	allBranches := settings.IncludeAllBranches
+	if req.IncludeAllBranches != nil {
+		allBranches = *req.IncludeAllBranches
+	}
+
+	return &templateSource{
+		repo:          repo,
+		settings:      settings,
+		values:        values,
+		allBranches:   allBranches,
+		defaultBranch: defaultBranch,
+	}, nil
+}
+
+// verifyTemplateBranch 确认模板的默认分支存在，空模板无法使用
+func (s *gitService) verifyTemplateBranch(ctx context.Context, repoPath, branch string) error {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "refs/heads/"+branch+"^{commit}")
+	cmd.Dir = repoPath
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: template has no commits on %s", models.ErrInvalidTemplate, branch)
+	}
+	return nil
+}
+
+// populateFromTemplate 在临时工作目录中生成模板分支的快照提交并推送到新仓库
+func (s *gitService) populateFromTemplate(ctx context.Context, tmpl *templateSource, repoPath string) error {
+	branches := []string{tmpl.repo.DefaultBranch}
+	if tmpl.allBranches {
+		others, err := s.listTemplateBranches(ctx, tmpl.repo.GitPath)
+		if err != nil {
+			return err
+		}
+		for _, branch := range others {
+			// 模板默认分支已映射到新仓库的默认分支，同名分支跳过
+			if branch != tmpl.repo.DefaultBranch && branch != tmpl.defaultBranch {
+				branches = append(branches, branch)
+			}
+		}
+	}
+
+	workDir, err := os.MkdirTemp("", "git-template-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	run := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = workDir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("git %s failed: %s", args[0], strings.TrimSpace(string(output)))
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	setup := [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "System"},
+		{"config", "user.email", "system@cloudplatform.local"},
+		{"config", "core.autocrlf", "false"},
+		{"symbolic-ref", "HEAD", "refs/heads/" + tmpl.defaultBranch},
+	}
+	for _, args := range setup {
+		if _, err := run(args...); err != nil {
+			return err
+		}
+	}
+
+	message := fmt.Sprintf("Initial commit from template %s", tmpl.repo.Name)
+
+	var baseSHA string
+	for i, branch := range branches {
+		target := branch
+		if i == 0 {
+			target = tmpl.defaultBranch
+		} else if _, err := run("checkout", "--quiet", "-B", target, baseSHA); err != nil {
+			return err
+		}
+
+		if err := clearWorkTree(workDir); err != nil {
+			return err
+		}
+		if err := s.extractTemplateTree(ctx, tmpl.repo.GitPath, "refs/heads/"+branch, workDir, tmpl.values); err != nil {
+			return fmt.Errorf("branch %s: %w", branch, err)
+		}
+
+		// 每个分支都写入流水线文件，避免其他分支相对默认分支显示为删除
+		if tmpl.settings.PipelinePath != "" {
+			if err := writeTemplatePipeline(workDir, tmpl.settings, tmpl.values); err != nil {
+				return err
+			}
+		}
+
+		if _, err := run("add", "--all", "--force"); err != nil {
+			return err
+		}
+		status, err := run("status", "--porcelain")
+		if err != nil {
+			return err
+		}
+		if i > 0 && status == "" {
+			// 与默认分支内容相同，分支直接指向初始提交
+			continue
+		}
+
+		if _, err := run("commit", "--quiet", "--allow-empty", "--no-verify", "-m", message); err != nil {
+			return err
+		}
+		if i == 0 {
+			if baseSHA, err = run("rev-parse", "HEAD"); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 新仓库尚未安装推送策略钩子，直接推送全部分支
+	cmd := exec.CommandContext(ctx, "git", "push", "--quiet", repoPath, "refs/heads/*:refs/heads/*")
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), gitGatewayInternalEnv+"=1")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to push template content: %s", strings.TrimSpace(string(output)))
+	}
+
+	s.logger.Info("Repository populated from template",
+		zap.String("template_id", tmpl.repo.ID.String()),
+		zap.String("repo_path", repoPath),
+		zap.Strings("branches", branches))
+
+	return nil
+}
+
+// listTemplateBranches 列出模板仓库的全部分支
+func (s *gitService) listTemplateBranches(ctx context.Context, repoPath string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(refname:lstrip=2)", "refs/heads")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list template branches: %w", err)
+	}
+	return strings.Fields(string(output)), nil
+}
+
+// extractTemplateTree 通过git archive读取模板分支的文件，替换变量后写入工作目录
+func (s *gitService) extractTemplateTree(ctx context.Context, templatePath, ref, workDir string, values map[string]string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "archive", "--format=tar", ref)
+	cmd.Dir = templatePath
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start git archive: %w", err)
+	}
+
+	extractErr := writeTemplateFiles(tar.NewReader(stdout), workDir, values)
+	// 提前出错时读完剩余输出，避免git archive阻塞在写管道上
+	io.Copy(io.Discard, stdout)
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("failed to read template: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return extractErr
+}
+
+// writeTemplateFiles 将tar中的文件写入工作目录，路径和文本内容中的变量被替换
+func writeTemplateFiles(tr *tar.Reader, workDir string, values map[string]string) error {
+	written := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read template archive: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeSymlink {
+			// 目录由文件路径隐式创建，git archive的全局头和子模块忽略
+			continue
+		}
+
+		name, err := models.ExpandTemplatePath(header.Name, values)
+		if err != nil {
+			return err
+		}
+		if previous, ok := written[name]; ok {
+			return fmt.Errorf("%w: %s and %s both become %s", models.ErrInvalidTemplatePath, previous, header.Name, name)
+		}
+		written[name] = header.Name
+
+		target, err := prepareTemplateTarget(workDir, name)
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag == tar.TypeSymlink {
+			link, err := models.ExpandTemplateLink(name, header.Linkname, values)
+			if err != nil {
+				return err
+			}
+			if err := os.Symlink(filepath.FromSlash(link), target); err != nil {
+				return err
+			}
+			continue
+		}
+
+		mode := os.FileMode(0644)
+		if header.Mode&0111 != 0 {
+			mode = 0755
+		}
+		if err := writeTemplateFile(tr, header.Size, target, mode, values); err != nil {
+			return err
+		}
+	}
+}
+
+// writeTemplateFile 写入单个文件，超过替换上限的文件直接复制
+func writeTemplateFile(r io.Reader, size int64, target string, mode os.FileMode, values map[string]string) error {
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if size > models.TemplateSubstitutionMaxSize {
+		_, err = io.Copy(file, r)
+		return err
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(models.ExpandTemplateContent(content, values))
+	return err
+}
+
+// writeTemplatePipeline 写入模板配置的流水线文件，覆盖模板树中的同名文件
+func writeTemplatePipeline(workDir string, settings *models.RepositoryTemplate, values map[string]string) error {
+	name, err := models.ExpandTemplatePath(settings.PipelinePath, values)
+	if err != nil {
+		return err
+	}
+
+	target, err := prepareTemplateTarget(workDir, name)
+	if err != nil {
+		return err
+	}
+	content := models.ExpandTemplateVariables(settings.PipelineContent, values)
+	return os.WriteFile(target, []byte(content), 0644)
+}
+
+// prepareTemplateTarget 逐级创建相对路径name的父目录并返回目标路径。
+// 模板中的符号链接可能与后续条目的目录同名，父路径中的任何一级是符号链接或普通文件时拒绝写入，
+// 已存在的符号链接目标同样拒绝，保证写入不会离开工作目录
+func prepareTemplateTarget(workDir, name string) (string, error) {
+	dir := workDir
+	parts := strings.Split(name, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if err := os.Mkdir(dir, 0755); err != nil {
+				return "", err
+			}
+		case err != nil:
+			return "", err
+		case !info.IsDir():
+			return "", fmt.Errorf("%w: %s passes through a symlink or file", models.ErrInvalidTemplatePath, name)
+		}
+	}
+
+	target := filepath.Join(dir, parts[len(parts)-1])
+	if info, err := os.Lstat(target); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("%w: %s is a symlink", models.ErrInvalidTemplatePath, name)
+	}
+	return target, nil
+}
+
+// clearWorkTree 删除工作目录中除.git外的全部内容
+func clearWorkTree(workDir string) error {
+	entries, err := os.ReadDir(workDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(workDir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTemplateSettings 创建分支记录，并按模板设置受保护分支和Webhook
+func (s *gitService) applyTemplateSettings(ctx context.Context, repository *models.Repository, tmpl *templateSource) {
+	if err := s.createForkBranches(ctx, repository); err != nil {
+		s.logger.Error("Failed to create template branch records", zap.Error(err))
+	}
+
+	if len(tmpl.settings.ProtectedBranches) > 0 {
+		branches, err := s.repo.ListBranches(ctx, repository.ID)
+		if err != nil {
+			s.logger.Error("Failed to list branches for protection", zap.Error(err))
+		}
+		for _, branch := range branches {
+			if !matchesAnyRefPattern(tmpl.settings.ProtectedBranches, "refs/heads/"+branch.Name) {
+				continue
+			}
+			if err := s.repo.UpdateBranch(ctx, repository.ID, branch.Name, map[string]interface{}{
+				"is_protected": true,
+			}); err != nil {
+				s.logger.Error("Failed to protect branch",
+					zap.String("branch", branch.Name),
+					zap.Error(err))
+			}
+		}
+	}
+
+	for _, hook := range tmpl.settings.Webhooks {
+		webhook := &models.Webhook{
+			RepositoryID: repository.ID,
+			URL:          models.ExpandTemplateVariables(hook.URL, tmpl.values),
+			Secret:       hook.Secret,
+			Events:       hook.Events,
+			IsActive:     true,
+		}
+		if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+			s.logger.Error("Failed to create template webhook",
+				zap.String("url", webhook.URL),
+				zap.Error(err))
+		}
+	}
+
+	s.updateRepositoryBranchCount(ctx, repository.ID)
+	s.updateRepositoryCommitCount(ctx, repository.ID)
+}
+
+// matchesAnyRefPattern 引用是否匹配任一模式
+func matchesAnyRefPattern(patterns []string, ref string) bool {
+	for _, pattern := range patterns {
+		if models.MatchRefPattern(pattern, ref) {
+			return true
+		}
+	}
+	return false
+}
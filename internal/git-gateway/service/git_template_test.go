@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepositoryTemplateAccess(t *testing.T) {
+	access := models.RepositoryAccess{UserID: uuid.New(), TenantID: uuid.New()}
+
+	s, data, repo, _ := newTransferTestService(t)
+	data.admin = false
+	_, err := s.SetRepositoryTemplate(context.Background(), repo.ID, &models.SetRepositoryTemplateRequest{}, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+
+	// 从不可读的模板创建与模板不存在返回相同错误
+	templateID := repo.ID.String()
+	req := &models.CreateRepositoryRequest{Name: "copy", TemplateID: &templateID}
+	data.readable = false
+	_, err = s.resolveTemplateSource(context.Background(), uuid.New(), req, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryNotFound)
+
+	data.readable, data.writable = true, false
+	_, err = s.resolveTemplateSource(context.Background(), uuid.New(), req, access)
+	assert.ErrorIs(t, err, models.ErrProjectAccessDenied)
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 仓库迁移、改名与归档。
+// 迁移和改名会移动磁盘上的仓库目录并更新克隆地址，旧的项目和名称通过重定向表继续指向仓库；
+// PR、Webhook和流水线触发器都按仓库ID关联，不受影响。
+// 归档的仓库只读：推送、分支和标签变更、合并都会被拒绝，仍可克隆和读取。
+
+// TransferRepository 迁移仓库到另一个项目，可同时改名。
+// 需要源仓库的管理权限，以及同租户目标项目的写入权限
+func (s *gitService) TransferRepository(ctx context.Context, id uuid.UUID, req *models.TransferRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error) {
+	projectID, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID: %w", err)
+	}
+	if err := s.checkRepositoryAdmin(ctx, id, access); err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectWritable(ctx, projectID, access); err != nil {
+		return nil, err
+	}
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := repo.Name
+	if req.Name != nil {
+		name = *req.Name
+	}
+	return s.relocateRepository(ctx, repo, models.RepositoryLocation{ProjectID: projectID, Name: name}, access.UserID)
+}
+
+// RenameRepository 在当前项目内改名，需要仓库的管理权限
+func (s *gitService) RenameRepository(ctx context.Context, id uuid.UUID, name string, access models.RepositoryAccess) (*models.Repository, error) {
+	if err := s.checkRepositoryAdmin(ctx, id, access); err != nil {
+		return nil, err
+	}
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.relocateRepository(ctx, repo, models.RepositoryLocation{ProjectID: repo.ProjectID, Name: name}, access.UserID)
+}
+
+// ArchiveRepository 归档仓库，需要仓库的管理权限
+func (s *gitService) ArchiveRepository(ctx context.Context, id uuid.UUID, access models.RepositoryAccess) (*models.Repository, error) {
+	return s.setRepositoryArchived(ctx, id, true, access)
+}
+
+// UnarchiveRepository 取消归档，需要仓库的管理权限
+func (s *gitService) UnarchiveRepository(ctx context.Context, id uuid.UUID, access models.RepositoryAccess) (*models.Repository, error) {
+	return s.setRepositoryArchived(ctx, id, false, access)
+}
+
+// ResolveRepository 按项目和名称查找仓库，旧名称沿重定向解析到当前仓库。
+// 用户不可读的仓库与不存在返回相同错误
+func (s *gitService) ResolveRepository(ctx context.Context, projectID uuid.UUID, name string, access models.RepositoryAccess) (*models.RepositoryResolution, error) {
+	repo, redirected, err := s.repo.ResolveRepository(ctx, projectID, models.TrimRepositoryName(name))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", models.ErrRepositoryNotFound, name)
+		}
+		return nil, fmt.Errorf("failed to resolve repository: %w", err)
+	}
+	if err := s.checkRepositoryAccess(ctx, repo.ID, access, false); err != nil {
+		if errors.Is(err, models.ErrRepositoryNotFound) {
+			return nil, fmt.Errorf("%w: %s", models.ErrRepositoryNotFound, name)
+		}
+		return nil, err
+	}
+	return &models.RepositoryResolution{Repository: repo, Redirected: redirected}, nil
+}
+
+// checkRepositoryAdmin 校验用户能管理仓库，不可读的仓库按不存在处理
+func (s *gitService) checkRepositoryAdmin(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) error {
+	return requireRepositoryAdmin(ctx, s.repo, repositoryID, access)
+}
+
+// requireRepositoryAdmin 校验用户能管理仓库，供不持有gitService的服务共用
+func requireRepositoryAdmin(ctx context.Context, repo repository.GitRepository, repositoryID uuid.UUID, access models.RepositoryAccess) error {
+	if err := requireRepositoryReadable(ctx, repo, repositoryID, access); err != nil {
+		return err
+	}
+	admin, err := repo.CanAdminRepository(ctx, repositoryID, access)
+	if err != nil {
+		return fmt.Errorf("failed to check repository access: %w", err)
+	}
+	if !admin {
+		return models.ErrRepositoryAccessDenied
+	}
+	return nil
+}
+
+// relocateRepository 把仓库移动到新的项目和名称
+func (s *gitService) relocateRepository(ctx context.Context, repo *models.Repository, to models.RepositoryLocation, userID uuid.UUID) (*models.Repository, error) {
+	from := models.RepositoryLocation{ProjectID: repo.ProjectID, Name: repo.Name}
+	action := models.RelocationAction(from, to)
+	if action == "" {
+		return repo, nil
+	}
+	if repo.IsArchived() {
+		return nil, models.ErrRepositoryArchived
+	}
+	if err := models.ValidateRepositoryName(to.Name); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.GetRepositoryByProjectAndName(ctx, to.ProjectID, to.Name)
+	if err == nil && existing.ID != repo.ID {
+		return nil, fmt.Errorf("%w: %s", models.ErrRepositoryNameTaken, to.Name)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check repository name: %w", err)
+	}
+
+	oldPath := repo.GitPath
+	newPath := filepath.Join(s.gitRoot, to.ProjectID.String(), to.Name+".git")
+	// 已删除仓库的目录可能仍在等待异步清理
+	if _, err := os.Stat(newPath); err == nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrRepositoryNameTaken, to.Name)
+	}
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create project directory: %w", err)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return nil, fmt.Errorf("failed to move repository: %w", err)
+	}
+
+	// 共享对象的fork通过绝对路径引用本仓库的对象库
+	if err := s.relinkForkAlternates(ctx, repo.ID, oldPath, newPath); err != nil {
+		s.rollbackRelocation(ctx, repo.ID, oldPath, newPath)
+		return nil, err
+	}
+
+	updates := map[string]interface{}{
+		"git_path":   newPath,
+		"clone_url":  s.generateCloneURL(to.ProjectID, to.Name),
+		"ssh_url":    s.generateSSHURL(to.ProjectID, to.Name),
+		"updated_at": time.Now(),
+	}
+	if err := s.repo.RelocateRepository(ctx, repo.ID, to, updates); err != nil {
+		s.rollbackRelocation(ctx, repo.ID, oldPath, newPath)
+		return nil, fmt.Errorf("failed to update repository location: %w", err)
+	}
+
+	s.logger.Info("Repository relocated",
+		zap.String("repository_id", repo.ID.String()),
+		zap.String("action", string(action)),
+		zap.String("from_project_id", from.ProjectID.String()),
+		zap.String("from_name", from.Name),
+		zap.String("to_project_id", to.ProjectID.String()),
+		zap.String("to_name", to.Name))
+
+	updated, err := s.repo.GetRepositoryByID(ctx, repo.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository: %w", err)
+	}
+	s.notifyProjectService(ctx, updated, action, &from, userID)
+	return updated, nil
+}
+
+// rollbackRelocation 数据库更新失败时把仓库目录移回原处
+func (s *gitService) rollbackRelocation(ctx context.Context, repositoryID uuid.UUID, oldPath, newPath string) {
+	if err := s.relinkForkAlternates(ctx, repositoryID, newPath, oldPath); err != nil {
+		s.logger.Error("Failed to restore fork alternates", zap.String("repository_id", repositoryID.String()), zap.Error(err))
+	}
+	if err := os.Rename(newPath, oldPath); err != nil {
+		s.logger.Error("Failed to move repository back",
+			zap.String("repository_id", repositoryID.String()),
+			zap.String("path", newPath),
+			zap.Error(err))
+	}
+}
+
+// relinkForkAlternates 把共享对象的fork中指向旧对象库的alternates改为新路径
+func (s *gitService) relinkForkAlternates(ctx context.Context, repositoryID uuid.UUID, oldPath, newPath string) error {
+	oldObjects := filepath.Join(oldPath, "objects")
+	newObjects := filepath.Join(newPath, "objects")
+
+	const pageSize = 100
+	for page := 1; ; page++ {
+		forks, total, err := s.repo.ListForks(ctx, repositoryID, page, pageSize)
+		if err != nil {
+			return fmt.Errorf("failed to list forks: %w", err)
+		}
+
+		for _, fork := range forks {
+			if !fork.ObjectsShared {
+				continue
+			}
+			if err := rewriteAlternates(fork.GitPath, oldObjects, newObjects); err != nil {
+				return fmt.Errorf("failed to relink fork %s: %w", fork.ID, err)
+			}
+		}
+
+		if int64(page*pageSize) >= total {
+			return nil
+		}
+	}
+}
+
+// rewriteAlternates 替换alternates文件中的对象库路径
+func rewriteAlternates(repoPath, oldObjects, newObjects string) error {
+	alternates := filepath.Join(repoPath, "objects", "info", "alternates")
+	content, err := os.ReadFile(alternates)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	changed := false
+	for i, line := range lines {
+		if line != "" && filepath.Clean(line) == oldObjects {
+			lines[i] = newObjects
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	tmp := alternates + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, alternates)
+}
+
+// setRepositoryArchived 切换仓库归档状态
+func (s *gitService) setRepositoryArchived(ctx context.Context, id uuid.UUID, archived bool, access models.RepositoryAccess) (*models.Repository, error) {
+	if err := s.checkRepositoryAdmin(ctx, id, access); err != nil {
+		return nil, err
+	}
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status, action := models.RepositoryStatusActive, models.RepositoryActionUnarchived
+	if archived {
+		status, action = models.RepositoryStatusArchived, models.RepositoryActionArchived
+	}
+	if repo.Status == status {
+		return repo, nil
+	}
+
+	if err := s.repo.UpdateRepository(ctx, id, map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update repository status: %w", err)
+	}
+	repo.Status = status
+
+	s.logger.Info("Repository archive state changed",
+		zap.String("repository_id", id.String()),
+		zap.String("action", string(action)))
+	s.notifyProjectService(ctx, repo, action, nil, access.UserID)
+	return repo, nil
+}
+
+// notifyProjectService 通知项目服务仓库变化，失败只记录日志
+func (s *gitService) notifyProjectService(ctx context.Context, repo *models.Repository, action models.RepositoryLifecycleAction, previous *models.RepositoryLocation, userID uuid.UUID) {
+	if s.projects == nil {
+		return
+	}
+	if err := s.projects.NotifyRepository(ctx, repo, action, previous, userID); err != nil {
+		s.logger.Warn("Failed to notify project service",
+			zap.String("repository_id", repo.ID.String()),
+			zap.String("action", string(action)),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/cloud-platform/collaborative-dev/shared/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// transferTestRepo 迁移测试用的内存仓库数据，未覆盖的方法不会被调用
+type transferTestRepo struct {
+	repository.GitRepository
+
+	repos       map[uuid.UUID]*models.Repository
+	forks       []models.Repository
+	readable    bool
+	admin       bool
+	writable    bool
+	relocateErr error
+	relocated   map[string]interface{}
+}
+
+func (r *transferTestRepo) GetRepositoryByID(ctx context.Context, id uuid.UUID) (*models.Repository, error) {
+	repo, ok := r.repos[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *repo
+	return &copied, nil
+}
+
+func (r *transferTestRepo) GetRepositoryByProjectAndName(ctx context.Context, projectID uuid.UUID, name string) (*models.Repository, error) {
+	for _, repo := range r.repos {
+		if repo.ProjectID == projectID && repo.Name == name {
+			return repo, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *transferTestRepo) ListForks(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) ([]models.Repository, int64, error) {
+	return r.forks, int64(len(r.forks)), nil
+}
+
+func (r *transferTestRepo) RelocateRepository(ctx context.Context, id uuid.UUID, to models.RepositoryLocation, updates map[string]interface{}) error {
+	if r.relocateErr != nil {
+		return r.relocateErr
+	}
+	repo := r.repos[id]
+	repo.ProjectID = to.ProjectID
+	repo.Name = to.Name
+	repo.GitPath = updates["git_path"].(string)
+	r.relocated = updates
+	return nil
+}
+
+func (r *transferTestRepo) ResolveRepository(ctx context.Context, projectID uuid.UUID, name string) (*models.Repository, bool, error) {
+	repo, err := r.GetRepositoryByProjectAndName(ctx, projectID, name)
+	return repo, false, err
+}
+
+func (r *transferTestRepo) UpdateRepository(ctx context.Context, id uuid.UUID, updates map[string]interface{}) error {
+	if status, ok := updates["status"].(models.RepositoryStatus); ok {
+		r.repos[id].Status = status
+	}
+	return nil
+}
+
+func (r *transferTestRepo) CanReadRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	return r.readable, nil
+}
+
+func (r *transferTestRepo) CanWriteRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	return r.writable, nil
+}
+
+func (r *transferTestRepo) CanAdminRepository(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	return r.admin, nil
+}
+
+func (r *transferTestRepo) CanWriteProject(ctx context.Context, projectID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	return r.writable, nil
+}
+
+func (r *transferTestRepo) CanAdminProject(ctx context.Context, projectID uuid.UUID, access models.RepositoryAccess) (bool, error) {
+	return r.admin, nil
+}
+
+// newTransferTestService 在临时目录中创建一个仓库目录和一个共享其对象的fork
+func newTransferTestService(t *testing.T) (*gitService, *transferTestRepo, *models.Repository, string) {
+	root := t.TempDir()
+	projectID := uuid.New()
+	repo := &models.Repository{
+		ID:        uuid.New(),
+		ProjectID: projectID,
+		Name:      "app",
+		GitPath:   filepath.Join(root, projectID.String(), "app.git"),
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(repo.GitPath, "objects"), 0755))
+
+	forkPath := filepath.Join(root, "fork.git")
+	alternates := filepath.Join(forkPath, "objects", "info", "alternates")
+	require.NoError(t, os.MkdirAll(filepath.Dir(alternates), 0755))
+	require.NoError(t, os.WriteFile(alternates, []byte(filepath.Join(repo.GitPath, "objects")+"\n"), 0644))
+
+	data := &transferTestRepo{
+		repos:    map[uuid.UUID]*models.Repository{repo.ID: repo},
+		forks:    []models.Repository{{ID: uuid.New(), GitPath: forkPath, ObjectsShared: true}},
+		readable: true,
+		admin:    true,
+		writable: true,
+	}
+	s := &gitService{
+		repo:    data,
+		logger:  zap.NewNop(),
+		gitRoot: root,
+		config:  &config.Config{},
+	}
+	return s, data, repo, alternates
+}
+
+func TestRelocateRepository(t *testing.T) {
+	s, data, repo, alternates := newTransferTestService(t)
+	oldPath := repo.GitPath
+	to := models.RepositoryLocation{ProjectID: uuid.New(), Name: "renamed"}
+
+	updated, err := s.relocateRepository(context.Background(), repo, to, uuid.New())
+	require.NoError(t, err)
+
+	newPath := filepath.Join(s.gitRoot, to.ProjectID.String(), "renamed.git")
+	assert.Equal(t, newPath, updated.GitPath)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.DirExists(t, newPath)
+	assert.NoDirExists(t, oldPath)
+	assert.Contains(t, data.relocated["clone_url"], to.ProjectID.String()+"/renamed.git")
+
+	content, err := os.ReadFile(alternates)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(newPath, "objects")+"\n", string(content))
+}
+
+func TestRelocateRepositoryRollback(t *testing.T) {
+	s, data, repo, alternates := newTransferTestService(t)
+	oldPath := repo.GitPath
+	data.relocateErr = errors.New("database unavailable")
+
+	_, err := s.relocateRepository(context.Background(), repo, models.RepositoryLocation{ProjectID: uuid.New(), Name: "app"}, uuid.New())
+	require.Error(t, err)
+
+	// 数据库更新失败时目录和fork的alternates都恢复原状
+	assert.DirExists(t, oldPath)
+	content, err := os.ReadFile(alternates)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(oldPath, "objects")+"\n", string(content))
+}
+
+func TestRelocateRepositoryNameTaken(t *testing.T) {
+	s, data, repo, _ := newTransferTestService(t)
+	other := &models.Repository{ID: uuid.New(), ProjectID: repo.ProjectID, Name: "taken"}
+	data.repos[other.ID] = other
+
+	_, err := s.relocateRepository(context.Background(), repo, models.RepositoryLocation{ProjectID: repo.ProjectID, Name: "taken"}, uuid.New())
+	assert.ErrorIs(t, err, models.ErrRepositoryNameTaken)
+	assert.DirExists(t, repo.GitPath)
+
+	// 位置未变化时直接返回
+	same, err := s.relocateRepository(context.Background(), repo, models.RepositoryLocation{ProjectID: repo.ProjectID, Name: repo.Name}, uuid.New())
+	require.NoError(t, err)
+	assert.Equal(t, repo.GitPath, same.GitPath)
+}
+
+func TestTransferRepositoryAccess(t *testing.T) {
+	access := models.RepositoryAccess{UserID: uuid.New(), TenantID: uuid.New()}
+	req := &models.TransferRepositoryRequest{ProjectID: uuid.New().String()}
+
+	s, data, repo, _ := newTransferTestService(t)
+	data.admin = false
+	_, err := s.TransferRepository(context.Background(), repo.ID, req, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+	assert.DirExists(t, repo.GitPath)
+
+	s, data, repo, _ = newTransferTestService(t)
+	data.writable = false
+	_, err = s.TransferRepository(context.Background(), repo.ID, req, access)
+	assert.ErrorIs(t, err, models.ErrProjectAccessDenied)
+	assert.DirExists(t, repo.GitPath)
+
+	s, _, repo, _ = newTransferTestService(t)
+	updated, err := s.TransferRepository(context.Background(), repo.ID, req, access)
+	require.NoError(t, err)
+	assert.Equal(t, req.ProjectID, updated.ProjectID.String())
+}
+
+func TestArchiveRepositoryAccess(t *testing.T) {
+	access := models.RepositoryAccess{UserID: uuid.New(), TenantID: uuid.New()}
+
+	s, data, repo, _ := newTransferTestService(t)
+	data.admin = false
+	_, err := s.ArchiveRepository(context.Background(), repo.ID, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+	assert.NotEqual(t, models.RepositoryStatusArchived, data.repos[repo.ID].Status)
+
+	data.readable = false
+	_, err = s.UnarchiveRepository(context.Background(), repo.ID, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryNotFound)
+
+	data.readable, data.admin = true, true
+	archived, err := s.ArchiveRepository(context.Background(), repo.ID, access)
+	require.NoError(t, err)
+	assert.Equal(t, models.RepositoryStatusArchived, archived.Status)
+}
+
+func TestRenameRepositoryAccess(t *testing.T) {
+	access := models.RepositoryAccess{UserID: uuid.New(), TenantID: uuid.New()}
+
+	s, data, repo, _ := newTransferTestService(t)
+	data.admin = false
+	_, err := s.RenameRepository(context.Background(), repo.ID, "renamed", access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+	assert.DirExists(t, repo.GitPath)
+}
+
+func TestResolveRepositoryAccess(t *testing.T) {
+	access := models.RepositoryAccess{UserID: uuid.New(), TenantID: uuid.New()}
+
+	s, data, repo, _ := newTransferTestService(t)
+	resolution, err := s.ResolveRepository(context.Background(), repo.ProjectID, "app.git", access)
+	require.NoError(t, err)
+	assert.Equal(t, repo.ID, resolution.Repository.ID)
+
+	// 不可读的仓库与不存在的仓库返回相同错误，不泄露仓库ID
+	data.readable = false
+	_, err = s.ResolveRepository(context.Background(), repo.ProjectID, "app", access)
+	assert.ErrorIs(t, err, models.ErrRepositoryNotFound)
+	assert.NotContains(t, err.Error(), repo.ID.String())
+}
+
+func TestRewriteAlternates(t *testing.T) {
+	repoPath := t.TempDir()
+	alternates := filepath.Join(repoPath, "objects", "info", "alternates")
+
+	// 没有alternates文件时不做任何事
+	require.NoError(t, rewriteAlternates(repoPath, "/old/objects", "/new/objects"))
+	assert.NoFileExists(t, alternates)
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(alternates), 0755))
+	require.NoError(t, os.WriteFile(alternates, []byte("/other/objects\n/old/objects/\n"), 0644))
+	require.NoError(t, rewriteAlternates(repoPath, "/old/objects", "/new/objects"))
+	content, err := os.ReadFile(alternates)
+	require.NoError(t, err)
+	assert.Equal(t, "/other/objects\n/new/objects\n", string(content))
+
+	// 不引用旧路径时文件保持不变
+	require.NoError(t, rewriteAlternates(repoPath, "/missing/objects", "/new/objects"))
+	content, err = os.ReadFile(alternates)
+	require.NoError(t, err)
+	assert.Equal(t, "/other/objects\n/new/objects\n", string(content))
+}
@@ -0,0 +1,546 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/storage"
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxForkDepth   = 10  // 下载时沿fork链查找上游对象的最大层数
+	lfsGCBatchSize = 500 // 垃圾回收每批处理的仓库/对象数
+)
+
+// LFSService Git LFS服务接口
+type LFSService interface {
+	// 批量接口
+	Batch(ctx context.Context, projectID uuid.UUID, repoName string, req *models.LFSBatchRequest, access models.LFSAccess) (*models.LFSBatchResponse, error)
+
+	// 对象传输，调用方已校验批量接口签发的对象令牌
+	UploadObject(ctx context.Context, repositoryID uuid.UUID, oid string, size int64, data io.Reader) error
+	DownloadObject(ctx context.Context, repositoryID uuid.UUID, oid string) (io.ReadSeekCloser, error)
+	VerifyObject(ctx context.Context, repositoryID uuid.UUID, object *models.LFSObjectSpec) error
+
+	// 管理
+	ListObjects(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) (*models.LFSObjectListResponse, error)
+	CollectGarbage(ctx context.Context) (*models.LFSGCResult, error)
+}
+
+// lfsService Git LFS服务实现
+type lfsService struct {
+	repo         repository.GitRepository
+	lfsRepo      repository.LFSRepository
+	store        storage.LFSStore
+	tokens       *auth.JWTService
+	logger       *zap.Logger
+	baseURL      string        // 对象传输链接的地址前缀
+	actionExpiry time.Duration // 对象令牌有效期
+	gracePeriod  time.Duration // 垃圾回收宽限期
+}
+
+// NewLFSService 创建Git LFS服务
+func NewLFSService(repo repository.GitRepository, lfsRepo repository.LFSRepository, store storage.LFSStore, tokens *auth.JWTService, logger *zap.Logger, baseURL string, actionExpiry, gracePeriod time.Duration) LFSService {
+	return &lfsService{
+		repo:         repo,
+		lfsRepo:      lfsRepo,
+		store:        store,
+		tokens:       tokens,
+		logger:       logger,
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		actionExpiry: actionExpiry,
+		gracePeriod:  gracePeriod,
+	}
+}
+
+// Batch 处理批量接口请求，为需要传输的对象签发上传/下载链接
+func (s *lfsService) Batch(ctx context.Context, projectID uuid.UUID, repoName string, req *models.LFSBatchRequest, access models.LFSAccess) (*models.LFSBatchResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 仓库改名或迁移后旧地址仍可使用
+	repo, _, err := s.repo.ResolveRepository(ctx, projectID, models.TrimRepositoryName(repoName))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRepositoryNotFound
+		}
+		return nil, fmt.Errorf("获取仓库失败: %w", err)
+	}
+	if err := s.checkAccess(ctx, repo, access, req.Operation == models.LFSOperationUpload); err != nil {
+		return nil, err
+	}
+
+	oids := make([]string, 0, len(req.Objects))
+	for _, object := range req.Objects {
+		oids = append(oids, object.OID)
+	}
+	links, err := s.lfsRepo.ListLinkedObjects(ctx, repo.ID, oids)
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库LFS对象失败: %w", err)
+	}
+	linked := make(map[string]int64, len(links))
+	for _, link := range links {
+		linked[link.OID] = link.Size
+	}
+
+	resp := &models.LFSBatchResponse{
+		Transfer: models.LFSTransferBasic,
+		Objects:  make([]models.LFSObjectResult, 0, len(req.Objects)),
+		HashAlgo: models.LFSHashAlgoSHA256,
+	}
+
+	var pendingBytes int64
+	for _, object := range req.Objects {
+		result := models.LFSObjectResult{OID: object.OID, Size: object.Size}
+		if err := models.ValidateLFSOID(object.OID); err != nil || object.Size < 0 {
+			result.Error = &models.LFSObjectError{Code: 422, Message: "invalid object oid or size"}
+			resp.Objects = append(resp.Objects, result)
+			continue
+		}
+
+		if req.Operation == models.LFSOperationUpload {
+			// 已关联且存储中存在的对象无需再传；其他仓库已有的对象仍需上传，以证明持有内容
+			if size, ok := linked[object.OID]; ok && size == object.Size {
+				if _, err := s.store.Stat(ctx, object.OID); err == nil {
+					resp.Objects = append(resp.Objects, result)
+					continue
+				}
+			}
+			pendingBytes += object.Size
+			result.Actions, err = s.objectActions(repo.ID, object.OID, access, models.LFSOperationUpload, "verify")
+		} else {
+			size, ok := linked[object.OID]
+			if !ok {
+				size, ok, err = s.linkFromUpstream(ctx, repo, object.OID)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if !ok {
+				result.Error = &models.LFSObjectError{Code: 404, Message: "object does not exist"}
+				resp.Objects = append(resp.Objects, result)
+				continue
+			}
+			result.Size = size
+			result.Actions, err = s.objectActions(repo.ID, object.OID, access, models.LFSOperationDownload)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Authenticated = true
+		resp.Objects = append(resp.Objects, result)
+	}
+
+	if pendingBytes > 0 {
+		if err := s.checkQuota(ctx, repo.ID, pendingBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// UploadObject 保存上传的对象并关联到仓库
+func (s *lfsService) UploadObject(ctx context.Context, repositoryID uuid.UUID, oid string, size int64, data io.Reader) error {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return err
+	}
+	if err := repo.CheckWritable(); err != nil {
+		return err
+	}
+
+	links, err := s.lfsRepo.ListLinkedObjects(ctx, repositoryID, []string{oid})
+	if err != nil {
+		return fmt.Errorf("获取仓库LFS对象失败: %w", err)
+	}
+	if len(links) == 0 {
+		if err := s.checkQuota(ctx, repositoryID, size); err != nil {
+			return err
+		}
+	}
+
+	if err := s.store.Put(ctx, oid, size, data); err != nil {
+		return err
+	}
+	if err := s.lfsRepo.CreateObject(ctx, &models.LFSObject{OID: oid, Size: size}); err != nil {
+		return fmt.Errorf("保存LFS对象失败: %w", err)
+	}
+	if _, err := s.lfsRepo.LinkObject(ctx, repositoryID, oid, size); err != nil {
+		return fmt.Errorf("关联LFS对象失败: %w", err)
+	}
+
+	s.logger.Info("LFS对象上传成功",
+		zap.String("repository_id", repositoryID.String()),
+		zap.String("oid", oid),
+		zap.Int64("size", size))
+	return nil
+}
+
+// DownloadObject 打开仓库的对象
+func (s *lfsService) DownloadObject(ctx context.Context, repositoryID uuid.UUID, oid string) (io.ReadSeekCloser, error) {
+	links, err := s.lfsRepo.ListLinkedObjects(ctx, repositoryID, []string{oid})
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库LFS对象失败: %w", err)
+	}
+	if len(links) == 0 {
+		return nil, models.ErrLFSObjectNotFound
+	}
+	return s.store.Open(ctx, oid)
+}
+
+// VerifyObject 确认对象已上传并关联到仓库
+func (s *lfsService) VerifyObject(ctx context.Context, repositoryID uuid.UUID, object *models.LFSObjectSpec) error {
+	links, err := s.lfsRepo.ListLinkedObjects(ctx, repositoryID, []string{object.OID})
+	if err != nil {
+		return fmt.Errorf("获取仓库LFS对象失败: %w", err)
+	}
+	if len(links) == 0 {
+		return models.ErrLFSObjectNotFound
+	}
+	if links[0].Size != object.Size {
+		return fmt.Errorf("%w: expected %d bytes, stored %d", models.ErrLFSSizeMismatch, object.Size, links[0].Size)
+	}
+	if _, err := s.store.Stat(ctx, object.OID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListObjects 分页获取仓库的LFS对象
+func (s *lfsService) ListObjects(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) (*models.LFSObjectListResponse, error) {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	objects, total, err := s.lfsRepo.ListRepositoryObjects(ctx, repositoryID, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取仓库LFS对象失败: %w", err)
+	}
+
+	return &models.LFSObjectListResponse{
+		Objects:   objects,
+		Total:     total,
+		TotalSize: repo.LFSSize,
+		Page:      page,
+		PageSize:  pageSize,
+	}, nil
+}
+
+// CollectGarbage 回收LFS对象
+// 先解除仓库中不再被任何引用指向的对象关联，再删除未关联任何仓库的对象；
+// 宽限期内新关联或新上传的对象不参与回收，避免与推送中的上传竞争
+func (s *lfsService) CollectGarbage(ctx context.Context) (*models.LFSGCResult, error) {
+	start := time.Now()
+	cutoff := start.Add(-s.gracePeriod)
+	result := &models.LFSGCResult{}
+
+	after := uuid.Nil
+	for {
+		ids, err := s.lfsRepo.ListRepositoriesWithObjects(ctx, after, lfsGCBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取仓库列表失败: %w", err)
+		}
+		for _, id := range ids {
+			if err := s.collectRepository(ctx, id, cutoff, result); err != nil {
+				// 单个仓库失败不影响其他仓库，未确认的关联保持不变
+				s.logger.Warn("LFS仓库对象回收失败", zap.String("repository_id", id.String()), zap.Error(err))
+			}
+			result.RepositoriesScanned++
+		}
+		if len(ids) < lfsGCBatchSize {
+			break
+		}
+		after = ids[len(ids)-1]
+	}
+
+	for {
+		objects, err := s.lfsRepo.ListOrphanObjects(ctx, cutoff, lfsGCBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取待回收对象失败: %w", err)
+		}
+		deletedInBatch := 0
+		for _, object := range objects {
+			deleted, err := s.lfsRepo.DeleteOrphanObject(ctx, object.OID)
+			if err != nil {
+				return nil, err
+			}
+			if !deleted {
+				continue
+			}
+			if err := s.store.Delete(ctx, object.OID); err != nil {
+				s.logger.Warn("删除LFS对象文件失败", zap.String("oid", object.OID), zap.Error(err))
+			}
+			deletedInBatch++
+			result.ObjectsDeleted++
+			result.BytesFreed += object.Size
+		}
+		// 整批都被重新关联时停止，避免重复获取同一批对象
+		if len(objects) < lfsGCBatchSize || deletedInBatch == 0 {
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+	s.logger.Info("LFS垃圾回收完成",
+		zap.Int("repositories_scanned", result.RepositoriesScanned),
+		zap.Int("links_deleted", result.LinksDeleted),
+		zap.Int("objects_deleted", result.ObjectsDeleted),
+		zap.Int64("bytes_freed", result.BytesFreed),
+		zap.Duration("duration", result.Duration))
+	return result, nil
+}
+
+// collectRepository 解除仓库中不再被引用的对象关联
+func (s *lfsService) collectRepository(ctx context.Context, repositoryID uuid.UUID, cutoff time.Time, result *models.LFSGCResult) error {
+	links, err := s.lfsRepo.ListLinksBefore(ctx, repositoryID, cutoff)
+	if err != nil {
+		return fmt.Errorf("获取仓库LFS对象失败: %w", err)
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	// 仓库已删除时全部解除关联
+	referenced := map[string]bool{}
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err == nil {
+		referenced, err = s.referencedObjects(ctx, repo.GitPath)
+		if err != nil {
+			return err
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("获取仓库失败: %w", err)
+	}
+
+	var stale []string
+	for _, link := range links {
+		if !referenced[link.OID] {
+			stale = append(stale, link.OID)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	if _, err := s.lfsRepo.UnlinkObjects(ctx, repositoryID, stale); err != nil {
+		return fmt.Errorf("解除LFS对象关联失败: %w", err)
+	}
+	result.LinksDeleted += len(stale)
+	return nil
+}
+
+// referencedObjects 扫描仓库全部引用可达的LFS指针，返回被引用的对象ID
+func (s *lfsService) referencedObjects(ctx context.Context, repoPath string) (map[string]bool, error) {
+	objects, err := s.runGit(ctx, repoPath, nil, "rev-list", "--objects", "--all")
+	if err != nil {
+		return nil, fmt.Errorf("列出仓库对象失败: %w", err)
+	}
+
+	var shas bytes.Buffer
+	for _, line := range strings.Split(objects, "\n") {
+		if sha, _, _ := strings.Cut(line, " "); sha != "" {
+			shas.WriteString(sha + "\n")
+		}
+	}
+	if shas.Len() == 0 {
+		return map[string]bool{}, nil
+	}
+
+	// 只有不超过指针大小上限的blob才可能是LFS指针
+	checks, err := s.runGit(ctx, repoPath, &shas, "cat-file", "--batch-check=%(objectname) %(objecttype) %(objectsize)")
+	if err != nil {
+		return nil, fmt.Errorf("读取对象信息失败: %w", err)
+	}
+	var candidates bytes.Buffer
+	for _, line := range strings.Split(checks, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		if size, err := strconv.Atoi(fields[2]); err == nil && size <= models.LFSPointerMaxSize {
+			candidates.WriteString(fields[0] + "\n")
+		}
+	}
+
+	referenced := map[string]bool{}
+	if candidates.Len() == 0 {
+		return referenced, nil
+	}
+
+	contents, err := s.runGit(ctx, repoPath, &candidates, "cat-file", "--batch")
+	if err != nil {
+		return nil, fmt.Errorf("读取指针内容失败: %w", err)
+	}
+	reader := bufio.NewReader(strings.NewReader(contents))
+	for {
+		header, err := reader.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取对象头失败: %w", err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("对象读取失败: %s", strings.TrimSpace(header))
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("无效的对象大小: %w", err)
+		}
+		content := make([]byte, size+1) // 内容后的换行符
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return nil, fmt.Errorf("读取对象内容失败: %w", err)
+		}
+		if oid, _, ok := models.ParseLFSPointer(content[:size]); ok {
+			referenced[oid] = true
+		}
+	}
+	return referenced, nil
+}
+
+// runGit 执行git命令并返回标准输出
+func (s *lfsService) runGit(ctx context.Context, repoPath string, stdin io.Reader, args ...string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	cmd.Stdin = stdin
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(output), nil
+}
+
+// checkAccess 校验用户对仓库的读写权限，不可读时与不存在返回相同错误
+func (s *lfsService) checkAccess(ctx context.Context, repo *models.Repository, access models.LFSAccess, write bool) error {
+	readable, err := s.lfsRepo.CanAccessRepository(ctx, repo.ID, access, false)
+	if err != nil {
+		return fmt.Errorf("校验仓库权限失败: %w", err)
+	}
+	if !readable {
+		return models.ErrRepositoryNotFound
+	}
+	if !write {
+		return nil
+	}
+
+	if err := repo.CheckWritable(); err != nil {
+		return err
+	}
+	writable, err := s.lfsRepo.CanAccessRepository(ctx, repo.ID, access, true)
+	if err != nil {
+		return fmt.Errorf("校验仓库权限失败: %w", err)
+	}
+	if !writable {
+		return models.ErrLFSAccessDenied
+	}
+	return nil
+}
+
+// checkQuota 校验写入size字节后是否超出租户存储配额
+func (s *lfsService) checkQuota(ctx context.Context, repositoryID uuid.UUID, size int64) error {
+	usage, err := s.lfsRepo.GetTenantStorageUsage(ctx, repositoryID)
+	if err != nil {
+		return err
+	}
+	if usage.Exceeds(size) {
+		return fmt.Errorf("%w: %d of %d bytes used, %d more requested", models.ErrLFSQuotaExceeded, usage.UsedBytes(), usage.QuotaBytes, size)
+	}
+	return nil
+}
+
+// linkFromUpstream fork仓库中找不到的对象沿fork链向上游查找，找到时关联到fork
+func (s *lfsService) linkFromUpstream(ctx context.Context, repo *models.Repository, oid string) (int64, bool, error) {
+	upstreamID := repo.ForkedFromID
+	for depth := 0; upstreamID != nil && depth < maxForkDepth; depth++ {
+		links, err := s.lfsRepo.ListLinkedObjects(ctx, *upstreamID, []string{oid})
+		if err != nil {
+			return 0, false, fmt.Errorf("获取上游LFS对象失败: %w", err)
+		}
+		if len(links) > 0 {
+			if _, err := s.lfsRepo.LinkObject(ctx, repo.ID, oid, links[0].Size); err != nil {
+				return 0, false, fmt.Errorf("关联LFS对象失败: %w", err)
+			}
+			return links[0].Size, true, nil
+		}
+
+		upstream, err := s.repo.GetRepositoryByID(ctx, *upstreamID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, false, nil
+			}
+			return 0, false, fmt.Errorf("获取上游仓库失败: %w", err)
+		}
+		upstreamID = upstream.ForkedFromID
+	}
+	return 0, false, nil
+}
+
+// objectActions 为对象签发操作链接，verify与upload共用上传令牌
+func (s *lfsService) objectActions(repositoryID uuid.UUID, oid string, access models.LFSAccess, operation string, extra ...string) (map[string]*models.LFSAction, error) {
+	token, _, err := s.tokens.GenerateLFSToken(access.UserID, access.TenantID, repositoryID, oid, operation, s.actionExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	href := fmt.Sprintf("%s/api/v1/lfs/objects/%s/%s", s.baseURL, repositoryID, oid)
+	header := map[string]string{"Authorization": "Bearer " + token}
+	expiresIn := int(s.actionExpiry.Seconds())
+
+	actions := map[string]*models.LFSAction{
+		operation: {Href: href, Header: header, ExpiresIn: expiresIn},
+	}
+	for _, name := range extra {
+		actions[name] = &models.LFSAction{Href: href + "/" + name, Header: header, ExpiresIn: expiresIn}
+	}
+	return actions, nil
+}
+
+// StartLFSGarbageCollector 按固定间隔执行LFS垃圾回收，直到ctx取消
+func StartLFSGarbageCollector(ctx context.Context, svc LFSService, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		logger.Info("LFS垃圾回收已禁用")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.CollectGarbage(ctx); err != nil {
+				logger.Error("LFS垃圾回收失败", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,566 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/service/notification"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 仓库维护实现
+//
+// 推送只累加计数，后台按推送量调度：平时执行增量repack，每隔 maintenanceFullGCInterval 执行一次完整gc。
+// 每次维护后执行fsck并根据 git count-objects 重新计算仓库大小和对象数。
+// 被fork借用对象库的上游执行gc时不清理不可达对象（--prune=never），fork可能仍引用上游已删除分支上的对象；
+// fork自身的repack/gc只处理本地对象（-l），不会影响上游。
+
+// 仓库维护参数
+const (
+	maintenanceTimeout        = time.Hour          // 单次维护的超时时间
+	maintenanceConcurrency    = 2                  // 同时进行的维护数，gc和fsck占用大量IO
+	maintenanceClaimBatchSize = 10                 // 每次领取的到期仓库数
+	maintenanceFullGCInterval = 7 * 24 * time.Hour // 定时维护执行完整gc的间隔
+	maxFsckOutputLength       = 4000               // 保存的fsck输出最大长度
+)
+
+// MaintenanceService 仓库维护服务接口
+type MaintenanceService interface {
+	PushListener
+
+	TriggerMaintenance(ctx context.Context, repositoryID uuid.UUID, req *models.TriggerMaintenanceRequest, userID uuid.UUID) (*models.MaintenanceRun, error)
+	GetStatus(ctx context.Context, repositoryID uuid.UUID) (*models.MaintenanceStatusResponse, error)
+	ListRuns(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) (*models.MaintenanceRunListResponse, error)
+
+	// 定时维护
+	Start(ctx context.Context) error
+	Stop() error
+}
+
+// maintenanceService 仓库维护服务实现
+type maintenanceService struct {
+	repo            repository.GitRepository
+	maintenanceRepo repository.MaintenanceRepository
+	alerts          *notification.NotificationManager // 为空时失败只记录日志
+	logger          *zap.Logger
+
+	interval      time.Duration // 检查到期仓库的间隔，<=0 时不启动定时维护
+	pushThreshold int           // 推送次数达到该值时立即维护
+	maxAge        time.Duration // 有推送的仓库最长间隔多久维护一次
+
+	semaphore chan struct{} // 限制并发的维护数
+
+	mu     sync.Mutex
+	runs   map[uuid.UUID]struct{} // 进行中的维护，key为仓库ID
+	ctx    context.Context        // 后台任务的根上下文，Stop时取消
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMaintenanceService 创建仓库维护服务
+func NewMaintenanceService(repo repository.GitRepository, maintenanceRepo repository.MaintenanceRepository, alerts *notification.NotificationManager, logger *zap.Logger, interval time.Duration, pushThreshold int, maxAge time.Duration) MaintenanceService {
+	ctx, cancel := context.WithCancel(context.Background())
+	if pushThreshold <= 0 {
+		pushThreshold = 1
+	}
+	return &maintenanceService{
+		repo:            repo,
+		maintenanceRepo: maintenanceRepo,
+		alerts:          alerts,
+		logger:          logger,
+		interval:        interval,
+		pushThreshold:   pushThreshold,
+		maxAge:          maxAge,
+		semaphore:       make(chan struct{}, maintenanceConcurrency),
+		runs:            make(map[uuid.UUID]struct{}),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+}
+
+// OnPush 记录推送，由定时任务决定何时维护
+func (s *maintenanceService) OnPush(repositoryID uuid.UUID, ref string) {
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	defer cancel()
+
+	if err := s.maintenanceRepo.RecordPush(ctx, repositoryID); err != nil {
+		s.logger.Error("记录仓库推送失败", zap.String("repository_id", repositoryID.String()), zap.Error(err))
+	}
+}
+
+// TriggerMaintenance 立即在后台维护仓库，返回创建的维护记录
+func (s *maintenanceService) TriggerMaintenance(ctx context.Context, repositoryID uuid.UUID, req *models.TriggerMaintenanceRequest, userID uuid.UUID) (*models.MaintenanceRun, error) {
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.acquire(repositoryID) {
+		return nil, models.ErrMaintenanceRunning
+	}
+
+	// 推迟定时维护，避免其他实例同时处理该仓库
+	err = s.maintenanceRepo.UpdateState(ctx, repositoryID, map[string]interface{}{
+		"pushes_since_run": 0,
+		"retry_after":      time.Now().Add(maintenanceTimeout),
+	})
+	if err != nil {
+		s.release(repositoryID)
+		return nil, fmt.Errorf("更新维护状态失败: %w", err)
+	}
+
+	run, err := s.createRun(ctx, repo, models.MaintenanceTriggerManual, req.Mode, &userID)
+	if err != nil {
+		s.release(repositoryID)
+		return nil, err
+	}
+
+	s.execute(run, repo)
+	return run, nil
+}
+
+// GetStatus 获取仓库维护状态及最近的维护记录
+func (s *maintenanceService) GetStatus(ctx context.Context, repositoryID uuid.UUID) (*models.MaintenanceStatusResponse, error) {
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+
+	state, err := s.maintenanceRepo.GetState(ctx, repositoryID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("获取维护状态失败: %w", err)
+		}
+		// 尚无推送和维护记录
+		state = &models.RepositoryMaintenance{
+			RepositoryID: repositoryID,
+			Health:       models.RepositoryHealthUnknown,
+		}
+	}
+
+	runs, _, err := s.maintenanceRepo.ListRuns(ctx, repositoryID, 1, 10)
+	if err != nil {
+		return nil, fmt.Errorf("获取维护记录失败: %w", err)
+	}
+
+	return &models.MaintenanceStatusResponse{
+		State: state,
+		Runs:  runs,
+	}, nil
+}
+
+// ListRuns 分页获取仓库的维护记录
+func (s *maintenanceService) ListRuns(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) (*models.MaintenanceRunListResponse, error) {
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	runs, total, err := s.maintenanceRepo.ListRuns(ctx, repositoryID, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取维护记录失败: %w", err)
+	}
+
+	return &models.MaintenanceRunListResponse{
+		Runs:     runs,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// Start 启动定时维护
+func (s *maintenanceService) Start(ctx context.Context) error {
+	// 服务重启会中断进行中的维护，标记为失败，仓库在租约到期后重新调度
+	if count, err := s.maintenanceRepo.FailInterruptedRuns(ctx, "维护因服务重启中断"); err != nil {
+		s.logger.Error("标记中断的维护记录失败", zap.Error(err))
+	} else if count > 0 {
+		s.logger.Warn("已将中断的维护记录标记为失败", zap.Int64("count", count))
+	}
+
+	if s.interval <= 0 {
+		s.logger.Info("定时仓库维护已禁用")
+		return nil
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.scheduleDueRepositories()
+			}
+		}
+	}()
+
+	s.logger.Info("仓库维护调度已启动",
+		zap.Duration("interval", s.interval),
+		zap.Int("push_threshold", s.pushThreshold),
+		zap.Duration("max_age", s.maxAge))
+	return nil
+}
+
+// Stop 停止调度并取消进行中的维护
+func (s *maintenanceService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("仓库维护调度已停止")
+	return nil
+}
+
+// scheduleDueRepositories 领取到期的仓库并维护
+func (s *maintenanceService) scheduleDueRepositories() {
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	states, err := s.maintenanceRepo.ClaimDueRepositories(ctx, now, s.pushThreshold, s.maxAge, maintenanceTimeout, maintenanceClaimBatchSize)
+	if err != nil {
+		s.logger.Error("领取待维护仓库失败", zap.Error(err))
+		return
+	}
+
+	for _, state := range states {
+		repo, err := s.repo.GetRepositoryByID(ctx, state.RepositoryID)
+		if err != nil {
+			s.logger.Warn("待维护仓库不存在", zap.String("repository_id", state.RepositoryID.String()), zap.Error(err))
+			continue
+		}
+		if !s.acquire(repo.ID) {
+			continue
+		}
+
+		mode := models.MaintenanceModeRepack
+		if state.LastFullGCAt == nil || now.Sub(*state.LastFullGCAt) >= maintenanceFullGCInterval {
+			mode = models.MaintenanceModeGC
+		}
+
+		run, err := s.createRun(ctx, repo, models.MaintenanceTriggerScheduled, mode, nil)
+		if err != nil {
+			s.release(repo.ID)
+			s.logger.Error("创建维护记录失败", zap.String("repository_id", repo.ID.String()), zap.Error(err))
+			continue
+		}
+		s.execute(run, repo)
+	}
+}
+
+// acquire 标记仓库正在维护，已在维护时返回false
+func (s *maintenanceService) acquire(repositoryID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.runs[repositoryID]; ok {
+		return false
+	}
+	s.runs[repositoryID] = struct{}{}
+	return true
+}
+
+// release 清除仓库的维护标记
+func (s *maintenanceService) release(repositoryID uuid.UUID) {
+	s.mu.Lock()
+	delete(s.runs, repositoryID)
+	s.mu.Unlock()
+}
+
+// createRun 创建执行中的维护记录
+func (s *maintenanceService) createRun(ctx context.Context, repo *models.Repository, trigger models.MaintenanceTrigger, mode models.MaintenanceMode, userID *uuid.UUID) (*models.MaintenanceRun, error) {
+	run := &models.MaintenanceRun{
+		ID:           uuid.New(),
+		RepositoryID: repo.ID,
+		Trigger:      trigger,
+		Mode:         mode,
+		Status:       models.MaintenanceStatusRunning,
+		TriggeredBy:  userID,
+		PruneObjects: !sharesObjectsWithForks(repo),
+		StartedAt:    time.Now(),
+	}
+	if err := s.maintenanceRepo.CreateRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("创建维护记录失败: %w", err)
+	}
+	return run, nil
+}
+
+// execute 在后台执行维护，调用前须已通过 acquire 标记仓库
+func (s *maintenanceService) execute(run *models.MaintenanceRun, repo *models.Repository) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.release(repo.ID)
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Error("仓库维护时发生panic",
+					zap.String("repository_id", repo.ID.String()),
+					zap.Any("panic", r))
+			}
+		}()
+
+		select {
+		case s.semaphore <- struct{}{}:
+		case <-s.ctx.Done():
+			s.finishRun(run, repo, &maintenanceResult{err: s.ctx.Err()})
+			return
+		}
+		defer func() { <-s.semaphore }()
+
+		ctx, cancel := context.WithTimeout(s.ctx, maintenanceTimeout)
+		defer cancel()
+		s.finishRun(run, repo, s.maintain(ctx, run, repo))
+	}()
+}
+
+// maintenanceResult 一次维护的执行结果
+type maintenanceResult struct {
+	before     *models.GitObjectStats
+	after      *models.GitObjectStats
+	health     models.RepositoryHealth
+	fsckOutput string
+	err        error
+}
+
+// maintain 打包对象、检查对象库并统计大小
+// gc失败时仍执行fsck，以便判断失败是否由对象库损坏引起
+func (s *maintenanceService) maintain(ctx context.Context, run *models.MaintenanceRun, repo *models.Repository) *maintenanceResult {
+	result := &maintenanceResult{}
+
+	if stats, err := countGitObjects(ctx, repo.GitPath); err == nil {
+		result.before = &stats
+	}
+
+	var args []string
+	switch run.Mode {
+	case models.MaintenanceModeGC:
+		args = []string{"gc", "--quiet"}
+		if !run.PruneObjects {
+			args = append(args, "--prune=never")
+		}
+	default:
+		args = []string{"repack", "-d", "-l", "-q"}
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repo.GitPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		result.err = fmt.Errorf("git %s 失败: %s: %w", args[0], lastLines(string(output), 20), err)
+	}
+
+	health, fsckOutput, err := fsckRepository(ctx, repo.GitPath)
+	if err != nil {
+		if result.err == nil {
+			result.err = err
+		}
+	} else {
+		result.health = health
+		result.fsckOutput = fsckOutput
+	}
+
+	stats, err := countGitObjects(ctx, repo.GitPath)
+	if err != nil {
+		if result.err == nil {
+			result.err = err
+		}
+	} else {
+		result.after = &stats
+	}
+	return result
+}
+
+// finishRun 保存维护结果，更新仓库大小并在状态变化时发送告警
+func (s *maintenanceService) finishRun(run *models.MaintenanceRun, repo *models.Repository, result *maintenanceResult) {
+	// 维护可能因服务停止被取消，结果仍需记录
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	previous, err := s.maintenanceRepo.GetState(ctx, repo.ID)
+	if err != nil {
+		previous = &models.RepositoryMaintenance{Health: models.RepositoryHealthUnknown}
+	}
+
+	now := time.Now()
+	status := models.MaintenanceStatusSucceeded
+	if result.err != nil || result.health == models.RepositoryHealthCorrupt {
+		status = models.MaintenanceStatusFailed
+	}
+
+	runUpdates := map[string]interface{}{
+		"status":      status,
+		"finished_at": now,
+	}
+	stateUpdates := map[string]interface{}{
+		"last_status": status,
+		"last_run_at": now,
+	}
+	if result.before != nil {
+		runUpdates["size_before"] = result.before.DiskSize()
+	}
+	if result.after != nil {
+		runUpdates["size_after"] = result.after.DiskSize()
+		runUpdates["object_count"] = result.after.ObjectCount()
+		runUpdates["pack_count"] = result.after.PackCount
+		stateUpdates["disk_size"] = result.after.DiskSize()
+		stateUpdates["object_count"] = result.after.ObjectCount()
+		stateUpdates["pack_count"] = result.after.PackCount
+
+		// LFS对象大小由LFS服务增量维护，这里只替换Git对象库部分
+		err := s.repo.UpdateRepository(ctx, repo.ID, map[string]interface{}{
+			"size": gorm.Expr("? + lfs_size", result.after.DiskSize()),
+		})
+		if err != nil {
+			s.logger.Error("更新仓库大小失败", zap.String("repository_id", repo.ID.String()), zap.Error(err))
+		}
+	}
+	if result.health != "" {
+		runUpdates["health"] = result.health
+		stateUpdates["health"] = result.health
+		if result.fsckOutput != "" {
+			runUpdates["fsck_output"] = truncateFsckOutput(result.fsckOutput)
+		}
+	}
+	if result.err != nil {
+		runUpdates["error_message"] = truncateSyncError(result.err.Error())
+	}
+
+	failures := 0
+	if status == models.MaintenanceStatusFailed {
+		failures = previous.ConsecutiveFailures + 1
+		stateUpdates["retry_after"] = now.Add(models.MaintenanceRetryDelay(failures))
+	} else {
+		stateUpdates["retry_after"] = nil
+		stateUpdates["last_success_at"] = now
+		if run.Mode == models.MaintenanceModeGC {
+			stateUpdates["last_full_gc_at"] = now
+		}
+	}
+	stateUpdates["consecutive_failures"] = failures
+
+	if err := s.maintenanceRepo.UpdateRun(ctx, run.ID, runUpdates); err != nil {
+		s.logger.Error("保存维护记录失败", zap.String("run_id", run.ID.String()), zap.Error(err))
+	}
+	if err := s.maintenanceRepo.UpdateState(ctx, repo.ID, stateUpdates); err != nil {
+		s.logger.Error("保存维护状态失败", zap.String("repository_id", repo.ID.String()), zap.Error(err))
+	}
+
+	fields := []zap.Field{
+		zap.String("repository_id", repo.ID.String()),
+		zap.String("mode", string(run.Mode)),
+		zap.String("trigger", string(run.Trigger)),
+		zap.Bool("prune", run.PruneObjects),
+		zap.String("health", string(result.health)),
+	}
+	if result.after != nil {
+		fields = append(fields, zap.Int64("size", result.after.DiskSize()), zap.Int64("objects", result.after.ObjectCount()))
+	}
+	if status == models.MaintenanceStatusSucceeded {
+		s.logger.Info("仓库维护完成", fields...)
+	} else {
+		s.logger.Error("仓库维护失败", append(fields, zap.Error(result.err))...)
+	}
+
+	s.alert(ctx, repo, previous, status, result)
+}
+
+// alert 在首次失败、发现损坏及恢复正常时发送告警，持续失败不重复发送
+func (s *maintenanceService) alert(ctx context.Context, repo *models.Repository, previous *models.RepositoryMaintenance, status models.MaintenanceStatus, result *maintenanceResult) {
+	var subject, body, priority string
+	switch {
+	case result.health == models.RepositoryHealthCorrupt && previous.Health != models.RepositoryHealthCorrupt:
+		subject = fmt.Sprintf("仓库 %s 对象库损坏", repo.Name)
+		body = lastLines(result.fsckOutput, 20)
+		priority = "high"
+	case status == models.MaintenanceStatusFailed && previous.ConsecutiveFailures == 0 && result.health != models.RepositoryHealthCorrupt:
+		subject = fmt.Sprintf("仓库 %s 维护失败", repo.Name)
+		body = result.err.Error()
+		priority = "high"
+	case status == models.MaintenanceStatusSucceeded && (previous.ConsecutiveFailures > 0 || previous.Health == models.RepositoryHealthCorrupt):
+		subject = fmt.Sprintf("仓库 %s 维护已恢复正常", repo.Name)
+		body = fmt.Sprintf("此前连续失败 %d 次", previous.ConsecutiveFailures)
+		priority = "normal"
+	default:
+		return
+	}
+
+	if s.alerts == nil {
+		return
+	}
+	_, err := s.alerts.Send(ctx, &notification.Notification{
+		Type:     notification.NotificationTypeSlack,
+		Subject:  subject,
+		Body:     body,
+		Priority: priority,
+		Metadata: map[string]interface{}{
+			"repository_id": repo.ID.String(),
+			"git_path":      repo.GitPath,
+		},
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		s.logger.Warn("发送维护告警失败", zap.String("repository_id", repo.ID.String()), zap.Error(err))
+	}
+}
+
+// sharesObjectsWithForks 仓库是否可能被fork借用对象库
+// 以fork数判断而不逐个检查fork的objects_shared，宁可少清理也不能让fork丢失对象
+func sharesObjectsWithForks(repo *models.Repository) bool {
+	return repo.ForkCount > 0
+}
+
+// countGitObjects 统计仓库对象数和对象库大小
+func countGitObjects(ctx context.Context, repoPath string) (models.GitObjectStats, error) {
+	cmd := exec.CommandContext(ctx, "git", "count-objects", "-v")
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return models.GitObjectStats{}, fmt.Errorf("统计仓库对象失败: %w", err)
+	}
+	return models.ParseCountObjects(string(output))
+}
+
+// fsckRepository 检查对象库完整性，返回健康状态和发现的问题
+// fsck以非零状态退出表示发现问题；命令本身无法执行（超时、仓库不存在）时返回错误
+func fsckRepository(ctx context.Context, repoPath string) (models.RepositoryHealth, string, error) {
+	cmd := exec.CommandContext(ctx, "git", "fsck", "--no-dangling", "--no-progress")
+	cmd.Dir = repoPath
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return models.RepositoryHealthHealthy, "", nil
+	}
+
+	var exitErr *exec.ExitError
+	if ctx.Err() == nil && errors.As(err, &exitErr) && len(strings.TrimSpace(string(output))) > 0 {
+		return models.RepositoryHealthCorrupt, string(output), nil
+	}
+	return "", "", fmt.Errorf("执行fsck失败: %s", strings.TrimSpace(string(output)+" "+err.Error()))
+}
+
+// truncateFsckOutput 截断过长的fsck输出
+func truncateFsckOutput(output string) string {
+	if len(output) > maxFsckOutputLength {
+		return output[:maxFsckOutputLength]
+	}
+	return output
+}
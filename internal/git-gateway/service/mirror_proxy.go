@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"syscall"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+)
+
+// 远程连接代理参数
+const (
+	remoteDialTimeout   = 30 * time.Second // 连接外部远程的超时时间
+	remoteHeaderTimeout = 10 * time.Second // 读取git请求头的超时时间
+)
+
+// remoteProxy 访问外部远程的本地HTTP代理
+//
+// git命令自行解析域名并建立连接，URL校验无法防止域名解析到内网地址或重定向到内网。
+// 因此外部git命令统一经由该代理出站，代理在建立连接时校验实际连接的地址，
+// 拒绝私有网段、回环、链路本地等内部地址（含DNS重绑定和重定向后的地址）。
+type remoteProxy struct {
+	listener net.Listener
+	server   *http.Server
+	dialer   *net.Dialer
+}
+
+// startRemoteProxy 在回环地址上启动代理，调用方负责关闭
+func startRemoteProxy() (*remoteProxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("启动远程访问代理失败: %w", err)
+	}
+
+	p := &remoteProxy{
+		listener: listener,
+		dialer: &net.Dialer{
+			Timeout: remoteDialTimeout,
+			Control: denyInternalAddress,
+		},
+	}
+	forward := &httputil.ReverseProxy{
+		// 代理请求已是绝对地址，无需改写
+		Director: func(*http.Request) {},
+		Transport: &http.Transport{
+			DialContext:         p.dialer.DialContext,
+			TLSHandshakeTimeout: remoteDialTimeout,
+		},
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		},
+	}
+	p.server = &http.Server{
+		ReadHeaderTimeout: remoteHeaderTimeout,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodConnect {
+				p.tunnel(w, r)
+				return
+			}
+			if r.URL.Scheme != "http" || r.URL.Host == "" {
+				http.Error(w, "only proxy requests are supported", http.StatusBadRequest)
+				return
+			}
+			forward.ServeHTTP(w, r)
+		}),
+	}
+
+	go p.server.Serve(listener)
+	return p, nil
+}
+
+// URL 代理地址，用于git的http.proxy配置
+func (p *remoteProxy) URL() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+// Close 关闭代理及其上的全部连接
+func (p *remoteProxy) Close() error {
+	return p.server.Close()
+}
+
+// tunnel 处理https远程的CONNECT请求
+func (p *remoteProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dialer.DialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+
+	// Hijack后连接的超时由git和远程自行控制
+	client.SetDeadline(time.Time{})
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		// 转发客户端在握手时可能已发送的数据
+		io.Copy(upstream, buffered)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(client, upstream)
+		done <- struct{}{}
+	}()
+	<-done
+	client.Close()
+	upstream.Close()
+	<-done
+}
+
+// denyInternalAddress net.Dialer的Control钩子，在域名解析之后、建立连接之前校验目标地址
+func denyInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || models.IsDeniedRemoteIP(ip) {
+		return fmt.Errorf("%w: %s", models.ErrRemoteAddressDenied, host)
+	}
+	return nil
+}
@@ -0,0 +1,1001 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/cloud-platform/collaborative-dev/shared/vault"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 导入与镜像参数
+const (
+	importTimeout          = 2 * time.Hour    // 单次导入的超时时间
+	mirrorSyncTimeout      = 30 * time.Minute // 单次镜像同步的超时时间
+	mirrorConcurrency      = 4                // 同时进行的导入和同步数
+	mirrorPollInterval     = time.Minute      // 检查到期拉取镜像的间隔
+	mirrorClaimBatchSize   = 20               // 每次领取的到期镜像数
+	progressUpdateInterval = 2 * time.Second  // 导入进度写库的最小间隔
+	maxSyncErrorLength     = 2000             // 保存的错误信息最大长度
+
+	mirrorSecretPrefix = "git-gateway/mirrors"
+	importSecretPrefix = "git-gateway/imports"
+)
+
+// MirrorService 仓库导入与镜像服务接口
+type MirrorService interface {
+	PushListener
+
+	// 导入
+	ImportRepository(ctx context.Context, req *models.ImportRepositoryRequest, access models.RepositoryAccess) (*models.ImportRepositoryResponse, error)
+	GetImportStatus(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (*models.RepositoryImport, error)
+
+	// 镜像管理，需要仓库管理员权限
+	CreateMirror(ctx context.Context, repositoryID uuid.UUID, req *models.CreateMirrorRequest, access models.RepositoryAccess) (*models.RepositoryMirror, error)
+	ListMirrors(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) ([]models.RepositoryMirror, error)
+	UpdateMirror(ctx context.Context, repositoryID, mirrorID uuid.UUID, req *models.UpdateMirrorRequest, access models.RepositoryAccess) (*models.RepositoryMirror, error)
+	DeleteMirror(ctx context.Context, repositoryID, mirrorID uuid.UUID, access models.RepositoryAccess) error
+	SyncMirror(ctx context.Context, repositoryID, mirrorID uuid.UUID, access models.RepositoryAccess) error
+
+	// 定时同步
+	Start(ctx context.Context) error
+	Stop() error
+}
+
+// mirrorService 仓库导入与镜像服务实现
+type mirrorService struct {
+	gitService    GitService
+	repo          repository.GitRepository
+	mirrorRepo    repository.MirrorRepository
+	secrets       vault.VaultClient
+	refAudit      RefUpdateRecorder // 为空时不记录拉取镜像的引用更新
+	pushListeners []PushListener
+	logger        *zap.Logger
+
+	semaphore chan struct{} // 限制并发的导入和同步数
+
+	mu     sync.Mutex
+	runs   map[uuid.UUID]*mirrorRun // 进行中的镜像同步，key为镜像ID
+	ctx    context.Context          // 后台任务的根上下文，Stop时取消
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// mirrorRun 进行中的镜像同步，期间再次触发时在结束后补跑一次
+type mirrorRun struct {
+	rerun bool
+}
+
+// NewMirrorService 创建仓库导入与镜像服务
+// pushListeners 在导入完成或拉取镜像更新分支后收到通知
+func NewMirrorService(gitService GitService, repo repository.GitRepository, mirrorRepo repository.MirrorRepository, secrets vault.VaultClient, refAudit RefUpdateRecorder, logger *zap.Logger, pushListeners ...PushListener) MirrorService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &mirrorService{
+		gitService:    gitService,
+		repo:          repo,
+		mirrorRepo:    mirrorRepo,
+		secrets:       secrets,
+		refAudit:      refAudit,
+		pushListeners: pushListeners,
+		logger:        logger,
+		semaphore:     make(chan struct{}, mirrorConcurrency),
+		runs:          make(map[uuid.UUID]*mirrorRun),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// ImportRepository 创建仓库并在后台从外部远程导入全部分支和标签
+func (s *mirrorService) ImportRepository(ctx context.Context, req *models.ImportRepositoryRequest, access models.RepositoryAccess) (*models.ImportRepositoryResponse, error) {
+	sourceURL, urlCredentials, err := models.ParseRemoteURL(req.SourceURL)
+	if err != nil {
+		return nil, err
+	}
+	credentials := req.Credentials
+	if credentials == nil {
+		credentials = urlCredentials
+	}
+
+	interval := 0
+	if req.Mirror {
+		if interval, err = models.NormalizeMirrorInterval(req.MirrorIntervalMinutes); err != nil {
+			return nil, err
+		}
+	}
+
+	projectID, err := uuid.Parse(req.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID: %w", err)
+	}
+	if err := requireProjectWritable(ctx, s.repo, projectID, access); err != nil {
+		return nil, err
+	}
+	if existing, err := s.repo.GetRepositoryByProjectAndName(ctx, projectID, req.Name); err == nil && existing != nil {
+		return nil, fmt.Errorf("%w: %s", models.ErrRepositoryNameTaken, req.Name)
+	}
+
+	repo, err := s.gitService.CreateRepository(ctx, &models.CreateRepositoryRequest{
+		ProjectID:   req.ProjectID,
+		Name:        req.Name,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+	}, access)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := &models.RepositoryImport{
+		RepositoryID: repo.ID,
+		SourceURL:    sourceURL,
+		Status:       models.ImportStatusPending,
+		CreatedBy:    access.UserID,
+	}
+	if err := s.mirrorRepo.CreateImport(ctx, imp); err != nil {
+		return nil, fmt.Errorf("创建导入任务失败: %w", err)
+	}
+
+	if credentials != nil {
+		if err := s.secrets.PutSecret(ctx, importSecretPath(imp.ID), credentials.ToSecret()); err != nil {
+			s.finishImport(ctx, imp.ID, fmt.Errorf("保存凭据失败: %w", err))
+			return nil, fmt.Errorf("保存凭据失败: %w", err)
+		}
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.runImport(imp, repo, credentials != nil, req.Mirror, interval)
+	}()
+
+	s.logger.Info("开始导入仓库",
+		zap.String("repository_id", repo.ID.String()),
+		zap.String("import_id", imp.ID.String()),
+		zap.String("source_url", sourceURL))
+
+	return &models.ImportRepositoryResponse{Repository: repo, Import: imp}, nil
+}
+
+// GetImportStatus 获取仓库最近一次导入的状态和进度
+func (s *mirrorService) GetImportStatus(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) (*models.RepositoryImport, error) {
+	if err := requireRepositoryReadable(ctx, s.repo, repositoryID, access); err != nil {
+		return nil, err
+	}
+	imp, err := s.mirrorRepo.GetLatestImport(ctx, repositoryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrImportNotFound
+		}
+		return nil, fmt.Errorf("获取导入任务失败: %w", err)
+	}
+	return imp, nil
+}
+
+// CreateMirror 创建镜像并立即进行首次同步
+func (s *mirrorService) CreateMirror(ctx context.Context, repositoryID uuid.UUID, req *models.CreateMirrorRequest, access models.RepositoryAccess) (*models.RepositoryMirror, error) {
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+	if err := requireRepositoryAdmin(ctx, s.repo, repositoryID, access); err != nil {
+		return nil, err
+	}
+
+	remoteURL, urlCredentials, err := models.ParseRemoteURL(req.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+	credentials := req.Credentials
+	if credentials == nil {
+		credentials = urlCredentials
+	}
+
+	mirror := &models.RepositoryMirror{
+		RepositoryID:   repositoryID,
+		Direction:      req.Direction,
+		RemoteURL:      remoteURL,
+		HasCredentials: credentials != nil,
+		Enabled:        true,
+		LastSyncStatus: models.MirrorSyncStatusPending,
+		CreatedBy:      access.UserID,
+	}
+
+	if req.Direction == models.MirrorDirectionPull {
+		if mirror.IntervalMinutes, err = models.NormalizeMirrorInterval(req.IntervalMinutes); err != nil {
+			return nil, err
+		}
+		existing, err := s.mirrorRepo.ListMirrors(ctx, repositoryID)
+		if err != nil {
+			return nil, fmt.Errorf("获取镜像失败: %w", err)
+		}
+		for _, m := range existing {
+			if m.Direction == models.MirrorDirectionPull {
+				return nil, models.ErrPullMirrorExists
+			}
+		}
+		nextSync := time.Now().Add(time.Duration(mirror.IntervalMinutes) * time.Minute)
+		mirror.NextSyncAt = &nextSync
+	}
+
+	if err := s.mirrorRepo.CreateMirror(ctx, mirror); err != nil {
+		return nil, fmt.Errorf("创建镜像失败: %w", err)
+	}
+
+	if credentials != nil {
+		if err := s.secrets.PutSecret(ctx, mirrorSecretPath(mirror.ID), credentials.ToSecret()); err != nil {
+			s.mirrorRepo.DeleteMirror(ctx, mirror.ID)
+			return nil, fmt.Errorf("保存凭据失败: %w", err)
+		}
+	}
+
+	// 拉取镜像由远程决定内容，本地仓库只读
+	if mirror.Direction == models.MirrorDirectionPull {
+		if err := s.repo.UpdateRepository(ctx, repositoryID, map[string]interface{}{"is_mirror": true}); err != nil {
+			return nil, fmt.Errorf("更新仓库失败: %w", err)
+		}
+	}
+
+	s.logger.Info("镜像创建成功",
+		zap.String("repository_id", repositoryID.String()),
+		zap.String("mirror_id", mirror.ID.String()),
+		zap.String("direction", string(mirror.Direction)),
+		zap.String("remote_url", remoteURL))
+
+	s.runMirror(mirror.ID, repositoryID)
+	return mirror, nil
+}
+
+// ListMirrors 获取仓库的镜像及同步状态
+func (s *mirrorService) ListMirrors(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) ([]models.RepositoryMirror, error) {
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+	if err := requireRepositoryAdmin(ctx, s.repo, repositoryID, access); err != nil {
+		return nil, err
+	}
+	return s.mirrorRepo.ListMirrors(ctx, repositoryID)
+}
+
+// UpdateMirror 启用/停用镜像、调整同步间隔或替换凭据
+func (s *mirrorService) UpdateMirror(ctx context.Context, repositoryID, mirrorID uuid.UUID, req *models.UpdateMirrorRequest, access models.RepositoryAccess) (*models.RepositoryMirror, error) {
+	if err := requireRepositoryAdmin(ctx, s.repo, repositoryID, access); err != nil {
+		return nil, err
+	}
+	mirror, err := s.getMirror(ctx, repositoryID, mirrorID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Enabled != nil {
+		updates["enabled"] = *req.Enabled
+	}
+	if req.IntervalMinutes != nil && mirror.Direction == models.MirrorDirectionPull {
+		interval, err := models.NormalizeMirrorInterval(*req.IntervalMinutes)
+		if err != nil {
+			return nil, err
+		}
+		updates["interval_minutes"] = interval
+		updates["next_sync_at"] = time.Now().Add(time.Duration(interval) * time.Minute)
+	}
+	if req.Credentials != nil {
+		if err := s.secrets.PutSecret(ctx, mirrorSecretPath(mirror.ID), req.Credentials.ToSecret()); err != nil {
+			return nil, fmt.Errorf("保存凭据失败: %w", err)
+		}
+		updates["has_credentials"] = true
+	}
+
+	if len(updates) > 0 {
+		if err := s.mirrorRepo.UpdateMirror(ctx, mirror.ID, updates); err != nil {
+			return nil, fmt.Errorf("更新镜像失败: %w", err)
+		}
+	}
+
+	return s.mirrorRepo.GetMirror(ctx, repositoryID, mirrorID)
+}
+
+// DeleteMirror 删除镜像及其凭据，删除拉取镜像后仓库恢复可写
+func (s *mirrorService) DeleteMirror(ctx context.Context, repositoryID, mirrorID uuid.UUID, access models.RepositoryAccess) error {
+	if err := requireRepositoryAdmin(ctx, s.repo, repositoryID, access); err != nil {
+		return err
+	}
+	mirror, err := s.getMirror(ctx, repositoryID, mirrorID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mirrorRepo.DeleteMirror(ctx, mirror.ID); err != nil {
+		return fmt.Errorf("删除镜像失败: %w", err)
+	}
+
+	if mirror.HasCredentials {
+		if err := s.secrets.DeleteSecret(ctx, mirrorSecretPath(mirror.ID)); err != nil {
+			s.logger.Error("删除镜像凭据失败", zap.String("mirror_id", mirror.ID.String()), zap.Error(err))
+		}
+	}
+
+	if mirror.Direction == models.MirrorDirectionPull {
+		if err := s.repo.UpdateRepository(ctx, repositoryID, map[string]interface{}{"is_mirror": false}); err != nil {
+			return fmt.Errorf("更新仓库失败: %w", err)
+		}
+	}
+
+	s.logger.Info("镜像已删除",
+		zap.String("repository_id", repositoryID.String()),
+		zap.String("mirror_id", mirror.ID.String()))
+	return nil
+}
+
+// SyncMirror 立即在后台同步镜像
+func (s *mirrorService) SyncMirror(ctx context.Context, repositoryID, mirrorID uuid.UUID, access models.RepositoryAccess) error {
+	if err := requireRepositoryAdmin(ctx, s.repo, repositoryID, access); err != nil {
+		return err
+	}
+	mirror, err := s.getMirror(ctx, repositoryID, mirrorID)
+	if err != nil {
+		return err
+	}
+	if !mirror.Enabled {
+		return models.ErrMirrorDisabled
+	}
+	s.runMirror(mirror.ID, repositoryID)
+	return nil
+}
+
+// OnPush 推送后将仓库复制到全部推送镜像
+func (s *mirrorService) OnPush(repositoryID uuid.UUID, ref string) {
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	mirrors, err := s.mirrorRepo.ListEnabledMirrors(ctx, repositoryID, models.MirrorDirectionPush)
+	if err != nil {
+		s.logger.Error("获取推送镜像失败", zap.String("repository_id", repositoryID.String()), zap.Error(err))
+		return
+	}
+	for _, mirror := range mirrors {
+		s.runMirror(mirror.ID, repositoryID)
+	}
+}
+
+// Start 启动拉取镜像定时同步
+func (s *mirrorService) Start(ctx context.Context) error {
+	// 服务重启会中断进行中的导入，这些任务无法继续，标记为失败
+	if count, err := s.mirrorRepo.FailInterruptedImports(ctx, "导入因服务重启中断，请删除仓库后重新导入"); err != nil {
+		s.logger.Error("标记中断的导入任务失败", zap.Error(err))
+	} else if count > 0 {
+		s.logger.Warn("已将中断的导入任务标记为失败", zap.Int64("count", count))
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(mirrorPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.scheduleDueMirrors()
+			}
+		}
+	}()
+
+	s.logger.Info("镜像同步调度已启动")
+	return nil
+}
+
+// Stop 停止调度并取消进行中的导入和同步
+func (s *mirrorService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("镜像同步调度已停止")
+	return nil
+}
+
+// scheduleDueMirrors 领取到期的拉取镜像并同步
+func (s *mirrorService) scheduleDueMirrors() {
+	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+	defer cancel()
+
+	mirrors, err := s.mirrorRepo.ClaimDuePullMirrors(ctx, time.Now(), mirrorClaimBatchSize)
+	if err != nil {
+		s.logger.Error("领取到期镜像失败", zap.Error(err))
+		return
+	}
+	for _, mirror := range mirrors {
+		s.runMirror(mirror.ID, mirror.RepositoryID)
+	}
+}
+
+// runMirror 在后台同步镜像，同一镜像同时只有一个同步，期间的触发合并为结束后的一次补跑
+func (s *mirrorService) runMirror(mirrorID, repositoryID uuid.UUID) {
+	s.mu.Lock()
+	if run, ok := s.runs[mirrorID]; ok {
+		run.rerun = true
+		s.mu.Unlock()
+		return
+	}
+	run := &mirrorRun{}
+	s.runs[mirrorID] = run
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				s.logger.Error("镜像同步时发生panic",
+					zap.String("mirror_id", mirrorID.String()),
+					zap.Any("panic", r))
+				s.mu.Lock()
+				delete(s.runs, mirrorID)
+				s.mu.Unlock()
+			}
+		}()
+
+		for {
+			select {
+			case s.semaphore <- struct{}{}:
+			case <-s.ctx.Done():
+				s.mu.Lock()
+				delete(s.runs, mirrorID)
+				s.mu.Unlock()
+				return
+			}
+			s.syncMirror(mirrorID, repositoryID)
+			<-s.semaphore
+
+			s.mu.Lock()
+			if run.rerun {
+				run.rerun = false
+				s.mu.Unlock()
+				continue
+			}
+			delete(s.runs, mirrorID)
+			s.mu.Unlock()
+			return
+		}
+	}()
+}
+
+// syncMirror 执行一次镜像同步并记录结果
+func (s *mirrorService) syncMirror(mirrorID, repositoryID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(s.ctx, mirrorSyncTimeout)
+	defer cancel()
+
+	mirror, err := s.mirrorRepo.GetMirror(ctx, repositoryID, mirrorID)
+	if err != nil {
+		// 镜像已被删除
+		return
+	}
+	if !mirror.Enabled {
+		return
+	}
+
+	repo, err := s.repo.GetRepositoryByID(ctx, repositoryID)
+	if err != nil {
+		s.logger.Warn("镜像所属仓库不存在", zap.String("mirror_id", mirrorID.String()), zap.Error(err))
+		return
+	}
+	// 归档的仓库内容冻结，不再拉取上游；推送镜像仍可同步
+	if repo.IsArchived() && mirror.Direction == models.MirrorDirectionPull {
+		return
+	}
+
+	s.mirrorRepo.UpdateMirror(ctx, mirror.ID, map[string]interface{}{
+		"last_sync_status": models.MirrorSyncStatusRunning,
+	})
+
+	var credentials *models.MirrorCredentials
+	if mirror.HasCredentials {
+		credentials, err = s.loadCredentials(ctx, mirrorSecretPath(mirror.ID))
+	}
+	if err == nil {
+		if mirror.Direction == models.MirrorDirectionPull {
+			err = s.pullMirror(ctx, repo, mirror.RemoteURL, credentials)
+		} else {
+			err = s.pushMirror(ctx, repo, mirror.RemoteURL, credentials)
+		}
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"last_sync_at": now,
+	}
+	if err != nil {
+		message := truncateSyncError(err.Error())
+		updates["last_sync_status"] = models.MirrorSyncStatusFailed
+		updates["last_error"] = message
+		s.logger.Warn("镜像同步失败",
+			zap.String("mirror_id", mirror.ID.String()),
+			zap.String("direction", string(mirror.Direction)),
+			zap.String("error", message))
+	} else {
+		updates["last_sync_status"] = models.MirrorSyncStatusSucceeded
+		updates["last_success_at"] = now
+		updates["last_error"] = nil
+		s.logger.Info("镜像同步成功",
+			zap.String("mirror_id", mirror.ID.String()),
+			zap.String("direction", string(mirror.Direction)))
+	}
+
+	// 同步可能因服务停止被取消，结果仍需记录
+	saveCtx, saveCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer saveCancel()
+	if err := s.mirrorRepo.UpdateMirror(saveCtx, mirror.ID, updates); err != nil {
+		s.logger.Error("保存镜像同步结果失败", zap.String("mirror_id", mirror.ID.String()), zap.Error(err))
+	}
+}
+
+// pullMirror 从远程拉取分支和标签，远程已删除的引用同步删除
+func (s *mirrorService) pullMirror(ctx context.Context, repo *models.Repository, remoteURL string, credentials *models.MirrorCredentials) error {
+	before, err := listGitRefs(ctx, repo.GitPath)
+	if err != nil {
+		return err
+	}
+
+	args := append([]string{"fetch", "--prune", "--quiet", remoteURL}, models.MirrorRefspecs...)
+	if output, err := runRemoteGit(ctx, repo.GitPath, credentials, nil, args...); err != nil {
+		return fmt.Errorf("拉取远程仓库失败: %s", strings.TrimSpace(string(output)))
+	}
+
+	after, err := listGitRefs(ctx, repo.GitPath)
+	if err != nil {
+		return err
+	}
+
+	changed := changedRefs(before, after)
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if err := s.reconcileRefs(ctx, repo, after); err != nil {
+		return err
+	}
+	if s.refAudit != nil {
+		s.refAudit.RecordRefUpdates(ctx, repo, refUpdatesBetween(before, after), models.RefActor{}, models.RefTransportMirror)
+	}
+
+	// 拉取到的更新视同推送：更新代码索引，并复制到推送镜像
+	for _, ref := range changed {
+		if strings.HasPrefix(ref, "refs/heads/") {
+			s.notifyPush(repo.ID, ref)
+		}
+	}
+	s.OnPush(repo.ID, "")
+	return nil
+}
+
+// pushMirror 将全部分支和标签推送到远程，本地已删除的引用同步删除
+func (s *mirrorService) pushMirror(ctx context.Context, repo *models.Repository, remoteURL string, credentials *models.MirrorCredentials) error {
+	args := append([]string{"push", "--prune", "--quiet", remoteURL}, models.MirrorRefspecs...)
+	if output, err := runRemoteGit(ctx, repo.GitPath, credentials, nil, args...); err != nil {
+		return fmt.Errorf("推送到远程仓库失败: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// runImport 执行导入任务
+func (s *mirrorService) runImport(imp *models.RepositoryImport, repo *models.Repository, hasCredentials, keepMirror bool, interval int) {
+	ctx, cancel := context.WithTimeout(s.ctx, importTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.Error("导入仓库时发生panic",
+				zap.String("import_id", imp.ID.String()),
+				zap.Any("panic", r))
+			s.finishImport(context.Background(), imp.ID, fmt.Errorf("导入异常终止"))
+		}
+	}()
+
+	select {
+	case s.semaphore <- struct{}{}:
+		defer func() { <-s.semaphore }()
+	case <-ctx.Done():
+		s.finishImport(context.Background(), imp.ID, ctx.Err())
+		return
+	}
+
+	err := s.importRefs(ctx, imp, repo, hasCredentials, keepMirror, interval)
+	if hasCredentials {
+		if delErr := s.secrets.DeleteSecret(context.Background(), importSecretPath(imp.ID)); delErr != nil {
+			s.logger.Error("删除导入凭据失败", zap.String("import_id", imp.ID.String()), zap.Error(delErr))
+		}
+	}
+	s.finishImport(context.Background(), imp.ID, err)
+
+	if err != nil {
+		s.logger.Warn("导入仓库失败",
+			zap.String("repository_id", repo.ID.String()),
+			zap.String("import_id", imp.ID.String()),
+			zap.Error(err))
+		return
+	}
+
+	s.logger.Info("导入仓库成功",
+		zap.String("repository_id", repo.ID.String()),
+		zap.String("import_id", imp.ID.String()))
+	s.notifyPush(repo.ID, "refs/heads/"+repo.DefaultBranch)
+}
+
+// importRefs 拉取远程全部分支和标签，按远程HEAD设置默认分支，并按需保留为拉取镜像
+func (s *mirrorService) importRefs(ctx context.Context, imp *models.RepositoryImport, repo *models.Repository, hasCredentials, keepMirror bool, interval int) error {
+	now := time.Now()
+	s.mirrorRepo.UpdateImport(ctx, imp.ID, map[string]interface{}{
+		"status":     models.ImportStatusRunning,
+		"started_at": now,
+	})
+
+	var credentials *models.MirrorCredentials
+	if hasCredentials {
+		var err error
+		if credentials, err = s.loadCredentials(ctx, importSecretPath(imp.ID)); err != nil {
+			return err
+		}
+	}
+
+	// 远程默认分支
+	output, err := runRemoteGit(ctx, repo.GitPath, credentials, nil, "ls-remote", "--symref", imp.SourceURL, "HEAD")
+	if err != nil {
+		return fmt.Errorf("访问远程仓库失败: %s", strings.TrimSpace(string(output)))
+	}
+	defaultBranch := models.ParseSymrefHead(string(output))
+
+	// 拉取并上报进度
+	var lastPhase string
+	var lastUpdate time.Time
+	onProgress := func(phase string, percent int) {
+		progress := models.ImportProgress(phase, percent)
+		if progress < 0 || (phase == lastPhase && time.Since(lastUpdate) < progressUpdateInterval) {
+			return
+		}
+		lastPhase, lastUpdate = phase, time.Now()
+		s.mirrorRepo.UpdateImport(ctx, imp.ID, map[string]interface{}{
+			"phase":    phase,
+			"progress": progress,
+		})
+	}
+	args := append([]string{"fetch", "--progress", imp.SourceURL}, models.MirrorRefspecs...)
+	if output, err := runRemoteGit(ctx, repo.GitPath, credentials, onProgress, args...); err != nil {
+		return fmt.Errorf("拉取远程仓库失败: %s", lastLines(string(output), 5))
+	}
+
+	s.mirrorRepo.UpdateImport(ctx, imp.ID, map[string]interface{}{
+		"phase":    "Updating branches",
+		"progress": 95,
+	})
+
+	if defaultBranch != "" && defaultBranch != repo.DefaultBranch {
+		cmd := exec.CommandContext(ctx, "git", "symbolic-ref", "HEAD", "refs/heads/"+defaultBranch)
+		cmd.Dir = repo.GitPath
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("设置默认分支失败: %s", string(output))
+		}
+		if err := s.repo.UpdateRepository(ctx, repo.ID, map[string]interface{}{"default_branch": defaultBranch}); err != nil {
+			return fmt.Errorf("更新默认分支失败: %w", err)
+		}
+		repo.DefaultBranch = defaultBranch
+	}
+
+	refs, err := listGitRefs(ctx, repo.GitPath)
+	if err != nil {
+		return err
+	}
+	if err := s.reconcileRefs(ctx, repo, refs); err != nil {
+		return err
+	}
+
+	if keepMirror {
+		return s.createImportMirror(ctx, imp, repo, credentials, interval)
+	}
+	return nil
+}
+
+// createImportMirror 导入完成后将仓库设为拉取镜像
+func (s *mirrorService) createImportMirror(ctx context.Context, imp *models.RepositoryImport, repo *models.Repository, credentials *models.MirrorCredentials, interval int) error {
+	now := time.Now()
+	nextSync := now.Add(time.Duration(interval) * time.Minute)
+	mirror := &models.RepositoryMirror{
+		RepositoryID:    repo.ID,
+		Direction:       models.MirrorDirectionPull,
+		RemoteURL:       imp.SourceURL,
+		HasCredentials:  credentials != nil,
+		Enabled:         true,
+		IntervalMinutes: interval,
+		LastSyncStatus:  models.MirrorSyncStatusSucceeded,
+		LastSyncAt:      &now,
+		LastSuccessAt:   &now,
+		NextSyncAt:      &nextSync,
+		CreatedBy:       imp.CreatedBy,
+	}
+	if err := s.mirrorRepo.CreateMirror(ctx, mirror); err != nil {
+		return fmt.Errorf("创建拉取镜像失败: %w", err)
+	}
+	if credentials != nil {
+		if err := s.secrets.PutSecret(ctx, mirrorSecretPath(mirror.ID), credentials.ToSecret()); err != nil {
+			return fmt.Errorf("保存镜像凭据失败: %w", err)
+		}
+	}
+	if err := s.repo.UpdateRepository(ctx, repo.ID, map[string]interface{}{"is_mirror": true}); err != nil {
+		return fmt.Errorf("更新仓库失败: %w", err)
+	}
+	return nil
+}
+
+// finishImport 记录导入结果
+func (s *mirrorService) finishImport(ctx context.Context, importID uuid.UUID, err error) {
+	updates := map[string]interface{}{
+		"finished_at": time.Now(),
+	}
+	if err != nil {
+		updates["status"] = models.ImportStatusFailed
+		updates["error_message"] = truncateSyncError(err.Error())
+	} else {
+		updates["status"] = models.ImportStatusSucceeded
+		updates["phase"] = "Completed"
+		updates["progress"] = 100
+	}
+	if updateErr := s.mirrorRepo.UpdateImport(ctx, importID, updates); updateErr != nil {
+		s.logger.Error("更新导入任务失败", zap.String("import_id", importID.String()), zap.Error(updateErr))
+	}
+}
+
+// reconcileRefs 按Git中的实际分支更新分支记录和仓库统计
+func (s *mirrorService) reconcileRefs(ctx context.Context, repo *models.Repository, refs map[string]string) error {
+	branches, err := s.repo.ListBranches(ctx, repo.ID)
+	if err != nil {
+		return fmt.Errorf("获取分支记录失败: %w", err)
+	}
+	existing := make(map[string]models.Branch, len(branches))
+	for _, branch := range branches {
+		existing[branch.Name] = branch
+	}
+
+	branchCount, tagCount := 0, 0
+	for ref, sha := range refs {
+		if strings.HasPrefix(ref, "refs/tags/") {
+			tagCount++
+			continue
+		}
+		name, ok := strings.CutPrefix(ref, "refs/heads/")
+		if !ok {
+			continue
+		}
+		branchCount++
+
+		isDefault := name == repo.DefaultBranch
+		if branch, ok := existing[name]; ok {
+			delete(existing, name)
+			if branch.CommitSHA != sha || branch.IsDefault != isDefault {
+				if err := s.repo.UpdateBranch(ctx, repo.ID, name, map[string]interface{}{
+					"commit_sha": sha,
+					"is_default": isDefault,
+				}); err != nil {
+					return fmt.Errorf("更新分支记录失败: %w", err)
+				}
+			}
+			continue
+		}
+		if err := s.repo.CreateBranch(ctx, &models.Branch{
+			RepositoryID: repo.ID,
+			Name:         name,
+			CommitSHA:    sha,
+			IsDefault:    isDefault,
+		}); err != nil {
+			return fmt.Errorf("创建分支记录失败: %w", err)
+		}
+	}
+
+	// 远程已删除的分支
+	for name := range existing {
+		if err := s.repo.DeleteBranch(ctx, repo.ID, name); err != nil {
+			return fmt.Errorf("删除分支记录失败: %w", err)
+		}
+	}
+
+	updates := map[string]interface{}{
+		"branch_count":   branchCount,
+		"tag_count":      tagCount,
+		"last_pushed_at": time.Now(),
+	}
+	if _, ok := refs["refs/heads/"+repo.DefaultBranch]; ok {
+		cmd := exec.CommandContext(ctx, "git", "rev-list", "--count", "refs/heads/"+repo.DefaultBranch)
+		cmd.Dir = repo.GitPath
+		if output, err := cmd.Output(); err == nil {
+			if count, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64); err == nil {
+				updates["commit_count"] = count
+			}
+		}
+	}
+	return s.repo.UpdateRepository(ctx, repo.ID, updates)
+}
+
+// notifyPush 通知推送监听器
+func (s *mirrorService) notifyPush(repositoryID uuid.UUID, ref string) {
+	for _, listener := range s.pushListeners {
+		listener.OnPush(repositoryID, ref)
+	}
+}
+
+// loadCredentials 从Vault读取凭据
+func (s *mirrorService) loadCredentials(ctx context.Context, path string) (*models.MirrorCredentials, error) {
+	data, err := s.secrets.GetSecret(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("读取凭据失败: %w", err)
+	}
+	return models.MirrorCredentialsFromSecret(data)
+}
+
+// getMirror 获取仓库下的镜像
+func (s *mirrorService) getMirror(ctx context.Context, repositoryID, mirrorID uuid.UUID) (*models.RepositoryMirror, error) {
+	mirror, err := s.mirrorRepo.GetMirror(ctx, repositoryID, mirrorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrMirrorNotFound
+		}
+		return nil, fmt.Errorf("获取镜像失败: %w", err)
+	}
+	return mirror, nil
+}
+
+// runRemoteGit 执行访问外部远程的git命令
+//
+// 凭据通过环境变量注入为HTTP认证头，不出现在命令行参数、远程地址或仓库配置中；
+// 仅允许http(s)传输，禁止交互式提示。连接经由remoteProxy出站，拒绝内部网络地址。
+// onProgress不为空时解析--progress输出。
+func runRemoteGit(ctx context.Context, dir string, credentials *models.MirrorCredentials, onProgress func(phase string, percent int), args ...string) ([]byte, error) {
+	proxy, err := startRemoteProxy()
+	if err != nil {
+		return nil, err
+	}
+	defer proxy.Close()
+
+	config := [][2]string{{"http.proxy", proxy.URL()}}
+	if credentials != nil {
+		config = append(config, [2]string{"http.extraHeader", credentials.AuthorizationHeader()})
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_ALLOW_PROTOCOL=http:https",
+		"GIT_CONFIG_NOSYSTEM=1",
+		// 清除可能让连接绕过代理的环境变量
+		"no_proxy=",
+		"NO_PROXY=",
+		"GIT_CONFIG_COUNT="+strconv.Itoa(len(config)),
+	)
+	for i, kv := range config {
+		cmd.Env = append(cmd.Env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]),
+		)
+	}
+
+	if onProgress == nil {
+		return cmd.CombinedOutput()
+	}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// 进度行以\r刷新，按\r或\n分行
+	scanner := bufio.NewScanner(stderr)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if phase, percent, ok := models.ParseGitProgress(line); ok {
+			onProgress(phase, percent)
+			continue
+		}
+		output.WriteString(line)
+		output.WriteByte('\n')
+	}
+
+	err = cmd.Wait()
+	return output.Bytes(), err
+}
+
+// scanProgressLines 以\r或\n为分隔的bufio.SplitFunc
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// listGitRefs 列出仓库的引用，key为完整引用名。未指定 patterns 时列出全部分支和标签
+func listGitRefs(ctx context.Context, repoPath string, patterns ...string) (map[string]string, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"refs/heads", "refs/tags"}
+	}
+	args := append([]string{"for-each-ref", "--format=%(objectname) %(refname)"}, patterns...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("列出引用失败: %w", err)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if sha, ref, ok := strings.Cut(line, " "); ok {
+			refs[ref] = sha
+		}
+	}
+	return refs, nil
+}
+
+// changedRefs 返回新增、更新或删除的引用
+func changedRefs(before, after map[string]string) []string {
+	var changed []string
+	for ref, sha := range after {
+		if before[ref] != sha {
+			changed = append(changed, ref)
+		}
+	}
+	for ref := range before {
+		if _, ok := after[ref]; !ok {
+			changed = append(changed, ref)
+		}
+	}
+	return changed
+}
+
+// lastLines 返回输出的最后几行
+func lastLines(output string, n int) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// truncateSyncError 截断过长的错误信息
+func truncateSyncError(message string) string {
+	if len(message) > maxSyncErrorLength {
+		return message[:maxSyncErrorLength]
+	}
+	return message
+}
+
+// mirrorSecretPath 镜像凭据在Vault中的路径
+func mirrorSecretPath(id uuid.UUID) string {
+	return fmt.Sprintf("%s/%s", mirrorSecretPrefix, id)
+}
+
+// importSecretPath 导入凭据在Vault中的路径
+func importSecretPath(id uuid.UUID) string {
+	return fmt.Sprintf("%s/%s", importSecretPrefix, id)
+}
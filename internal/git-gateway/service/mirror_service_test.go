@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirrorAccess(t *testing.T) {
+	access := models.RepositoryAccess{UserID: uuid.New(), TenantID: uuid.New()}
+
+	_, data, repo, _ := newTransferTestService(t)
+	s := &mirrorService{repo: data}
+
+	data.admin = false
+	_, err := s.CreateMirror(context.Background(), repo.ID, &models.CreateMirrorRequest{}, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+	err = s.DeleteMirror(context.Background(), repo.ID, uuid.New(), access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+
+	// 在不可写的项目下导入时不创建仓库
+	data.writable = false
+	req := &models.ImportRepositoryRequest{ProjectID: uuid.New().String(), Name: "imported", SourceURL: "https://example.com/app.git"}
+	_, err = s.ImportRepository(context.Background(), req, access)
+	assert.ErrorIs(t, err, models.ErrProjectAccessDenied)
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+)
+
+const projectServiceTimeout = 5 * time.Second
+
+// ProjectNotifier 向项目服务推送仓库生命周期事件
+type ProjectNotifier interface {
+	// NotifyRepository 发送仓库事件，previous为改名或迁移前的位置
+	NotifyRepository(ctx context.Context, repo *models.Repository, action models.RepositoryLifecycleAction, previous *models.RepositoryLocation, userID uuid.UUID) error
+}
+
+// projectServiceNotifier 通过项目服务的Git事件Webhook发送通知
+type projectServiceNotifier struct {
+	baseURL string
+	secret  string
+	client  *http.Client
+}
+
+// NewProjectNotifier 创建项目服务通知客户端，baseURL为空时返回 nil
+func NewProjectNotifier(baseURL, secret string) ProjectNotifier {
+	if baseURL == "" {
+		return nil
+	}
+	return &projectServiceNotifier{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		secret:  secret,
+		client:  &http.Client{Timeout: projectServiceTimeout},
+	}
+}
+
+// projectGitEvent 项目服务接收的Git事件，与项目服务 webhook.GitEvent 对应
+type projectGitEvent struct {
+	EventType    string          `json:"event_type"`
+	EventID      string          `json:"event_id"`
+	Timestamp    time.Time       `json:"timestamp"`
+	ProjectID    string          `json:"project_id"`
+	RepositoryID string          `json:"repository_id"`
+	UserID       string          `json:"user_id,omitempty"`
+	Payload      json.RawMessage `json:"payload"`
+}
+
+// projectRepositoryPayload 仓库事件负载，与项目服务 webhook.RepositoryEvent 对应
+type projectRepositoryPayload struct {
+	Action     models.RepositoryLifecycleAction `json:"action"`
+	Repository struct {
+		ID            string `json:"id"`
+		Name          string `json:"name"`
+		ProjectID     string `json:"project_id"`
+		Visibility    string `json:"visibility"`
+		DefaultBranch string `json:"default_branch"`
+		Status        string `json:"status"`
+		CloneURL      string `json:"clone_url"`
+	} `json:"repository"`
+	Previous *models.RepositoryLocation `json:"previous,omitempty"`
+}
+
+// NotifyRepository 发送仓库事件，签名方式与项目服务的 X-Hub-Signature-256 校验一致
+func (n *projectServiceNotifier) NotifyRepository(ctx context.Context, repo *models.Repository, action models.RepositoryLifecycleAction, previous *models.RepositoryLocation, userID uuid.UUID) error {
+	payload := projectRepositoryPayload{Action: action, Previous: previous}
+	payload.Repository.ID = repo.ID.String()
+	payload.Repository.Name = repo.Name
+	payload.Repository.ProjectID = repo.ProjectID.String()
+	payload.Repository.Visibility = string(repo.Visibility)
+	payload.Repository.DefaultBranch = repo.DefaultBranch
+	payload.Repository.Status = string(repo.Status)
+	payload.Repository.CloneURL = repo.CloneURL
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化仓库事件失败: %w", err)
+	}
+
+	event := projectGitEvent{
+		EventType:    "repository",
+		EventID:      uuid.New().String(),
+		Timestamp:    time.Now().UTC(),
+		ProjectID:    repo.ProjectID.String(),
+		RepositoryID: repo.ID.String(),
+		Payload:      rawPayload,
+	}
+	if userID != uuid.Nil {
+		event.UserID = userID.String()
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化仓库事件失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+"/api/v1/webhooks/git", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		mac := hmac.New(sha256.New, []byte(n.secret))
+		mac.Write(body)
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("项目服务返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
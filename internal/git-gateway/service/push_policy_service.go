@@ -0,0 +1,862 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxPushViolations = 50 // 拒绝信息中最多列出的违规项
+
+	// gitGatewayInternalEnv 网关自身推送（如合并PR）时设置，钩子直接放行
+	gitGatewayInternalEnv = "GIT_GATEWAY_INTERNAL"
+)
+
+// preReceiveHookScript pre-receive钩子脚本，把引用更新和隔离目录提交给网关检查
+// 网关不可达时curl失败，推送被拒绝
+const preReceiveHookScript = `#!/bin/sh
+# 由git-gateway自动安装的推送策略检查钩子，请勿手动修改
+[ -n "$` + gitGatewayInternalEnv + `" ] && exit 0
+
+output=$(curl --silent --fail-with-body --max-time 120 \
+	-H "Content-Type: text/plain" \
+	-H "X-Git-Hook-Token: %s" \
+	-H "X-Git-Quarantine-Path: $GIT_QUARANTINE_PATH" \
+	--data-binary @- \
+	"%s/api/v1/git-hooks/%s/pre-receive")
+status=$?
+[ -n "$output" ] && printf '%%s\n' "$output" >&2
+# 22为网关返回的拒绝，其他为网关不可达等错误
+if [ $status -ne 0 ] && [ $status -ne 22 ]; then
+	echo "push policy check is unavailable (curl exit $status), push rejected" >&2
+fi
+exit $status
+`
+
+// PushPolicyService 推送策略服务接口
+type PushPolicyService interface {
+	// 策略管理
+	GetPolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID) (*models.PushPolicy, error)
+	SetPolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID, req *models.PushPolicyRequest, access models.RepositoryAccess) (*models.PushPolicy, error)
+	DeletePolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID, access models.RepositoryAccess) error
+	GetEffectivePolicy(ctx context.Context, repositoryID uuid.UUID) (*models.EffectivePushPolicy, error)
+	RequiresCodeOwnerApproval(ctx context.Context, repositoryID uuid.UUID, branch string) (bool, error)
+
+	// pre-receive检查
+	CheckPreReceive(ctx context.Context, repositoryID uuid.UUID, req *models.PreReceiveRequest) (*models.PreReceiveResult, error)
+	// CheckRefUpdate 检查网关自身发起的引用更新，受保护引用不允许删除或强制更新
+	CheckRefUpdate(ctx context.Context, repositoryID uuid.UUID, update models.RefUpdate) error
+}
+
+// pushPolicyService 推送策略服务实现
+type pushPolicyService struct {
+	repo        repository.GitRepository
+	policyRepo  repository.PushPolicyRepository
+	verifier    SignatureVerifier
+	logger      *zap.Logger
+	hookBaseURL string // 钩子回调网关的地址
+	hookSecret  string // 计算钩子回调令牌的密钥
+}
+
+// NewPushPolicyService 创建推送策略服务
+func NewPushPolicyService(repo repository.GitRepository, policyRepo repository.PushPolicyRepository, verifier SignatureVerifier, logger *zap.Logger, hookBaseURL, hookSecret string) PushPolicyService {
+	return &pushPolicyService{
+		repo:        repo,
+		policyRepo:  policyRepo,
+		verifier:    verifier,
+		logger:      logger,
+		hookBaseURL: strings.TrimRight(hookBaseURL, "/"),
+		hookSecret:  hookSecret,
+	}
+}
+
+// GetPolicy 获取项目或仓库的推送策略
+func (s *pushPolicyService) GetPolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID) (*models.PushPolicy, error) {
+	if err := validatePushPolicyScope(scope); err != nil {
+		return nil, err
+	}
+	policy, err := s.policyRepo.GetPolicy(ctx, scope, scopeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPushPolicyNotFound
+		}
+		return nil, fmt.Errorf("获取推送策略失败: %w", err)
+	}
+	return policy, nil
+}
+
+// SetPolicy 设置推送策略，并为受影响的仓库安装钩子
+func (s *pushPolicyService) SetPolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID, req *models.PushPolicyRequest, access models.RepositoryAccess) (*models.PushPolicy, error) {
+	if err := validatePushPolicyScope(scope); err != nil {
+		return nil, err
+	}
+	if err := s.checkPolicyAdmin(ctx, scope, scopeID, access); err != nil {
+		return nil, err
+	}
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+
+	var repos []models.Repository
+	if scope == models.PushPolicyScopeRepository {
+		repo, err := getRepositoryOrNotFound(ctx, s.repo, scopeID)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, *repo)
+	} else {
+		projectRepos, err := s.listProjectRepositories(ctx, scopeID)
+		if err != nil {
+			return nil, err
+		}
+		repos = projectRepos
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	policy := &models.PushPolicy{
+		ScopeType:                scope,
+		ScopeID:                  scopeID,
+		Enabled:                  enabled,
+		MaxFileSize:              req.MaxFileSize,
+		ForbiddenPaths:           req.ForbiddenPaths,
+		ForbiddenExtensions:      req.ForbiddenExtensions,
+		CommitMessagePattern:     req.CommitMessagePattern,
+		AllowedEmailDomains:      req.AllowedEmailDomains,
+		SecretScanning:           req.SecretScanning,
+		SecretPatterns:           req.SecretPatterns,
+		ProtectedRefs:            req.ProtectedRefs,
+		RequireSignedCommits:     req.RequireSignedCommits,
+		RequireCodeOwnerApproval: req.RequireCodeOwnerApproval,
+		UpdatedBy:                access.UserID,
+	}
+	if err := s.policyRepo.UpsertPolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("保存推送策略失败: %w", err)
+	}
+
+	// 早于钩子机制创建的仓库在此补装
+	for _, repo := range repos {
+		if err := installGitHooks(repo.GitPath, s.hookBaseURL, s.hookSecret, repo.ID); err != nil {
+			s.logger.Warn("安装仓库钩子失败",
+				zap.String("repository_id", repo.ID.String()),
+				zap.Error(err))
+		}
+	}
+
+	s.logger.Info("推送策略已更新",
+		zap.String("scope_type", string(scope)),
+		zap.String("scope_id", scopeID.String()),
+		zap.Int("repositories", len(repos)))
+	return policy, nil
+}
+
+// DeletePolicy 删除推送策略
+func (s *pushPolicyService) DeletePolicy(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID, access models.RepositoryAccess) error {
+	if err := validatePushPolicyScope(scope); err != nil {
+		return err
+	}
+	if err := s.checkPolicyAdmin(ctx, scope, scopeID, access); err != nil {
+		return err
+	}
+	deleted, err := s.policyRepo.DeletePolicy(ctx, scope, scopeID)
+	if err != nil {
+		return fmt.Errorf("删除推送策略失败: %w", err)
+	}
+	if !deleted {
+		return models.ErrPushPolicyNotFound
+	}
+	return nil
+}
+
+// checkPolicyAdmin 仓库策略要求仓库管理员，项目策略要求项目负责人或项目管理员
+func (s *pushPolicyService) checkPolicyAdmin(ctx context.Context, scope models.PushPolicyScope, scopeID uuid.UUID, access models.RepositoryAccess) error {
+	if scope == models.PushPolicyScopeRepository {
+		return requireRepositoryAdmin(ctx, s.repo, scopeID, access)
+	}
+	return requireProjectAdmin(ctx, s.repo, scopeID, access)
+}
+
+// GetEffectivePolicy 获取仓库生效的推送策略
+func (s *pushPolicyService) GetEffectivePolicy(ctx context.Context, repositoryID uuid.UUID) (*models.EffectivePushPolicy, error) {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	return s.effectivePolicy(ctx, repo)
+}
+
+// RequiresCodeOwnerApproval 判断向该分支发起的PR是否须经代码所有者批准
+func (s *pushPolicyService) RequiresCodeOwnerApproval(ctx context.Context, repositoryID uuid.UUID, branch string) (bool, error) {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return false, err
+	}
+	policy, err := s.effectivePolicy(ctx, repo)
+	if err != nil {
+		return false, err
+	}
+	if !policy.RequireCodeOwnerApproval {
+		return false, nil
+	}
+	protected, err := s.protectedBranches(ctx, repo.ID)
+	if err != nil {
+		return false, err
+	}
+	return isProtectedRef(policy, protected, "refs/heads/"+branch), nil
+}
+
+// CheckPreReceive 检查一次推送是否符合推送策略
+// 推送的对象仍在隔离目录中，检查通过后git才会把对象移入仓库并更新引用
+func (s *pushPolicyService) CheckPreReceive(ctx context.Context, repositoryID uuid.UUID, req *models.PreReceiveRequest) (*models.PreReceiveResult, error) {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := &pushChecker{}
+	if err := repo.CheckWritable(); err != nil {
+		checker.add(models.PushPolicyViolation{Rule: models.PushRuleReadOnly, Message: err.Error()})
+		return checker.result(), nil
+	}
+
+	env, err := quarantineEnv(repo.GitPath, req.QuarantinePath)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.effectivePolicy(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	checker.policy = policy
+	checker.repoPath = repo.GitPath
+	checker.env = env
+	checker.verifier = s.verifier
+	if err := checker.compile(); err != nil {
+		return nil, err
+	}
+
+	protected, err := s.protectedBranches(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, update := range req.Updates {
+		if isProtectedRef(policy, protected, update.Ref) {
+			if err := checker.checkProtectedRef(ctx, update); err != nil {
+				return nil, err
+			}
+			if policy.RequireSignedCommits && !update.IsDelete() && strings.HasPrefix(update.Ref, "refs/heads/") {
+				if err := checker.checkSignedCommits(ctx, update); err != nil {
+					return nil, fmt.Errorf("校验提交签名失败: %w", err)
+				}
+			}
+		}
+	}
+
+	if policy.NeedsContentScan() {
+		for _, update := range req.Updates {
+			if update.IsDelete() || checker.full() {
+				continue
+			}
+			if err := checker.scanCommits(ctx, update); err != nil {
+				return nil, fmt.Errorf("扫描推送提交失败: %w", err)
+			}
+		}
+		if err := checker.checkBlobSizes(ctx); err != nil {
+			return nil, fmt.Errorf("检查文件大小失败: %w", err)
+		}
+	}
+
+	result := checker.result()
+	if !result.Allowed {
+		s.logger.Info("推送被策略拒绝",
+			zap.String("repository_id", repositoryID.String()),
+			zap.Int("violations", len(result.Violations)))
+	}
+	return result, nil
+}
+
+// CheckRefUpdate 检查网关自身发起的引用更新，违反受保护引用规则时返回 ErrProtectedRefUpdate
+func (s *pushPolicyService) CheckRefUpdate(ctx context.Context, repositoryID uuid.UUID, update models.RefUpdate) error {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return err
+	}
+	policy, err := s.effectivePolicy(ctx, repo)
+	if err != nil {
+		return err
+	}
+	protected, err := s.protectedBranches(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+	if !isProtectedRef(policy, protected, update.Ref) {
+		return nil
+	}
+
+	checker := &pushChecker{policy: policy, repoPath: repo.GitPath}
+	if err := checker.checkProtectedRef(ctx, update); err != nil {
+		return err
+	}
+	if len(checker.violations) > 0 {
+		return fmt.Errorf("%w: %s", models.ErrProtectedRefUpdate, checker.violations[0].Message)
+	}
+	return nil
+}
+
+// effectivePolicy 合并项目策略和仓库策略
+func (s *pushPolicyService) effectivePolicy(ctx context.Context, repo *models.Repository) (*models.EffectivePushPolicy, error) {
+	var policies []*models.PushPolicy
+	for _, scope := range []struct {
+		scope models.PushPolicyScope
+		id    uuid.UUID
+	}{
+		{models.PushPolicyScopeProject, repo.ProjectID},
+		{models.PushPolicyScopeRepository, repo.ID},
+	} {
+		policy, err := s.policyRepo.GetPolicy(ctx, scope.scope, scope.id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("获取推送策略失败: %w", err)
+		}
+		policies = append(policies, policy)
+	}
+	return models.MergePushPolicies(policies...), nil
+}
+
+// protectedBranches 获取仓库中标记为受保护的分支
+func (s *pushPolicyService) protectedBranches(ctx context.Context, repositoryID uuid.UUID) (map[string]bool, error) {
+	branches, err := s.repo.ListBranches(ctx, repositoryID)
+	if err != nil {
+		return nil, fmt.Errorf("获取分支列表失败: %w", err)
+	}
+	protected := make(map[string]bool)
+	for _, branch := range branches {
+		if branch.IsProtectedBranch() {
+			protected[branch.Name] = true
+		}
+	}
+	return protected, nil
+}
+
+// isProtectedRef 判断引用是否受保护：标记为受保护的分支，或匹配策略中的受保护引用模式
+func isProtectedRef(policy *models.EffectivePushPolicy, protectedBranches map[string]bool, ref string) bool {
+	if branch, ok := strings.CutPrefix(ref, "refs/heads/"); ok && protectedBranches[branch] {
+		return true
+	}
+	for _, pattern := range policy.ProtectedRefs {
+		if models.MatchRefPattern(pattern, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// listProjectRepositories 获取项目下全部仓库
+func (s *pushPolicyService) listProjectRepositories(ctx context.Context, projectID uuid.UUID) ([]models.Repository, error) {
+	const pageSize = 100
+	var repos []models.Repository
+	for page := 1; ; page++ {
+		batch, total, err := s.repo.ListRepositories(ctx, &projectID, page, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取项目仓库失败: %w", err)
+		}
+		repos = append(repos, batch...)
+		if len(batch) < pageSize || int64(len(repos)) >= total {
+			return repos, nil
+		}
+	}
+}
+
+// validatePushPolicyScope 校验策略作用范围
+func validatePushPolicyScope(scope models.PushPolicyScope) error {
+	if scope != models.PushPolicyScopeProject && scope != models.PushPolicyScopeRepository {
+		return fmt.Errorf("%w: %s", models.ErrInvalidPushPolicyScope, scope)
+	}
+	return nil
+}
+
+// quarantineEnv 生成在隔离目录中读取推送对象的git环境变量
+// 隔离目录由钩子上报，必须位于仓库对象目录内
+func quarantineEnv(repoPath, quarantinePath string) ([]string, error) {
+	env := os.Environ()
+	if quarantinePath == "" {
+		return env, nil
+	}
+
+	objectsDir := filepath.Join(repoPath, "objects")
+	cleaned := filepath.Clean(quarantinePath)
+	if !strings.HasPrefix(cleaned, objectsDir+string(filepath.Separator)) {
+		return nil, fmt.Errorf("%w: quarantine path is outside the repository", models.ErrInvalidPreReceive)
+	}
+	return append(env,
+		"GIT_OBJECT_DIRECTORY="+cleaned,
+		"GIT_ALTERNATE_OBJECT_DIRECTORIES="+objectsDir,
+	), nil
+}
+
+// installGitHooks 为仓库安装推送策略检查钩子和引用审计钩子，hookBaseURL或hookSecret为空时不安装
+func installGitHooks(repoPath, hookBaseURL, hookSecret string, repositoryID uuid.UUID) error {
+	scripts := gitHookScripts(hookBaseURL, hookSecret, repositoryID)
+	if scripts == nil {
+		return nil
+	}
+	hooksDir := filepath.Join(repoPath, "hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("创建钩子目录失败: %w", err)
+	}
+
+	for _, name := range []string{"pre-receive", "post-receive"} {
+		if err := writeGitHook(hooksDir, name, scripts[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitHooksInstalled 判断仓库已安装当前地址和令牌的钩子
+func gitHooksInstalled(repoPath, hookBaseURL, hookSecret string, repositoryID uuid.UUID) bool {
+	for name, script := range gitHookScripts(hookBaseURL, hookSecret, repositoryID) {
+		content, err := os.ReadFile(filepath.Join(repoPath, "hooks", name))
+		if err != nil || string(content) != script {
+			return false
+		}
+	}
+	return true
+}
+
+// gitHookScripts 生成仓库的钩子脚本，钩子携带该仓库的回调令牌；未配置钩子时返回nil
+func gitHookScripts(hookBaseURL, hookSecret string, repositoryID uuid.UUID) map[string]string {
+	if hookBaseURL == "" || hookSecret == "" {
+		return nil
+	}
+	baseURL := strings.TrimRight(hookBaseURL, "/")
+	token := models.GitHookToken(hookSecret, repositoryID)
+	return map[string]string{
+		"pre-receive":  fmt.Sprintf(preReceiveHookScript, token, baseURL, repositoryID),
+		"post-receive": fmt.Sprintf(postReceiveHookScript, token, baseURL, repositoryID),
+	}
+}
+
+// writeGitHook 原子地写入可执行的钩子脚本
+func writeGitHook(hooksDir, name, script string) error {
+	tmp, err := os.CreateTemp(hooksDir, "."+name+"-*")
+	if err != nil {
+		return fmt.Errorf("创建钩子文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(script); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入钩子失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入钩子失败: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return fmt.Errorf("设置钩子权限失败: %w", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(hooksDir, name))
+}
+
+// pushChecker 一次推送的策略检查状态
+type pushChecker struct {
+	policy   *models.EffectivePushPolicy
+	repoPath string
+	env      []string
+	verifier SignatureVerifier
+
+	messagePatterns []*regexp.Regexp
+	secretRules     []models.SecretRule
+
+	seenCommits map[string]bool
+	blobs       map[string]models.PushPolicyViolation // 待检查大小的blob及其首次出现位置
+	reported    map[string]bool                       // 每个提交/文件/规则只报告一次
+	violations  []models.PushPolicyViolation
+	omitted     int
+}
+
+// compile 编译策略中的正则
+func (c *pushChecker) compile() error {
+	for _, pattern := range c.policy.CommitMessagePatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("%w: commit message pattern: %v", models.ErrInvalidPushPolicy, err)
+		}
+		c.messagePatterns = append(c.messagePatterns, re)
+	}
+	if c.policy.SecretScanning {
+		c.secretRules = append(c.secretRules, models.BuiltinSecretRules...)
+		for _, pattern := range c.policy.SecretPatterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("%w: secret pattern: %v", models.ErrInvalidPushPolicy, err)
+			}
+			c.secretRules = append(c.secretRules, models.SecretRule{Name: "custom pattern " + pattern, Pattern: re})
+		}
+	}
+	c.seenCommits = make(map[string]bool)
+	c.blobs = make(map[string]models.PushPolicyViolation)
+	c.reported = make(map[string]bool)
+	return nil
+}
+
+// add 记录违规项，超过上限的只计数
+func (c *pushChecker) add(v models.PushPolicyViolation) {
+	if c.full() {
+		c.omitted++
+		return
+	}
+	c.violations = append(c.violations, v)
+}
+
+// addOnce 同一提交、文件和规则只记录一次
+func (c *pushChecker) addOnce(key string, v models.PushPolicyViolation) {
+	if c.reported[key] {
+		return
+	}
+	c.reported[key] = true
+	c.add(v)
+}
+
+// full 违规项是否已达上限
+func (c *pushChecker) full() bool {
+	return len(c.violations) >= maxPushViolations
+}
+
+// result 生成检查结果
+func (c *pushChecker) result() *models.PreReceiveResult {
+	violations := c.violations
+	if c.omitted > 0 {
+		violations = append(violations, models.PushPolicyViolation{
+			Rule:    "summary",
+			Message: fmt.Sprintf("%d more violations omitted", c.omitted),
+		})
+	}
+	return &models.PreReceiveResult{
+		Allowed:    len(violations) == 0,
+		Violations: violations,
+	}
+}
+
+// checkProtectedRef 受保护引用禁止删除、强制推送，受保护标签禁止移动
+func (c *pushChecker) checkProtectedRef(ctx context.Context, update models.RefUpdate) error {
+	violation := models.PushPolicyViolation{Rule: models.PushRuleProtectedRef, Ref: update.Ref}
+	switch {
+	case update.IsDelete():
+		violation.Message = "protected ref cannot be deleted"
+	case update.IsCreate():
+		return nil
+	case strings.HasPrefix(update.Ref, "refs/tags/"):
+		violation.Message = "protected tag cannot be moved"
+	default:
+		fastForward, err := c.isAncestor(ctx, update.OldSHA, update.NewSHA)
+		if err != nil {
+			return fmt.Errorf("检查快进更新失败: %w", err)
+		}
+		if fastForward {
+			return nil
+		}
+		violation.Message = "force push to a protected ref is not allowed"
+	}
+	c.add(violation)
+	return nil
+}
+
+// checkSignedCommits 受保护分支要求新提交均带有已验证的签名
+func (c *pushChecker) checkSignedCommits(ctx context.Context, update models.RefUpdate) error {
+	cmd := exec.CommandContext(ctx, "git", "rev-list", update.NewSHA, "--not", "--all")
+	cmd.Dir = c.repoPath
+	cmd.Env = c.env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("列出新提交失败: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var shas []string
+	for _, sha := range strings.Fields(string(output)) {
+		if !c.reported[sha+models.PushRuleSignedCommit] {
+			shas = append(shas, sha)
+		}
+	}
+	signatures, err := c.verifier.VerifyObjects(ctx, c.repoPath, c.env, "commit", shas)
+	if err != nil {
+		return err
+	}
+
+	for _, sha := range shas {
+		signature := signatures[sha]
+		if signature.IsVerified() {
+			continue
+		}
+		c.addOnce(sha+models.PushRuleSignedCommit, models.PushPolicyViolation{
+			Rule: models.PushRuleSignedCommit, Ref: update.Ref, Commit: sha,
+			Message: unverifiedSignatureMessage(signature),
+		})
+	}
+	return nil
+}
+
+// unverifiedSignatureMessage 说明提交签名未通过验证的原因
+func unverifiedSignatureMessage(signature models.SignatureInfo) string {
+	switch signature.Status {
+	case models.SignatureStatusUnknownKey:
+		return "commit is signed with a key that is not registered on the platform; upload it as a signing key"
+	case models.SignatureStatusBadSignature:
+		return "commit signature is invalid"
+	default:
+		if signature.Type == "" {
+			return "protected branch requires signed commits"
+		}
+		return fmt.Sprintf("signing key does not belong to committer e-mail %q", signature.SignerEmail)
+	}
+}
+
+// isAncestor 判断ancestor是否为commit的祖先
+func (c *pushChecker) isAncestor(ctx context.Context, ancestor, commit string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", ancestor, commit)
+	cmd.Dir = c.repoPath
+	cmd.Env = c.env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+}
+
+// scanCommits 检查引用更新带来的新提交，即仓库现有引用都不可达的提交
+func (c *pushChecker) scanCommits(ctx context.Context, update models.RefUpdate) error {
+	args := []string{"-c", "core.quotePath=false", "log", "--no-color", "--no-ext-diff", "--no-renames",
+		"--format=%x1e%H%x1f%P%x1f%ae%x1f%B%x1f"}
+	if c.policy.MaxFileSize > 0 || len(c.policy.ForbiddenPaths) > 0 || len(c.policy.ForbiddenExtensions) > 0 {
+		args = append(args, "--raw", "--no-abbrev")
+	}
+	if len(c.secretRules) > 0 {
+		args = append(args, "-p", "-U0")
+	}
+	args = append(args, update.NewSHA, "--not", "--all")
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = c.repoPath
+	cmd.Env = c.env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("创建git输出管道失败: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动git log失败: %w", err)
+	}
+
+	reader := bufio.NewReaderSize(stdout, 64*1024)
+	readErr := func() error {
+		for {
+			record, err := reader.ReadString('\x1e')
+			if record = strings.TrimSuffix(record, "\x1e"); record != "" {
+				c.checkCommit(update.Ref, record)
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}()
+
+	if readErr != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return readErr
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git log执行失败: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// checkCommit 检查一个提交的提交信息、作者邮箱、变更文件和新增行
+func (c *pushChecker) checkCommit(ref, record string) {
+	fields := strings.SplitN(record, "\x1f", 5)
+	if len(fields) != 5 {
+		return
+	}
+	sha, email, message, changes := fields[0], fields[2], strings.TrimSpace(fields[3]), fields[4]
+	if c.seenCommits[sha] {
+		return
+	}
+	c.seenCommits[sha] = true
+
+	for _, re := range c.messagePatterns {
+		if !re.MatchString(message) {
+			c.add(models.PushPolicyViolation{
+				Rule: models.PushRuleCommitMessage, Ref: ref, Commit: sha,
+				Message: fmt.Sprintf("commit message must match %q", re.String()),
+			})
+		}
+	}
+	if len(c.policy.AllowedEmailDomains) > 0 && !models.EmailDomainAllowed(c.policy.AllowedEmailDomains, email) {
+		c.add(models.PushPolicyViolation{
+			Rule: models.PushRuleAuthorEmail, Ref: ref, Commit: sha,
+			Message: fmt.Sprintf("author e-mail %q is not in an allowed domain (%s)", email, strings.Join(c.policy.AllowedEmailDomains, ", ")),
+		})
+	}
+
+	// 合并提交不输出差异，其变更由被合并的提交各自检查
+	var file string
+	inHunk := false
+	for _, line := range strings.Split(changes, "\n") {
+		switch {
+		case strings.HasPrefix(line, ":"):
+			c.checkRawEntry(ref, sha, line)
+		case strings.HasPrefix(line, "diff --git "):
+			file, inHunk = "", false
+		case !inHunk && strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(unquoteGitPath(strings.TrimPrefix(line, "+++ ")), "b/")
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case inHunk && strings.HasPrefix(line, "+"):
+			c.checkAddedLine(ref, sha, file, line[1:])
+		}
+	}
+}
+
+// checkRawEntry 检查 --raw 输出中的一个文件变更
+// 格式：:<旧模式> <新模式> <旧对象> <新对象> <状态>\t<路径>
+func (c *pushChecker) checkRawEntry(ref, sha, line string) {
+	meta, filePath, ok := strings.Cut(line, "\t")
+	if !ok {
+		return
+	}
+	fields := strings.Fields(strings.TrimPrefix(meta, ":"))
+	if len(fields) != 5 || strings.HasPrefix(fields[4], "D") || fields[1] == "160000" {
+		return
+	}
+	filePath = unquoteGitPath(filePath)
+
+	for _, pattern := range c.policy.ForbiddenPaths {
+		if models.MatchPathPattern(pattern, filePath) {
+			c.addOnce(sha+filePath+models.PushRuleForbiddenPath, models.PushPolicyViolation{
+				Rule: models.PushRuleForbiddenPath, Ref: ref, Commit: sha, Path: filePath,
+				Message: fmt.Sprintf("path matches forbidden pattern %q", pattern),
+			})
+			break
+		}
+	}
+	if ext, ok := models.HasForbiddenExtension(c.policy.ForbiddenExtensions, filePath); ok {
+		c.addOnce(sha+filePath+models.PushRuleForbiddenExt, models.PushPolicyViolation{
+			Rule: models.PushRuleForbiddenExt, Ref: ref, Commit: sha, Path: filePath,
+			Message: fmt.Sprintf("files with extension %s are not allowed", ext),
+		})
+	}
+	if c.policy.MaxFileSize > 0 {
+		if _, exists := c.blobs[fields[3]]; !exists {
+			c.blobs[fields[3]] = models.PushPolicyViolation{Rule: models.PushRuleMaxFileSize, Ref: ref, Commit: sha, Path: filePath}
+		}
+	}
+}
+
+// checkAddedLine 扫描新增行中的密钥，不在拒绝信息中回显密钥内容
+func (c *pushChecker) checkAddedLine(ref, sha, file, line string) {
+	for _, rule := range c.secretRules {
+		if rule.Pattern.MatchString(line) {
+			c.addOnce(sha+file+rule.Name, models.PushPolicyViolation{
+				Rule: models.PushRuleSecret, Ref: ref, Commit: sha, Path: file,
+				Message: fmt.Sprintf("added line matches secret rule %q; remove it from the commit history and rotate the secret", rule.Name),
+			})
+			return
+		}
+	}
+}
+
+// checkBlobSizes 批量读取新文件大小，超过上限的记录违规
+func (c *pushChecker) checkBlobSizes(ctx context.Context) error {
+	if len(c.blobs) == 0 {
+		return nil
+	}
+
+	var input bytes.Buffer
+	for sha := range c.blobs {
+		input.WriteString(sha + "\n")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch-check=%(objectname) %(objecttype) %(objectsize)")
+	cmd.Dir = c.repoPath
+	cmd.Env = c.env
+	cmd.Stdin = &input
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil || size <= c.policy.MaxFileSize {
+			continue
+		}
+		violation := c.blobs[fields[0]]
+		violation.Message = fmt.Sprintf("file is %s, larger than the %s limit", formatSize(size), formatSize(c.policy.MaxFileSize))
+		c.add(violation)
+	}
+	return nil
+}
+
+// unquoteGitPath 还原git输出中带引号转义的路径
+func unquoteGitPath(p string) string {
+	if strings.HasPrefix(p, `"`) {
+		if unquoted, err := strconv.Unquote(p); err == nil {
+			return unquoted
+		}
+	}
+	return p
+}
+
+// formatSize 格式化字节数
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushPolicyAccess(t *testing.T) {
+	access := models.RepositoryAccess{UserID: uuid.New(), TenantID: uuid.New()}
+
+	_, data, repo, _ := newTransferTestService(t)
+	s := &pushPolicyService{repo: data}
+	data.admin = false
+
+	_, err := s.SetPolicy(context.Background(), models.PushPolicyScopeRepository, repo.ID, &models.PushPolicyRequest{}, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+	err = s.DeletePolicy(context.Background(), models.PushPolicyScopeRepository, repo.ID, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+
+	_, err = s.SetPolicy(context.Background(), models.PushPolicyScopeProject, repo.ProjectID, &models.PushPolicyRequest{}, access)
+	assert.ErrorIs(t, err, models.ErrProjectAccessDenied)
+	err = s.DeletePolicy(context.Background(), models.PushPolicyScopeProject, repo.ProjectID, access)
+	assert.ErrorIs(t, err, models.ErrProjectAccessDenied)
+}
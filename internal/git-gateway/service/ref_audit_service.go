@@ -0,0 +1,579 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 引用审计实现
+//
+// 推送由post-receive钩子上报，网关API、镜像同步和恢复操作在更新引用后直接记录。
+// 删除和强制推送丢弃的旧提交挂到 refs/keep-around/<sha> 下保留 retention 时长，
+// 期间gc不会清理这些对象，可以通过恢复分支操作找回；保留期过后由后台任务删除保留引用。
+
+// 引用审计参数
+const (
+	refKeepSweepInterval = time.Hour // 清理过期保留引用的间隔
+	refKeepSweepBatch    = 100       // 每次清理的保留提交数
+)
+
+// postReceiveHookScript post-receive钩子脚本，把引用更新上报给网关记录审计日志。
+// 推送前端通过 GIT_GATEWAY_USER_ID、GIT_GATEWAY_USER_NAME、GIT_GATEWAY_TRANSPORT 传入推送者和协议；
+// 推送已经完成，上报失败只输出警告
+const postReceiveHookScript = `#!/bin/sh
+# 由git-gateway自动安装的引用审计钩子，请勿手动修改
+[ -n "$` + gitGatewayInternalEnv + `" ] && exit 0
+
+curl --silent --fail --max-time 30 \
+	-H "Content-Type: text/plain" \
+	-H "X-Git-Hook-Token: %s" \
+	-H "X-Git-User-ID: $GIT_GATEWAY_USER_ID" \
+	-H "X-Git-User-Name: $GIT_GATEWAY_USER_NAME" \
+	-H "X-Git-Transport: $GIT_GATEWAY_TRANSPORT" \
+	--data-binary @- \
+	"%s/api/v1/git-hooks/%s/post-receive" >/dev/null
+status=$?
+if [ $status -ne 0 ]; then
+	echo "warning: failed to record ref updates (curl exit $status)" >&2
+fi
+exit 0
+`
+
+// RefUpdateRecorder 记录引用更新，由网关内部修改引用的服务调用
+type RefUpdateRecorder interface {
+	// RecordRefUpdates 记录引用更新，删除和强制推送的旧提交会被保留。返回写入的审计记录，失败只记录日志
+	RecordRefUpdates(ctx context.Context, repo *models.Repository, updates []models.RefUpdate, actor models.RefActor, transport models.RefTransport) []models.RefAuditEntry
+}
+
+// RefAuditService 引用审计服务接口
+type RefAuditService interface {
+	RefUpdateRecorder
+
+	// 推送上报
+	HandlePostReceive(ctx context.Context, repositoryID uuid.UUID, updates []models.RefUpdate, actor models.RefActor, transport models.RefTransport) error
+
+	// 查询与恢复
+	ListRefAudit(ctx context.Context, repositoryID uuid.UUID, filter *models.RefAuditFilter, access models.RepositoryAccess) (*models.RefAuditListResponse, error)
+	RestoreBranch(ctx context.Context, repositoryID uuid.UUID, req *models.RestoreBranchRequest, access models.RepositoryAccess) (*models.RestoreBranchResult, error)
+
+	// 保留引用清理
+	Start(ctx context.Context) error
+	Stop() error
+}
+
+// refAuditService 引用审计服务实现
+type refAuditService struct {
+	repo        repository.GitRepository
+	auditRepo   repository.RefAuditRepository
+	policies    PushPolicyService // 恢复分支时检查受保护引用
+	logger      *zap.Logger
+	retention   time.Duration // 被丢弃提交的保留时长，<=0 时不保留
+	hookBaseURL string        // 为缺少审计钩子的仓库补装钩子
+	hookSecret  string        // 计算钩子回调令牌的密钥
+
+	ctx    context.Context // 后台任务的根上下文，Stop时取消
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRefAuditService 创建引用审计服务
+func NewRefAuditService(repo repository.GitRepository, auditRepo repository.RefAuditRepository, policies PushPolicyService, logger *zap.Logger, retention time.Duration, hookBaseURL, hookSecret string) RefAuditService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &refAuditService{
+		repo:        repo,
+		auditRepo:   auditRepo,
+		policies:    policies,
+		logger:      logger,
+		retention:   retention,
+		hookBaseURL: hookBaseURL,
+		hookSecret:  hookSecret,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// RecordRefUpdates 记录引用更新
+func (s *refAuditService) RecordRefUpdates(ctx context.Context, repo *models.Repository, updates []models.RefUpdate, actor models.RefActor, transport models.RefTransport) []models.RefAuditEntry {
+	now := time.Now()
+	entries := make([]models.RefAuditEntry, 0, len(updates))
+	for _, update := range updates {
+		if strings.HasPrefix(update.Ref, models.KeepAroundRefPrefix) {
+			continue
+		}
+
+		entry := models.RefAuditEntry{
+			RepositoryID: repo.ID,
+			Ref:          update.Ref,
+			RefType:      models.RefTypeOf(update.Ref),
+			OldSHA:       update.OldSHA,
+			NewSHA:       update.NewSHA,
+			ActorID:      actor.UserID,
+			ActorName:    actor.Name,
+			Transport:    transport,
+			CreatedAt:    now,
+		}
+		if !update.IsCreate() && !update.IsDelete() {
+			entry.Forced = isForcedUpdate(ctx, repo.GitPath, update)
+		}
+
+		if entry.Rewritten() && s.retention > 0 {
+			if err := keepAroundCommit(ctx, repo.GitPath, update.OldSHA); err != nil {
+				s.logger.Warn("保留被覆盖的提交失败",
+					zap.String("repository_id", repo.ID.String()),
+					zap.String("ref", update.Ref),
+					zap.String("sha", update.OldSHA),
+					zap.Error(err))
+			} else {
+				keptUntil := now.Add(s.retention)
+				entry.KeptUntil = &keptUntil
+				entry.Kept = true
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := s.auditRepo.CreateEntries(ctx, entries); err != nil {
+		s.logger.Error("写入引用审计记录失败",
+			zap.String("repository_id", repo.ID.String()),
+			zap.Int("updates", len(entries)),
+			zap.Error(err))
+		return nil
+	}
+	return entries
+}
+
+// HandlePostReceive 记录推送引起的引用更新。
+// 只记录与仓库中引用当前值一致的更新：删除的引用须已不存在，其余引用须指向上报的新提交
+func (s *refAuditService) HandlePostReceive(ctx context.Context, repositoryID uuid.UUID, updates []models.RefUpdate, actor models.RefActor, transport models.RefTransport) error {
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(updates))
+	for _, update := range updates {
+		names = append(names, update.Ref)
+	}
+	current, err := listGitRefs(ctx, repo.GitPath, names...)
+	if err != nil {
+		return err
+	}
+
+	verified := make([]models.RefUpdate, 0, len(updates))
+	for _, update := range updates {
+		want := update.NewSHA
+		if update.IsDelete() {
+			want = ""
+		}
+		if current[update.Ref] != want {
+			// 上报内容与仓库不符，或引用在上报前又被更新
+			s.logger.Warn("忽略与仓库不一致的引用更新",
+				zap.String("repository_id", repo.ID.String()),
+				zap.String("ref", update.Ref),
+				zap.String("new_sha", update.NewSHA),
+				zap.String("current", current[update.Ref]))
+			continue
+		}
+		verified = append(verified, update)
+	}
+	if len(verified) > 0 {
+		s.RecordRefUpdates(ctx, repo, verified, actor, transport)
+	}
+	return nil
+}
+
+// ListRefAudit 按条件查询仓库的引用审计记录
+func (s *refAuditService) ListRefAudit(ctx context.Context, repositoryID uuid.UUID, filter *models.RefAuditFilter, access models.RepositoryAccess) (*models.RefAuditListResponse, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, err
+	}
+	if _, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID); err != nil {
+		return nil, err
+	}
+	if err := requireRepositoryReadable(ctx, s.repo, repositoryID, access); err != nil {
+		return nil, err
+	}
+
+	entries, total, err := s.auditRepo.ListEntries(ctx, repositoryID, filter)
+	if err != nil {
+		return nil, fmt.Errorf("获取引用审计记录失败: %w", err)
+	}
+
+	return &models.RefAuditListResponse{
+		Entries:  entries,
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}, nil
+}
+
+// RestoreBranch 重新创建已删除的分支，或把被强制推送的分支回退到之前的提交
+func (s *refAuditService) RestoreBranch(ctx context.Context, repositoryID uuid.UUID, req *models.RestoreBranchRequest, access models.RepositoryAccess) (*models.RestoreBranchResult, error) {
+	if err := req.Normalize(); err != nil {
+		return nil, err
+	}
+	repo, err := getRepositoryOrNotFound(ctx, s.repo, repositoryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireRepositoryReadable(ctx, s.repo, repositoryID, access); err != nil {
+		return nil, err
+	}
+	if err := requireRepositoryWritable(ctx, s.repo, repositoryID, access); err != nil {
+		return nil, err
+	}
+	if err := repo.CheckWritable(); err != nil {
+		return nil, err
+	}
+
+	ref := "refs/heads/" + req.Branch
+	target := req.SHA
+	if target == "" {
+		entry, err := s.auditRepo.GetLatestRewrite(ctx, repo.ID, ref)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: %s", models.ErrNothingToRestore, req.Branch)
+			}
+			return nil, fmt.Errorf("获取引用审计记录失败: %w", err)
+		}
+		target = entry.OldSHA
+	}
+
+	// 保留期已过的提交可能已被gc清理
+	if !commitExists(ctx, repo.GitPath, target) {
+		return nil, fmt.Errorf("%w: %s", models.ErrRestoreTargetMissing, target)
+	}
+	// 只能恢复到该分支曾经包含的提交，防止借恢复把任意对象（如其他仓库共享的对象）挂到分支上
+	if req.SHA != "" {
+		recorded, err := s.isRecordedRevision(ctx, repo, ref, target)
+		if err != nil {
+			return nil, err
+		}
+		if !recorded {
+			return nil, fmt.Errorf("%w: %s", models.ErrRestoreTargetUnknown, target)
+		}
+	}
+
+	refs, err := listGitRefs(ctx, repo.GitPath, ref)
+	if err != nil {
+		return nil, err
+	}
+	current := refs[ref]
+	result := &models.RestoreBranchResult{
+		Branch:   req.Branch,
+		SHA:      target,
+		Previous: current,
+		Created:  current == "",
+	}
+	if current == target {
+		return result, nil
+	}
+
+	// 使用旧值校验，防止覆盖恢复期间的并发推送
+	oldSHA := current
+	if oldSHA == "" {
+		oldSHA = strings.Repeat("0", len(target))
+	}
+	update := models.RefUpdate{OldSHA: oldSHA, NewSHA: target, Ref: ref}
+	// 与推送相同，受保护分支只能快进恢复
+	if err := s.policies.CheckRefUpdate(ctx, repo.ID, update); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "update-ref", ref, target, oldSHA)
+	cmd.Dir = repo.GitPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("恢复分支失败: %s", strings.TrimSpace(string(output)))
+	}
+
+	if entries := s.RecordRefUpdates(ctx, repo, []models.RefUpdate{update}, models.RefActor{UserID: &access.UserID}, models.RefTransportRestore); len(entries) > 0 {
+		result.Entry = &entries[0]
+	}
+	s.syncRestoredBranch(ctx, repo, req.Branch, target)
+
+	s.logger.Info("分支已恢复",
+		zap.String("repository_id", repo.ID.String()),
+		zap.String("branch", req.Branch),
+		zap.String("sha", target),
+		zap.String("previous", current),
+		zap.String("user_id", access.UserID.String()))
+	return result, nil
+}
+
+// syncRestoredBranch 同步恢复后的分支记录
+func (s *refAuditService) syncRestoredBranch(ctx context.Context, repo *models.Repository, branch, sha string) {
+	if _, err := s.repo.GetBranchByName(ctx, repo.ID, branch); err == nil {
+		if err := s.repo.UpdateBranch(ctx, repo.ID, branch, map[string]interface{}{
+			"commit_sha": sha,
+		}); err != nil {
+			s.logger.Warn("更新分支记录失败", zap.String("repository_id", repo.ID.String()), zap.String("branch", branch), zap.Error(err))
+		}
+		return
+	}
+
+	if err := s.repo.CreateBranch(ctx, &models.Branch{
+		RepositoryID: repo.ID,
+		Name:         branch,
+		CommitSHA:    sha,
+		IsDefault:    branch == repo.DefaultBranch,
+	}); err != nil {
+		s.logger.Warn("创建分支记录失败", zap.String("repository_id", repo.ID.String()), zap.String("branch", branch), zap.Error(err))
+		return
+	}
+
+	branches, err := s.repo.ListBranches(ctx, repo.ID)
+	if err != nil {
+		return
+	}
+	if err := s.repo.UpdateRepository(ctx, repo.ID, map[string]interface{}{
+		"branch_count": len(branches),
+	}); err != nil {
+		s.logger.Warn("更新仓库分支数失败", zap.String("repository_id", repo.ID.String()), zap.Error(err))
+	}
+}
+
+// Start 为缺少审计钩子的仓库补装钩子，并定时清理过期的保留引用
+func (s *refAuditService) Start(ctx context.Context) error {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.installMissingHooks()
+
+		ticker := time.NewTicker(refKeepSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.releaseExpiredKeeps()
+			}
+		}
+	}()
+
+	s.logger.Info("引用审计服务已启动", zap.Duration("retention", s.retention))
+	return nil
+}
+
+// Stop 停止后台任务
+func (s *refAuditService) Stop() error {
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("引用审计服务已停止")
+	return nil
+}
+
+// installMissingHooks 为引用审计上线前创建的仓库安装post-receive钩子，钩子地址或令牌变化时重新安装
+func (s *refAuditService) installMissingHooks() {
+	if s.hookBaseURL == "" || s.hookSecret == "" {
+		return
+	}
+
+	const pageSize = 100
+	installed := 0
+	for page := 1; ; page++ {
+		if s.ctx.Err() != nil {
+			return
+		}
+		repos, total, err := s.repo.ListRepositories(s.ctx, nil, page, pageSize)
+		if err != nil {
+			s.logger.Error("列出仓库失败", zap.Error(err))
+			return
+		}
+
+		for _, repo := range repos {
+			if gitHooksInstalled(repo.GitPath, s.hookBaseURL, s.hookSecret, repo.ID) {
+				continue
+			}
+			if err := installGitHooks(repo.GitPath, s.hookBaseURL, s.hookSecret, repo.ID); err != nil {
+				s.logger.Warn("安装仓库钩子失败", zap.String("repository_id", repo.ID.String()), zap.Error(err))
+				continue
+			}
+			installed++
+		}
+
+		if int64(page*pageSize) >= total {
+			break
+		}
+	}
+
+	if installed > 0 {
+		s.logger.Info("已为现有仓库安装引用审计钩子", zap.Int("repositories", installed))
+	}
+}
+
+// releaseExpiredKeeps 删除保留期已过的保留引用，之后对象可被gc清理
+func (s *refAuditService) releaseExpiredKeeps() {
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Minute)
+	defer cancel()
+
+	now := time.Now()
+	entries, err := s.auditRepo.ListExpiredKeeps(ctx, now, refKeepSweepBatch)
+	if err != nil {
+		s.logger.Error("获取过期保留引用失败", zap.Error(err))
+		return
+	}
+
+	repos := make(map[uuid.UUID]*models.Repository)
+	released := 0
+	for _, entry := range entries {
+		repo, ok := repos[entry.RepositoryID]
+		if !ok {
+			repo, err = s.repo.GetRepositoryByID(ctx, entry.RepositoryID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				s.logger.Warn("获取仓库失败", zap.String("repository_id", entry.RepositoryID.String()), zap.Error(err))
+				continue
+			}
+			repos[entry.RepositoryID] = repo
+		}
+
+		// 仓库已删除时只更新记录
+		if repo != nil {
+			cmd := exec.CommandContext(ctx, "git", "update-ref", "-d", models.KeepAroundRef(entry.OldSHA))
+			cmd.Dir = repo.GitPath
+			if output, err := cmd.CombinedOutput(); err != nil {
+				s.logger.Warn("删除保留引用失败",
+					zap.String("repository_id", repo.ID.String()),
+					zap.String("sha", entry.OldSHA),
+					zap.String("output", strings.TrimSpace(string(output))))
+				continue
+			}
+		}
+
+		if err := s.auditRepo.ReleaseKeeps(ctx, entry.RepositoryID, entry.OldSHA, now); err != nil {
+			s.logger.Error("更新保留记录失败", zap.String("repository_id", entry.RepositoryID.String()), zap.Error(err))
+			continue
+		}
+		released++
+	}
+
+	if released > 0 {
+		s.logger.Info("已释放过期的保留提交", zap.Int("count", released))
+	}
+}
+
+// isForcedUpdate 判断引用更新是否为非快进更新。标签移动一律视为强制更新
+func isForcedUpdate(ctx context.Context, repoPath string, update models.RefUpdate) bool {
+	if models.RefTypeOf(update.Ref) == models.RefTypeTag {
+		return true
+	}
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", update.OldSHA, update.NewSHA)
+	cmd.Dir = repoPath
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	// 退出码1表示旧提交不是新提交的祖先；其他错误（如非提交对象）无法判断，按快进处理
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 1
+}
+
+// keepAroundCommit 创建保留引用，并确保该前缀对克隆和推送隐藏
+func keepAroundCommit(ctx context.Context, repoPath, sha string) error {
+	cmd := exec.CommandContext(ctx, "git", "update-ref", models.KeepAroundRef(sha), sha)
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("创建保留引用失败: %s", strings.TrimSpace(string(output)))
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "config", "--replace-all", "transfer.hideRefs", "refs/keep-around", "^refs/keep-around$")
+	cmd.Dir = repoPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("隐藏保留引用失败: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// maxRestoreRevisions 判断恢复目标时最多检查的审计记录数
+const maxRestoreRevisions = 100
+
+// isRecordedRevision 判断提交是否为审计记录中该分支曾经指向的提交或其祖先，或被保留引用保留
+func (s *refAuditService) isRecordedRevision(ctx context.Context, repo *models.Repository, ref, sha string) (bool, error) {
+	revisions, err := s.auditRepo.ListRefRevisions(ctx, repo.ID, ref, maxRestoreRevisions)
+	if err != nil {
+		return false, fmt.Errorf("获取引用审计记录失败: %w", err)
+	}
+	for _, revision := range revisions {
+		if revision == sha {
+			return true, nil
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--count=1", "--format=%(refname)", "--contains", sha, models.KeepAroundRefPrefix)
+	cmd.Dir = repo.GitPath
+	output, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("检查保留引用失败: %w", err)
+	}
+	if strings.TrimSpace(string(output)) != "" {
+		return true, nil
+	}
+
+	for _, revision := range revisions {
+		if isAncestorCommit(ctx, repo.GitPath, sha, revision) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isAncestorCommit 判断ancestor是否为commit的祖先，无法判断时视为否
+func isAncestorCommit(ctx context.Context, repoPath, ancestor, commit string) bool {
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", ancestor, commit)
+	cmd.Dir = repoPath
+	return cmd.Run() == nil
+}
+
+// commitExists 判断提交是否仍在对象库中
+func commitExists(ctx context.Context, repoPath, sha string) bool {
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "-e", sha+"^{commit}")
+	cmd.Dir = repoPath
+	return cmd.Run() == nil
+}
+
+// refUpdatesBetween 把操作前后的引用快照转换为引用更新，按引用名排序
+func refUpdatesBetween(before, after map[string]string) []models.RefUpdate {
+	changed := changedRefs(before, after)
+	sort.Strings(changed)
+
+	updates := make([]models.RefUpdate, 0, len(changed))
+	for _, ref := range changed {
+		oldSHA, newSHA := before[ref], after[ref]
+		if oldSHA == "" {
+			oldSHA = strings.Repeat("0", len(newSHA))
+		}
+		if newSHA == "" {
+			newSHA = strings.Repeat("0", len(oldSHA))
+		}
+		updates = append(updates, models.RefUpdate{OldSHA: oldSHA, NewSHA: newSHA, Ref: ref})
+	}
+	return updates
+}
+
+// refActorKey 请求上下文中引用更新发起者的键
+type refActorKey struct{}
+
+// WithRefActor 在上下文中携带操作用户，网关API引起的引用更新据此记录发起者
+func WithRefActor(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, refActorKey{}, userID)
+}
+
+// refActorFromContext 读取上下文中的操作用户
+func refActorFromContext(ctx context.Context) models.RefActor {
+	if userID, ok := ctx.Value(refActorKey{}).(uuid.UUID); ok && userID != uuid.Nil {
+		return models.RefActor{UserID: &userID}
+	}
+	return models.RefActor{}
+}
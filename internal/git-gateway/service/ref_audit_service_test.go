@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefAuditAccess(t *testing.T) {
+	access := models.RepositoryAccess{UserID: uuid.New(), TenantID: uuid.New()}
+
+	_, data, repo, _ := newTransferTestService(t)
+	s := &refAuditService{repo: data}
+
+	data.writable = false
+	_, err := s.RestoreBranch(context.Background(), repo.ID, &models.RestoreBranchRequest{Branch: "main"}, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryAccessDenied)
+
+	// 不可读的仓库与不存在返回相同错误
+	data.readable = false
+	_, err = s.ListRefAudit(context.Background(), repo.ID, &models.RefAuditFilter{}, access)
+	assert.ErrorIs(t, err, models.ErrRepositoryNotFound)
+}
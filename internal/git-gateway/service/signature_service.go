@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/repository"
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"go.uber.org/zap"
+)
+
+// SignatureVerifier 提交和标签签名校验接口
+type SignatureVerifier interface {
+	// VerifyObjects 批量校验仓库中同一类型对象的签名，返回以对象名为键的结果
+	// 对象实际类型与objectType不符时（如轻量标签指向的提交）视为未签名；env为空时使用当前环境
+	VerifyObjects(ctx context.Context, repoPath string, env []string, objectType string, names []string) (map[string]models.SignatureInfo, error)
+}
+
+// signatureVerifier 签名校验实现，按签名中的密钥标识查找用户在IAM上传的签名公钥
+type signatureVerifier struct {
+	keyRepo repository.SigningKeyRepository
+	logger  *zap.Logger
+}
+
+// NewSignatureVerifier 创建签名校验服务
+func NewSignatureVerifier(keyRepo repository.SigningKeyRepository, logger *zap.Logger) SignatureVerifier {
+	return &signatureVerifier{
+		keyRepo: keyRepo,
+		logger:  logger,
+	}
+}
+
+// VerifyObjects 批量读取对象并校验签名
+func (v *signatureVerifier) VerifyObjects(ctx context.Context, repoPath string, env []string, objectType string, names []string) (map[string]models.SignatureInfo, error) {
+	results := make(map[string]models.SignatureInfo, len(names))
+	if len(names) == 0 {
+		return results, nil
+	}
+
+	var input bytes.Buffer
+	for _, name := range names {
+		input.WriteString(name + "\n")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = repoPath
+	cmd.Env = env
+	cmd.Stdin = &input
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("读取对象失败: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	keys := make(map[string]*models.SigningKey)
+	reader := bufio.NewReader(bytes.NewReader(output))
+	for _, name := range names {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("读取对象头失败: %w", err)
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			// 对象不存在（<name> missing）
+			results[name] = models.UnsignedSignature()
+			continue
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("无效的对象大小: %w", err)
+		}
+		content := make([]byte, size+1) // 内容后的换行符
+		if _, err := io.ReadFull(reader, content); err != nil {
+			return nil, fmt.Errorf("读取对象内容失败: %w", err)
+		}
+		if fields[1] != objectType {
+			results[name] = models.UnsignedSignature()
+			continue
+		}
+
+		info, err := v.verify(ctx, objectType, content[:size], keys)
+		if err != nil {
+			return nil, err
+		}
+		results[name] = info
+	}
+	return results, nil
+}
+
+// verify 校验一个对象的签名，keys缓存本批次已查询的签名公钥
+func (v *signatureVerifier) verify(ctx context.Context, objectType string, raw []byte, keys map[string]*models.SigningKey) (models.SignatureInfo, error) {
+	var obj *models.SignedObject
+	switch objectType {
+	case "commit":
+		obj = models.ParseSignedCommit(raw)
+	case "tag":
+		obj = models.ParseSignedTag(raw)
+	default:
+		return models.UnsignedSignature(), nil
+	}
+
+	info := models.SignatureInfo{Status: models.SignatureStatusUnverified, SignerEmail: obj.Email}
+	if len(obj.Signature) == 0 {
+		return info, nil
+	}
+
+	// 不支持的签名格式（如x509）无法对应到平台上的签名公钥
+	info.Type = auth.SignatureType(obj.Signature)
+	if info.Type == "" {
+		info.Status = models.SignatureStatusUnknownKey
+		return info, nil
+	}
+	keyID, err := auth.SignatureKeyID(obj.Signature)
+	if err != nil {
+		info.Status = models.SignatureStatusBadSignature
+		return info, nil
+	}
+	info.KeyID = keyID
+
+	cacheKey := info.Type + ":" + keyID
+	key, cached := keys[cacheKey]
+	if !cached {
+		key, err = v.keyRepo.FindByKeyID(ctx, info.Type, keyID)
+		if err != nil {
+			return info, fmt.Errorf("查找签名公钥失败: %w", err)
+		}
+		keys[cacheKey] = key
+	}
+	if key == nil {
+		info.Status = models.SignatureStatusUnknownKey
+		return info, nil
+	}
+
+	if err := auth.VerifySignature(key.KeyType, key.PublicKey, obj.Payload, obj.Signature); err != nil {
+		if !errors.Is(err, auth.ErrBadSignature) && !errors.Is(err, auth.ErrInvalidSignature) {
+			v.logger.Warn("签名公钥无法用于校验",
+				zap.String("signing_key_id", key.ID.String()),
+				zap.Error(err))
+		}
+		info.Status = models.SignatureStatusBadSignature
+		return info, nil
+	}
+
+	signerID := key.UserID
+	info.SignerUserID = &signerID
+	if key.AllowsEmail(obj.Email) {
+		info.Status = models.SignatureStatusVerified
+	}
+	return info, nil
+}
@@ -0,0 +1,524 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// webhookDeliveryConcurrency 同时进行的投递请求数
+	webhookDeliveryConcurrency = 8
+	// webhookDeliveryPollInterval 扫描到期投递的间隔，新投递入队时会立即唤醒
+	webhookDeliveryPollInterval = 10 * time.Second
+	// webhookDeliveryBatchSize 每次领取的投递数
+	webhookDeliveryBatchSize = 50
+	// webhookResponseExcerptLimit 记录的响应体长度上限
+	webhookResponseExcerptLimit = 4 << 10
+)
+
+// Start 启动投递队列的后台调度
+func (s *webhookService) Start(ctx context.Context) error {
+	s.logger.Info("启动Webhook投递队列",
+		zap.Int("concurrency", webhookDeliveryConcurrency),
+		zap.Int("max_retries", s.config.MaxRetries))
+
+	s.wg.Add(1)
+	go s.deliveryLoop()
+
+	return nil
+}
+
+// Stop 停止投递队列，等待进行中的投递完成
+func (s *webhookService) Stop() error {
+	s.logger.Info("停止Webhook投递队列")
+	s.cancel()
+	s.wg.Wait()
+	return nil
+}
+
+// deliveryLoop 定时或被唤醒时领取到期的投递
+func (s *webhookService) deliveryLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(webhookDeliveryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatchDueDeliveries()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// dispatchDueDeliveries 领取到期投递并并发发送
+func (s *webhookService) dispatchDueDeliveries() {
+	// 租约覆盖一次请求的超时时间，进程在发送期间退出时投递会在租约到期后被重新领取
+	lease := s.config.RequestTimeout + time.Minute
+	deliveries, err := s.webhookRepo.ClaimDueWebhookDeliveries(s.ctx, time.Now(), lease, webhookDeliveryBatchSize)
+	if err != nil {
+		if s.ctx.Err() == nil {
+			s.logger.Error("领取Webhook投递失败", zap.Error(err))
+		}
+		return
+	}
+
+	for i := range deliveries {
+		delivery := deliveries[i]
+
+		select {
+		case s.semaphore <- struct{}{}:
+		case <-s.ctx.Done():
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() { <-s.semaphore }()
+			s.deliver(s.ctx, &delivery)
+		}()
+	}
+}
+
+// notifyDeliveryQueue 唤醒调度，不阻塞
+func (s *webhookService) notifyDeliveryQueue() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// enqueueDelivery 写入一条待投递记录
+func (s *webhookService) enqueueDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := time.Now()
+	delivery.ID = uuid.New()
+	delivery.Status = models.DeliveryStatusPending
+	delivery.Attempts = 0
+	delivery.MaxAttempts = s.config.MaxRetries + 1
+	delivery.NextRetryAt = &now
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	if delivery.RequestHeaders == nil {
+		delivery.RequestHeaders = map[string]string{}
+	}
+
+	if err := s.webhookRepo.CreateWebhookDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("创建投递记录失败: %w", err)
+	}
+
+	s.notifyDeliveryQueue()
+	return nil
+}
+
+// enqueueRepositoryWebhooks 为订阅了事件的仓库Webhook创建投递
+func (s *webhookService) enqueueRepositoryWebhooks(ctx context.Context, event *models.WebhookEvent) error {
+	webhooks, err := s.repo.ListWebhooks(ctx, event.RepositoryID)
+	if err != nil {
+		return fmt.Errorf("获取仓库Webhook失败: %w", err)
+	}
+
+	var payload []byte
+	for i := range webhooks {
+		webhook := &webhooks[i]
+		if !webhook.IsActive || !webhook.SubscribesTo(event.EventType) {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(map[string]interface{}{
+				"event_id":   event.ID.String(),
+				"event_type": event.EventType,
+				"timestamp":  event.CreatedAt.Format(time.RFC3339),
+				"data":       event.EventData,
+			})
+			if err != nil {
+				return fmt.Errorf("序列化事件载荷失败: %w", err)
+			}
+		}
+
+		if err := s.enqueueDelivery(ctx, &models.WebhookDelivery{
+			WebhookID:    &webhook.ID,
+			RepositoryID: event.RepositoryID,
+			EventID:      event.ID,
+			EventType:    event.EventType,
+			URL:          webhook.URL,
+			Method:       http.MethodPost,
+			RequestBody:  string(payload),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deliveryTarget 发送时解析的投递目标
+type deliveryTarget struct {
+	url    string
+	secret string
+	active bool
+}
+
+// resolveDeliveryTarget 读取投递目标当前的地址、密钥和启用状态
+func (s *webhookService) resolveDeliveryTarget(ctx context.Context, delivery *models.WebhookDelivery) (*deliveryTarget, error) {
+	if delivery.WebhookID != nil {
+		webhook, err := s.repo.GetWebhookByID(ctx, *delivery.WebhookID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &deliveryTarget{}, nil
+			}
+			return nil, err
+		}
+		target := &deliveryTarget{url: webhook.URL, active: webhook.IsActive}
+		if webhook.Secret != nil {
+			target.secret = *webhook.Secret
+		}
+		return target, nil
+	}
+
+	if delivery.TriggerID != nil {
+		trigger, err := s.webhookRepo.GetWebhookTrigger(ctx, *delivery.TriggerID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return &deliveryTarget{}, nil
+			}
+			return nil, err
+		}
+		target := &deliveryTarget{active: trigger.Enabled}
+		if trigger.Actions.CallWebhook != nil {
+			target.url = trigger.Actions.CallWebhook.URL
+			target.secret = trigger.Actions.CallWebhook.Secret
+		}
+		// 触发器不再调用Webhook时视为已停用
+		target.active = target.active && target.url != ""
+		return target, nil
+	}
+
+	return &deliveryTarget{}, nil
+}
+
+// deliver 发送一次投递并根据结果安排重试
+func (s *webhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	logger := s.logger.With(
+		zap.String("delivery_id", delivery.ID.String()),
+		zap.String("url", delivery.URL))
+
+	target, err := s.resolveDeliveryTarget(ctx, delivery)
+	if err != nil {
+		// 读取目标失败时保留租约，到期后重新领取
+		logger.Error("读取投递目标失败", zap.Error(err))
+		return
+	}
+	if !target.active {
+		now := time.Now()
+		if err := s.webhookRepo.UpdateWebhookDelivery(ctx, delivery.ID, map[string]interface{}{
+			"status":        models.DeliveryStatusFailed,
+			"next_retry_at": nil,
+			"last_error":    "投递目标已删除或已停用",
+			"updated_at":    now,
+		}); err != nil {
+			logger.Error("更新投递记录失败", zap.Error(err))
+		}
+		return
+	}
+
+	attempt := s.sendHTTPRequest(ctx, delivery, target)
+	attempt.ID = uuid.New()
+	attempt.DeliveryID = delivery.ID
+	attempt.Attempt = delivery.Attempts + 1
+	attempt.CreatedAt = time.Now()
+
+	if err := s.webhookRepo.CreateWebhookDeliveryAttempt(ctx, attempt); err != nil {
+		logger.Error("记录投递尝试失败", zap.Error(err))
+	}
+
+	updates := map[string]interface{}{
+		"status_code":   attempt.StatusCode,
+		"response_body": attempt.ResponseBody,
+		"duration":      attempt.Duration,
+		"attempts":      attempt.Attempt,
+		"last_error":    attempt.Error,
+		"updated_at":    attempt.CreatedAt,
+	}
+
+	final := true
+	switch {
+	case attempt.Succeeded():
+		updates["status"] = models.DeliveryStatusSuccess
+		updates["next_retry_at"] = nil
+	case attempt.Retryable() && attempt.Attempt < delivery.MaxAttempts:
+		next := attempt.CreatedAt.Add(models.WebhookRetryDelay(s.config.RetryInterval, attempt.Attempt))
+		updates["status"] = models.DeliveryStatusRetrying
+		updates["next_retry_at"] = next
+		final = false
+	default:
+		updates["status"] = models.DeliveryStatusFailed
+		updates["next_retry_at"] = nil
+	}
+
+	if err := s.webhookRepo.UpdateWebhookDelivery(ctx, delivery.ID, updates); err != nil {
+		logger.Error("更新投递记录失败", zap.Error(err))
+		return
+	}
+
+	if attempt.Succeeded() {
+		logger.Info("Webhook投递成功",
+			zap.Int("attempt", attempt.Attempt),
+			zap.Int("status_code", attempt.StatusCode))
+	} else {
+		logger.Warn("Webhook投递失败",
+			zap.Int("attempt", attempt.Attempt),
+			zap.Int("status_code", attempt.StatusCode),
+			zap.String("error", attempt.Error),
+			zap.Bool("will_retry", !final))
+	}
+
+	if final && delivery.WebhookID != nil {
+		s.recordWebhookOutcome(ctx, *delivery.WebhookID, attempt)
+	}
+}
+
+// sendHTTPRequest 发送HTTP请求，签名使用请求体的HMAC-SHA256
+// 请求发往目标当前的地址，投递创建后修改的Webhook地址同样生效
+func (s *webhookService) sendHTTPRequest(ctx context.Context, delivery *models.WebhookDelivery, target *deliveryTarget) *models.WebhookDeliveryAttempt {
+	attempt := &models.WebhookDeliveryAttempt{}
+
+	reqCtx, cancel := context.WithTimeout(ctx, s.config.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, delivery.Method, target.url, bytes.NewBufferString(delivery.RequestBody))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("创建请求失败: %v", err)
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CloudPlatform-Webhook/1.0")
+	for key, value := range delivery.RequestHeaders {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.String())
+	if target.secret != "" {
+		req.Header.Set("X-Webhook-Signature", s.generateWebhookSignature(delivery.RequestBody, target.secret))
+	}
+
+	start := time.Now()
+	resp, err := s.httpClient.Do(req)
+	attempt.Duration = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = fmt.Sprintf("发送请求失败: %v", err)
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, webhookResponseExcerptLimit))
+	if err != nil {
+		attempt.Error = fmt.Sprintf("读取响应失败: %v", err)
+	}
+	attempt.StatusCode = resp.StatusCode
+	attempt.ResponseBody = string(body)
+	if attempt.Error == "" && !attempt.Succeeded() {
+		attempt.Error = fmt.Sprintf("响应状态码 %d", resp.StatusCode)
+	}
+
+	return attempt
+}
+
+// recordWebhookOutcome 记录仓库Webhook的最终投递结果，连续失败达到阈值时停用
+func (s *webhookService) recordWebhookOutcome(ctx context.Context, webhookID uuid.UUID, attempt *models.WebhookDeliveryAttempt) {
+	if attempt.Succeeded() {
+		if err := s.repo.UpdateWebhook(ctx, webhookID, map[string]interface{}{
+			"consecutive_failures": 0,
+		}); err != nil {
+			s.logger.Error("重置Webhook失败计数失败", zap.String("webhook_id", webhookID.String()), zap.Error(err))
+		}
+		return
+	}
+
+	if err := s.repo.UpdateWebhook(ctx, webhookID, map[string]interface{}{
+		"consecutive_failures": gorm.Expr("consecutive_failures + 1"),
+	}); err != nil {
+		s.logger.Error("更新Webhook失败计数失败", zap.String("webhook_id", webhookID.String()), zap.Error(err))
+		return
+	}
+
+	if s.config.DisableAfterFailures <= 0 {
+		return
+	}
+
+	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		s.logger.Error("获取Webhook失败", zap.String("webhook_id", webhookID.String()), zap.Error(err))
+		return
+	}
+	if !webhook.IsActive || webhook.ConsecutiveFailures < s.config.DisableAfterFailures {
+		return
+	}
+
+	now := time.Now()
+	reason := fmt.Sprintf("连续%d次投递失败，最后一次错误: %s", webhook.ConsecutiveFailures, attempt.Error)
+	if err := s.repo.UpdateWebhook(ctx, webhookID, map[string]interface{}{
+		"is_active":       false,
+		"disabled_at":     now,
+		"disabled_reason": reason,
+		"updated_at":      now,
+	}); err != nil {
+		s.logger.Error("停用Webhook失败", zap.String("webhook_id", webhookID.String()), zap.Error(err))
+		return
+	}
+
+	s.logger.Warn("Webhook连续投递失败，已自动停用",
+		zap.String("webhook_id", webhookID.String()),
+		zap.String("url", webhook.URL),
+		zap.Int("consecutive_failures", webhook.ConsecutiveFailures))
+}
+
+// GetWebhookDelivery 获取投递记录及每次尝试
+func (s *webhookService) GetWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, access models.RepositoryAccess) (*models.WebhookDelivery, error) {
+	delivery, err := s.getAuthorizedDelivery(ctx, deliveryID, access)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery.AttemptHistory, err = s.webhookRepo.ListWebhookDeliveryAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("获取投递尝试记录失败: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// RetryWebhookDelivery 重新投递：复制原请求创建新的投递，原记录保持不变。
+// 地址取Webhook或触发器当前的配置，与密钥和启用状态一致
+func (s *webhookService) RetryWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, access models.RepositoryAccess) (*models.WebhookDelivery, error) {
+	original, err := s.getAuthorizedDelivery(ctx, deliveryID, access)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.resolveDeliveryTarget(ctx, original)
+	if err != nil {
+		return nil, fmt.Errorf("读取投递目标失败: %w", err)
+	}
+	targetURL := target.url
+	if targetURL == "" {
+		// 目标已删除，投递时会被标记为失败
+		targetURL = original.URL
+	}
+
+	redelivery := &models.WebhookDelivery{
+		WebhookID:      original.WebhookID,
+		TriggerID:      original.TriggerID,
+		RepositoryID:   original.RepositoryID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		RedeliveryOf:   &original.ID,
+		URL:            targetURL,
+		Method:         original.Method,
+		RequestHeaders: original.RequestHeaders,
+		RequestBody:    original.RequestBody,
+	}
+	if err := s.enqueueDelivery(ctx, redelivery); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("已重新投递Webhook",
+		zap.String("delivery_id", original.ID.String()),
+		zap.String("redelivery_id", redelivery.ID.String()))
+
+	return redelivery, nil
+}
+
+// EnableWebhook 启用或停用仓库Webhook，重新启用时清除失败计数和停用原因
+func (s *webhookService) EnableWebhook(ctx context.Context, webhookID uuid.UUID, enabled bool, access models.RepositoryAccess) error {
+	if _, err := s.getAuthorizedWebhook(ctx, webhookID, access); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"is_active":  enabled,
+		"updated_at": time.Now(),
+	}
+	if enabled {
+		updates["consecutive_failures"] = 0
+		updates["disabled_at"] = nil
+		updates["disabled_reason"] = nil
+	}
+
+	if err := s.repo.UpdateWebhook(ctx, webhookID, updates); err != nil {
+		return fmt.Errorf("更新Webhook失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveryStats 获取单个仓库Webhook的投递统计
+func (s *webhookService) GetWebhookDeliveryStats(ctx context.Context, webhookID uuid.UUID, access models.RepositoryAccess) (*models.WebhookDeliveryStats, error) {
+	if _, err := s.getAuthorizedWebhook(ctx, webhookID, access); err != nil {
+		return nil, err
+	}
+
+	stats, err := s.webhookRepo.ListWebhookDeliveryStats(ctx, nil, &webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Webhook投递统计失败: %w", err)
+	}
+	if len(stats) == 0 {
+		return nil, models.ErrWebhookNotFound
+	}
+
+	return &stats[0], nil
+}
+
+// getAuthorizedDelivery 获取投递记录，并校验用户是其所属仓库的管理员
+func (s *webhookService) getAuthorizedDelivery(ctx context.Context, deliveryID uuid.UUID, access models.RepositoryAccess) (*models.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrWebhookDeliveryNotFound
+		}
+		return nil, fmt.Errorf("获取投递记录失败: %w", err)
+	}
+	if err := requireRepositoryAdmin(ctx, s.repo, delivery.RepositoryID, access); err != nil {
+		if errors.Is(err, models.ErrRepositoryNotFound) {
+			return nil, models.ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// getAuthorizedWebhook 获取仓库Webhook，并校验用户是其所属仓库的管理员
+func (s *webhookService) getAuthorizedWebhook(ctx context.Context, webhookID uuid.UUID, access models.RepositoryAccess) (*models.Webhook, error) {
+	webhook, err := s.repo.GetWebhookByID(ctx, webhookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("获取Webhook失败: %w", err)
+	}
+	if err := requireRepositoryAdmin(ctx, s.repo, webhook.RepositoryID, access); err != nil {
+		if errors.Is(err, models.ErrRepositoryNotFound) {
+			return nil, models.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
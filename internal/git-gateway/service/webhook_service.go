@@ -1,17 +1,16 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
@@ -38,12 +37,15 @@ type WebhookService interface {
 	DeleteWebhookTrigger(ctx context.Context, triggerID uuid.UUID) error
 	EnableWebhookTrigger(ctx context.Context, triggerID uuid.UUID, enabled bool) error
 
-	// 投递管理
-	ListWebhookDeliveries(ctx context.Context, webhookID *uuid.UUID, page, pageSize int) (*models.WebhookDeliveryListResponse, error)
-	RetryWebhookDelivery(ctx context.Context, deliveryID uuid.UUID) error
+	// 投递管理，均要求仓库管理员权限
+	ListWebhookDeliveries(ctx context.Context, repositoryID uuid.UUID, webhookID *uuid.UUID, page, pageSize int, access models.RepositoryAccess) (*models.WebhookDeliveryListResponse, error)
+	GetWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, access models.RepositoryAccess) (*models.WebhookDelivery, error)
+	RetryWebhookDelivery(ctx context.Context, deliveryID uuid.UUID, access models.RepositoryAccess) (*models.WebhookDelivery, error)
+	EnableWebhook(ctx context.Context, webhookID uuid.UUID, enabled bool, access models.RepositoryAccess) error
 
 	// 统计信息
 	GetWebhookStatistics(ctx context.Context, repositoryID *uuid.UUID) (*models.WebhookStatistics, error)
+	GetWebhookDeliveryStats(ctx context.Context, webhookID uuid.UUID, access models.RepositoryAccess) (*models.WebhookDeliveryStats, error)
 
 	// Git事件处理
 	HandleGitPushEvent(ctx context.Context, repositoryID uuid.UUID, pushData *models.PushEvent) error
@@ -54,6 +56,10 @@ type WebhookService interface {
 	// CI/CD集成
 	TriggerPipeline(ctx context.Context, repositoryID uuid.UUID, eventData interface{}) error
 	NotifyExternalSystems(ctx context.Context, event *models.WebhookEvent) error
+
+	// 投递队列
+	Start(ctx context.Context) error
+	Stop() error
 }
 
 // webhookService Webhook服务实现
@@ -64,6 +70,15 @@ type webhookService struct {
 	logger              *zap.Logger
 	config              WebhookConfig
 	notificationManager *notification.NotificationManager
+	pushListeners       []PushListener // 推送事件监听器（如代码搜索索引）
+
+	// 投递队列
+	httpClient *http.Client
+	semaphore  chan struct{} // 限制并发的投递数
+	wake       chan struct{} // 新投递入队时立即唤醒调度
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
 }
 
 // WebhookConfig Webhook配置
@@ -75,6 +90,8 @@ type WebhookConfig struct {
 	EnableBatching bool          `yaml:"enable_batching"`
 	BatchSize      int           `yaml:"batch_size"`
 	BatchTimeout   time.Duration `yaml:"batch_timeout"`
+
+	DisableAfterFailures int `yaml:"disable_after_failures"` // 仓库Webhook连续投递失败达到该次数后自动停用，0表示不停用
 }
 
 // CICDService CI/CD服务接口（避免循环依赖）
@@ -83,13 +100,23 @@ type CICDService interface {
 }
 
 // NewWebhookService 创建Webhook服务
-func NewWebhookService(repo repository.GitRepository, webhookRepo repository.WebhookRepository, cicdService CICDService, config WebhookConfig, logger *zap.Logger) WebhookService {
+func NewWebhookService(repo repository.GitRepository, webhookRepo repository.WebhookRepository, cicdService CICDService, config WebhookConfig, logger *zap.Logger, pushListeners ...PushListener) WebhookService {
+	if config.RequestTimeout <= 0 {
+		config.RequestTimeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
 	return &webhookService{
-		repo:        repo,
-		webhookRepo: webhookRepo,
-		cicdService: cicdService,
-		config:      config,
-		logger:      logger,
+		repo:          repo,
+		webhookRepo:   webhookRepo,
+		cicdService:   cicdService,
+		config:        config,
+		logger:        logger,
+		pushListeners: pushListeners,
+		httpClient:    &http.Client{Timeout: config.RequestTimeout},
+		semaphore:     make(chan struct{}, webhookDeliveryConcurrency),
+		wake:          make(chan struct{}, 1),
+		ctx:           ctx,
+		cancel:        cancel,
 	}
 }
 
@@ -156,6 +183,15 @@ func (s *webhookService) ProcessWebhookEvent(ctx context.Context, eventID uuid.U
 		zap.String("event_id", eventID.String()),
 		zap.String("event_type", string(event.EventType)))
 
+	// 通知推送监听器
+	if event.EventType == models.EventTypePush {
+		if ref, ok := event.EventData["ref"].(string); ok {
+			for _, listener := range s.pushListeners {
+				listener.OnPush(event.RepositoryID, ref)
+			}
+		}
+	}
+
 	// 获取匹配的触发器
 	triggers, err := s.getMatchingTriggers(ctx, event)
 	if err != nil {
@@ -177,6 +213,14 @@ func (s *webhookService) ProcessWebhookEvent(ctx context.Context, eventID uuid.U
 		}
 	}
 
+	// 投递到订阅该事件的仓库Webhook
+	if err := s.enqueueRepositoryWebhooks(ctx, event); err != nil {
+		processingErrors = append(processingErrors, err)
+		s.logger.Error("创建仓库Webhook投递失败",
+			zap.String("event_id", eventID.String()),
+			zap.Error(err))
+	}
+
 	// 更新事件处理状态
 	now := time.Now().UTC()
 	updates := map[string]interface{}{
@@ -433,7 +477,7 @@ func (s *webhookService) executeTriggerActions(ctx context.Context, event *model
 
 	// 调用Webhook
 	if actions.CallWebhook != nil {
-		if err := s.executeWebhookAction(ctx, event, actions.CallWebhook); err != nil {
+		if err := s.executeWebhookAction(ctx, event, trigger, actions.CallWebhook); err != nil {
 			return fmt.Errorf("执行Webhook动作失败: %w", err)
 		}
 	}
@@ -613,8 +657,8 @@ func (s *webhookService) generateDefaultNotificationBody(event *models.WebhookEv
 	return body.String()
 }
 
-// executeWebhookAction 执行Webhook动作
-func (s *webhookService) executeWebhookAction(ctx context.Context, event *models.WebhookEvent, action *models.WebhookAction) error {
+// executeWebhookAction 执行Webhook动作，请求写入投递队列后由后台发送和重试
+func (s *webhookService) executeWebhookAction(ctx context.Context, event *models.WebhookEvent, trigger *models.WebhookTrigger, action *models.WebhookAction) error {
 	s.logger.Info("执行Webhook动作",
 		zap.String("url", action.URL),
 		zap.String("event_id", event.ID.String()))
@@ -628,35 +672,21 @@ func (s *webhookService) executeWebhookAction(ctx context.Context, event *models
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	// 发送HTTP请求（带重试）
-	maxRetries := 3
-	retryDelay := 1 * time.Second
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(retryDelay):
-				retryDelay *= 2 // 指数退避
-			}
-		}
-
-		err = s.sendHTTPRequest(ctx, action, data)
-		if err == nil {
-			s.logger.Info("Webhook sent successfully",
-				zap.String("url", action.URL),
-				zap.Int("attempts", attempt+1))
-			return nil
-		}
-
-		s.logger.Warn("Failed to send webhook, retrying",
-			zap.String("url", action.URL),
-			zap.Int("attempt", attempt+1),
-			zap.Error(err))
+	method := action.Method
+	if method == "" {
+		method = http.MethodPost
 	}
 
-	return fmt.Errorf("failed to send webhook after %d attempts: %w", maxRetries, err)
+	return s.enqueueDelivery(ctx, &models.WebhookDelivery{
+		TriggerID:      &trigger.ID,
+		RepositoryID:   event.RepositoryID,
+		EventID:        event.ID,
+		EventType:      event.EventType,
+		URL:            action.URL,
+		Method:         method,
+		RequestHeaders: action.Headers,
+		RequestBody:    string(data),
+	})
 }
 
 // prepareWebhookPayload 准备Webhook载荷
@@ -685,60 +715,6 @@ func (s *webhookService) prepareWebhookPayload(event *models.WebhookEvent, actio
 	return payload
 }
 
-// sendHTTPRequest 发送HTTP请求
-func (s *webhookService) sendHTTPRequest(ctx context.Context, action *models.WebhookAction, data []byte) error {
-	// 创建请求
-	req, err := http.NewRequestWithContext(ctx, action.Method, action.URL, bytes.NewBuffer(data))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "CloudPlatform-Webhook/1.0")
-
-	// 添加自定义请求头
-	for key, value := range action.Headers {
-		req.Header.Set(key, value)
-	}
-
-	// 添加签名头（如果配置了密钥）
-	if action.Secret != "" {
-		signature := s.generateWebhookSignature(string(data), action.Secret)
-		req.Header.Set("X-Webhook-Signature", signature)
-	}
-
-	// 创建HTTP客户端
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	// 发送请求
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// 读取响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// 检查响应状态
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	s.logger.Debug("Webhook response",
-		zap.String("url", action.URL),
-		zap.Int("status", resp.StatusCode),
-		zap.String("body", string(body)))
-
-	return nil
-}
-
 // generateWebhookSignature 生成Webhook签名
 func (s *webhookService) generateWebhookSignature(data, secret string) string {
 	h := hmac.New(sha256.New, []byte(secret))
@@ -895,17 +871,29 @@ func (s *webhookService) EnableWebhookTrigger(ctx context.Context, triggerID uui
 	return s.webhookRepo.UpdateWebhookTrigger(ctx, triggerID, updates)
 }
 
-func (s *webhookService) ListWebhookDeliveries(ctx context.Context, webhookID *uuid.UUID, page, pageSize int) (*models.WebhookDeliveryListResponse, error) {
-	// TODO: 实现投递记录列表
-	return &models.WebhookDeliveryListResponse{}, nil
-}
-
-func (s *webhookService) RetryWebhookDelivery(ctx context.Context, deliveryID uuid.UUID) error {
-	// TODO: 实现投递重试
-	return fmt.Errorf("未实现")
+func (s *webhookService) ListWebhookDeliveries(ctx context.Context, repositoryID uuid.UUID, webhookID *uuid.UUID, page, pageSize int, access models.RepositoryAccess) (*models.WebhookDeliveryListResponse, error) {
+	if err := requireRepositoryAdmin(ctx, s.repo, repositoryID, access); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	return s.webhookRepo.ListWebhookDeliveries(ctx, repositoryID, webhookID, page, pageSize)
 }
 
 func (s *webhookService) GetWebhookStatistics(ctx context.Context, repositoryID *uuid.UUID) (*models.WebhookStatistics, error) {
-	// TODO: 实现统计信息
-	return &models.WebhookStatistics{}, nil
+	stats, err := s.webhookRepo.GetWebhookStatistics(ctx, repositoryID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Webhook统计信息失败: %w", err)
+	}
+
+	stats.Webhooks, err = s.webhookRepo.ListWebhookDeliveryStats(ctx, repositoryID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取Webhook投递统计失败: %w", err)
+	}
+
+	return stats, nil
 }
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+)
+
+// LFSStore Git LFS对象存储接口，对象按OID内容寻址
+type LFSStore interface {
+	Stat(ctx context.Context, oid string) (int64, error)
+	Open(ctx context.Context, oid string) (io.ReadSeekCloser, error)
+	Put(ctx context.Context, oid string, size int64, data io.Reader) error
+	Delete(ctx context.Context, oid string) error
+}
+
+// localLFSStore 基于本地文件系统的LFS对象存储
+//
+// 目录布局：
+//
+//	<root>/objects/<OID前2位>/<OID第3-4位>/<OID>
+//	<root>/tmp/<上传临时文件>
+type localLFSStore struct {
+	root string
+}
+
+// NewLocalLFSStore 创建本地LFS对象存储
+func NewLocalLFSStore(root string) (LFSStore, error) {
+	for _, dir := range []string{"objects", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0750); err != nil {
+			return nil, fmt.Errorf("创建存储目录失败: %w", err)
+		}
+	}
+	return &localLFSStore{root: root}, nil
+}
+
+// Stat 获取对象大小
+func (s *localLFSStore) Stat(ctx context.Context, oid string) (int64, error) {
+	path, err := s.objectPath(oid)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, models.ErrLFSObjectNotFound
+		}
+		return 0, fmt.Errorf("读取对象信息失败: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// Open 打开对象
+func (s *localLFSStore) Open(ctx context.Context, oid string) (io.ReadSeekCloser, error) {
+	path, err := s.objectPath(oid)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, models.ErrLFSObjectNotFound
+		}
+		return nil, fmt.Errorf("打开对象失败: %w", err)
+	}
+	return file, nil
+}
+
+// Put 写入对象，内容的SHA-256和大小必须与OID和size一致
+func (s *localLFSStore) Put(ctx context.Context, oid string, size int64, data io.Reader) error {
+	path, err := s.objectPath(oid)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Join(s.root, "tmp"), oid+"-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	// 多读1字节用于发现超出声明大小的内容
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(data, size+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("写入对象失败: %w", err)
+	}
+	if written != size {
+		return fmt.Errorf("%w: expected %d bytes, received %d", models.ErrLFSSizeMismatch, size, written)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != oid {
+		return fmt.Errorf("%w: content does not match oid", models.ErrLFSInvalidObject)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("创建对象目录失败: %w", err)
+	}
+
+	// 内容寻址：相同OID的对象已存在时直接复用
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("提交对象失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除对象
+func (s *localLFSStore) Delete(ctx context.Context, oid string) error {
+	path, err := s.objectPath(oid)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除对象失败: %w", err)
+	}
+	return nil
+}
+
+// objectPath 计算对象存储路径
+func (s *localLFSStore) objectPath(oid string) (string, error) {
+	if err := models.ValidateLFSOID(oid); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, "objects", oid[:2], oid[2:4], oid), nil
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cloud-platform/collaborative-dev/internal/git-gateway/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lfsOID(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestLocalLFSStorePut(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalLFSStore(t.TempDir())
+	require.NoError(t, err)
+
+	content := "large design asset"
+	oid := lfsOID(content)
+
+	require.NoError(t, store.Put(ctx, oid, int64(len(content)), strings.NewReader(content)))
+
+	size, err := store.Stat(ctx, oid)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+
+	reader, err := store.Open(ctx, oid)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	// 重复上传相同内容
+	require.NoError(t, store.Put(ctx, oid, int64(len(content)), strings.NewReader(content)))
+
+	require.NoError(t, store.Delete(ctx, oid))
+	_, err = store.Stat(ctx, oid)
+	assert.ErrorIs(t, err, models.ErrLFSObjectNotFound)
+}
+
+func TestLocalLFSStoreRejectsInvalidContent(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalLFSStore(t.TempDir())
+	require.NoError(t, err)
+
+	content := "payload"
+	oid := lfsOID(content)
+
+	// 内容与OID不一致
+	err = store.Put(ctx, lfsOID("other"), int64(len(content)), strings.NewReader(content))
+	assert.ErrorIs(t, err, models.ErrLFSInvalidObject)
+
+	// 内容比声明的短或长
+	err = store.Put(ctx, oid, int64(len(content))+1, strings.NewReader(content))
+	assert.ErrorIs(t, err, models.ErrLFSSizeMismatch)
+	err = store.Put(ctx, oid, int64(len(content))-1, strings.NewReader(content))
+	assert.ErrorIs(t, err, models.ErrLFSSizeMismatch)
+
+	_, err = store.Stat(ctx, oid)
+	assert.ErrorIs(t, err, models.ErrLFSObjectNotFound)
+
+	// 非法OID不能用于拼接路径
+	_, err = store.Open(ctx, "../../etc/passwd")
+	assert.ErrorIs(t, err, models.ErrLFSInvalidObject)
+}
@@ -116,6 +116,8 @@ func (ec *EventConsumer) processMessage(message kafka.Message) error {
 		return ec.handleTaskCompletedEvent(event)
 	case models.EventTypeTaskOverdue:
 		return ec.handleTaskOverdueEvent(event)
+	case models.EventTypeTaskTransitioned:
+		return ec.handleTaskTransitionedEvent(event)
 	case models.EventTypeSprintStarted:
 		return ec.handleSprintStartedEvent(event)
 	case models.EventTypeSprintCompleted:
@@ -234,6 +236,42 @@ func (ec *EventConsumer) handleTaskOverdueEvent(event models.Event) error {
 
 // Sprint相关事件处理器
 
+func (ec *EventConsumer) handleTaskTransitionedEvent(event models.Event) error {
+	var eventData models.TaskTransitionedEventData
+	if err := json.Unmarshal(event.Data, &eventData); err != nil {
+		return fmt.Errorf("failed to unmarshal task transitioned event data: %w", err)
+	}
+
+	// 为每个接收人创建站内通知
+	for _, recipientID := range eventData.RecipientIDs {
+		recipientID := recipientID
+		request := &services.CreateNotificationRequest{
+			UserID:    &recipientID,
+			TenantID:  event.TenantID,
+			ProjectID: &eventData.ProjectID,
+			Type:      models.EventTypeTaskTransitioned,
+			Category:  models.CategoryProject,
+			Priority:  models.PriorityMedium,
+			Title:     fmt.Sprintf("%s moved to %s", eventData.TaskKey, eventData.ToName),
+			Content:   fmt.Sprintf("%s: %s → %s (%s)", eventData.TaskTitle, eventData.FromName, eventData.ToName, eventData.Transition),
+			EventData: event.Data,
+			Channels: &models.Channels{
+				InApp: &models.InAppChannel{
+					Enabled: true,
+					Badge:   true,
+				},
+			},
+			CorrelationID: event.CorrelationID,
+			SourceEvent:   event.Type,
+			CreatedBy:     eventData.ActorID,
+		}
+		if err := ec.notificationService.CreateNotification(ec.ctx, request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (ec *EventConsumer) handleSprintStartedEvent(event models.Event) error {
 	var eventData models.SprintStartedEventData
 	if err := json.Unmarshal(event.Data, &eventData); err != nil {
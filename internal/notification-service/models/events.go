@@ -51,6 +51,21 @@ type TaskCompletedEventData struct {
 	Duration      int64     `json:"duration"` // 任务持续时间(小时)
 }
 
+// TaskTransitionedEventData 任务工作流转换事件数据，RecipientIDs 为需要通知的用户
+type TaskTransitionedEventData struct {
+	TaskID       uuid.UUID   `json:"task_id"`
+	TaskKey      string      `json:"task_key"`
+	TaskTitle    string      `json:"task_title"`
+	ProjectID    uuid.UUID   `json:"project_id"`
+	Transition   string      `json:"transition"`
+	From         string      `json:"from"`
+	FromName     string      `json:"from_name"`
+	To           string      `json:"to"`
+	ToName       string      `json:"to_name"`
+	ActorID      uuid.UUID   `json:"actor_id"`
+	RecipientIDs []uuid.UUID `json:"recipient_ids"`
+}
+
 // SprintStartedEventData Sprint开始事件数据
 type SprintStartedEventData struct {
 	SprintID    uuid.UUID    `json:"sprint_id"`
@@ -230,6 +245,7 @@ const (
 	EventTypeTaskAssigned     = "project.task.assigned"
 	EventTypeTaskCompleted    = "project.task.completed"
 	EventTypeTaskOverdue      = "project.task.overdue"
+	EventTypeTaskTransitioned = "project.task.transitioned"
 	EventTypeSprintStarted    = "project.sprint.started"
 	EventTypeSprintCompleted  = "project.sprint.completed"
 	EventTypeMilestoneReached = "project.milestone.reached"
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// PlatformEventsTopic 平台事件主题，通知服务从该主题消费事件
+const PlatformEventsTopic = "platform-events"
+
+// EventPublisher 平台事件发布器
+type EventPublisher interface {
+	Publish(ctx context.Context, event *Event) error
+	Close() error
+}
+
+// Event 平台事件，结构与通知服务消费的事件一致
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	Source        string          `json:"source"`
+	Subject       string          `json:"subject"`
+	Time          time.Time       `json:"time"`
+	TenantID      uuid.UUID       `json:"tenant_id"`
+	UserID        *uuid.UUID      `json:"user_id,omitempty"`
+	ProjectID     *uuid.UUID      `json:"project_id,omitempty"`
+	Data          json.RawMessage `json:"data"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+}
+
+// kafkaEventPublisher 基于Kafka的事件发布器
+type kafkaEventPublisher struct {
+	writer *kafka.Writer
+	logger *zap.Logger
+}
+
+// NewKafkaEventPublisher 创建Kafka事件发布器
+func NewKafkaEventPublisher(brokers []string, topic string, logger *zap.Logger) EventPublisher {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &kafkaEventPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+		},
+		logger: logger,
+	}
+}
+
+// Publish 发布事件，同一主题（Subject）的事件写入同一分区以保持顺序
+func (p *kafkaEventPublisher) Publish(ctx context.Context, event *Event) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if err := p.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(event.Subject),
+		Value: value,
+	}); err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", event.Type, err)
+	}
+
+	p.logger.Debug("Event published",
+		zap.String("type", event.Type),
+		zap.String("subject", event.Subject),
+	)
+	return nil
+}
+
+// Close 关闭发布器
+func (p *kafkaEventPublisher) Close() error {
+	return p.writer.Close()
+}
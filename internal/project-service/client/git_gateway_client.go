@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,9 @@ type GitGatewayClient interface {
 	UpdateRepository(ctx context.Context, repositoryID uuid.UUID, req *UpdateRepositoryRequest) (*Repository, error)
 	DeleteRepository(ctx context.Context, repositoryID uuid.UUID) error
 	ListRepositories(ctx context.Context, projectID *uuid.UUID, page, pageSize int) (*RepositoryListResponse, error)
+	TransferRepository(ctx context.Context, repositoryID uuid.UUID, req *TransferRepositoryRequest) (*Repository, error)
+	ArchiveRepository(ctx context.Context, repositoryID uuid.UUID) (*Repository, error)
+	UnarchiveRepository(ctx context.Context, repositoryID uuid.UUID) (*Repository, error)
 
 	// 分支管理
 	CreateBranch(ctx context.Context, repositoryID uuid.UUID, req *CreateBranchRequest) (*Branch, error)
@@ -30,6 +34,10 @@ type GitGatewayClient interface {
 	SetDefaultBranch(ctx context.Context, repositoryID uuid.UUID, branchName string) error
 	MergeBranch(ctx context.Context, repositoryID uuid.UUID, targetBranch, sourceBranch string) error
 
+	// 合并请求
+	ListPullRequests(ctx context.Context, repositoryID uuid.UUID, status PullRequestStatus, page, pageSize int) (*PullRequestListResponse, error)
+	ListProjectPullRequests(ctx context.Context, projectID uuid.UUID, reference string, status PullRequestStatus, page, pageSize int) (*PullRequestListResponse, error)
+
 	// 提交管理
 	CreateCommit(ctx context.Context, repositoryID uuid.UUID, req *CreateCommitRequest) (*Commit, error)
 	GetCommit(ctx context.Context, repositoryID uuid.UUID, sha string) (*Commit, error)
@@ -280,6 +288,52 @@ func (c *gitGatewayClient) ListRepositories(ctx context.Context, projectID *uuid
 	return &result, nil
 }
 
+// TransferRepository 迁移仓库到另一个项目，旧地址由Git网关重定向
+func (c *gitGatewayClient) TransferRepository(ctx context.Context, repositoryID uuid.UUID, req *TransferRepositoryRequest) (*Repository, error) {
+	var result Repository
+	path := fmt.Sprintf("/api/v1/repositories/%s/transfer", repositoryID.String())
+	err := c.doRequest(ctx, "POST", path, req, &result)
+	if err != nil {
+		c.logger.Error("Failed to transfer repository",
+			zap.String("repository_id", repositoryID.String()),
+			zap.String("project_id", req.ProjectID.String()),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	c.logger.Info("Repository transferred successfully",
+		zap.String("repository_id", repositoryID.String()),
+		zap.String("project_id", req.ProjectID.String()),
+	)
+	return &result, nil
+}
+
+// ArchiveRepository 归档仓库
+func (c *gitGatewayClient) ArchiveRepository(ctx context.Context, repositoryID uuid.UUID) (*Repository, error) {
+	return c.setRepositoryArchived(ctx, repositoryID, "archive")
+}
+
+// UnarchiveRepository 取消归档
+func (c *gitGatewayClient) UnarchiveRepository(ctx context.Context, repositoryID uuid.UUID) (*Repository, error) {
+	return c.setRepositoryArchived(ctx, repositoryID, "unarchive")
+}
+
+// setRepositoryArchived 调用归档或取消归档接口
+func (c *gitGatewayClient) setRepositoryArchived(ctx context.Context, repositoryID uuid.UUID, action string) (*Repository, error) {
+	var result Repository
+	path := fmt.Sprintf("/api/v1/repositories/%s/%s", repositoryID.String(), action)
+	err := c.doRequest(ctx, "POST", path, nil, &result)
+	if err != nil {
+		c.logger.Error("Failed to "+action+" repository",
+			zap.String("repository_id", repositoryID.String()),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &result, nil
+}
+
 // 分支管理方法实现
 
 // CreateBranch 创建分支
@@ -412,6 +466,48 @@ func (c *gitGatewayClient) MergeBranch(ctx context.Context, repositoryID uuid.UU
 	return nil
 }
 
+// 合并请求方法实现
+
+// ListPullRequests 获取仓库的合并请求列表，status 为空时不过滤
+func (c *gitGatewayClient) ListPullRequests(ctx context.Context, repositoryID uuid.UUID, status PullRequestStatus, page, pageSize int) (*PullRequestListResponse, error) {
+	path := fmt.Sprintf("/api/v1/repositories/%s/pull-requests?page=%d&page_size=%d", repositoryID.String(), page, pageSize)
+	if status != "" {
+		path += "&status=" + string(status)
+	}
+
+	var result PullRequestListResponse
+	err := c.doRequest(ctx, "GET", path, nil, &result)
+	if err != nil {
+		c.logger.Error("Failed to list pull requests",
+			zap.String("repository_id", repositoryID.String()),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListProjectPullRequests 获取项目下所有仓库中标题或源分支引用了任务编号的合并请求，status 为空时不过滤
+func (c *gitGatewayClient) ListProjectPullRequests(ctx context.Context, projectID uuid.UUID, reference string, status PullRequestStatus, page, pageSize int) (*PullRequestListResponse, error) {
+	path := fmt.Sprintf("/api/v1/projects/%s/pull-requests?reference=%s&page=%d&page_size=%d",
+		projectID.String(), url.QueryEscape(reference), page, pageSize)
+	if status != "" {
+		path += "&status=" + string(status)
+	}
+
+	var result PullRequestListResponse
+	err := c.doRequest(ctx, "GET", path, nil, &result)
+	if err != nil {
+		c.logger.Error("Failed to list project pull requests",
+			zap.String("project_id", projectID.String()),
+			zap.String("reference", reference),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	return &result, nil
+}
+
 // 提交管理方法实现
 
 // CreateCommit 创建提交
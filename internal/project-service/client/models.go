@@ -118,16 +118,43 @@ type GitDiff struct {
 	Files        []DiffFile `json:"files"`
 	TotalAdded   int32      `json:"total_added"`
 	TotalDeleted int32      `json:"total_deleted"`
+	TotalFiles   int        `json:"total_files"`
+	Page         int        `json:"page"`
+	PageSize     int        `json:"page_size"`
+	HasMore      bool       `json:"has_more"`
 }
 
 // DiffFile 差异文件
 type DiffFile struct {
-	Path         string  `json:"path"`
-	OldPath      *string `json:"old_path"`
-	Status       string  `json:"status"`
-	AddedLines   int32   `json:"added_lines"`
-	DeletedLines int32   `json:"deleted_lines"`
-	Patch        string  `json:"patch"`
+	Path         string     `json:"path"`
+	OldPath      *string    `json:"old_path"`
+	Status       string     `json:"status"`
+	Similarity   int        `json:"similarity,omitempty"`
+	AddedLines   int32      `json:"added_lines"`
+	DeletedLines int32      `json:"deleted_lines"`
+	Binary       bool       `json:"binary"`
+	Collapsed    bool       `json:"collapsed"`
+	Truncated    bool       `json:"truncated"`
+	Hunks        []DiffHunk `json:"hunks,omitempty"`
+}
+
+// DiffHunk 差异块
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Section  string     `json:"section,omitempty"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// DiffLine 差异行
+type DiffLine struct {
+	Type      string `json:"type"`
+	OldLine   int    `json:"old_line,omitempty"`
+	NewLine   int    `json:"new_line,omitempty"`
+	Content   string `json:"content"`
+	NoNewline bool   `json:"no_newline,omitempty"`
 }
 
 // RepositoryStats 仓库统计信息
@@ -139,6 +166,21 @@ type RepositoryStats struct {
 	LastPushedAt *time.Time `json:"last_pushed_at"`
 }
 
+// PullRequest 合并请求模型
+type PullRequest struct {
+	ID           uuid.UUID         `json:"id"`
+	RepositoryID uuid.UUID         `json:"repository_id"`
+	Number       int               `json:"number"`
+	Title        string            `json:"title"`
+	Status       PullRequestStatus `json:"status"`
+	SourceBranch string            `json:"source_branch"`
+	TargetBranch string            `json:"target_branch"`
+	AuthorID     uuid.UUID         `json:"author_id"`
+	MergedAt     *time.Time        `json:"merged_at"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
 // 枚举类型
 
 // RepositoryStatus 仓库状态枚举
@@ -150,6 +192,16 @@ const (
 	RepositoryStatusDeleted  RepositoryStatus = "deleted"
 )
 
+// PullRequestStatus 合并请求状态枚举
+type PullRequestStatus string
+
+const (
+	PullRequestStatusOpen   PullRequestStatus = "open"
+	PullRequestStatusClosed PullRequestStatus = "closed"
+	PullRequestStatusMerged PullRequestStatus = "merged"
+	PullRequestStatusDraft  PullRequestStatus = "draft"
+)
+
 // RepositoryVisibility 仓库可见性枚举
 type RepositoryVisibility string
 
@@ -179,6 +231,12 @@ type UpdateRepositoryRequest struct {
 	DefaultBranch *string               `json:"default_branch,omitempty"`
 }
 
+// TransferRepositoryRequest 迁移仓库请求
+type TransferRepositoryRequest struct {
+	ProjectID uuid.UUID `json:"project_id"`
+	Name      *string   `json:"name,omitempty"` // 为空时沿用当前名称
+}
+
 // CreateBranchRequest 创建分支请求
 type CreateBranchRequest struct {
 	Name      string `json:"name"`
@@ -225,6 +283,14 @@ type RepositoryListResponse struct {
 	PageSize     int          `json:"page_size"`
 }
 
+// PullRequestListResponse 合并请求列表响应
+type PullRequestListResponse struct {
+	PullRequests []PullRequest `json:"pull_requests"`
+	Total        int64         `json:"total"`
+	Page         int           `json:"page"`
+	PageSize     int           `json:"page_size"`
+}
+
 // CommitListResponse 提交列表响应
 type CommitListResponse struct {
 	Commits  []Commit `json:"commits"`
@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
 	"github.com/cloud-platform/collaborative-dev/internal/project-service/service"
 	"github.com/cloud-platform/collaborative-dev/shared/response"
 	"github.com/gin-gonic/gin"
@@ -213,6 +216,10 @@ func (h *AgileHandler) CreateTask(c *gin.Context) {
 
 	task, err := h.agileService.CreateTask(c.Request.Context(), &req, userID, tenantID)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidCustomFieldValue) {
+			response.Error(c, http.StatusBadRequest, "Invalid custom field value", err)
+			return
+		}
 		h.logger.Error("创建任务失败", zap.Error(err))
 		response.Error(c, http.StatusInternalServerError, "Failed to create task", err)
 		return
@@ -258,7 +265,30 @@ func (h *AgileHandler) ListTasks(c *gin.Context) {
 		return
 	}
 
-	// 构建过滤器
+	filter, err := parseTaskFilter(c, projectID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid filter ID", err)
+		return
+	}
+
+	// 解析分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	userID := getUserIDFromContext(c)
+	tenantID := getTenantIDFromContext(c)
+
+	tasks, err := h.agileService.ListTasks(c.Request.Context(), filter, page, pageSize, userID, tenantID)
+	if err != nil {
+		h.respondFilterError(c, "Failed to list tasks", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tasks retrieved successfully", tasks)
+}
+
+// parseTaskFilter 从查询参数解析任务过滤条件，cf.<key>=value 形式的参数按自定义字段过滤
+func parseTaskFilter(c *gin.Context, projectID uuid.UUID) (*service.TaskFilter, error) {
 	filter := &service.TaskFilter{
 		ProjectID: projectID,
 	}
@@ -298,21 +328,29 @@ func (h *AgileHandler) ListTasks(c *gin.Context) {
 		filter.SearchText = &searchText
 	}
 
-	// 解析分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	// 任务查询语句和保存的过滤器
+	if query := c.Query("q"); query != "" {
+		filter.Query = &query
+	}
 
-	userID := getUserIDFromContext(c)
-	tenantID := getTenantIDFromContext(c)
+	if filterIDStr := c.Query("filter_id"); filterIDStr != "" {
+		filterID, err := uuid.Parse(filterIDStr)
+		if err != nil {
+			return nil, err
+		}
+		filter.FilterID = &filterID
+	}
 
-	tasks, err := h.agileService.ListTasks(c.Request.Context(), filter, page, pageSize, userID, tenantID)
-	if err != nil {
-		h.logger.Error("获取任务列表失败", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to list tasks", err)
-		return
+	for key, values := range c.Request.URL.Query() {
+		if field, ok := strings.CutPrefix(key, "cf."); ok && len(values) > 0 {
+			if filter.CustomFields == nil {
+				filter.CustomFields = make(map[string]string)
+			}
+			filter.CustomFields[field] = values[0]
+		}
 	}
 
-	response.Success(c, http.StatusOK, "Tasks retrieved successfully", tasks)
+	return filter, nil
 }
 
 // UpdateTask 更新任务
@@ -335,8 +373,7 @@ func (h *AgileHandler) UpdateTask(c *gin.Context) {
 
 	task, err := h.agileService.UpdateTask(c.Request.Context(), taskID, &req, userID, tenantID)
 	if err != nil {
-		h.logger.Error("更新任务失败", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to update task", err)
+		h.respondWorkflowError(c, "Failed to update task", err)
 		return
 	}
 
@@ -353,7 +390,7 @@ func (h *AgileHandler) TransitionTask(c *gin.Context) {
 	}
 
 	var req struct {
-		Status string `json:"status" binding:"required,oneof=todo in_progress in_review testing done cancelled"`
+		Status string `json:"status" binding:"required,min=1,max=50"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
@@ -364,8 +401,7 @@ func (h *AgileHandler) TransitionTask(c *gin.Context) {
 	tenantID := getTenantIDFromContext(c)
 
 	if err := h.agileService.TransitionTask(c.Request.Context(), taskID, req.Status, userID, tenantID); err != nil {
-		h.logger.Error("任务状态转换失败", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to transition task", err)
+		h.respondWorkflowError(c, "Failed to transition task", err)
 		return
 	}
 
@@ -669,12 +705,7 @@ func (h *AgileHandler) MoveTask(c *gin.Context) {
 
 	err := h.agileService.MoveTask(c.Request.Context(), &req, userID, tenantID)
 	if err != nil {
-		h.logger.Error("任务移动失败", zap.Error(err))
-		if err.Error() == "task not found" {
-			response.Error(c, http.StatusNotFound, "Task not found", err)
-			return
-		}
-		response.Error(c, http.StatusInternalServerError, "Failed to move task", err)
+		h.respondWorkflowError(c, "Failed to move task", err)
 		return
 	}
 
@@ -922,7 +953,7 @@ func (h *AgileHandler) UpdateTaskStatus(c *gin.Context) {
 	}
 
 	var req struct {
-		Status string `json:"status" binding:"required,oneof=todo in_progress in_review testing done cancelled"`
+		Status string `json:"status" binding:"required,min=1,max=50"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
@@ -933,8 +964,7 @@ func (h *AgileHandler) UpdateTaskStatus(c *gin.Context) {
 	tenantID := getTenantIDFromContext(c)
 
 	if err := h.agileService.UpdateTaskStatus(c.Request.Context(), taskID, req.Status, userID, tenantID); err != nil {
-		h.logger.Error("更新任务状态失败", zap.Error(err))
-		response.Error(c, http.StatusInternalServerError, "Failed to update task status", err)
+		h.respondWorkflowError(c, "Failed to update task status", err)
 		return
 	}
 
@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
 	"github.com/cloud-platform/collaborative-dev/internal/project-service/service"
 	"github.com/cloud-platform/collaborative-dev/shared/response"
 	"github.com/gin-gonic/gin"
@@ -120,6 +122,10 @@ func (h *BoardHandler) UpdateBoard(c *gin.Context) {
 
 	board, err := h.agileService.UpdateBoard(c.Request.Context(), id, &req, userID, tenantID)
 	if err != nil {
+		if errors.Is(err, models.ErrInvalidSavedFilter) {
+			response.Error(c, http.StatusBadRequest, "Invalid swimlane filters", err)
+			return
+		}
 		h.logger.Error("更新看板失败", zap.Error(err))
 		response.Error(c, http.StatusInternalServerError, "Failed to update board", err)
 		return
@@ -305,4 +311,34 @@ func (h *BoardHandler) GetBoardStatistics(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Board statistics retrieved successfully", stats)
 }
 
+// GetBoardSwimlanes 获取按泳道和列分组的看板任务
+func (h *BoardHandler) GetBoardSwimlanes(c *gin.Context) {
+	userID := getUserIDFromContext(c)
+	tenantID := getTenantIDFromContext(c)
+
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid board ID", err)
+		return
+	}
+
+	swimlanes, err := h.agileService.GetBoardSwimlanes(c.Request.Context(), id, userID, tenantID)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidTaskQuery) {
+			response.Error(c, http.StatusBadRequest, "Invalid swimlane query", err)
+			return
+		}
+		if err.Error() == "board not found" {
+			response.Error(c, http.StatusNotFound, "Board not found", err)
+			return
+		}
+		h.logger.Error("获取看板泳道失败", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to get board swimlanes", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Board swimlanes retrieved successfully", swimlanes)
+}
+
 // 注意：工具函数 getUserIDFromContext 和 getTenantIDFromContext 已在 agile_handler.go 中定义
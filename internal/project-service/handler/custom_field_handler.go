@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 任务自定义字段和导出接口
+
+// CreateCustomField 创建项目自定义字段
+func (h *AgileHandler) CreateCustomField(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	var req service.CreateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	req.ProjectID = projectID
+
+	field, err := h.agileService.CreateCustomField(c.Request.Context(), &req, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondCustomFieldError(c, "Failed to create custom field", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Custom field created successfully", field)
+}
+
+// ListCustomFields 获取项目自定义字段列表
+func (h *AgileHandler) ListCustomFields(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	fields, err := h.agileService.ListCustomFields(c.Request.Context(), projectID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondCustomFieldError(c, "Failed to list custom fields", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Custom fields retrieved successfully", fields)
+}
+
+// UpdateCustomField 更新项目自定义字段
+func (h *AgileHandler) UpdateCustomField(c *gin.Context) {
+	fieldID, err := uuid.Parse(c.Param("fieldId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid custom field ID", err)
+		return
+	}
+
+	var req service.UpdateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	field, err := h.agileService.UpdateCustomField(c.Request.Context(), fieldID, &req, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondCustomFieldError(c, "Failed to update custom field", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Custom field updated successfully", field)
+}
+
+// DeleteCustomField 删除项目自定义字段
+func (h *AgileHandler) DeleteCustomField(c *gin.Context) {
+	fieldID, err := uuid.Parse(c.Param("fieldId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid custom field ID", err)
+		return
+	}
+
+	if err := h.agileService.DeleteCustomField(c.Request.Context(), fieldID, getUserIDFromContext(c), getTenantIDFromContext(c)); err != nil {
+		h.respondCustomFieldError(c, "Failed to delete custom field", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Custom field deleted successfully", nil)
+}
+
+// ExportTasks 按任务列表的过滤参数导出CSV
+func (h *AgileHandler) ExportTasks(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	filter, err := parseTaskFilter(c, projectID)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid filter ID", err)
+		return
+	}
+
+	export, err := h.agileService.ExportTasks(c.Request.Context(), filter, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondFilterError(c, "Failed to export tasks", err)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName))
+	if export.Truncated {
+		c.Header("X-Export-Truncated", "true")
+	}
+	c.Status(http.StatusOK)
+
+	// UTF-8 BOM，便于电子表格软件正确识别中文
+	c.Writer.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(c.Writer)
+	if err := w.WriteAll(export.Rows); err != nil {
+		h.logger.Error("导出任务失败", zap.Error(err))
+	}
+}
+
+// respondCustomFieldError 将自定义字段错误映射为HTTP状态码
+func (h *AgileHandler) respondCustomFieldError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidCustomField):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrCustomFieldNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	case err.Error() == "no access to project":
+		response.Error(c, http.StatusForbidden, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
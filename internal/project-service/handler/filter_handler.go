@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 保存的过滤器接口
+
+// CreateSavedFilter 创建保存的过滤器
+func (h *AgileHandler) CreateSavedFilter(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	var req service.CreateSavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	req.ProjectID = projectID
+
+	filter, err := h.agileService.CreateSavedFilter(c.Request.Context(), &req, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondFilterError(c, "Failed to create filter", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Filter created successfully", filter)
+}
+
+// ListSavedFilters 获取项目中当前用户可见的过滤器
+func (h *AgileHandler) ListSavedFilters(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	filters, err := h.agileService.ListSavedFilters(c.Request.Context(), projectID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondFilterError(c, "Failed to list filters", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Filters retrieved successfully", filters)
+}
+
+// GetSavedFilter 获取过滤器详情
+func (h *AgileHandler) GetSavedFilter(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid filter ID", err)
+		return
+	}
+
+	filter, err := h.agileService.GetSavedFilter(c.Request.Context(), filterID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondFilterError(c, "Failed to get filter", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Filter retrieved successfully", filter)
+}
+
+// UpdateSavedFilter 更新过滤器，仅创建者可以修改
+func (h *AgileHandler) UpdateSavedFilter(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid filter ID", err)
+		return
+	}
+
+	var req service.UpdateSavedFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	filter, err := h.agileService.UpdateSavedFilter(c.Request.Context(), filterID, &req, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondFilterError(c, "Failed to update filter", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Filter updated successfully", filter)
+}
+
+// DeleteSavedFilter 删除过滤器，仅创建者可以删除
+func (h *AgileHandler) DeleteSavedFilter(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid filter ID", err)
+		return
+	}
+
+	if err := h.agileService.DeleteSavedFilter(c.Request.Context(), filterID, getUserIDFromContext(c), getTenantIDFromContext(c)); err != nil {
+		h.respondFilterError(c, "Failed to delete filter", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Filter deleted successfully", nil)
+}
+
+// RunSavedFilter 执行过滤器并分页返回匹配的任务
+func (h *AgileHandler) RunSavedFilter(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid filter ID", err)
+		return
+	}
+
+	userID := getUserIDFromContext(c)
+	tenantID := getTenantIDFromContext(c)
+
+	filter, err := h.agileService.GetSavedFilter(c.Request.Context(), filterID, userID, tenantID)
+	if err != nil {
+		h.respondFilterError(c, "Failed to run filter", err)
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	tasks, err := h.agileService.ListTasks(c.Request.Context(), &service.TaskFilter{
+		ProjectID: filter.ProjectID,
+		FilterID:  &filter.ID,
+	}, page, pageSize, userID, tenantID)
+	if err != nil {
+		h.respondFilterError(c, "Failed to run filter", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tasks retrieved successfully", tasks)
+}
+
+// GetSavedFilterStatistics 获取过滤器分组统计，作为仪表板组件的数据源
+func (h *AgileHandler) GetSavedFilterStatistics(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid filter ID", err)
+		return
+	}
+
+	stats, err := h.agileService.GetSavedFilterStatistics(c.Request.Context(), filterID, c.Query("group_by"), getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondFilterError(c, "Failed to get filter statistics", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Filter statistics retrieved successfully", stats)
+}
+
+// respondFilterError 将查询和过滤器错误映射为HTTP状态码
+func (h *AgileHandler) respondFilterError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidTaskQuery), errors.Is(err, models.ErrInvalidSavedFilter):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrSavedFilterReadOnly), err.Error() == "no access to project":
+		response.Error(c, http.StatusForbidden, message, err)
+	case errors.Is(err, models.ErrSavedFilterNotFound):
+		response.Error(c, http.StatusNotFound, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 任务变更历史、项目活动流和流动指标接口
+
+// GetTaskHistory 获取任务变更历史
+func (h *AgileHandler) GetTaskHistory(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("taskId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid task ID", err)
+		return
+	}
+
+	changes, err := h.agileService.GetTaskHistory(c.Request.Context(), taskID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondHistoryError(c, "Failed to get task history", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Task history retrieved successfully", changes)
+}
+
+// ListProjectActivity 分页获取项目活动流
+func (h *AgileHandler) ListProjectActivity(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	filter := &service.ActivityFilter{ProjectID: projectID}
+	if taskIDStr := c.Query("task_id"); taskIDStr != "" {
+		taskID, err := uuid.Parse(taskIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid task ID", err)
+			return
+		}
+		filter.TaskID = &taskID
+	}
+	if actorIDStr := c.Query("actor_id"); actorIDStr != "" {
+		actorID, err := uuid.Parse(actorIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid actor ID", err)
+			return
+		}
+		filter.ActorID = &actorID
+	}
+	if field := c.Query("field"); field != "" {
+		filter.Field = &field
+	}
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid since time, expected RFC3339", err)
+			return
+		}
+		filter.Since = &since
+	}
+	if untilStr := c.Query("until"); untilStr != "" {
+		until, err := time.Parse(time.RFC3339, untilStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid until time, expected RFC3339", err)
+			return
+		}
+		filter.Until = &until
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	activity, err := h.agileService.ListProjectActivity(c.Request.Context(), filter, page, pageSize, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondHistoryError(c, "Failed to list project activity", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Project activity retrieved successfully", activity)
+}
+
+// GetTaskFlowMetrics 获取项目的累积流图和周期时间，默认最近30天
+func (h *AgileHandler) GetTaskFlowMetrics(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	endDate := time.Now()
+	if endStr := c.Query("end_date"); endStr != "" {
+		if endDate, err = time.Parse("2006-01-02", endStr); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid end date, expected YYYY-MM-DD", err)
+			return
+		}
+	}
+	startDate := endDate.AddDate(0, 0, -29)
+	if startStr := c.Query("start_date"); startStr != "" {
+		if startDate, err = time.Parse("2006-01-02", startStr); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid start date, expected YYYY-MM-DD", err)
+			return
+		}
+	}
+
+	metrics, err := h.agileService.GetTaskFlowMetrics(c.Request.Context(), projectID, startDate, endDate, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondHistoryError(c, "Failed to get flow metrics", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Flow metrics retrieved successfully", metrics)
+}
+
+// respondHistoryError 将变更历史错误映射为HTTP状态码
+func (h *AgileHandler) respondHistoryError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidActivityQuery):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case err.Error() == "task not found":
+		response.Error(c, http.StatusNotFound, message, err)
+	case err.Error() == "no access to project":
+		response.Error(c, http.StatusForbidden, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
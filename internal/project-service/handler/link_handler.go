@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 任务链接和依赖图接口
+
+// CreateTaskLink 创建任务链接
+func (h *AgileHandler) CreateTaskLink(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("taskId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid task ID", err)
+		return
+	}
+
+	var req service.CreateTaskLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	link, err := h.agileService.CreateTaskLink(c.Request.Context(), taskID, &req, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondLinkError(c, "Failed to create task link", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Task link created successfully", link)
+}
+
+// ListTaskLinks 获取任务链接列表
+func (h *AgileHandler) ListTaskLinks(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("taskId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid task ID", err)
+		return
+	}
+
+	links, err := h.agileService.ListTaskLinks(c.Request.Context(), taskID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondLinkError(c, "Failed to list task links", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Task links retrieved successfully", links)
+}
+
+// DeleteTaskLink 删除任务链接
+func (h *AgileHandler) DeleteTaskLink(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("taskId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid task ID", err)
+		return
+	}
+	linkID, err := uuid.Parse(c.Param("linkId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid link ID", err)
+		return
+	}
+
+	if err := h.agileService.DeleteTaskLink(c.Request.Context(), taskID, linkID, getUserIDFromContext(c), getTenantIDFromContext(c)); err != nil {
+		h.respondLinkError(c, "Failed to delete task link", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Task link deleted successfully", nil)
+}
+
+// GetTaskDependencyGraph 获取任务的阻塞依赖图
+func (h *AgileHandler) GetTaskDependencyGraph(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("taskId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid task ID", err)
+		return
+	}
+
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", strconv.Itoa(service.DefaultDependencyDepth)))
+
+	graph, err := h.agileService.GetTaskDependencyGraph(c.Request.Context(), taskID, depth, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondLinkError(c, "Failed to get dependency graph", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Dependency graph retrieved successfully", graph)
+}
+
+// GetSprintDependencies 获取Sprint任务的阻塞链
+func (h *AgileHandler) GetSprintDependencies(c *gin.Context) {
+	sprintID, err := uuid.Parse(c.Param("sprintId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid sprint ID", err)
+		return
+	}
+
+	report, err := h.agileService.GetSprintDependencies(c.Request.Context(), sprintID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondLinkError(c, "Failed to get sprint dependencies", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Sprint dependencies retrieved successfully", report)
+}
+
+// respondLinkError 将任务链接错误映射为HTTP状态码
+func (h *AgileHandler) respondLinkError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidTaskLink):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrTaskLinkExists), errors.Is(err, models.ErrTaskLinkCycle):
+		response.Error(c, http.StatusConflict, message, err)
+	case errors.Is(err, models.ErrTaskLinkNotFound), err.Error() == "task not found", err.Error() == "sprint not found":
+		response.Error(c, http.StatusNotFound, message, err)
+	case err.Error() == "no access to project":
+		response.Error(c, http.StatusForbidden, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
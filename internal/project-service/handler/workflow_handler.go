@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 工作流管理接口
+
+// CreateWorkflow 创建项目工作流
+func (h *AgileHandler) CreateWorkflow(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	var req service.CreateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	req.ProjectID = projectID
+
+	workflow, err := h.agileService.CreateWorkflow(c.Request.Context(), &req, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondWorkflowError(c, "Failed to create workflow", err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Workflow created successfully", workflow)
+}
+
+// ListWorkflows 获取项目工作流列表
+func (h *AgileHandler) ListWorkflows(c *gin.Context) {
+	projectID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid project ID", err)
+		return
+	}
+
+	workflows, err := h.agileService.ListWorkflows(c.Request.Context(), projectID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondWorkflowError(c, "Failed to list workflows", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Workflows retrieved successfully", gin.H{
+		"workflows": workflows,
+		"default":   models.DefaultWorkflow(),
+	})
+}
+
+// GetWorkflow 获取工作流详情
+func (h *AgileHandler) GetWorkflow(c *gin.Context) {
+	workflowID, err := uuid.Parse(c.Param("workflowId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid workflow ID", err)
+		return
+	}
+
+	workflow, err := h.agileService.GetWorkflow(c.Request.Context(), workflowID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondWorkflowError(c, "Failed to get workflow", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Workflow retrieved successfully", workflow)
+}
+
+// UpdateWorkflow 更新工作流
+func (h *AgileHandler) UpdateWorkflow(c *gin.Context) {
+	workflowID, err := uuid.Parse(c.Param("workflowId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid workflow ID", err)
+		return
+	}
+
+	var req service.UpdateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	workflow, err := h.agileService.UpdateWorkflow(c.Request.Context(), workflowID, &req, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondWorkflowError(c, "Failed to update workflow", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Workflow updated successfully", workflow)
+}
+
+// DeleteWorkflow 删除工作流，相关任务类型回退到项目默认工作流
+func (h *AgileHandler) DeleteWorkflow(c *gin.Context) {
+	workflowID, err := uuid.Parse(c.Param("workflowId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid workflow ID", err)
+		return
+	}
+
+	if err := h.agileService.DeleteWorkflow(c.Request.Context(), workflowID, getUserIDFromContext(c), getTenantIDFromContext(c)); err != nil {
+		h.respondWorkflowError(c, "Failed to delete workflow", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Workflow deleted successfully", nil)
+}
+
+// GetTaskTransitions 获取任务可用的状态转换
+func (h *AgileHandler) GetTaskTransitions(c *gin.Context) {
+	taskID, err := uuid.Parse(c.Param("taskId"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid task ID", err)
+		return
+	}
+
+	transitions, err := h.agileService.GetTaskTransitions(c.Request.Context(), taskID, getUserIDFromContext(c), getTenantIDFromContext(c))
+	if err != nil {
+		h.respondWorkflowError(c, "Failed to get task transitions", err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Task transitions retrieved successfully", transitions)
+}
+
+// respondWorkflowError 将工作流和状态转换错误映射为HTTP状态码
+func (h *AgileHandler) respondWorkflowError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidWorkflow), errors.Is(err, models.ErrTransitionNotAllowed), errors.Is(err, models.ErrInvalidCustomFieldValue):
+		response.Error(c, http.StatusBadRequest, message, err)
+	case errors.Is(err, models.ErrTransitionConditionFailed):
+		response.Error(c, http.StatusConflict, message, err)
+	case errors.Is(err, models.ErrWorkflowNotFound), err.Error() == "task not found":
+		response.Error(c, http.StatusNotFound, message, err)
+	case errors.Is(err, models.ErrWorkflowManageDenied), err.Error() == "no access to project":
+		response.Error(c, http.StatusForbidden, message, err)
+	default:
+		h.logger.Error(message, zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, message, err)
+	}
+}
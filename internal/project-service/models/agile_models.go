@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -71,6 +73,13 @@ type AgileTask struct {
 	// 业务字段
 	AcceptanceCriteria []AcceptanceCriteria `json:"acceptance_criteria" gorm:"type:jsonb"`
 
+	// 自定义字段取值，键为项目自定义字段的 Key
+	CustomFields map[string]interface{} `json:"custom_fields" gorm:"type:jsonb;serializer:json"`
+
+	// 解决结果，由工作流转换的后置动作设置
+	Resolution *string    `json:"resolution" gorm:"size:50"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+
 	// 审计字段
 	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
@@ -187,6 +196,9 @@ type Board struct {
 	Description *string   `json:"description" gorm:"type:text"`
 	Type        string    `json:"type" gorm:"size:50;not null;default:'kanban'"` // kanban, scrum
 
+	// 泳道，按顺序引用保存的过滤器，任务归入第一个匹配的泳道
+	SwimlaneFilterIDs []uuid.UUID `json:"swimlane_filter_ids" gorm:"type:jsonb;serializer:json"`
+
 	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
 	DeletedAt *time.Time `json:"deleted_at" gorm:"index"`
@@ -420,27 +432,22 @@ func (at *AgileTask) IsSubTask() bool {
 	return at.Type == TaskTypeSubTask || at.HasParent()
 }
 
-// CanTransitionTo 检查是否可以转换到指定状态
+// CanTransitionTo 检查内置工作流是否允许转换到指定状态，项目配置的工作流由服务层校验
 func (at *AgileTask) CanTransitionTo(newStatus string) bool {
-	// 简化的状态转换逻辑，实际应该根据工作流配置
-	validTransitions := map[string][]string{
-		TaskStatusTodo:       {TaskStatusInProgress, TaskStatusCancelled},
-		TaskStatusInProgress: {TaskStatusTodo, TaskStatusInReview, TaskStatusTesting, TaskStatusDone, TaskStatusCancelled},
-		TaskStatusInReview:   {TaskStatusInProgress, TaskStatusTesting, TaskStatusDone},
-		TaskStatusTesting:    {TaskStatusInProgress, TaskStatusDone, TaskStatusInReview},
-		TaskStatusDone:       {TaskStatusInProgress}, // 允许重新打开
-		TaskStatusCancelled:  {TaskStatusTodo},
-	}
-
-	validTargets, exists := validTransitions[at.Status]
-	if !exists {
-		return false
-	}
+	return DefaultWorkflow().FindTransition(at.Status, newStatus) != nil
+}
 
-	for _, valid := range validTargets {
-		if valid == newStatus {
-			return true
+// AcceptanceCriteriaCompleted 检查验收标准是否全部完成，没有验收标准时视为完成
+func (at *AgileTask) AcceptanceCriteriaCompleted() bool {
+	for _, criteria := range at.AcceptanceCriteria {
+		if !criteria.Completed {
+			return false
 		}
 	}
-	return false
+	return true
+}
+
+// TaskKey 任务编号，如 PROJ-123
+func TaskKey(projectKey string, taskNumber int64) string {
+	return fmt.Sprintf("%s-%d", strings.ToUpper(projectKey), taskNumber)
 }
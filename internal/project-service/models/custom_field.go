@@ -0,0 +1,385 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// 自定义字段错误
+var (
+	ErrInvalidCustomField      = errors.New("invalid custom field")
+	ErrCustomFieldNotFound     = errors.New("custom field not found")
+	ErrInvalidCustomFieldValue = errors.New("invalid custom field value")
+)
+
+// 自定义字段类型
+const (
+	CustomFieldText        = "text"
+	CustomFieldNumber      = "number"
+	CustomFieldDate        = "date"
+	CustomFieldSelect      = "select"
+	CustomFieldMultiSelect = "multi_select"
+	CustomFieldUser        = "user"
+	CustomFieldURL         = "url"
+)
+
+// 自定义字段限制
+const (
+	MaxCustomFieldsPerProject = 50
+	MaxCustomFieldOptions     = 100
+	maxCustomFieldTextLength  = 2000
+	maxCustomFieldNameLength  = 100
+)
+
+// CustomFieldDateLayout 日期字段的存储格式
+const CustomFieldDateLayout = "2006-01-02"
+
+// customFieldKeyPattern 字段键会内联到查询语言生成的SQL中，只允许小写字母、数字和下划线
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+var customFieldTypes = []string{
+	CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldSelect,
+	CustomFieldMultiSelect, CustomFieldUser, CustomFieldURL,
+}
+
+var taskTypes = []string{TaskTypeStory, TaskTypeTask, TaskTypeBug, TaskTypeEpic, TaskTypeSubTask}
+
+// CustomField 项目级的任务自定义字段定义，取值保存在 AgileTask.CustomFields 中，以 Key 为键
+type CustomField struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID   uuid.UUID `json:"project_id" gorm:"type:uuid;not null;index"`
+	Key         string    `json:"key" gorm:"size:50;not null"` // 创建后不可修改
+	Name        string    `json:"name" gorm:"size:100;not null"`
+	Description *string   `json:"description" gorm:"type:text"`
+	Type        string    `json:"type" gorm:"size:20;not null"` // 创建后不可修改
+
+	// 单选和多选字段的可选项
+	Options []string `json:"options" gorm:"type:jsonb;serializer:json"`
+
+	// 适用的任务类型，为空表示全部类型
+	TaskTypes []string `json:"task_types" gorm:"type:jsonb;serializer:json"`
+
+	// 必填的任务类型，必须是适用类型的子集
+	RequiredTypes []string `json:"required_types" gorm:"type:jsonb;serializer:json"`
+
+	// 创建任务时的默认值
+	DefaultValue interface{} `json:"default_value" gorm:"type:jsonb;serializer:json"`
+
+	ShowOnCard bool `json:"show_on_card" gorm:"not null;default:false"` // 在看板卡片上显示
+	Position   int  `json:"position" gorm:"not null;default:0"`
+
+	CreatedBy *uuid.UUID `json:"created_by" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt *time.Time `json:"deleted_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (CustomField) TableName() string {
+	return "task_custom_fields"
+}
+
+// Validate 校验字段定义，并规范化可选项、任务类型和默认值
+func (f *CustomField) Validate() error {
+	if !customFieldKeyPattern.MatchString(f.Key) {
+		return fmt.Errorf("%w: key must start with a lowercase letter and contain only lowercase letters, digits and underscores (max 50)", ErrInvalidCustomField)
+	}
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" || utf8.RuneCountInString(f.Name) > maxCustomFieldNameLength {
+		return fmt.Errorf("%w: name is required and must be at most %d characters", ErrInvalidCustomField, maxCustomFieldNameLength)
+	}
+	if !containsString(customFieldTypes, f.Type) {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidCustomField, f.Type)
+	}
+
+	if f.hasOptions() {
+		options, err := normalizeCustomFieldOptions(f.Options)
+		if err != nil {
+			return err
+		}
+		f.Options = options
+	} else {
+		f.Options = nil
+	}
+
+	taskTypes, err := normalizeCustomFieldTaskTypes(f.TaskTypes)
+	if err != nil {
+		return err
+	}
+	requiredTypes, err := normalizeCustomFieldTaskTypes(f.RequiredTypes)
+	if err != nil {
+		return err
+	}
+	for _, t := range requiredTypes {
+		if len(taskTypes) > 0 && !containsString(taskTypes, t) {
+			return fmt.Errorf("%w: field cannot be required for %q tasks it does not apply to", ErrInvalidCustomField, t)
+		}
+	}
+	f.TaskTypes, f.RequiredTypes = taskTypes, requiredTypes
+
+	if f.DefaultValue != nil {
+		value, err := f.NormalizeValue(f.DefaultValue)
+		if err != nil {
+			return fmt.Errorf("%w: default value: %v", ErrInvalidCustomField, err)
+		}
+		f.DefaultValue = value
+	}
+	return nil
+}
+
+// AppliesTo 判断字段是否适用于任务类型
+func (f *CustomField) AppliesTo(taskType string) bool {
+	return len(f.TaskTypes) == 0 || containsString(f.TaskTypes, taskType)
+}
+
+// RequiredFor 判断字段对任务类型是否必填
+func (f *CustomField) RequiredFor(taskType string) bool {
+	return f.AppliesTo(taskType) && containsString(f.RequiredTypes, taskType)
+}
+
+func (f *CustomField) hasOptions() bool {
+	return f.Type == CustomFieldSelect || f.Type == CustomFieldMultiSelect
+}
+
+// NormalizeValue 按字段类型校验取值并转换为存储形式。
+// 空字符串和空数组视为未设置，返回 nil
+func (f *CustomField) NormalizeValue(value interface{}) (interface{}, error) {
+	invalid := func(expected string) error {
+		return fmt.Errorf("%w: field %q expects %s", ErrInvalidCustomFieldValue, f.Key, expected)
+	}
+
+	if value == nil {
+		return nil, nil
+	}
+
+	switch f.Type {
+	case CustomFieldNumber:
+		n, ok := customFieldNumber(value)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, invalid("a number")
+		}
+		return n, nil
+
+	case CustomFieldMultiSelect:
+		values, ok := customFieldStrings(value)
+		if !ok {
+			return nil, invalid("a list of options")
+		}
+		var selected []string
+		for _, v := range values {
+			option, ok := f.matchOption(v)
+			if !ok {
+				return nil, invalid("options from: " + strings.Join(f.Options, ", "))
+			}
+			if !containsString(selected, option) {
+				selected = append(selected, option)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, nil
+		}
+		return selected, nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, invalid("a string")
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+
+	switch f.Type {
+	case CustomFieldText:
+		if utf8.RuneCountInString(s) > maxCustomFieldTextLength {
+			return nil, invalid(fmt.Sprintf("at most %d characters", maxCustomFieldTextLength))
+		}
+		return s, nil
+
+	case CustomFieldURL:
+		u, err := url.Parse(s)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(s) > maxCustomFieldTextLength {
+			return nil, invalid("an http or https URL")
+		}
+		return s, nil
+
+	case CustomFieldDate:
+		d, err := time.Parse(CustomFieldDateLayout, s)
+		if err != nil {
+			return nil, invalid("a date in YYYY-MM-DD format")
+		}
+		return d.Format(CustomFieldDateLayout), nil
+
+	case CustomFieldSelect:
+		option, ok := f.matchOption(s)
+		if !ok {
+			return nil, invalid("one of: " + strings.Join(f.Options, ", "))
+		}
+		return option, nil
+
+	case CustomFieldUser:
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, invalid("a user ID")
+		}
+		return id.String(), nil
+	}
+
+	return nil, invalid("a supported value")
+}
+
+// matchOption 不区分大小写匹配可选项，返回定义中的原始写法
+func (f *CustomField) matchOption(s string) (string, bool) {
+	for _, option := range f.Options {
+		if strings.EqualFold(option, strings.TrimSpace(s)) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+// ResolveCustomFieldValues 合并并校验任务的自定义字段取值。
+// current 为任务现有的取值，changes 中值为 null 表示清除该字段。
+// 创建任务时为缺失的字段填充默认值，并检查所有必填字段；
+// 更新时只检查本次修改的字段，避免新增必填字段后历史任务无法编辑
+func ResolveCustomFieldValues(fields []CustomField, taskType string, current, changes map[string]interface{}, creating bool) (map[string]interface{}, error) {
+	byKey := make(map[string]*CustomField, len(fields))
+	for i := range fields {
+		byKey[fields[i].Key] = &fields[i]
+	}
+
+	result := make(map[string]interface{}, len(current)+len(changes))
+	for k, v := range current {
+		result[k] = v
+	}
+
+	for key, value := range changes {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidCustomFieldValue, key)
+		}
+		if !field.AppliesTo(taskType) {
+			return nil, fmt.Errorf("%w: field %q does not apply to %s tasks", ErrInvalidCustomFieldValue, key, taskType)
+		}
+		normalized, err := field.NormalizeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		if normalized == nil {
+			delete(result, key)
+		} else {
+			result[key] = normalized
+		}
+	}
+
+	for i := range fields {
+		field := &fields[i]
+		if _, set := result[field.Key]; set || !field.AppliesTo(taskType) {
+			continue
+		}
+		if creating && field.DefaultValue != nil {
+			result[field.Key] = field.DefaultValue
+			continue
+		}
+		if _, changed := changes[field.Key]; field.RequiredFor(taskType) && (creating || changed) {
+			return nil, fmt.Errorf("%w: field %q is required for %s tasks", ErrInvalidCustomFieldValue, field.Key, taskType)
+		}
+	}
+	return result, nil
+}
+
+// CustomFieldDisplay 将存储的取值格式化为文本，用于导出
+func CustomFieldDisplay(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%g", v)
+	case []interface{}, []string:
+		values, _ := customFieldStrings(v)
+		return strings.Join(values, "; ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func normalizeCustomFieldOptions(options []string) ([]string, error) {
+	var result []string
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > maxCustomFieldNameLength {
+			return nil, fmt.Errorf("%w: options must be non-empty and at most %d characters", ErrInvalidCustomField, maxCustomFieldNameLength)
+		}
+		for _, existing := range result {
+			if strings.EqualFold(existing, option) {
+				return nil, fmt.Errorf("%w: duplicate option %q", ErrInvalidCustomField, option)
+			}
+		}
+		result = append(result, option)
+	}
+	if len(result) == 0 || len(result) > MaxCustomFieldOptions {
+		return nil, fmt.Errorf("%w: select fields need between 1 and %d options", ErrInvalidCustomField, MaxCustomFieldOptions)
+	}
+	return result, nil
+}
+
+func normalizeCustomFieldTaskTypes(types []string) ([]string, error) {
+	var result []string
+	for _, t := range types {
+		if !containsString(taskTypes, t) {
+			return nil, fmt.Errorf("%w: unknown task type %q", ErrInvalidCustomField, t)
+		}
+		if !containsString(result, t) {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// customFieldNumber 兼容JSON解码得到的 float64 和Go调用方传入的整数
+func customFieldNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func customFieldStrings(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	}
+	return nil, false
+}
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCustomFields() []CustomField {
+	return []CustomField{
+		{Key: "customer", Name: "Customer", Type: CustomFieldText},
+		{Key: "severity", Name: "Severity", Type: CustomFieldSelect, Options: []string{"S1", "S2", "S3"}, TaskTypes: []string{TaskTypeBug}, RequiredTypes: []string{TaskTypeBug}},
+		{Key: "environment", Name: "Environment", Type: CustomFieldMultiSelect, Options: []string{"staging", "production"}, DefaultValue: []string{"production"}},
+		{Key: "due_date", Name: "Due date", Type: CustomFieldDate},
+		{Key: "cost", Name: "Cost", Type: CustomFieldNumber},
+		{Key: "owner", Name: "Owner", Type: CustomFieldUser},
+		{Key: "ticket", Name: "Ticket", Type: CustomFieldURL},
+	}
+}
+
+func TestCustomFieldValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   CustomField
+		wantErr bool
+	}{
+		{"合法的单选字段", CustomField{Key: "severity", Name: " Severity ", Type: CustomFieldSelect, Options: []string{"S1", " S2 "}, DefaultValue: "s2"}, false},
+		{"键包含大写", CustomField{Key: "Customer", Name: "Customer", Type: CustomFieldText}, true},
+		{"键包含引号", CustomField{Key: "a'b", Name: "x", Type: CustomFieldText}, true},
+		{"未知类型", CustomField{Key: "a", Name: "x", Type: "color"}, true},
+		{"单选缺少可选项", CustomField{Key: "a", Name: "x", Type: CustomFieldSelect}, true},
+		{"可选项重复", CustomField{Key: "a", Name: "x", Type: CustomFieldSelect, Options: []string{"A", "a"}}, true},
+		{"必填类型不在适用类型中", CustomField{Key: "a", Name: "x", Type: CustomFieldText, TaskTypes: []string{TaskTypeBug}, RequiredTypes: []string{TaskTypeStory}}, true},
+		{"默认值类型不符", CustomField{Key: "a", Name: "x", Type: CustomFieldNumber, DefaultValue: "ten"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.field.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCustomField)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Severity", tt.field.Name)
+			assert.Equal(t, []string{"S1", "S2"}, tt.field.Options)
+			assert.Equal(t, "S2", tt.field.DefaultValue)
+		})
+	}
+}
+
+func TestCustomFieldNormalizeValue(t *testing.T) {
+	fields := map[string]CustomField{}
+	for _, f := range testCustomFields() {
+		fields[f.Key] = f
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"文本去除空白", "customer", "  ACME ", "ACME", false},
+		{"空文本视为未设置", "customer", " ", nil, false},
+		{"数字", "cost", 12.5, 12.5, false},
+		{"数字类型不符", "cost", "12", nil, true},
+		{"日期", "due_date", "2024-05-01", "2024-05-01", false},
+		{"日期格式错误", "due_date", "05/01/2024", nil, true},
+		{"单选匹配原始写法", "severity", "s1", "S1", false},
+		{"单选不在可选项中", "severity", "S9", nil, true},
+		{"多选去重", "environment", []interface{}{"staging", "STAGING"}, []string{"staging"}, false},
+		{"多选包含非字符串", "environment", []interface{}{1}, nil, true},
+		{"用户ID", "owner", "AAAAAAAA-0000-0000-0000-000000000000", "aaaaaaaa-0000-0000-0000-000000000000", false},
+		{"链接必须是http", "ticket", "javascript:alert(1)", nil, true},
+		{"链接", "ticket", "https://support.example.com/t/1", "https://support.example.com/t/1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := fields[tt.key]
+			got, err := field.NormalizeValue(tt.value)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCustomFieldValue)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveCustomFieldValues(t *testing.T) {
+	fields := testCustomFields()
+
+	// 创建时填充默认值并检查必填
+	values, err := ResolveCustomFieldValues(fields, TaskTypeBug, nil, map[string]interface{}{"severity": "s2"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"severity": "S2", "environment": []string{"production"}}, values)
+
+	_, err = ResolveCustomFieldValues(fields, TaskTypeBug, nil, nil, true)
+	assert.ErrorIs(t, err, ErrInvalidCustomFieldValue)
+
+	// 不适用的任务类型和未知字段
+	_, err = ResolveCustomFieldValues(fields, TaskTypeStory, nil, map[string]interface{}{"severity": "S1"}, true)
+	assert.ErrorIs(t, err, ErrInvalidCustomFieldValue)
+	_, err = ResolveCustomFieldValues(fields, TaskTypeStory, nil, map[string]interface{}{"colour": "red"}, true)
+	assert.ErrorIs(t, err, ErrInvalidCustomFieldValue)
+
+	// 更新时 null 清除字段，不能清除必填字段，未修改的必填字段不检查
+	current := map[string]interface{}{"customer": "ACME", "severity": "S1"}
+	values, err = ResolveCustomFieldValues(fields, TaskTypeBug, current, map[string]interface{}{"customer": nil}, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"severity": "S1"}, values)
+	assert.Equal(t, "ACME", current["customer"])
+
+	_, err = ResolveCustomFieldValues(fields, TaskTypeBug, current, map[string]interface{}{"severity": nil}, false)
+	assert.ErrorIs(t, err, ErrInvalidCustomFieldValue)
+
+	values, err = ResolveCustomFieldValues(fields, TaskTypeBug, nil, map[string]interface{}{"cost": 3}, false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"cost": 3.0}, values)
+}
+
+func TestCompileTaskQueryCustomFields(t *testing.T) {
+	qc := testQueryContext()
+	qc.CustomFields = testCustomFields()
+
+	tests := []struct {
+		name  string
+		query string
+		where string
+		args  []interface{}
+		order string
+	}{
+		{
+			name:  "单选字段使用定义中的写法",
+			query: "cf.severity = s1",
+			where: "(agile_tasks.custom_fields->>'severity') = ?",
+			args:  []interface{}{"S1"},
+		},
+		{
+			name:  "多选字段",
+			query: "cf.environment = production",
+			where: "(agile_tasks.custom_fields->'environment') @> ?::jsonb",
+			args:  []interface{}{`["production"]`},
+		},
+		{
+			name:  "数字和日期字段",
+			query: "cf.cost > 100 AND cf.due_date < 2024-06-01 ORDER BY cf.due_date",
+			where: "((agile_tasks.custom_fields->>'cost')::numeric > ? AND (agile_tasks.custom_fields->>'due_date')::date < ?)",
+			args:  []interface{}{100.0, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+			order: "(agile_tasks.custom_fields->>'due_date')::date ASC",
+		},
+		{
+			name:  "用户字段和为空判断",
+			query: "cf.owner = currentUser() OR cf.customer IS EMPTY",
+			where: "((agile_tasks.custom_fields->>'owner')::uuid = ? OR ((agile_tasks.custom_fields->>'customer') IS NULL OR (agile_tasks.custom_fields->>'customer') = ''))",
+			args:  []interface{}{qc.UserID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := CompileTaskQuery(tt.query, qc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, compiled.Where)
+			assert.Equal(t, tt.order, compiled.OrderBy)
+			assert.Equal(t, tt.args, compiled.Args)
+			assert.Equal(t, strings.Count(compiled.Where, "?"), len(compiled.Args))
+		})
+	}
+
+	for _, query := range []string{"cf.unknown = x", "cf.severity = S9", "cf.cost = abc"} {
+		_, err := CompileTaskQuery(query, qc)
+		assert.ErrorIs(t, err, ErrInvalidTaskQuery, query)
+	}
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 保存的过滤器错误
+var (
+	ErrInvalidSavedFilter  = errors.New("invalid saved filter")
+	ErrSavedFilterNotFound = errors.New("saved filter not found")
+	ErrSavedFilterReadOnly = errors.New("only the filter owner can modify it")
+)
+
+// 过滤器可见性
+const (
+	FilterVisibilityPrivate = "private" // 仅创建者
+	FilterVisibilityProject = "project" // 项目全部成员
+	FilterVisibilityUsers   = "users"   // 创建者指定的成员
+)
+
+// MaxFilterSharedMembers 指定成员共享的最大成员数
+const MaxFilterSharedMembers = 200
+
+// SavedFilter 保存的任务查询，可作为看板泳道和仪表板组件的数据源
+type SavedFilter struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID   uuid.UUID `json:"project_id" gorm:"type:uuid;not null;index"`
+	OwnerID     uuid.UUID `json:"owner_id" gorm:"type:uuid;not null;index"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Description *string   `json:"description" gorm:"type:text"`
+	Query       string    `json:"query" gorm:"type:text;not null"`
+	Visibility  string    `json:"visibility" gorm:"size:20;not null;default:'private'"`
+
+	// 指定成员可见时共享的成员
+	SharedWith []uuid.UUID `json:"shared_with" gorm:"type:jsonb;serializer:json"`
+
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt *time.Time `json:"deleted_at" gorm:"index"`
+}
+
+// TableName 指定表名
+func (SavedFilter) TableName() string {
+	return "saved_task_filters"
+}
+
+// Validate 校验过滤器配置，查询语法由调用方编译校验
+func (f *SavedFilter) Validate() error {
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSavedFilter)
+	}
+	if strings.TrimSpace(f.Query) == "" {
+		return fmt.Errorf("%w: query is required", ErrInvalidSavedFilter)
+	}
+
+	switch f.Visibility {
+	case "":
+		f.Visibility = FilterVisibilityPrivate
+	case FilterVisibilityPrivate, FilterVisibilityProject, FilterVisibilityUsers:
+	default:
+		return fmt.Errorf("%w: unknown visibility %q", ErrInvalidSavedFilter, f.Visibility)
+	}
+
+	if f.Visibility != FilterVisibilityUsers {
+		f.SharedWith = nil
+		return nil
+	}
+	if len(f.SharedWith) == 0 {
+		return fmt.Errorf("%w: users filters must be shared with at least one member", ErrInvalidSavedFilter)
+	}
+	if len(f.SharedWith) > MaxFilterSharedMembers {
+		return fmt.Errorf("%w: cannot share with more than %d members", ErrInvalidSavedFilter, MaxFilterSharedMembers)
+	}
+
+	// 去重并移除创建者本人
+	seen := map[uuid.UUID]bool{f.OwnerID: true}
+	members := make([]uuid.UUID, 0, len(f.SharedWith))
+	for _, id := range f.SharedWith {
+		if id == uuid.Nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		members = append(members, id)
+	}
+	f.SharedWith = members
+	return nil
+}
+
+// VisibleTo 判断用户能否查看和使用过滤器，项目访问权限由调用方检查
+func (f *SavedFilter) VisibleTo(userID uuid.UUID) bool {
+	switch f.Visibility {
+	case FilterVisibilityProject:
+		return true
+	case FilterVisibilityUsers:
+		if f.OwnerID == userID {
+			return true
+		}
+		for _, id := range f.SharedWith {
+			if id == userID {
+				return true
+			}
+		}
+		return false
+	default:
+		return f.OwnerID == userID
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSavedFilterValidate(t *testing.T) {
+	owner, member := uuid.New(), uuid.New()
+
+	f := &SavedFilter{OwnerID: owner, Name: " Mine ", Query: "assignee = currentUser()", SharedWith: []uuid.UUID{member}}
+	require.NoError(t, f.Validate())
+	assert.Equal(t, "Mine", f.Name)
+	assert.Equal(t, FilterVisibilityPrivate, f.Visibility)
+	assert.Nil(t, f.SharedWith)
+
+	// 共享给指定成员时去重并移除创建者
+	f = &SavedFilter{OwnerID: owner, Name: "Shared", Query: "status = todo", Visibility: FilterVisibilityUsers,
+		SharedWith: []uuid.UUID{member, owner, member, uuid.Nil}}
+	require.NoError(t, f.Validate())
+	assert.Equal(t, []uuid.UUID{member}, f.SharedWith)
+
+	tests := []struct {
+		name   string
+		filter SavedFilter
+	}{
+		{"缺少名称", SavedFilter{Query: "status = todo"}},
+		{"缺少查询", SavedFilter{Name: "x", Query: " "}},
+		{"未知可见性", SavedFilter{Name: "x", Query: "status = todo", Visibility: "public"}},
+		{"指定成员共享缺少成员", SavedFilter{Name: "x", Query: "status = todo", Visibility: FilterVisibilityUsers}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.filter.Validate(), ErrInvalidSavedFilter)
+		})
+	}
+}
+
+func TestSavedFilterVisibleTo(t *testing.T) {
+	owner, member, other := uuid.New(), uuid.New(), uuid.New()
+
+	private := SavedFilter{OwnerID: owner, Visibility: FilterVisibilityPrivate}
+	project := SavedFilter{OwnerID: owner, Visibility: FilterVisibilityProject}
+	users := SavedFilter{OwnerID: owner, Visibility: FilterVisibilityUsers, SharedWith: []uuid.UUID{member}}
+
+	assert.True(t, private.VisibleTo(owner))
+	assert.False(t, private.VisibleTo(member))
+	assert.True(t, project.VisibleTo(other))
+	assert.True(t, users.VisibleTo(owner))
+	assert.True(t, users.VisibleTo(member))
+	assert.False(t, users.VisibleTo(other))
+}
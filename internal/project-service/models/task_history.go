@@ -0,0 +1,309 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidActivityQuery 活动流或流动指标的查询参数错误
+var ErrInvalidActivityQuery = errors.New("invalid activity query")
+
+// 任务变更动作
+const (
+	TaskChangeCreated = "created"
+	TaskChangeUpdated = "updated"
+	TaskChangeDeleted = "deleted"
+)
+
+// TaskChange 任务的字段级变更记录。
+// 同一次操作产生的多条记录共享 ChangeSetID；创建任务时记录各字段从空到初始值的变更，
+// 删除任务时记录一条 Field 为空的记录
+type TaskChange struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TaskID      uuid.UUID `json:"task_id" gorm:"type:uuid;not null;index"`
+	ProjectID   uuid.UUID `json:"project_id" gorm:"type:uuid;not null;index"`
+	ActorID     uuid.UUID `json:"actor_id" gorm:"type:uuid;not null"`
+	ChangeSetID uuid.UUID `json:"change_set_id" gorm:"type:uuid;not null"`
+	Action      string    `json:"action" gorm:"size:20;not null"`
+	Field       string    `json:"field" gorm:"size:100"` // 字段名，自定义字段为 cf.<key>
+	OldValue    *string   `json:"old_value" gorm:"type:text"`
+	NewValue    *string   `json:"new_value" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	Actor *User `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+}
+
+// TableName 指定表名
+func (TaskChange) TableName() string {
+	return "task_changes"
+}
+
+// taskHistoryFields 记录历史的任务字段。排序权重、已记录工时和审计字段不记录
+var taskHistoryFields = []struct {
+	name  string
+	value func(*AgileTask) interface{}
+}{
+	{"title", func(t *AgileTask) interface{} { return t.Title }},
+	{"description", func(t *AgileTask) interface{} { return t.Description }},
+	{"type", func(t *AgileTask) interface{} { return t.Type }},
+	{"status", func(t *AgileTask) interface{} { return t.Status }},
+	{"priority", func(t *AgileTask) interface{} { return t.Priority }},
+	{"resolution", func(t *AgileTask) interface{} { return t.Resolution }},
+	{"assignee_id", func(t *AgileTask) interface{} { return t.AssigneeID }},
+	{"sprint_id", func(t *AgileTask) interface{} { return t.SprintID }},
+	{"epic_id", func(t *AgileTask) interface{} { return t.EpicID }},
+	{"parent_id", func(t *AgileTask) interface{} { return t.ParentID }},
+	{"story_points", func(t *AgileTask) interface{} { return t.StoryPoints }},
+	{"original_estimate", func(t *AgileTask) interface{} { return t.OriginalEstimate }},
+	{"remaining_time", func(t *AgileTask) interface{} { return t.RemainingTime }},
+	{"labels", func(t *AgileTask) interface{} { return t.Labels }},
+	{"components", func(t *AgileTask) interface{} { return t.Components }},
+	{"acceptance_criteria", func(t *AgileTask) interface{} { return t.AcceptanceCriteria }},
+}
+
+// NewTaskChanges 比较任务修改前后的字段，生成一组变更记录。before 为 nil 表示新建任务
+func NewTaskChanges(before, after *AgileTask, actorID uuid.UUID) []TaskChange {
+	action := TaskChangeUpdated
+	if before == nil {
+		action = TaskChangeCreated
+		before = &AgileTask{}
+	}
+
+	setID := uuid.New()
+	now := time.Now()
+	var changes []TaskChange
+	add := func(field string, oldValue, newValue *string) {
+		if equalChangeValues(oldValue, newValue) {
+			return
+		}
+		changes = append(changes, TaskChange{
+			TaskID:      after.ID,
+			ProjectID:   after.ProjectID,
+			ActorID:     actorID,
+			ChangeSetID: setID,
+			Action:      action,
+			Field:       field,
+			OldValue:    oldValue,
+			NewValue:    newValue,
+			CreatedAt:   now,
+		})
+	}
+
+	for _, field := range taskHistoryFields {
+		add(field.name, changeValue(field.value(before)), changeValue(field.value(after)))
+	}
+
+	keys := make([]string, 0, len(before.CustomFields)+len(after.CustomFields))
+	for key := range before.CustomFields {
+		keys = append(keys, key)
+	}
+	for key := range after.CustomFields {
+		if _, ok := before.CustomFields[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add(customFieldQueryPrefix+key, changeValue(before.CustomFields[key]), changeValue(after.CustomFields[key]))
+	}
+	return changes
+}
+
+// NewTaskDeletion 生成删除任务的变更记录
+func NewTaskDeletion(task *AgileTask, actorID uuid.UUID) TaskChange {
+	return TaskChange{
+		TaskID:      task.ID,
+		ProjectID:   task.ProjectID,
+		ActorID:     actorID,
+		ChangeSetID: uuid.New(),
+		Action:      TaskChangeDeleted,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// NewCustomFieldRemoval 删除自定义字段定义时，为被清除取值的任务各生成一条 cf.<key> 变更记录
+func NewCustomFieldRemoval(tasks []AgileTask, key string, actorID uuid.UUID) []TaskChange {
+	now := time.Now()
+	changes := make([]TaskChange, 0, len(tasks))
+	for _, task := range tasks {
+		oldValue := changeValue(task.CustomFields[key])
+		if oldValue == nil {
+			continue
+		}
+		changes = append(changes, TaskChange{
+			TaskID:      task.ID,
+			ProjectID:   task.ProjectID,
+			ActorID:     actorID,
+			ChangeSetID: uuid.New(),
+			Action:      TaskChangeUpdated,
+			Field:       customFieldQueryPrefix + key,
+			OldValue:    oldValue,
+			CreatedAt:   now,
+		})
+	}
+	return changes
+}
+
+// changeValue 将字段值转换为文本，空值返回 nil；字符串和ID原样保存，其余类型保存为JSON
+func changeValue(value interface{}) *string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		s = v
+	case *string:
+		if v == nil {
+			return nil
+		}
+		s = *v
+	case *uuid.UUID:
+		if v == nil {
+			return nil
+		}
+		s = v.String()
+	case *int:
+		if v == nil {
+			return nil
+		}
+		s = strconv.Itoa(*v)
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		s = strconv.FormatFloat(*v, 'f', -1, 64)
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		if len(v) == 0 {
+			return nil
+		}
+		encoded, _ := json.Marshal(v)
+		s = string(encoded)
+	case []AcceptanceCriteria:
+		if len(v) == 0 {
+			return nil
+		}
+		encoded, _ := json.Marshal(v)
+		s = string(encoded)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprint(v)
+		} else {
+			s = string(encoded)
+		}
+	}
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func equalChangeValues(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// 状态时间线和流动指标
+
+// StatusInterval 任务处于某个状态的时间段，To 为零值表示仍处于该状态
+type StatusInterval struct {
+	Status string    `json:"status"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+}
+
+// StatusTimeline 根据 status 字段的变更记录（按时间升序）还原任务的状态时间线。
+// 历史记录上线前创建的任务没有创建记录，第一次变更之前的状态取该变更的旧值；
+// 完全没有记录的任务视为自创建起一直处于当前状态
+func StatusTimeline(createdAt time.Time, currentStatus string, changes []TaskChange) []StatusInterval {
+	if len(changes) == 0 {
+		return []StatusInterval{{Status: currentStatus, From: createdAt}}
+	}
+
+	var timeline []StatusInterval
+	for _, change := range changes {
+		if change.NewValue == nil {
+			continue
+		}
+		if len(timeline) == 0 {
+			if change.Action != TaskChangeCreated && change.OldValue != nil {
+				timeline = append(timeline, StatusInterval{Status: *change.OldValue, From: createdAt})
+			} else {
+				timeline = append(timeline, StatusInterval{Status: *change.NewValue, From: createdAt})
+				continue
+			}
+		}
+		last := &timeline[len(timeline)-1]
+		if last.Status == *change.NewValue {
+			continue
+		}
+		last.To = change.CreatedAt
+		timeline = append(timeline, StatusInterval{Status: *change.NewValue, From: change.CreatedAt})
+	}
+	if len(timeline) == 0 {
+		return []StatusInterval{{Status: currentStatus, From: createdAt}}
+	}
+	return timeline
+}
+
+// StatusAt 返回时间线在某一时刻的状态，任务尚未创建时返回空字符串
+func StatusAt(timeline []StatusInterval, at time.Time) string {
+	status := ""
+	for _, interval := range timeline {
+		if interval.From.After(at) {
+			break
+		}
+		status = interval.Status
+	}
+	return status
+}
+
+// TaskCycle 已完成任务的周期时间
+type TaskCycle struct {
+	StartedAt   time.Time          `json:"started_at"`   // 第一次离开待办分类
+	CompletedAt time.Time          `json:"completed_at"` // 最后一次进入完成分类
+	Hours       float64            `json:"hours"`
+	StatusHours map[string]float64 `json:"status_hours"` // 周期内各状态停留的小时数
+}
+
+// CycleOf 计算当前处于完成分类的任务的周期时间，category 返回状态所属分类。
+// 任务未完成时返回 false；直接从待办完成的任务周期为零
+func CycleOf(timeline []StatusInterval, category func(status string) string) (*TaskCycle, bool) {
+	if len(timeline) == 0 {
+		return nil, false
+	}
+	last := timeline[len(timeline)-1]
+	if category(last.Status) != StatusCategoryDone {
+		return nil, false
+	}
+
+	// 完成分类之间的流转（例如 done -> cancelled）不改变完成时间
+	completed := len(timeline) - 1
+	for completed > 0 && category(timeline[completed-1].Status) == StatusCategoryDone {
+		completed--
+	}
+
+	cycle := &TaskCycle{CompletedAt: timeline[completed].From, StatusHours: map[string]float64{}}
+	cycle.StartedAt = cycle.CompletedAt
+	for i := 0; i < completed; i++ {
+		if category(timeline[i].Status) != StatusCategoryTodo {
+			cycle.StartedAt = timeline[i].From
+			for _, interval := range timeline[i:completed] {
+				cycle.StatusHours[interval.Status] += interval.To.Sub(interval.From).Hours()
+			}
+			break
+		}
+	}
+	cycle.Hours = cycle.CompletedAt.Sub(cycle.StartedAt).Hours()
+	return cycle, true
+}
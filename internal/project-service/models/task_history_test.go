@@ -0,0 +1,185 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func statusChange(action string, from, to string, at time.Time) TaskChange {
+	change := TaskChange{Action: action, Field: "status", CreatedAt: at}
+	if from != "" {
+		change.OldValue = &from
+	}
+	change.NewValue = &to
+	return change
+}
+
+func TestNewTaskChanges(t *testing.T) {
+	actor := uuid.New()
+	assignee := uuid.New()
+	points := 3
+
+	task := &AgileTask{
+		ID:           uuid.New(),
+		ProjectID:    uuid.New(),
+		Title:        "Login page",
+		Type:         TaskTypeStory,
+		Status:       TaskStatusTodo,
+		Priority:     PriorityMedium,
+		CustomFields: map[string]interface{}{"customer": "ACME"},
+	}
+
+	// 创建时记录非空字段
+	created := NewTaskChanges(nil, task, actor)
+	fields := map[string]string{}
+	for _, change := range created {
+		assert.Equal(t, TaskChangeCreated, change.Action)
+		assert.Nil(t, change.OldValue)
+		assert.Equal(t, created[0].ChangeSetID, change.ChangeSetID)
+		fields[change.Field] = *change.NewValue
+	}
+	assert.Equal(t, map[string]string{
+		"title": "Login page", "type": TaskTypeStory, "status": TaskStatusTodo,
+		"priority": PriorityMedium, "cf.customer": "ACME",
+	}, fields)
+
+	// 更新时只记录变化的字段
+	after := *task
+	after.Status = TaskStatusInProgress
+	after.AssigneeID = &assignee
+	after.StoryPoints = &points
+	after.Labels = []string{}
+	after.CustomFields = map[string]interface{}{"customer": "ACME", "cost": 12.5}
+
+	changes := NewTaskChanges(task, &after, actor)
+	require.Len(t, changes, 4)
+	byField := map[string]TaskChange{}
+	for _, change := range changes {
+		assert.Equal(t, TaskChangeUpdated, change.Action)
+		byField[change.Field] = change
+	}
+	assert.Equal(t, TaskStatusTodo, *byField["status"].OldValue)
+	assert.Equal(t, TaskStatusInProgress, *byField["status"].NewValue)
+	assert.Nil(t, byField["assignee_id"].OldValue)
+	assert.Equal(t, assignee.String(), *byField["assignee_id"].NewValue)
+	assert.Equal(t, "3", *byField["story_points"].NewValue)
+	assert.Equal(t, "12.5", *byField["cf.cost"].NewValue)
+
+	assert.Empty(t, NewTaskChanges(task, task, actor))
+}
+
+func TestNewCustomFieldRemoval(t *testing.T) {
+	actor := uuid.New()
+	tasks := []AgileTask{
+		{ID: uuid.New(), ProjectID: uuid.New(), CustomFields: map[string]interface{}{"customer": "ACME"}},
+		{ID: uuid.New(), ProjectID: uuid.New(), CustomFields: map[string]interface{}{"cost": 12.5}},
+		{ID: uuid.New(), ProjectID: uuid.New(), CustomFields: map[string]interface{}{"customer": ""}},
+	}
+
+	changes := NewCustomFieldRemoval(tasks, "customer", actor)
+	require.Len(t, changes, 1)
+	assert.Equal(t, tasks[0].ID, changes[0].TaskID)
+	assert.Equal(t, TaskChangeUpdated, changes[0].Action)
+	assert.Equal(t, "cf.customer", changes[0].Field)
+	assert.Equal(t, "ACME", *changes[0].OldValue)
+	assert.Nil(t, changes[0].NewValue)
+}
+
+func TestStatusTimeline(t *testing.T) {
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return created.Add(time.Duration(hours) * time.Hour) }
+
+	tests := []struct {
+		name    string
+		current string
+		changes []TaskChange
+		want    []StatusInterval
+	}{
+		{
+			name:    "没有历史记录",
+			current: TaskStatusInProgress,
+			want:    []StatusInterval{{Status: TaskStatusInProgress, From: created}},
+		},
+		{
+			name:    "从创建记录开始",
+			current: TaskStatusDone,
+			changes: []TaskChange{
+				statusChange(TaskChangeCreated, "", TaskStatusTodo, created),
+				statusChange(TaskChangeUpdated, TaskStatusTodo, TaskStatusInProgress, at(2)),
+				statusChange(TaskChangeUpdated, TaskStatusInProgress, TaskStatusDone, at(10)),
+			},
+			want: []StatusInterval{
+				{Status: TaskStatusTodo, From: created, To: at(2)},
+				{Status: TaskStatusInProgress, From: at(2), To: at(10)},
+				{Status: TaskStatusDone, From: at(10)},
+			},
+		},
+		{
+			name:    "历史记录上线前创建的任务",
+			current: TaskStatusInReview,
+			changes: []TaskChange{
+				statusChange(TaskChangeUpdated, TaskStatusInProgress, TaskStatusInReview, at(5)),
+			},
+			want: []StatusInterval{
+				{Status: TaskStatusInProgress, From: created, To: at(5)},
+				{Status: TaskStatusInReview, From: at(5)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeline := StatusTimeline(created, tt.current, tt.changes)
+			assert.Equal(t, tt.want, timeline)
+		})
+	}
+
+	timeline := StatusTimeline(created, TaskStatusDone, tests[1].changes)
+	assert.Equal(t, "", StatusAt(timeline, created.Add(-time.Minute)))
+	assert.Equal(t, TaskStatusTodo, StatusAt(timeline, at(1)))
+	assert.Equal(t, TaskStatusInProgress, StatusAt(timeline, at(2)))
+	assert.Equal(t, TaskStatusDone, StatusAt(timeline, at(100)))
+}
+
+func TestCycleOf(t *testing.T) {
+	created := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(hours int) time.Time { return created.Add(time.Duration(hours) * time.Hour) }
+	workflow := DefaultWorkflow()
+	categoryOf := func(status string) string {
+		if s := workflow.Status(status); s != nil {
+			return s.Category
+		}
+		return ""
+	}
+
+	timeline := []StatusInterval{
+		{Status: TaskStatusTodo, From: created, To: at(2)},
+		{Status: TaskStatusInProgress, From: at(2), To: at(6)},
+		{Status: TaskStatusInReview, From: at(6), To: at(8)},
+		{Status: TaskStatusInProgress, From: at(8), To: at(10)},
+		{Status: TaskStatusDone, From: at(10), To: at(12)},
+		{Status: TaskStatusCancelled, From: at(12)},
+	}
+	cycle, ok := CycleOf(timeline, categoryOf)
+	require.True(t, ok)
+	assert.Equal(t, at(2), cycle.StartedAt)
+	assert.Equal(t, at(10), cycle.CompletedAt)
+	assert.Equal(t, 8.0, cycle.Hours)
+	assert.Equal(t, map[string]float64{TaskStatusInProgress: 6, TaskStatusInReview: 2}, cycle.StatusHours)
+
+	// 未完成
+	_, ok = CycleOf(timeline[:3], categoryOf)
+	assert.False(t, ok)
+
+	// 直接从待办完成
+	cycle, ok = CycleOf([]StatusInterval{
+		{Status: TaskStatusTodo, From: created, To: at(3)},
+		{Status: TaskStatusDone, From: at(3)},
+	}, categoryOf)
+	require.True(t, ok)
+	assert.Zero(t, cycle.Hours)
+}
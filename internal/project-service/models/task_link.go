@@ -0,0 +1,278 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 任务链接错误
+var (
+	ErrInvalidTaskLink  = errors.New("invalid task link")
+	ErrTaskLinkNotFound = errors.New("task link not found")
+	ErrTaskLinkExists   = errors.New("task link already exists")
+	ErrTaskLinkCycle    = errors.New("task link would create a blocking cycle")
+)
+
+// 任务链接类型，按正向存储：源任务 blocks/duplicates/clones 目标任务
+const (
+	TaskLinkBlocks     = "blocks"
+	TaskLinkRelatesTo  = "relates_to"
+	TaskLinkDuplicates = "duplicates"
+	TaskLinkClones     = "clones"
+)
+
+// 链接的反向类型，创建时转换为正向类型并交换方向
+const (
+	TaskLinkIsBlockedBy    = "is_blocked_by"
+	TaskLinkIsDuplicatedBy = "is_duplicated_by"
+	TaskLinkIsClonedBy     = "is_cloned_by"
+)
+
+// 链接方向
+const (
+	TaskLinkOutward = "outward"
+	TaskLinkInward  = "inward"
+)
+
+// taskLinkLabels 链接类型从源任务和目标任务看的名称
+var taskLinkLabels = map[string][2]string{
+	TaskLinkBlocks:     {"blocks", "is blocked by"},
+	TaskLinkRelatesTo:  {"relates to", "relates to"},
+	TaskLinkDuplicates: {"duplicates", "is duplicated by"},
+	TaskLinkClones:     {"clones", "is cloned by"},
+}
+
+var taskLinkInverse = map[string]string{
+	TaskLinkIsBlockedBy:    TaskLinkBlocks,
+	TaskLinkIsDuplicatedBy: TaskLinkDuplicates,
+	TaskLinkIsClonedBy:     TaskLinkClones,
+}
+
+// TaskLink 任务之间的类型化链接，可以跨项目
+type TaskLink struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	SourceTaskID uuid.UUID  `json:"source_task_id" gorm:"type:uuid;not null;index"`
+	TargetTaskID uuid.UUID  `json:"target_task_id" gorm:"type:uuid;not null;index"`
+	Type         string     `json:"type" gorm:"column:link_type;size:30;not null"`
+	CreatedBy    *uuid.UUID `json:"created_by" gorm:"type:uuid"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName 指定表名
+func (TaskLink) TableName() string {
+	return "task_links"
+}
+
+// NewTaskLink 根据从 taskID 看的链接类型构造链接。
+// 反向类型会交换源和目标；relates_to 没有方向，按ID排序保证同一对任务只存一条
+func NewTaskLink(taskID, otherTaskID uuid.UUID, linkType string) (*TaskLink, error) {
+	if taskID == otherTaskID {
+		return nil, fmt.Errorf("%w: a task cannot be linked to itself", ErrInvalidTaskLink)
+	}
+
+	link := &TaskLink{SourceTaskID: taskID, TargetTaskID: otherTaskID, Type: linkType}
+	if outward, ok := taskLinkInverse[linkType]; ok {
+		link.SourceTaskID, link.TargetTaskID, link.Type = otherTaskID, taskID, outward
+	}
+	if _, ok := taskLinkLabels[link.Type]; !ok {
+		return nil, fmt.Errorf("%w: unknown link type %q", ErrInvalidTaskLink, linkType)
+	}
+
+	if link.Type == TaskLinkRelatesTo && link.TargetTaskID.String() < link.SourceTaskID.String() {
+		link.SourceTaskID, link.TargetTaskID = link.TargetTaskID, link.SourceTaskID
+	}
+	return link, nil
+}
+
+// Involves 判断链接是否涉及任务
+func (l *TaskLink) Involves(taskID uuid.UUID) bool {
+	return l.SourceTaskID == taskID || l.TargetTaskID == taskID
+}
+
+// OtherTask 返回链接另一端的任务
+func (l *TaskLink) OtherTask(taskID uuid.UUID) uuid.UUID {
+	if l.SourceTaskID == taskID {
+		return l.TargetTaskID
+	}
+	return l.SourceTaskID
+}
+
+// Describe 返回从 taskID 看的方向和名称，例如 inward / "is blocked by"
+func (l *TaskLink) Describe(taskID uuid.UUID) (direction, label string) {
+	labels := taskLinkLabels[l.Type]
+	if l.SourceTaskID == taskID {
+		return TaskLinkOutward, labels[0]
+	}
+	return TaskLinkInward, labels[1]
+}
+
+// 依赖图
+
+// DependencyNode 依赖图中的任务
+type DependencyNode struct {
+	TaskID    uuid.UUID  `json:"task_id"`
+	ProjectID uuid.UUID  `json:"project_id"`
+	SprintID  *uuid.UUID `json:"sprint_id,omitempty"`
+	Key       string     `json:"key"`
+	Title     string     `json:"title"`
+	Status    string     `json:"status"`
+	Resolved  bool       `json:"resolved"` // 状态属于完成分类
+}
+
+// DependencyEdge 阻塞关系，From 阻塞 To
+type DependencyEdge struct {
+	From uuid.UUID `json:"from"`
+	To   uuid.UUID `json:"to"`
+}
+
+// DependencyGraph 由 blocks 链接构成的有向图
+type DependencyGraph struct {
+	Nodes  []DependencyNode `json:"nodes"`
+	Edges  []DependencyEdge `json:"edges"`
+	Cycles [][]uuid.UUID    `json:"cycles"` // 互相阻塞的任务组
+}
+
+// BlockedChain 被阻塞任务及其上游阻塞链
+type BlockedChain struct {
+	TaskID  uuid.UUID   `json:"task_id"`
+	Path    []uuid.UUID `json:"path"`     // 从最上游的未解决阻塞任务到该任务
+	InCycle bool        `json:"in_cycle"` // 链上存在循环阻塞，无法按顺序解除
+}
+
+// DetectCycles 使用 Tarjan 算法找出强连通分量，包含多个任务的分量即循环阻塞
+func (g *DependencyGraph) DetectCycles() [][]uuid.UUID {
+	adjacency := g.adjacency(false)
+
+	index := make(map[uuid.UUID]int, len(g.Nodes))
+	lowlink := make(map[uuid.UUID]int, len(g.Nodes))
+	onStack := make(map[uuid.UUID]bool, len(g.Nodes))
+	var stack []uuid.UUID
+	cycles := [][]uuid.UUID{}
+	next := 0
+
+	var connect func(v uuid.UUID)
+	connect = func(v uuid.UUID) {
+		index[v], lowlink[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range adjacency[v] {
+			if _, visited := index[w]; !visited {
+				connect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+
+		if lowlink[v] != index[v] {
+			return
+		}
+		var component []uuid.UUID
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			if w == v {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Slice(component, func(i, j int) bool { return component[i].String() < component[j].String() })
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, node := range g.Nodes {
+		if _, visited := index[node.TaskID]; !visited {
+			connect(node.TaskID)
+		}
+	}
+	return cycles
+}
+
+// BlockedChains 计算指定任务的阻塞链，只考虑未解决的任务；没有未解决阻塞的任务不返回
+func (g *DependencyGraph) BlockedChains(taskIDs []uuid.UUID) []BlockedChain {
+	nodes := make(map[uuid.UUID]*DependencyNode, len(g.Nodes))
+	for i := range g.Nodes {
+		nodes[g.Nodes[i].TaskID] = &g.Nodes[i]
+	}
+	unresolved := func(id uuid.UUID) bool {
+		node, ok := nodes[id]
+		return ok && !node.Resolved
+	}
+	blockers := g.adjacency(true)
+
+	inCycle := make(map[uuid.UUID]bool)
+	for _, cycle := range g.DetectCycles() {
+		for _, id := range cycle {
+			inCycle[id] = true
+		}
+	}
+
+	// 最长上游链，循环中的任务在访问路径上时不再展开
+	memo := make(map[uuid.UUID][]uuid.UUID)
+	visiting := make(map[uuid.UUID]bool)
+	var longest func(id uuid.UUID) []uuid.UUID
+	longest = func(id uuid.UUID) []uuid.UUID {
+		if chain, ok := memo[id]; ok {
+			return chain
+		}
+		visiting[id] = true
+		var best []uuid.UUID
+		for _, blocker := range blockers[id] {
+			if !unresolved(blocker) || visiting[blocker] {
+				continue
+			}
+			if chain := longest(blocker); len(chain) > len(best) {
+				best = chain
+			}
+		}
+		visiting[id] = false
+
+		chain := append(append([]uuid.UUID{}, best...), id)
+		if !inCycle[id] {
+			memo[id] = chain
+		}
+		return chain
+	}
+
+	var chains []BlockedChain
+	for _, id := range taskIDs {
+		if !unresolved(id) {
+			continue
+		}
+		path := longest(id)
+		if len(path) < 2 {
+			continue
+		}
+		chain := BlockedChain{TaskID: id, Path: path}
+		for _, step := range path {
+			if inCycle[step] {
+				chain.InCycle = true
+				break
+			}
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// adjacency 构造邻接表，reverse 为 true 时得到每个任务的阻塞者
+func (g *DependencyGraph) adjacency(reverse bool) map[uuid.UUID][]uuid.UUID {
+	adjacency := make(map[uuid.UUID][]uuid.UUID, len(g.Nodes))
+	for _, edge := range g.Edges {
+		if reverse {
+			adjacency[edge.To] = append(adjacency[edge.To], edge.From)
+		} else {
+			adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+		}
+	}
+	return adjacency
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTaskLink(t *testing.T) {
+	a := uuid.MustParse("aaaaaaaa-0000-0000-0000-000000000000")
+	b := uuid.MustParse("bbbbbbbb-0000-0000-0000-000000000000")
+
+	link, err := NewTaskLink(a, b, TaskLinkBlocks)
+	require.NoError(t, err)
+	assert.Equal(t, a, link.SourceTaskID)
+	assert.Equal(t, b, link.TargetTaskID)
+
+	// 反向类型交换方向
+	link, err = NewTaskLink(a, b, TaskLinkIsBlockedBy)
+	require.NoError(t, err)
+	assert.Equal(t, b, link.SourceTaskID)
+	assert.Equal(t, TaskLinkBlocks, link.Type)
+	direction, label := link.Describe(a)
+	assert.Equal(t, TaskLinkInward, direction)
+	assert.Equal(t, "is blocked by", label)
+	direction, label = link.Describe(b)
+	assert.Equal(t, TaskLinkOutward, direction)
+	assert.Equal(t, "blocks", label)
+	assert.Equal(t, b, link.OtherTask(a))
+
+	// relates_to 不区分方向，同一对任务只有一种存储形式
+	forward, err := NewTaskLink(b, a, TaskLinkRelatesTo)
+	require.NoError(t, err)
+	assert.Equal(t, a, forward.SourceTaskID)
+
+	_, err = NewTaskLink(a, a, TaskLinkRelatesTo)
+	assert.ErrorIs(t, err, ErrInvalidTaskLink)
+	_, err = NewTaskLink(a, b, "depends_on")
+	assert.ErrorIs(t, err, ErrInvalidTaskLink)
+}
+
+func dependencyGraph(resolved map[uuid.UUID]bool, edges ...[2]uuid.UUID) *DependencyGraph {
+	g := &DependencyGraph{}
+	seen := map[uuid.UUID]bool{}
+	for _, e := range edges {
+		g.Edges = append(g.Edges, DependencyEdge{From: e[0], To: e[1]})
+		for _, id := range e {
+			if !seen[id] {
+				seen[id] = true
+				g.Nodes = append(g.Nodes, DependencyNode{TaskID: id, Resolved: resolved[id]})
+			}
+		}
+	}
+	return g
+}
+
+func TestDependencyGraphCycles(t *testing.T) {
+	a, b, c, d := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	g := dependencyGraph(nil, [2]uuid.UUID{a, b}, [2]uuid.UUID{b, c}, [2]uuid.UUID{c, d})
+	assert.Empty(t, g.DetectCycles())
+
+	g = dependencyGraph(nil, [2]uuid.UUID{a, b}, [2]uuid.UUID{b, c}, [2]uuid.UUID{c, a}, [2]uuid.UUID{c, d})
+	cycles := g.DetectCycles()
+	require.Len(t, cycles, 1)
+	assert.ElementsMatch(t, []uuid.UUID{a, b, c}, cycles[0])
+}
+
+func TestDependencyGraphBlockedChains(t *testing.T) {
+	a, b, c, d, e := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		graph    *DependencyGraph
+		tasks    []uuid.UUID
+		want     [][]uuid.UUID
+		inCycle  bool
+		wantNone bool
+	}{
+		{
+			name:  "取最长的上游链",
+			graph: dependencyGraph(nil, [2]uuid.UUID{a, b}, [2]uuid.UUID{b, d}, [2]uuid.UUID{c, d}),
+			tasks: []uuid.UUID{d},
+			want:  [][]uuid.UUID{{a, b, d}},
+		},
+		{
+			name:  "已解决的阻塞任务不计入",
+			graph: dependencyGraph(map[uuid.UUID]bool{b: true}, [2]uuid.UUID{a, b}, [2]uuid.UUID{b, d}, [2]uuid.UUID{c, d}),
+			tasks: []uuid.UUID{d},
+			want:  [][]uuid.UUID{{c, d}},
+		},
+		{
+			name:     "没有阻塞",
+			graph:    dependencyGraph(map[uuid.UUID]bool{a: true}, [2]uuid.UUID{a, d}),
+			tasks:    []uuid.UUID{d, e},
+			wantNone: true,
+		},
+		{
+			name:    "循环阻塞",
+			graph:   dependencyGraph(nil, [2]uuid.UUID{a, b}, [2]uuid.UUID{b, a}, [2]uuid.UUID{b, d}),
+			tasks:   []uuid.UUID{d},
+			want:    [][]uuid.UUID{{a, b, d}},
+			inCycle: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chains := tt.graph.BlockedChains(tt.tasks)
+			if tt.wantNone {
+				assert.Empty(t, chains)
+				return
+			}
+			require.Len(t, chains, len(tt.want))
+			for i, chain := range chains {
+				assert.Equal(t, tt.want[i], chain.Path)
+				assert.Equal(t, tt.inCycle, chain.InCycle)
+			}
+		})
+	}
+}
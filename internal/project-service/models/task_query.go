@@ -0,0 +1,909 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// 任务查询语言
+//
+// 类似JQL的文本查询，编译为参数化SQL条件，所有用户输入都以占位符参数传递：
+//
+//	assignee = currentUser() AND status IN (todo, in_progress) AND labels = backend
+//	created >= -7d AND (priority = high OR sprint IN openSprints()) ORDER BY priority DESC, created
+//	resolved >= startOfSprint() AND component IS NOT EMPTY AND text ~ "login"
+
+// ErrInvalidTaskQuery 查询语法或语义错误
+var ErrInvalidTaskQuery = errors.New("invalid task query")
+
+// 查询规模限制，防止构造出过大的SQL
+const (
+	MaxTaskQueryLength = 2000
+	maxQueryClauses    = 50
+	maxQueryDepth      = 10
+	maxQueryListValues = 100
+)
+
+// TaskQueryContext 编译查询时使用的上下文
+type TaskQueryContext struct {
+	UserID uuid.UUID // currentUser() 对应的用户
+	Now    time.Time // 相对日期和日期函数的基准时间
+
+	// 项目的自定义字段，以 cf.<key> 的形式查询
+	CustomFields []CustomField
+}
+
+// CompiledTaskQuery 编译后的参数化查询
+type CompiledTaskQuery struct {
+	Where   string        // WHERE 条件，为空表示不过滤
+	Args    []interface{} // 条件参数
+	OrderBy string        // ORDER BY 表达式，为空表示使用默认排序
+}
+
+// 字段类型
+type queryFieldKind int
+
+const (
+	queryFieldEnum   queryFieldKind = iota // 固定取值的字符串
+	queryFieldString                       // 任意取值的字符串
+	queryFieldText                         // 支持包含匹配的文本
+	queryFieldUser                         // 用户ID，支持 currentUser()
+	queryFieldRef                          // 关联对象ID
+	queryFieldNumber                       // 数值
+	queryFieldDate                         // 时间
+	queryFieldList                         // jsonb 字符串数组
+)
+
+type taskQueryField struct {
+	kind    queryFieldKind
+	columns []string
+	values  []string // 枚举字段的合法取值
+	order   string   // 自定义排序表达式
+	noSort  bool
+}
+
+func (f taskQueryField) column() string {
+	return f.columns[0]
+}
+
+func taskColumn(name string) []string {
+	return []string{"agile_tasks." + name}
+}
+
+// priorityOrder 按优先级高低排序，而不是按字母顺序
+var priorityOrder = "CASE agile_tasks.priority WHEN '" + PriorityLowest + "' THEN 1 WHEN '" + PriorityLow +
+	"' THEN 2 WHEN '" + PriorityMedium + "' THEN 3 WHEN '" + PriorityHigh + "' THEN 4 WHEN '" + PriorityHighest + "' THEN 5 END"
+
+// taskQueryFields 可查询字段白名单，字段名不区分大小写
+var taskQueryFields = map[string]taskQueryField{
+	"type":              {kind: queryFieldEnum, columns: taskColumn("type"), values: []string{TaskTypeStory, TaskTypeTask, TaskTypeBug, TaskTypeEpic, TaskTypeSubTask}},
+	"priority":          {kind: queryFieldEnum, columns: taskColumn("priority"), values: []string{PriorityLowest, PriorityLow, PriorityMedium, PriorityHigh, PriorityHighest}, order: priorityOrder},
+	"status":            {kind: queryFieldString, columns: taskColumn("status")},
+	"resolution":        {kind: queryFieldString, columns: taskColumn("resolution")},
+	"title":             {kind: queryFieldText, columns: taskColumn("title")},
+	"summary":           {kind: queryFieldText, columns: taskColumn("title")},
+	"description":       {kind: queryFieldText, columns: taskColumn("description")},
+	"text":              {kind: queryFieldText, columns: []string{"agile_tasks.title", "agile_tasks.description"}, noSort: true},
+	"assignee":          {kind: queryFieldUser, columns: taskColumn("assignee_id")},
+	"reporter":          {kind: queryFieldUser, columns: taskColumn("reporter_id")},
+	"sprint":            {kind: queryFieldRef, columns: taskColumn("sprint_id")},
+	"epic":              {kind: queryFieldRef, columns: taskColumn("epic_id")},
+	"parent":            {kind: queryFieldRef, columns: taskColumn("parent_id")},
+	"number":            {kind: queryFieldNumber, columns: taskColumn("task_number")},
+	"points":            {kind: queryFieldNumber, columns: taskColumn("story_points")},
+	"story_points":      {kind: queryFieldNumber, columns: taskColumn("story_points")},
+	"original_estimate": {kind: queryFieldNumber, columns: taskColumn("original_estimate")},
+	"remaining_time":    {kind: queryFieldNumber, columns: taskColumn("remaining_time")},
+	"logged_time":       {kind: queryFieldNumber, columns: taskColumn("logged_time")},
+	"created":           {kind: queryFieldDate, columns: taskColumn("created_at")},
+	"updated":           {kind: queryFieldDate, columns: taskColumn("updated_at")},
+	"resolved":          {kind: queryFieldDate, columns: taskColumn("resolved_at")},
+	"labels":            {kind: queryFieldList, columns: taskColumn("labels"), noSort: true},
+	"label":             {kind: queryFieldList, columns: taskColumn("labels"), noSort: true},
+	"components":        {kind: queryFieldList, columns: taskColumn("components"), noSort: true},
+	"component":         {kind: queryFieldList, columns: taskColumn("components"), noSort: true},
+}
+
+// customFieldQueryPrefix 自定义字段在查询中的前缀
+const customFieldQueryPrefix = "cf."
+
+// CustomFieldColumn 返回自定义字段取值的SQL表达式（文本形式），键必须已通过校验
+func CustomFieldColumn(key string) string {
+	return "(agile_tasks.custom_fields->>'" + key + "')"
+}
+
+// queryField 将自定义字段映射为查询字段，按类型转换列表达式
+func (f *CustomField) queryField() (taskQueryField, bool) {
+	if !customFieldKeyPattern.MatchString(f.Key) {
+		return taskQueryField{}, false
+	}
+	column := CustomFieldColumn(f.Key)
+	switch f.Type {
+	case CustomFieldNumber:
+		return taskQueryField{kind: queryFieldNumber, columns: []string{column + "::numeric"}}, true
+	case CustomFieldDate:
+		return taskQueryField{kind: queryFieldDate, columns: []string{column + "::date"}}, true
+	case CustomFieldUser:
+		return taskQueryField{kind: queryFieldUser, columns: []string{column + "::uuid"}}, true
+	case CustomFieldSelect:
+		return taskQueryField{kind: queryFieldEnum, columns: []string{column}, values: f.Options}, true
+	case CustomFieldMultiSelect:
+		return taskQueryField{kind: queryFieldList, columns: []string{"(agile_tasks.custom_fields->'" + f.Key + "')"}, noSort: true}, true
+	default:
+		return taskQueryField{kind: queryFieldText, columns: []string{column}}, true
+	}
+}
+
+// taskQuerySortOnly 只能用于排序的字段
+var taskQuerySortOnly = map[string]string{
+	"rank": "agile_tasks.rank",
+}
+
+// CompileTaskQuery 解析并编译任务查询
+func CompileTaskQuery(input string, qc TaskQueryContext) (*CompiledTaskQuery, error) {
+	if len(input) > MaxTaskQueryLength {
+		return nil, fmt.Errorf("%w: query exceeds %d characters", ErrInvalidTaskQuery, MaxTaskQueryLength)
+	}
+	if qc.Now.IsZero() {
+		qc.Now = time.Now()
+	}
+
+	tokens, err := lexTaskQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &taskQueryParser{tokens: tokens, qc: qc}
+	return p.parse()
+}
+
+// 词法分析
+
+type queryTokenKind int
+
+const (
+	queryTokenEOF queryTokenKind = iota
+	queryTokenWord
+	queryTokenString
+	queryTokenOperator
+	queryTokenLParen
+	queryTokenRParen
+	queryTokenComma
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+}
+
+// keyword 判断未加引号的单词是否为指定关键字
+func (t queryToken) keyword(kw string) bool {
+	return t.kind == queryTokenWord && strings.EqualFold(t.text, kw)
+}
+
+func (t queryToken) describe() string {
+	if t.kind == queryTokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(t.text)
+}
+
+func isQueryWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' || r == ':' || r == '+'
+}
+
+func lexTaskQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: queryTokenLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: queryTokenRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, queryToken{kind: queryTokenComma, text: ",", pos: pos})
+			i++
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				sb.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string at position %d", ErrInvalidTaskQuery, pos)
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenString, text: sb.String(), pos: pos})
+			i = j + 1
+		case r == '=' || r == '~':
+			tokens = append(tokens, queryToken{kind: queryTokenOperator, text: string(r), pos: pos})
+			i++
+		case r == '!' || r == '<' || r == '>':
+			op := string(r)
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '!' && runes[i+1] == '~')) {
+				op += string(runes[i+1])
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("%w: unexpected \"!\" at position %d", ErrInvalidTaskQuery, pos)
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenOperator, text: op, pos: pos})
+			i += len(op)
+		case isQueryWordRune(r):
+			j := i
+			for j < len(runes) && isQueryWordRune(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenWord, text: string(runes[i:j]), pos: pos})
+			i = j
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q at position %d", ErrInvalidTaskQuery, r, pos)
+		}
+	}
+
+	return append(tokens, queryToken{kind: queryTokenEOF, pos: len(runes) + 1}), nil
+}
+
+// 语法分析
+//
+//	query   := [or] [ORDER BY sort {, sort}]
+//	or      := and {OR and}
+//	and     := not {AND not}
+//	not     := NOT not | '(' or ')' | clause
+//	clause  := field op value | field [NOT] IN list | field IS [NOT] EMPTY
+//	list    := '(' value {, value} ')' | function
+
+type taskQueryParser struct {
+	tokens  []queryToken
+	pos     int
+	qc      TaskQueryContext
+	args    []interface{}
+	clauses int
+	depth   int
+}
+
+// queryValue 查询中的取值
+type queryValue struct {
+	text     string
+	quoted   bool
+	function string // 小写的函数名，非函数调用时为空
+	pos      int
+}
+
+// isEmpty 判断取值是否为 EMPTY/NULL 关键字
+func (v queryValue) isEmpty() bool {
+	return !v.quoted && v.function == "" && (strings.EqualFold(v.text, "empty") || strings.EqualFold(v.text, "null"))
+}
+
+func (v queryValue) describe() string {
+	if v.function != "" {
+		return v.text + "()"
+	}
+	return strconv.Quote(v.text)
+}
+
+func (p *taskQueryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *taskQueryParser) next() queryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != queryTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *taskQueryParser) errorf(tok queryToken, format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s at position %d", ErrInvalidTaskQuery, fmt.Sprintf(format, a...), tok.pos)
+}
+
+func (p *taskQueryParser) expect(kind queryTokenKind, text string) error {
+	tok := p.next()
+	if tok.kind != kind {
+		return p.errorf(tok, "expected %q, got %s", text, tok.describe())
+	}
+	return nil
+}
+
+// arg 追加参数并返回占位符
+func (p *taskQueryParser) arg(v interface{}) string {
+	p.args = append(p.args, v)
+	return "?"
+}
+
+func (p *taskQueryParser) parse() (*CompiledTaskQuery, error) {
+	compiled := &CompiledTaskQuery{}
+
+	if tok := p.peek(); tok.kind != queryTokenEOF && !tok.keyword("order") {
+		where, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		compiled.Where = where
+	}
+
+	if p.peek().keyword("order") {
+		p.next()
+		if tok := p.next(); !tok.keyword("by") {
+			return nil, p.errorf(tok, "expected BY after ORDER, got %s", tok.describe())
+		}
+		orderBy, err := p.parseOrderBy()
+		if err != nil {
+			return nil, err
+		}
+		compiled.OrderBy = orderBy
+	}
+
+	if tok := p.peek(); tok.kind != queryTokenEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok.describe())
+	}
+
+	compiled.Args = p.args
+	return compiled, nil
+}
+
+func (p *taskQueryParser) parseOr() (string, error) {
+	return p.parseBinary("or", p.parseAnd)
+}
+
+func (p *taskQueryParser) parseAnd() (string, error) {
+	return p.parseBinary("and", p.parseNot)
+}
+
+func (p *taskQueryParser) parseBinary(keyword string, operand func() (string, error)) (string, error) {
+	first, err := operand()
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{first}
+	for p.peek().keyword(keyword) {
+		p.next()
+		part, err := operand()
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 1 {
+		return first, nil
+	}
+	return "(" + strings.Join(parts, " "+strings.ToUpper(keyword)+" ") + ")", nil
+}
+
+func (p *taskQueryParser) parseNot() (string, error) {
+	tok := p.peek()
+
+	switch {
+	case tok.keyword("not"):
+		p.next()
+		inner, err := p.parseNot()
+		if err != nil {
+			return "", err
+		}
+		// NULL 比较结果视为不匹配，取反后应为匹配
+		return notSQL(inner), nil
+	case tok.kind == queryTokenLParen:
+		p.next()
+		p.depth++
+		if p.depth > maxQueryDepth {
+			return "", p.errorf(tok, "nesting deeper than %d levels", maxQueryDepth)
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect(queryTokenRParen, ")"); err != nil {
+			return "", err
+		}
+		p.depth--
+		return inner, nil
+	default:
+		return p.parseClause()
+	}
+}
+
+func notSQL(expr string) string {
+	return "NOT COALESCE(" + expr + ", false)"
+}
+
+func (p *taskQueryParser) parseField() (string, taskQueryField, error) {
+	tok := p.next()
+	if tok.kind != queryTokenWord {
+		return "", taskQueryField{}, p.errorf(tok, "expected field name, got %s", tok.describe())
+	}
+	name := strings.ToLower(tok.text)
+	field, ok := p.lookupField(name)
+	if !ok {
+		return "", taskQueryField{}, p.errorf(tok, "unknown field %q", tok.text)
+	}
+	return name, field, nil
+}
+
+// lookupField 查找内置字段或 cf.<key> 形式的自定义字段
+func (p *taskQueryParser) lookupField(name string) (taskQueryField, bool) {
+	if key, ok := strings.CutPrefix(name, customFieldQueryPrefix); ok {
+		for i := range p.qc.CustomFields {
+			if p.qc.CustomFields[i].Key == key {
+				return p.qc.CustomFields[i].queryField()
+			}
+		}
+		return taskQueryField{}, false
+	}
+	field, ok := taskQueryFields[name]
+	return field, ok
+}
+
+func (p *taskQueryParser) parseClause() (string, error) {
+	p.clauses++
+	if p.clauses > maxQueryClauses {
+		return "", p.errorf(p.peek(), "more than %d conditions", maxQueryClauses)
+	}
+
+	name, field, err := p.parseField()
+	if err != nil {
+		return "", err
+	}
+
+	tok := p.next()
+	switch {
+	case tok.keyword("is"):
+		negate := false
+		if p.peek().keyword("not") {
+			p.next()
+			negate = true
+		}
+		value := p.next()
+		if !value.keyword("empty") && !value.keyword("null") {
+			return "", p.errorf(value, "expected EMPTY after IS, got %s", value.describe())
+		}
+		return p.compileEmpty(field, negate), nil
+
+	case tok.keyword("in"):
+		return p.compileIn(name, field, false)
+
+	case tok.keyword("not"):
+		if in := p.next(); !in.keyword("in") {
+			return "", p.errorf(in, "expected IN after NOT, got %s", in.describe())
+		}
+		return p.compileIn(name, field, true)
+
+	case tok.kind == queryTokenOperator:
+		value, err := p.parseValue()
+		if err != nil {
+			return "", err
+		}
+		if value.isEmpty() {
+			switch tok.text {
+			case "=":
+				return p.compileEmpty(field, false), nil
+			case "!=":
+				return p.compileEmpty(field, true), nil
+			}
+		}
+		return p.compileComparison(name, field, tok, value)
+
+	default:
+		return "", p.errorf(tok, "expected operator after %q, got %s", name, tok.describe())
+	}
+}
+
+func (p *taskQueryParser) parseValue() (queryValue, error) {
+	tok := p.next()
+	switch tok.kind {
+	case queryTokenString:
+		return queryValue{text: tok.text, quoted: true, pos: tok.pos}, nil
+	case queryTokenWord:
+		value := queryValue{text: tok.text, pos: tok.pos}
+		if p.peek().kind == queryTokenLParen {
+			p.next()
+			if err := p.expect(queryTokenRParen, ")"); err != nil {
+				return queryValue{}, err
+			}
+			value.function = strings.ToLower(tok.text)
+		}
+		return value, nil
+	default:
+		return queryValue{}, p.errorf(tok, "expected value, got %s", tok.describe())
+	}
+}
+
+func (p *taskQueryParser) parseList() ([]queryValue, error) {
+	var values []queryValue
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		if len(values) > maxQueryListValues {
+			return nil, p.errorf(p.peek(), "more than %d values in list", maxQueryListValues)
+		}
+
+		tok := p.next()
+		if tok.kind == queryTokenRParen {
+			return values, nil
+		}
+		if tok.kind != queryTokenComma {
+			return nil, p.errorf(tok, "expected \",\" or \")\", got %s", tok.describe())
+		}
+	}
+}
+
+// 条件编译
+
+func (p *taskQueryParser) compileEmpty(field taskQueryField, negate bool) string {
+	var exprs []string
+	for _, column := range field.columns {
+		switch field.kind {
+		case queryFieldList:
+			exprs = append(exprs, fmt.Sprintf("(%s IS NULL OR %s = 'null'::jsonb OR %s = '[]'::jsonb)", column, column, column))
+		case queryFieldString, queryFieldText:
+			exprs = append(exprs, fmt.Sprintf("(%s IS NULL OR %s = '')", column, column))
+		default:
+			exprs = append(exprs, column+" IS NULL")
+		}
+	}
+
+	expr := exprs[0]
+	if len(exprs) > 1 {
+		expr = "(" + strings.Join(exprs, " AND ") + ")"
+	}
+	if negate {
+		return "NOT " + expr
+	}
+	return expr
+}
+
+func (p *taskQueryParser) compileComparison(name string, field taskQueryField, op queryToken, value queryValue) (string, error) {
+	allowed := map[queryFieldKind][]string{
+		queryFieldEnum:   {"=", "!="},
+		queryFieldString: {"=", "!="},
+		queryFieldText:   {"~", "!~", "=", "!="},
+		queryFieldUser:   {"=", "!="},
+		queryFieldRef:    {"=", "!="},
+		queryFieldNumber: {"=", "!=", ">", ">=", "<", "<="},
+		queryFieldDate:   {">", ">=", "<", "<="},
+		queryFieldList:   {"=", "!=", "~", "!~"},
+	}[field.kind]
+	if !containsString(allowed, op.text) {
+		return "", p.errorf(op, "operator %q is not supported for field %q", op.text, name)
+	}
+
+	switch field.kind {
+	case queryFieldText:
+		if op.text == "~" || op.text == "!~" {
+			pattern := "%" + escapeLikePattern(value.text) + "%"
+			var exprs []string
+			for _, column := range field.columns {
+				exprs = append(exprs, column+" ILIKE "+p.arg(pattern))
+			}
+			expr := exprs[0]
+			if len(exprs) > 1 {
+				expr = "(" + strings.Join(exprs, " OR ") + ")"
+			}
+			if op.text == "!~" {
+				return notSQL(expr), nil
+			}
+			return expr, nil
+		}
+		if len(field.columns) > 1 {
+			return "", p.errorf(op, "field %q only supports ~ and !~", name)
+		}
+
+	case queryFieldList:
+		if value.function != "" {
+			return "", p.errorf(queryToken{pos: value.pos}, "invalid value %s for field %q", value.describe(), name)
+		}
+		expr := p.listContains(field.column(), value.text)
+		if op.text == "!=" || op.text == "!~" {
+			return notSQL(expr), nil
+		}
+		return expr, nil
+	}
+
+	operand, err := p.operand(name, field, value)
+	if err != nil {
+		return "", err
+	}
+	sqlOp := op.text
+	if sqlOp == "!=" {
+		sqlOp = "<>"
+	}
+	return field.column() + " " + sqlOp + " " + operand, nil
+}
+
+func (p *taskQueryParser) compileIn(name string, field taskQueryField, negate bool) (string, error) {
+	switch field.kind {
+	case queryFieldText, queryFieldDate:
+		return "", p.errorf(p.peek(), "IN is not supported for field %q", name)
+	}
+
+	// sprint IN openSprints() 这类返回集合的函数
+	if tok := p.peek(); tok.kind == queryTokenWord {
+		value, err := p.parseValue()
+		if err != nil {
+			return "", err
+		}
+		subquery, err := p.setFunction(name, value)
+		if err != nil {
+			return "", err
+		}
+		if negate {
+			return notSQL(field.column() + " IN " + subquery), nil
+		}
+		return field.column() + " IN " + subquery, nil
+	}
+
+	if err := p.expect(queryTokenLParen, "("); err != nil {
+		return "", err
+	}
+	values, err := p.parseList()
+	if err != nil {
+		return "", err
+	}
+
+	if field.kind == queryFieldList {
+		var exprs []string
+		for _, value := range values {
+			if value.function != "" || value.isEmpty() {
+				return "", p.errorf(queryToken{pos: value.pos}, "invalid value %s for field %q", value.describe(), name)
+			}
+			exprs = append(exprs, p.listContains(field.column(), value.text))
+		}
+		expr := "(" + strings.Join(exprs, " OR ") + ")"
+		if negate {
+			return notSQL(expr), nil
+		}
+		return expr, nil
+	}
+
+	var operands []string
+	includeEmpty := false
+	for _, value := range values {
+		if value.isEmpty() {
+			includeEmpty = true
+			continue
+		}
+		operand, err := p.operand(name, field, value)
+		if err != nil {
+			return "", err
+		}
+		operands = append(operands, operand)
+	}
+
+	var exprs []string
+	if len(operands) > 0 {
+		exprs = append(exprs, field.column()+" IN ("+strings.Join(operands, ", ")+")")
+	}
+	if includeEmpty {
+		exprs = append(exprs, p.compileEmpty(field, false))
+	}
+	expr := exprs[0]
+	if len(exprs) > 1 {
+		expr = "(" + strings.Join(exprs, " OR ") + ")"
+	}
+	if negate {
+		return notSQL(expr), nil
+	}
+	return expr, nil
+}
+
+// listContains 生成 jsonb 数组包含某个元素的条件
+func (p *taskQueryParser) listContains(column, value string) string {
+	encoded, _ := json.Marshal([]string{value})
+	return column + " @> " + p.arg(string(encoded)) + "::jsonb"
+}
+
+// setFunction 编译返回集合的函数为子查询
+func (p *taskQueryParser) setFunction(name string, value queryValue) (string, error) {
+	if value.function == "" {
+		return "", p.errorf(queryToken{pos: value.pos}, "expected \"(\" after IN, got %s", value.describe())
+	}
+	if name != "sprint" {
+		return "", p.errorf(queryToken{pos: value.pos}, "function %s cannot be used with IN on field %q", value.describe(), name)
+	}
+
+	var statuses []string
+	switch value.function {
+	case "opensprints":
+		statuses = []string{SprintStatusPlanned, SprintStatusActive}
+	case "closedsprints":
+		statuses = []string{SprintStatusClosed}
+	default:
+		return "", p.errorf(queryToken{pos: value.pos}, "unknown sprint function %s", value.describe())
+	}
+
+	return "(SELECT s.id FROM sprints s WHERE s.project_id = agile_tasks.project_id AND s.deleted_at IS NULL AND s.status IN " +
+		p.arg(statuses) + ")", nil
+}
+
+// operand 将单个取值转换为SQL操作数
+func (p *taskQueryParser) operand(name string, field taskQueryField, value queryValue) (string, error) {
+	invalid := func(expected string) error {
+		return p.errorf(queryToken{pos: value.pos}, "invalid value %s for field %q: expected %s", value.describe(), name, expected)
+	}
+
+	if value.function != "" && field.kind != queryFieldUser && field.kind != queryFieldDate {
+		return "", invalid("a literal value")
+	}
+
+	switch field.kind {
+	case queryFieldEnum:
+		// 不区分大小写，参数使用定义中的原始写法
+		for _, v := range field.values {
+			if strings.EqualFold(v, value.text) {
+				return p.arg(v), nil
+			}
+		}
+		return "", invalid("one of " + strings.Join(field.values, ", "))
+
+	case queryFieldString, queryFieldText:
+		return p.arg(value.text), nil
+
+	case queryFieldUser:
+		if value.function != "" {
+			if value.function != "currentuser" {
+				return "", invalid("a user ID or currentUser()")
+			}
+			return p.arg(p.qc.UserID), nil
+		}
+		id, err := uuid.Parse(value.text)
+		if err != nil {
+			return "", invalid("a user ID or currentUser()")
+		}
+		return p.arg(id), nil
+
+	case queryFieldRef:
+		id, err := uuid.Parse(value.text)
+		if err != nil {
+			return "", invalid("an ID")
+		}
+		return p.arg(id), nil
+
+	case queryFieldNumber:
+		n, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return "", invalid("a number")
+		}
+		return p.arg(n), nil
+
+	case queryFieldDate:
+		return p.dateOperand(value, invalid)
+	}
+
+	return "", invalid("a supported value")
+}
+
+var relativeDatePattern = regexp.MustCompile(`^([-+]?)(\d+)([wdhm])$`)
+
+var absoluteDateLayouts = []string{"2006-01-02", "2006-01-02 15:04", "2006/01/02", time.RFC3339}
+
+func (p *taskQueryParser) dateOperand(value queryValue, invalid func(string) error) (string, error) {
+	now := p.qc.Now
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if value.function != "" {
+		switch value.function {
+		case "now":
+			return p.arg(now), nil
+		case "startofday":
+			return p.arg(startOfDay), nil
+		case "endofday":
+			return p.arg(startOfDay.AddDate(0, 0, 1)), nil
+		case "startofweek":
+			return p.arg(startOfWeek(startOfDay)), nil
+		case "endofweek":
+			return p.arg(startOfWeek(startOfDay).AddDate(0, 0, 7)), nil
+		case "startofmonth":
+			return p.arg(startOfDay.AddDate(0, 0, 1-startOfDay.Day())), nil
+		case "endofmonth":
+			return p.arg(startOfDay.AddDate(0, 1, 1-startOfDay.Day())), nil
+		case "startofsprint", "endofsprint":
+			// 任务所在项目当前进行中Sprint的起止时间，没有进行中的Sprint时条件不匹配
+			column := "s.start_date"
+			if value.function == "endofsprint" {
+				column = "s.end_date"
+			}
+			return "(SELECT MAX(" + column + ") FROM sprints s WHERE s.project_id = agile_tasks.project_id AND s.deleted_at IS NULL AND s.status = " +
+				p.arg(SprintStatusActive) + ")", nil
+		}
+		return "", invalid("a date, a relative date like -7d, or a date function")
+	}
+
+	if m := relativeDatePattern.FindStringSubmatch(strings.ToLower(value.text)); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "-" {
+			n = -n
+		}
+		unit := map[string]time.Duration{"w": 7 * 24 * time.Hour, "d": 24 * time.Hour, "h": time.Hour, "m": time.Minute}[m[3]]
+		return p.arg(now.Add(time.Duration(n) * unit)), nil
+	}
+
+	for _, layout := range absoluteDateLayouts {
+		if t, err := time.ParseInLocation(layout, value.text, now.Location()); err == nil {
+			return p.arg(t), nil
+		}
+	}
+
+	return "", invalid("a date, a relative date like -7d, or a date function")
+}
+
+// startOfWeek 返回所在周的周一
+func startOfWeek(day time.Time) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func (p *taskQueryParser) parseOrderBy() (string, error) {
+	var orders []string
+	for {
+		tok := p.next()
+		if tok.kind != queryTokenWord {
+			return "", p.errorf(tok, "expected field name, got %s", tok.describe())
+		}
+
+		name := strings.ToLower(tok.text)
+		expr, ok := taskQuerySortOnly[name]
+		if !ok {
+			field, known := p.lookupField(name)
+			if !known {
+				return "", p.errorf(tok, "unknown field %q", tok.text)
+			}
+			if field.noSort {
+				return "", p.errorf(tok, "field %q cannot be used in ORDER BY", tok.text)
+			}
+			expr = field.column()
+			if field.order != "" {
+				expr = field.order
+			}
+		}
+
+		direction := "ASC"
+		if next := p.peek(); next.keyword("asc") || next.keyword("desc") {
+			p.next()
+			direction = strings.ToUpper(next.text)
+		}
+		orders = append(orders, expr+" "+direction)
+
+		if p.peek().kind != queryTokenComma {
+			return strings.Join(orders, ", "), nil
+		}
+		p.next()
+	}
+}
+
+// escapeLikePattern 转义 LIKE 模式中的通配符
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testQueryContext() TaskQueryContext {
+	// 2024-05-15 是周三
+	return TaskQueryContext{
+		UserID: uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		Now:    time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestCompileTaskQuery(t *testing.T) {
+	qc := testQueryContext()
+	sprintID := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+
+	tests := []struct {
+		name  string
+		query string
+		where string
+		args  []interface{}
+		order string
+	}{
+		{
+			name:  "简单比较",
+			query: "status = in_progress",
+			where: "agile_tasks.status = ?",
+			args:  []interface{}{"in_progress"},
+		},
+		{
+			name:  "关键字和枚举不区分大小写",
+			query: "Type = BUG and priority != Low",
+			where: "(agile_tasks.type = ? AND agile_tasks.priority <> ?)",
+			args:  []interface{}{"bug", "low"},
+		},
+		{
+			name:  "IN列表和当前用户",
+			query: "assignee IN (currentUser(), EMPTY)",
+			where: "(agile_tasks.assignee_id IN (?) OR agile_tasks.assignee_id IS NULL)",
+			args:  []interface{}{qc.UserID},
+		},
+		{
+			name:  "NOT IN",
+			query: "sprint NOT IN (" + sprintID.String() + ")",
+			where: "NOT COALESCE(agile_tasks.sprint_id IN (?), false)",
+			args:  []interface{}{sprintID},
+		},
+		{
+			name:  "开放Sprint子查询",
+			query: "sprint in openSprints()",
+			where: "agile_tasks.sprint_id IN (SELECT s.id FROM sprints s WHERE s.project_id = agile_tasks.project_id AND s.deleted_at IS NULL AND s.status IN ?)",
+			args:  []interface{}{[]string{SprintStatusPlanned, SprintStatusActive}},
+		},
+		{
+			name:  "为空判断",
+			query: "resolution IS EMPTY OR labels is not empty",
+			where: "((agile_tasks.resolution IS NULL OR agile_tasks.resolution = '') OR NOT (agile_tasks.labels IS NULL OR agile_tasks.labels = 'null'::jsonb OR agile_tasks.labels = '[]'::jsonb))",
+		},
+		{
+			name:  "等于EMPTY",
+			query: "epic = EMPTY",
+			where: "agile_tasks.epic_id IS NULL",
+		},
+		{
+			name:  "标签和组件匹配",
+			query: `labels = "front end" AND component IN (api, db) AND label != legacy`,
+			where: "(agile_tasks.labels @> ?::jsonb AND (agile_tasks.components @> ?::jsonb OR agile_tasks.components @> ?::jsonb) AND NOT COALESCE(agile_tasks.labels @> ?::jsonb, false))",
+			args:  []interface{}{`["front end"]`, `["api"]`, `["db"]`, `["legacy"]`},
+		},
+		{
+			name:  "文本包含并转义通配符",
+			query: `text ~ "100%_done"`,
+			where: "(agile_tasks.title ILIKE ? OR agile_tasks.description ILIKE ?)",
+			args:  []interface{}{`%100\%\_done%`, `%100\%\_done%`},
+		},
+		{
+			name:  "相对日期",
+			query: "created >= -7d AND updated < +2h",
+			where: "(agile_tasks.created_at >= ? AND agile_tasks.updated_at < ?)",
+			args:  []interface{}{qc.Now.AddDate(0, 0, -7), qc.Now.Add(2 * time.Hour)},
+		},
+		{
+			name:  "日期函数",
+			query: "created >= startOfWeek() AND resolved < '2024-06-01'",
+			where: "(agile_tasks.created_at >= ? AND agile_tasks.resolved_at < ?)",
+			args:  []interface{}{time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:  "Sprint开始时间",
+			query: "resolved >= startOfSprint()",
+			where: "agile_tasks.resolved_at >= (SELECT MAX(s.start_date) FROM sprints s WHERE s.project_id = agile_tasks.project_id AND s.deleted_at IS NULL AND s.status = ?)",
+			args:  []interface{}{SprintStatusActive},
+		},
+		{
+			name:  "NOT和括号优先级",
+			query: "NOT (status = done OR status = cancelled) AND points > 3",
+			where: "(NOT COALESCE((agile_tasks.status = ? OR agile_tasks.status = ?), false) AND agile_tasks.story_points > ?)",
+			args:  []interface{}{"done", "cancelled", float64(3)},
+		},
+		{
+			name:  "排序",
+			query: "type = bug ORDER BY priority DESC, created",
+			where: "agile_tasks.type = ?",
+			args:  []interface{}{"bug"},
+			order: priorityOrder + " DESC, agile_tasks.created_at ASC",
+		},
+		{
+			name:  "只有排序",
+			query: "order by rank",
+			order: "agile_tasks.rank ASC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compiled, err := CompileTaskQuery(tt.query, qc)
+			require.NoError(t, err)
+			assert.Equal(t, tt.where, compiled.Where)
+			assert.Equal(t, tt.args, compiled.Args)
+			assert.Equal(t, tt.order, compiled.OrderBy)
+			assert.Equal(t, strings.Count(compiled.Where, "?"), len(compiled.Args))
+		})
+	}
+}
+
+func TestCompileTaskQueryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"未知字段", "owner = x"},
+		{"不支持的操作符", "created = 2024-01-01"},
+		{"枚举值不合法", "priority = urgent"},
+		{"ID不合法", "epic = abc"},
+		{"文本字段不支持IN", "title IN (a)"},
+		{"缺少右括号", "(status = todo"},
+		{"未闭合字符串", `title ~ "abc`},
+		{"多余内容", "status = todo done"},
+		{"未知函数", "assignee = nobody()"},
+		{"非Sprint字段使用集合函数", "epic IN openSprints()"},
+		{"IN缺少列表", "status IN todo"},
+		{"不能排序的字段", "ORDER BY labels"},
+		{"缺少BY", "status = todo ORDER priority"},
+		{"注入尝试", "status = todo; DROP TABLE agile_tasks"},
+		{"过长", strings.Repeat("a", MaxTaskQueryLength+1)},
+		{"条件过多", strings.TrimSuffix(strings.Repeat("status = todo OR ", maxQueryClauses+1), " OR ")},
+		{"嵌套过深", strings.Repeat("(", maxQueryDepth+1) + "status = todo" + strings.Repeat(")", maxQueryDepth+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileTaskQuery(tt.query, testQueryContext())
+			assert.ErrorIs(t, err, ErrInvalidTaskQuery)
+		})
+	}
+}
+
+func TestCompileTaskQueryEmpty(t *testing.T) {
+	compiled, err := CompileTaskQuery("   ", testQueryContext())
+	require.NoError(t, err)
+	assert.Empty(t, compiled.Where)
+	assert.Empty(t, compiled.OrderBy)
+}
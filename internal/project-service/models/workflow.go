@@ -0,0 +1,368 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 工作流错误
+var (
+	ErrInvalidWorkflow           = errors.New("invalid workflow")
+	ErrWorkflowNotFound          = errors.New("workflow not found")
+	ErrTransitionNotAllowed      = errors.New("invalid status transition")
+	ErrTransitionConditionFailed = errors.New("transition condition not met")
+	ErrWorkflowManageDenied      = errors.New("only project admins and managers can change workflows")
+)
+
+// 状态分类，决定状态在统计和看板中的含义
+const (
+	StatusCategoryTodo       = "todo"
+	StatusCategoryInProgress = "in_progress"
+	StatusCategoryDone       = "done"
+)
+
+// 转换条件类型
+const (
+	ConditionAssigneeOnly       = "assignee_only"                 // 只有经办人可以执行
+	ConditionAcceptanceComplete = "acceptance_criteria_completed" // 验收标准全部完成
+	ConditionPullRequestMerged  = "pull_request_merged"           // 关联的PR已合并
+	ConditionNotBlocked         = "not_blocked"                   // 没有未解决的阻塞任务
+)
+
+// 转换后置动作类型
+const (
+	PostFunctionSetResolution   = "set_resolution"   // 设置解决结果，Value为结果名称
+	PostFunctionClearResolution = "clear_resolution" // 清除解决结果
+	PostFunctionClearAssignee   = "clear_assignee"   // 清除经办人
+	PostFunctionAssignToActor   = "assign_to_actor"  // 分配给执行转换的用户
+	PostFunctionNotify          = "notify"           // 发送站内通知，Value为逗号分隔的接收人
+)
+
+// 通知接收人
+const (
+	NotifyAssignee = "assignee"
+	NotifyReporter = "reporter"
+)
+
+// 工作流限制
+const (
+	WorkflowMaxStatuses    = 50
+	WorkflowMaxTransitions = 200
+)
+
+var workflowStatusKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// Workflow 任务工作流，按项目和任务类型配置
+type Workflow struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProjectID   uuid.UUID `json:"project_id" gorm:"type:uuid;not null;index"`
+	Name        string    `json:"name" gorm:"size:255;not null"`
+	Description *string   `json:"description" gorm:"type:text"`
+
+	// 适用的任务类型，为空时作为项目的默认工作流
+	TaskTypes     []string             `json:"task_types" gorm:"type:jsonb;serializer:json"`
+	InitialStatus string               `json:"initial_status" gorm:"size:50;not null"`
+	Statuses      []WorkflowStatus     `json:"statuses" gorm:"type:jsonb;serializer:json"`
+	Transitions   []WorkflowTransition `json:"transitions" gorm:"type:jsonb;serializer:json"`
+
+	// 审计字段
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt *time.Time `json:"deleted_at" gorm:"index"`
+	CreatedBy *uuid.UUID `json:"created_by" gorm:"type:uuid"`
+}
+
+// WorkflowStatus 工作流中的状态
+type WorkflowStatus struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+// WorkflowTransition 状态转换，From为空表示可以从任意状态转换
+type WorkflowTransition struct {
+	Name          string                `json:"name"`
+	From          []string              `json:"from"`
+	To            string                `json:"to"`
+	Conditions    []TransitionCondition `json:"conditions,omitempty"`
+	PostFunctions []PostFunction        `json:"post_functions,omitempty"`
+}
+
+// TransitionCondition 转换条件
+type TransitionCondition struct {
+	Type string `json:"type"`
+}
+
+// PostFunction 转换成功后执行的动作
+type PostFunction struct {
+	Type  string `json:"type"`
+	Value string `json:"value,omitempty"`
+}
+
+func (w *Workflow) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+func (Workflow) TableName() string {
+	return "task_workflows"
+}
+
+// DefaultWorkflow 未配置工作流的项目使用的内置工作流，被阻塞的任务不能开始或完成
+func DefaultWorkflow() *Workflow {
+	notBlocked := []TransitionCondition{{Type: ConditionNotBlocked}}
+	return &Workflow{
+		Name:          "Default",
+		InitialStatus: TaskStatusTodo,
+		Statuses: []WorkflowStatus{
+			{Key: TaskStatusTodo, Name: "To Do", Category: StatusCategoryTodo},
+			{Key: TaskStatusInProgress, Name: "In Progress", Category: StatusCategoryInProgress},
+			{Key: TaskStatusInReview, Name: "In Review", Category: StatusCategoryInProgress},
+			{Key: TaskStatusTesting, Name: "Testing", Category: StatusCategoryInProgress},
+			{Key: TaskStatusDone, Name: "Done", Category: StatusCategoryDone},
+			{Key: TaskStatusCancelled, Name: "Cancelled", Category: StatusCategoryDone},
+		},
+		Transitions: []WorkflowTransition{
+			{Name: "Start", From: []string{TaskStatusTodo, TaskStatusInReview, TaskStatusTesting, TaskStatusDone}, To: TaskStatusInProgress, Conditions: notBlocked},
+			{Name: "Stop", From: []string{TaskStatusInProgress, TaskStatusCancelled}, To: TaskStatusTodo},
+			{Name: "Review", From: []string{TaskStatusInProgress, TaskStatusTesting}, To: TaskStatusInReview},
+			{Name: "Test", From: []string{TaskStatusInProgress, TaskStatusInReview}, To: TaskStatusTesting},
+			{Name: "Done", From: []string{TaskStatusInProgress, TaskStatusInReview, TaskStatusTesting}, To: TaskStatusDone, Conditions: notBlocked},
+			{Name: "Cancel", From: []string{TaskStatusTodo, TaskStatusInProgress}, To: TaskStatusCancelled},
+		},
+	}
+}
+
+// Validate 校验工作流定义
+func (w *Workflow) Validate() error {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidWorkflow)
+	}
+	if len(w.Statuses) == 0 {
+		return fmt.Errorf("%w: at least one status is required", ErrInvalidWorkflow)
+	}
+	if len(w.Statuses) > WorkflowMaxStatuses {
+		return fmt.Errorf("%w: at most %d statuses are allowed", ErrInvalidWorkflow, WorkflowMaxStatuses)
+	}
+	if len(w.Transitions) > WorkflowMaxTransitions {
+		return fmt.Errorf("%w: at most %d transitions are allowed", ErrInvalidWorkflow, WorkflowMaxTransitions)
+	}
+
+	types := make(map[string]bool, len(w.TaskTypes))
+	for _, taskType := range w.TaskTypes {
+		switch taskType {
+		case TaskTypeStory, TaskTypeTask, TaskTypeBug, TaskTypeEpic, TaskTypeSubTask:
+		default:
+			return fmt.Errorf("%w: unknown task type %q", ErrInvalidWorkflow, taskType)
+		}
+		if types[taskType] {
+			return fmt.Errorf("%w: duplicate task type %q", ErrInvalidWorkflow, taskType)
+		}
+		types[taskType] = true
+	}
+
+	statuses := make(map[string]bool, len(w.Statuses))
+	for i := range w.Statuses {
+		status := &w.Statuses[i]
+		if !workflowStatusKeyPattern.MatchString(status.Key) {
+			return fmt.Errorf("%w: invalid status key %q", ErrInvalidWorkflow, status.Key)
+		}
+		if statuses[status.Key] {
+			return fmt.Errorf("%w: duplicate status %q", ErrInvalidWorkflow, status.Key)
+		}
+		statuses[status.Key] = true
+
+		status.Name = strings.TrimSpace(status.Name)
+		if status.Name == "" {
+			status.Name = status.Key
+		}
+		switch status.Category {
+		case StatusCategoryTodo, StatusCategoryInProgress, StatusCategoryDone:
+		case "":
+			status.Category = StatusCategoryInProgress
+		default:
+			return fmt.Errorf("%w: unknown category %q for status %q", ErrInvalidWorkflow, status.Category, status.Key)
+		}
+	}
+
+	if w.InitialStatus == "" {
+		w.InitialStatus = w.Statuses[0].Key
+	}
+	if !statuses[w.InitialStatus] {
+		return fmt.Errorf("%w: unknown initial status %q", ErrInvalidWorkflow, w.InitialStatus)
+	}
+
+	for i := range w.Transitions {
+		if err := w.Transitions[i].validate(statuses); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate 校验单个转换
+func (t *WorkflowTransition) validate(statuses map[string]bool) error {
+	if !statuses[t.To] {
+		return fmt.Errorf("%w: transition to unknown status %q", ErrInvalidWorkflow, t.To)
+	}
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		t.Name = t.To
+	}
+	for _, from := range t.From {
+		if !statuses[from] {
+			return fmt.Errorf("%w: transition %q from unknown status %q", ErrInvalidWorkflow, t.Name, from)
+		}
+		if from == t.To {
+			return fmt.Errorf("%w: transition %q starts and ends at %q", ErrInvalidWorkflow, t.Name, from)
+		}
+	}
+
+	for _, condition := range t.Conditions {
+		switch condition.Type {
+		case ConditionAssigneeOnly, ConditionAcceptanceComplete, ConditionPullRequestMerged, ConditionNotBlocked:
+		default:
+			return fmt.Errorf("%w: unknown condition %q in transition %q", ErrInvalidWorkflow, condition.Type, t.Name)
+		}
+	}
+
+	for i := range t.PostFunctions {
+		fn := &t.PostFunctions[i]
+		fn.Value = strings.TrimSpace(fn.Value)
+		switch fn.Type {
+		case PostFunctionSetResolution:
+			if fn.Value == "" || len(fn.Value) > 50 {
+				return fmt.Errorf("%w: set_resolution in transition %q requires a resolution of at most 50 characters", ErrInvalidWorkflow, t.Name)
+			}
+		case PostFunctionNotify:
+			if _, err := fn.NotifyTargets(); err != nil {
+				return fmt.Errorf("%w: transition %q: %v", ErrInvalidWorkflow, t.Name, err)
+			}
+		case PostFunctionClearResolution, PostFunctionClearAssignee, PostFunctionAssignToActor:
+		default:
+			return fmt.Errorf("%w: unknown post function %q in transition %q", ErrInvalidWorkflow, fn.Type, t.Name)
+		}
+	}
+	return nil
+}
+
+// NotifyTargets 解析通知动作的接收人，未指定时通知经办人和报告人
+func (fn *PostFunction) NotifyTargets() ([]string, error) {
+	if fn.Value == "" {
+		return []string{NotifyAssignee, NotifyReporter}, nil
+	}
+
+	var targets []string
+	for _, target := range strings.Split(fn.Value, ",") {
+		target = strings.TrimSpace(target)
+		switch target {
+		case NotifyAssignee, NotifyReporter:
+			targets = append(targets, target)
+		default:
+			return nil, fmt.Errorf("unknown notify target %q", target)
+		}
+	}
+	return targets, nil
+}
+
+// AppliesTo 工作流是否适用于指定任务类型
+func (w *Workflow) AppliesTo(taskType string) bool {
+	for _, t := range w.TaskTypes {
+		if t == taskType {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDefault 是否为项目默认工作流
+func (w *Workflow) IsDefault() bool {
+	return len(w.TaskTypes) == 0
+}
+
+// Overlaps 检查两个工作流是否覆盖相同的任务类型，返回冲突的类型，默认工作流之间以空字符串表示
+func (w *Workflow) Overlaps(other *Workflow) (string, bool) {
+	if w.IsDefault() && other.IsDefault() {
+		return "", true
+	}
+	for _, t := range w.TaskTypes {
+		if other.AppliesTo(t) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// HasStatus 工作流是否包含指定状态
+func (w *Workflow) HasStatus(key string) bool {
+	return w.Status(key) != nil
+}
+
+// Status 获取状态定义
+func (w *Workflow) Status(key string) *WorkflowStatus {
+	for i := range w.Statuses {
+		if w.Statuses[i].Key == key {
+			return &w.Statuses[i]
+		}
+	}
+	return nil
+}
+
+// FindTransition 查找从 from 到 to 的转换
+func (w *Workflow) FindTransition(from, to string) *WorkflowTransition {
+	for i := range w.Transitions {
+		if w.Transitions[i].To == to && w.Transitions[i].allowsFrom(from) {
+			return &w.Transitions[i]
+		}
+	}
+	return nil
+}
+
+// AvailableTransitions 获取从指定状态出发的所有转换
+func (w *Workflow) AvailableTransitions(from string) []WorkflowTransition {
+	var transitions []WorkflowTransition
+	for _, t := range w.Transitions {
+		if t.To != from && t.allowsFrom(from) {
+			transitions = append(transitions, t)
+		}
+	}
+	return transitions
+}
+
+func (t *WorkflowTransition) allowsFrom(status string) bool {
+	if len(t.From) == 0 {
+		return true
+	}
+	for _, from := range t.From {
+		if from == status {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectWorkflow 按任务类型选择工作流：先匹配类型专用工作流，再使用项目默认工作流，都没有时使用内置工作流
+func SelectWorkflow(workflows []Workflow, taskType string) *Workflow {
+	var fallback *Workflow
+	for i := range workflows {
+		if workflows[i].AppliesTo(taskType) {
+			return &workflows[i]
+		}
+		if workflows[i].IsDefault() && fallback == nil {
+			fallback = &workflows[i]
+		}
+	}
+	if fallback != nil {
+		return fallback
+	}
+	return DefaultWorkflow()
+}
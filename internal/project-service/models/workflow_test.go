@@ -0,0 +1,141 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bugWorkflow() *Workflow {
+	return &Workflow{
+		Name:      "Bug",
+		TaskTypes: []string{TaskTypeBug},
+		Statuses: []WorkflowStatus{
+			{Key: "open", Name: "Open", Category: StatusCategoryTodo},
+			{Key: "fixing"},
+			{Key: "verified", Name: "Verified", Category: StatusCategoryDone},
+		},
+		Transitions: []WorkflowTransition{
+			{Name: "Fix", From: []string{"open"}, To: "fixing", PostFunctions: []PostFunction{{Type: PostFunctionAssignToActor}}},
+			{
+				Name:          "Verify",
+				From:          []string{"fixing"},
+				To:            "verified",
+				Conditions:    []TransitionCondition{{Type: ConditionPullRequestMerged}},
+				PostFunctions: []PostFunction{{Type: PostFunctionSetResolution, Value: "fixed"}, {Type: PostFunctionNotify, Value: "reporter"}},
+			},
+			{Name: "Reopen", To: "open", PostFunctions: []PostFunction{{Type: PostFunctionClearResolution}}},
+		},
+	}
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	w := bugWorkflow()
+	require.NoError(t, w.Validate())
+	assert.Equal(t, "open", w.InitialStatus)
+	assert.Equal(t, "fixing", w.Statuses[1].Name)
+	assert.Equal(t, StatusCategoryInProgress, w.Statuses[1].Category)
+
+	tests := []struct {
+		name   string
+		modify func(w *Workflow)
+	}{
+		{"缺少名称", func(w *Workflow) { w.Name = " " }},
+		{"未知任务类型", func(w *Workflow) { w.TaskTypes = []string{"incident"} }},
+		{"状态键不合法", func(w *Workflow) { w.Statuses[0].Key = "In Progress" }},
+		{"状态重复", func(w *Workflow) { w.Statuses[1].Key = "open" }},
+		{"未知分类", func(w *Workflow) { w.Statuses[0].Category = "blocked" }},
+		{"初始状态不存在", func(w *Workflow) { w.InitialStatus = "new" }},
+		{"转换目标不存在", func(w *Workflow) { w.Transitions[0].To = "closed" }},
+		{"转换起点不存在", func(w *Workflow) { w.Transitions[0].From = []string{"closed"} }},
+		{"起点与终点相同", func(w *Workflow) { w.Transitions[0].From = []string{"fixing"} }},
+		{"未知条件", func(w *Workflow) { w.Transitions[0].Conditions = []TransitionCondition{{Type: "approved"}} }},
+		{"解决结果为空", func(w *Workflow) { w.Transitions[1].PostFunctions[0].Value = "" }},
+		{"未知通知对象", func(w *Workflow) { w.Transitions[1].PostFunctions[1].Value = "watchers" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := bugWorkflow()
+			tt.modify(w)
+			assert.ErrorIs(t, w.Validate(), ErrInvalidWorkflow)
+		})
+	}
+}
+
+func TestWorkflowTransitions(t *testing.T) {
+	w := bugWorkflow()
+	require.NoError(t, w.Validate())
+
+	assert.NotNil(t, w.FindTransition("open", "fixing"))
+	assert.Nil(t, w.FindTransition("open", "verified"))
+	// 没有起点限制的转换可以从任意状态执行
+	assert.Equal(t, "Reopen", w.FindTransition("verified", "open").Name)
+
+	var names []string
+	for _, transition := range w.AvailableTransitions("open") {
+		names = append(names, transition.Name)
+	}
+	assert.Equal(t, []string{"Fix"}, names)
+}
+
+func TestDefaultWorkflowMatchesBuiltinTransitions(t *testing.T) {
+	w := DefaultWorkflow()
+	require.NoError(t, w.Validate())
+
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{TaskStatusTodo, TaskStatusInProgress, true},
+		{TaskStatusTodo, TaskStatusDone, false},
+		{TaskStatusInReview, TaskStatusDone, true},
+		{TaskStatusDone, TaskStatusInProgress, true},
+		{TaskStatusDone, TaskStatusTodo, false},
+		{TaskStatusCancelled, TaskStatusTodo, true},
+	}
+	for _, tt := range tests {
+		task := AgileTask{Status: tt.from}
+		assert.Equal(t, tt.want, task.CanTransitionTo(tt.to), "%s -> %s", tt.from, tt.to)
+	}
+
+	// 被阻塞的任务不能开始或完成
+	for _, to := range []string{TaskStatusInProgress, TaskStatusDone} {
+		transition := w.FindTransition(TaskStatusInReview, to)
+		require.NotNil(t, transition)
+		assert.Contains(t, transition.Conditions, TransitionCondition{Type: ConditionNotBlocked}, to)
+	}
+}
+
+func TestSelectWorkflow(t *testing.T) {
+	bug := *bugWorkflow()
+	projectDefault := Workflow{Name: "Project"}
+
+	assert.Equal(t, "Bug", SelectWorkflow([]Workflow{projectDefault, bug}, TaskTypeBug).Name)
+	assert.Equal(t, "Project", SelectWorkflow([]Workflow{projectDefault, bug}, TaskTypeStory).Name)
+	assert.Equal(t, "Default", SelectWorkflow([]Workflow{bug}, TaskTypeStory).Name)
+
+	_, ok := bug.Overlaps(&Workflow{TaskTypes: []string{TaskTypeTask, TaskTypeBug}})
+	assert.True(t, ok)
+	_, ok = projectDefault.Overlaps(&bug)
+	assert.False(t, ok)
+	_, ok = projectDefault.Overlaps(&Workflow{})
+	assert.True(t, ok)
+}
+
+func TestAgileTaskHelpers(t *testing.T) {
+	task := AgileTask{AcceptanceCriteria: []AcceptanceCriteria{{Completed: true}, {Completed: false}}}
+	assert.False(t, task.AcceptanceCriteriaCompleted())
+	task.AcceptanceCriteria[1].Completed = true
+	assert.True(t, task.AcceptanceCriteriaCompleted())
+	assert.True(t, (&AgileTask{ID: uuid.New()}).AcceptanceCriteriaCompleted())
+
+	assert.Equal(t, "PROJ-42", TaskKey("proj", 42))
+
+	fn := PostFunction{Type: PostFunctionNotify}
+	targets, err := fn.NotifyTargets()
+	require.NoError(t, err)
+	assert.Equal(t, []string{NotifyAssignee, NotifyReporter}, targets)
+}
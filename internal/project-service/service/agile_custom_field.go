@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 任务自定义字段。
+// 字段定义属于项目，取值以字段键保存在任务的 custom_fields 中。键和类型创建后不可修改，
+// 否则已保存的取值和引用 cf.<key> 的过滤器都会失效。
+
+func (s *agileServiceImpl) CreateCustomField(ctx context.Context, req *CreateCustomFieldRequest, userID, tenantID uuid.UUID) (*models.CustomField, error) {
+	if err := s.checkProjectAccess(ctx, req.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	field := &models.CustomField{
+		ProjectID:     req.ProjectID,
+		Key:           req.Key,
+		Name:          req.Name,
+		Description:   req.Description,
+		Type:          req.Type,
+		Options:       req.Options,
+		TaskTypes:     req.TaskTypes,
+		RequiredTypes: req.RequiredTypes,
+		DefaultValue:  req.DefaultValue,
+		ShowOnCard:    req.ShowOnCard,
+		CreatedBy:     &userID,
+	}
+	if err := field.Validate(); err != nil {
+		return nil, err
+	}
+
+	fields, err := s.projectCustomFields(ctx, req.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) >= models.MaxCustomFieldsPerProject {
+		return nil, fmt.Errorf("%w: a project can have at most %d custom fields", models.ErrInvalidCustomField, models.MaxCustomFieldsPerProject)
+	}
+	for _, existing := range fields {
+		if existing.Key == field.Key {
+			return nil, fmt.Errorf("%w: key %q is already used", models.ErrInvalidCustomField, field.Key)
+		}
+	}
+	field.Position = len(fields)
+	if req.Position != nil {
+		field.Position = *req.Position
+	}
+
+	if err := s.db.WithContext(ctx).Create(field).Error; err != nil {
+		return nil, fmt.Errorf("failed to create custom field: %w", err)
+	}
+
+	s.logger.Info("创建自定义字段",
+		zap.String("project_id", req.ProjectID.String()),
+		zap.String("field_id", field.ID.String()),
+		zap.String("key", field.Key),
+		zap.String("type", field.Type))
+	return field, nil
+}
+
+// ListCustomFields 获取项目的自定义字段，按位置排序
+func (s *agileServiceImpl) ListCustomFields(ctx context.Context, projectID uuid.UUID, userID, tenantID uuid.UUID) ([]models.CustomField, error) {
+	if err := s.checkProjectAccess(ctx, projectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+	return s.projectCustomFields(ctx, projectID)
+}
+
+func (s *agileServiceImpl) UpdateCustomField(ctx context.Context, fieldID uuid.UUID, req *UpdateCustomFieldRequest, userID, tenantID uuid.UUID) (*models.CustomField, error) {
+	field, err := s.getCustomField(ctx, fieldID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		field.Name = *req.Name
+	}
+	if req.Description != nil {
+		field.Description = req.Description
+	}
+	if req.Options != nil {
+		field.Options = *req.Options
+	}
+	if req.TaskTypes != nil {
+		field.TaskTypes = *req.TaskTypes
+	}
+	if req.RequiredTypes != nil {
+		field.RequiredTypes = *req.RequiredTypes
+	}
+	if req.DefaultValue != nil {
+		var value interface{}
+		if err := json.Unmarshal(req.DefaultValue, &value); err != nil {
+			return nil, fmt.Errorf("%w: invalid default value", models.ErrInvalidCustomField)
+		}
+		field.DefaultValue = value
+	}
+	if req.ShowOnCard != nil {
+		field.ShowOnCard = *req.ShowOnCard
+	}
+	if req.Position != nil {
+		field.Position = *req.Position
+	}
+	// 移除的可选项不影响已有任务的取值，这些任务再次修改该字段时需要选择新的选项
+	if err := field.Validate(); err != nil {
+		return nil, err
+	}
+
+	// 结构体更新才会经过jsonb序列化
+	if err := s.db.WithContext(ctx).Model(field).
+		Select("name", "description", "options", "task_types", "required_types", "default_value", "show_on_card", "position").
+		Updates(field).Error; err != nil {
+		return nil, fmt.Errorf("failed to update custom field: %w", err)
+	}
+	return field, nil
+}
+
+// DeleteCustomField 删除字段定义并清除任务上的取值，之后可以重新使用该键。
+// 被清除取值的任务各记录一条 cf.<key> 变更，与清除在同一事务中完成
+func (s *agileServiceImpl) DeleteCustomField(ctx context.Context, fieldID uuid.UUID, userID, tenantID uuid.UUID) error {
+	field, err := s.getCustomField(ctx, fieldID, userID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(field).Update("deleted_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("failed to delete custom field: %w", err)
+		}
+
+		// 锁定有取值的任务，记录的旧值与清除的取值一致
+		var tasks []models.AgileTask
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "project_id", "custom_fields").
+			Where("project_id = ? AND custom_fields -> ? IS NOT NULL", field.ProjectID, field.Key).
+			Find(&tasks).Error; err != nil {
+			return fmt.Errorf("failed to load custom field values: %w", err)
+		}
+		if len(tasks) == 0 {
+			return nil
+		}
+
+		taskIDs := make([]uuid.UUID, 0, len(tasks))
+		for _, task := range tasks {
+			taskIDs = append(taskIDs, task.ID)
+		}
+		if err := tx.Model(&models.AgileTask{}).
+			Where("id IN ?", taskIDs).
+			Update("custom_fields", gorm.Expr("custom_fields - ?", field.Key)).Error; err != nil {
+			return fmt.Errorf("failed to clear custom field values: %w", err)
+		}
+
+		changes := models.NewCustomFieldRemoval(tasks, field.Key, userID)
+		if len(changes) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(&changes, 500).Error; err != nil {
+			return fmt.Errorf("failed to record task changes: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *agileServiceImpl) getCustomField(ctx context.Context, fieldID uuid.UUID, userID, tenantID uuid.UUID) (*models.CustomField, error) {
+	var field models.CustomField
+	if err := s.db.WithContext(ctx).First(&field, "id = ? AND deleted_at IS NULL", fieldID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrCustomFieldNotFound
+		}
+		return nil, fmt.Errorf("failed to get custom field: %w", err)
+	}
+	if err := s.checkProjectAccess(ctx, field.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+// projectCustomFields 加载项目的自定义字段定义，不检查访问权限
+func (s *agileServiceImpl) projectCustomFields(ctx context.Context, projectID uuid.UUID) ([]models.CustomField, error) {
+	var fields []models.CustomField
+	if err := s.db.WithContext(ctx).
+		Where("project_id = ? AND deleted_at IS NULL", projectID).
+		Order("position ASC, created_at ASC").
+		Find(&fields).Error; err != nil {
+		return nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	return fields, nil
+}
+
+// resolveTaskCustomFields 校验任务的自定义字段取值，创建时填充默认值并检查必填字段
+func (s *agileServiceImpl) resolveTaskCustomFields(ctx context.Context, task *models.AgileTask, changes map[string]interface{}, creating bool) (map[string]interface{}, error) {
+	fields, err := s.projectCustomFields(ctx, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return models.ResolveCustomFieldValues(fields, task.Type, task.CustomFields, changes, creating)
+}
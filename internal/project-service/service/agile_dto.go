@@ -1,6 +1,7 @@
 package service
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
@@ -56,13 +57,14 @@ type CreateTaskRequest struct {
 	Labels             []string                    `json:"labels,omitempty"`
 	Components         []string                    `json:"components,omitempty"`
 	AcceptanceCriteria []models.AcceptanceCriteria `json:"acceptance_criteria,omitempty"`
+	CustomFields       map[string]interface{}      `json:"custom_fields,omitempty"` // 自定义字段取值，缺失的字段使用默认值
 }
 
 // UpdateTaskRequest 更新任务请求
 type UpdateTaskRequest struct {
 	Title              *string                     `json:"title,omitempty" binding:"omitempty,min=1,max=500"`
 	Description        *string                     `json:"description,omitempty"`
-	Status             *string                     `json:"status,omitempty" binding:"omitempty,oneof=todo in_progress in_review testing done cancelled"`
+	Status             *string                     `json:"status,omitempty" binding:"omitempty,min=1,max=50"` // 可用状态由工作流决定
 	Priority           *string                     `json:"priority,omitempty" binding:"omitempty,oneof=lowest low medium high highest"`
 	StoryPoints        *int                        `json:"story_points,omitempty" binding:"omitempty,min=1,max=100"`
 	OriginalEstimate   *float64                    `json:"original_estimate,omitempty" binding:"omitempty,min=0"`
@@ -73,18 +75,22 @@ type UpdateTaskRequest struct {
 	Labels             []string                    `json:"labels,omitempty"`
 	Components         []string                    `json:"components,omitempty"`
 	AcceptanceCriteria []models.AcceptanceCriteria `json:"acceptance_criteria,omitempty"`
+	CustomFields       map[string]interface{}      `json:"custom_fields,omitempty"` // 只更新出现的字段，null 表示清除
 }
 
 // TaskFilter 任务过滤器
 type TaskFilter struct {
-	ProjectID  uuid.UUID  `json:"project_id" binding:"required"`
-	SprintID   *uuid.UUID `json:"sprint_id,omitempty"`
-	EpicID     *uuid.UUID `json:"epic_id,omitempty"`
-	AssigneeID *uuid.UUID `json:"assignee_id,omitempty"`
-	Status     []string   `json:"status,omitempty"`
-	Type       []string   `json:"type,omitempty"`
-	Priority   []string   `json:"priority,omitempty"`
-	SearchText *string    `json:"search_text,omitempty"`
+	ProjectID    uuid.UUID         `json:"project_id" binding:"required"`
+	SprintID     *uuid.UUID        `json:"sprint_id,omitempty"`
+	EpicID       *uuid.UUID        `json:"epic_id,omitempty"`
+	AssigneeID   *uuid.UUID        `json:"assignee_id,omitempty"`
+	Status       []string          `json:"status,omitempty"`
+	Type         []string          `json:"type,omitempty"`
+	Priority     []string          `json:"priority,omitempty"`
+	SearchText   *string           `json:"search_text,omitempty"`
+	Query        *string           `json:"query,omitempty"`         // 任务查询语句
+	FilterID     *uuid.UUID        `json:"filter_id,omitempty"`     // 保存的过滤器
+	CustomFields map[string]string `json:"custom_fields,omitempty"` // 自定义字段等值条件，多选字段匹配包含该选项的任务
 }
 
 // TaskListResponse 任务列表响应
@@ -153,8 +159,9 @@ type CreateBoardRequest struct {
 
 // UpdateBoardRequest 更新看板请求
 type UpdateBoardRequest struct {
-	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
-	Description *string `json:"description,omitempty"`
+	Name              *string      `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Description       *string      `json:"description,omitempty"`
+	SwimlaneFilterIDs *[]uuid.UUID `json:"swimlane_filter_ids,omitempty" binding:"omitempty,max=20"`
 }
 
 // CreateBoardColumnRequest 创建看板列请求
@@ -313,18 +320,139 @@ type AddTasksToSprintRequest struct {
 
 // 工作流相关DTO
 
-// WorkflowTransition 工作流转换
-type WorkflowTransition struct {
-	FromStatus string              `json:"from_status"`
-	ToStatus   string              `json:"to_status"`
-	Name       string              `json:"name"`
-	Conditions []WorkflowCondition `json:"conditions,omitempty"`
+// CreateWorkflowRequest 创建工作流请求，task_types 为空时作为项目默认工作流
+type CreateWorkflowRequest struct {
+	ProjectID     uuid.UUID                   `json:"-"`
+	Name          string                      `json:"name" binding:"required,min=1,max=255"`
+	Description   *string                     `json:"description,omitempty"`
+	TaskTypes     []string                    `json:"task_types,omitempty"`
+	InitialStatus string                      `json:"initial_status,omitempty"`
+	Statuses      []models.WorkflowStatus     `json:"statuses" binding:"required,min=1"`
+	Transitions   []models.WorkflowTransition `json:"transitions"`
+}
+
+// UpdateWorkflowRequest 更新工作流请求，状态和转换整体替换
+type UpdateWorkflowRequest struct {
+	Name          *string                      `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Description   *string                      `json:"description,omitempty"`
+	TaskTypes     *[]string                    `json:"task_types,omitempty"`
+	InitialStatus *string                      `json:"initial_status,omitempty"`
+	Statuses      []models.WorkflowStatus      `json:"statuses,omitempty"`
+	Transitions   *[]models.WorkflowTransition `json:"transitions,omitempty"`
+}
+
+// TaskTransitionsResponse 任务可用的状态转换
+type TaskTransitionsResponse struct {
+	TaskID       uuid.UUID              `json:"task_id"`
+	Status       string                 `json:"status"`
+	WorkflowID   *uuid.UUID             `json:"workflow_id"` // 使用内置工作流时为空
+	WorkflowName string                 `json:"workflow_name"`
+	Transitions  []TaskTransitionOption `json:"transitions"`
 }
 
-// WorkflowCondition 工作流条件
-type WorkflowCondition struct {
-	Type  string      `json:"type"` // assignee_required, approval_required, etc.
-	Value interface{} `json:"value"`
+// TaskTransitionOption 单个可用转换
+type TaskTransitionOption struct {
+	Name    string `json:"name"`
+	To      string `json:"to"`
+	ToName  string `json:"to_name"`
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`  // 条件不满足的原因
+	Warning string `json:"warning,omitempty"` // 允许执行但需要注意，例如仍有未解决的阻塞任务
+}
+
+// 任务链接相关DTO
+
+// CreateTaskLinkRequest 创建任务链接请求，类型从当前任务的角度描述
+type CreateTaskLinkRequest struct {
+	TargetTaskID uuid.UUID `json:"target_task_id" binding:"required"`
+	Type         string    `json:"type" binding:"required,oneof=blocks is_blocked_by relates_to duplicates is_duplicated_by clones is_cloned_by"`
+}
+
+// TaskLinkView 从某个任务看的链接
+type TaskLinkView struct {
+	ID        uuid.UUID             `json:"id"`
+	Type      string                `json:"type"`
+	Direction string                `json:"direction"` // outward 或 inward
+	Label     string                `json:"label"`     // 例如 blocks、is blocked by
+	Task      models.DependencyNode `json:"task"`      // 链接另一端的任务
+	CreatedAt time.Time             `json:"created_at"`
+}
+
+// TaskLinksResponse 任务链接列表
+type TaskLinksResponse struct {
+	TaskID             uuid.UUID      `json:"task_id"`
+	Links              []TaskLinkView `json:"links"`
+	UnresolvedBlockers int            `json:"unresolved_blockers"`
+}
+
+// SprintDependencyReport Sprint规划用的依赖分析
+type SprintDependencyReport struct {
+	SprintID      uuid.UUID               `json:"sprint_id"`
+	Graph         *models.DependencyGraph `json:"graph"`
+	BlockedChains []models.BlockedChain   `json:"blocked_chains"`
+}
+
+// 保存的过滤器相关DTO
+
+// CreateSavedFilterRequest 创建保存的过滤器请求
+type CreateSavedFilterRequest struct {
+	ProjectID   uuid.UUID   `json:"-"`
+	Name        string      `json:"name" binding:"required,min=1,max=255"`
+	Description *string     `json:"description,omitempty"`
+	Query       string      `json:"query" binding:"required,max=2000"`
+	Visibility  string      `json:"visibility" binding:"omitempty,oneof=private project users"`
+	SharedWith  []uuid.UUID `json:"shared_with,omitempty"`
+}
+
+// UpdateSavedFilterRequest 更新保存的过滤器请求
+type UpdateSavedFilterRequest struct {
+	Name        *string      `json:"name,omitempty" binding:"omitempty,min=1,max=255"`
+	Description *string      `json:"description,omitempty"`
+	Query       *string      `json:"query,omitempty" binding:"omitempty,min=1,max=2000"`
+	Visibility  *string      `json:"visibility,omitempty" binding:"omitempty,oneof=private project users"`
+	SharedWith  *[]uuid.UUID `json:"shared_with,omitempty"`
+}
+
+// FilterStatistics 过滤器分组统计，用作仪表板组件数据源
+type FilterStatistics struct {
+	FilterID    uuid.UUID               `json:"filter_id"`
+	GroupBy     string                  `json:"group_by"`
+	Total       int64                   `json:"total"`
+	StoryPoints int64                   `json:"story_points"`
+	Groups      []FilterStatisticsGroup `json:"groups"`
+}
+
+// FilterStatisticsGroup 单个分组的统计
+type FilterStatisticsGroup struct {
+	Key         *string `json:"key"` // 为空表示未设置，例如未分配
+	Count       int64   `json:"count"`
+	StoryPoints int64   `json:"story_points"`
+}
+
+// BoardSwimlanesResponse 按泳道和列分组的看板任务
+type BoardSwimlanesResponse struct {
+	BoardID   uuid.UUID            `json:"board_id"`
+	Columns   []models.BoardColumn `json:"columns"`
+	Swimlanes []BoardSwimlane      `json:"swimlanes"`
+	Truncated bool                 `json:"truncated"` // 任务数超过上限时只返回排名靠前的任务
+
+	// 需要在卡片上显示的自定义字段，取值在任务的 custom_fields 中
+	CardFields []models.CustomField `json:"card_fields"`
+}
+
+// BoardSwimlane 单个泳道，FilterID 为空表示未匹配任何过滤器的默认泳道
+type BoardSwimlane struct {
+	FilterID  *uuid.UUID            `json:"filter_id"`
+	Name      string                `json:"name"`
+	Query     string                `json:"query,omitempty"`
+	TaskCount int                   `json:"task_count"`
+	Columns   []BoardSwimlaneColumn `json:"columns"`
+}
+
+// BoardSwimlaneColumn 泳道中某一列的任务
+type BoardSwimlaneColumn struct {
+	ColumnID uuid.UUID          `json:"column_id"`
+	Tasks    []models.AgileTask `json:"tasks"`
 }
 
 // 时间追踪相关DTO
@@ -530,3 +658,91 @@ type SprintProgress struct {
 	HealthStatus         string    `json:"health_status"`
 	HealthScore          float64   `json:"health_score"`
 }
+
+// 自定义字段相关DTO
+
+// CreateCustomFieldRequest 创建自定义字段请求
+type CreateCustomFieldRequest struct {
+	ProjectID     uuid.UUID   `json:"-"`
+	Key           string      `json:"key" binding:"required,max=50"`
+	Name          string      `json:"name" binding:"required,min=1,max=100"`
+	Description   *string     `json:"description,omitempty"`
+	Type          string      `json:"type" binding:"required,oneof=text number date select multi_select user url"`
+	Options       []string    `json:"options,omitempty"`
+	TaskTypes     []string    `json:"task_types,omitempty"`
+	RequiredTypes []string    `json:"required_types,omitempty"`
+	DefaultValue  interface{} `json:"default_value,omitempty"`
+	ShowOnCard    bool        `json:"show_on_card"`
+	Position      *int        `json:"position,omitempty"`
+}
+
+// UpdateCustomFieldRequest 更新自定义字段请求，键和类型创建后不可修改
+type UpdateCustomFieldRequest struct {
+	Name          *string         `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Description   *string         `json:"description,omitempty"`
+	Options       *[]string       `json:"options,omitempty"`
+	TaskTypes     *[]string       `json:"task_types,omitempty"`
+	RequiredTypes *[]string       `json:"required_types,omitempty"`
+	DefaultValue  json.RawMessage `json:"default_value,omitempty"` // 传 null 清除默认值
+	ShowOnCard    *bool           `json:"show_on_card,omitempty"`
+	Position      *int            `json:"position,omitempty"`
+}
+
+// TaskExport 任务导出结果，第一行为表头
+type TaskExport struct {
+	FileName  string
+	Rows      [][]string
+	Truncated bool // 任务数超过导出上限
+}
+
+// 任务历史相关DTO
+
+// ActivityFilter 项目活动流过滤条件
+type ActivityFilter struct {
+	ProjectID uuid.UUID
+	TaskID    *uuid.UUID
+	ActorID   *uuid.UUID
+	Field     *string
+	Since     *time.Time
+	Until     *time.Time
+}
+
+// TaskChangeEntry 活动流中的一条变更，附带任务标识
+type TaskChangeEntry struct {
+	models.TaskChange
+	TaskKey   string `json:"task_key"`
+	TaskTitle string `json:"task_title"`
+}
+
+// ActivityListResponse 项目活动流响应，按时间倒序
+type ActivityListResponse struct {
+	Activities []TaskChangeEntry `json:"activities"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+}
+
+// TaskFlowMetrics 根据状态历史计算的流动指标
+type TaskFlowMetrics struct {
+	ProjectID      uuid.UUID           `json:"project_id"`
+	StartDate      time.Time           `json:"start_date"`
+	EndDate        time.Time           `json:"end_date"`
+	Statuses       []string            `json:"statuses"` // 按工作流顺序
+	CumulativeFlow []CumulativeFlowDay `json:"cumulative_flow"`
+	CycleTimes     []TaskCycleTime     `json:"cycle_times"` // 在时间范围内完成的任务
+}
+
+// CumulativeFlowDay 某一天结束时各状态的任务数
+type CumulativeFlowDay struct {
+	Date   time.Time        `json:"date"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+// TaskCycleTime 单个任务的周期时间
+type TaskCycleTime struct {
+	TaskID        uuid.UUID `json:"task_id"`
+	TaskKey       string    `json:"task_key"`
+	TaskTitle     string    `json:"task_title"`
+	LeadTimeHours float64   `json:"lead_time_hours"` // 从创建到完成
+	models.TaskCycle
+}
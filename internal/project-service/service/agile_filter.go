@@ -0,0 +1,508 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 保存的任务过滤器。
+// 过滤器保存一条任务查询语句，编译时 currentUser() 等函数按查看者求值，
+// 因此同一个共享过滤器对不同成员返回各自的结果。看板泳道和仪表板组件通过过滤器ID引用查询。
+
+// BoardSwimlaneTaskLimit 泳道视图最多加载的任务数
+const BoardSwimlaneTaskLimit = 1000
+
+// filterStatisticsColumns 过滤器统计支持的分组字段
+var filterStatisticsColumns = map[string]string{
+	"status":     "agile_tasks.status",
+	"type":       "agile_tasks.type",
+	"priority":   "agile_tasks.priority",
+	"resolution": "agile_tasks.resolution",
+	"assignee":   "agile_tasks.assignee_id",
+	"sprint":     "agile_tasks.sprint_id",
+	"epic":       "agile_tasks.epic_id",
+}
+
+func (s *agileServiceImpl) CreateSavedFilter(ctx context.Context, req *CreateSavedFilterRequest, userID, tenantID uuid.UUID) (*models.SavedFilter, error) {
+	if err := s.checkProjectAccess(ctx, req.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	filter := &models.SavedFilter{
+		ProjectID:   req.ProjectID,
+		OwnerID:     userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Query:       req.Query,
+		Visibility:  req.Visibility,
+		SharedWith:  req.SharedWith,
+	}
+	if err := s.validateSavedFilter(ctx, filter, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Create(filter).Error; err != nil {
+		return nil, fmt.Errorf("failed to create saved filter: %w", err)
+	}
+
+	s.logger.Info("创建保存的过滤器",
+		zap.String("project_id", req.ProjectID.String()),
+		zap.String("filter_id", filter.ID.String()),
+		zap.String("visibility", filter.Visibility))
+	return filter, nil
+}
+
+func (s *agileServiceImpl) GetSavedFilter(ctx context.Context, filterID uuid.UUID, userID, tenantID uuid.UUID) (*models.SavedFilter, error) {
+	var filter models.SavedFilter
+	if err := s.db.WithContext(ctx).First(&filter, "id = ? AND deleted_at IS NULL", filterID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrSavedFilterNotFound
+		}
+		return nil, fmt.Errorf("failed to get saved filter: %w", err)
+	}
+
+	if err := s.checkProjectAccess(ctx, filter.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+	// 不可见的过滤器按不存在处理，避免泄露私有过滤器
+	if !filter.VisibleTo(userID) {
+		return nil, models.ErrSavedFilterNotFound
+	}
+	return &filter, nil
+}
+
+// ListSavedFilters 获取用户在项目中可见的过滤器
+func (s *agileServiceImpl) ListSavedFilters(ctx context.Context, projectID uuid.UUID, userID, tenantID uuid.UUID) ([]models.SavedFilter, error) {
+	if err := s.checkProjectAccess(ctx, projectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	var filters []models.SavedFilter
+	if err := s.db.WithContext(ctx).
+		Where("project_id = ? AND deleted_at IS NULL", projectID).
+		Where("owner_id = ? OR visibility = ? OR (visibility = ? AND shared_with @> ?::jsonb)",
+			userID, models.FilterVisibilityProject, models.FilterVisibilityUsers, fmt.Sprintf("[%q]", userID.String())).
+		Order("name ASC").
+		Find(&filters).Error; err != nil {
+		return nil, fmt.Errorf("failed to list saved filters: %w", err)
+	}
+	return filters, nil
+}
+
+func (s *agileServiceImpl) UpdateSavedFilter(ctx context.Context, filterID uuid.UUID, req *UpdateSavedFilterRequest, userID, tenantID uuid.UUID) (*models.SavedFilter, error) {
+	filter, err := s.GetSavedFilter(ctx, filterID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if filter.OwnerID != userID {
+		return nil, models.ErrSavedFilterReadOnly
+	}
+
+	if req.Name != nil {
+		filter.Name = *req.Name
+	}
+	if req.Description != nil {
+		filter.Description = req.Description
+	}
+	if req.Query != nil {
+		filter.Query = *req.Query
+	}
+	if req.Visibility != nil {
+		filter.Visibility = *req.Visibility
+	}
+	if req.SharedWith != nil {
+		filter.SharedWith = *req.SharedWith
+	}
+	if err := s.validateSavedFilter(ctx, filter, userID); err != nil {
+		return nil, err
+	}
+
+	// 结构体更新才会经过jsonb序列化
+	if err := s.db.WithContext(ctx).Model(filter).
+		Select("name", "description", "query", "visibility", "shared_with").
+		Updates(filter).Error; err != nil {
+		return nil, fmt.Errorf("failed to update saved filter: %w", err)
+	}
+	return filter, nil
+}
+
+func (s *agileServiceImpl) DeleteSavedFilter(ctx context.Context, filterID uuid.UUID, userID, tenantID uuid.UUID) error {
+	filter, err := s.GetSavedFilter(ctx, filterID, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if filter.OwnerID != userID {
+		return models.ErrSavedFilterReadOnly
+	}
+
+	// 引用该过滤器的看板泳道在读取时跳过
+	if err := s.db.WithContext(ctx).Model(filter).Update("deleted_at", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to delete saved filter: %w", err)
+	}
+	return nil
+}
+
+// GetSavedFilterStatistics 按字段分组统计过滤器匹配的任务，供仪表板组件使用
+func (s *agileServiceImpl) GetSavedFilterStatistics(ctx context.Context, filterID uuid.UUID, groupBy string, userID, tenantID uuid.UUID) (*FilterStatistics, error) {
+	if groupBy == "" {
+		groupBy = "status"
+	}
+
+	filter, err := s.GetSavedFilter(ctx, filterID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	qc, err := s.taskQueryContext(ctx, filter.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	column, err := statisticsColumn(groupBy, qc.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := models.CompileTaskQuery(filter.Query, qc)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []FilterStatisticsGroup
+	query := s.db.WithContext(ctx).
+		Model(&models.AgileTask{}).
+		Where("agile_tasks.project_id = ? AND agile_tasks.deleted_at IS NULL", filter.ProjectID)
+	if err := applyCompiledTaskQuery(query, compiled).
+		Select(column + "::text AS key, COUNT(*) AS count, COALESCE(SUM(agile_tasks.story_points), 0) AS story_points").
+		Group(column).
+		Order("count DESC").
+		Scan(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to get filter statistics: %w", err)
+	}
+
+	stats := &FilterStatistics{
+		FilterID: filter.ID,
+		GroupBy:  groupBy,
+		Groups:   groups,
+	}
+	for _, group := range groups {
+		stats.Total += group.Count
+		stats.StoryPoints += group.StoryPoints
+	}
+	return stats, nil
+}
+
+// GetBoardSwimlanes 按泳道过滤器和看板列分组返回任务，任务归入第一个匹配的泳道，其余进入默认泳道
+func (s *agileServiceImpl) GetBoardSwimlanes(ctx context.Context, boardID uuid.UUID, userID, tenantID uuid.UUID) (*BoardSwimlanesResponse, error) {
+	var board models.Board
+	if err := s.db.WithContext(ctx).First(&board, "id = ? AND deleted_at IS NULL", boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("board not found")
+		}
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	if err := s.checkProjectAccess(ctx, board.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	var columns []models.BoardColumn
+	if err := s.db.WithContext(ctx).
+		Where("board_id = ? AND deleted_at IS NULL", boardID).
+		Order("position ASC").
+		Find(&columns).Error; err != nil {
+		return nil, fmt.Errorf("failed to get board columns: %w", err)
+	}
+
+	qc, err := s.taskQueryContext(ctx, board.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BoardSwimlanesResponse{BoardID: boardID, Columns: columns, Swimlanes: []BoardSwimlane{}, CardFields: []models.CustomField{}}
+	for _, field := range qc.CustomFields {
+		if field.ShowOnCard {
+			result.CardFields = append(result.CardFields, field)
+		}
+	}
+	if len(columns) == 0 {
+		return result, nil
+	}
+
+	statuses := make([]string, 0, len(columns))
+	for _, column := range columns {
+		statuses = append(statuses, column.Status)
+	}
+
+	var tasks []models.AgileTask
+	if err := s.db.WithContext(ctx).
+		Where("project_id = ? AND deleted_at IS NULL AND status IN ?", board.ProjectID, statuses).
+		Preload("Assignee").
+		Order("rank ASC, created_at DESC").
+		Limit(BoardSwimlaneTaskLimit + 1).
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list board tasks: %w", err)
+	}
+	if len(tasks) > BoardSwimlaneTaskLimit {
+		tasks = tasks[:BoardSwimlaneTaskLimit]
+		result.Truncated = true
+	}
+
+	taskIDs := make([]uuid.UUID, 0, len(tasks))
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+	}
+
+	// 逐个泳道查询匹配的任务，已归入前面泳道的任务不再重复出现
+	laneOf := make(map[uuid.UUID]int, len(tasks))
+	for _, filterID := range board.SwimlaneFilterIDs {
+		filter, err := s.GetSavedFilter(ctx, filterID, userID, tenantID)
+		if err != nil {
+			s.logger.Warn("跳过不可用的泳道过滤器",
+				zap.String("board_id", boardID.String()),
+				zap.String("filter_id", filterID.String()),
+				zap.Error(err))
+			continue
+		}
+
+		lane := BoardSwimlane{FilterID: &filter.ID, Name: filter.Name, Query: filter.Query}
+		if len(taskIDs) > 0 {
+			compiled, err := models.CompileTaskQuery(filter.Query, qc)
+			if err != nil {
+				return nil, fmt.Errorf("swimlane %q: %w", filter.Name, err)
+			}
+
+			var matched []uuid.UUID
+			query := s.db.WithContext(ctx).Model(&models.AgileTask{}).Where("agile_tasks.id IN ?", taskIDs)
+			if err := applyCompiledTaskQuery(query, compiled).Pluck("agile_tasks.id", &matched).Error; err != nil {
+				return nil, fmt.Errorf("failed to match swimlane tasks: %w", err)
+			}
+			for _, id := range matched {
+				if _, assigned := laneOf[id]; !assigned {
+					laneOf[id] = len(result.Swimlanes)
+				}
+			}
+		}
+		result.Swimlanes = append(result.Swimlanes, lane)
+	}
+	result.Swimlanes = append(result.Swimlanes, BoardSwimlane{Name: "Other"})
+	defaultLane := len(result.Swimlanes) - 1
+
+	columnIndex := make(map[string]int, len(columns))
+	for i, column := range columns {
+		if _, exists := columnIndex[column.Status]; !exists {
+			columnIndex[column.Status] = i
+		}
+	}
+	for i := range result.Swimlanes {
+		result.Swimlanes[i].Columns = make([]BoardSwimlaneColumn, len(columns))
+		for j, column := range columns {
+			result.Swimlanes[i].Columns[j] = BoardSwimlaneColumn{ColumnID: column.ID, Tasks: []models.AgileTask{}}
+		}
+	}
+
+	for _, task := range tasks {
+		laneIndex, ok := laneOf[task.ID]
+		if !ok {
+			laneIndex = defaultLane
+		}
+		lane := &result.Swimlanes[laneIndex]
+		column := &lane.Columns[columnIndex[task.Status]]
+		column.Tasks = append(column.Tasks, task)
+		lane.TaskCount++
+	}
+
+	return result, nil
+}
+
+// validateSavedFilter 校验过滤器配置、查询语法和共享成员
+func (s *agileServiceImpl) validateSavedFilter(ctx context.Context, filter *models.SavedFilter, userID uuid.UUID) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	if _, err := s.compileTaskQuery(ctx, filter.ProjectID, filter.Query, userID); err != nil {
+		return err
+	}
+	if len(filter.SharedWith) == 0 {
+		return nil
+	}
+
+	// 只能共享给项目成员
+	var members []uuid.UUID
+	if err := s.db.WithContext(ctx).
+		Table("project_members").
+		Where("project_id = ? AND user_id IN ?", filter.ProjectID, filter.SharedWith).
+		Distinct().
+		Pluck("user_id", &members).Error; err != nil {
+		return fmt.Errorf("failed to check filter members: %w", err)
+	}
+	var managers []uuid.UUID
+	if err := s.db.WithContext(ctx).
+		Table("projects").
+		Where("id = ? AND manager_id IN ?", filter.ProjectID, filter.SharedWith).
+		Pluck("manager_id", &managers).Error; err != nil {
+		return fmt.Errorf("failed to check filter members: %w", err)
+	}
+
+	known := make(map[uuid.UUID]bool, len(members)+len(managers))
+	for _, id := range append(members, managers...) {
+		known[id] = true
+	}
+	var unknown []string
+	for _, id := range filter.SharedWith {
+		if !known[id] {
+			unknown = append(unknown, id.String())
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: not project members: %s", models.ErrInvalidSavedFilter, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// validateSwimlaneFilters 泳道对所有看板成员可见，只能使用项目内共享的过滤器
+func (s *agileServiceImpl) validateSwimlaneFilters(ctx context.Context, projectID uuid.UUID, filterIDs []uuid.UUID) error {
+	seen := make(map[uuid.UUID]bool, len(filterIDs))
+	for _, id := range filterIDs {
+		if seen[id] {
+			return fmt.Errorf("%w: duplicate swimlane filter %s", models.ErrInvalidSavedFilter, id)
+		}
+		seen[id] = true
+	}
+	if len(filterIDs) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).
+		Model(&models.SavedFilter{}).
+		Where("id IN ? AND project_id = ? AND visibility = ? AND deleted_at IS NULL", filterIDs, projectID, models.FilterVisibilityProject).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check swimlane filters: %w", err)
+	}
+	if count != int64(len(filterIDs)) {
+		return fmt.Errorf("%w: swimlane filters must belong to the project and be shared with it", models.ErrInvalidSavedFilter)
+	}
+	return nil
+}
+
+// taskListQuery 编译任务列表请求中的查询语句、保存的过滤器和自定义字段条件，同时存在时取交集
+func (s *agileServiceImpl) taskListQuery(ctx context.Context, filter *TaskFilter, userID, tenantID uuid.UUID) (*models.CompiledTaskQuery, error) {
+	var queries []string
+	if filter.Query != nil && strings.TrimSpace(*filter.Query) != "" {
+		queries = append(queries, *filter.Query)
+	}
+	if filter.FilterID != nil {
+		saved, err := s.GetSavedFilter(ctx, *filter.FilterID, userID, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if saved.ProjectID != filter.ProjectID {
+			return nil, models.ErrSavedFilterNotFound
+		}
+		queries = append(queries, saved.Query)
+	}
+
+	combined := &models.CompiledTaskQuery{}
+	if len(queries) == 0 && len(filter.CustomFields) == 0 {
+		return combined, nil
+	}
+	qc, err := s.taskQueryContext(ctx, filter.ProjectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(filter.CustomFields) > 0 {
+		queries = append(queries, customFieldConditions(filter.CustomFields, qc.CustomFields))
+	}
+
+	var conditions []string
+	for _, q := range queries {
+		compiled, err := models.CompileTaskQuery(q, qc)
+		if err != nil {
+			return nil, err
+		}
+		if compiled.Where != "" {
+			conditions = append(conditions, compiled.Where)
+			combined.Args = append(combined.Args, compiled.Args...)
+		}
+		// 请求中的查询语句排序优先
+		if combined.OrderBy == "" {
+			combined.OrderBy = compiled.OrderBy
+		}
+	}
+	combined.Where = strings.Join(conditions, " AND ")
+	return combined, nil
+}
+
+func (s *agileServiceImpl) compileTaskQuery(ctx context.Context, projectID uuid.UUID, query string, userID uuid.UUID) (*models.CompiledTaskQuery, error) {
+	qc, err := s.taskQueryContext(ctx, projectID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return models.CompileTaskQuery(query, qc)
+}
+
+// taskQueryContext 构造查询编译上下文，包含项目的自定义字段
+func (s *agileServiceImpl) taskQueryContext(ctx context.Context, projectID, userID uuid.UUID) (models.TaskQueryContext, error) {
+	fields, err := s.projectCustomFields(ctx, projectID)
+	if err != nil {
+		return models.TaskQueryContext{}, err
+	}
+	return models.TaskQueryContext{UserID: userID, Now: time.Now(), CustomFields: fields}, nil
+}
+
+// statisticsColumn 返回统计分组字段对应的列，支持 cf.<key> 形式的自定义字段（多选字段除外）
+func statisticsColumn(groupBy string, fields []models.CustomField) (string, error) {
+	if column, ok := filterStatisticsColumns[groupBy]; ok {
+		return column, nil
+	}
+	if key, ok := strings.CutPrefix(groupBy, "cf."); ok {
+		for _, field := range fields {
+			if field.Key == key && field.Type != models.CustomFieldMultiSelect {
+				return models.CustomFieldColumn(field.Key), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: cannot group by %q", models.ErrInvalidTaskQuery, groupBy)
+}
+
+// customFieldConditions 将自定义字段等值条件转换为查询语句，复用查询语言的类型校验。
+// 日期字段不支持等于操作符，转换为当天的闭区间
+func customFieldConditions(conditions map[string]string, fields []models.CustomField) string {
+	keys := make([]string, 0, len(conditions))
+	for key := range conditions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clauses := make([]string, 0, len(keys))
+	for _, key := range keys {
+		name, value := "cf."+key, quoteQueryValue(conditions[key])
+		clause := name + " = " + value
+		for _, field := range fields {
+			if field.Key == key && field.Type == models.CustomFieldDate {
+				clause = "(" + name + " >= " + value + " AND " + name + " <= " + value + ")"
+			}
+		}
+		clauses = append(clauses, clause)
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// quoteQueryValue 将取值转换为查询语言的字符串字面量
+func quoteQueryValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// applyCompiledTaskQuery 将编译后的查询条件应用到任务查询
+func applyCompiledTaskQuery(query *gorm.DB, compiled *models.CompiledTaskQuery) *gorm.DB {
+	if compiled == nil || compiled.Where == "" {
+		return query
+	}
+	return query.Where(compiled.Where, compiled.Args...)
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 任务变更历史。
+// 每次修改任务后重新读取任务，与修改前的快照逐字段比较并在同一事务中写入 task_changes。
+// 状态变更历史同时用于还原任务的状态时间线，计算周期时间和累积流图。
+
+// 活动流和流动指标限制
+const (
+	DefaultActivityPageSize = 20
+	MaxActivityPageSize     = 100
+	MaxFlowMetricsDays      = 366
+)
+
+// recordTaskChanges 重新读取任务并记录与修改前的差异，before 为 nil 表示新建任务
+func (s *agileServiceImpl) recordTaskChanges(ctx context.Context, db *gorm.DB, before *models.AgileTask, taskID, actorID uuid.UUID) error {
+	var after models.AgileTask
+	if err := db.WithContext(ctx).First(&after, "id = ?", taskID).Error; err != nil {
+		return fmt.Errorf("failed to reload task: %w", err)
+	}
+
+	changes := models.NewTaskChanges(before, &after, actorID)
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := db.WithContext(ctx).Create(&changes).Error; err != nil {
+		return fmt.Errorf("failed to record task changes: %w", err)
+	}
+	return nil
+}
+
+// GetTaskHistory 获取任务的全部变更记录，按时间升序
+func (s *agileServiceImpl) GetTaskHistory(ctx context.Context, taskID uuid.UUID, userID, tenantID uuid.UUID) ([]models.TaskChange, error) {
+	var task models.AgileTask
+	if err := s.db.WithContext(ctx).First(&task, "id = ? AND deleted_at IS NULL", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("task not found")
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	if err := s.checkProjectAccess(ctx, task.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	var changes []models.TaskChange
+	if err := s.db.WithContext(ctx).
+		Where("task_id = ?", taskID).
+		Preload("Actor").
+		Order("created_at ASC, id ASC").
+		Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get task history: %w", err)
+	}
+	return changes, nil
+}
+
+// ListProjectActivity 分页获取项目的任务变更活动流，按时间倒序，包含已删除任务的记录
+func (s *agileServiceImpl) ListProjectActivity(ctx context.Context, filter *ActivityFilter, page, pageSize int, userID, tenantID uuid.UUID) (*ActivityListResponse, error) {
+	if err := s.checkProjectAccess(ctx, filter.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Until.After(*filter.Since) {
+		return nil, fmt.Errorf("%w: until must be after since", models.ErrInvalidActivityQuery)
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultActivityPageSize
+	}
+	if pageSize > MaxActivityPageSize {
+		pageSize = MaxActivityPageSize
+	}
+
+	query := s.db.WithContext(ctx).Model(&models.TaskChange{}).Where("project_id = ?", filter.ProjectID)
+	if filter.TaskID != nil {
+		query = query.Where("task_id = ?", *filter.TaskID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Field != nil {
+		query = query.Where("field = ?", *filter.Field)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count activity: %w", err)
+	}
+
+	var changes []models.TaskChange
+	if err := query.
+		Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+
+	result := &ActivityListResponse{Activities: make([]TaskChangeEntry, 0, len(changes)), Total: total, Page: page, PageSize: pageSize}
+	if len(changes) == 0 {
+		return result, nil
+	}
+
+	projectKey, err := s.projectKey(ctx, filter.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	taskIDs := make([]uuid.UUID, 0, len(changes))
+	for _, change := range changes {
+		taskIDs = append(taskIDs, change.TaskID)
+	}
+	var tasks []models.AgileTask
+	if err := s.db.WithContext(ctx).
+		Select("id", "task_number", "title").
+		Where("id IN ?", taskIDs).
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get activity tasks: %w", err)
+	}
+	byID := make(map[uuid.UUID]*models.AgileTask, len(tasks))
+	for i := range tasks {
+		byID[tasks[i].ID] = &tasks[i]
+	}
+
+	for _, change := range changes {
+		activity := TaskChangeEntry{TaskChange: change}
+		if task, ok := byID[change.TaskID]; ok {
+			activity.TaskKey = models.TaskKey(projectKey, task.TaskNumber)
+			activity.TaskTitle = task.Title
+		}
+		result.Activities = append(result.Activities, activity)
+	}
+	return result, nil
+}
+
+// GetTaskFlowMetrics 根据状态历史计算累积流图和周期时间。
+// 累积流图统计每天结束时各状态的任务数，删除的任务在删除之前仍计入；
+// 周期时间统计在时间范围内完成的任务，从第一次离开待办分类到最后一次进入完成分类
+func (s *agileServiceImpl) GetTaskFlowMetrics(ctx context.Context, projectID uuid.UUID, startDate, endDate time.Time, userID, tenantID uuid.UUID) (*TaskFlowMetrics, error) {
+	if err := s.checkProjectAccess(ctx, projectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	start := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, startDate.Location())
+	end := time.Date(endDate.Year(), endDate.Month(), endDate.Day(), 0, 0, 0, 0, endDate.Location()).AddDate(0, 0, 1)
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end date must not be before start date", models.ErrInvalidActivityQuery)
+	}
+	if end.Sub(start) > MaxFlowMetricsDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: date range cannot exceed %d days", models.ErrInvalidActivityQuery, MaxFlowMetricsDays)
+	}
+
+	var tasks []models.AgileTask
+	if err := s.db.WithContext(ctx).
+		Select("id", "task_number", "title", "type", "status", "created_at", "deleted_at").
+		Where("project_id = ? AND created_at < ? AND (deleted_at IS NULL OR deleted_at >= ?)", projectID, end, start).
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get flow tasks: %w", err)
+	}
+
+	var changes []models.TaskChange
+	if err := s.db.WithContext(ctx).
+		Where("project_id = ? AND field = ? AND created_at < ?", projectID, "status", end).
+		Order("created_at ASC, id ASC").
+		Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+	changesByTask := make(map[uuid.UUID][]models.TaskChange)
+	for _, change := range changes {
+		changesByTask[change.TaskID] = append(changesByTask[change.TaskID], change)
+	}
+
+	workflows, err := s.projectWorkflows(ctx, s.db, projectID)
+	if err != nil {
+		return nil, err
+	}
+	projectKey, err := s.projectKey(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &TaskFlowMetrics{
+		ProjectID:      projectID,
+		StartDate:      start,
+		EndDate:        end.AddDate(0, 0, -1),
+		Statuses:       flowStatuses(workflows),
+		CumulativeFlow: []CumulativeFlowDay{},
+		CycleTimes:     []TaskCycleTime{},
+	}
+	known := make(map[string]bool, len(metrics.Statuses))
+	for _, status := range metrics.Statuses {
+		known[status] = true
+	}
+
+	timelines := make([][]models.StatusInterval, len(tasks))
+	for i, task := range tasks {
+		timelines[i] = models.StatusTimeline(task.CreatedAt, task.Status, changesByTask[task.ID])
+		for _, interval := range timelines[i] {
+			if !known[interval.Status] {
+				known[interval.Status] = true
+				metrics.Statuses = append(metrics.Statuses, interval.Status)
+			}
+		}
+
+		if task.DeletedAt != nil {
+			continue
+		}
+		workflow := models.SelectWorkflow(workflows, task.Type)
+		cycle, ok := models.CycleOf(timelines[i], func(status string) string {
+			if s := workflow.Status(status); s != nil {
+				return s.Category
+			}
+			return ""
+		})
+		if !ok || cycle.CompletedAt.Before(start) || !cycle.CompletedAt.Before(end) {
+			continue
+		}
+		metrics.CycleTimes = append(metrics.CycleTimes, TaskCycleTime{
+			TaskID:        task.ID,
+			TaskKey:       models.TaskKey(projectKey, task.TaskNumber),
+			TaskTitle:     task.Title,
+			LeadTimeHours: cycle.CompletedAt.Sub(task.CreatedAt).Hours(),
+			TaskCycle:     *cycle,
+		})
+	}
+	sort.Slice(metrics.CycleTimes, func(i, j int) bool {
+		return metrics.CycleTimes[i].CompletedAt.Before(metrics.CycleTimes[j].CompletedAt)
+	})
+
+	now := time.Now()
+	for day := start; day.Before(end) && !day.After(now); day = day.AddDate(0, 0, 1) {
+		at := day.AddDate(0, 0, 1)
+		if at.After(now) {
+			at = now
+		}
+		counts := make(map[string]int64, len(metrics.Statuses))
+		for _, status := range metrics.Statuses {
+			counts[status] = 0
+		}
+		for i, task := range tasks {
+			if task.DeletedAt != nil && !at.Before(*task.DeletedAt) {
+				continue
+			}
+			if status := models.StatusAt(timelines[i], at); status != "" {
+				counts[status]++
+			}
+		}
+		metrics.CumulativeFlow = append(metrics.CumulativeFlow, CumulativeFlowDay{Date: day, Counts: counts})
+	}
+	return metrics, nil
+}
+
+// flowStatuses 按工作流顺序列出项目使用的状态，未配置工作流时使用默认工作流
+func flowStatuses(workflows []models.Workflow) []string {
+	if len(workflows) == 0 {
+		workflows = []models.Workflow{*models.DefaultWorkflow()}
+	}
+	var statuses []string
+	seen := make(map[string]bool)
+	for _, workflow := range workflows {
+		for _, status := range workflow.Statuses {
+			if !seen[status.Key] {
+				seen[status.Key] = true
+				statuses = append(statuses, status.Key)
+			}
+		}
+	}
+	return statuses
+}
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 任务链接和依赖图。
+// blocks 链接构成有向依赖图：创建时拒绝形成循环，读取时仍做循环检测以发现历史数据中的问题。
+// 阻塞的强制程度由工作流决定：转换带有 not_blocked 条件时禁止，否则在可用转换中给出警告。
+
+// 依赖图遍历限制
+const (
+	DefaultDependencyDepth  = 3
+	MaxDependencyDepth      = 10
+	MaxDependencyGraphNodes = 500
+)
+
+func (s *agileServiceImpl) CreateTaskLink(ctx context.Context, taskID uuid.UUID, req *CreateTaskLinkRequest, userID, tenantID uuid.UUID) (*TaskLinkView, error) {
+	task, err := s.GetTask(ctx, taskID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	// 目标任务可以在其他项目，同样需要访问权限
+	other, err := s.GetTask(ctx, req.TargetTaskID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := models.NewTaskLink(task.ID, other.ID, req.Type)
+	if err != nil {
+		return nil, err
+	}
+	link.CreatedBy = &userID
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if link.Type == models.TaskLinkBlocks {
+			// 串行化阻塞链接的创建，避免并发创建出循环
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('task_links.blocks'))").Error; err != nil {
+				return fmt.Errorf("failed to lock task links: %w", err)
+			}
+		}
+
+		var count int64
+		if err := tx.Model(&models.TaskLink{}).
+			Where("source_task_id = ? AND target_task_id = ? AND link_type = ?", link.SourceTaskID, link.TargetTaskID, link.Type).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check task link: %w", err)
+		}
+		if count > 0 {
+			return models.ErrTaskLinkExists
+		}
+
+		if link.Type == models.TaskLinkBlocks {
+			cycle, err := s.blockingPathExists(ctx, tx, link.TargetTaskID, link.SourceTaskID)
+			if err != nil {
+				return err
+			}
+			if cycle {
+				return models.ErrTaskLinkCycle
+			}
+		}
+
+		if err := tx.Create(link).Error; err != nil {
+			return fmt.Errorf("failed to create task link: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.dependencyNodes(ctx, []models.AgileTask{*other})
+	if err != nil {
+		return nil, err
+	}
+	direction, label := link.Describe(task.ID)
+
+	s.logger.Info("创建任务链接",
+		zap.String("source_task_id", link.SourceTaskID.String()),
+		zap.String("target_task_id", link.TargetTaskID.String()),
+		zap.String("type", link.Type))
+	return &TaskLinkView{
+		ID:        link.ID,
+		Type:      link.Type,
+		Direction: direction,
+		Label:     label,
+		Task:      nodes[0],
+		CreatedAt: link.CreatedAt,
+	}, nil
+}
+
+// ListTaskLinks 获取任务的链接，无权访问的项目中的任务不返回
+func (s *agileServiceImpl) ListTaskLinks(ctx context.Context, taskID uuid.UUID, userID, tenantID uuid.UUID) (*TaskLinksResponse, error) {
+	task, err := s.GetTask(ctx, taskID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []models.TaskLink
+	if err := s.db.WithContext(ctx).
+		Where("source_task_id = ? OR target_task_id = ?", task.ID, task.ID).
+		Order("created_at ASC").
+		Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to list task links: %w", err)
+	}
+
+	otherIDs := make([]uuid.UUID, 0, len(links))
+	for i := range links {
+		otherIDs = append(otherIDs, links[i].OtherTask(task.ID))
+	}
+	others, err := s.accessibleTasks(ctx, otherIDs, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.dependencyNodes(ctx, others)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.DependencyNode, len(nodes))
+	for _, node := range nodes {
+		byID[node.TaskID] = node
+	}
+
+	resp := &TaskLinksResponse{TaskID: task.ID, Links: []TaskLinkView{}}
+	for i := range links {
+		link := &links[i]
+		node, ok := byID[link.OtherTask(task.ID)]
+		if !ok {
+			continue
+		}
+		direction, label := link.Describe(task.ID)
+		if link.Type == models.TaskLinkBlocks && direction == models.TaskLinkInward && !node.Resolved {
+			resp.UnresolvedBlockers++
+		}
+		resp.Links = append(resp.Links, TaskLinkView{
+			ID:        link.ID,
+			Type:      link.Type,
+			Direction: direction,
+			Label:     label,
+			Task:      node,
+			CreatedAt: link.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+func (s *agileServiceImpl) DeleteTaskLink(ctx context.Context, taskID, linkID uuid.UUID, userID, tenantID uuid.UUID) error {
+	task, err := s.GetTask(ctx, taskID, userID, tenantID)
+	if err != nil {
+		return err
+	}
+
+	var link models.TaskLink
+	if err := s.db.WithContext(ctx).First(&link, "id = ?", linkID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.ErrTaskLinkNotFound
+		}
+		return fmt.Errorf("failed to get task link: %w", err)
+	}
+	if !link.Involves(task.ID) {
+		return models.ErrTaskLinkNotFound
+	}
+
+	if err := s.db.WithContext(ctx).Delete(&link).Error; err != nil {
+		return fmt.Errorf("failed to delete task link: %w", err)
+	}
+	return nil
+}
+
+// GetTaskDependencyGraph 获取任务上下游指定深度内的阻塞依赖图
+func (s *agileServiceImpl) GetTaskDependencyGraph(ctx context.Context, taskID uuid.UUID, depth int, userID, tenantID uuid.UUID) (*models.DependencyGraph, error) {
+	task, err := s.GetTask(ctx, taskID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if depth <= 0 {
+		depth = DefaultDependencyDepth
+	}
+	if depth > MaxDependencyDepth {
+		depth = MaxDependencyDepth
+	}
+	return s.dependencyGraph(ctx, []uuid.UUID{task.ID}, depth, false, userID, tenantID)
+}
+
+// GetSprintDependencies 分析Sprint任务的上游阻塞链，供Sprint规划展示
+func (s *agileServiceImpl) GetSprintDependencies(ctx context.Context, sprintID uuid.UUID, userID, tenantID uuid.UUID) (*SprintDependencyReport, error) {
+	var sprint models.Sprint
+	if err := s.db.WithContext(ctx).First(&sprint, "id = ? AND deleted_at IS NULL", sprintID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("sprint not found")
+		}
+		return nil, fmt.Errorf("failed to get sprint: %w", err)
+	}
+	if err := s.checkProjectAccess(ctx, sprint.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	var taskIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).
+		Model(&models.AgileTask{}).
+		Where("sprint_id = ? AND deleted_at IS NULL", sprintID).
+		Order("rank ASC").
+		Pluck("id", &taskIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to list sprint tasks: %w", err)
+	}
+
+	graph, err := s.dependencyGraph(ctx, taskIDs, MaxDependencyDepth, true, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	chains := graph.BlockedChains(taskIDs)
+	if chains == nil {
+		chains = []models.BlockedChain{}
+	}
+	return &SprintDependencyReport{
+		SprintID:      sprintID,
+		Graph:         graph,
+		BlockedChains: chains,
+	}, nil
+}
+
+// dependencyGraph 从根任务出发按 blocks 链接广度遍历，upstreamOnly 时只查找阻塞者
+func (s *agileServiceImpl) dependencyGraph(ctx context.Context, roots []uuid.UUID, depth int, upstreamOnly bool, userID, tenantID uuid.UUID) (*models.DependencyGraph, error) {
+	visited := make(map[uuid.UUID]bool, len(roots))
+	for _, id := range roots {
+		visited[id] = true
+	}
+	edges := make(map[models.DependencyEdge]bool)
+
+	frontier := roots
+	for level := 0; level < depth && len(frontier) > 0 && len(visited) < MaxDependencyGraphNodes; level++ {
+		query := s.db.WithContext(ctx).Where("link_type = ?", models.TaskLinkBlocks)
+		if upstreamOnly {
+			query = query.Where("target_task_id IN ?", frontier)
+		} else {
+			query = query.Where("target_task_id IN ? OR source_task_id IN ?", frontier, frontier)
+		}
+
+		var links []models.TaskLink
+		if err := query.Find(&links).Error; err != nil {
+			return nil, fmt.Errorf("failed to load task links: %w", err)
+		}
+
+		var next []uuid.UUID
+		for _, link := range links {
+			for _, id := range []uuid.UUID{link.SourceTaskID, link.TargetTaskID} {
+				if !visited[id] && len(visited) < MaxDependencyGraphNodes {
+					visited[id] = true
+					next = append(next, id)
+				}
+			}
+			edges[models.DependencyEdge{From: link.SourceTaskID, To: link.TargetTaskID}] = true
+		}
+		frontier = next
+	}
+
+	ids := make([]uuid.UUID, 0, len(visited))
+	for id := range visited {
+		ids = append(ids, id)
+	}
+	tasks, err := s.accessibleTasks(ctx, ids, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.dependencyNodes(ctx, tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &models.DependencyGraph{Nodes: nodes, Edges: []models.DependencyEdge{}}
+	present := make(map[uuid.UUID]bool, len(nodes))
+	for _, node := range nodes {
+		present[node.TaskID] = true
+	}
+	for edge := range edges {
+		if present[edge.From] && present[edge.To] {
+			graph.Edges = append(graph.Edges, edge)
+		}
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From.String() < graph.Edges[j].From.String()
+		}
+		return graph.Edges[i].To.String() < graph.Edges[j].To.String()
+	})
+	graph.Cycles = graph.DetectCycles()
+	return graph, nil
+}
+
+// blockingPathExists 检查 from 是否经由 blocks 链接阻塞（直接或间接）to
+func (s *agileServiceImpl) blockingPathExists(ctx context.Context, tx *gorm.DB, from, to uuid.UUID) (bool, error) {
+	var exists bool
+	err := tx.WithContext(ctx).Raw(`
+		WITH RECURSIVE downstream(id) AS (
+			SELECT ?::uuid
+			UNION
+			SELECT l.target_task_id FROM task_links l JOIN downstream d ON l.source_task_id = d.id
+			WHERE l.link_type = ?
+		)
+		SELECT EXISTS (SELECT 1 FROM downstream WHERE id = ?)`,
+		from, models.TaskLinkBlocks, to).Scan(&exists).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check blocking cycle: %w", err)
+	}
+	return exists, nil
+}
+
+// unresolvedBlockers 获取阻塞任务中尚未解决的任务。
+// 阻塞任务可能位于调用者无权访问的项目，这些任务仍会阻止转换，但隐藏编号和标题
+func (s *agileServiceImpl) unresolvedBlockers(ctx context.Context, taskID uuid.UUID, userID, tenantID uuid.UUID) ([]models.DependencyNode, error) {
+	var blockers []models.AgileTask
+	if err := s.db.WithContext(ctx).
+		Joins("JOIN task_links l ON l.source_task_id = agile_tasks.id").
+		Where("l.target_task_id = ? AND l.link_type = ? AND agile_tasks.deleted_at IS NULL", taskID, models.TaskLinkBlocks).
+		Find(&blockers).Error; err != nil {
+		return nil, fmt.Errorf("failed to load blocking tasks: %w", err)
+	}
+
+	nodes, err := s.dependencyNodes(ctx, blockers)
+	if err != nil {
+		return nil, err
+	}
+
+	access := make(map[uuid.UUID]bool)
+	var unresolved []models.DependencyNode
+	for _, node := range nodes {
+		if node.Resolved {
+			continue
+		}
+		allowed, err := s.cachedProjectAccess(ctx, access, node.ProjectID, userID, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			node.Key = ""
+			node.Title = ""
+		}
+		unresolved = append(unresolved, node)
+	}
+	return unresolved, nil
+}
+
+// blockedReason 生成阻塞提示，例如 "blocked by PROJ-1, PROJ-7"；
+// 无权访问的阻塞任务只给出数量，例如 "blocked by PROJ-1 and 2 task(s) in other projects"
+func blockedReason(blockers []models.DependencyNode) string {
+	keys := make([]string, 0, len(blockers))
+	hidden := 0
+	for _, blocker := range blockers {
+		if blocker.Key == "" {
+			hidden++
+			continue
+		}
+		keys = append(keys, blocker.Key)
+	}
+
+	switch {
+	case hidden == 0:
+		return "blocked by " + strings.Join(keys, ", ")
+	case len(keys) == 0:
+		return fmt.Sprintf("blocked by %d task(s) in other projects", hidden)
+	default:
+		return fmt.Sprintf("blocked by %s and %d task(s) in other projects", strings.Join(keys, ", "), hidden)
+	}
+}
+
+// accessibleTasks 加载未删除且调用者有权访问其项目的任务
+func (s *agileServiceImpl) accessibleTasks(ctx context.Context, taskIDs []uuid.UUID, userID, tenantID uuid.UUID) ([]models.AgileTask, error) {
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+
+	var tasks []models.AgileTask
+	if err := s.db.WithContext(ctx).
+		Where("id IN ? AND deleted_at IS NULL", taskIDs).
+		Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	access := make(map[uuid.UUID]bool)
+	accessible := tasks[:0]
+	for _, task := range tasks {
+		allowed, err := s.cachedProjectAccess(ctx, access, task.ProjectID, userID, tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			accessible = append(accessible, task)
+		}
+	}
+	return accessible, nil
+}
+
+// cachedProjectAccess 检查调用者能否访问项目，结果按项目缓存在access中
+func (s *agileServiceImpl) cachedProjectAccess(ctx context.Context, access map[uuid.UUID]bool, projectID, userID, tenantID uuid.UUID) (bool, error) {
+	if allowed, ok := access[projectID]; ok {
+		return allowed, nil
+	}
+	err := s.checkProjectAccess(ctx, projectID, userID, tenantID)
+	if err != nil && err.Error() != "no access to project" {
+		return false, err
+	}
+	access[projectID] = err == nil
+	return err == nil, nil
+}
+
+// dependencyNodes 将任务转换为依赖图节点，按任务所在工作流的状态分类判断是否已解决
+func (s *agileServiceImpl) dependencyNodes(ctx context.Context, tasks []models.AgileTask) ([]models.DependencyNode, error) {
+	keys := make(map[uuid.UUID]string)
+	workflows := make(map[uuid.UUID][]models.Workflow)
+
+	nodes := make([]models.DependencyNode, 0, len(tasks))
+	for _, task := range tasks {
+		if _, ok := keys[task.ProjectID]; !ok {
+			key, err := s.projectKey(ctx, task.ProjectID)
+			if err != nil {
+				return nil, err
+			}
+			projectWorkflows, err := s.projectWorkflows(ctx, s.db, task.ProjectID)
+			if err != nil {
+				return nil, err
+			}
+			keys[task.ProjectID] = key
+			workflows[task.ProjectID] = projectWorkflows
+		}
+
+		resolved := false
+		if status := models.SelectWorkflow(workflows[task.ProjectID], task.Type).Status(task.Status); status != nil {
+			resolved = status.Category == models.StatusCategoryDone
+		}
+		nodes = append(nodes, models.DependencyNode{
+			TaskID:    task.ID,
+			ProjectID: task.ProjectID,
+			SprintID:  task.SprintID,
+			Key:       models.TaskKey(keys[task.ProjectID], task.TaskNumber),
+			Title:     task.Title,
+			Status:    task.Status,
+			Resolved:  resolved,
+		})
+	}
+
+	// 按项目编号和任务序号排序，PROJ-2 排在 PROJ-10 之前
+	numbers := make(map[uuid.UUID]int64, len(tasks))
+	for _, task := range tasks {
+		numbers[task.ID] = task.TaskNumber
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		pi, pj := keys[nodes[i].ProjectID], keys[nodes[j].ProjectID]
+		if pi != pj {
+			return pi < pj
+		}
+		return numbers[nodes[i].TaskID] < numbers[nodes[j].TaskID]
+	})
+	return nodes, nil
+}
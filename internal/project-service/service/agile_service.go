@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/client"
 	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -35,6 +36,43 @@ type AgileService interface {
 
 	// 任务状态转换
 	TransitionTask(ctx context.Context, taskID uuid.UUID, newStatus string, userID, tenantID uuid.UUID) error
+	GetTaskTransitions(ctx context.Context, taskID uuid.UUID, userID, tenantID uuid.UUID) (*TaskTransitionsResponse, error)
+
+	// 任务链接和依赖
+	CreateTaskLink(ctx context.Context, taskID uuid.UUID, req *CreateTaskLinkRequest, userID, tenantID uuid.UUID) (*TaskLinkView, error)
+	ListTaskLinks(ctx context.Context, taskID uuid.UUID, userID, tenantID uuid.UUID) (*TaskLinksResponse, error)
+	DeleteTaskLink(ctx context.Context, taskID, linkID uuid.UUID, userID, tenantID uuid.UUID) error
+	GetTaskDependencyGraph(ctx context.Context, taskID uuid.UUID, depth int, userID, tenantID uuid.UUID) (*models.DependencyGraph, error)
+	GetSprintDependencies(ctx context.Context, sprintID uuid.UUID, userID, tenantID uuid.UUID) (*SprintDependencyReport, error)
+
+	// 工作流管理
+	CreateWorkflow(ctx context.Context, req *CreateWorkflowRequest, userID, tenantID uuid.UUID) (*models.Workflow, error)
+	GetWorkflow(ctx context.Context, workflowID uuid.UUID, userID, tenantID uuid.UUID) (*models.Workflow, error)
+	ListWorkflows(ctx context.Context, projectID uuid.UUID, userID, tenantID uuid.UUID) ([]models.Workflow, error)
+	UpdateWorkflow(ctx context.Context, workflowID uuid.UUID, req *UpdateWorkflowRequest, userID, tenantID uuid.UUID) (*models.Workflow, error)
+	DeleteWorkflow(ctx context.Context, workflowID uuid.UUID, userID, tenantID uuid.UUID) error
+
+	// 保存的过滤器
+	CreateSavedFilter(ctx context.Context, req *CreateSavedFilterRequest, userID, tenantID uuid.UUID) (*models.SavedFilter, error)
+	GetSavedFilter(ctx context.Context, filterID uuid.UUID, userID, tenantID uuid.UUID) (*models.SavedFilter, error)
+	ListSavedFilters(ctx context.Context, projectID uuid.UUID, userID, tenantID uuid.UUID) ([]models.SavedFilter, error)
+	UpdateSavedFilter(ctx context.Context, filterID uuid.UUID, req *UpdateSavedFilterRequest, userID, tenantID uuid.UUID) (*models.SavedFilter, error)
+	DeleteSavedFilter(ctx context.Context, filterID uuid.UUID, userID, tenantID uuid.UUID) error
+	GetSavedFilterStatistics(ctx context.Context, filterID uuid.UUID, groupBy string, userID, tenantID uuid.UUID) (*FilterStatistics, error)
+
+	// 任务自定义字段
+	CreateCustomField(ctx context.Context, req *CreateCustomFieldRequest, userID, tenantID uuid.UUID) (*models.CustomField, error)
+	ListCustomFields(ctx context.Context, projectID uuid.UUID, userID, tenantID uuid.UUID) ([]models.CustomField, error)
+	UpdateCustomField(ctx context.Context, fieldID uuid.UUID, req *UpdateCustomFieldRequest, userID, tenantID uuid.UUID) (*models.CustomField, error)
+	DeleteCustomField(ctx context.Context, fieldID uuid.UUID, userID, tenantID uuid.UUID) error
+
+	// 任务导出
+	ExportTasks(ctx context.Context, filter *TaskFilter, userID, tenantID uuid.UUID) (*TaskExport, error)
+
+	// 任务变更历史
+	GetTaskHistory(ctx context.Context, taskID uuid.UUID, userID, tenantID uuid.UUID) ([]models.TaskChange, error)
+	ListProjectActivity(ctx context.Context, filter *ActivityFilter, page, pageSize int, userID, tenantID uuid.UUID) (*ActivityListResponse, error)
+	GetTaskFlowMetrics(ctx context.Context, projectID uuid.UUID, startDate, endDate time.Time, userID, tenantID uuid.UUID) (*TaskFlowMetrics, error)
 
 	// 任务排序（拖拽）
 	ReorderTasks(ctx context.Context, req *ReorderTasksRequest, userID, tenantID uuid.UUID) error
@@ -68,6 +106,7 @@ type AgileService interface {
 
 	// 看板统计
 	GetBoardStatistics(ctx context.Context, boardID uuid.UUID, userID, tenantID uuid.UUID) (*BoardStatistics, error)
+	GetBoardSwimlanes(ctx context.Context, boardID uuid.UUID, userID, tenantID uuid.UUID) (*BoardSwimlanesResponse, error)
 
 	// 工作日志管理
 	LogWork(ctx context.Context, req *LogWorkRequest, userID, tenantID uuid.UUID) (*models.WorkLog, error)
@@ -96,13 +135,17 @@ type AgileService interface {
 // agileServiceImpl 敏捷开发服务实现
 type agileServiceImpl struct {
 	db     *gorm.DB
+	git    client.GitGatewayClient // 查询关联的合并请求
+	events client.EventPublisher   // 发布工作流通知事件，为空时不发送通知
 	logger *zap.Logger
 }
 
 // NewAgileService 创建敏捷开发服务
-func NewAgileService(db *gorm.DB, logger *zap.Logger) AgileService {
+func NewAgileService(db *gorm.DB, git client.GitGatewayClient, events client.EventPublisher, logger *zap.Logger) AgileService {
 	return &agileServiceImpl{
 		db:     db,
+		git:    git,
+		events: events,
 		logger: logger,
 	}
 }
@@ -323,6 +366,12 @@ func (s *agileServiceImpl) CreateTask(ctx context.Context, req *CreateTaskReques
 		return nil, err
 	}
 
+	// 新任务使用工作流的初始状态
+	workflow, err := s.taskWorkflow(ctx, req.ProjectID, req.Type)
+	if err != nil {
+		return nil, err
+	}
+
 	// 生成排序权重（简化实现，实际应使用Lexorank算法）
 	rank := s.generateRank(ctx, req.ProjectID)
 
@@ -334,7 +383,7 @@ func (s *agileServiceImpl) CreateTask(ctx context.Context, req *CreateTaskReques
 		Title:              req.Title,
 		Description:        req.Description,
 		Type:               req.Type,
-		Status:             models.TaskStatusTodo,
+		Status:             workflow.InitialStatus,
 		Priority:           req.Priority,
 		StoryPoints:        req.StoryPoints,
 		OriginalEstimate:   req.OriginalEstimate,
@@ -346,9 +395,20 @@ func (s *agileServiceImpl) CreateTask(ctx context.Context, req *CreateTaskReques
 		Rank:               rank,
 	}
 
-	if err := s.db.WithContext(ctx).Create(task).Error; err != nil {
+	// 校验自定义字段，缺失的字段使用默认值
+	if task.CustomFields, err = s.resolveTaskCustomFields(ctx, task, req.CustomFields, true); err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(task).Error; err != nil {
+			return fmt.Errorf("failed to create task: %w", err)
+		}
+		return s.recordTaskChanges(ctx, tx, nil, task.ID, userID)
+	})
+	if err != nil {
 		s.logger.Error("创建任务失败", zap.Error(err))
-		return nil, fmt.Errorf("failed to create task: %w", err)
+		return nil, err
 	}
 
 	// 加载关联数据
@@ -395,6 +455,12 @@ func (s *agileServiceImpl) ListTasks(ctx context.Context, filter *TaskFilter, pa
 		return nil, err
 	}
 
+	// 编译查询语句和保存的过滤器
+	compiled, err := s.taskListQuery(ctx, filter, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
 	var tasks []models.AgileTask
 	var total int64
 
@@ -404,6 +470,12 @@ func (s *agileServiceImpl) ListTasks(ctx context.Context, filter *TaskFilter, pa
 
 	// 应用过滤条件
 	s.applyTaskFilters(baseQuery, filter)
+	baseQuery = applyCompiledTaskQuery(baseQuery, compiled)
+
+	order := "rank ASC, created_at DESC"
+	if compiled.OrderBy != "" {
+		order = compiled.OrderBy + ", " + order
+	}
 
 	// 获取总数
 	if err := baseQuery.Count(&total).Error; err != nil {
@@ -417,7 +489,7 @@ func (s *agileServiceImpl) ListTasks(ctx context.Context, filter *TaskFilter, pa
 		Preload("Epic").
 		Preload("Assignee").
 		Preload("Reporter").
-		Order("rank ASC, created_at DESC").
+		Order(order).
 		Offset(offset).
 		Limit(pageSize).
 		Find(&tasks).Error; err != nil {
@@ -455,12 +527,13 @@ func (s *agileServiceImpl) UpdateTask(ctx context.Context, taskID uuid.UUID, req
 	if req.Description != nil {
 		updates["description"] = req.Description
 	}
-	if req.Status != nil {
-		// 验证状态转换是否合法
-		if !task.CanTransitionTo(*req.Status) {
-			return nil, fmt.Errorf("invalid status transition from %s to %s", task.Status, *req.Status)
+	var plan *transitionPlan
+	if req.Status != nil && *req.Status != task.Status {
+		// 按工作流验证状态转换
+		var err error
+		if plan, err = s.planTransition(ctx, &task, *req.Status, userID, tenantID); err != nil {
+			return nil, err
 		}
-		updates["status"] = *req.Status
 	}
 	if req.Priority != nil {
 		updates["priority"] = *req.Priority
@@ -492,9 +565,41 @@ func (s *agileServiceImpl) UpdateTask(ctx context.Context, taskID uuid.UUID, req
 	if req.AcceptanceCriteria != nil {
 		updates["acceptance_criteria"] = req.AcceptanceCriteria
 	}
+	var customFields map[string]interface{}
+	if req.CustomFields != nil {
+		var err error
+		if customFields, err = s.resolveTaskCustomFields(ctx, &task, req.CustomFields, false); err != nil {
+			return nil, err
+		}
+	}
+	if plan != nil {
+		// 后置动作覆盖请求中的同名字段
+		for field, value := range plan.updates {
+			updates[field] = value
+		}
+	}
 
-	if err := s.db.WithContext(ctx).Model(&task).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("failed to update task: %w", err)
+	before := task
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&task).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update task: %w", err)
+			}
+		}
+		if customFields != nil {
+			// 结构体更新才会经过jsonb序列化
+			task.CustomFields = customFields
+			if err := tx.Model(&task).Select("custom_fields").Updates(&task).Error; err != nil {
+				return fmt.Errorf("failed to update task custom fields: %w", err)
+			}
+		}
+		return s.recordTaskChanges(ctx, tx, &before, task.ID, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil {
+		s.notifyTransition(ctx, plan, userID, tenantID)
 	}
 
 	// 重新加载任务数据
@@ -527,6 +632,31 @@ func (s *agileServiceImpl) checkProjectAccess(ctx context.Context, projectID, us
 	return nil
 }
 
+// projectManagerRoles 可以管理项目配置的成员角色
+var projectManagerRoles = []string{"admin", "manager"}
+
+// checkProjectManager 校验用户是项目负责人，或在项目中担任管理员、项目经理角色
+func (s *agileServiceImpl) checkProjectManager(ctx context.Context, projectID, userID, tenantID uuid.UUID) error {
+	var count int64
+	err := s.db.WithContext(ctx).
+		Table("projects p").
+		Joins("LEFT JOIN project_members pm ON p.id = pm.project_id AND pm.user_id = ?", userID).
+		Joins("LEFT JOIN roles r ON pm.role_id = r.id").
+		Where("p.id = ? AND p.tenant_id = ? AND (p.manager_id = ? OR r.name IN ?)",
+			projectID, tenantID, userID, projectManagerRoles).
+		Count(&count).Error
+
+	if err != nil {
+		return fmt.Errorf("failed to check project role: %w", err)
+	}
+
+	if count == 0 {
+		return models.ErrWorkflowManageDenied
+	}
+
+	return nil
+}
+
 func (s *agileServiceImpl) generateRank(ctx context.Context, projectID uuid.UUID) string {
 	// 简化的排序权重生成，实际应使用Lexorank算法
 	var maxRank string
@@ -599,12 +729,17 @@ func (s *agileServiceImpl) DeleteTask(ctx context.Context, taskID uuid.UUID, use
 		return err
 	}
 
-	// 软删除
-	if err := s.db.WithContext(ctx).Model(&task).Update("deleted_at", time.Now()).Error; err != nil {
-		return fmt.Errorf("failed to delete task: %w", err)
-	}
-
-	return nil
+	// 软删除，同时记录删除历史
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&task).Update("deleted_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
+		deletion := models.NewTaskDeletion(&task, userID)
+		if err := tx.Create(&deletion).Error; err != nil {
+			return fmt.Errorf("failed to record task changes: %w", err)
+		}
+		return nil
+	})
 }
 
 func (s *agileServiceImpl) TransitionTask(ctx context.Context, taskID uuid.UUID, newStatus string, userID, tenantID uuid.UUID) error {
@@ -619,15 +754,24 @@ func (s *agileServiceImpl) TransitionTask(ctx context.Context, taskID uuid.UUID,
 		return err
 	}
 
-	// 验证状态转换是否合法
-	if !task.CanTransitionTo(newStatus) {
-		return fmt.Errorf("invalid status transition from %s to %s", task.Status, newStatus)
+	// 按工作流验证状态转换
+	plan, err := s.planTransition(ctx, &task, newStatus, userID, tenantID)
+	if err != nil {
+		return err
 	}
 
-	// 更新状态
-	if err := s.db.WithContext(ctx).Model(&task).Update("status", newStatus).Error; err != nil {
-		return fmt.Errorf("failed to transition task: %w", err)
+	// 更新状态并执行后置动作
+	before := task
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&task).Updates(plan.updates).Error; err != nil {
+			return fmt.Errorf("failed to transition task: %w", err)
+		}
+		return s.recordTaskChanges(ctx, tx, &before, task.ID, userID)
+	})
+	if err != nil {
+		return err
 	}
+	s.notifyTransition(ctx, plan, userID, tenantID)
 
 	return nil
 }
@@ -923,6 +1067,12 @@ func (s *agileServiceImpl) UpdateBoard(ctx context.Context, boardID uuid.UUID, r
 		return nil, err
 	}
 
+	if req.SwimlaneFilterIDs != nil {
+		if err := s.validateSwimlaneFilters(ctx, board.ProjectID, *req.SwimlaneFilterIDs); err != nil {
+			return nil, err
+		}
+	}
+
 	// 更新字段
 	updates := make(map[string]interface{})
 	if req.Name != nil {
@@ -932,8 +1082,18 @@ func (s *agileServiceImpl) UpdateBoard(ctx context.Context, boardID uuid.UUID, r
 		updates["description"] = req.Description
 	}
 
-	if err := s.db.WithContext(ctx).Model(&board).Updates(updates).Error; err != nil {
-		return nil, fmt.Errorf("failed to update board: %w", err)
+	if len(updates) > 0 {
+		if err := s.db.WithContext(ctx).Model(&board).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update board: %w", err)
+		}
+	}
+
+	if req.SwimlaneFilterIDs != nil {
+		// 结构体更新才会经过jsonb序列化
+		board.SwimlaneFilterIDs = *req.SwimlaneFilterIDs
+		if err := s.db.WithContext(ctx).Model(&board).Select("swimlane_filter_ids").Updates(&board).Error; err != nil {
+			return nil, fmt.Errorf("failed to update board swimlanes: %w", err)
+		}
 	}
 
 	return &board, nil
@@ -1033,28 +1193,9 @@ func (s *agileServiceImpl) GetProjectStatistics(ctx context.Context, projectID u
 	return statistics, nil
 }
 
-// UpdateTaskStatus 更新任务状态
+// UpdateTaskStatus 更新任务状态，与 TransitionTask 一样经过工作流校验
 func (s *agileServiceImpl) UpdateTaskStatus(ctx context.Context, taskID uuid.UUID, status string, userID, tenantID uuid.UUID) error {
-	var task models.AgileTask
-
-	if err := s.db.WithContext(ctx).First(&task, "id = ? AND deleted_at IS NULL", taskID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("task not found")
-		}
-		return fmt.Errorf("failed to get task: %w", err)
-	}
-
-	// 检查项目访问权限
-	if err := s.checkProjectAccess(ctx, task.ProjectID, userID, tenantID); err != nil {
-		return err
-	}
-
-	// 更新状态
-	if err := s.db.WithContext(ctx).Model(&task).Update("status", status).Error; err != nil {
-		return fmt.Errorf("failed to update task status: %w", err)
-	}
-
-	return nil
+	return s.TransitionTask(ctx, taskID, status, userID, tenantID)
 }
 
 // AssignTask 分配任务
@@ -1074,11 +1215,13 @@ func (s *agileServiceImpl) AssignTask(ctx context.Context, taskID uuid.UUID, ass
 	}
 
 	// 分配任务
-	if err := s.db.WithContext(ctx).Model(&task).Update("assignee_id", assigneeID).Error; err != nil {
-		return fmt.Errorf("failed to assign task: %w", err)
-	}
-
-	return nil
+	before := task
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&task).Update("assignee_id", assigneeID).Error; err != nil {
+			return fmt.Errorf("failed to assign task: %w", err)
+		}
+		return s.recordTaskChanges(ctx, tx, &before, task.ID, userID)
+	})
 }
 
 // GetUserWorkload 获取用户工作负载
@@ -1388,12 +1531,16 @@ func (s *agileServiceImpl) MoveTask(ctx context.Context, req *TaskMoveRequest, u
 	updates := make(map[string]interface{})
 
 	// 如果移动到不同状态
+	var plan *transitionPlan
 	if req.TargetStatus != nil && task.Status != *req.TargetStatus {
-		// 验证状态转换是否合法
-		if !task.CanTransitionTo(*req.TargetStatus) {
-			return fmt.Errorf("invalid status transition from %s to %s", task.Status, *req.TargetStatus)
+		// 按工作流验证状态转换
+		var err error
+		if plan, err = s.planTransition(ctx, &task, *req.TargetStatus, userID, tenantID); err != nil {
+			return err
+		}
+		for field, value := range plan.updates {
+			updates[field] = value
 		}
-		updates["status"] = *req.TargetStatus
 	}
 
 	// 如果移动到不同Sprint
@@ -1428,9 +1575,19 @@ func (s *agileServiceImpl) MoveTask(ctx context.Context, req *TaskMoveRequest, u
 
 	updates["rank"] = newRank
 
-	// 执行更新
-	if err := s.db.WithContext(ctx).Model(&task).Updates(updates).Error; err != nil {
-		return fmt.Errorf("failed to move task: %w", err)
+	// 执行更新，仅调整排序时不产生变更记录
+	before := task
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&task).Updates(updates).Error; err != nil {
+			return fmt.Errorf("failed to move task: %w", err)
+		}
+		return s.recordTaskChanges(ctx, tx, &before, task.ID, userID)
+	})
+	if err != nil {
+		return err
+	}
+	if plan != nil {
+		s.notifyTransition(ctx, plan, userID, tenantID)
 	}
 
 	return nil
@@ -1462,6 +1619,19 @@ func (s *agileServiceImpl) BatchMoveTasks(ctx context.Context, req *BatchTaskMov
 		return fmt.Errorf("target column not found")
 	}
 
+	// 所有任务的状态转换都通过工作流校验后才开始移动
+	plans := make(map[uuid.UUID]*transitionPlan, len(tasks))
+	for i := range tasks {
+		if tasks[i].Status == targetColumn.Status {
+			continue
+		}
+		plan, err := s.planTransition(ctx, &tasks[i], targetColumn.Status, userID, tenantID)
+		if err != nil {
+			return fmt.Errorf("cannot move task %s: %w", tasks[i].ID, err)
+		}
+		plans[tasks[i].ID] = plan
+	}
+
 	// 批量移动任务
 	tx := s.db.WithContext(ctx).Begin()
 	defer func() {
@@ -1475,10 +1645,20 @@ func (s *agileServiceImpl) BatchMoveTasks(ctx context.Context, req *BatchTaskMov
 		basePosition = *req.NewPosition
 	}
 
+	befores := make(map[uuid.UUID]models.AgileTask, len(tasks))
+	for _, task := range tasks {
+		befores[task.ID] = task
+	}
+
 	for i, taskID := range req.TaskIDs {
 		updates := map[string]interface{}{
-			"status": targetColumn.Status,
-			"rank":   fmt.Sprintf("batch_move_%d_%d", time.Now().Unix(), basePosition+i),
+			"rank": fmt.Sprintf("batch_move_%d_%d", time.Now().Unix(), basePosition+i),
+		}
+		plan, ok := plans[taskID]
+		if ok {
+			for field, value := range plan.updates {
+				updates[field] = value
+			}
 		}
 
 		if err := tx.Model(&models.AgileTask{}).
@@ -1487,11 +1667,23 @@ func (s *agileServiceImpl) BatchMoveTasks(ctx context.Context, req *BatchTaskMov
 			tx.Rollback()
 			return fmt.Errorf("failed to batch move task: %w", err)
 		}
+
+		// 只有状态变化的任务才有字段变更
+		if ok {
+			before := befores[taskID]
+			if err := s.recordTaskChanges(ctx, tx, &before, taskID, userID); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		return fmt.Errorf("failed to commit batch move: %w", err)
 	}
+	for _, plan := range plans {
+		s.notifyTransition(ctx, plan, userID, tenantID)
+	}
 
 	return nil
 }
@@ -0,0 +1,541 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/client"
+	"github.com/cloud-platform/collaborative-dev/internal/project-service/models"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 工作流管理。
+// 每个项目可以为不同任务类型配置工作流，未覆盖的类型使用项目默认工作流（task_types 为空），
+// 都没有时使用与原有状态机一致的内置工作流。所有修改任务状态的入口都通过 planTransition 校验。
+// 工作流包含转换条件，只有项目负责人和担任管理员、项目经理角色的成员可以创建、修改和删除。
+
+// TaskTransitionNotificationType 工作流通知事件的类型，由通知服务消费
+const TaskTransitionNotificationType = "project.task.transitioned"
+
+// taskTransitionedEventData 任务转换事件数据，RecipientIDs 为需要通知的用户
+type taskTransitionedEventData struct {
+	TaskID       uuid.UUID   `json:"task_id"`
+	TaskKey      string      `json:"task_key"`
+	TaskTitle    string      `json:"task_title"`
+	ProjectID    uuid.UUID   `json:"project_id"`
+	Transition   string      `json:"transition"`
+	From         string      `json:"from"`
+	FromName     string      `json:"from_name"`
+	To           string      `json:"to"`
+	ToName       string      `json:"to_name"`
+	ActorID      uuid.UUID   `json:"actor_id"`
+	RecipientIDs []uuid.UUID `json:"recipient_ids"`
+}
+
+// transitionPlan 一次状态转换的执行计划
+type transitionPlan struct {
+	task       *models.AgileTask
+	workflow   *models.Workflow
+	transition *models.WorkflowTransition
+	from       string
+	updates    map[string]interface{}
+}
+
+func (s *agileServiceImpl) CreateWorkflow(ctx context.Context, req *CreateWorkflowRequest, userID, tenantID uuid.UUID) (*models.Workflow, error) {
+	if err := s.checkProjectAccess(ctx, req.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectManager(ctx, req.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	workflow := &models.Workflow{
+		ProjectID:     req.ProjectID,
+		Name:          req.Name,
+		Description:   req.Description,
+		TaskTypes:     req.TaskTypes,
+		InitialStatus: req.InitialStatus,
+		Statuses:      req.Statuses,
+		Transitions:   req.Transitions,
+		CreatedBy:     &userID,
+	}
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		workflows, err := s.lockProjectWorkflows(ctx, tx, req.ProjectID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkWorkflowChange(ctx, tx, req.ProjectID, workflows, workflow); err != nil {
+			return err
+		}
+		if err := tx.Create(workflow).Error; err != nil {
+			return fmt.Errorf("failed to create workflow: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("创建工作流",
+		zap.String("project_id", req.ProjectID.String()),
+		zap.String("workflow_id", workflow.ID.String()),
+		zap.Strings("task_types", workflow.TaskTypes))
+	return workflow, nil
+}
+
+func (s *agileServiceImpl) GetWorkflow(ctx context.Context, workflowID uuid.UUID, userID, tenantID uuid.UUID) (*models.Workflow, error) {
+	workflow, err := s.getWorkflow(ctx, s.db, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectAccess(ctx, workflow.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+func (s *agileServiceImpl) ListWorkflows(ctx context.Context, projectID uuid.UUID, userID, tenantID uuid.UUID) ([]models.Workflow, error) {
+	if err := s.checkProjectAccess(ctx, projectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+	return s.projectWorkflows(ctx, s.db, projectID)
+}
+
+func (s *agileServiceImpl) UpdateWorkflow(ctx context.Context, workflowID uuid.UUID, req *UpdateWorkflowRequest, userID, tenantID uuid.UUID) (*models.Workflow, error) {
+	workflow, err := s.GetWorkflow(ctx, workflowID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkProjectManager(ctx, workflow.ProjectID, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		workflow.Name = *req.Name
+	}
+	if req.Description != nil {
+		workflow.Description = req.Description
+	}
+	if req.TaskTypes != nil {
+		workflow.TaskTypes = *req.TaskTypes
+	}
+	if req.InitialStatus != nil {
+		workflow.InitialStatus = *req.InitialStatus
+	}
+	if req.Statuses != nil {
+		workflow.Statuses = req.Statuses
+	}
+	if req.Transitions != nil {
+		workflow.Transitions = *req.Transitions
+	}
+	if err := workflow.Validate(); err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		workflows, err := s.lockProjectWorkflows(ctx, tx, workflow.ProjectID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkWorkflowChange(ctx, tx, workflow.ProjectID, withoutWorkflow(workflows, workflow.ID), workflow); err != nil {
+			return err
+		}
+
+		// 结构体更新才会经过jsonb序列化
+		if err := tx.Model(workflow).
+			Select("name", "description", "task_types", "initial_status", "statuses", "transitions").
+			Updates(workflow).Error; err != nil {
+			return fmt.Errorf("failed to update workflow: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+func (s *agileServiceImpl) DeleteWorkflow(ctx context.Context, workflowID uuid.UUID, userID, tenantID uuid.UUID) error {
+	workflow, err := s.GetWorkflow(ctx, workflowID, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkProjectManager(ctx, workflow.ProjectID, userID, tenantID); err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		workflows, err := s.lockProjectWorkflows(ctx, tx, workflow.ProjectID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkWorkflowChange(ctx, tx, workflow.ProjectID, withoutWorkflow(workflows, workflow.ID), nil); err != nil {
+			return err
+		}
+		if err := tx.Model(workflow).Update("deleted_at", time.Now()).Error; err != nil {
+			return fmt.Errorf("failed to delete workflow: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetTaskTransitions 获取任务当前可用的转换，以及每个转换的条件是否满足
+func (s *agileServiceImpl) GetTaskTransitions(ctx context.Context, taskID uuid.UUID, userID, tenantID uuid.UUID) (*TaskTransitionsResponse, error) {
+	task, err := s.GetTask(ctx, taskID, userID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow, err := s.taskWorkflow(ctx, task.ProjectID, task.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &TaskTransitionsResponse{
+		TaskID:       task.ID,
+		Status:       task.Status,
+		WorkflowName: workflow.Name,
+		Transitions:  []TaskTransitionOption{},
+	}
+	if workflow.ID != uuid.Nil {
+		resp.WorkflowID = &workflow.ID
+	}
+
+	// 未解决的阻塞任务，仅在有转换进入完成分类时查询
+	var blockers []models.DependencyNode
+	blockersLoaded := false
+
+	for _, transition := range workflow.AvailableTransitions(task.Status) {
+		option := TaskTransitionOption{
+			Name:    transition.Name,
+			To:      transition.To,
+			ToName:  workflow.Status(transition.To).Name,
+			Allowed: true,
+		}
+		if err := s.checkTransitionConditions(ctx, task, &transition, userID, tenantID); err != nil {
+			if !errors.Is(err, models.ErrTransitionConditionFailed) {
+				return nil, err
+			}
+			option.Allowed = false
+			option.Reason = err.Error()
+		}
+
+		// 没有 not_blocked 条件的工作流只提示，不阻止完成被阻塞的任务
+		if option.Allowed && workflow.Status(transition.To).Category == models.StatusCategoryDone {
+			if !blockersLoaded {
+				if blockers, err = s.unresolvedBlockers(ctx, task.ID, userID, tenantID); err != nil {
+					return nil, err
+				}
+				blockersLoaded = true
+			}
+			if len(blockers) > 0 {
+				option.Warning = blockedReason(blockers)
+			}
+		}
+		resp.Transitions = append(resp.Transitions, option)
+	}
+	return resp, nil
+}
+
+// getWorkflow 按ID获取工作流
+func (s *agileServiceImpl) getWorkflow(ctx context.Context, db *gorm.DB, workflowID uuid.UUID) (*models.Workflow, error) {
+	var workflow models.Workflow
+	if err := db.WithContext(ctx).First(&workflow, "id = ? AND deleted_at IS NULL", workflowID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrWorkflowNotFound
+		}
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+	return &workflow, nil
+}
+
+// projectWorkflows 获取项目配置的全部工作流
+func (s *agileServiceImpl) projectWorkflows(ctx context.Context, db *gorm.DB, projectID uuid.UUID) ([]models.Workflow, error) {
+	var workflows []models.Workflow
+	if err := db.WithContext(ctx).
+		Where("project_id = ? AND deleted_at IS NULL", projectID).
+		Order("created_at ASC").
+		Find(&workflows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	return workflows, nil
+}
+
+// lockProjectWorkflows 锁定项目后读取工作流，避免并发配置出重复的任务类型
+func (s *agileServiceImpl) lockProjectWorkflows(ctx context.Context, tx *gorm.DB, projectID uuid.UUID) ([]models.Workflow, error) {
+	if err := tx.Exec("SELECT id FROM projects WHERE id = ? FOR UPDATE", projectID).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock project: %w", err)
+	}
+	return s.projectWorkflows(ctx, tx, projectID)
+}
+
+// taskWorkflow 获取任务类型适用的工作流
+func (s *agileServiceImpl) taskWorkflow(ctx context.Context, projectID uuid.UUID, taskType string) (*models.Workflow, error) {
+	workflows, err := s.projectWorkflows(ctx, s.db, projectID)
+	if err != nil {
+		return nil, err
+	}
+	return models.SelectWorkflow(workflows, taskType), nil
+}
+
+// withoutWorkflow 去掉指定ID的工作流
+func withoutWorkflow(workflows []models.Workflow, workflowID uuid.UUID) []models.Workflow {
+	others := make([]models.Workflow, 0, len(workflows))
+	for _, w := range workflows {
+		if w.ID != workflowID {
+			others = append(others, w)
+		}
+	}
+	return others
+}
+
+// checkWorkflowChange 校验工作流变更：任务类型不能与其他工作流重复，
+// 且变更后项目中现有任务的状态必须仍然存在于各自适用的工作流中
+func (s *agileServiceImpl) checkWorkflowChange(ctx context.Context, tx *gorm.DB, projectID uuid.UUID, others []models.Workflow, changed *models.Workflow) error {
+	after := others
+	if changed != nil {
+		for i := range others {
+			if taskType, ok := changed.Overlaps(&others[i]); ok {
+				if taskType == "" {
+					return fmt.Errorf("%w: project already has a default workflow %q", models.ErrInvalidWorkflow, others[i].Name)
+				}
+				return fmt.Errorf("%w: task type %s already uses workflow %q", models.ErrInvalidWorkflow, taskType, others[i].Name)
+			}
+		}
+		after = append(append([]models.Workflow{}, others...), *changed)
+	}
+
+	var usages []struct {
+		Type   string
+		Status string
+	}
+	if err := tx.WithContext(ctx).
+		Model(&models.AgileTask{}).
+		Distinct("type", "status").
+		Where("project_id = ? AND deleted_at IS NULL", projectID).
+		Scan(&usages).Error; err != nil {
+		return fmt.Errorf("failed to get task statuses: %w", err)
+	}
+
+	for _, usage := range usages {
+		workflow := models.SelectWorkflow(after, usage.Type)
+		if !workflow.HasStatus(usage.Status) {
+			return fmt.Errorf("%w: %s tasks in status %s would not exist in workflow %q", models.ErrInvalidWorkflow, usage.Type, usage.Status, workflow.Name)
+		}
+	}
+	return nil
+}
+
+// planTransition 按任务适用的工作流校验状态转换及其条件，并生成需要写入的字段
+func (s *agileServiceImpl) planTransition(ctx context.Context, task *models.AgileTask, newStatus string, userID, tenantID uuid.UUID) (*transitionPlan, error) {
+	workflow, err := s.taskWorkflow(ctx, task.ProjectID, task.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	if !workflow.HasStatus(newStatus) {
+		return nil, fmt.Errorf("%w: status %s is not defined in workflow %q", models.ErrTransitionNotAllowed, newStatus, workflow.Name)
+	}
+	transition := workflow.FindTransition(task.Status, newStatus)
+	if transition == nil {
+		return nil, fmt.Errorf("%w from %s to %s", models.ErrTransitionNotAllowed, task.Status, newStatus)
+	}
+	if err := s.checkTransitionConditions(ctx, task, transition, userID, tenantID); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{"status": newStatus}
+	for _, fn := range transition.PostFunctions {
+		switch fn.Type {
+		case models.PostFunctionSetResolution:
+			updates["resolution"] = fn.Value
+			updates["resolved_at"] = time.Now()
+		case models.PostFunctionClearResolution:
+			updates["resolution"] = nil
+			updates["resolved_at"] = nil
+		case models.PostFunctionClearAssignee:
+			updates["assignee_id"] = nil
+		case models.PostFunctionAssignToActor:
+			updates["assignee_id"] = userID
+		}
+	}
+
+	return &transitionPlan{
+		task:       task,
+		workflow:   workflow,
+		transition: transition,
+		from:       task.Status,
+		updates:    updates,
+	}, nil
+}
+
+// checkTransitionConditions 检查转换条件，不满足时返回 ErrTransitionConditionFailed
+func (s *agileServiceImpl) checkTransitionConditions(ctx context.Context, task *models.AgileTask, transition *models.WorkflowTransition, userID, tenantID uuid.UUID) error {
+	for _, condition := range transition.Conditions {
+		switch condition.Type {
+		case models.ConditionAssigneeOnly:
+			if task.AssigneeID == nil || *task.AssigneeID != userID {
+				return fmt.Errorf("%w: only the assignee can perform %q", models.ErrTransitionConditionFailed, transition.Name)
+			}
+		case models.ConditionAcceptanceComplete:
+			if !task.AcceptanceCriteriaCompleted() {
+				return fmt.Errorf("%w: all acceptance criteria must be completed before %q", models.ErrTransitionConditionFailed, transition.Name)
+			}
+		case models.ConditionPullRequestMerged:
+			merged, err := s.hasMergedPullRequest(ctx, task)
+			if err != nil {
+				return err
+			}
+			if !merged {
+				return fmt.Errorf("%w: a linked pull request must be merged before %q", models.ErrTransitionConditionFailed, transition.Name)
+			}
+		case models.ConditionNotBlocked:
+			blockers, err := s.unresolvedBlockers(ctx, task.ID, userID, tenantID)
+			if err != nil {
+				return err
+			}
+			if len(blockers) > 0 {
+				return fmt.Errorf("%w: %s", models.ErrTransitionConditionFailed, blockedReason(blockers))
+			}
+		}
+	}
+	return nil
+}
+
+// projectKey 获取项目编号前缀
+func (s *agileServiceImpl) projectKey(ctx context.Context, projectID uuid.UUID) (string, error) {
+	var key string
+	if err := s.db.WithContext(ctx).Table("projects").Select("key").Where("id = ?", projectID).Scan(&key).Error; err != nil {
+		return "", fmt.Errorf("failed to get project key: %w", err)
+	}
+	return key, nil
+}
+
+// hasMergedPullRequest 通过Git网关检查项目仓库中是否有已合并的PR关联了任务。
+// PR标题或源分支中出现任务编号（如 PROJ-123、feature/PROJ-123-login）即视为关联，匹配在网关侧完成，只取一条
+func (s *agileServiceImpl) hasMergedPullRequest(ctx context.Context, task *models.AgileTask) (bool, error) {
+	if s.git == nil {
+		return false, errors.New("git gateway client is not configured")
+	}
+
+	key, err := s.projectKey(ctx, task.ProjectID)
+	if err != nil {
+		return false, err
+	}
+	if key == "" {
+		return false, nil
+	}
+
+	prs, err := s.git.ListProjectPullRequests(ctx, task.ProjectID, models.TaskKey(key, task.TaskNumber), client.PullRequestStatusMerged, 1, 1)
+	if err != nil {
+		return false, fmt.Errorf("failed to check linked pull requests: %w", err)
+	}
+	return prs.Total > 0, nil
+}
+
+// notifyTransition 执行转换的通知动作，发布任务转换事件由通知服务生成通知。
+// 发布失败不影响已完成的转换
+func (s *agileServiceImpl) notifyTransition(ctx context.Context, plan *transitionPlan, userID, tenantID uuid.UUID) {
+	if s.events == nil {
+		return
+	}
+
+	var targets []string
+	for i := range plan.transition.PostFunctions {
+		fn := &plan.transition.PostFunctions[i]
+		if fn.Type != models.PostFunctionNotify {
+			continue
+		}
+		fnTargets, _ := fn.NotifyTargets()
+		targets = append(targets, fnTargets...)
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	// 经办人以转换后的值为准
+	assigneeID := plan.task.AssigneeID
+	if value, ok := plan.updates["assignee_id"]; ok {
+		assigneeID = nil
+		if id, ok := value.(uuid.UUID); ok {
+			assigneeID = &id
+		}
+	}
+
+	recipients := make(map[uuid.UUID]bool)
+	for _, target := range targets {
+		switch target {
+		case models.NotifyAssignee:
+			if assigneeID != nil {
+				recipients[*assigneeID] = true
+			}
+		case models.NotifyReporter:
+			recipients[plan.task.ReporterID] = true
+		}
+	}
+	delete(recipients, userID)
+	if len(recipients) == 0 {
+		return
+	}
+
+	key, err := s.projectKey(ctx, plan.task.ProjectID)
+	if err != nil {
+		s.logger.Warn("获取项目编号失败", zap.Error(err))
+	}
+	taskKey := plan.task.Title
+	if key != "" {
+		taskKey = models.TaskKey(key, plan.task.TaskNumber)
+	}
+	toName := plan.workflow.Status(plan.transition.To).Name
+	fromName := plan.from
+	if status := plan.workflow.Status(plan.from); status != nil {
+		fromName = status.Name
+	}
+
+	recipientIDs := make([]uuid.UUID, 0, len(recipients))
+	for recipient := range recipients {
+		recipientIDs = append(recipientIDs, recipient)
+	}
+	data, err := json.Marshal(taskTransitionedEventData{
+		TaskID:       plan.task.ID,
+		TaskKey:      taskKey,
+		TaskTitle:    plan.task.Title,
+		ProjectID:    plan.task.ProjectID,
+		Transition:   plan.transition.Name,
+		From:         plan.from,
+		FromName:     fromName,
+		To:           plan.transition.To,
+		ToName:       toName,
+		ActorID:      userID,
+		RecipientIDs: recipientIDs,
+	})
+	if err != nil {
+		s.logger.Warn("序列化工作流通知事件失败", zap.Error(err))
+		return
+	}
+
+	event := &client.Event{
+		Type:          TaskTransitionNotificationType,
+		Source:        "project-service",
+		Subject:       plan.task.ID.String(),
+		TenantID:      tenantID,
+		UserID:        &userID,
+		ProjectID:     &plan.task.ProjectID,
+		Data:          data,
+		CorrelationID: plan.task.ID.String(),
+	}
+	if err := s.events.Publish(ctx, event); err != nil {
+		s.logger.Warn("发布工作流通知事件失败",
+			zap.String("task_id", plan.task.ID.String()),
+			zap.Error(err))
+	}
+}
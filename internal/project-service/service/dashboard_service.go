@@ -396,86 +396,49 @@ func (s *dashboardServiceImpl) GetTeamMetrics(ctx context.Context, projectID uui
 	return metrics, nil
 }
 
-// GetCumulativeFlowData 获取累积流图数据
+// GetCumulativeFlowData 获取累积流图数据，按状态变更历史还原每天的状态分布
 func (s *dashboardServiceImpl) GetCumulativeFlowData(ctx context.Context, projectID uuid.UUID, dateRange DateRange, userID, tenantID uuid.UUID) (*CumulativeFlowData, error) {
-	// 检查项目访问权限
-	if err := s.checkProjectAccess(ctx, projectID, userID, tenantID); err != nil {
+	flow, err := s.agileService.GetTaskFlowMetrics(ctx, projectID, dateRange.StartDate, dateRange.EndDate, userID, tenantID)
+	if err != nil {
 		return nil, err
 	}
 
-	// 生成日期范围
-	var dates []time.Time
-	for d := dateRange.StartDate; d.Before(dateRange.EndDate) || d.Equal(dateRange.EndDate); d = d.AddDate(0, 0, 1) {
-		dates = append(dates, d)
-	}
-
-	// 获取每天的任务状态分布（简化实现）
-	statusData := make(map[string][]int)
-	statuses := []string{"todo", "in_progress", "in_review", "testing", "done"}
-
-	for _, status := range statuses {
-		counts := make([]int, len(dates))
-		for i, date := range dates {
-			var count int64
-			s.db.WithContext(ctx).Model(&models.AgileTask{}).
-				Where("project_id = ? AND status = ? AND created_at <= ? AND deleted_at IS NULL",
-					projectID, status, date.Add(24*time.Hour)).
-				Count(&count)
-			counts[i] = int(count)
-		}
-		statusData[status] = counts
-	}
-
-	// 识别瓶颈状态（简化：持续增长的状态）
-	bottlenecks := []string{}
-	for status, counts := range statusData {
-		if len(counts) >= 2 && counts[len(counts)-1] > counts[len(counts)-2] {
-			bottlenecks = append(bottlenecks, status)
-		}
+	dataPoints := make([]CumulativeFlowPoint, 0, len(flow.CumulativeFlow))
+	for _, day := range flow.CumulativeFlow {
+		dataPoints = append(dataPoints, CumulativeFlowPoint{Date: day.Date, Values: day.Counts})
 	}
 
 	return &CumulativeFlowData{
 		ProjectID:  projectID,
 		StartDate:  dateRange.StartDate,
 		EndDate:    dateRange.EndDate,
-		DataPoints: []CumulativeFlowPoint{}, // 简化实现
-		Categories: []string{"Todo", "In Progress", "Done"},
+		DataPoints: dataPoints,
+		Categories: flow.Statuses,
 	}, nil
 }
 
-// GetCycleTimeChart 获取周期时间图数据
+// GetCycleTimeChart 获取周期时间图数据，周期从第一次开始处理到进入完成状态
 func (s *dashboardServiceImpl) GetCycleTimeChart(ctx context.Context, projectID uuid.UUID, dateRange DateRange, userID, tenantID uuid.UUID) (*CycleTimeChartData, error) {
-	// 检查项目访问权限
-	if err := s.checkProjectAccess(ctx, projectID, userID, tenantID); err != nil {
+	flow, err := s.agileService.GetTaskFlowMetrics(ctx, projectID, dateRange.StartDate, dateRange.EndDate, userID, tenantID)
+	if err != nil {
 		return nil, err
 	}
 
-	// 获取在指定日期范围内完成的任务
-	var tasks []models.AgileTask
-	if err := s.db.WithContext(ctx).
-		Where("project_id = ? AND status = ? AND updated_at BETWEEN ? AND ? AND deleted_at IS NULL",
-			projectID, models.TaskStatusDone, dateRange.StartDate, dateRange.EndDate).
-		Find(&tasks).Error; err != nil {
-		return nil, fmt.Errorf("failed to get completed tasks: %w", err)
-	}
-
-	dataPoints := make([]CycleTimePoint, 0, len(tasks))
+	dataPoints := make([]CycleTimePoint, 0, len(flow.CycleTimes))
 	var totalCycleTime float64
-	cycleTimes := make([]float64, 0, len(tasks))
+	cycleTimes := make([]float64, 0, len(flow.CycleTimes))
 
-	for _, task := range tasks {
-		// 计算周期时间（从创建到完成）
-		cycleTime := task.UpdatedAt.Sub(task.CreatedAt).Hours()
-		totalCycleTime += cycleTime
-		cycleTimes = append(cycleTimes, cycleTime)
+	for _, task := range flow.CycleTimes {
+		totalCycleTime += task.Hours
+		cycleTimes = append(cycleTimes, task.Hours)
 
 		dataPoints = append(dataPoints, CycleTimePoint{
-			TaskID:         task.ID,
-			TaskTitle:      task.Title,
-			StartDate:      task.CreatedAt,
-			EndDate:        task.UpdatedAt,
-			CycleTime:      cycleTime,
-			StageBreakdown: make(map[string]float64), // 简化处理
+			TaskID:         task.TaskID,
+			TaskTitle:      task.TaskTitle,
+			StartDate:      task.StartedAt,
+			EndDate:        task.CompletedAt,
+			CycleTime:      task.Hours,
+			StageBreakdown: task.StatusHours,
 		})
 	}
 
@@ -506,61 +469,85 @@ func (s *dashboardServiceImpl) GetCycleTimeChart(ctx context.Context, projectID
 	}, nil
 }
 
-// GetRecentActivity 获取最近活动
+// GetRecentActivity 获取最近活动，同一次操作的多个字段变更合并为一条
 func (s *dashboardServiceImpl) GetRecentActivity(ctx context.Context, projectID uuid.UUID, limit int, userID, tenantID uuid.UUID) ([]ActivityItem, error) {
-	// 检查项目访问权限
-	if err := s.checkProjectAccess(ctx, projectID, userID, tenantID); err != nil {
+	// 一次操作最多产生十余条字段变更，多取一些以凑满条数
+	changes, err := s.agileService.ListProjectActivity(ctx, &ActivityFilter{ProjectID: projectID}, 1, MaxActivityPageSize, userID, tenantID)
+	if err != nil {
 		return nil, err
 	}
 
-	// 获取最近的任务活动（简化实现）
-	var tasks []models.AgileTask
-	if err := s.db.WithContext(ctx).
-		Where("project_id = ? AND deleted_at IS NULL", projectID).
-		Preload("Assignee").
-		Preload("Reporter").
-		Order("updated_at DESC").
-		Limit(limit).
-		Find(&tasks).Error; err != nil {
-		return nil, fmt.Errorf("failed to get recent tasks: %w", err)
+	// 按变更集分组，保持时间倒序
+	var groups [][]TaskChangeEntry
+	index := make(map[uuid.UUID]int)
+	for _, change := range changes.Activities {
+		if i, ok := index[change.ChangeSetID]; ok {
+			groups[i] = append(groups[i], change)
+			continue
+		}
+		if len(groups) >= limit {
+			continue
+		}
+		index[change.ChangeSetID] = len(groups)
+		groups = append(groups, []TaskChangeEntry{change})
 	}
 
-	activities := make([]ActivityItem, 0, len(tasks))
-	for _, task := range tasks {
+	activities := make([]ActivityItem, 0, len(groups))
+	for _, group := range groups {
+		first := group[0]
 		actorName := "Unknown"
-		actorID := uuid.Nil
-
-		if task.Assignee != nil {
-			actorName = getUserDisplayName(task.Assignee)
-			actorID = task.Assignee.ID
-		} else if task.Reporter != nil {
-			actorName = getUserDisplayName(task.Reporter)
-			actorID = task.ReporterID
+		if first.Actor != nil {
+			actorName = getUserDisplayName(first.Actor)
+		}
+		title := first.TaskTitle
+		if first.TaskKey != "" {
+			title = first.TaskKey + " " + first.TaskTitle
 		}
 
-		// 根据任务状态生成活动类型
-		activityType := "task_updated"
-		description := fmt.Sprintf("Updated task: %s", task.Title)
+		fields := make([]string, 0, len(group))
+		var status *string
+		assigned := false
+		for _, change := range group {
+			if change.Field == "" {
+				continue
+			}
+			fields = append(fields, change.Field)
+			switch change.Field {
+			case "status":
+				status = change.NewValue
+			case "assignee_id":
+				assigned = true
+			}
+		}
 
-		if task.Status == models.TaskStatusDone {
-			activityType = "task_completed"
-			description = fmt.Sprintf("Completed task: %s", task.Title)
+		// 根据变更内容生成活动类型
+		activityType, description := "task_updated", fmt.Sprintf("Updated task: %s", title)
+		switch {
+		case first.Action == models.TaskChangeCreated:
+			activityType, description = "task_created", fmt.Sprintf("Created task: %s", title)
+		case first.Action == models.TaskChangeDeleted:
+			activityType, description = "task_deleted", fmt.Sprintf("Deleted task: %s", title)
+		case status != nil:
+			activityType, description = "task_transitioned", fmt.Sprintf("Moved task %s to %s", title, *status)
+		case assigned:
+			activityType, description = "task_assigned", fmt.Sprintf("Reassigned task: %s", title)
 		}
 
+		taskID := first.TaskID
 		activities = append(activities, ActivityItem{
-			ID:          uuid.New(), // 临时ID
+			ID:          first.ChangeSetID,
 			Type:        activityType,
-			Title:       task.Title,
+			Title:       first.TaskTitle,
 			Description: description,
-			UserID:      actorID,
+			UserID:      first.ActorID,
 			UserName:    actorName,
 			ProjectID:   projectID,
-			EntityID:    &task.ID,
-			Timestamp:   task.UpdatedAt,
+			EntityID:    &taskID,
+			Timestamp:   first.CreatedAt,
 			Metadata: map[string]interface{}{
-				"task_id":     task.ID,
-				"task_status": task.Status,
-				"task_type":   task.Type,
+				"task_id":  first.TaskID,
+				"task_key": first.TaskKey,
+				"fields":   fields,
 			},
 		})
 	}
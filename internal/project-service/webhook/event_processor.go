@@ -62,6 +62,10 @@ func (p *DefaultEventProcessor) ProcessRepositoryEvent(ctx context.Context, even
 		return p.handleRepositoryArchived(ctx, event, payload)
 	case "unarchived":
 		return p.handleRepositoryUnarchived(ctx, event, payload)
+	case "renamed":
+		return p.handleRepositoryRenamed(ctx, event, payload)
+	case "transferred":
+		return p.handleRepositoryTransferred(ctx, event, payload)
 	default:
 		p.logger.Info("忽略未处理的仓库事件",
 			zap.String("action", payload.Action),
@@ -244,6 +248,65 @@ func (p *DefaultEventProcessor) handleRepositoryUnarchived(ctx context.Context,
 	})
 }
 
+func (p *DefaultEventProcessor) handleRepositoryRenamed(ctx context.Context, event *GitEvent, payload *RepositoryEvent) error {
+	if payload.Previous == nil {
+		return fmt.Errorf("仓库改名事件缺少旧位置")
+	}
+	p.logger.Info("处理仓库改名事件",
+		zap.String("repository_id", payload.Repository.ID),
+		zap.String("old_name", payload.Previous.Name),
+		zap.String("new_name", payload.Repository.Name))
+
+	projectID, err := uuid.Parse(payload.Repository.ProjectID)
+	if err != nil {
+		return fmt.Errorf("无效的项目ID: %w", err)
+	}
+
+	return p.recordProjectActivity(ctx, projectID, "repository_renamed", map[string]interface{}{
+		"repository_id":   payload.Repository.ID,
+		"repository_name": payload.Repository.Name,
+		"old_name":        payload.Previous.Name,
+		"clone_url":       payload.Repository.CloneURL,
+		"event_id":        event.EventID,
+	})
+}
+
+// handleRepositoryTransferred 仓库迁移同时记录到迁出和迁入的项目
+func (p *DefaultEventProcessor) handleRepositoryTransferred(ctx context.Context, event *GitEvent, payload *RepositoryEvent) error {
+	if payload.Previous == nil {
+		return fmt.Errorf("仓库迁移事件缺少旧位置")
+	}
+	p.logger.Info("处理仓库迁移事件",
+		zap.String("repository_id", payload.Repository.ID),
+		zap.String("from_project_id", payload.Previous.ProjectID),
+		zap.String("to_project_id", payload.Repository.ProjectID))
+
+	fromProjectID, err := uuid.Parse(payload.Previous.ProjectID)
+	if err != nil {
+		return fmt.Errorf("无效的项目ID: %w", err)
+	}
+	toProjectID, err := uuid.Parse(payload.Repository.ProjectID)
+	if err != nil {
+		return fmt.Errorf("无效的项目ID: %w", err)
+	}
+
+	if err := p.recordProjectActivity(ctx, fromProjectID, "repository_transferred_out", map[string]interface{}{
+		"repository_id":   payload.Repository.ID,
+		"repository_name": payload.Previous.Name,
+		"to_project_id":   payload.Repository.ProjectID,
+		"event_id":        event.EventID,
+	}); err != nil {
+		return err
+	}
+	return p.recordProjectActivity(ctx, toProjectID, "repository_transferred_in", map[string]interface{}{
+		"repository_id":   payload.Repository.ID,
+		"repository_name": payload.Repository.Name,
+		"from_project_id": payload.Previous.ProjectID,
+		"clone_url":       payload.Repository.CloneURL,
+		"event_id":        event.EventID,
+	})
+}
+
 // 分支事件处理方法
 
 func (p *DefaultEventProcessor) handleBranchCreated(ctx context.Context, event *GitEvent, payload *BranchEvent) error {
@@ -44,7 +44,14 @@ type RepositoryEvent struct {
 		ProjectID     string `json:"project_id"`
 		Visibility    string `json:"visibility"`
 		DefaultBranch string `json:"default_branch"`
+		Status        string `json:"status,omitempty"`
+		CloneURL      string `json:"clone_url,omitempty"`
 	} `json:"repository"`
+	// Previous 改名或迁移前的位置，仅 renamed 和 transferred 事件携带
+	Previous *struct {
+		ProjectID string `json:"project_id"`
+		Name      string `json:"name"`
+	} `json:"previous,omitempty"`
 }
 
 // BranchEvent 分支事件
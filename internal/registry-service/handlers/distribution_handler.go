@@ -0,0 +1,619 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/models"
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/service"
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/storage"
+	"github.com/cloud-platform/collaborative-dev/shared/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/opencontainers/go-digest"
+	"go.uber.org/zap"
+)
+
+// OCI Distribution 错误码
+const (
+	ErrCodeBlobUnknown         = "BLOB_UNKNOWN"
+	ErrCodeBlobUploadInvalid   = "BLOB_UPLOAD_INVALID"
+	ErrCodeBlobUploadUnknown   = "BLOB_UPLOAD_UNKNOWN"
+	ErrCodeDigestInvalid       = "DIGEST_INVALID"
+	ErrCodeManifestBlobUnknown = "MANIFEST_BLOB_UNKNOWN"
+	ErrCodeManifestInvalid     = "MANIFEST_INVALID"
+	ErrCodeManifestUnknown     = "MANIFEST_UNKNOWN"
+	ErrCodeNameInvalid         = "NAME_INVALID"
+	ErrCodeNameUnknown         = "NAME_UNKNOWN"
+	ErrCodeRangeInvalid        = "RANGE_INVALID"
+	ErrCodeTagInvalid          = "TAG_INVALID"
+	ErrCodeUnauthorized        = "UNAUTHORIZED"
+	ErrCodeDenied              = "DENIED"
+	ErrCodeUnsupported         = "UNSUPPORTED"
+	ErrCodeUnknown             = "UNKNOWN"
+)
+
+// 路由匹配规则，仓库名称的合法性由 models.ParseRepositoryName 校验
+var (
+	uploadsPattern   = regexp.MustCompile(`^/(.+)/blobs/uploads/?$`)
+	uploadPattern    = regexp.MustCompile(`^/(.+)/blobs/uploads/([^/]+)$`)
+	blobPattern      = regexp.MustCompile(`^/(.+)/blobs/([^/]+)$`)
+	manifestPattern  = regexp.MustCompile(`^/(.+)/manifests/([^/]+)$`)
+	tagsListPattern  = regexp.MustCompile(`^/(.+)/tags/list$`)
+	contentRangeExpr = regexp.MustCompile(`^(?:bytes )?([0-9]+)-([0-9]+)$`)
+)
+
+// DistributionError OCI错误详情
+type DistributionError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Detail  interface{} `json:"detail,omitempty"`
+}
+
+// DistributionHandler OCI Distribution v2 API处理器
+type DistributionHandler struct {
+	registryService service.RegistryService
+	jwtService      *auth.JWTService
+	service         string
+	realm           string
+	logger          *zap.Logger
+}
+
+// NewDistributionHandler 创建OCI Distribution处理器
+func NewDistributionHandler(registryService service.RegistryService, jwtService *auth.JWTService, serviceName, realm string, logger *zap.Logger) *DistributionHandler {
+	return &DistributionHandler{
+		registryService: registryService,
+		jwtService:      jwtService,
+		service:         serviceName,
+		realm:           realm,
+		logger:          logger,
+	}
+}
+
+// RegisterRoutes 注册 /v2 路由
+func (h *DistributionHandler) RegisterRoutes(r *gin.Engine) {
+	r.Any("/v2/*path", h.Dispatch)
+}
+
+// Base API版本检查，客户端据此发现认证方式
+func (h *DistributionHandler) Base(c *gin.Context) {
+	c.Header("Docker-Distribution-API-Version", "registry/2.0")
+	if _, ok := h.authenticate(c, "", ""); !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// Dispatch 按路径分发 /v2/<name>/... 请求
+func (h *DistributionHandler) Dispatch(c *gin.Context) {
+	c.Header("Docker-Distribution-API-Version", "registry/2.0")
+	path := c.Param("path")
+	method := c.Request.Method
+
+	if path == "/" || path == "" {
+		h.Base(c)
+		return
+	}
+	if path == "/_catalog" {
+		h.writeError(c, http.StatusNotFound, ErrCodeUnsupported, "多租户仓库不支持全局目录查询", nil)
+		return
+	}
+
+	if m := uploadsPattern.FindStringSubmatch(path); m != nil {
+		if method == http.MethodPost {
+			h.StartBlobUpload(c, m[1])
+			return
+		}
+	} else if m := uploadPattern.FindStringSubmatch(path); m != nil {
+		switch method {
+		case http.MethodGet:
+			h.GetBlobUpload(c, m[1], m[2])
+			return
+		case http.MethodPatch:
+			h.PatchBlobUpload(c, m[1], m[2])
+			return
+		case http.MethodPut:
+			h.PutBlobUpload(c, m[1], m[2])
+			return
+		case http.MethodDelete:
+			h.CancelBlobUpload(c, m[1], m[2])
+			return
+		}
+	} else if m := blobPattern.FindStringSubmatch(path); m != nil {
+		switch method {
+		case http.MethodGet, http.MethodHead:
+			h.GetBlob(c, m[1], m[2])
+			return
+		case http.MethodDelete:
+			h.DeleteBlob(c, m[1], m[2])
+			return
+		}
+	} else if m := manifestPattern.FindStringSubmatch(path); m != nil {
+		switch method {
+		case http.MethodGet, http.MethodHead:
+			h.GetManifest(c, m[1], m[2])
+			return
+		case http.MethodPut:
+			h.PutManifest(c, m[1], m[2])
+			return
+		case http.MethodDelete:
+			h.DeleteManifest(c, m[1], m[2])
+			return
+		}
+	} else if m := tagsListPattern.FindStringSubmatch(path); m != nil {
+		if method == http.MethodGet {
+			h.ListTags(c, m[1])
+			return
+		}
+	} else {
+		h.writeError(c, http.StatusNotFound, ErrCodeNameUnknown, "未知的API路径", path)
+		return
+	}
+
+	h.writeError(c, http.StatusMethodNotAllowed, ErrCodeUnsupported, "不支持的请求方法", method)
+}
+
+// 数据块
+
+// GetBlob 下载或检查数据块，支持Range请求
+func (h *DistributionHandler) GetBlob(c *gin.Context, name, reference string) {
+	repo, ok := h.resolve(c, name, auth.RegistryActionPull)
+	if !ok {
+		return
+	}
+
+	dgst, err := digest.Parse(reference)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, ErrCodeDigestInvalid, "无效的摘要", reference)
+		return
+	}
+
+	if c.Request.Method == http.MethodHead {
+		blob, err := h.registryService.StatBlob(c.Request.Context(), repo, dgst)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		c.Header("Content-Length", strconv.FormatInt(blob.Size, 10))
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Docker-Content-Digest", blob.Digest)
+		c.Header("Accept-Ranges", "bytes")
+		c.Status(http.StatusOK)
+		return
+	}
+
+	reader, blob, err := h.registryService.OpenBlob(c.Request.Context(), repo, dgst)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Docker-Content-Digest", blob.Digest)
+	c.Header("Etag", `"`+blob.Digest+`"`)
+	c.Header("Cache-Control", "max-age=31536000")
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, reader)
+}
+
+// DeleteBlob 从仓库中删除数据块
+func (h *DistributionHandler) DeleteBlob(c *gin.Context, name, reference string) {
+	repo, ok := h.resolve(c, name, auth.RegistryActionDelete)
+	if !ok {
+		return
+	}
+
+	dgst, err := digest.Parse(reference)
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, ErrCodeDigestInvalid, "无效的摘要", reference)
+		return
+	}
+
+	if err := h.registryService.DeleteBlob(c.Request.Context(), repo, dgst); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// 上传
+
+// StartBlobUpload 开始上传，支持跨仓库挂载（mount/from）和单次上传（digest）
+func (h *DistributionHandler) StartBlobUpload(c *gin.Context, name string) {
+	claims, ok := h.authenticate(c, name, auth.RegistryActionPush)
+	if !ok {
+		return
+	}
+
+	repo, err := h.registryService.ResolveRepository(c.Request.Context(), name, true)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// 跨仓库挂载：需要源仓库的拉取权限，失败时回退为普通上传
+	if mount, from := c.Query("mount"), c.Query("from"); mount != "" && from != "" {
+		if dgst, err := digest.Parse(mount); err == nil && claims.HasAccess(from, auth.RegistryActionPull) {
+			blob, err := h.registryService.MountBlob(c.Request.Context(), repo, from, dgst)
+			if err == nil {
+				h.writeBlobCreated(c, name, blob)
+				return
+			}
+			h.logger.Debug("挂载数据块失败，回退为上传", zap.String("from", from), zap.Error(err))
+		}
+	}
+
+	upload, err := h.registryService.StartUpload(c.Request.Context(), repo)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// 单次上传
+	if reference := c.Query("digest"); reference != "" {
+		dgst, err := digest.Parse(reference)
+		if err != nil {
+			_ = h.registryService.CancelUpload(c.Request.Context(), repo, upload.ID)
+			h.writeError(c, http.StatusBadRequest, ErrCodeDigestInvalid, "无效的摘要", reference)
+			return
+		}
+
+		blob, err := h.registryService.CompleteUpload(c.Request.Context(), repo, upload.ID, dgst, c.Request.ContentLength, c.Request.Body)
+		if err != nil {
+			_ = h.registryService.CancelUpload(c.Request.Context(), repo, upload.ID)
+			h.handleError(c, err)
+			return
+		}
+		h.writeBlobCreated(c, name, blob)
+		return
+	}
+
+	h.writeUploadStatus(c, name, upload, http.StatusAccepted)
+}
+
+// GetBlobUpload 查询上传进度
+func (h *DistributionHandler) GetBlobUpload(c *gin.Context, name, uploadID string) {
+	_, upload, ok := h.resolveUpload(c, name, uploadID)
+	if !ok {
+		return
+	}
+
+	h.writeUploadStatus(c, name, upload, http.StatusNoContent)
+}
+
+// PatchBlobUpload 追加上传分块
+func (h *DistributionHandler) PatchBlobUpload(c *gin.Context, name, uploadID string) {
+	repo, upload, ok := h.resolveUpload(c, name, uploadID)
+	if !ok {
+		return
+	}
+
+	offset := int64(-1)
+	if contentRange := c.GetHeader("Content-Range"); contentRange != "" {
+		m := contentRangeExpr.FindStringSubmatch(contentRange)
+		if m == nil {
+			h.writeRangeInvalid(c, name, upload)
+			return
+		}
+		start, _ := strconv.ParseInt(m[1], 10, 64)
+		end, _ := strconv.ParseInt(m[2], 10, 64)
+		if end < start || (c.Request.ContentLength >= 0 && end-start+1 != c.Request.ContentLength) {
+			h.writeRangeInvalid(c, name, upload)
+			return
+		}
+		offset = start
+	}
+
+	upload, err := h.registryService.AppendUpload(c.Request.Context(), repo, upload.ID, offset, c.Request.ContentLength, c.Request.Body)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidOffset) {
+			h.writeRangeInvalid(c, name, upload)
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	h.writeUploadStatus(c, name, upload, http.StatusAccepted)
+}
+
+// PutBlobUpload 完成上传，请求体可携带最后一个分块
+func (h *DistributionHandler) PutBlobUpload(c *gin.Context, name, uploadID string) {
+	repo, upload, ok := h.resolveUpload(c, name, uploadID)
+	if !ok {
+		return
+	}
+
+	dgst, err := digest.Parse(c.Query("digest"))
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, ErrCodeDigestInvalid, "缺少或无效的digest参数", c.Query("digest"))
+		return
+	}
+
+	blob, err := h.registryService.CompleteUpload(c.Request.Context(), repo, upload.ID, dgst, c.Request.ContentLength, c.Request.Body)
+	if err != nil {
+		if errors.Is(err, storage.ErrInvalidOffset) {
+			h.writeRangeInvalid(c, name, upload)
+			return
+		}
+		h.handleError(c, err)
+		return
+	}
+
+	h.writeBlobCreated(c, name, blob)
+}
+
+// CancelBlobUpload 取消上传
+func (h *DistributionHandler) CancelBlobUpload(c *gin.Context, name, uploadID string) {
+	repo, upload, ok := h.resolveUpload(c, name, uploadID)
+	if !ok {
+		return
+	}
+
+	if err := h.registryService.CancelUpload(c.Request.Context(), repo, upload.ID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// 清单
+
+// GetManifest 获取或检查清单
+func (h *DistributionHandler) GetManifest(c *gin.Context, name, reference string) {
+	repo, ok := h.resolve(c, name, auth.RegistryActionPull)
+	if !ok {
+		return
+	}
+
+	manifest, err := h.registryService.GetManifest(c.Request.Context(), repo, reference)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Docker-Content-Digest", manifest.Digest)
+	c.Header("Etag", `"`+manifest.Digest+`"`)
+	if c.Request.Method == http.MethodHead {
+		c.Header("Content-Type", manifest.MediaType)
+		c.Header("Content-Length", strconv.FormatInt(manifest.Size, 10))
+		c.Status(http.StatusOK)
+		return
+	}
+
+	c.Data(http.StatusOK, manifest.MediaType, manifest.Payload)
+}
+
+// PutManifest 上传清单
+func (h *DistributionHandler) PutManifest(c *gin.Context, name, reference string) {
+	if _, ok := h.authenticate(c, name, auth.RegistryActionPush); !ok {
+		return
+	}
+
+	repo, err := h.registryService.ResolveRepository(c.Request.Context(), name, true)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, service.MaxManifestSize+1))
+	if err != nil {
+		h.writeError(c, http.StatusBadRequest, ErrCodeManifestInvalid, "读取清单失败", err.Error())
+		return
+	}
+
+	mediaType := c.ContentType()
+	manifest, err := h.registryService.PutManifest(c.Request.Context(), repo, reference, mediaType, bytes.TrimSpace(payload))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if manifest.SubjectDigest != "" {
+		c.Header("OCI-Subject", manifest.SubjectDigest)
+	}
+	c.Header("Location", fmt.Sprintf("/v2/%s/manifests/%s", name, manifest.Digest))
+	c.Header("Docker-Content-Digest", manifest.Digest)
+	c.Status(http.StatusCreated)
+}
+
+// DeleteManifest 删除清单（摘要）或标签
+func (h *DistributionHandler) DeleteManifest(c *gin.Context, name, reference string) {
+	repo, ok := h.resolve(c, name, auth.RegistryActionDelete)
+	if !ok {
+		return
+	}
+
+	if err := h.registryService.DeleteManifest(c.Request.Context(), repo, reference); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// ListTags 获取标签列表，支持 n/last 分页
+func (h *DistributionHandler) ListTags(c *gin.Context, name string) {
+	repo, ok := h.resolve(c, name, auth.RegistryActionPull)
+	if !ok {
+		return
+	}
+
+	limit := 0
+	if n := c.Query("n"); n != "" {
+		parsed, err := strconv.Atoi(n)
+		if err != nil || parsed < 0 {
+			h.writeError(c, http.StatusBadRequest, ErrCodeUnsupported, "无效的分页参数", n)
+			return
+		}
+		limit = parsed
+	}
+
+	tags, err := h.registryService.ListTags(c.Request.Context(), repo, c.Query("last"), limit)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if limit > 0 && len(tags) == limit {
+		next := url.Values{"n": {strconv.Itoa(limit)}, "last": {tags[len(tags)-1]}}
+		c.Header("Link", fmt.Sprintf(`</v2/%s/tags/list?%s>; rel="next"`, name, next.Encode()))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name": name,
+		"tags": tags,
+	})
+}
+
+// 认证与辅助方法
+
+// authenticate 校验Bearer令牌，失败时返回带认证质询的401
+func (h *DistributionHandler) authenticate(c *gin.Context, name, action string) (*auth.RegistryClaims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		claims, err := h.jwtService.ValidateRegistryToken(strings.TrimPrefix(authHeader, "Bearer "), h.service)
+		if err == nil && (name == "" || claims.HasAccess(name, action)) {
+			return claims, true
+		}
+		if err != nil {
+			h.logger.Debug("镜像仓库令牌无效", zap.Error(err))
+		}
+	}
+
+	challenge := fmt.Sprintf(`Bearer realm=%q,service=%q`, h.realm, h.service)
+	if name != "" {
+		actions := action
+		if action == auth.RegistryActionPush {
+			actions = auth.RegistryActionPull + "," + auth.RegistryActionPush
+		}
+		challenge += fmt.Sprintf(`,scope="repository:%s:%s"`, name, actions)
+	}
+	c.Header("WWW-Authenticate", challenge)
+
+	var detail interface{}
+	if name != "" {
+		detail = []auth.RegistryAccess{{Type: "repository", Name: name, Actions: []string{action}}}
+	}
+	h.writeError(c, http.StatusUnauthorized, ErrCodeUnauthorized, "需要认证", detail)
+	return nil, false
+}
+
+// resolve 认证并获取已存在的仓库
+func (h *DistributionHandler) resolve(c *gin.Context, name, action string) (*models.Repository, bool) {
+	if _, ok := h.authenticate(c, name, action); !ok {
+		return nil, false
+	}
+
+	repo, err := h.registryService.ResolveRepository(c.Request.Context(), name, false)
+	if err != nil {
+		h.handleError(c, err)
+		return nil, false
+	}
+	return repo, true
+}
+
+// resolveUpload 认证并获取上传会话
+func (h *DistributionHandler) resolveUpload(c *gin.Context, name, uploadID string) (*models.Repository, *models.Upload, bool) {
+	repo, ok := h.resolve(c, name, auth.RegistryActionPush)
+	if !ok {
+		return nil, nil, false
+	}
+
+	id, err := uuid.Parse(uploadID)
+	if err != nil {
+		h.writeError(c, http.StatusNotFound, ErrCodeBlobUploadUnknown, "上传会话不存在", uploadID)
+		return nil, nil, false
+	}
+
+	upload, err := h.registryService.GetUpload(c.Request.Context(), repo, id)
+	if err != nil {
+		h.handleError(c, err)
+		return nil, nil, false
+	}
+	return repo, upload, true
+}
+
+// writeUploadStatus 返回上传会话状态
+func (h *DistributionHandler) writeUploadStatus(c *gin.Context, name string, upload *models.Upload, status int) {
+	c.Header("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", name, upload.ID))
+	c.Header("Range", uploadRange(upload.Offset))
+	c.Header("Docker-Upload-UUID", upload.ID.String())
+	c.Header("Content-Length", "0")
+	c.Status(status)
+}
+
+// writeBlobCreated 返回数据块创建成功
+func (h *DistributionHandler) writeBlobCreated(c *gin.Context, name string, blob *models.Blob) {
+	c.Header("Location", fmt.Sprintf("/v2/%s/blobs/%s", name, blob.Digest))
+	c.Header("Docker-Content-Digest", blob.Digest)
+	c.Header("Content-Length", "0")
+	c.Status(http.StatusCreated)
+}
+
+// writeRangeInvalid 返回416及当前上传进度，客户端可据此续传
+func (h *DistributionHandler) writeRangeInvalid(c *gin.Context, name string, upload *models.Upload) {
+	if upload != nil {
+		c.Header("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%s", name, upload.ID))
+		c.Header("Range", uploadRange(upload.Offset))
+		c.Header("Docker-Upload-UUID", upload.ID.String())
+	}
+	h.writeError(c, http.StatusRequestedRangeNotSatisfiable, ErrCodeRangeInvalid, "分块范围无效", nil)
+}
+
+// handleError 将业务错误映射为OCI错误码
+func (h *DistributionHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, models.ErrRepositoryNotFound), errors.Is(err, models.ErrNamespaceNotFound):
+		h.writeError(c, http.StatusNotFound, ErrCodeNameUnknown, "仓库不存在", err.Error())
+	case errors.Is(err, models.ErrNameInvalid):
+		h.writeError(c, http.StatusBadRequest, ErrCodeNameInvalid, "仓库名称无效", err.Error())
+	case errors.Is(err, models.ErrBlobNotFound):
+		h.writeError(c, http.StatusNotFound, ErrCodeBlobUnknown, "数据块不存在", nil)
+	case errors.Is(err, models.ErrUploadNotFound):
+		h.writeError(c, http.StatusNotFound, ErrCodeBlobUploadUnknown, "上传会话不存在", nil)
+	case errors.Is(err, models.ErrManifestNotFound):
+		h.writeError(c, http.StatusNotFound, ErrCodeManifestUnknown, "清单不存在", nil)
+	case errors.Is(err, models.ErrTagInvalid):
+		h.writeError(c, http.StatusBadRequest, ErrCodeTagInvalid, "标签无效", nil)
+	case errors.Is(err, models.ErrManifestBlobUnknown):
+		h.writeError(c, http.StatusBadRequest, ErrCodeManifestBlobUnknown, "清单引用的数据块不存在", err.Error())
+	case errors.Is(err, models.ErrManifestInvalid):
+		h.writeError(c, http.StatusBadRequest, ErrCodeManifestInvalid, "清单无效", err.Error())
+	case errors.Is(err, models.ErrDigestInvalid):
+		h.writeError(c, http.StatusBadRequest, ErrCodeDigestInvalid, "摘要无效", err.Error())
+	case errors.Is(err, models.ErrQuotaExceeded):
+		h.writeError(c, http.StatusForbidden, ErrCodeDenied, "超出租户存储配额", err.Error())
+	case errors.Is(err, models.ErrUnsupported):
+		h.writeError(c, http.StatusMethodNotAllowed, ErrCodeUnsupported, "不支持的操作", nil)
+	default:
+		h.logger.Error("镜像仓库请求失败", zap.String("path", c.Request.URL.Path), zap.Error(err))
+		h.writeError(c, http.StatusInternalServerError, ErrCodeUnknown, "内部错误", nil)
+	}
+}
+
+// writeError 输出OCI格式的错误响应
+func (h *DistributionHandler) writeError(c *gin.Context, status int, code, message string, detail interface{}) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"errors": []DistributionError{{Code: code, Message: message, Detail: detail}},
+	})
+}
+
+// uploadRange 计算Range响应头，格式为 0-<最后一个字节的位置>
+func uploadRange(offset int64) string {
+	end := offset - 1
+	if end < 0 {
+		end = 0
+	}
+	return fmt.Sprintf("0-%d", end)
+}
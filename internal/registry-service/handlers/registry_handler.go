@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/models"
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/service"
+	"github.com/cloud-platform/collaborative-dev/shared/response"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// RegistryHandler 镜像仓库管理API处理器
+type RegistryHandler struct {
+	registryService service.RegistryService
+	logger          *zap.Logger
+}
+
+// NewRegistryHandler 创建镜像仓库管理处理器
+func NewRegistryHandler(registryService service.RegistryService, logger *zap.Logger) *RegistryHandler {
+	return &RegistryHandler{
+		registryService: registryService,
+		logger:          logger,
+	}
+}
+
+// ListRepositories 获取当前租户的镜像仓库列表
+// @Summary 获取镜像仓库列表
+// @Tags Registry
+// @Produce json
+// @Param project_id query string false "项目ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=service.RepositoryListResponse}
+// @Router /api/v1/registry/repositories [get]
+func (h *RegistryHandler) ListRepositories(c *gin.Context) {
+	tenantID, ok := getTenantID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	var projectID *uuid.UUID
+	if projectIDStr := c.Query("project_id"); projectIDStr != "" {
+		pid, err := uuid.Parse(projectIDStr)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid project ID", err.Error())
+			return
+		}
+		projectID = &pid
+	}
+
+	resp, err := h.registryService.ListRepositories(c.Request.Context(), tenantID, projectID, page, pageSize)
+	if err != nil {
+		h.logger.Error("Failed to list registry repositories", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to list repositories", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Repositories retrieved successfully", resp)
+}
+
+// DeleteRepository 删除镜像仓库及其全部标签和清单
+// @Summary 删除镜像仓库
+// @Tags Registry
+// @Produce json
+// @Param name path string true "仓库全名，如 acme/web/api"
+// @Success 200 {object} response.Response
+// @Router /api/v1/registry/repositories/{name} [delete]
+func (h *RegistryHandler) DeleteRepository(c *gin.Context) {
+	tenantID, ok := getTenantID(c)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimPrefix(c.Param("name"), "/")
+	if err := h.registryService.DeleteRepository(c.Request.Context(), tenantID, name); err != nil {
+		switch {
+		case errors.Is(err, models.ErrRepositoryNotFound):
+			response.Error(c, http.StatusNotFound, "Repository not found", nil)
+		case errors.Is(err, models.ErrNameInvalid):
+			response.Error(c, http.StatusBadRequest, "Invalid repository name", err.Error())
+		default:
+			h.logger.Error("Failed to delete registry repository", zap.String("name", name), zap.Error(err))
+			response.Error(c, http.StatusInternalServerError, "Failed to delete repository", err.Error())
+		}
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Repository deleted successfully", nil)
+}
+
+// GetUsage 获取当前租户的镜像存储用量
+// @Summary 获取镜像存储用量
+// @Tags Registry
+// @Produce json
+// @Success 200 {object} response.Response{data=quota.TenantStorage}
+// @Router /api/v1/registry/usage [get]
+func (h *RegistryHandler) GetUsage(c *gin.Context) {
+	tenantID, ok := getTenantID(c)
+	if !ok {
+		return
+	}
+
+	usage, err := h.registryService.GetTenantUsage(c.Request.Context(), tenantID)
+	if err != nil {
+		h.logger.Error("Failed to get registry usage", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to get usage", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Usage retrieved successfully", usage)
+}
+
+// CollectGarbage 手动触发垃圾回收
+// @Summary 触发镜像仓库垃圾回收
+// @Tags Registry
+// @Produce json
+// @Success 200 {object} response.Response{data=models.GCResult}
+// @Router /api/v1/registry/gc [post]
+func (h *RegistryHandler) CollectGarbage(c *gin.Context) {
+	result, err := h.registryService.CollectGarbage(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to collect registry garbage", zap.Error(err))
+		response.Error(c, http.StatusInternalServerError, "Failed to collect garbage", err.Error())
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Garbage collection completed", result)
+}
+
+// getTenantID 从JWT上下文获取租户ID
+func getTenantID(c *gin.Context) (uuid.UUID, bool) {
+	value, exists := c.Get("tenant_id")
+	if !exists {
+		response.Error(c, http.StatusUnauthorized, "User not authenticated", nil)
+		return uuid.Nil, false
+	}
+
+	tenantID, ok := value.(uuid.UUID)
+	if !ok || tenantID == uuid.Nil {
+		response.Error(c, http.StatusBadRequest, "Invalid tenant ID", nil)
+		return uuid.Nil, false
+	}
+	return tenantID, true
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 业务错误
+var (
+	ErrRepositoryNotFound = errors.New("镜像仓库不存在")
+	ErrManifestNotFound   = errors.New("清单不存在")
+	ErrBlobNotFound       = errors.New("数据块不存在")
+	ErrUploadNotFound     = errors.New("上传会话不存在")
+	ErrNamespaceNotFound  = errors.New("命名空间不存在")
+	ErrQuotaExceeded      = errors.New("超出租户存储配额")
+
+	ErrNameInvalid         = errors.New("仓库名称无效")
+	ErrTagInvalid          = errors.New("标签无效")
+	ErrDigestInvalid       = errors.New("摘要无效")
+	ErrManifestInvalid     = errors.New("清单无效")
+	ErrManifestBlobUnknown = errors.New("清单引用的数据块不存在")
+	ErrUnsupported         = errors.New("不支持的操作")
+)
+
+// ManifestReferenceKind 清单引用类型
+type ManifestReferenceKind string
+
+const (
+	ManifestReferenceBlob     ManifestReferenceKind = "blob"     // 配置或镜像层
+	ManifestReferenceManifest ManifestReferenceKind = "manifest" // 索引中的子清单
+)
+
+// Repository 镜像仓库（按 租户/项目/镜像名 命名）
+type Repository struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	TenantID     uuid.UUID  `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	ProjectID    uuid.UUID  `json:"project_id" gorm:"type:uuid;not null;index"`
+	Name         string     `json:"name" gorm:"size:255;not null;uniqueIndex"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"not null;default:now()"`
+	LastPushedAt *time.Time `json:"last_pushed_at"`
+
+	// 统计信息（查询时填充）
+	TagCount int   `json:"tag_count" gorm:"-"`
+	Size     int64 `json:"size" gorm:"-"`
+}
+
+// Blob 内容寻址的数据块，跨仓库共享存储
+type Blob struct {
+	Digest    string    `json:"digest" gorm:"size:100;primary_key"`
+	Size      int64     `json:"size" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// RepositoryBlob 仓库与数据块的关联，决定数据块在哪些仓库可见
+type RepositoryBlob struct {
+	RepositoryID uuid.UUID `json:"repository_id" gorm:"type:uuid;primary_key"`
+	Digest       string    `json:"digest" gorm:"size:100;primary_key"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
+}
+
+// Manifest 镜像清单
+type Manifest struct {
+	ID            uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RepositoryID  uuid.UUID `json:"repository_id" gorm:"type:uuid;not null;uniqueIndex:idx_registry_manifest_digest"`
+	Digest        string    `json:"digest" gorm:"size:100;not null;uniqueIndex:idx_registry_manifest_digest"`
+	MediaType     string    `json:"media_type" gorm:"size:255;not null"`
+	ArtifactType  string    `json:"artifact_type,omitempty" gorm:"size:255"`
+	SubjectDigest string    `json:"subject_digest,omitempty" gorm:"size:100;index"`
+	Size          int64     `json:"size" gorm:"not null"`
+	Payload       []byte    `json:"-" gorm:"type:bytea;not null"`
+	CreatedAt     time.Time `json:"created_at" gorm:"not null;default:now()"`
+
+	// 关联关系
+	References []ManifestReference `json:"references,omitempty" gorm:"foreignKey:ManifestID"`
+	Tags       []Tag               `json:"tags,omitempty" gorm:"foreignKey:ManifestID"`
+}
+
+// ManifestReference 清单引用的数据块或子清单
+type ManifestReference struct {
+	ManifestID uuid.UUID             `json:"manifest_id" gorm:"type:uuid;primary_key"`
+	Digest     string                `json:"digest" gorm:"size:100;primary_key"`
+	Kind       ManifestReferenceKind `json:"kind" gorm:"size:20;not null"`
+}
+
+// Tag 镜像标签
+type Tag struct {
+	RepositoryID uuid.UUID `json:"repository_id" gorm:"type:uuid;primary_key"`
+	Name         string    `json:"name" gorm:"size:128;primary_key"`
+	ManifestID   uuid.UUID `json:"manifest_id" gorm:"type:uuid;not null;index"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"not null;default:now()"`
+
+	// 关联关系
+	Manifest *Manifest `json:"manifest,omitempty" gorm:"foreignKey:ManifestID"`
+}
+
+// Upload 分块上传会话
+type Upload struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	RepositoryID uuid.UUID `json:"repository_id" gorm:"type:uuid;not null;index"`
+	TenantID     uuid.UUID `json:"tenant_id" gorm:"type:uuid;not null;index"`
+	Offset       int64     `json:"offset" gorm:"not null;default:0"`
+	CreatedAt    time.Time `json:"created_at" gorm:"not null;default:now()"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"not null;default:now()"`
+}
+
+// GCResult 垃圾回收结果
+type GCResult struct {
+	ManifestsDeleted int           `json:"manifests_deleted"`
+	LinksDeleted     int           `json:"links_deleted"`
+	BlobsDeleted     int           `json:"blobs_deleted"`
+	BytesFreed       int64         `json:"bytes_freed"`
+	UploadsExpired   int           `json:"uploads_expired"`
+	Duration         time.Duration `json:"duration"`
+}
+
+// 设置表名
+func (Repository) TableName() string {
+	return "registry_repositories"
+}
+
+func (Blob) TableName() string {
+	return "registry_blobs"
+}
+
+func (RepositoryBlob) TableName() string {
+	return "registry_repository_blobs"
+}
+
+func (Manifest) TableName() string {
+	return "registry_manifests"
+}
+
+func (ManifestReference) TableName() string {
+	return "registry_manifest_references"
+}
+
+func (Tag) TableName() string {
+	return "registry_tags"
+}
+
+func (Upload) TableName() string {
+	return "registry_uploads"
+}
+
+// 命名规则（OCI Distribution规范）
+var (
+	pathComponentPattern = regexp.MustCompile(`^[a-z0-9]+(?:(?:\.|_|__|-+)[a-z0-9]+)*$`)
+	tagPattern           = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+)
+
+// RepositoryName 解析后的仓库名称
+type RepositoryName struct {
+	Tenant  string // 租户域名
+	Project string // 项目Key（小写）
+	Image   string // 镜像名，可包含多级路径
+}
+
+// String 获取仓库全名
+func (n RepositoryName) String() string {
+	return n.Tenant + "/" + n.Project + "/" + n.Image
+}
+
+// ParseRepositoryName 解析仓库名称，格式为 <租户>/<项目>/<镜像>[/<子路径>...]
+func ParseRepositoryName(name string) (*RepositoryName, error) {
+	if len(name) == 0 || len(name) > 255 {
+		return nil, fmt.Errorf("%w: 长度必须在1-255之间", ErrNameInvalid)
+	}
+
+	components := strings.Split(name, "/")
+	if len(components) < 3 {
+		return nil, fmt.Errorf("%w: 必须为 租户/项目/镜像 格式: %s", ErrNameInvalid, name)
+	}
+
+	for _, component := range components {
+		if !pathComponentPattern.MatchString(component) {
+			return nil, fmt.Errorf("%w: 非法路径段 %q", ErrNameInvalid, component)
+		}
+	}
+
+	return &RepositoryName{
+		Tenant:  components[0],
+		Project: components[1],
+		Image:   strings.Join(components[2:], "/"),
+	}, nil
+}
+
+// IsValidTag 校验标签名
+func IsValidTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
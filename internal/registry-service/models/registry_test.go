@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepositoryName(t *testing.T) {
+	name, err := ParseRepositoryName("acme/web/api")
+	require.NoError(t, err)
+	assert.Equal(t, "acme", name.Tenant)
+	assert.Equal(t, "web", name.Project)
+	assert.Equal(t, "api", name.Image)
+
+	name, err = ParseRepositoryName("acme.io/web/tools/lint-runner")
+	require.NoError(t, err)
+	assert.Equal(t, "tools/lint-runner", name.Image)
+	assert.Equal(t, "acme.io/web/tools/lint-runner", name.String())
+
+	for _, invalid := range []string{"", "api", "acme/api", "Acme/web/api", "acme//api", "acme/web/-api", "acme/web/api/"} {
+		_, err := ParseRepositoryName(invalid)
+		assert.ErrorIs(t, err, ErrNameInvalid, invalid)
+	}
+}
+
+func TestIsValidTag(t *testing.T) {
+	assert.True(t, IsValidTag("latest"))
+	assert.True(t, IsValidTag("v1.2.3-rc.1"))
+	assert.True(t, IsValidTag("_internal"))
+	assert.False(t, IsValidTag(""))
+	assert.False(t, IsValidTag(".hidden"))
+	assert.False(t, IsValidTag("sha256:abc"))
+}
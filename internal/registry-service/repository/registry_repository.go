@@ -0,0 +1,522 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/models"
+	"github.com/cloud-platform/collaborative-dev/shared/quota"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RegistryRepository 镜像仓库数据访问接口
+type RegistryRepository interface {
+	// 命名空间与配额
+	ResolveNamespace(ctx context.Context, tenantDomain, projectKey string) (tenantID, projectID uuid.UUID, err error)
+	GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*quota.TenantStorage, error)
+
+	// 仓库管理
+	CreateRepository(ctx context.Context, repo *models.Repository) error
+	GetRepositoryByName(ctx context.Context, name string) (*models.Repository, error)
+	ListRepositories(ctx context.Context, tenantID uuid.UUID, projectID *uuid.UUID, page, pageSize int) ([]models.Repository, int64, error)
+	DeleteRepository(ctx context.Context, id uuid.UUID) error
+	TouchRepository(ctx context.Context, id uuid.UUID) error
+
+	// 数据块管理
+	CreateBlob(ctx context.Context, blob *models.Blob) error
+	GetBlob(ctx context.Context, digest string) (*models.Blob, error)
+	LinkBlob(ctx context.Context, repositoryID uuid.UUID, digest string) error
+	IsBlobLinked(ctx context.Context, repositoryID uuid.UUID, digest string) (bool, error)
+	UnlinkBlob(ctx context.Context, repositoryID uuid.UUID, digest string) error
+	CountLinkedBlobs(ctx context.Context, repositoryID uuid.UUID, digests []string) (int64, error)
+	TenantHasBlob(ctx context.Context, tenantID uuid.UUID, digest string) (bool, error)
+
+	// 清单与标签管理
+	SaveManifest(ctx context.Context, manifest *models.Manifest, tag string) error
+	GetManifestByDigest(ctx context.Context, repositoryID uuid.UUID, digest string) (*models.Manifest, error)
+	GetManifestByTag(ctx context.Context, repositoryID uuid.UUID, tag string) (*models.Manifest, error)
+	CountManifests(ctx context.Context, repositoryID uuid.UUID, digests []string) (int64, error)
+	DeleteManifest(ctx context.Context, repositoryID uuid.UUID, digest string) error
+	DeleteTag(ctx context.Context, repositoryID uuid.UUID, tag string) error
+	ListTags(ctx context.Context, repositoryID uuid.UUID, last string, limit int) ([]string, error)
+
+	// 上传会话管理
+	CreateUpload(ctx context.Context, upload *models.Upload) error
+	GetUpload(ctx context.Context, repositoryID, id uuid.UUID) (*models.Upload, error)
+	UpdateUploadOffset(ctx context.Context, id uuid.UUID, offset int64) error
+	DeleteUpload(ctx context.Context, id uuid.UUID) error
+	ListExpiredUploads(ctx context.Context, before time.Time, limit int) ([]models.Upload, error)
+
+	// 垃圾回收
+	ListUntaggedManifests(ctx context.Context, before time.Time, limit int) ([]models.Manifest, error)
+	DeleteUnreferencedLinks(ctx context.Context, before time.Time) (int64, error)
+	ListOrphanBlobs(ctx context.Context, before time.Time, limit int) ([]models.Blob, error)
+	DeleteOrphanBlob(ctx context.Context, digest string) (bool, error)
+}
+
+// registryRepository 镜像仓库数据访问实现
+type registryRepository struct {
+	db *gorm.DB
+}
+
+// NewRegistryRepository 创建镜像仓库数据访问实例
+func NewRegistryRepository(db *gorm.DB) RegistryRepository {
+	return &registryRepository{db: db}
+}
+
+// 命名空间与配额
+
+// ResolveNamespace 根据租户域名和项目Key解析租户与项目
+func (r *registryRepository) ResolveNamespace(ctx context.Context, tenantDomain, projectKey string) (uuid.UUID, uuid.UUID, error) {
+	var result struct {
+		TenantID  uuid.UUID
+		ProjectID uuid.UUID
+	}
+
+	err := r.db.WithContext(ctx).
+		Table("projects p").
+		Select("t.id AS tenant_id, p.id AS project_id").
+		Joins("JOIN tenants t ON t.id = p.tenant_id").
+		Where("t.domain = ? AND t.status = ? AND t.deleted_at IS NULL", tenantDomain, "active").
+		Where("LOWER(p.key) = ? AND p.deleted_at IS NULL", strings.ToLower(projectKey)).
+		Take(&result).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, uuid.Nil, models.ErrNamespaceNotFound
+		}
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return result.TenantID, result.ProjectID, nil
+}
+
+// GetTenantUsage 获取租户存储用量与配额，用量包含租户的全部镜像仓库和Git仓库
+func (r *registryRepository) GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*quota.TenantStorage, error) {
+	return quota.GetTenantStorage(ctx, r.db, tenantID)
+}
+
+// 仓库管理
+
+// CreateRepository 创建仓库，同名仓库已存在时返回已有记录
+func (r *registryRepository) CreateRepository(ctx context.Context, repo *models.Repository) error {
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).
+		Create(repo).Error
+	if err != nil {
+		return err
+	}
+
+	return r.db.WithContext(ctx).Where("name = ?", repo.Name).First(repo).Error
+}
+
+// GetRepositoryByName 根据名称获取仓库
+func (r *registryRepository) GetRepositoryByName(ctx context.Context, name string) (*models.Repository, error) {
+	var repo models.Repository
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&repo).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrRepositoryNotFound
+		}
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// ListRepositories 获取租户的仓库列表
+func (r *registryRepository) ListRepositories(ctx context.Context, tenantID uuid.UUID, projectID *uuid.UUID, page, pageSize int) ([]models.Repository, int64, error) {
+	var repos []models.Repository
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Repository{}).Where("tenant_id = ?", tenantID)
+	if projectID != nil {
+		query = query.Where("project_id = ?", *projectID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("name ASC").Offset(offset).Limit(pageSize).Find(&repos).Error; err != nil {
+		return nil, 0, err
+	}
+
+	for i := range repos {
+		var tagCount int64
+		if err := r.db.WithContext(ctx).Model(&models.Tag{}).Where("repository_id = ?", repos[i].ID).Count(&tagCount).Error; err != nil {
+			return nil, 0, err
+		}
+		repos[i].TagCount = int(tagCount)
+
+		err := r.db.WithContext(ctx).Raw(`
+			SELECT COALESCE(SUM(b.size), 0) FROM registry_blobs b
+			JOIN registry_repository_blobs l ON l.digest = b.digest
+			WHERE l.repository_id = ?`, repos[i].ID).Scan(&repos[i].Size).Error
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return repos, total, nil
+}
+
+// DeleteRepository 删除仓库及其清单、标签、数据块关联，数据块文件由垃圾回收清理
+func (r *registryRepository) DeleteRepository(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("repository_id = ?", id).Delete(&models.Tag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("manifest_id IN (?)", tx.Model(&models.Manifest{}).Select("id").Where("repository_id = ?", id)).
+			Delete(&models.ManifestReference{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("repository_id = ?", id).Delete(&models.Manifest{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("repository_id = ?", id).Delete(&models.RepositoryBlob{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("repository_id = ?", id).Delete(&models.Upload{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Repository{}).Error
+	})
+}
+
+// TouchRepository 更新最后推送时间
+func (r *registryRepository) TouchRepository(ctx context.Context, id uuid.UUID) error {
+	now := time.Now().UTC()
+	return r.db.WithContext(ctx).
+		Model(&models.Repository{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_pushed_at": now,
+			"updated_at":     now,
+		}).Error
+}
+
+// 数据块管理
+
+// CreateBlob 记录数据块，已存在时忽略
+func (r *registryRepository) CreateBlob(ctx context.Context, blob *models.Blob) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(blob).Error
+}
+
+// GetBlob 获取数据块
+func (r *registryRepository) GetBlob(ctx context.Context, digest string) (*models.Blob, error) {
+	var blob models.Blob
+	err := r.db.WithContext(ctx).Where("digest = ?", digest).First(&blob).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrBlobNotFound
+		}
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// LinkBlob 关联数据块到仓库
+func (r *registryRepository) LinkBlob(ctx context.Context, repositoryID uuid.UUID, digest string) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.RepositoryBlob{RepositoryID: repositoryID, Digest: digest}).Error
+}
+
+// IsBlobLinked 判断数据块是否关联到仓库
+func (r *registryRepository) IsBlobLinked(ctx context.Context, repositoryID uuid.UUID, digest string) (bool, error) {
+	count, err := r.CountLinkedBlobs(ctx, repositoryID, []string{digest})
+	return count > 0, err
+}
+
+// UnlinkBlob 解除数据块与仓库的关联
+func (r *registryRepository) UnlinkBlob(ctx context.Context, repositoryID uuid.UUID, digest string) error {
+	result := r.db.WithContext(ctx).
+		Where("repository_id = ? AND digest = ?", repositoryID, digest).
+		Delete(&models.RepositoryBlob{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrBlobNotFound
+	}
+	return nil
+}
+
+// CountLinkedBlobs 统计仓库中已关联的数据块数量
+func (r *registryRepository) CountLinkedBlobs(ctx context.Context, repositoryID uuid.UUID, digests []string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.RepositoryBlob{}).
+		Where("repository_id = ? AND digest IN ?", repositoryID, digests).
+		Count(&count).Error
+	return count, err
+}
+
+// TenantHasBlob 判断租户下是否已有仓库关联该数据块
+func (r *registryRepository) TenantHasBlob(ctx context.Context, tenantID uuid.UUID, digest string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.RepositoryBlob{}).
+		Joins("JOIN registry_repositories repo ON repo.id = registry_repository_blobs.repository_id").
+		Where("repo.tenant_id = ? AND registry_repository_blobs.digest = ?", tenantID, digest).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// 清单与标签管理
+
+// SaveManifest 保存清单及其引用，tag非空时同时更新标签指向
+func (r *registryRepository) SaveManifest(ctx context.Context, manifest *models.Manifest, tag string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		references := manifest.References
+		manifest.References = nil
+
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "repository_id"}, {Name: "digest"}},
+			DoNothing: true,
+		}).Create(manifest).Error
+		manifest.References = references
+		if err != nil {
+			return err
+		}
+
+		// 相同摘要的清单已存在时复用原记录
+		if err := tx.Where("repository_id = ? AND digest = ?", manifest.RepositoryID, manifest.Digest).
+			Select("id", "created_at").First(manifest).Error; err != nil {
+			return err
+		}
+
+		if len(references) > 0 {
+			for i := range references {
+				references[i].ManifestID = manifest.ID
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&references).Error; err != nil {
+				return err
+			}
+		}
+
+		if tag == "" {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "repository_id"}, {Name: "name"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"manifest_id": manifest.ID, "updated_at": now}),
+		}).Create(&models.Tag{
+			RepositoryID: manifest.RepositoryID,
+			Name:         tag,
+			ManifestID:   manifest.ID,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}).Error
+	})
+}
+
+// GetManifestByDigest 根据摘要获取清单
+func (r *registryRepository) GetManifestByDigest(ctx context.Context, repositoryID uuid.UUID, digest string) (*models.Manifest, error) {
+	var manifest models.Manifest
+	err := r.db.WithContext(ctx).
+		Where("repository_id = ? AND digest = ?", repositoryID, digest).
+		First(&manifest).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrManifestNotFound
+		}
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// GetManifestByTag 根据标签获取清单
+func (r *registryRepository) GetManifestByTag(ctx context.Context, repositoryID uuid.UUID, tag string) (*models.Manifest, error) {
+	var manifest models.Manifest
+	err := r.db.WithContext(ctx).
+		Joins("JOIN registry_tags t ON t.manifest_id = registry_manifests.id").
+		Where("t.repository_id = ? AND t.name = ?", repositoryID, tag).
+		First(&manifest).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrManifestNotFound
+		}
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// CountManifests 统计仓库中已存在的清单数量
+func (r *registryRepository) CountManifests(ctx context.Context, repositoryID uuid.UUID, digests []string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Manifest{}).
+		Where("repository_id = ? AND digest IN ?", repositoryID, digests).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteManifest 删除清单及指向它的标签
+func (r *registryRepository) DeleteManifest(ctx context.Context, repositoryID uuid.UUID, digest string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var manifest models.Manifest
+		err := tx.Select("id").Where("repository_id = ? AND digest = ?", repositoryID, digest).First(&manifest).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.ErrManifestNotFound
+			}
+			return err
+		}
+
+		if err := tx.Where("manifest_id = ?", manifest.ID).Delete(&models.Tag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("manifest_id = ?", manifest.ID).Delete(&models.ManifestReference{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", manifest.ID).Delete(&models.Manifest{}).Error
+	})
+}
+
+// DeleteTag 删除标签
+func (r *registryRepository) DeleteTag(ctx context.Context, repositoryID uuid.UUID, tag string) error {
+	result := r.db.WithContext(ctx).
+		Where("repository_id = ? AND name = ?", repositoryID, tag).
+		Delete(&models.Tag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrManifestNotFound
+	}
+	return nil
+}
+
+// ListTags 按字典序分页获取标签名
+func (r *registryRepository) ListTags(ctx context.Context, repositoryID uuid.UUID, last string, limit int) ([]string, error) {
+	var tags []string
+	query := r.db.WithContext(ctx).
+		Model(&models.Tag{}).
+		Where("repository_id = ?", repositoryID)
+	if last != "" {
+		query = query.Where("name > ?", last)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	err := query.Order("name ASC").Pluck("name", &tags).Error
+	return tags, err
+}
+
+// 上传会话管理
+
+// CreateUpload 创建上传会话
+func (r *registryRepository) CreateUpload(ctx context.Context, upload *models.Upload) error {
+	return r.db.WithContext(ctx).Create(upload).Error
+}
+
+// GetUpload 获取上传会话
+func (r *registryRepository) GetUpload(ctx context.Context, repositoryID, id uuid.UUID) (*models.Upload, error) {
+	var upload models.Upload
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND repository_id = ?", id, repositoryID).
+		First(&upload).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrUploadNotFound
+		}
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// UpdateUploadOffset 更新上传偏移量
+func (r *registryRepository) UpdateUploadOffset(ctx context.Context, id uuid.UUID, offset int64) error {
+	return r.db.WithContext(ctx).
+		Model(&models.Upload{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"offset":     offset,
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
+
+// DeleteUpload 删除上传会话
+func (r *registryRepository) DeleteUpload(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.Upload{}).Error
+}
+
+// ListExpiredUploads 获取长时间未更新的上传会话
+func (r *registryRepository) ListExpiredUploads(ctx context.Context, before time.Time, limit int) ([]models.Upload, error) {
+	var uploads []models.Upload
+	err := r.db.WithContext(ctx).
+		Where("updated_at < ?", before).
+		Order("updated_at ASC").
+		Limit(limit).
+		Find(&uploads).Error
+	return uploads, err
+}
+
+// 垃圾回收
+
+// ListUntaggedManifests 获取可回收的清单：无标签、未被索引引用、且不是现存清单的引用者（referrer）
+func (r *registryRepository) ListUntaggedManifests(ctx context.Context, before time.Time, limit int) ([]models.Manifest, error) {
+	var manifests []models.Manifest
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT m.id, m.repository_id, m.digest, m.size FROM registry_manifests m
+		WHERE m.created_at < ?
+		  AND NOT EXISTS (SELECT 1 FROM registry_tags t WHERE t.manifest_id = m.id)
+		  AND NOT EXISTS (
+			SELECT 1 FROM registry_manifest_references r
+			JOIN registry_manifests p ON p.id = r.manifest_id
+			WHERE p.repository_id = m.repository_id AND r.digest = m.digest AND r.kind = ?)
+		  AND NOT EXISTS (
+			SELECT 1 FROM registry_manifests s
+			WHERE s.repository_id = m.repository_id AND s.digest = m.subject_digest)
+		ORDER BY m.created_at ASC
+		LIMIT ?`, before, models.ManifestReferenceManifest, limit).Scan(&manifests).Error
+	return manifests, err
+}
+
+// DeleteUnreferencedLinks 删除不再被任何清单引用的数据块关联
+func (r *registryRepository) DeleteUnreferencedLinks(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		DELETE FROM registry_repository_blobs l
+		WHERE l.created_at < ?
+		  AND NOT EXISTS (
+			SELECT 1 FROM registry_manifest_references r
+			JOIN registry_manifests m ON m.id = r.manifest_id
+			WHERE m.repository_id = l.repository_id AND r.digest = l.digest AND r.kind = ?)`,
+		before, models.ManifestReferenceBlob)
+	return result.RowsAffected, result.Error
+}
+
+// ListOrphanBlobs 获取未关联到任何仓库的数据块
+func (r *registryRepository) ListOrphanBlobs(ctx context.Context, before time.Time, limit int) ([]models.Blob, error) {
+	var blobs []models.Blob
+	err := r.db.WithContext(ctx).
+		Where("created_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM registry_repository_blobs l WHERE l.digest = registry_blobs.digest)").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&blobs).Error
+	return blobs, err
+}
+
+// DeleteOrphanBlob 删除数据块记录，期间被重新关联时不删除
+func (r *registryRepository) DeleteOrphanBlob(ctx context.Context, digest string) (bool, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		DELETE FROM registry_blobs b
+		WHERE b.digest = ?
+		  AND NOT EXISTS (SELECT 1 FROM registry_repository_blobs l WHERE l.digest = b.digest)`, digest)
+	if result.Error != nil {
+		return false, fmt.Errorf("删除数据块记录失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/models"
+	"github.com/opencontainers/go-digest"
+	"go.uber.org/zap"
+)
+
+// gcBatchSize 每批处理的记录数
+const gcBatchSize = 500
+
+// CollectGarbage 执行一次垃圾回收
+//
+// 回收顺序：过期上传会话 -> 无标签且未被引用的清单 -> 不再被清单引用的数据块关联 -> 未关联任何仓库的数据块。
+// 只处理创建时间早于宽限期的记录，避免与正在进行的推送（先传数据块后传清单）冲突。
+func (s *registryService) CollectGarbage(ctx context.Context) (*models.GCResult, error) {
+	start := time.Now()
+	result := &models.GCResult{}
+	now := time.Now().UTC()
+
+	// 过期上传会话
+	uploads, err := s.repo.ListExpiredUploads(ctx, now.Add(-s.uploadTTL), gcBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取过期上传会话失败: %w", err)
+	}
+	for _, upload := range uploads {
+		if err := s.store.CancelUpload(ctx, upload.ID.String()); err != nil {
+			s.logger.Warn("清理上传文件失败", zap.String("upload_id", upload.ID.String()), zap.Error(err))
+			continue
+		}
+		if err := s.repo.DeleteUpload(ctx, upload.ID); err != nil {
+			return nil, fmt.Errorf("删除上传会话失败: %w", err)
+		}
+		result.UploadsExpired++
+	}
+
+	cutoff := now.Add(-s.gracePeriod)
+
+	// 无标签清单：删除索引后其子清单在下一轮变为可回收
+	for {
+		manifests, err := s.repo.ListUntaggedManifests(ctx, cutoff, gcBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取无标签清单失败: %w", err)
+		}
+		for _, manifest := range manifests {
+			if err := s.repo.DeleteManifest(ctx, manifest.RepositoryID, manifest.Digest); err != nil && !errors.Is(err, models.ErrManifestNotFound) {
+				return nil, fmt.Errorf("删除清单失败: %w", err)
+			}
+			result.ManifestsDeleted++
+		}
+		if len(manifests) < gcBatchSize {
+			break
+		}
+	}
+
+	links, err := s.repo.DeleteUnreferencedLinks(ctx, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("删除数据块关联失败: %w", err)
+	}
+	result.LinksDeleted = int(links)
+
+	// 孤立数据块
+	for {
+		blobs, err := s.repo.ListOrphanBlobs(ctx, cutoff, gcBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取孤立数据块失败: %w", err)
+		}
+		for _, blob := range blobs {
+			deleted, err := s.repo.DeleteOrphanBlob(ctx, blob.Digest)
+			if err != nil {
+				return nil, err
+			}
+			if !deleted {
+				continue
+			}
+			if err := s.store.Delete(ctx, digest.Digest(blob.Digest)); err != nil {
+				s.logger.Warn("删除数据块文件失败", zap.String("digest", blob.Digest), zap.Error(err))
+				continue
+			}
+			result.BlobsDeleted++
+			result.BytesFreed += blob.Size
+		}
+		if len(blobs) < gcBatchSize {
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+	s.logger.Info("镜像仓库垃圾回收完成",
+		zap.Int("manifests_deleted", result.ManifestsDeleted),
+		zap.Int("links_deleted", result.LinksDeleted),
+		zap.Int("blobs_deleted", result.BlobsDeleted),
+		zap.Int64("bytes_freed", result.BytesFreed),
+		zap.Int("uploads_expired", result.UploadsExpired),
+		zap.Duration("duration", result.Duration))
+
+	return result, nil
+}
+
+// StartGarbageCollector 按固定间隔执行垃圾回收，直到ctx取消
+func StartGarbageCollector(ctx context.Context, svc RegistryService, interval time.Duration, logger *zap.Logger) {
+	if interval <= 0 {
+		logger.Info("镜像仓库垃圾回收已禁用")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.CollectGarbage(ctx); err != nil {
+				logger.Error("镜像仓库垃圾回收失败", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,557 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/models"
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/repository"
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/storage"
+	"github.com/cloud-platform/collaborative-dev/shared/quota"
+	"github.com/google/uuid"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"go.uber.org/zap"
+)
+
+// 支持的清单媒体类型
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	// MaxManifestSize 清单最大字节数
+	MaxManifestSize = 4 * 1024 * 1024
+)
+
+// RepositoryListResponse 仓库列表响应
+type RepositoryListResponse struct {
+	Repositories []models.Repository `json:"repositories"`
+	Total        int64               `json:"total"`
+	Page         int                 `json:"page"`
+	PageSize     int                 `json:"page_size"`
+}
+
+// RegistryService 镜像仓库服务接口
+type RegistryService interface {
+	// 仓库
+	ResolveRepository(ctx context.Context, name string, create bool) (*models.Repository, error)
+
+	// 数据块
+	StatBlob(ctx context.Context, repo *models.Repository, dgst digest.Digest) (*models.Blob, error)
+	OpenBlob(ctx context.Context, repo *models.Repository, dgst digest.Digest) (io.ReadSeekCloser, *models.Blob, error)
+	DeleteBlob(ctx context.Context, repo *models.Repository, dgst digest.Digest) error
+	MountBlob(ctx context.Context, repo *models.Repository, from string, dgst digest.Digest) (*models.Blob, error)
+
+	// 上传
+	StartUpload(ctx context.Context, repo *models.Repository) (*models.Upload, error)
+	GetUpload(ctx context.Context, repo *models.Repository, uploadID uuid.UUID) (*models.Upload, error)
+	AppendUpload(ctx context.Context, repo *models.Repository, uploadID uuid.UUID, offset, length int64, data io.Reader) (*models.Upload, error)
+	CompleteUpload(ctx context.Context, repo *models.Repository, uploadID uuid.UUID, dgst digest.Digest, length int64, data io.Reader) (*models.Blob, error)
+	CancelUpload(ctx context.Context, repo *models.Repository, uploadID uuid.UUID) error
+
+	// 清单与标签
+	PutManifest(ctx context.Context, repo *models.Repository, reference, mediaType string, payload []byte) (*models.Manifest, error)
+	GetManifest(ctx context.Context, repo *models.Repository, reference string) (*models.Manifest, error)
+	DeleteManifest(ctx context.Context, repo *models.Repository, reference string) error
+	ListTags(ctx context.Context, repo *models.Repository, last string, limit int) ([]string, error)
+
+	// 管理
+	ListRepositories(ctx context.Context, tenantID uuid.UUID, projectID *uuid.UUID, page, pageSize int) (*RepositoryListResponse, error)
+	DeleteRepository(ctx context.Context, tenantID uuid.UUID, name string) error
+	GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*quota.TenantStorage, error)
+	CollectGarbage(ctx context.Context) (*models.GCResult, error)
+}
+
+// registryService 镜像仓库服务实现
+type registryService struct {
+	repo        repository.RegistryRepository
+	store       storage.BlobStore
+	logger      *zap.Logger
+	gracePeriod time.Duration
+	uploadTTL   time.Duration
+}
+
+// NewRegistryService 创建镜像仓库服务
+func NewRegistryService(repo repository.RegistryRepository, store storage.BlobStore, logger *zap.Logger, gracePeriod, uploadTTL time.Duration) RegistryService {
+	return &registryService{
+		repo:        repo,
+		store:       store,
+		logger:      logger,
+		gracePeriod: gracePeriod,
+		uploadTTL:   uploadTTL,
+	}
+}
+
+// ResolveRepository 解析仓库，create为true时（推送）不存在则在对应租户项目下创建
+func (s *registryService) ResolveRepository(ctx context.Context, name string, create bool) (*models.Repository, error) {
+	parsed, err := models.ParseRepositoryName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := s.repo.GetRepositoryByName(ctx, parsed.String())
+	if err == nil || !errors.Is(err, models.ErrRepositoryNotFound) || !create {
+		return repo, err
+	}
+
+	tenantID, projectID, err := s.repo.ResolveNamespace(ctx, parsed.Tenant, parsed.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	repo = &models.Repository{
+		TenantID:  tenantID,
+		ProjectID: projectID,
+		Name:      parsed.String(),
+	}
+	if err := s.repo.CreateRepository(ctx, repo); err != nil {
+		return nil, fmt.Errorf("创建镜像仓库失败: %w", err)
+	}
+
+	s.logger.Info("创建镜像仓库", zap.String("name", repo.Name), zap.String("tenant_id", tenantID.String()))
+	return repo, nil
+}
+
+// 数据块
+
+// StatBlob 获取仓库中数据块信息
+func (s *registryService) StatBlob(ctx context.Context, repo *models.Repository, dgst digest.Digest) (*models.Blob, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrDigestInvalid, err)
+	}
+
+	linked, err := s.repo.IsBlobLinked(ctx, repo.ID, dgst.String())
+	if err != nil {
+		return nil, err
+	}
+	if !linked {
+		return nil, models.ErrBlobNotFound
+	}
+
+	return s.repo.GetBlob(ctx, dgst.String())
+}
+
+// OpenBlob 打开仓库中的数据块
+func (s *registryService) OpenBlob(ctx context.Context, repo *models.Repository, dgst digest.Digest) (io.ReadSeekCloser, *models.Blob, error) {
+	blob, err := s.StatBlob(ctx, repo, dgst)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := s.store.Open(ctx, dgst)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, blob, nil
+}
+
+// DeleteBlob 解除数据块与仓库的关联，文件由垃圾回收清理
+func (s *registryService) DeleteBlob(ctx context.Context, repo *models.Repository, dgst digest.Digest) error {
+	if err := dgst.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrDigestInvalid, err)
+	}
+	return s.repo.UnlinkBlob(ctx, repo.ID, dgst.String())
+}
+
+// MountBlob 从同租户的其他仓库挂载数据块，无需重新上传
+func (s *registryService) MountBlob(ctx context.Context, repo *models.Repository, from string, dgst digest.Digest) (*models.Blob, error) {
+	source, err := s.ResolveRepository(ctx, from, false)
+	if err != nil {
+		return nil, err
+	}
+	if source.TenantID != repo.TenantID {
+		return nil, models.ErrBlobNotFound
+	}
+
+	blob, err := s.StatBlob(ctx, source, dgst)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.LinkBlob(ctx, repo.ID, blob.Digest); err != nil {
+		return nil, fmt.Errorf("挂载数据块失败: %w", err)
+	}
+	return blob, nil
+}
+
+// 上传
+
+// StartUpload 创建上传会话
+func (s *registryService) StartUpload(ctx context.Context, repo *models.Repository) (*models.Upload, error) {
+	now := time.Now().UTC()
+	upload := &models.Upload{
+		ID:           uuid.New(),
+		RepositoryID: repo.ID,
+		TenantID:     repo.TenantID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := s.store.StartUpload(ctx, upload.ID.String()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateUpload(ctx, upload); err != nil {
+		_ = s.store.CancelUpload(ctx, upload.ID.String())
+		return nil, fmt.Errorf("创建上传会话失败: %w", err)
+	}
+	return upload, nil
+}
+
+// GetUpload 获取上传会话
+func (s *registryService) GetUpload(ctx context.Context, repo *models.Repository, uploadID uuid.UUID) (*models.Upload, error) {
+	return s.repo.GetUpload(ctx, repo.ID, uploadID)
+}
+
+// AppendUpload 追加上传分块，offset小于0表示从当前位置追加，length小于0表示长度未知
+func (s *registryService) AppendUpload(ctx context.Context, repo *models.Repository, uploadID uuid.UUID, offset, length int64, data io.Reader) (*models.Upload, error) {
+	upload, err := s.repo.GetUpload(ctx, repo.ID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		offset = upload.Offset
+	}
+	if offset != upload.Offset {
+		return upload, storage.ErrInvalidOffset
+	}
+
+	if length > 0 {
+		if err := s.checkQuota(ctx, repo.TenantID, length, true); err != nil {
+			return nil, err
+		}
+	}
+
+	newOffset, err := s.store.AppendUpload(ctx, uploadID.String(), offset, data)
+	if err != nil {
+		return upload, err
+	}
+
+	if err := s.repo.UpdateUploadOffset(ctx, uploadID, newOffset); err != nil {
+		return nil, fmt.Errorf("更新上传进度失败: %w", err)
+	}
+	upload.Offset = newOffset
+	return upload, nil
+}
+
+// CompleteUpload 追加最后一个分块（可为空）并提交数据块
+func (s *registryService) CompleteUpload(ctx context.Context, repo *models.Repository, uploadID uuid.UUID, dgst digest.Digest, length int64, data io.Reader) (*models.Blob, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrDigestInvalid, err)
+	}
+
+	if data != nil && length != 0 {
+		if _, err := s.AppendUpload(ctx, repo, uploadID, -1, length, data); err != nil {
+			return nil, err
+		}
+	} else if _, err := s.repo.GetUpload(ctx, repo.ID, uploadID); err != nil {
+		return nil, err
+	}
+
+	size, err := s.store.CommitUpload(ctx, uploadID.String(), dgst)
+	if err != nil {
+		if errors.Is(err, storage.ErrDigestMismatch) {
+			return nil, fmt.Errorf("%w: %v", models.ErrDigestInvalid, err)
+		}
+		return nil, err
+	}
+	if err := s.repo.DeleteUpload(ctx, uploadID); err != nil {
+		s.logger.Warn("删除上传会话失败", zap.String("upload_id", uploadID.String()), zap.Error(err))
+	}
+
+	// 数据块已在租户内存在时不重复计入配额
+	exists, err := s.repo.TenantHasBlob(ctx, repo.TenantID, dgst.String())
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := s.checkQuota(ctx, repo.TenantID, size, false); err != nil {
+			// 其他租户未使用该数据块时清理已提交的文件
+			if _, getErr := s.repo.GetBlob(ctx, dgst.String()); errors.Is(getErr, models.ErrBlobNotFound) {
+				_ = s.store.Delete(ctx, dgst)
+			}
+			return nil, err
+		}
+	}
+
+	blob := &models.Blob{Digest: dgst.String(), Size: size, CreatedAt: time.Now().UTC()}
+	if err := s.repo.CreateBlob(ctx, blob); err != nil {
+		return nil, fmt.Errorf("保存数据块记录失败: %w", err)
+	}
+	if err := s.repo.LinkBlob(ctx, repo.ID, blob.Digest); err != nil {
+		return nil, fmt.Errorf("关联数据块失败: %w", err)
+	}
+
+	return blob, nil
+}
+
+// CancelUpload 取消上传会话
+func (s *registryService) CancelUpload(ctx context.Context, repo *models.Repository, uploadID uuid.UUID) error {
+	if _, err := s.repo.GetUpload(ctx, repo.ID, uploadID); err != nil {
+		return err
+	}
+	if err := s.store.CancelUpload(ctx, uploadID.String()); err != nil {
+		return err
+	}
+	return s.repo.DeleteUpload(ctx, uploadID)
+}
+
+// checkQuota 校验租户存储配额，includePending为false时不计算进行中的上传
+func (s *registryService) checkQuota(ctx context.Context, tenantID uuid.UUID, size int64, includePending bool) error {
+	usage, err := s.GetTenantUsage(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if !includePending {
+		usage.PendingBytes = 0
+	}
+	if usage.Exceeds(size) {
+		return fmt.Errorf("%w: 已用 %d 字节，配额 %d 字节", models.ErrQuotaExceeded, usage.UsedBytes(), usage.QuotaBytes)
+	}
+	return nil
+}
+
+// 清单与标签
+
+// manifestPayload 清单与索引的公共字段
+type manifestPayload struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	MediaType     string               `json:"mediaType"`
+	ArtifactType  string               `json:"artifactType"`
+	Config        *ocispec.Descriptor  `json:"config"`
+	Layers        []ocispec.Descriptor `json:"layers"`
+	Manifests     []ocispec.Descriptor `json:"manifests"`
+	Subject       *ocispec.Descriptor  `json:"subject"`
+}
+
+// PutManifest 上传清单，reference为标签或摘要
+func (s *registryService) PutManifest(ctx context.Context, repo *models.Repository, reference, mediaType string, payload []byte) (*models.Manifest, error) {
+	if len(payload) > MaxManifestSize {
+		return nil, fmt.Errorf("%w: 清单大小超过 %d 字节", models.ErrManifestInvalid, MaxManifestSize)
+	}
+
+	dgst := digest.FromBytes(payload)
+	tag := ""
+	if ref, err := digest.Parse(reference); err == nil {
+		if ref != dgst {
+			return nil, fmt.Errorf("%w: 清单内容与摘要 %s 不匹配", models.ErrDigestInvalid, reference)
+		}
+	} else if models.IsValidTag(reference) {
+		tag = reference
+	} else {
+		return nil, models.ErrTagInvalid
+	}
+
+	var parsed manifestPayload
+	if err := json.Unmarshal(payload, &parsed); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrManifestInvalid, err)
+	}
+	if parsed.SchemaVersion != 2 {
+		return nil, fmt.Errorf("%w: 不支持的schemaVersion %d", models.ErrManifestInvalid, parsed.SchemaVersion)
+	}
+	if parsed.MediaType != "" {
+		if mediaType != "" && mediaType != parsed.MediaType {
+			return nil, fmt.Errorf("%w: Content-Type与清单mediaType不一致", models.ErrManifestInvalid)
+		}
+		mediaType = parsed.MediaType
+	}
+
+	manifest := &models.Manifest{
+		RepositoryID: repo.ID,
+		Digest:       dgst.String(),
+		MediaType:    mediaType,
+		ArtifactType: parsed.ArtifactType,
+		Size:         int64(len(payload)),
+		Payload:      payload,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if parsed.Subject != nil {
+		manifest.SubjectDigest = parsed.Subject.Digest.String()
+	}
+
+	var blobs, children []string
+	switch mediaType {
+	case ocispec.MediaTypeImageManifest, MediaTypeDockerManifest:
+		if parsed.Config == nil {
+			return nil, fmt.Errorf("%w: 缺少config", models.ErrManifestInvalid)
+		}
+		if manifest.ArtifactType == "" && parsed.Config.MediaType != ocispec.MediaTypeImageConfig {
+			manifest.ArtifactType = parsed.Config.MediaType
+		}
+		blobs = append(blobs, parsed.Config.Digest.String())
+		for _, layer := range parsed.Layers {
+			blobs = append(blobs, layer.Digest.String())
+		}
+	case ocispec.MediaTypeImageIndex, MediaTypeDockerManifestList:
+		for _, child := range parsed.Manifests {
+			children = append(children, child.Digest.String())
+		}
+	default:
+		return nil, fmt.Errorf("%w: 不支持的清单类型 %q", models.ErrManifestInvalid, mediaType)
+	}
+
+	for _, d := range append(append([]string{}, blobs...), children...) {
+		if err := digest.Digest(d).Validate(); err != nil {
+			return nil, fmt.Errorf("%w: 引用的摘要无效 %s", models.ErrManifestInvalid, d)
+		}
+	}
+
+	if err := s.verifyReferences(ctx, repo, blobs, children); err != nil {
+		return nil, err
+	}
+
+	for _, d := range uniqueStrings(blobs) {
+		manifest.References = append(manifest.References, models.ManifestReference{Digest: d, Kind: models.ManifestReferenceBlob})
+	}
+	for _, d := range uniqueStrings(children) {
+		manifest.References = append(manifest.References, models.ManifestReference{Digest: d, Kind: models.ManifestReferenceManifest})
+	}
+
+	if _, err := s.repo.GetManifestByDigest(ctx, repo.ID, manifest.Digest); errors.Is(err, models.ErrManifestNotFound) {
+		if err := s.checkQuota(ctx, repo.TenantID, manifest.Size, false); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveManifest(ctx, manifest, tag); err != nil {
+		return nil, fmt.Errorf("保存清单失败: %w", err)
+	}
+	if err := s.repo.TouchRepository(ctx, repo.ID); err != nil {
+		s.logger.Warn("更新仓库推送时间失败", zap.String("repository", repo.Name), zap.Error(err))
+	}
+
+	s.logger.Info("推送镜像清单",
+		zap.String("repository", repo.Name),
+		zap.String("digest", manifest.Digest),
+		zap.String("tag", tag))
+
+	return manifest, nil
+}
+
+// verifyReferences 校验清单引用的数据块和子清单都已存在于仓库中
+func (s *registryService) verifyReferences(ctx context.Context, repo *models.Repository, blobs, children []string) error {
+	if blobs = uniqueStrings(blobs); len(blobs) > 0 {
+		count, err := s.repo.CountLinkedBlobs(ctx, repo.ID, blobs)
+		if err != nil {
+			return err
+		}
+		if count != int64(len(blobs)) {
+			return models.ErrManifestBlobUnknown
+		}
+	}
+
+	if children = uniqueStrings(children); len(children) > 0 {
+		count, err := s.repo.CountManifests(ctx, repo.ID, children)
+		if err != nil {
+			return err
+		}
+		if count != int64(len(children)) {
+			return fmt.Errorf("%w: 索引引用的子清单不存在", models.ErrManifestBlobUnknown)
+		}
+	}
+
+	return nil
+}
+
+// GetManifest 根据标签或摘要获取清单
+func (s *registryService) GetManifest(ctx context.Context, repo *models.Repository, reference string) (*models.Manifest, error) {
+	if dgst, err := digest.Parse(reference); err == nil {
+		return s.repo.GetManifestByDigest(ctx, repo.ID, dgst.String())
+	}
+	if !models.IsValidTag(reference) {
+		return nil, models.ErrTagInvalid
+	}
+	return s.repo.GetManifestByTag(ctx, repo.ID, reference)
+}
+
+// DeleteManifest 按摘要删除清单；按标签删除时只删除标签，清单由垃圾回收处理
+func (s *registryService) DeleteManifest(ctx context.Context, repo *models.Repository, reference string) error {
+	if dgst, err := digest.Parse(reference); err == nil {
+		return s.repo.DeleteManifest(ctx, repo.ID, dgst.String())
+	}
+	if !models.IsValidTag(reference) {
+		return models.ErrTagInvalid
+	}
+	return s.repo.DeleteTag(ctx, repo.ID, reference)
+}
+
+// ListTags 获取标签列表
+func (s *registryService) ListTags(ctx context.Context, repo *models.Repository, last string, limit int) ([]string, error) {
+	tags, err := s.repo.ListTags(ctx, repo.ID, last, limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取标签列表失败: %w", err)
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	return tags, nil
+}
+
+// 管理
+
+// ListRepositories 获取租户的镜像仓库列表
+func (s *registryService) ListRepositories(ctx context.Context, tenantID uuid.UUID, projectID *uuid.UUID, page, pageSize int) (*RepositoryListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	repos, total, err := s.repo.ListRepositories(ctx, tenantID, projectID, page, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("获取镜像仓库列表失败: %w", err)
+	}
+
+	return &RepositoryListResponse{
+		Repositories: repos,
+		Total:        total,
+		Page:         page,
+		PageSize:     pageSize,
+	}, nil
+}
+
+// DeleteRepository 删除租户下的镜像仓库
+func (s *registryService) DeleteRepository(ctx context.Context, tenantID uuid.UUID, name string) error {
+	repo, err := s.ResolveRepository(ctx, name, false)
+	if err != nil {
+		return err
+	}
+	if repo.TenantID != tenantID {
+		return models.ErrRepositoryNotFound
+	}
+
+	if err := s.repo.DeleteRepository(ctx, repo.ID); err != nil {
+		return fmt.Errorf("删除镜像仓库失败: %w", err)
+	}
+
+	s.logger.Info("删除镜像仓库", zap.String("name", repo.Name))
+	return nil
+}
+
+// GetTenantUsage 获取租户存储用量与配额
+func (s *registryService) GetTenantUsage(ctx context.Context, tenantID uuid.UUID) (*quota.TenantStorage, error) {
+	usage, err := s.repo.GetTenantUsage(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取租户存储用量失败: %w", err)
+	}
+	return usage, nil
+}
+
+// uniqueStrings 去重并保持顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
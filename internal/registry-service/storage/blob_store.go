@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	_ "crypto/sha256" // 注册sha256摘要算法
+	_ "crypto/sha512" // 注册sha512摘要算法
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/models"
+	"github.com/opencontainers/go-digest"
+)
+
+// ErrInvalidOffset 分块上传偏移量与已接收数据不一致
+var ErrInvalidOffset = errors.New("上传偏移量不匹配")
+
+// ErrDigestMismatch 上传内容与声明的摘要不一致
+var ErrDigestMismatch = errors.New("内容摘要不匹配")
+
+// BlobStore 数据块存储接口
+type BlobStore interface {
+	// 已提交数据块
+	Stat(ctx context.Context, dgst digest.Digest) (int64, error)
+	Open(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, dgst digest.Digest) error
+
+	// 上传会话
+	StartUpload(ctx context.Context, uploadID string) error
+	AppendUpload(ctx context.Context, uploadID string, offset int64, data io.Reader) (int64, error)
+	CommitUpload(ctx context.Context, uploadID string, expected digest.Digest) (int64, error)
+	CancelUpload(ctx context.Context, uploadID string) error
+}
+
+// localBlobStore 基于本地文件系统的数据块存储
+//
+// 目录布局：
+//
+//	<root>/blobs/<algorithm>/<前2位>/<摘要>
+//	<root>/uploads/<上传ID>
+type localBlobStore struct {
+	root string
+}
+
+// NewLocalBlobStore 创建本地数据块存储
+func NewLocalBlobStore(root string) (BlobStore, error) {
+	for _, dir := range []string{"blobs", "uploads"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0750); err != nil {
+			return nil, fmt.Errorf("创建存储目录失败: %w", err)
+		}
+	}
+	return &localBlobStore{root: root}, nil
+}
+
+// Stat 获取数据块大小
+func (s *localBlobStore) Stat(ctx context.Context, dgst digest.Digest) (int64, error) {
+	path, err := s.blobPath(dgst)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, models.ErrBlobNotFound
+		}
+		return 0, fmt.Errorf("读取数据块信息失败: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// Open 打开数据块
+func (s *localBlobStore) Open(ctx context.Context, dgst digest.Digest) (io.ReadSeekCloser, error) {
+	path, err := s.blobPath(dgst)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, models.ErrBlobNotFound
+		}
+		return nil, fmt.Errorf("打开数据块失败: %w", err)
+	}
+	return file, nil
+}
+
+// Delete 删除数据块
+func (s *localBlobStore) Delete(ctx context.Context, dgst digest.Digest) error {
+	path, err := s.blobPath(dgst)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除数据块失败: %w", err)
+	}
+	return nil
+}
+
+// StartUpload 创建上传会话文件
+func (s *localBlobStore) StartUpload(ctx context.Context, uploadID string) error {
+	file, err := os.OpenFile(s.uploadPath(uploadID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("创建上传文件失败: %w", err)
+	}
+	return file.Close()
+}
+
+// AppendUpload 追加上传数据，offset必须等于已接收的字节数，返回新的偏移量
+func (s *localBlobStore) AppendUpload(ctx context.Context, uploadID string, offset int64, data io.Reader) (int64, error) {
+	file, err := os.OpenFile(s.uploadPath(uploadID), os.O_WRONLY, 0640)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, models.ErrUploadNotFound
+		}
+		return 0, fmt.Errorf("打开上传文件失败: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("读取上传文件信息失败: %w", err)
+	}
+	if info.Size() != offset {
+		return info.Size(), ErrInvalidOffset
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, fmt.Errorf("定位上传文件失败: %w", err)
+	}
+
+	written, err := io.Copy(file, data)
+	if err != nil {
+		// 写入失败时回退到原偏移量，保证客户端可以从已确认的位置重试
+		_ = file.Truncate(offset)
+		return offset, fmt.Errorf("写入上传数据失败: %w", err)
+	}
+
+	return offset + written, nil
+}
+
+// CommitUpload 校验摘要并将上传文件移动到数据块目录，返回数据块大小
+func (s *localBlobStore) CommitUpload(ctx context.Context, uploadID string, expected digest.Digest) (int64, error) {
+	if err := expected.Validate(); err != nil {
+		return 0, fmt.Errorf("无效的摘要: %w", err)
+	}
+
+	uploadPath := s.uploadPath(uploadID)
+	file, err := os.Open(uploadPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, models.ErrUploadNotFound
+		}
+		return 0, fmt.Errorf("打开上传文件失败: %w", err)
+	}
+
+	verifier := expected.Verifier()
+	size, err := io.Copy(verifier, file)
+	file.Close()
+	if err != nil {
+		return 0, fmt.Errorf("计算上传内容摘要失败: %w", err)
+	}
+	if !verifier.Verified() {
+		return 0, ErrDigestMismatch
+	}
+
+	blobPath, err := s.blobPath(expected)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0750); err != nil {
+		return 0, fmt.Errorf("创建数据块目录失败: %w", err)
+	}
+
+	// 内容寻址：相同摘要的数据块已存在时直接复用
+	if _, err := os.Stat(blobPath); err == nil {
+		_ = os.Remove(uploadPath)
+		return size, nil
+	}
+
+	if err := os.Rename(uploadPath, blobPath); err != nil {
+		return 0, fmt.Errorf("提交数据块失败: %w", err)
+	}
+	return size, nil
+}
+
+// CancelUpload 删除上传会话文件
+func (s *localBlobStore) CancelUpload(ctx context.Context, uploadID string) error {
+	if err := os.Remove(s.uploadPath(uploadID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除上传文件失败: %w", err)
+	}
+	return nil
+}
+
+// blobPath 计算数据块存储路径
+func (s *localBlobStore) blobPath(dgst digest.Digest) (string, error) {
+	if err := dgst.Validate(); err != nil {
+		return "", fmt.Errorf("无效的摘要: %w", err)
+	}
+	encoded := dgst.Encoded()
+	return filepath.Join(s.root, "blobs", dgst.Algorithm().String(), encoded[:2], encoded), nil
+}
+
+// uploadPath 计算上传会话文件路径
+func (s *localBlobStore) uploadPath(uploadID string) string {
+	return filepath.Join(s.root, "uploads", filepath.Base(uploadID))
+}
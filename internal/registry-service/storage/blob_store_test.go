@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cloud-platform/collaborative-dev/internal/registry-service/models"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalBlobStoreChunkedUpload(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	content := "hello registry"
+	dgst := digest.FromString(content)
+
+	require.NoError(t, store.StartUpload(ctx, "upload-1"))
+
+	offset, err := store.AppendUpload(ctx, "upload-1", 0, strings.NewReader(content[:5]))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), offset)
+
+	// 偏移量不匹配时返回当前已接收的字节数
+	current, err := store.AppendUpload(ctx, "upload-1", 3, strings.NewReader("x"))
+	assert.ErrorIs(t, err, ErrInvalidOffset)
+	assert.Equal(t, int64(5), current)
+
+	offset, err = store.AppendUpload(ctx, "upload-1", offset, strings.NewReader(content[5:]))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), offset)
+
+	size, err := store.CommitUpload(ctx, "upload-1", dgst)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+
+	reader, err := store.Open(ctx, dgst)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	require.NoError(t, store.Delete(ctx, dgst))
+	_, err = store.Stat(ctx, dgst)
+	assert.ErrorIs(t, err, models.ErrBlobNotFound)
+}
+
+func TestLocalBlobStoreDigestMismatch(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalBlobStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.StartUpload(ctx, "upload-2"))
+	_, err = store.AppendUpload(ctx, "upload-2", 0, strings.NewReader("payload"))
+	require.NoError(t, err)
+
+	_, err = store.CommitUpload(ctx, "upload-2", digest.FromString("other"))
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+
+	_, err = store.AppendUpload(ctx, "missing", 0, strings.NewReader("x"))
+	assert.ErrorIs(t, err, models.ErrUploadNotFound)
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Git LFS对象令牌类型及操作
+const (
+	LFSTokenType = "lfs"
+
+	LFSOperationDownload = "download"
+	LFSOperationUpload   = "upload"
+)
+
+// LFSClaims Git LFS对象令牌声明，只授权单个仓库中单个对象的一种操作
+type LFSClaims struct {
+	UserID       uuid.UUID `json:"user_id"`
+	TenantID     uuid.UUID `json:"tenant_id"`
+	RepositoryID uuid.UUID `json:"repository_id"`
+	OID          string    `json:"oid"`
+	Operation    string    `json:"operation"` // download, upload
+	TokenType    string    `json:"token_type"`
+	jwt.RegisteredClaims
+}
+
+// Allows 判断令牌是否授权了指定仓库对象的操作
+func (c *LFSClaims) Allows(repositoryID uuid.UUID, oid, operation string) bool {
+	return c.RepositoryID == repositoryID && c.OID == oid && c.Operation == operation
+}
+
+// GenerateLFSToken 生成Git LFS对象令牌，用于批量接口返回的上传/下载/校验链接
+func (j *JWTService) GenerateLFSToken(userID, tenantID, repositoryID uuid.UUID, oid, operation string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims := &LFSClaims{
+		UserID:       userID,
+		TenantID:     tenantID,
+		RepositoryID: repositoryID,
+		OID:          oid,
+		Operation:    operation,
+		TokenType:    LFSTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "collaborative-platform",
+			Audience:  []string{"git-lfs"},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(j.secretKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("生成LFS令牌失败: %w", err)
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// ValidateLFSToken 验证Git LFS对象令牌
+func (j *JWTService) ValidateLFSToken(tokenString string) (*LFSClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &LFSClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("无效的签名方法: %v", token.Header["alg"])
+		}
+		return j.secretKey, nil
+	}, jwt.WithAudience("git-lfs"), jwt.WithIssuer("collaborative-platform"))
+	if err != nil {
+		return nil, fmt.Errorf("令牌解析失败: %w", err)
+	}
+
+	claims, ok := token.Claims.(*LFSClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("无效的令牌")
+	}
+
+	if claims.TokenType != LFSTokenType {
+		return nil, fmt.Errorf("提供的不是LFS令牌")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLFSToken(t *testing.T) {
+	jwtService := NewJWTService("test-secret", time.Hour, time.Hour)
+	userID, tenantID, repositoryID := uuid.New(), uuid.New(), uuid.New()
+	oid := strings.Repeat("a", 64)
+
+	token, expiresAt, err := jwtService.GenerateLFSToken(userID, tenantID, repositoryID, oid, LFSOperationUpload, time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Second)
+
+	claims, err := jwtService.ValidateLFSToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.True(t, claims.Allows(repositoryID, oid, LFSOperationUpload))
+	assert.False(t, claims.Allows(repositoryID, oid, LFSOperationDownload))
+	assert.False(t, claims.Allows(uuid.New(), oid, LFSOperationUpload))
+	assert.False(t, claims.Allows(repositoryID, strings.Repeat("b", 64), LFSOperationUpload))
+
+	// 普通访问令牌不能用作LFS令牌
+	pair, err := jwtService.GenerateTokenPair(userID, tenantID, "dev@example.com", "user", nil)
+	require.NoError(t, err)
+	_, err = jwtService.ValidateLFSToken(pair.AccessToken)
+	assert.Error(t, err)
+
+	// LFS令牌不能用作普通访问令牌
+	_, err = jwtService.ValidateToken(token)
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// 镜像仓库令牌类型及操作
+const (
+	RegistryTokenType = "registry"
+
+	RegistryActionPull   = "pull"
+	RegistryActionPush   = "push"
+	RegistryActionDelete = "delete"
+)
+
+// RegistryAccess 镜像仓库访问范围（对应Docker Token规范中的access声明）
+type RegistryAccess struct {
+	Type    string   `json:"type"`    // repository
+	Name    string   `json:"name"`    // 仓库全名，如 tenant/project/app
+	Actions []string `json:"actions"` // pull, push, delete
+}
+
+// RegistryClaims 镜像仓库令牌声明
+type RegistryClaims struct {
+	UserID    uuid.UUID        `json:"user_id"`
+	TenantID  uuid.UUID        `json:"tenant_id"`
+	Access    []RegistryAccess `json:"access"`
+	TokenType string           `json:"token_type"`
+	jwt.RegisteredClaims
+}
+
+// ParseRegistryScope 解析scope参数，格式为 repository:<name>:<action>[,<action>]
+func ParseRegistryScope(scope string) (*RegistryAccess, error) {
+	first := strings.Index(scope, ":")
+	last := strings.LastIndex(scope, ":")
+	if first <= 0 || last == first || last == len(scope)-1 {
+		return nil, fmt.Errorf("无效的scope: %s", scope)
+	}
+
+	access := &RegistryAccess{
+		Type: scope[:first],
+		Name: scope[first+1 : last],
+	}
+	for _, action := range strings.Split(scope[last+1:], ",") {
+		action = strings.TrimSpace(action)
+		if action == "*" {
+			access.Actions = append(access.Actions, RegistryActionPull, RegistryActionPush, RegistryActionDelete)
+			continue
+		}
+		if action != "" {
+			access.Actions = append(access.Actions, action)
+		}
+	}
+
+	if access.Name == "" || len(access.Actions) == 0 {
+		return nil, fmt.Errorf("无效的scope: %s", scope)
+	}
+	return access, nil
+}
+
+// Allows 判断是否允许指定操作
+func (a *RegistryAccess) Allows(action string) bool {
+	for _, granted := range a.Actions {
+		if granted == action {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAccess 判断令牌是否授予了仓库的指定操作
+func (c *RegistryClaims) HasAccess(name, action string) bool {
+	for i := range c.Access {
+		if c.Access[i].Type == "repository" && c.Access[i].Name == name && c.Access[i].Allows(action) {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateRegistryToken 生成镜像仓库访问令牌，service作为令牌受众
+func (j *JWTService) GenerateRegistryToken(userID, tenantID uuid.UUID, service string, access []RegistryAccess, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims := &RegistryClaims{
+		UserID:    userID,
+		TenantID:  tenantID,
+		Access:    access,
+		TokenType: RegistryTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Subject:   userID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "collaborative-platform",
+			Audience:  []string{service},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(j.secretKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("生成镜像仓库令牌失败: %w", err)
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// ValidateRegistryToken 验证镜像仓库令牌
+func (j *JWTService) ValidateRegistryToken(tokenString, service string) (*RegistryClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &RegistryClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("无效的签名方法: %v", token.Header["alg"])
+		}
+		return j.secretKey, nil
+	}, jwt.WithAudience(service), jwt.WithIssuer("collaborative-platform"))
+	if err != nil {
+		return nil, fmt.Errorf("令牌解析失败: %w", err)
+	}
+
+	claims, ok := token.Claims.(*RegistryClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("无效的令牌")
+	}
+
+	if claims.TokenType != RegistryTokenType {
+		return nil, fmt.Errorf("提供的不是镜像仓库令牌")
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegistryScope(t *testing.T) {
+	access, err := ParseRegistryScope("repository:acme/web/api:pull,push")
+	require.NoError(t, err)
+	assert.Equal(t, "repository", access.Type)
+	assert.Equal(t, "acme/web/api", access.Name)
+	assert.Equal(t, []string{"pull", "push"}, access.Actions)
+
+	access, err = ParseRegistryScope("repository:acme/web/api:*")
+	require.NoError(t, err)
+	assert.True(t, access.Allows(RegistryActionDelete))
+
+	for _, scope := range []string{"", "repository", "repository:acme", "repository:acme:", ":acme:pull"} {
+		_, err := ParseRegistryScope(scope)
+		assert.Error(t, err, scope)
+	}
+}
+
+func TestRegistryToken(t *testing.T) {
+	jwtService := NewJWTService("test-secret", time.Hour, time.Hour)
+	userID, tenantID := uuid.New(), uuid.New()
+	access := []RegistryAccess{{Type: "repository", Name: "acme/web/api", Actions: []string{RegistryActionPull}}}
+
+	token, expiresAt, err := jwtService.GenerateRegistryToken(userID, tenantID, "registry", access, 5*time.Minute)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Second)
+
+	claims, err := jwtService.ValidateRegistryToken(token, "registry")
+	require.NoError(t, err)
+	assert.Equal(t, userID, claims.UserID)
+	assert.True(t, claims.HasAccess("acme/web/api", RegistryActionPull))
+	assert.False(t, claims.HasAccess("acme/web/api", RegistryActionPush))
+	assert.False(t, claims.HasAccess("acme/web/other", RegistryActionPull))
+
+	// 受众不匹配
+	_, err = jwtService.ValidateRegistryToken(token, "other-registry")
+	assert.Error(t, err)
+
+	// 普通访问令牌不能用于镜像仓库
+	pair, err := jwtService.GenerateTokenPair(userID, tenantID, "dev@example.com", "user", nil)
+	require.NoError(t, err)
+	_, err = jwtService.ValidateRegistryToken(pair.AccessToken, "collaborative-platform-api")
+	assert.Error(t, err)
+
+	// 镜像仓库令牌不能用作普通访问令牌
+	_, err = jwtService.ValidateToken(token)
+	assert.Error(t, err)
+}
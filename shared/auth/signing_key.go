@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/ssh"
+)
+
+// 签名公钥类型
+const (
+	SigningKeyTypeGPG = "gpg"
+	SigningKeyTypeSSH = "ssh"
+)
+
+const (
+	gpgSignatureHeader = "-----BEGIN PGP SIGNATURE-----"
+	sshSignatureHeader = "-----BEGIN SSH SIGNATURE-----"
+
+	sshSignatureMagic     = "SSHSIG"
+	sshSignatureNamespace = "git" // git签名使用的命名空间
+)
+
+var (
+	ErrInvalidSigningKey = errors.New("无效的签名公钥")
+	ErrInvalidSignature  = errors.New("无效的签名")
+	ErrBadSignature      = errors.New("签名与内容不匹配")
+)
+
+// SigningKeyInfo 签名公钥解析结果
+type SigningKeyInfo struct {
+	Type        string
+	Fingerprint string   // GPG为主密钥指纹（大写十六进制），SSH为SHA256指纹
+	KeyIDs      []string // 签名中可能出现的密钥标识：GPG为主密钥和子密钥的长ID，SSH为指纹
+	Emails      []string // GPG用户ID中的邮箱，小写
+}
+
+// ParseSigningKey 解析用户上传的签名公钥
+// GPG公钥为ASCII armor格式，每次只能包含一个主密钥；SSH公钥为authorized_keys单行格式
+func ParseSigningKey(keyType, publicKey string) (*SigningKeyInfo, error) {
+	switch keyType {
+	case SigningKeyTypeGPG:
+		entity, err := readGPGEntity(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		info := &SigningKeyInfo{
+			Type:        SigningKeyTypeGPG,
+			Fingerprint: fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint),
+			KeyIDs:      []string{entity.PrimaryKey.KeyIdString()},
+		}
+		for _, subkey := range entity.Subkeys {
+			info.KeyIDs = append(info.KeyIDs, subkey.PublicKey.KeyIdString())
+		}
+		for _, identity := range entity.Identities {
+			if email := strings.ToLower(identity.UserId.Email); email != "" && !containsString(info.Emails, email) {
+				info.Emails = append(info.Emails, email)
+			}
+		}
+		return info, nil
+	case SigningKeyTypeSSH:
+		pub, _, _, rest, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSigningKey, err)
+		}
+		if len(bytes.TrimSpace(rest)) > 0 {
+			return nil, fmt.Errorf("%w: 每次只能上传一个SSH公钥", ErrInvalidSigningKey)
+		}
+		fingerprint := ssh.FingerprintSHA256(pub)
+		return &SigningKeyInfo{
+			Type:        SigningKeyTypeSSH,
+			Fingerprint: fingerprint,
+			KeyIDs:      []string{fingerprint},
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: 不支持的密钥类型 %q", ErrInvalidSigningKey, keyType)
+	}
+}
+
+// SignatureType 根据armor头识别签名类型，无法识别时返回空字符串
+func SignatureType(signature []byte) string {
+	trimmed := bytes.TrimSpace(signature)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte(gpgSignatureHeader)):
+		return SigningKeyTypeGPG
+	case bytes.HasPrefix(trimmed, []byte(sshSignatureHeader)):
+		return SigningKeyTypeSSH
+	default:
+		return ""
+	}
+}
+
+// SignatureKeyID 从签名中取出签名者的密钥标识，用于查找签名公钥
+// 返回值与 SigningKeyInfo.KeyIDs 的格式一致
+func SignatureKeyID(signature []byte) (string, error) {
+	switch SignatureType(signature) {
+	case SigningKeyTypeGPG:
+		sig, err := readGPGSignature(signature)
+		if err != nil {
+			return "", err
+		}
+		if sig.IssuerKeyId == nil {
+			return "", fmt.Errorf("%w: 签名缺少签发者密钥ID", ErrInvalidSignature)
+		}
+		return fmt.Sprintf("%016X", *sig.IssuerKeyId), nil
+	case SigningKeyTypeSSH:
+		sig, err := readSSHSignature(signature)
+		if err != nil {
+			return "", err
+		}
+		return ssh.FingerprintSHA256(sig.publicKey), nil
+	default:
+		return "", fmt.Errorf("%w: 不支持的签名格式", ErrInvalidSignature)
+	}
+}
+
+// VerifySignature 使用签名公钥校验分离签名，签名与内容或公钥不匹配时返回 ErrBadSignature
+func VerifySignature(keyType, publicKey string, payload, signature []byte) error {
+	if SignatureType(signature) != keyType {
+		return fmt.Errorf("%w: 签名类型与公钥类型不一致", ErrBadSignature)
+	}
+
+	switch keyType {
+	case SigningKeyTypeGPG:
+		entity, err := readGPGEntity(publicKey)
+		if err != nil {
+			return err
+		}
+		keyring := openpgp.EntityList{entity}
+		if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(payload), bytes.NewReader(signature)); err != nil {
+			return fmt.Errorf("%w: %v", ErrBadSignature, err)
+		}
+		return nil
+	case SigningKeyTypeSSH:
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSigningKey, err)
+		}
+		sig, err := readSSHSignature(signature)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(sig.publicKey.Marshal(), pub.Marshal()) {
+			return fmt.Errorf("%w: 签名公钥不匹配", ErrBadSignature)
+		}
+		return sig.verify(payload)
+	default:
+		return fmt.Errorf("%w: 不支持的密钥类型 %q", ErrInvalidSigningKey, keyType)
+	}
+}
+
+// readGPGEntity 读取只包含一个主密钥的GPG公钥
+func readGPGEntity(publicKey string) (*openpgp.Entity, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSigningKey, err)
+	}
+	if len(entities) != 1 {
+		return nil, fmt.Errorf("%w: 每次只能上传一个GPG主密钥", ErrInvalidSigningKey)
+	}
+	if entities[0].PrivateKey != nil {
+		return nil, fmt.Errorf("%w: 请上传公钥而不是私钥", ErrInvalidSigningKey)
+	}
+	return entities[0], nil
+}
+
+// readGPGSignature 读取armor格式的GPG签名包
+func readGPGSignature(signature []byte) (*packet.Signature, error) {
+	block, err := armor.Decode(bytes.NewReader(signature))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if block.Type != openpgp.SignatureType {
+		return nil, fmt.Errorf("%w: 非签名块 %s", ErrInvalidSignature, block.Type)
+	}
+	p, err := packet.Read(block.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	sig, ok := p.(*packet.Signature)
+	if !ok {
+		return nil, fmt.Errorf("%w: 非签名包", ErrInvalidSignature)
+	}
+	return sig, nil
+}
+
+// sshSignature 解析后的SSH签名（OpenSSH PROTOCOL.sshsig）
+type sshSignature struct {
+	publicKey     ssh.PublicKey
+	namespace     string
+	reserved      string
+	hashAlgorithm string
+	signature     *ssh.Signature
+}
+
+// readSSHSignature 解析armor格式的SSH签名，只接受git命名空间
+func readSSHSignature(signature []byte) (*sshSignature, error) {
+	block, _ := pem.Decode(bytes.TrimSpace(signature))
+	if block == nil || block.Type != "SSH SIGNATURE" {
+		return nil, fmt.Errorf("%w: 无法解析SSH签名", ErrInvalidSignature)
+	}
+	if !bytes.HasPrefix(block.Bytes, []byte(sshSignatureMagic)) {
+		return nil, fmt.Errorf("%w: SSH签名格式错误", ErrInvalidSignature)
+	}
+
+	var wire struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}
+	if err := ssh.Unmarshal(block.Bytes[len(sshSignatureMagic):], &wire); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if wire.Version != 1 {
+		return nil, fmt.Errorf("%w: 不支持的SSH签名版本 %d", ErrInvalidSignature, wire.Version)
+	}
+	if wire.Namespace != sshSignatureNamespace {
+		return nil, fmt.Errorf("%w: SSH签名命名空间为 %q，应为 %q", ErrInvalidSignature, wire.Namespace, sshSignatureNamespace)
+	}
+
+	pub, err := ssh.ParsePublicKey(wire.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	var sig struct {
+		Format string
+		Blob   []byte
+		Rest   []byte `ssh:"rest"`
+	}
+	if err := ssh.Unmarshal(wire.Signature, &sig); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	return &sshSignature{
+		publicKey:     pub,
+		namespace:     wire.Namespace,
+		reserved:      wire.Reserved,
+		hashAlgorithm: wire.HashAlgorithm,
+		signature:     &ssh.Signature{Format: sig.Format, Blob: sig.Blob, Rest: sig.Rest},
+	}, nil
+}
+
+// verify 按sshsig规范重建被签名数据并校验
+func (s *sshSignature) verify(payload []byte) error {
+	var digest []byte
+	switch s.hashAlgorithm {
+	case "sha256":
+		sum := sha256.Sum256(payload)
+		digest = sum[:]
+	case "sha512":
+		sum := sha512.Sum512(payload)
+		digest = sum[:]
+	default:
+		return fmt.Errorf("%w: 不支持的哈希算法 %q", ErrInvalidSignature, s.hashAlgorithm)
+	}
+
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{s.namespace, s.reserved, s.hashAlgorithm, digest})...)
+
+	if err := s.publicKey.Verify(signed, s.signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	return nil
+}
+
+// containsString 判断切片是否包含指定字符串
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
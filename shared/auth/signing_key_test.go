@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGPGSignature(t *testing.T) {
+	entity, err := openpgp.NewEntity("Alice", "", "Alice@Example.com", nil)
+	require.NoError(t, err)
+
+	var publicKey bytes.Buffer
+	w, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+
+	info, err := ParseSigningKey(SigningKeyTypeGPG, publicKey.String())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice@example.com"}, info.Emails)
+	assert.Len(t, info.Fingerprint, 40)
+	assert.Contains(t, info.KeyIDs, entity.PrimaryKey.KeyIdString())
+
+	payload := []byte("tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n\nsigned commit\n")
+	var signature bytes.Buffer
+	require.NoError(t, openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(payload), nil))
+
+	assert.Equal(t, SigningKeyTypeGPG, SignatureType(signature.Bytes()))
+	keyID, err := SignatureKeyID(signature.Bytes())
+	require.NoError(t, err)
+	assert.Contains(t, info.KeyIDs, keyID)
+
+	assert.NoError(t, VerifySignature(SigningKeyTypeGPG, publicKey.String(), payload, signature.Bytes()))
+	err = VerifySignature(SigningKeyTypeGPG, publicKey.String(), []byte("tampered"), signature.Bytes())
+	assert.ErrorIs(t, err, ErrBadSignature)
+}
+
+func TestSSHSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	publicKey := string(ssh.MarshalAuthorizedKey(sshPub))
+
+	info, err := ParseSigningKey(SigningKeyTypeSSH, publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, ssh.FingerprintSHA256(sshPub), info.Fingerprint)
+	assert.Equal(t, []string{info.Fingerprint}, info.KeyIDs)
+
+	payload := []byte("object 4b825dc642cb6eb9a060e54bf8d69288fbee4904\n\nsigned tag\n")
+	signature := sshSign(t, signer, "git", payload)
+
+	assert.Equal(t, SigningKeyTypeSSH, SignatureType(signature))
+	keyID, err := SignatureKeyID(signature)
+	require.NoError(t, err)
+	assert.Equal(t, info.Fingerprint, keyID)
+
+	assert.NoError(t, VerifySignature(SigningKeyTypeSSH, publicKey, payload, signature))
+	assert.ErrorIs(t, VerifySignature(SigningKeyTypeSSH, publicKey, []byte("tampered"), signature), ErrBadSignature)
+
+	// 其他用途（如file命名空间）的签名不能当作提交签名
+	_, err = SignatureKeyID(sshSign(t, signer, "file", payload))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestParseSigningKeyInvalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyType   string
+		publicKey string
+	}{
+		{"未知类型", "x509", "anything"},
+		{"GPG内容错误", SigningKeyTypeGPG, "not a key"},
+		{"SSH内容错误", SigningKeyTypeSSH, "ssh-ed25519 bm90IGEga2V5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSigningKey(tt.keyType, tt.publicKey)
+			assert.ErrorIs(t, err, ErrInvalidSigningKey)
+		})
+	}
+}
+
+// sshSign 按sshsig规范生成armor格式签名，等价于 ssh-keygen -Y sign
+func sshSign(t *testing.T, signer ssh.Signer, namespace string, payload []byte) []byte {
+	digest := sha512.Sum512(payload)
+	signed := append([]byte(sshSignatureMagic), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{namespace, "", "sha512", digest[:]})...)
+
+	sig, err := signer.Sign(rand.Reader, signed)
+	require.NoError(t, err)
+
+	blob := append([]byte(sshSignatureMagic), ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{1, signer.PublicKey().Marshal(), namespace, "", "sha512", ssh.Marshal(sig)})...)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "SSH SIGNATURE", Bytes: blob})
+}
@@ -24,6 +24,7 @@ type Config struct {
 	Storage  StorageConfig  `mapstructure:"storage"`
 	CICD     CICDConfig     `mapstructure:"cicd"`
 	Git      GitConfig      `mapstructure:"git"`
+	Registry RegistryConfig `mapstructure:"registry"`
 }
 
 // ServerConfig 服务器配置
@@ -321,6 +322,37 @@ type GitConfig struct {
 	AsyncDeleteEnabled  bool          `mapstructure:"async_delete_enabled" default:"true"`
 	DeleteRetryAttempts int           `mapstructure:"delete_retry_attempts" default:"3"`
 	DeleteRetryDelay    time.Duration `mapstructure:"delete_retry_delay" default:"5s"`
+	// Git LFS设置
+	LFSStoragePath   string        `mapstructure:"lfs_storage_path"`                  // 为空时使用 storage.local.base_path/lfs
+	LFSActionExpiry  time.Duration `mapstructure:"lfs_action_expiry" default:"1h"`    // 批量接口返回的上传/下载链接有效期
+	LFSGCInterval    time.Duration `mapstructure:"lfs_gc_interval" default:"24h"`     // 0表示禁用自动回收
+	LFSGCGracePeriod time.Duration `mapstructure:"lfs_gc_grace_period" default:"24h"` // 新上传对象在推送引用前不被回收
+	// 推送策略设置
+	HookBaseURL string `mapstructure:"hook_base_url" default:"http://localhost:8084"` // pre-receive钩子回调网关的内部地址，为空时不安装钩子
+	HookSecret  string `mapstructure:"hook_secret"`                                   // 计算各仓库钩子回调令牌的密钥，为空时不安装钩子并拒绝钩子回调
+	// 代码所有者设置
+	TeamServiceURL string `mapstructure:"team_service_url" default:"http://localhost:8086"` // 解析CODEOWNERS中团队的团队服务地址，为空时忽略团队所有者
+	// 仓库维护设置
+	MaintenanceInterval      time.Duration `mapstructure:"maintenance_interval" default:"5m"`       // 检查待维护仓库的间隔，0表示禁用定时维护
+	MaintenancePushThreshold int           `mapstructure:"maintenance_push_threshold" default:"50"` // 推送次数达到该值时维护仓库
+	MaintenanceMaxAge        time.Duration `mapstructure:"maintenance_max_age" default:"24h"`       // 有推送的仓库最长间隔多久维护一次
+	MaintenanceAlertWebhook  string        `mapstructure:"maintenance_alert_webhook"`               // 维护失败告警的Slack Webhook地址，为空时只记录日志
+	// 项目服务通知设置
+	ProjectServiceURL    string `mapstructure:"project_service_url" default:"http://localhost:8082"` // 仓库归档、改名和迁移时通知的项目服务地址，为空时不通知
+	ProjectWebhookSecret string `mapstructure:"project_webhook_secret"`                              // 与项目服务 WEBHOOK_SECRET 一致，用于签名事件
+	// 引用审计设置
+	RefRetention time.Duration `mapstructure:"ref_retention" default:"720h"` // 删除或强制推送丢弃的提交保留时长，期间可恢复分支，0表示不保留
+}
+
+// RegistryConfig 镜像仓库服务配置
+type RegistryConfig struct {
+	Service       string        `mapstructure:"service" default:"registry"` // 令牌受众，与认证质询中的service一致
+	Realm         string        `mapstructure:"realm"`                      // IAM令牌端点地址
+	TokenTTL      time.Duration `mapstructure:"token_ttl" default:"5m"`
+	StoragePath   string        `mapstructure:"storage_path"`             // 为空时使用 storage.local.base_path/registry
+	GCInterval    time.Duration `mapstructure:"gc_interval" default:"6h"` // 0表示禁用自动回收
+	GCGracePeriod time.Duration `mapstructure:"gc_grace_period" default:"24h"`
+	UploadTimeout time.Duration `mapstructure:"upload_timeout" default:"24h"`
 }
 
 // SchedulerConfig 调度器配置
@@ -347,6 +379,9 @@ type ExecutorConfig struct {
 	MaxConcurrentJobs int           `mapstructure:"max_concurrent_jobs" default:"10"`
 	DefaultTimeout    time.Duration `mapstructure:"default_timeout" default:"30m"`
 	EnableAutoCleanup bool          `mapstructure:"enable_auto_cleanup" default:"true"`
+	DefaultRegistry   string        `mapstructure:"default_registry"`                         // docker-build步骤默认推送的镜像仓库
+	SecretPathPrefix  string        `mapstructure:"secret_path_prefix" default:"secret/cicd"` // 配置Vault时作业密钥在Vault中的路径前缀
+	GitGatewayURL     string        `mapstructure:"git_gateway_url"`                          // checkout步骤下载源码归档的Git网关地址
 }
 
 // ToStorageConfig 转换为存储配置
@@ -134,6 +134,39 @@ func (cl *ConfigLoader) setDefaults() {
 
 	// 安全默认值
 	viper.SetDefault("security.max_request_size", "10MB")
+
+	// 镜像仓库默认值
+	viper.SetDefault("registry.service", "registry")
+	viper.SetDefault("registry.realm", "http://localhost:8081/api/v1/auth/registry/token")
+	viper.SetDefault("registry.token_ttl", "5m")
+	viper.SetDefault("registry.storage_path", "")
+	viper.SetDefault("registry.gc_interval", "6h")
+	viper.SetDefault("registry.gc_grace_period", "24h")
+	viper.SetDefault("registry.upload_timeout", "24h")
+
+	// Git LFS默认值
+	viper.SetDefault("git.lfs_storage_path", "")
+	viper.SetDefault("git.lfs_action_expiry", "1h")
+	viper.SetDefault("git.lfs_gc_interval", "24h")
+	viper.SetDefault("git.lfs_gc_grace_period", "24h")
+
+	// 推送策略默认值
+	viper.SetDefault("git.hook_base_url", "http://localhost:8084")
+
+	// 代码所有者默认值
+	viper.SetDefault("git.team_service_url", "http://localhost:8086")
+
+	// 项目服务通知默认值
+	viper.SetDefault("git.project_service_url", "http://localhost:8082")
+
+	// 引用审计默认值
+	viper.SetDefault("git.ref_retention", "720h")
+
+	// 仓库维护默认值
+	viper.SetDefault("git.maintenance_interval", "5m")
+	viper.SetDefault("git.maintenance_push_threshold", 50)
+	viper.SetDefault("git.maintenance_max_age", "24h")
+	viper.SetDefault("git.maintenance_alert_webhook", "")
 }
 
 // loadConfigFile 加载配置文件
@@ -186,6 +219,7 @@ func (cl *ConfigLoader) bindEnvironmentVariables() {
 		"storage.s3.secret_access_key": {"AWS_SECRET_ACCESS_KEY", "S3_SECRET_KEY"},
 		"storage.s3.region":            {"AWS_REGION", "S3_REGION"},
 		"storage.s3.bucket":            {"S3_BUCKET"},
+		"git.hook_secret":              {"GIT_HOOK_SECRET"},
 	}
 
 	for configKey, envVars := range envBindings {
@@ -335,6 +335,18 @@ func Timeout(timeout time.Duration) gin.HandlerFunc {
 	}
 }
 
+// TimeoutExcept 超时中间件，skip 返回 true 的请求（如流式下载、大文件上传）不设置超时
+func TimeoutExcept(timeout time.Duration, skip func(c *gin.Context) bool) gin.HandlerFunc {
+	withTimeout := Timeout(timeout)
+	return func(c *gin.Context) {
+		if skip(c) {
+			c.Next()
+			return
+		}
+		withTimeout(c)
+	}
+}
+
 // HealthCheck 健康检查中间件
 func HealthCheck() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// SigningKey 用户上传的提交签名公钥（GPG或SSH），git网关用于校验提交和标签签名
+type SigningKey struct {
+	ID          uuid.UUID      `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"id"`
+	TenantID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"tenant_id"`
+	UserID      uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name        string         `gorm:"size:255;not null" json:"name"`
+	KeyType     string         `gorm:"size:10;not null" json:"key_type"`     // gpg, ssh
+	Fingerprint string         `gorm:"size:100;not null" json:"fingerprint"` // 同类型内唯一
+	KeyIDs      datatypes.JSON `gorm:"type:jsonb;default:'[]'" json:"key_ids"`
+	Emails      datatypes.JSON `gorm:"type:jsonb;default:'[]'" json:"emails"` // GPG用户ID中的邮箱
+	PublicKey   string         `gorm:"type:text;not null" json:"public_key"`
+	CreatedAt   time.Time      `json:"created_at"`
+
+	// Relations
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName 指定表名
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
@@ -0,0 +1,81 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TenantStorage 租户存储用量。
+// Git仓库（含LFS对象）和镜像仓库共用租户配额 tenant_configs.max_storage，
+// 各服务做配额校验时都以这里的统计为准，避免只计算自身存储而绕过配额
+type TenantStorage struct {
+	TenantID      uuid.UUID `json:"tenant_id"`
+	GitBytes      int64     `json:"git_bytes"`      // 仓库大小之和，仓库大小已包含LFS对象
+	RegistryBytes int64     `json:"registry_bytes"` // 已提交的镜像数据块（租户内去重）和清单
+	PendingBytes  int64     `json:"pending_bytes"`  // 进行中的镜像上传
+	QuotaBytes    int64     `json:"quota_bytes"`    // 0表示不限制
+}
+
+// UsedBytes 已用字节数，包含进行中的上传
+func (s *TenantStorage) UsedBytes() int64 {
+	return s.GitBytes + s.RegistryBytes + s.PendingBytes
+}
+
+// Exceeds 判断再写入size字节是否超出配额
+func (s *TenantStorage) Exceeds(size int64) bool {
+	return s.QuotaBytes > 0 && s.UsedBytes()+size > s.QuotaBytes
+}
+
+// GetTenantStorage 统计租户在各服务中的存储用量和配额
+func GetTenantStorage(ctx context.Context, db *gorm.DB, tenantID uuid.UUID) (*TenantStorage, error) {
+	usage := &TenantStorage{TenantID: tenantID}
+	db = db.WithContext(ctx)
+
+	err := db.Raw(`SELECT COALESCE(SUM(r.size), 0) FROM repositories r
+		JOIN projects p ON p.id = r.project_id
+		WHERE p.tenant_id = ? AND r.deleted_at IS NULL`, tenantID).
+		Scan(&usage.GitBytes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get repository storage usage: %w", err)
+	}
+
+	var blobBytes, manifestBytes int64
+	err = db.Raw(`SELECT COALESCE(SUM(b.size), 0) FROM registry_blobs b
+		WHERE b.digest IN (
+			SELECT l.digest FROM registry_repository_blobs l
+			JOIN registry_repositories repo ON repo.id = l.repository_id
+			WHERE repo.tenant_id = ?
+		)`, tenantID).Scan(&blobBytes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry blob usage: %w", err)
+	}
+	err = db.Raw(`SELECT COALESCE(SUM(m.size), 0) FROM registry_manifests m
+		JOIN registry_repositories repo ON repo.id = m.repository_id
+		WHERE repo.tenant_id = ?`, tenantID).Scan(&manifestBytes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry manifest usage: %w", err)
+	}
+	usage.RegistryBytes = blobBytes + manifestBytes
+
+	err = db.Raw(`SELECT COALESCE(SUM("offset"), 0) FROM registry_uploads
+		WHERE tenant_id = ?`, tenantID).Scan(&usage.PendingBytes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending upload usage: %w", err)
+	}
+
+	// TenantConfig.MaxStorage 单位为MB
+	var maxStorage int64
+	err = db.Table("tenant_configs").
+		Select("max_storage").
+		Where("tenant_id = ? AND deleted_at IS NULL", tenantID).
+		Scan(&maxStorage).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant storage quota: %w", err)
+	}
+	usage.QuotaBytes = maxStorage * 1024 * 1024
+
+	return usage, nil
+}
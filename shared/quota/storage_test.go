@@ -0,0 +1,23 @@
+package quota
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantStorageExceeds(t *testing.T) {
+	usage := &TenantStorage{TenantID: uuid.New(), GitBytes: 50, RegistryBytes: 30, PendingBytes: 10, QuotaBytes: 100}
+	assert.Equal(t, int64(90), usage.UsedBytes())
+	assert.False(t, usage.Exceeds(10))
+	assert.True(t, usage.Exceeds(11))
+
+	// Git仓库的用量同样占用镜像仓库的配额
+	usage.GitBytes = 60
+	assert.True(t, usage.Exceeds(10))
+
+	// 未配置配额时不限制
+	usage.QuotaBytes = 0
+	assert.False(t, usage.Exceeds(1<<40))
+}
@@ -59,7 +59,7 @@ func NewMockGitService() *MockGitService {
 }
 
 // 实现GitService接口的所有方法
-func (m *MockGitService) CreateRepository(ctx context.Context, req *models.CreateRepositoryRequest, userID uuid.UUID) (*models.Repository, error) {
+func (m *MockGitService) CreateRepository(ctx context.Context, req *models.CreateRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error) {
 	projectID, _ := uuid.Parse(req.ProjectID)
 	repo := &models.Repository{
 		ID:            uuid.New(),
@@ -110,7 +110,7 @@ func (m *MockGitService) ListRepositories(ctx context.Context, projectID *uuid.U
 	}, nil
 }
 
-func (m *MockGitService) UpdateRepository(ctx context.Context, id uuid.UUID, req *models.UpdateRepositoryRequest) (*models.Repository, error) {
+func (m *MockGitService) UpdateRepository(ctx context.Context, id uuid.UUID, req *models.UpdateRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error) {
 	repo, exists := m.repositories[id]
 	if !exists {
 		return nil, fmt.Errorf("repository not found")
@@ -141,6 +141,181 @@ func (m *MockGitService) DeleteRepository(ctx context.Context, id uuid.UUID) err
 	return nil
 }
 
+func (m *MockGitService) ForkRepository(ctx context.Context, sourceID uuid.UUID, req *models.ForkRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error) {
+	source, exists := m.repositories[sourceID]
+	if !exists {
+		return nil, models.ErrRepositoryNotFound
+	}
+	name := source.Name
+	if req.Name != nil {
+		name = *req.Name
+	}
+	fork := &models.Repository{
+		ID:            uuid.New(),
+		ProjectID:     uuid.MustParse(req.ProjectID),
+		Name:          name,
+		Visibility:    source.Visibility,
+		Status:        models.RepositoryStatusActive,
+		DefaultBranch: source.DefaultBranch,
+		ForkedFromID:  &source.ID,
+		ObjectsShared: true,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	m.repositories[fork.ID] = fork
+	source.ForkCount++
+	return fork, nil
+}
+
+func (m *MockGitService) ListForks(ctx context.Context, repositoryID uuid.UUID, page, pageSize int) (*models.RepositoryListResponse, error) {
+	var forks []models.Repository
+	for _, repo := range m.repositories {
+		if repo.ForkedFromID != nil && *repo.ForkedFromID == repositoryID {
+			forks = append(forks, *repo)
+		}
+	}
+	return &models.RepositoryListResponse{
+		Repositories: forks,
+		Total:        int64(len(forks)),
+		Page:         page,
+		PageSize:     pageSize,
+	}, nil
+}
+
+func (m *MockGitService) SyncFork(ctx context.Context, forkID uuid.UUID, req *models.SyncForkRequest, access models.RepositoryAccess) (*models.ForkSyncResult, error) {
+	fork, exists := m.repositories[forkID]
+	if !exists {
+		return nil, models.ErrRepositoryNotFound
+	}
+	if fork.ForkedFromID == nil {
+		return nil, models.ErrNotAFork
+	}
+	return &models.ForkSyncResult{
+		Branch:         fork.DefaultBranch,
+		UpstreamBranch: fork.DefaultBranch,
+		Status:         models.ForkSyncStatusUpToDate,
+	}, nil
+}
+
+func (m *MockGitService) SetRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID, req *models.SetRepositoryTemplateRequest, access models.RepositoryAccess) (*models.RepositoryTemplate, error) {
+	repo, exists := m.repositories[repositoryID]
+	if !exists {
+		return nil, models.ErrRepositoryNotFound
+	}
+	repo.IsTemplate = true
+	return &models.RepositoryTemplate{
+		RepositoryID:       repositoryID,
+		Variables:          req.Variables,
+		IncludeAllBranches: req.IncludeAllBranches,
+		UpdatedBy:          access.UserID,
+		UpdatedAt:          time.Now(),
+	}, nil
+}
+
+func (m *MockGitService) GetRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryTemplate, error) {
+	repo, exists := m.repositories[repositoryID]
+	if !exists {
+		return nil, models.ErrRepositoryNotFound
+	}
+	if !repo.IsTemplate {
+		return nil, models.ErrNotATemplate
+	}
+	return &models.RepositoryTemplate{RepositoryID: repositoryID}, nil
+}
+
+func (m *MockGitService) DeleteRepositoryTemplate(ctx context.Context, repositoryID uuid.UUID, access models.RepositoryAccess) error {
+	if _, err := m.GetRepositoryTemplate(ctx, repositoryID); err != nil {
+		return err
+	}
+	m.repositories[repositoryID].IsTemplate = false
+	return nil
+}
+
+func (m *MockGitService) TransferRepository(ctx context.Context, id uuid.UUID, req *models.TransferRepositoryRequest, access models.RepositoryAccess) (*models.Repository, error) {
+	repo, exists := m.repositories[id]
+	if !exists {
+		return nil, models.ErrRepositoryNotFound
+	}
+	repo.ProjectID = uuid.MustParse(req.ProjectID)
+	if req.Name != nil {
+		repo.Name = *req.Name
+	}
+	repo.UpdatedAt = time.Now()
+	return repo, nil
+}
+
+func (m *MockGitService) RenameRepository(ctx context.Context, id uuid.UUID, name string, access models.RepositoryAccess) (*models.Repository, error) {
+	repo, exists := m.repositories[id]
+	if !exists {
+		return nil, models.ErrRepositoryNotFound
+	}
+	repo.Name = name
+	repo.UpdatedAt = time.Now()
+	return repo, nil
+}
+
+func (m *MockGitService) ArchiveRepository(ctx context.Context, id uuid.UUID, access models.RepositoryAccess) (*models.Repository, error) {
+	repo, exists := m.repositories[id]
+	if !exists {
+		return nil, models.ErrRepositoryNotFound
+	}
+	repo.Status = models.RepositoryStatusArchived
+	return repo, nil
+}
+
+func (m *MockGitService) UnarchiveRepository(ctx context.Context, id uuid.UUID, access models.RepositoryAccess) (*models.Repository, error) {
+	repo, exists := m.repositories[id]
+	if !exists {
+		return nil, models.ErrRepositoryNotFound
+	}
+	repo.Status = models.RepositoryStatusActive
+	return repo, nil
+}
+
+func (m *MockGitService) ResolveRepository(ctx context.Context, projectID uuid.UUID, name string, access models.RepositoryAccess) (*models.RepositoryResolution, error) {
+	for _, repo := range m.repositories {
+		if repo.ProjectID == projectID && repo.Name == name {
+			return &models.RepositoryResolution{Repository: repo}, nil
+		}
+	}
+	return nil, models.ErrRepositoryNotFound
+}
+
+func (m *MockGitService) UploadPack(ctx context.Context, repo *models.Repository, advertise bool, protocol string, input io.Reader, output io.Writer) error {
+	return fmt.Errorf("upload-pack not supported by mock")
+}
+
+func (m *MockGitService) CreatePullRequest(ctx context.Context, repositoryID uuid.UUID, req *models.CreatePullRequestRequest, access models.RepositoryAccess) (*models.PullRequest, error) {
+	return &models.PullRequest{
+		ID:           uuid.New(),
+		RepositoryID: repositoryID,
+		Number:       1,
+		Title:        req.Title,
+		Status:       models.PullRequestStatusOpen,
+		SourceBranch: req.SourceBranch,
+		TargetBranch: req.TargetBranch,
+		AuthorID:     access.UserID,
+		AuthorName:   req.AuthorName,
+		AuthorEmail:  req.AuthorEmail,
+	}, nil
+}
+
+func (m *MockGitService) ListPullRequests(ctx context.Context, repositoryID uuid.UUID, status *models.PullRequestStatus, page, pageSize int) (*models.PullRequestListResponse, error) {
+	return &models.PullRequestListResponse{
+		PullRequests: []models.PullRequest{},
+		Page:         page,
+		PageSize:     pageSize,
+	}, nil
+}
+
+func (m *MockGitService) ListProjectPullRequests(ctx context.Context, projectID uuid.UUID, status *models.PullRequestStatus, reference string, page, pageSize int) (*models.PullRequestListResponse, error) {
+	return &models.PullRequestListResponse{
+		PullRequests: []models.PullRequest{},
+		Page:         page,
+		PageSize:     pageSize,
+	}, nil
+}
+
 func (m *MockGitService) CreateBranch(ctx context.Context, repositoryID uuid.UUID, req *models.CreateBranchRequest) (*models.Branch, error) {
 	branch := &models.Branch{
 		ID:           uuid.New(),
@@ -247,7 +422,7 @@ func (m *MockGitService) ListCommits(ctx context.Context, repositoryID uuid.UUID
 	}, nil
 }
 
-func (m *MockGitService) GetCommitDiff(ctx context.Context, repositoryID uuid.UUID, sha string) (*models.GitDiff, error) {
+func (m *MockGitService) GetCommitDiff(ctx context.Context, repositoryID uuid.UUID, sha string, opts *models.DiffOptions) (*models.GitDiff, error) {
 	return &models.GitDiff{
 		FromSHA: sha + "^",
 		ToSHA:   sha,
@@ -255,7 +430,7 @@ func (m *MockGitService) GetCommitDiff(ctx context.Context, repositoryID uuid.UU
 	}, nil
 }
 
-func (m *MockGitService) CompareBranches(ctx context.Context, repositoryID uuid.UUID, base, head string) (*models.GitDiff, error) {
+func (m *MockGitService) CompareBranches(ctx context.Context, repositoryID uuid.UUID, base, head string, opts *models.DiffOptions) (*models.GitDiff, error) {
 	return &models.GitDiff{
 		FromSHA: base,
 		ToSHA:   head,
@@ -332,6 +507,40 @@ func (m *MockGitService) GetDirectoryContent(ctx context.Context, repositoryID u
 	}, nil
 }
 
+func (m *MockGitService) BlameFile(ctx context.Context, repositoryID uuid.UUID, req *models.BlameRequest) (*models.FileBlame, error) {
+	return &models.FileBlame{
+		Path:    req.Path,
+		Ref:     req.Ref,
+		Ranges:  []models.BlameRange{},
+		Commits: map[string]models.BlameCommit{},
+	}, nil
+}
+
+func (m *MockGitService) GetFileHistory(ctx context.Context, repositoryID uuid.UUID, ref, filePath string, page, pageSize int) (*models.FileHistoryResponse, error) {
+	return &models.FileHistoryResponse{
+		Path:     filePath,
+		Ref:      ref,
+		Commits:  []models.FileCommit{},
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+func (m *MockGitService) ResolveArchive(ctx context.Context, repositoryID uuid.UUID, req *models.ArchiveRequest) (*models.RepositoryArchive, error) {
+	return &models.RepositoryArchive{
+		Ref:       req.Ref,
+		CommitSHA: "abc123def456",
+		Format:    req.Format,
+		Prefix:    "mock-repo/",
+		Filename:  "mock-repo." + string(req.Format),
+	}, nil
+}
+
+func (m *MockGitService) StreamArchive(ctx context.Context, archive *models.RepositoryArchive, w io.Writer) error {
+	_, err := w.Write([]byte("mock archive content"))
+	return err
+}
+
 func (m *MockGitService) GetRepositoryStats(ctx context.Context, repositoryID uuid.UUID) (*models.RepositoryStats, error) {
 	return &models.RepositoryStats{
 		Size:        1024,
@@ -62,7 +62,7 @@ func (suite *AgileServiceTestSuite) SetupSuite() {
 	suite.Require().NoError(err)
 
 	suite.db = db
-	suite.agileService = service.NewAgileService(db, zap.NewNop())
+	suite.agileService = service.NewAgileService(db, nil, nil, zap.NewNop())
 	suite.ctx = context.Background()
 
 	// 初始化测试ID